@@ -1,21 +1,68 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/alexpitcher/LanAudit/internal/api"
+	"github.com/alexpitcher/LanAudit/internal/capture"
+	"github.com/alexpitcher/LanAudit/internal/completion"
+	"github.com/alexpitcher/LanAudit/internal/console"
+	"github.com/alexpitcher/LanAudit/internal/console/fingerprint"
+	"github.com/alexpitcher/LanAudit/internal/logging"
+	netpkg "github.com/alexpitcher/LanAudit/internal/net"
+	"github.com/alexpitcher/LanAudit/internal/scan"
+	"github.com/alexpitcher/LanAudit/internal/store"
 	"github.com/alexpitcher/LanAudit/internal/tui"
 )
 
 var (
 	headless = flag.Bool("headless", false, "Run in headless mode (JSON output)")
 	iface    = flag.String("iface", "", "Network interface to use")
+	autoIf   = flag.Bool("auto-iface", false, "Pick the interface most likely in use when --iface is not given, skipping the TUI's interface picker")
+	autoPfx  = flag.String("auto-iface-filter", "", "Only let --auto-iface pick interfaces whose name starts with this prefix (e.g. en)")
 	snap     = flag.Bool("snap", false, "Create snapshot and exit")
+	snapFmt  = flag.String("export-format", "json", "Format --snap writes the snapshot in (json, yaml or csv)")
+	prune    = flag.Bool("prune", false, "Delete snapshots outside the configured retention limits and exit")
+	profile  = flag.String("profile", "", "Use the named config profile from ~/.lanaudit/profiles instead of config.json")
+	theme    = flag.String("theme", "", "TUI color theme (dark, light or solarized), overriding the config")
+	logLevel = flag.String("log-level", "info", "Least severe messages written to log.txt (debug, info, warn or error); debug also copies them to stderr")
+	logFmt   = flag.String("log-format", "text", "Format of log.txt lines (text or json)")
 	version  = flag.Bool("version", false, "Print version and exit")
+	complete = flag.String("completion", "", "Print a completion script for this shell (bash, zsh, fish or powershell) and exit")
+	listIfs  = flag.Bool("list-interfaces", false, "Print the selectable interface names, one per line, and exit")
+	pretty   = flag.Bool("pretty", false, "Indent headless JSON output")
+	format   = flag.String("format", "json", "Headless output format (json, yaml or table)")
+	output   = flag.String("output", "", "Write headless result to file instead of stdout")
+	history  = flag.Int("history", 0, "Print the last N stored diagnostic results (headless mode)")
+	diff     = flag.Bool("diff", false, "Print the changes between the last two gateway audits and snapshots (headless mode)")
+	stHist   = flag.Int("speedtest-history", 0, "Print the last N stored speedtest results (headless mode)")
+	pcapFile = flag.String("import-pcap", "", "Open a saved pcap/pcapng file in the capture view")
+	bgDir    = flag.String("background-capture", "", "Capture to rotating pcap files in this directory while the TUI runs (requires --iface)")
+	bgFileMB = flag.Int("background-file-mb", capture.DefaultBackgroundFileBytes/(1024*1024), "Rotate background capture files at this size in MB")
+	bgFiles  = flag.Int("background-files", capture.DefaultBackgroundMaxFiles, "Number of background capture files to keep")
+	replay   = flag.String("replay", "", "Replay a serial console transcript to stdout and exit")
+	speed    = flag.Float64("replay-speed", 1.0, "Replay speed multiplier (0 = no delay)")
+	fpDB     = flag.String("fingerprint-db", "", "Load extra console fingerprint signatures from this JSON file")
+	scanRate = flag.Int("scan-rate", scan.DefaultScanRate, "Maximum hosts per second a gateway audit starts scanning")
+	apiAddr  = flag.String("api", "", "Serve the HTTP API on this address (e.g. :8080) alongside the TUI, or on its own with --headless")
+	watch    = flag.Duration("watch", 0, "Repeat headless diagnostics at this interval (e.g. 30s), writing one JSON object per run; stop with Ctrl-C")
+	timeout  = flag.Duration("timeout", 10*time.Second, "Time limit for a headless run; when given, also replaces the configured diagnostics timeout for this session (100ms to 5m)")
 )
 
+// exitTimeout is the exit status when --timeout passes before a headless run
+// finishes
+const exitTimeout = 3
+
 const Version = "0.1.0-mvp"
 
 func main() {
@@ -26,32 +73,312 @@ func main() {
 		os.Exit(0)
 	}
 
-	ctx := context.Background()
+	if *complete != "" {
+		if err := completion.Generate(os.Stdout, *complete, flag.CommandLine); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	if *headless {
-		if *iface == "" {
-			fmt.Fprintf(os.Stderr, "Error: --iface required in headless mode\n")
+	if *listIfs {
+		ifaces, err := netpkg.ListUserInterfaces()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		for _, i := range ifaces {
+			fmt.Println(i.Name)
+		}
+		return
+	}
+
+	if *profile != "" {
+		if err := store.SetActiveProfile(*profile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if path, err := store.GetConfigPath(); err == nil {
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				fmt.Fprintf(os.Stderr, "Profile %q not found, starting from defaults; settings changes will create it\n", *profile)
+			}
+		}
+	}
+
+	if err := configureLogging(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *theme != "" {
+		if _, ok := store.ThemePresets[*theme]; !ok {
+			fmt.Fprintf(os.Stderr, "Error: unknown theme %q; use one of %s\n", *theme, strings.Join(store.ThemePresetNames(), ", "))
+			os.Exit(1)
+		}
+		tui.ThemePreset = *theme
+	}
+
+	if *fpDB != "" {
+		if err := fingerprint.LoadSignaturesFromFile(*fpDB); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
+	}
+
+	if *scanRate <= 0 {
+		fmt.Fprintf(os.Stderr, "Error: --scan-rate must be positive\n")
+		os.Exit(1)
+	}
+	scan.DefaultScanRate = *scanRate
+
+	if err := store.ValidateTimeout(*timeout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --%v\n", err)
+		os.Exit(1)
+	}
+	if flagGiven("timeout") {
+		store.SetTimeoutOverride(*timeout)
+	}
 
-		if err := tui.RunHeadless(ctx, *iface); err != nil {
+	if *replay != "" {
+		if err := console.ReplayTranscript(*replay, *speed, os.Stdout); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
 		return
 	}
 
-	if *iface != "" {
-		if err := tui.RunWithInterface(*iface); err != nil {
+	if *prune {
+		config, err := store.LoadConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if !config.RetentionEnabled() {
+			fmt.Fprintf(os.Stderr, "Error: no retention limit set; add snapshot_max_age or snapshot_max_count to the config\n")
+			os.Exit(1)
+		}
+		deleted, err := store.PruneSnapshots()
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
+		fmt.Printf("Pruned %d snapshots\n", deleted)
 		return
 	}
 
-	// Default: run TUI
-	if err := tui.Run(); err != nil {
+	autoPicked := false
+	if *autoIf && *iface == "" {
+		name, err := netpkg.BestInterfaceWithPrefix(*autoPfx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --auto-iface: %v\n", err)
+			os.Exit(1)
+		}
+		*iface = name
+		autoPicked = true
+		logging.Infof("--auto-iface picked %s", name)
+	}
+
+	ctx := context.Background()
+
+	if *apiAddr != "" {
+		token := ""
+		if config, err := store.LoadConfig(); err == nil {
+			token = config.APIToken
+		}
+		if *headless {
+			fmt.Fprintf(os.Stderr, "Serving the API on %s\n", *apiAddr)
+			if err := api.ListenAndServe(*apiAddr, token); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		// The TUI owns the terminal, so a failure goes to the log
+		go func() {
+			if err := api.ListenAndServe(*apiAddr, token); err != nil {
+				logging.Errorf("%v", err)
+			}
+		}()
+	}
+
+	if *headless || *snap {
+		// Speedtest history isn't tied to an interface
+		if *iface == "" && (*snap || *stHist == 0) {
+			fmt.Fprintf(os.Stderr, "Error: --iface or --auto-iface required in headless mode\n")
+			os.Exit(1)
+		}
+
+		opts := tui.HeadlessOptions{Format: *format, Pretty: *pretty, Timeout: *timeout}
+		if *watch != 0 {
+			os.Exit(runWatch(ctx, opts))
+		}
+		run := func(w io.Writer) error {
+			if *snap {
+				path, err := tui.RunHeadlessSnapshot(ctx, w, *iface, *snapFmt)
+				if path != "" {
+					fmt.Fprintf(os.Stderr, "Snapshot saved to %s\n", path)
+				}
+				return err
+			}
+			if *stHist > 0 {
+				return tui.RunHeadlessSpeedtestHistory(w, *stHist, opts)
+			}
+			if *history > 0 {
+				return tui.RunHeadlessHistory(w, *iface, *history, opts)
+			}
+			if *diff {
+				return tui.RunHeadlessDiff(w, *iface, opts)
+			}
+			return tui.RunHeadless(ctx, w, *iface, opts)
+		}
+
+		if *output == "" {
+			if err := run(os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(headlessExitCode(err))
+			}
+			return
+		}
+
+		if *snap {
+			fmt.Fprintf(os.Stderr, "Creating snapshot of %s...\n", *iface)
+		} else if *history == 0 && *stHist == 0 && !*diff {
+			fmt.Fprintf(os.Stderr, "Running diagnostics on %s...\n", *iface)
+		}
+		var buf bytes.Buffer
+		runErr := run(&buf)
+		// A run that timed out still wrote its partial report
+		if runErr != nil && headlessExitCode(runErr) != exitTimeout {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", runErr)
+			os.Exit(1)
+		}
+		if err := os.WriteFile(*output, buf.Bytes(), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to write output: %v\n", err)
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "Result written to %s\n", *output)
+		if runErr != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", runErr)
+			os.Exit(exitTimeout)
+		}
+		return
+	}
+
+	if *bgDir != "" {
+		if *iface == "" {
+			fmt.Fprintf(os.Stderr, "Error: --iface required for background capture\n")
+			os.Exit(1)
+		}
+		if _, err := capture.StartBackground(*iface, "", *bgDir, int64(*bgFileMB)*1024*1024, *bgFiles); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	err := runTUI(autoPicked)
+
+	// Complete the last background file before exiting
+	if *bgDir != "" {
+		if bgErr := capture.StopBackground(); bgErr != nil {
+			fmt.Fprintf(os.Stderr, "Background capture error: %v\n", bgErr)
+		}
+	}
+
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+// runWatch runs --watch until SIGINT or SIGTERM, letting the current run
+// finish, and returns the exit status
+func runWatch(ctx context.Context, opts tui.HeadlessOptions) int {
+	if *watch < 0 || *snap || *history > 0 || *stHist > 0 || *diff {
+		fmt.Fprintf(os.Stderr, "Error: --watch takes a positive interval and only repeats diagnostics\n")
+		return 1
+	}
+	w := io.Writer(os.Stdout)
+	if *output != "" {
+		f, err := os.OpenFile(*output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to open output: %v\n", err)
+			return 2
+		}
+		defer f.Close()
+		w = f
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := tui.RunHeadlessWatch(ctx, w, *iface, *watch, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// headlessExitCode returns the exit status for a failed headless run
+func headlessExitCode(err error) int {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return exitTimeout
+	}
+	return 1
+}
+
+// flagGiven reports whether the named flag was set on the command line
+func flagGiven(name string) bool {
+	given := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			given = true
+		}
+	})
+	return given
+}
+
+// configureLogging applies the log level, format, rotation limits and
+// syslog forwarding. The level is --log-level when given, else the config's
+// log_level, else the flag default; the format is chosen the same way.
+func configureLogging() error {
+	explicit := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	level, format := *logLevel, *logFmt
+	// Invalid settings are reported by the config's validation warnings
+	if config, err := store.LoadConfig(); err == nil {
+		if _, err := logging.ParseLevel(config.LogLevel); err == nil && !explicit["log-level"] && config.LogLevel != "" {
+			level = config.LogLevel
+		}
+		if logging.ValidFormat(config.LogFormat) && !explicit["log-format"] {
+			format = config.LogFormat
+		}
+		logging.SetRotation(config.LogMaxBytes, config.LogMaxFiles)
+		if config.Syslog.Enabled {
+			// An unreachable syslog server should not stop the audit
+			if err := logging.ConfigureSyslog(config.Syslog.Address, config.Syslog.Facility); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v; logging to log.txt only\n", err)
+			}
+		}
+	}
+	if err := logging.SetFormat(format); err != nil {
+		return err
+	}
+	return logging.SetLevel(level)
+}
+
+// runTUI starts the interactive interface selected by the flags
+func runTUI(autoPicked bool) error {
+	if *pcapFile != "" {
+		return tui.RunWithCapture(*pcapFile, *iface)
+	}
+	if autoPicked {
+		return tui.RunWithAutoInterface(*iface)
+	}
+	if *iface != "" {
+		return tui.RunWithInterface(*iface)
+	}
+	// Default: run TUI
+	return tui.Run()
+}
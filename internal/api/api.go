@@ -0,0 +1,203 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alexpitcher/LanAudit/internal/capture"
+	"github.com/alexpitcher/LanAudit/internal/diagnostics"
+	"github.com/alexpitcher/LanAudit/internal/logging"
+	netpkg "github.com/alexpitcher/LanAudit/internal/net"
+	"github.com/alexpitcher/LanAudit/internal/store"
+	"github.com/alexpitcher/LanAudit/internal/webhook"
+)
+
+// MaxCapturePackets is the packet limit of captures started over the API,
+// the same as the TUI's
+const MaxCapturePackets = 1000
+
+var log = logging.WithPkg("api")
+
+// The operations behind the endpoints; replaced in tests
+var (
+	listInterfaces = netpkg.ListInterfaces
+	runDiagnostics = diagnose
+	startCapture   = func(iface, filter string) error {
+		_, err := capture.Start(iface, filter, MaxCapturePackets, capture.StartOptions{})
+		return err
+	}
+	stopCapture     = capture.StopCurrentSession
+	capturedPackets = func() ([]capture.PacketSummary, error) {
+		session := capture.GetCurrentSession()
+		if session == nil {
+			return nil, errors.New("no capture session")
+		}
+		return session.GetPackets(), nil
+	}
+)
+
+// NewHandler returns the API's routes. Endpoints that send traffic, save
+// results or return captured packets require "Authorization: Bearer <token>"
+// and are refused when token is empty.
+func NewHandler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/interfaces", get(handleInterfaces))
+	mux.HandleFunc("/api/diagnose", get(authorized(token, handleDiagnose)))
+	mux.HandleFunc("/api/capture/start", get(authorized(token, handleCaptureStart)))
+	mux.HandleFunc("/api/capture/stop", get(authorized(token, handleCaptureStop)))
+	mux.HandleFunc("/api/capture/packets", get(authorized(token, handleCapturePackets)))
+	return mux
+}
+
+// ListenAndServe serves the API on addr, such as ":8080", until it fails
+func ListenAndServe(addr, token string) error {
+	if token == "" {
+		log.Warnf("api_token is not set; only /api/interfaces is enabled")
+	}
+	log.Infof("API listening on %s", addr)
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           NewHandler(token),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if err := server.ListenAndServe(); err != nil {
+		return fmt.Errorf("failed to serve API on %s: %w", addr, err)
+	}
+	return nil
+}
+
+// get rejects requests other than GET
+func get(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			writeError(w, http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// authorized requires the bearer token
+func authorized(token string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			writeError(w, http.StatusForbidden, "set api_token in the config to enable this endpoint")
+			return
+		}
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+			return
+		}
+		h(w, r)
+	}
+}
+
+func handleInterfaces(w http.ResponseWriter, r *http.Request) {
+	ifaces, err := listInterfaces()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list interfaces: %v", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ifaces)
+}
+
+func handleDiagnose(w http.ResponseWriter, r *http.Request) {
+	iface := r.URL.Query().Get("iface")
+	if iface == "" {
+		writeError(w, http.StatusBadRequest, "iface is required")
+		return
+	}
+	res, err := runDiagnostics(r.Context(), iface)
+	if res == nil {
+		writeError(w, http.StatusInternalServerError, "diagnostics failed: %v", err)
+		return
+	}
+	// A result with a failed check is still worth returning
+	if err != nil {
+		log.Warnf("diagnostics of %s: %v", iface, err)
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func handleCaptureStart(w http.ResponseWriter, r *http.Request) {
+	iface := r.URL.Query().Get("iface")
+	if iface == "" {
+		writeError(w, http.StatusBadRequest, "iface is required")
+		return
+	}
+	filter := r.URL.Query().Get("filter")
+	if err := startCapture(iface, filter); err != nil {
+		writeError(w, http.StatusConflict, "failed to start capture: %v", err)
+		return
+	}
+	log.Infof("capture started on %s (filter %q)", iface, filter)
+	writeJSON(w, http.StatusOK, map[string]string{"status": "capturing", "iface": iface, "filter": filter})
+}
+
+func handleCaptureStop(w http.ResponseWriter, r *http.Request) {
+	if err := stopCapture(); err != nil {
+		writeError(w, http.StatusConflict, "failed to stop capture: %v", err)
+		return
+	}
+	log.Infof("capture stopped")
+	writeJSON(w, http.StatusOK, map[string]string{"status": "stopped"})
+}
+
+func handleCapturePackets(w http.ResponseWriter, r *http.Request) {
+	packets, err := capturedPackets()
+	if err != nil {
+		writeError(w, http.StatusNotFound, "%v", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, packets)
+}
+
+// diagnose runs diagnostics on iface with the saved config, recording the
+// result in the diagnostic history as the TUI does
+func diagnose(ctx context.Context, iface string) (*diagnostics.Result, error) {
+	details, err := netpkg.GetInterfaceDetails(iface)
+	if err != nil {
+		return nil, err
+	}
+	config, err := store.LoadConfig()
+	if err != nil {
+		config = store.DefaultConfig()
+	}
+	timeout := config.DiagnosticsTimeoutDuration() + diagnostics.OptionalTimeout(config)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	res, err := diagnostics.Run(ctx, details, config)
+	if res != nil {
+		if err := store.SaveDiagnosticResult(iface, res); err != nil {
+			log.Warnf("diagnostics history save failed: %v", err)
+		}
+		go func() {
+			if _, err := webhook.NotifyIfFailed(config, res); err != nil {
+				log.Warnf("diagnostics webhook failed: %v", err)
+			}
+		}()
+	}
+	return res, err
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Warnf("failed to write response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, format string, args ...interface{}) {
+	writeJSON(w, status, map[string]string{"error": fmt.Sprintf(format, args...)})
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alexpitcher/LanAudit/internal/capture"
+	"github.com/alexpitcher/LanAudit/internal/diagnostics"
+	netpkg "github.com/alexpitcher/LanAudit/internal/net"
+)
+
+// stubOperations replaces the endpoints' operations for one test
+func stubOperations(t *testing.T) *[]string {
+	t.Helper()
+	origList, origDiag, origStart, origStop, origPackets := listInterfaces, runDiagnostics, startCapture, stopCapture, capturedPackets
+	t.Cleanup(func() {
+		listInterfaces, runDiagnostics, startCapture, stopCapture, capturedPackets = origList, origDiag, origStart, origStop, origPackets
+	})
+
+	var calls []string
+	listInterfaces = func() ([]netpkg.Iface, error) {
+		return []netpkg.Iface{{Name: "eth0", MTU: 1500}, {Name: "lo", MTU: 65536}}, nil
+	}
+	runDiagnostics = func(_ context.Context, iface string) (*diagnostics.Result, error) {
+		calls = append(calls, "diagnose "+iface)
+		if iface != "eth0" {
+			return nil, errors.New("no such interface")
+		}
+		return &diagnostics.Result{LinkUp: true, Gateway: "192.168.1.1"}, nil
+	}
+	startCapture = func(iface, filter string) error {
+		calls = append(calls, "start "+iface+" "+filter)
+		return nil
+	}
+	stopCapture = func() error {
+		calls = append(calls, "stop")
+		return nil
+	}
+	capturedPackets = func() ([]capture.PacketSummary, error) {
+		return []capture.PacketSummary{{Timestamp: time.Unix(0, 0).UTC(), SourceIP: "10.0.0.1", DestIP: "10.0.0.2", Protocol: "TCP", Length: 60}}, nil
+	}
+	return &calls
+}
+
+func request(t *testing.T, srv *httptest.Server, method, path, token string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestReadEndpoints(t *testing.T) {
+	stubOperations(t)
+	srv := httptest.NewServer(NewHandler("s3cret"))
+	defer srv.Close()
+
+	resp := request(t, srv, http.MethodGet, "/api/interfaces", "")
+	var ifaces []netpkg.Iface
+	if err := json.NewDecoder(resp.Body).Decode(&ifaces); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("interfaces: status %d, err %v", resp.StatusCode, err)
+	}
+	if len(ifaces) != 2 || ifaces[0].Name != "eth0" || ifaces[0].MTU != 1500 {
+		t.Errorf("interfaces = %+v", ifaces)
+	}
+
+	resp = request(t, srv, http.MethodGet, "/api/diagnose?iface=eth0", "s3cret")
+	var res diagnostics.Result
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("diagnose: status %d, err %v", resp.StatusCode, err)
+	}
+	if !res.LinkUp || res.Gateway != "192.168.1.1" {
+		t.Errorf("diagnose = %+v", res)
+	}
+
+	if resp := request(t, srv, http.MethodGet, "/api/diagnose", "s3cret"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("diagnose without iface: status %d, want 400", resp.StatusCode)
+	}
+	if resp := request(t, srv, http.MethodGet, "/api/diagnose?iface=wlan9", "s3cret"); resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("diagnose of a missing interface: status %d, want 500", resp.StatusCode)
+	}
+
+	resp = request(t, srv, http.MethodGet, "/api/capture/packets", "s3cret")
+	var packets []capture.PacketSummary
+	if err := json.NewDecoder(resp.Body).Decode(&packets); err != nil || len(packets) != 1 || packets[0].SourceIP != "10.0.0.1" {
+		t.Errorf("packets = %+v, err %v", packets, err)
+	}
+
+	if resp := request(t, srv, http.MethodPost, "/api/interfaces", ""); resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status %d, want 405", resp.StatusCode)
+	}
+}
+
+func TestDiagnoseAndPacketsRequireToken(t *testing.T) {
+	calls := stubOperations(t)
+
+	tests := []struct {
+		name      string
+		serverTok string
+		path      string
+		token     string
+		want      int
+	}{
+		{"diagnose without token", "s3cret", "/api/diagnose?iface=eth0", "", http.StatusUnauthorized},
+		{"diagnose with wrong token", "s3cret", "/api/diagnose?iface=eth0", "guess", http.StatusUnauthorized},
+		{"packets without token", "s3cret", "/api/capture/packets", "", http.StatusUnauthorized},
+		{"packets with wrong token", "s3cret", "/api/capture/packets", "guess", http.StatusUnauthorized},
+		{"diagnose with api_token unset", "", "/api/diagnose?iface=eth0", "", http.StatusForbidden},
+		{"packets with api_token unset", "", "/api/capture/packets", "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewServer(NewHandler(tt.serverTok))
+		resp := request(t, srv, http.MethodGet, tt.path, tt.token)
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, resp.StatusCode, tt.want)
+		}
+		var body struct{ Error string }
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Error == "" {
+			t.Errorf("%s: body %+v, err %v, want an error", tt.name, body, err)
+		}
+		srv.Close()
+	}
+	if len(*calls) != 0 {
+		t.Errorf("calls = %q, want none", *calls)
+	}
+}
+
+func TestCaptureEndpointsRequireToken(t *testing.T) {
+	calls := stubOperations(t)
+	srv := httptest.NewServer(NewHandler("s3cret"))
+	defer srv.Close()
+
+	tests := []struct {
+		name  string
+		path  string
+		token string
+		want  int
+	}{
+		{"start without token", "/api/capture/start?iface=eth0", "", http.StatusUnauthorized},
+		{"start with wrong token", "/api/capture/start?iface=eth0", "guess", http.StatusUnauthorized},
+		{"start without iface", "/api/capture/start", "s3cret", http.StatusBadRequest},
+		{"start", "/api/capture/start?iface=eth0&filter=tcp", "s3cret", http.StatusOK},
+		{"stop without token", "/api/capture/stop", "", http.StatusUnauthorized},
+		{"stop", "/api/capture/stop", "s3cret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		if resp := request(t, srv, http.MethodGet, tt.path, tt.token); resp.StatusCode != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, resp.StatusCode, tt.want)
+		}
+	}
+	if len(*calls) != 2 || (*calls)[0] != "start eth0 tcp" || (*calls)[1] != "stop" {
+		t.Errorf("calls = %q, want start then stop", *calls)
+	}
+}
+
+func TestCaptureEndpointsDisabledWithoutToken(t *testing.T) {
+	calls := stubOperations(t)
+	srv := httptest.NewServer(NewHandler(""))
+	defer srv.Close()
+
+	if resp := request(t, srv, http.MethodGet, "/api/capture/start?iface=eth0", ""); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("status %d, want 403", resp.StatusCode)
+	}
+	if len(*calls) != 0 {
+		t.Errorf("calls = %q, want none", *calls)
+	}
+}
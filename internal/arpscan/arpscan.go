@@ -0,0 +1,291 @@
+package arpscan
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alexpitcher/LanAudit/internal/oui"
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcap"
+)
+
+const (
+	// DefaultTimeout is how long Scan waits for replies after the last
+	// request is sent
+	DefaultTimeout = 3 * time.Second
+
+	// MaxHosts caps the addresses one scan probes (a /20)
+	MaxHosts = 4096
+)
+
+// sendInterval spaces requests so a large range does not burst the switch
+var sendInterval = time.Millisecond
+
+// ARPHost is a host that answered an ARP request
+type ARPHost struct {
+	IP     net.IP
+	MAC    net.HardwareAddr
+	Vendor string        // OUI registrant of MAC
+	RTT    time.Duration // from request sent to reply captured
+}
+
+// Scan sends an ARP request to every host address in cidr from iface and
+// returns the hosts that reply within timeout of the last request, sorted by
+// IP. Only IPv4 ranges can be scanned. Requires sudo/root.
+func Scan(iface string, cidr string, timeout time.Duration) ([]ARPHost, error) {
+	targets, err := hostsInCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get interface %s: %w", iface, err)
+	}
+	if len(ifi.HardwareAddr) != 6 {
+		return nil, fmt.Errorf("interface %s has no Ethernet address", iface)
+	}
+	addrs, err := ifi.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get addresses of %s: %w", iface, err)
+	}
+	srcIP := sourceIP(addrs, targets[0])
+	if srcIP == nil {
+		return nil, fmt.Errorf("interface %s has no IPv4 address", iface)
+	}
+
+	// A short read timeout lets the receiver notice the deadline
+	handle, err := pcap.OpenLive(iface, 128, true, 100*time.Millisecond)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open interface %s: %w (requires sudo/root)", iface, err)
+	}
+	defer handle.Close()
+	if err := handle.SetBPFFilter("arp"); err != nil {
+		return nil, fmt.Errorf("failed to set ARP filter: %w", err)
+	}
+
+	c := newCollector()
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.receive(handle, stop)
+	}()
+
+	for _, ip := range targets {
+		frame, err := buildRequest(ifi.HardwareAddr, srcIP, ip)
+		if err != nil {
+			close(stop)
+			<-done
+			return nil, err
+		}
+		c.sent(ip, time.Now())
+		if err := handle.WritePacketData(frame); err != nil {
+			close(stop)
+			<-done
+			return nil, fmt.Errorf("failed to send ARP request for %s: %w", ip, err)
+		}
+		time.Sleep(sendInterval)
+	}
+
+	time.Sleep(timeout)
+	close(stop)
+	<-done
+	return c.hosts(), nil
+}
+
+// collector matches ARP replies to the requests sent
+type collector struct {
+	mu     sync.Mutex
+	sentAt map[string]time.Time
+	found  map[string]ARPHost
+}
+
+func newCollector() *collector {
+	return &collector{
+		sentAt: make(map[string]time.Time),
+		found:  make(map[string]ARPHost),
+	}
+}
+
+func (c *collector) sent(ip net.IP, at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sentAt[ip.String()] = at
+}
+
+// receive reads frames until stop is closed
+func (c *collector) receive(handle *pcap.Handle, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		data, ci, err := handle.ReadPacketData()
+		if err != nil {
+			continue
+		}
+		packet := gopacket.NewPacket(data, layers.LayerTypeEthernet, gopacket.NoCopy)
+		if arpLayer := packet.Layer(layers.LayerTypeARP); arpLayer != nil {
+			c.observe(arpLayer.(*layers.ARP), ci.Timestamp)
+		}
+	}
+}
+
+// observe records a reply from an address a request was sent to. Later
+// replies from the same address are ignored, so RTT is the first answer's.
+func (c *collector) observe(arp *layers.ARP, at time.Time) bool {
+	if arp.Operation != layers.ARPReply || len(arp.SourceProtAddress) != 4 || len(arp.SourceHwAddress) != 6 {
+		return false
+	}
+	ip := net.IP(append([]byte(nil), arp.SourceProtAddress...))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sentAt, ok := c.sentAt[ip.String()]
+	if !ok {
+		return false
+	}
+	if _, dup := c.found[ip.String()]; dup {
+		return false
+	}
+	rtt := at.Sub(sentAt)
+	if rtt < 0 {
+		rtt = 0
+	}
+	mac := net.HardwareAddr(append([]byte(nil), arp.SourceHwAddress...))
+	vendor, _ := oui.Lookup(mac.String())
+	c.found[ip.String()] = ARPHost{
+		IP:     ip,
+		MAC:    mac,
+		Vendor: vendor,
+		RTT:    rtt,
+	}
+	return true
+}
+
+// hosts returns the hosts found, sorted by IP
+func (c *collector) hosts() []ARPHost {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	hosts := make([]ARPHost, 0, len(c.found))
+	for _, h := range c.found {
+		hosts = append(hosts, h)
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		return bytes.Compare(hosts[i].IP.To4(), hosts[j].IP.To4()) < 0
+	})
+	return hosts
+}
+
+// buildRequest returns a broadcast Ethernet frame asking who has dstIP
+func buildRequest(srcMAC net.HardwareAddr, srcIP, dstIP net.IP) ([]byte, error) {
+	eth := &layers.Ethernet{
+		SrcMAC:       srcMAC,
+		DstMAC:       net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		EthernetType: layers.EthernetTypeARP,
+	}
+	arp := &layers.ARP{
+		AddrType:          layers.LinkTypeEthernet,
+		Protocol:          layers.EthernetTypeIPv4,
+		HwAddressSize:     6,
+		ProtAddressSize:   4,
+		Operation:         layers.ARPRequest,
+		SourceHwAddress:   srcMAC,
+		SourceProtAddress: srcIP.To4(),
+		DstHwAddress:      make([]byte, 6),
+		DstProtAddress:    dstIP.To4(),
+	}
+	buf := gopacket.NewSerializeBuffer()
+	if err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true}, eth, arp); err != nil {
+		return nil, fmt.Errorf("failed to build ARP request: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// hostsInCIDR lists the host addresses of an IPv4 range, leaving out the
+// network and broadcast addresses unless the prefix is /31 or /32
+func hostsInCIDR(cidr string) ([]net.IP, error) {
+	_, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid range %q: %w", cidr, err)
+	}
+	base := ipnet.IP.To4()
+	if base == nil {
+		return nil, fmt.Errorf("invalid range %q: ARP scanning needs an IPv4 range", cidr)
+	}
+	ones, bits := ipnet.Mask.Size()
+	size := uint64(1) << uint(bits-ones)
+	first, last := uint64(0), size-1
+	if size > 2 {
+		first, last = 1, size-2
+	}
+	if count := last - first + 1; count > MaxHosts {
+		return nil, fmt.Errorf("range %q has %d hosts; at most %d can be scanned", cidr, count, MaxHosts)
+	}
+
+	start := uint64(binary.BigEndian.Uint32(base))
+	hosts := make([]net.IP, 0, last-first+1)
+	for i := first; i <= last; i++ {
+		ip := make(net.IP, 4)
+		binary.BigEndian.PutUint32(ip, uint32(start+i))
+		hosts = append(hosts, ip)
+	}
+	return hosts, nil
+}
+
+// sourceIP picks the interface's IPv4 address in the same subnet as target,
+// or else its first IPv4 address
+func sourceIP(addrs []net.Addr, target net.IP) net.IP {
+	var first net.IP
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok || ipnet.IP.To4() == nil {
+			continue
+		}
+		if ipnet.Contains(target) {
+			return ipnet.IP.To4()
+		}
+		if first == nil {
+			first = ipnet.IP.To4()
+		}
+	}
+	return first
+}
+
+// InterfaceSubnet returns the IPv4 subnet of iface in CIDR form, such as
+// "192.168.1.0/24", to offer as the default scan range
+func InterfaceSubnet(iface string) (string, error) {
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return "", fmt.Errorf("failed to get interface %s: %w", iface, err)
+	}
+	addrs, err := ifi.Addrs()
+	if err != nil {
+		return "", fmt.Errorf("failed to get addresses of %s: %w", iface, err)
+	}
+	if subnet := firstSubnet(addrs); subnet != "" {
+		return subnet, nil
+	}
+	return "", fmt.Errorf("interface %s has no IPv4 address", iface)
+}
+
+// firstSubnet returns the network of the first IPv4 address, or ""
+func firstSubnet(addrs []net.Addr) string {
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok || ipnet.IP.To4() == nil {
+			continue
+		}
+		network := &net.IPNet{IP: ipnet.IP.Mask(ipnet.Mask), Mask: ipnet.Mask}
+		return network.String()
+	}
+	return ""
+}
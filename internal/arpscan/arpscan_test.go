@@ -0,0 +1,161 @@
+package arpscan
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+func TestHostsInCIDR(t *testing.T) {
+	tests := []struct {
+		cidr    string
+		count   int
+		first   string
+		last    string
+		wantErr bool
+	}{
+		{cidr: "192.168.1.0/24", count: 254, first: "192.168.1.1", last: "192.168.1.254"},
+		{cidr: "192.168.1.77/24", count: 254, first: "192.168.1.1", last: "192.168.1.254"},
+		{cidr: "10.0.0.0/30", count: 2, first: "10.0.0.1", last: "10.0.0.2"},
+		{cidr: "10.0.0.4/31", count: 2, first: "10.0.0.4", last: "10.0.0.5"},
+		{cidr: "10.0.0.9/32", count: 1, first: "10.0.0.9", last: "10.0.0.9"},
+		{cidr: "172.16.0.0/20", count: 4094, first: "172.16.0.1", last: "172.16.15.254"},
+		{cidr: "172.16.0.0/19", wantErr: true},
+		{cidr: "2001:db8::/120", wantErr: true},
+		{cidr: "192.168.1.0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cidr, func(t *testing.T) {
+			hosts, err := hostsInCIDR(tt.cidr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("hostsInCIDR(%q) error = %v, wantErr %v", tt.cidr, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(hosts) != tt.count {
+				t.Fatalf("hostsInCIDR(%q) returned %d hosts, want %d", tt.cidr, len(hosts), tt.count)
+			}
+			if got := hosts[0].String(); got != tt.first {
+				t.Errorf("first host = %s, want %s", got, tt.first)
+			}
+			if got := hosts[len(hosts)-1].String(); got != tt.last {
+				t.Errorf("last host = %s, want %s", got, tt.last)
+			}
+		})
+	}
+}
+
+func TestBuildRequest(t *testing.T) {
+	srcMAC := net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55}
+	frame, err := buildRequest(srcMAC, net.ParseIP("192.168.1.10"), net.ParseIP("192.168.1.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	packet := gopacket.NewPacket(frame, layers.LayerTypeEthernet, gopacket.Default)
+	eth, _ := packet.Layer(layers.LayerTypeEthernet).(*layers.Ethernet)
+	arp, _ := packet.Layer(layers.LayerTypeARP).(*layers.ARP)
+	if eth == nil || arp == nil {
+		t.Fatalf("frame does not decode as Ethernet/ARP: %v", packet)
+	}
+	if eth.DstMAC.String() != "ff:ff:ff:ff:ff:ff" || eth.SrcMAC.String() != srcMAC.String() {
+		t.Errorf("Ethernet %s -> %s, want %s -> broadcast", eth.SrcMAC, eth.DstMAC, srcMAC)
+	}
+	if arp.Operation != layers.ARPRequest {
+		t.Errorf("Operation = %d, want request", arp.Operation)
+	}
+	if got := net.IP(arp.SourceProtAddress).String(); got != "192.168.1.10" {
+		t.Errorf("sender IP = %s, want 192.168.1.10", got)
+	}
+	if got := net.IP(arp.DstProtAddress).String(); got != "192.168.1.1" {
+		t.Errorf("target IP = %s, want 192.168.1.1", got)
+	}
+}
+
+func TestCollectorObserve(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	reply := func(ip net.IP, mac byte) *layers.ARP {
+		return &layers.ARP{
+			Operation:         layers.ARPReply,
+			SourceHwAddress:   []byte{0xaa, 0xbb, 0xcc, 0x00, 0x00, mac},
+			SourceProtAddress: ip.To4(),
+		}
+	}
+
+	c := newCollector()
+	c.sent(net.ParseIP("10.0.0.2"), start)
+	c.sent(net.ParseIP("10.0.0.1"), start.Add(time.Millisecond))
+
+	if !c.observe(reply(net.ParseIP("10.0.0.2"), 2), start.Add(5*time.Millisecond)) {
+		t.Error("reply from a scanned address should be recorded")
+	}
+	if c.observe(reply(net.ParseIP("10.0.0.2"), 9), start.Add(9*time.Millisecond)) {
+		t.Error("a second reply from the same address should be ignored")
+	}
+	if c.observe(reply(net.ParseIP("10.0.0.50"), 50), start.Add(5*time.Millisecond)) {
+		t.Error("reply from an address not scanned should be ignored")
+	}
+	request := reply(net.ParseIP("10.0.0.1"), 1)
+	request.Operation = layers.ARPRequest
+	if c.observe(request, start.Add(2*time.Millisecond)) {
+		t.Error("requests should be ignored")
+	}
+	c.observe(reply(net.ParseIP("10.0.0.1"), 1), start.Add(3*time.Millisecond))
+
+	hosts := c.hosts()
+	if len(hosts) != 2 {
+		t.Fatalf("hosts() = %v, want 2", hosts)
+	}
+	want := []struct {
+		ip  string
+		mac string
+		rtt time.Duration
+	}{
+		{"10.0.0.1", "aa:bb:cc:00:00:01", 2 * time.Millisecond},
+		{"10.0.0.2", "aa:bb:cc:00:00:02", 5 * time.Millisecond},
+	}
+	for i, w := range want {
+		if hosts[i].IP.String() != w.ip || hosts[i].MAC.String() != w.mac || hosts[i].RTT != w.rtt {
+			t.Errorf("hosts[%d] = %s %s %v, want %s %s %v", i, hosts[i].IP, hosts[i].MAC, hosts[i].RTT, w.ip, w.mac, w.rtt)
+		}
+	}
+
+	c.sent(net.ParseIP("10.0.0.3"), start)
+	cisco := reply(net.ParseIP("10.0.0.3"), 3)
+	cisco.SourceHwAddress = []byte{0x00, 0x00, 0x0c, 0x9f, 0xf0, 0x01}
+	c.observe(cisco, start.Add(time.Millisecond))
+	if got := c.hosts()[2].Vendor; got != "Cisco Systems" {
+		t.Errorf("Vendor = %q, want Cisco Systems", got)
+	}
+}
+
+func TestSubnetHelpers(t *testing.T) {
+	addrs := []net.Addr{
+		&net.IPNet{IP: net.ParseIP("fe80::1"), Mask: net.CIDRMask(64, 128)},
+		&net.IPNet{IP: net.ParseIP("10.1.2.3"), Mask: net.CIDRMask(16, 32)},
+		&net.IPNet{IP: net.ParseIP("192.168.1.10"), Mask: net.CIDRMask(24, 32)},
+	}
+	if got := firstSubnet(addrs); got != "10.1.0.0/16" {
+		t.Errorf("firstSubnet() = %q, want 10.1.0.0/16", got)
+	}
+	if got := sourceIP(addrs, net.ParseIP("192.168.1.1")); got.String() != "192.168.1.10" {
+		t.Errorf("sourceIP() in 192.168.1.0/24 = %s, want 192.168.1.10", got)
+	}
+	if got := sourceIP(addrs, net.ParseIP("172.16.0.1")); got.String() != "10.1.2.3" {
+		t.Errorf("sourceIP() outside every subnet = %s, want the first IPv4 address", got)
+	}
+	if got := firstSubnet(addrs[:1]); got != "" {
+		t.Errorf("firstSubnet() with only IPv6 = %q, want empty", got)
+	}
+}
+
+func TestScanRejectsBadRange(t *testing.T) {
+	if _, err := Scan("lo", "2001:db8::/64", time.Millisecond); err == nil {
+		t.Error("Scan should reject an IPv6 range before opening the interface")
+	}
+}
@@ -0,0 +1,253 @@
+package capture
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcap"
+)
+
+// ARPEventType classifies an ARP frame
+type ARPEventType string
+
+const (
+	ARPRequest    ARPEventType = "Request"
+	ARPReply      ARPEventType = "Reply"
+	ARPGratuitous ARPEventType = "Gratuitous"
+)
+
+// arpRateWindow is the interval over which per-sender request rates are measured
+const arpRateWindow = time.Second
+
+// ARPEvent describes ARP traffic from a single sender
+type ARPEvent struct {
+	Type      ARPEventType
+	SenderIP  string
+	SenderMAC string
+	TargetIP  string
+	Count     int     // requests seen from this sender since the monitor started
+	Rate      float64 // requests per second over the last second
+	Timestamp time.Time
+}
+
+// arpSender tracks request timing for one sender MAC
+type arpSender struct {
+	last      ARPEvent
+	recent    []time.Time // request timestamps within arpRateWindow
+	lastAlert time.Time
+}
+
+// arpTracker counts ARP requests per sender and flags senders over threshold
+type arpTracker struct {
+	threshold int
+	senders   map[string]*arpSender
+}
+
+func newARPTracker(threshold int) *arpTracker {
+	return &arpTracker{threshold: threshold, senders: make(map[string]*arpSender)}
+}
+
+// observe records an ARP frame and returns an alert event when its sender has
+// exceeded the threshold. Alerts for a sender are limited to one per window.
+func (t *arpTracker) observe(arp *layers.ARP, now time.Time) (ARPEvent, bool) {
+	ev := ARPEvent{
+		Type:      classifyARP(arp),
+		SenderIP:  net.IP(arp.SourceProtAddress).String(),
+		SenderMAC: net.HardwareAddr(arp.SourceHwAddress).String(),
+		TargetIP:  net.IP(arp.DstProtAddress).String(),
+		Timestamp: now,
+	}
+
+	sender, ok := t.senders[ev.SenderMAC]
+	if !ok {
+		sender = &arpSender{}
+		t.senders[ev.SenderMAC] = sender
+	}
+
+	// Replies don't contribute to a broadcast storm
+	if ev.Type != ARPReply {
+		cutoff := now.Add(-arpRateWindow)
+		kept := sender.recent[:0]
+		for _, ts := range sender.recent {
+			if ts.After(cutoff) {
+				kept = append(kept, ts)
+			}
+		}
+		sender.recent = append(kept, now)
+		ev.Count = sender.last.Count + 1
+	} else {
+		ev.Count = sender.last.Count
+	}
+	ev.Rate = float64(len(sender.recent)) / arpRateWindow.Seconds()
+	sender.last = ev
+
+	if len(sender.recent) > t.threshold && now.Sub(sender.lastAlert) >= arpRateWindow {
+		sender.lastAlert = now
+		return ev, true
+	}
+	return ev, false
+}
+
+// snapshot returns the latest event for each sender, busiest first
+func (t *arpTracker) snapshot() []ARPEvent {
+	events := make([]ARPEvent, 0, len(t.senders))
+	for _, s := range t.senders {
+		events = append(events, s.last)
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Count != events[j].Count {
+			return events[i].Count > events[j].Count
+		}
+		return events[i].SenderMAC < events[j].SenderMAC
+	})
+	return events
+}
+
+// classifyARP determines whether a frame is a request, reply or gratuitous ARP
+func classifyARP(arp *layers.ARP) ARPEventType {
+	if net.IP(arp.SourceProtAddress).Equal(net.IP(arp.DstProtAddress)) {
+		return ARPGratuitous
+	}
+	if arp.Operation == layers.ARPReply {
+		return ARPReply
+	}
+	return ARPRequest
+}
+
+// ARPMonitor watches ARP traffic on an interface
+type ARPMonitor struct {
+	Interface string
+	handle    *pcap.Handle
+	tracker   *arpTracker
+	events    chan ARPEvent
+	mu        sync.Mutex
+	stopChan  chan struct{}
+	running   bool
+}
+
+var (
+	currentARPMonitor *ARPMonitor
+	arpMonitorMu      sync.Mutex
+)
+
+// StartARPMonitor captures ARP frames on iface and sends an event whenever a
+// single sender exceeds threshold requests per second.
+// Requires sudo/root privileges
+func StartARPMonitor(iface string, threshold int) (<-chan ARPEvent, error) {
+	arpMonitorMu.Lock()
+	defer arpMonitorMu.Unlock()
+
+	if threshold <= 0 {
+		return nil, fmt.Errorf("threshold must be positive")
+	}
+	if currentARPMonitor != nil && currentARPMonitor.isRunning() {
+		return nil, fmt.Errorf("ARP monitor already running on %s", currentARPMonitor.Interface)
+	}
+
+	handle, err := pcap.OpenLive(iface, 128, true, pcap.BlockForever)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open interface %s: %w (requires sudo/root)", iface, err)
+	}
+	if err := handle.SetBPFFilter("arp"); err != nil {
+		handle.Close()
+		return nil, fmt.Errorf("failed to set ARP filter: %w", err)
+	}
+
+	mon := &ARPMonitor{
+		Interface: iface,
+		handle:    handle,
+		tracker:   newARPTracker(threshold),
+		events:    make(chan ARPEvent, 64),
+		stopChan:  make(chan struct{}),
+		running:   true,
+	}
+	currentARPMonitor = mon
+
+	go mon.loop()
+
+	return mon.events, nil
+}
+
+// loop reads ARP frames until the monitor is stopped
+func (m *ARPMonitor) loop() {
+	defer close(m.events)
+	packets := gopacket.NewPacketSource(m.handle, m.handle.LinkType()).Packets()
+
+	for {
+		select {
+		case <-m.stopChan:
+			return
+		case packet, ok := <-packets:
+			if !ok {
+				m.Stop()
+				return
+			}
+			arpLayer := packet.Layer(layers.LayerTypeARP)
+			if arpLayer == nil {
+				continue
+			}
+
+			m.mu.Lock()
+			ev, alert := m.tracker.observe(arpLayer.(*layers.ARP), packet.Metadata().Timestamp)
+			m.mu.Unlock()
+
+			if alert {
+				// Never block capture on a slow consumer
+				select {
+				case m.events <- ev:
+				default:
+				}
+			}
+		}
+	}
+}
+
+// Senders returns current per-sender counters, busiest first
+func (m *ARPMonitor) Senders() []ARPEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.tracker.snapshot()
+}
+
+// Stop halts the monitor
+func (m *ARPMonitor) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.running {
+		return
+	}
+	m.running = false
+	close(m.stopChan)
+	m.handle.Close()
+}
+
+func (m *ARPMonitor) isRunning() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.running
+}
+
+// GetARPMonitor returns the active ARP monitor if any
+func GetARPMonitor() *ARPMonitor {
+	arpMonitorMu.Lock()
+	defer arpMonitorMu.Unlock()
+	return currentARPMonitor
+}
+
+// StopARPMonitor stops the current ARP monitor if running
+func StopARPMonitor() error {
+	arpMonitorMu.Lock()
+	defer arpMonitorMu.Unlock()
+
+	if currentARPMonitor == nil {
+		return fmt.Errorf("no active ARP monitor")
+	}
+	currentARPMonitor.Stop()
+	return nil
+}
@@ -0,0 +1,92 @@
+package capture
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/gopacket/layers"
+)
+
+func arpFrame(op uint16, mac byte, src, dst byte) *layers.ARP {
+	return &layers.ARP{
+		Operation:         op,
+		SourceHwAddress:   []byte{0, 0x11, 0x22, 0x33, 0x44, mac},
+		SourceProtAddress: []byte{192, 168, 1, src},
+		DstHwAddress:      []byte{0, 0, 0, 0, 0, 0},
+		DstProtAddress:    []byte{192, 168, 1, dst},
+	}
+}
+
+func TestClassifyARP(t *testing.T) {
+	tests := []struct {
+		name string
+		arp  *layers.ARP
+		want ARPEventType
+	}{
+		{"request", arpFrame(layers.ARPRequest, 1, 10, 1), ARPRequest},
+		{"reply", arpFrame(layers.ARPReply, 1, 1, 10), ARPReply},
+		{"gratuitous", arpFrame(layers.ARPRequest, 1, 10, 10), ARPGratuitous},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyARP(tt.arp); got != tt.want {
+				t.Errorf("classifyARP() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestARPTrackerThreshold(t *testing.T) {
+	tracker := newARPTracker(5)
+	start := time.Unix(1700000000, 0)
+
+	// A quiet host stays under the threshold
+	for i := 0; i < 3; i++ {
+		if _, alert := tracker.observe(arpFrame(layers.ARPRequest, 2, 20, 1), start.Add(time.Duration(i)*100*time.Millisecond)); alert {
+			t.Fatal("unexpected alert for quiet sender")
+		}
+	}
+
+	// A noisy host crosses it on the sixth request within a second
+	var alerts []ARPEvent
+	for i := 0; i < 20; i++ {
+		ev, alert := tracker.observe(arpFrame(layers.ARPRequest, 1, 10, byte(i)), start.Add(time.Duration(i)*10*time.Millisecond))
+		if alert {
+			alerts = append(alerts, ev)
+		}
+	}
+	if len(alerts) != 1 {
+		t.Fatalf("got %d alerts, want 1 per window", len(alerts))
+	}
+	if a := alerts[0]; a.SenderIP != "192.168.1.10" || a.SenderMAC != "00:11:22:33:44:01" || a.Count != 6 || a.Rate != 6 {
+		t.Errorf("unexpected alert %+v", a)
+	}
+
+	// Requests older than the window no longer count
+	ev, alert := tracker.observe(arpFrame(layers.ARPRequest, 1, 10, 1), start.Add(3*time.Second))
+	if alert || ev.Rate != 1 || ev.Count != 21 {
+		t.Errorf("after idle: alert=%v event=%+v", alert, ev)
+	}
+
+	senders := tracker.snapshot()
+	if len(senders) != 2 || senders[0].SenderMAC != "00:11:22:33:44:01" {
+		t.Errorf("snapshot = %+v, want busiest sender first", senders)
+	}
+}
+
+func TestARPTrackerIgnoresReplies(t *testing.T) {
+	tracker := newARPTracker(1)
+	now := time.Unix(1700000000, 0)
+	for i := 0; i < 5; i++ {
+		if _, alert := tracker.observe(arpFrame(layers.ARPReply, 1, 1, 10), now); alert {
+			t.Fatal("replies should not trigger storm alerts")
+		}
+	}
+}
+
+func TestStartARPMonitorRejectsThreshold(t *testing.T) {
+	if _, err := StartARPMonitor("lo", 0); err == nil {
+		t.Error("expected error for non-positive threshold")
+	}
+}
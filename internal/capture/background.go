@@ -0,0 +1,323 @@
+package capture
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcap"
+	"github.com/google/gopacket/pcapgo"
+)
+
+const (
+	// DefaultBackgroundFileBytes is the default size at which background capture files rotate
+	DefaultBackgroundFileBytes = 100 * 1024 * 1024
+	// DefaultBackgroundMaxFiles is the default number of background capture files kept
+	DefaultBackgroundMaxFiles = 10
+
+	pcapFileHeaderLen   = 24
+	pcapRecordHeaderLen = 16
+	backgroundSnaplen   = 65536
+)
+
+// BackgroundStatus describes a background capture
+type BackgroundStatus struct {
+	Dir          string
+	CurrentFile  string   // file being written, empty once stopped
+	Files        []string // completed files still on disk, oldest first
+	MaxFiles     int
+	Packets      int
+	BytesWritten int64
+	Running      bool
+	Err          error
+}
+
+// BackgroundSession captures to a rotating set of pcap files on disk
+type BackgroundSession struct {
+	Interface string
+	handle    *pcap.Handle
+	writer    *rotatingWriter
+	mu        sync.Mutex
+	packets   int
+	running   bool
+	err       error
+	stopChan  chan struct{}
+	done      chan struct{}
+}
+
+var (
+	currentBackground *BackgroundSession
+	backgroundMu      sync.Mutex
+)
+
+// StartBackground captures on iface into dir, starting a new file when the
+// current one would exceed maxFileBytes and deleting the oldest files so at
+// most maxFiles remain. Files are written as .tmp and renamed once complete.
+// Requires sudo/root privileges
+func StartBackground(iface, filter, dir string, maxFileBytes int64, maxFiles int) (*BackgroundSession, error) {
+	backgroundMu.Lock()
+	defer backgroundMu.Unlock()
+
+	if maxFileBytes <= pcapFileHeaderLen {
+		return nil, fmt.Errorf("max file size must be larger than %d bytes", pcapFileHeaderLen)
+	}
+	if maxFiles <= 0 {
+		return nil, fmt.Errorf("max files must be positive")
+	}
+	if currentBackground != nil && currentBackground.IsRunning() {
+		return nil, fmt.Errorf("background capture already running on %s", currentBackground.Interface)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create capture directory: %w", err)
+	}
+
+	handle, err := pcap.OpenLive(iface, backgroundSnaplen, true, pcap.BlockForever)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open interface %s: %w (requires sudo/root)", iface, err)
+	}
+	if filter != "" {
+		if err := handle.SetBPFFilter(filter); err != nil {
+			handle.Close()
+			return nil, fmt.Errorf("invalid filter: %w", err)
+		}
+	}
+
+	bg := &BackgroundSession{
+		Interface: iface,
+		handle:    handle,
+		writer:    newRotatingWriter(dir, maxFileBytes, maxFiles, handle.LinkType()),
+		running:   true,
+		stopChan:  make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	currentBackground = bg
+
+	go bg.loop()
+
+	return bg, nil
+}
+
+// loop writes packets until the session is stopped or a write fails
+func (b *BackgroundSession) loop() {
+	defer close(b.done)
+	packets := gopacket.NewPacketSource(b.handle, b.handle.LinkType()).Packets()
+
+	for {
+		select {
+		case <-b.stopChan:
+			b.finish(b.writer.close())
+			return
+		case packet, ok := <-packets:
+			if !ok {
+				b.finish(b.writer.close())
+				return
+			}
+			if err := b.writer.writePacket(packet.Metadata().CaptureInfo, packet.Data()); err != nil {
+				b.writer.close()
+				b.finish(err)
+				return
+			}
+			b.mu.Lock()
+			b.packets++
+			b.mu.Unlock()
+		}
+	}
+}
+
+// finish records why the loop ended and releases the capture handle
+func (b *BackgroundSession) finish(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err != nil && b.err == nil {
+		b.err = err
+	}
+	if b.running {
+		b.running = false
+		b.handle.Close()
+	}
+}
+
+// Stop ends the capture and waits for the current file to be completed
+func (b *BackgroundSession) Stop() error {
+	b.mu.Lock()
+	if b.running {
+		close(b.stopChan)
+	}
+	b.mu.Unlock()
+
+	<-b.done
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.err
+}
+
+// IsRunning returns whether the background capture is still writing
+func (b *BackgroundSession) IsRunning() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.running
+}
+
+// Status returns the current state of the background capture
+func (b *BackgroundSession) Status() BackgroundStatus {
+	b.mu.Lock()
+	packets, running, err := b.packets, b.running, b.err
+	b.mu.Unlock()
+
+	st := b.writer.status()
+	st.Packets = packets
+	st.Running = running
+	st.Err = err
+	return st
+}
+
+// GetBackgroundSession returns the current background capture if any
+func GetBackgroundSession() *BackgroundSession {
+	backgroundMu.Lock()
+	defer backgroundMu.Unlock()
+	return currentBackground
+}
+
+// StopBackground stops the current background capture if running
+func StopBackground() error {
+	backgroundMu.Lock()
+	bg := currentBackground
+	backgroundMu.Unlock()
+
+	if bg == nil {
+		return fmt.Errorf("no active background capture")
+	}
+	return bg.Stop()
+}
+
+// rotatingWriter writes pcap files of bounded size, keeping a bounded number
+type rotatingWriter struct {
+	dir      string
+	maxBytes int64
+	maxFiles int
+	linkType layers.LinkType
+	now      func() time.Time
+
+	mu       sync.Mutex
+	file     *os.File
+	buf      *bufio.Writer
+	w        *pcapgo.Writer
+	tmpPath  string
+	size     int64 // bytes in the current file
+	total    int64 // bytes across all files
+	finished []string
+}
+
+func newRotatingWriter(dir string, maxBytes int64, maxFiles int, linkType layers.LinkType) *rotatingWriter {
+	return &rotatingWriter{dir: dir, maxBytes: maxBytes, maxFiles: maxFiles, linkType: linkType, now: time.Now}
+}
+
+// writePacket appends a packet, first rotating if it would overflow the current file
+func (r *rotatingWriter) writePacket(ci gopacket.CaptureInfo, data []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record := int64(pcapRecordHeaderLen + len(data))
+	if r.file != nil && r.size > pcapFileHeaderLen && r.size+record > r.maxBytes {
+		if err := r.finishFile(); err != nil {
+			return err
+		}
+	}
+	if r.file == nil {
+		if err := r.openFile(); err != nil {
+			return err
+		}
+	}
+
+	if err := r.w.WritePacket(ci, data); err != nil {
+		return fmt.Errorf("failed to write packet: %w", err)
+	}
+	r.size += record
+	r.total += record
+	return nil
+}
+
+// openFile starts a new .tmp file, pruning old files so that at most
+// maxFiles exist once it is complete. Caller must hold r.mu.
+func (r *rotatingWriter) openFile() error {
+	for len(r.finished) >= r.maxFiles {
+		if err := os.Remove(r.finished[0]); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove old capture: %w", err)
+		}
+		r.finished = r.finished[1:]
+	}
+
+	name := fmt.Sprintf("capture_%s.pcap", r.now().Format("20060102_150405.000000"))
+	r.tmpPath = filepath.Join(r.dir, name+".tmp")
+	f, err := os.Create(r.tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to create capture file: %w", err)
+	}
+
+	r.file = f
+	r.buf = bufio.NewWriter(f)
+	r.w = pcapgo.NewWriter(r.buf)
+	if err := r.w.WriteFileHeader(backgroundSnaplen, r.linkType); err != nil {
+		f.Close()
+		os.Remove(r.tmpPath)
+		r.file = nil
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+	r.size = pcapFileHeaderLen
+	r.total += pcapFileHeaderLen
+	return nil
+}
+
+// finishFile flushes and closes the current file and renames it into place.
+// Caller must hold r.mu.
+func (r *rotatingWriter) finishFile() error {
+	f, tmp := r.file, r.tmpPath
+	r.file = nil
+
+	if err := r.buf.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write capture file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close capture file: %w", err)
+	}
+
+	final := tmp[:len(tmp)-len(".tmp")]
+	if err := os.Rename(tmp, final); err != nil {
+		return fmt.Errorf("failed to finalize capture file: %w", err)
+	}
+	r.finished = append(r.finished, final)
+	return nil
+}
+
+// close completes the current file, if any
+func (r *rotatingWriter) close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	return r.finishFile()
+}
+
+func (r *rotatingWriter) status() BackgroundStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	st := BackgroundStatus{
+		Dir:          r.dir,
+		Files:        append([]string(nil), r.finished...),
+		MaxFiles:     r.maxFiles,
+		BytesWritten: r.total,
+	}
+	if r.file != nil {
+		st.CurrentFile = r.tmpPath
+	}
+	return st
+}
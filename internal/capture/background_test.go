@@ -0,0 +1,132 @@
+package capture
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+)
+
+// testRotatingWriter returns a writer with a deterministic clock
+func testRotatingWriter(t *testing.T, maxBytes int64, maxFiles int) *rotatingWriter {
+	t.Helper()
+	w := newRotatingWriter(t.TempDir(), maxBytes, maxFiles, layers.LinkTypeEthernet)
+	clock := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	w.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+	return w
+}
+
+func countPCAPPackets(t *testing.T, path string) int {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := pcapgo.NewReader(f)
+	if err != nil {
+		t.Fatalf("%s is not a valid pcap: %v", path, err)
+	}
+	n := 0
+	for {
+		if _, _, err := r.ReadPacketData(); err != nil {
+			return n
+		}
+		n++
+	}
+}
+
+func TestRotatingWriterRotatesAndPrunes(t *testing.T) {
+	// Header (24) + two 100-byte records (2*116) fit; a third does not
+	w := testRotatingWriter(t, 24+2*116, 2)
+	data := make([]byte, 100)
+	ci := gopacket.CaptureInfo{Timestamp: time.Unix(1700000000, 0), CaptureLength: len(data), Length: len(data)}
+
+	for i := 0; i < 7; i++ {
+		if err := w.writePacket(ci, data); err != nil {
+			t.Fatalf("writePacket %d: %v", i, err)
+		}
+	}
+
+	st := w.status()
+	if !strings.HasSuffix(st.CurrentFile, ".pcap.tmp") {
+		t.Errorf("current file %q should be a .tmp file", st.CurrentFile)
+	}
+	if len(st.Files) != 1 {
+		t.Errorf("expected 1 completed file alongside the current one, got %v", st.Files)
+	}
+
+	if err := w.close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	// Files 1 and 2 were pruned; files 3 (2 packets) and 4 (1 packet) remain
+	want := []string{"capture_20240101_120003.000000.pcap", "capture_20240101_120004.000000.pcap"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("files on disk = %v, want %v", names, want)
+	}
+	if n := countPCAPPackets(t, filepath.Join(w.dir, want[0])); n != 2 {
+		t.Errorf("%s has %d packets, want 2", want[0], n)
+	}
+	if n := countPCAPPackets(t, filepath.Join(w.dir, want[1])); n != 1 {
+		t.Errorf("%s has %d packets, want 1", want[1], n)
+	}
+	if st := w.status(); st.CurrentFile != "" || len(st.Files) != 2 {
+		t.Errorf("unexpected status after close: %+v", st)
+	}
+}
+
+func TestRotatingWriterOversizedPacket(t *testing.T) {
+	w := testRotatingWriter(t, 64, 3)
+	data := make([]byte, 200)
+	ci := gopacket.CaptureInfo{Timestamp: time.Unix(1700000000, 0), CaptureLength: len(data), Length: len(data)}
+
+	// A packet larger than the limit still gets a file of its own
+	for i := 0; i < 2; i++ {
+		if err := w.writePacket(ci, data); err != nil {
+			t.Fatalf("writePacket: %v", err)
+		}
+	}
+	if err := w.close(); err != nil {
+		t.Fatal(err)
+	}
+	if st := w.status(); len(st.Files) != 2 {
+		t.Errorf("expected one file per oversized packet, got %v", st.Files)
+	}
+}
+
+func TestRotatingWriterCloseWithoutPackets(t *testing.T) {
+	w := testRotatingWriter(t, 1024, 1)
+	if err := w.close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if entries, _ := os.ReadDir(w.dir); len(entries) != 0 {
+		t.Errorf("expected no files, got %d", len(entries))
+	}
+}
+
+func TestStartBackgroundValidation(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := StartBackground("lo0", "", dir, 10, 1); err == nil {
+		t.Error("expected error for a file size smaller than the pcap header")
+	}
+	if _, err := StartBackground("lo0", "", dir, 1024, 0); err == nil {
+		t.Error("expected error for zero max files")
+	}
+}
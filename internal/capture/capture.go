@@ -2,7 +2,10 @@ package capture
 
 import (
 	"fmt"
+	"io"
+	"net"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,26 +17,62 @@ import (
 
 // PacketSummary represents a captured packet
 type PacketSummary struct {
-	Timestamp  time.Time
-	SourceIP   string
-	DestIP     string
-	SourcePort string
-	DestPort   string
-	Protocol   string
-	Length     int
-	Info       string
+	Timestamp  time.Time `json:"timestamp"`
+	SourceIP   string    `json:"src_ip"`
+	DestIP     string    `json:"dst_ip"`
+	SourcePort string    `json:"src_port"`
+	DestPort   string    `json:"dst_port"`
+	Protocol   string    `json:"protocol"`
+	Length     int       `json:"length"`
+	Info       string    `json:"info"`
 }
 
 // Session represents an active capture session
 type Session struct {
-	Interface  string
-	Handle     *pcap.Handle
-	LinkType   layers.LinkType
-	Packets    []PacketSummary
-	RawPackets []gopacket.Packet
-	mu         sync.RWMutex
-	stopChan   chan struct{}
-	running    bool
+	Interface    string
+	Handle       *pcap.Handle
+	LinkType     layers.LinkType
+	Packets      []PacketSummary
+	RawPackets   []gopacket.Packet
+	DHCPEvents   []DHCPEvent // DHCP messages seen, oldest first
+	TotalDropped uint64      // packets evicted from the ring buffer
+	mu           sync.RWMutex
+	stopChan     chan struct{}
+	running      bool
+
+	// In ring mode Packets and RawPackets are fixed-size circular buffers
+	ringMode bool
+	ringHead int // next slot to write
+	ringLen  int // number of valid slots
+
+	// With a trigger, packets go to the ring until StartPattern matches
+	trigger      *TriggerConfig
+	triggered    bool
+	triggerStart int // index of the triggering packet in Packets
+
+	stats       protocolCounters
+	lengths     lengthCounters
+	talkers     sync.Map // IP -> *talkerCounters
+	reassembler *TCPReassembler
+
+	// statsReader reports kernel drop counters; nil for imported captures.
+	// drops keeps the last reading once the handle is closed.
+	statsReader StatsReader
+	drops       pcap.Stats
+}
+
+// StatsReader reports the packet counters of a capture handle. *pcap.Handle
+// implements it.
+type StatsReader interface {
+	Stats() (*pcap.Stats, error)
+}
+
+// StartOptions configures how a capture session stores packets
+type StartOptions struct {
+	// RingMode keeps only the most recent RingCapacity packets instead of
+	// stopping once maxPackets is reached
+	RingMode     bool
+	RingCapacity int
 }
 
 var (
@@ -43,7 +82,36 @@ var (
 
 // Start begins packet capture on the specified interface
 // Requires sudo/root privileges
-func Start(iface string, filter string, maxPackets int) (*Session, error) {
+func Start(iface string, filter string, maxPackets int, opts StartOptions) (*Session, error) {
+	if opts.RingMode && opts.RingCapacity <= 0 {
+		return nil, fmt.Errorf("ring capacity must be positive")
+	}
+
+	return startSession(iface, filter, maxPackets, func(s *Session) {
+		if opts.RingMode {
+			s.initRing(opts.RingCapacity)
+		} else {
+			s.Packets = make([]PacketSummary, 0, maxPackets)
+			s.RawPackets = make([]gopacket.Packet, 0, maxPackets)
+		}
+	})
+}
+
+// ValidateFilter compiles a BPF filter without opening an interface, so a
+// syntax error shows up before a capture starts. An empty filter is valid.
+func ValidateFilter(filter string) error {
+	if strings.TrimSpace(filter) == "" {
+		return nil
+	}
+	if _, err := pcap.CompileBPFFilter(layers.LinkTypeEthernet, 65535, filter); err != nil {
+		return fmt.Errorf("invalid filter: %w", err)
+	}
+	return nil
+}
+
+// startSession opens iface, lets setup configure packet storage and starts
+// the capture loop
+func startSession(iface, filter string, maxPackets int, setup func(*Session)) (*Session, error) {
 	sessionMu.Lock()
 	defer sessionMu.Unlock()
 
@@ -66,14 +134,15 @@ func Start(iface string, filter string, maxPackets int) (*Session, error) {
 	}
 
 	session := &Session{
-		Interface:  iface,
-		Handle:     handle,
-		LinkType:   handle.LinkType(),
-		Packets:    make([]PacketSummary, 0, maxPackets),
-		RawPackets: make([]gopacket.Packet, 0, maxPackets),
-		stopChan:   make(chan struct{}),
-		running:    true,
+		Interface:   iface,
+		Handle:      handle,
+		LinkType:    handle.LinkType(),
+		stopChan:    make(chan struct{}),
+		running:     true,
+		reassembler: NewTCPReassembler(),
+		statsReader: handle,
 	}
+	setup(session)
 
 	currentSession = session
 
@@ -86,6 +155,7 @@ func Start(iface string, filter string, maxPackets int) (*Session, error) {
 // captureLoop processes packets in the background
 func (s *Session) captureLoop(maxPackets int) {
 	packetSource := gopacket.NewPacketSource(s.Handle, s.Handle.LinkType())
+	defer s.reassembler.Close()
 
 	for {
 		select {
@@ -97,20 +167,87 @@ func (s *Session) captureLoop(maxPackets int) {
 			}
 
 			summary := s.parsePacket(packet)
+			s.reassembler.Assemble(packet)
 
-			s.mu.Lock()
-			if len(s.Packets) >= maxPackets {
-				s.mu.Unlock()
+			if done := s.record(summary, packet, maxPackets); done {
 				s.Stop()
 				return
 			}
-			s.Packets = append(s.Packets, summary)
-			s.RawPackets = append(s.RawPackets, packet)
-			s.mu.Unlock()
 		}
 	}
 }
 
+// record stores a parsed packet and reports whether the capture is complete
+func (s *Session) record(summary PacketSummary, packet gopacket.Packet, maxPackets int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.trigger != nil && !s.triggered {
+		if !s.trigger.StartPattern.MatchString(triggerText(summary)) {
+			if s.ringMode {
+				s.addPacket(summary, packet)
+			}
+			return false
+		}
+		s.startRecording()
+	}
+
+	if !s.ringMode && len(s.Packets)-s.triggerStart >= maxPackets {
+		return true
+	}
+	s.addPacket(summary, packet)
+
+	return s.trigger != nil && s.trigger.StopPattern != nil &&
+		s.trigger.StopPattern.MatchString(triggerText(summary))
+}
+
+// initRing switches the session to a circular buffer of the given capacity
+func (s *Session) initRing(capacity int) {
+	s.ringMode = true
+	s.Packets = make([]PacketSummary, capacity)
+	s.RawPackets = make([]gopacket.Packet, capacity)
+	s.ringHead = 0
+	s.ringLen = 0
+}
+
+// addPacket stores a packet, overwriting the oldest one when the ring is full.
+// Caller must hold s.mu.
+func (s *Session) addPacket(summary PacketSummary, raw gopacket.Packet) {
+	if !s.ringMode {
+		s.Packets = append(s.Packets, summary)
+		s.RawPackets = append(s.RawPackets, raw)
+		return
+	}
+
+	capacity := len(s.Packets)
+	s.Packets[s.ringHead] = summary
+	s.RawPackets[s.ringHead] = raw
+	s.ringHead = (s.ringHead + 1) % capacity
+	if s.ringLen < capacity {
+		s.ringLen++
+	} else {
+		s.TotalDropped++
+	}
+}
+
+// slot maps a logical packet index (0 = oldest) to its buffer position.
+// Caller must hold s.mu.
+func (s *Session) slot(i int) int {
+	if !s.ringMode {
+		return i
+	}
+	capacity := len(s.Packets)
+	return (s.ringHead - s.ringLen + i + capacity) % capacity
+}
+
+// count returns the number of stored packets. Caller must hold s.mu.
+func (s *Session) count() int {
+	if s.ringMode {
+		return s.ringLen
+	}
+	return len(s.Packets)
+}
+
 // parsePacket extracts summary information from a packet
 func (s *Session) parsePacket(packet gopacket.Packet) PacketSummary {
 	summary := PacketSummary{
@@ -158,10 +295,23 @@ func (s *Session) parsePacket(packet gopacket.Packet) PacketSummary {
 		summary.SourcePort = fmt.Sprintf("%d", udp.SrcPort)
 		summary.DestPort = fmt.Sprintf("%d", udp.DstPort)
 		summary.Protocol = "UDP"
+		if ev, ok := parseDHCP(packet, udp); ok {
+			summary.Info = ev.Summary()
+			s.addDHCPEvent(ev)
+		}
 	} else if icmpLayer := packet.Layer(layers.LayerTypeICMPv4); icmpLayer != nil {
 		icmp, _ := icmpLayer.(*layers.ICMPv4)
 		summary.Protocol = "ICMP"
 		summary.Info = fmt.Sprintf("Type: %d", icmp.TypeCode.Type())
+	} else if icmpLayer := packet.Layer(layers.LayerTypeICMPv6); icmpLayer != nil {
+		icmp, _ := icmpLayer.(*layers.ICMPv6)
+		summary.Protocol = "ICMP"
+		summary.Info = fmt.Sprintf("Type: %d", icmp.TypeCode.Type())
+	} else if arpLayer := packet.Layer(layers.LayerTypeARP); arpLayer != nil {
+		arp, _ := arpLayer.(*layers.ARP)
+		summary.SourceIP = net.IP(arp.SourceProtAddress).String()
+		summary.DestIP = net.IP(arp.DstProtAddress).String()
+		summary.Protocol = "ARP"
 	}
 
 	// Application layer hints
@@ -175,6 +325,10 @@ func (s *Session) parsePacket(packet gopacket.Packet) PacketSummary {
 		}
 	}
 
+	s.stats.count(packet, summary)
+	s.lengths.count(summary.Length)
+	s.countTalkers(summary)
+
 	return summary
 }
 
@@ -189,7 +343,14 @@ func (s *Session) Stop() {
 
 	s.running = false
 	close(s.stopChan)
-	s.Handle.Close()
+	if s.statsReader != nil {
+		// Keep the final drop counts; the handle can't report them once closed.
+		// On failure the last successful reading stands.
+		_ = s.readDrops()
+	}
+	if s.Handle != nil {
+		s.Handle.Close()
+	}
 }
 
 // GetPackets returns a copy of captured packets
@@ -197,8 +358,10 @@ func (s *Session) GetPackets() []PacketSummary {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	packets := make([]PacketSummary, len(s.Packets))
-	copy(packets, s.Packets)
+	packets := make([]PacketSummary, s.count())
+	for i := range packets {
+		packets[i] = s.Packets[s.slot(i)]
+	}
 	return packets
 }
 
@@ -206,7 +369,64 @@ func (s *Session) GetPackets() []PacketSummary {
 func (s *Session) GetPacketCount() int {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return len(s.Packets)
+	return s.count()
+}
+
+// GetTotalDropped returns how many packets were evicted from the ring buffer
+func (s *Session) GetTotalDropped() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.TotalDropped
+}
+
+// GetStats returns the per-protocol packet counts so far, along with the
+// packets dropped by the kernel and the interface. Drop counts are read from
+// the capture handle while it runs and kept after Stop.
+func (s *Session) GetStats() (Stats, error) {
+	st := s.stats.snapshot()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var err error
+	if s.running && s.statsReader != nil {
+		err = s.readDrops()
+	}
+	st.KernelDropped = uint64(s.drops.PacketsDropped)
+	st.InterfaceDropped = uint64(s.drops.PacketsIfDropped)
+	return st, err
+}
+
+// readDrops refreshes the drop counters from the capture handle.
+// Caller must hold s.mu.
+func (s *Session) readDrops() error {
+	ps, err := s.statsReader.Stats()
+	if err != nil {
+		return fmt.Errorf("failed to read capture stats: %w", err)
+	}
+	s.drops = *ps
+	return nil
+}
+
+// LengthHistogram returns the number of packets seen per length range,
+// keyed by LengthBuckets
+func (s *Session) LengthHistogram() map[string]int {
+	return s.lengths.snapshot()
+}
+
+// GetConversations returns HTTP conversations reassembled from port 80 traffic
+func (s *Session) GetConversations() []HTTPConversation {
+	if s.reassembler == nil {
+		return nil
+	}
+	return s.reassembler.Conversations()
+}
+
+// IsRingMode reports whether the session keeps only the most recent packets
+func (s *Session) IsRingMode() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.ringMode
 }
 
 // IsRunning returns whether the session is currently capturing
@@ -246,6 +466,12 @@ func Status() string {
 		return "No active capture"
 	}
 
+	if currentSession.IsWaitingForTrigger() {
+		return fmt.Sprintf("Waiting for trigger on %s: %d packets buffered",
+			currentSession.Interface,
+			currentSession.GetPacketCount())
+	}
+
 	return fmt.Sprintf("Capturing on %s: %d packets",
 		currentSession.Interface,
 		currentSession.GetPacketCount())
@@ -256,7 +482,8 @@ func (s *Session) SaveToPCAP(filename string) error {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	if len(s.RawPackets) == 0 {
+	n := s.count()
+	if n == 0 {
 		return fmt.Errorf("no packets to save")
 	}
 
@@ -266,12 +493,19 @@ func (s *Session) SaveToPCAP(filename string) error {
 	}
 	defer f.Close()
 
+	// pcapng carries the protocol breakdown in its section comment; classic
+	// pcap has nowhere to put it
+	if strings.HasSuffix(filename, ".pcapng") {
+		return s.writePCAPNG(f, n)
+	}
+
 	w := pcapgo.NewWriter(f)
 	if err := w.WriteFileHeader(65536, s.LinkType); err != nil {
 		return fmt.Errorf("failed to write header: %w", err)
 	}
 
-	for _, p := range s.RawPackets {
+	for i := 0; i < n; i++ {
+		p := s.RawPackets[s.slot(i)]
 		if err := w.WritePacket(p.Metadata().CaptureInfo, p.Data()); err != nil {
 			return fmt.Errorf("failed to write packet: %w", err)
 		}
@@ -279,3 +513,31 @@ func (s *Session) SaveToPCAP(filename string) error {
 
 	return nil
 }
+
+// writePCAPNG writes the first n packets in capture order as pcapng.
+// Caller must hold s.mu.
+func (s *Session) writePCAPNG(f io.Writer, n int) error {
+	intf := pcapgo.DefaultNgInterface
+	intf.Name = s.Interface
+	intf.LinkType = s.LinkType
+
+	opts := pcapgo.DefaultNgWriterOptions
+	opts.SectionInfo.Application = "LanAudit"
+	opts.SectionInfo.Comment = fmt.Sprintf("Protocol stats: %s", s.stats.snapshot())
+
+	w, err := pcapgo.NewNgWriterInterface(f, intf, opts)
+	if err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	for i := 0; i < n; i++ {
+		p := s.RawPackets[s.slot(i)]
+		ci := p.Metadata().CaptureInfo
+		ci.InterfaceIndex = 0
+		if err := w.WritePacket(ci, p.Data()); err != nil {
+			return fmt.Errorf("failed to write packet: %w", err)
+		}
+	}
+
+	return w.Flush()
+}
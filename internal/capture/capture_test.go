@@ -1,6 +1,7 @@
 package capture
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -70,3 +71,78 @@ func TestStopCurrentSession(t *testing.T) {
 		t.Error("Expected error when stopping non-existent session")
 	}
 }
+
+func TestRingBufferWraps(t *testing.T) {
+	sess := &Session{}
+	sess.initRing(3)
+
+	for i := 1; i <= 5; i++ {
+		sess.addPacket(PacketSummary{Length: i}, nil)
+	}
+
+	if count := sess.GetPacketCount(); count != 3 {
+		t.Errorf("GetPacketCount() = %d, want 3", count)
+	}
+	if dropped := sess.GetTotalDropped(); dropped != 2 {
+		t.Errorf("GetTotalDropped() = %d, want 2", dropped)
+	}
+
+	// Oldest two packets are evicted and the rest come back in capture order
+	pkts := sess.GetPackets()
+	for i, want := range []int{3, 4, 5} {
+		if pkts[i].Length != want {
+			t.Errorf("packet %d length = %d, want %d (got %+v)", i, pkts[i].Length, want, pkts)
+		}
+	}
+}
+
+func TestRingBufferPartiallyFilled(t *testing.T) {
+	sess := &Session{}
+	sess.initRing(4)
+
+	sess.addPacket(PacketSummary{Length: 1}, nil)
+	sess.addPacket(PacketSummary{Length: 2}, nil)
+
+	pkts := sess.GetPackets()
+	if len(pkts) != 2 || pkts[0].Length != 1 || pkts[1].Length != 2 {
+		t.Errorf("GetPackets() = %+v, want lengths [1 2]", pkts)
+	}
+	if dropped := sess.GetTotalDropped(); dropped != 0 {
+		t.Errorf("GetTotalDropped() = %d, want 0", dropped)
+	}
+}
+
+func TestStartRejectsEmptyRing(t *testing.T) {
+	if _, err := Start("lo", "", 10, StartOptions{RingMode: true}); err == nil {
+		t.Error("expected error for ring mode without capacity")
+	}
+}
+
+func TestValidateFilter(t *testing.T) {
+	if err := ValidateFilter("tcp"); err != nil && strings.Contains(err.Error(), "dead capture") {
+		t.Skipf("libpcap cannot compile filters here: %v", err)
+	}
+
+	tests := []struct {
+		filter  string
+		wantErr bool
+	}{
+		{filter: "", wantErr: false},
+		{filter: "tcp port 80", wantErr: false},
+		{filter: "host 192.168.1.1 and not arp", wantErr: false},
+		{filter: "udp dst portrange 5000-5100", wantErr: false},
+		{filter: "tcp port", wantErr: true},
+		{filter: "hots 192.168.1.1", wantErr: true},
+		{filter: "port 99999", wantErr: true},
+		{filter: "(tcp", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			err := ValidateFilter(tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateFilter(%q) error = %v, wantErr %v", tt.filter, err, tt.wantErr)
+			}
+		})
+	}
+}
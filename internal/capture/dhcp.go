@@ -0,0 +1,126 @@
+package capture
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// maxDHCPEvents bounds the DHCP messages a session keeps; oldest are dropped
+const maxDHCPEvents = 1000
+
+// DHCPEvent is a DHCP message seen during a capture with the lease options
+// it carried. Options missing from the message are left empty.
+type DHCPEvent struct {
+	Timestamp   time.Time     `json:"timestamp"`
+	MessageType string        `json:"message_type"` // e.g. "Offer"
+	ClientMAC   string        `json:"client_mac"`
+	YourIP      string        `json:"your_ip,omitempty"`     // address offered or assigned
+	SubnetMask  string        `json:"subnet_mask,omitempty"` // option 1
+	Routers     []string      `json:"routers,omitempty"`     // option 3
+	DNSServers  []string      `json:"dns_servers,omitempty"` // option 6
+	LeaseTime   time.Duration `json:"lease_time,omitempty"`  // option 51
+	ServerID    string        `json:"server_id,omitempty"`   // option 54
+	ClientID    string        `json:"client_id,omitempty"`   // option 61, hex encoded
+}
+
+// Summary returns the packet list description, e.g.
+// "DHCP OFFER 192.168.1.100 lease=86400s"
+func (e DHCPEvent) Summary() string {
+	s := "DHCP " + strings.ToUpper(e.MessageType)
+	if e.YourIP != "" {
+		s += " " + e.YourIP
+	}
+	if e.LeaseTime > 0 {
+		s += fmt.Sprintf(" lease=%ds", int64(e.LeaseTime/time.Second))
+	}
+	return s
+}
+
+// parseDHCP extracts a DHCPEvent from a UDP packet to or from the DHCP
+// ports (67 and 68)
+func parseDHCP(packet gopacket.Packet, udp *layers.UDP) (DHCPEvent, bool) {
+	if !isDHCPPort(udp.SrcPort) && !isDHCPPort(udp.DstPort) {
+		return DHCPEvent{}, false
+	}
+
+	var dhcp *layers.DHCPv4
+	if l := packet.Layer(layers.LayerTypeDHCPv4); l != nil {
+		dhcp = l.(*layers.DHCPv4)
+	} else {
+		dhcp = &layers.DHCPv4{}
+		if err := dhcp.DecodeFromBytes(udp.Payload, gopacket.NilDecodeFeedback); err != nil {
+			return DHCPEvent{}, false
+		}
+	}
+
+	ev := DHCPEvent{
+		Timestamp:   packet.Metadata().Timestamp,
+		MessageType: dhcpMessageType(dhcp).String(),
+		ClientMAC:   dhcp.ClientHWAddr.String(),
+	}
+	if dhcp.YourClientIP != nil && !dhcp.YourClientIP.IsUnspecified() {
+		ev.YourIP = dhcp.YourClientIP.String()
+	}
+
+	for _, opt := range dhcp.Options {
+		switch opt.Type {
+		case layers.DHCPOptSubnetMask:
+			if len(opt.Data) == 4 {
+				ev.SubnetMask = net.IP(opt.Data).String()
+			}
+		case layers.DHCPOptRouter:
+			ev.Routers = dhcpAddresses(opt.Data)
+		case layers.DHCPOptDNS:
+			ev.DNSServers = dhcpAddresses(opt.Data)
+		case layers.DHCPOptLeaseTime:
+			if len(opt.Data) == 4 {
+				ev.LeaseTime = time.Duration(binary.BigEndian.Uint32(opt.Data)) * time.Second
+			}
+		case layers.DHCPOptServerID:
+			if len(opt.Data) == 4 {
+				ev.ServerID = net.IP(opt.Data).String()
+			}
+		case layers.DHCPOptClientID:
+			ev.ClientID = hex.EncodeToString(opt.Data)
+		}
+	}
+	return ev, true
+}
+
+func isDHCPPort(port layers.UDPPort) bool {
+	return port == 67 || port == 68
+}
+
+// dhcpAddresses splits an option holding a list of IPv4 addresses
+func dhcpAddresses(data []byte) []string {
+	var addrs []string
+	for i := 0; i+4 <= len(data); i += 4 {
+		addrs = append(addrs, net.IP(data[i:i+4]).String())
+	}
+	return addrs
+}
+
+// addDHCPEvent records a DHCP message, dropping the oldest once
+// maxDHCPEvents are kept
+func (s *Session) addDHCPEvent(ev DHCPEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.DHCPEvents = append(s.DHCPEvents, ev)
+	if len(s.DHCPEvents) > maxDHCPEvents {
+		s.DHCPEvents = s.DHCPEvents[len(s.DHCPEvents)-maxDHCPEvents:]
+	}
+}
+
+// GetDHCPEvents returns a copy of the DHCP messages seen, oldest first
+func (s *Session) GetDHCPEvents() []DHCPEvent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]DHCPEvent(nil), s.DHCPEvents...)
+}
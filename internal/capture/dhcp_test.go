@@ -0,0 +1,72 @@
+package capture
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseDHCPGolden(t *testing.T) {
+	sess, err := OpenPCAP(filepath.Join("testdata", "dhcp.pcap"))
+	if err != nil {
+		t.Fatalf("OpenPCAP: %v", err)
+	}
+
+	server := DHCPEvent{
+		ClientMAC:  "00:11:22:33:44:55",
+		YourIP:     "192.168.1.100",
+		SubnetMask: "255.255.255.0",
+		Routers:    []string{"192.168.1.1"},
+		DNSServers: []string{"192.168.1.1", "1.1.1.1"},
+		LeaseTime:  86400 * time.Second,
+		ServerID:   "192.168.1.1",
+	}
+	offer, ack := server, server
+	offer.MessageType = "Offer"
+	ack.MessageType = "Ack"
+	want := []DHCPEvent{
+		{MessageType: "Discover", ClientMAC: "00:11:22:33:44:55", ClientID: "01001122334455"},
+		offer,
+		{MessageType: "Request", ClientMAC: "00:11:22:33:44:55", ServerID: "192.168.1.1", ClientID: "01001122334455"},
+		ack,
+	}
+
+	events := sess.GetDHCPEvents()
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(events), len(want), events)
+	}
+	for i := range want {
+		got := events[i]
+		got.Timestamp = time.Time{}
+		if !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("event %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+	if !events[1].Timestamp.Equal(time.Date(2024, 5, 1, 10, 0, 0, 10e6, time.UTC)) {
+		t.Errorf("offer timestamp = %v", events[1].Timestamp)
+	}
+
+	wantInfo := []string{
+		"DHCP DISCOVER",
+		"DHCP OFFER 192.168.1.100 lease=86400s",
+		"DHCP REQUEST",
+		"DHCP ACK 192.168.1.100 lease=86400s",
+	}
+	for i, p := range sess.GetPackets() {
+		if p.Protocol != "UDP" || p.Info != wantInfo[i] {
+			t.Errorf("packet %d = %s %q, want UDP %q", i, p.Protocol, p.Info, wantInfo[i])
+		}
+	}
+}
+
+func TestAddDHCPEventBounded(t *testing.T) {
+	sess := &Session{}
+	for i := 0; i < maxDHCPEvents+5; i++ {
+		sess.addDHCPEvent(DHCPEvent{ClientID: string(rune('a' + i%26))})
+	}
+	events := sess.GetDHCPEvents()
+	if len(events) != maxDHCPEvents || events[0].ClientID != "f" {
+		t.Errorf("kept %d events starting with %q, want %d starting with the sixth", len(events), events[0].ClientID, maxDHCPEvents)
+	}
+}
@@ -0,0 +1,239 @@
+package capture
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcap"
+	"github.com/miekg/dns"
+)
+
+const (
+	// maxDNSEvents bounds memory use on busy resolvers; oldest events are dropped
+	maxDNSEvents = 10000
+	// dnsPendingTimeout is how long a query waits for its response
+	dnsPendingTimeout = 10 * time.Second
+	// dnsPruneInterval is how many events are added between stale query sweeps
+	dnsPruneInterval = 1024
+)
+
+// DNSEvent is a DNS query and, once seen, its response
+type DNSEvent struct {
+	Timestamp    time.Time
+	SourceIP     string // client that sent the query
+	QueryName    string
+	QueryType    string
+	ResponseCode string // empty until a response is seen
+	Answers      []string
+	Latency      time.Duration
+}
+
+// DNSLogger records DNS traffic on an interface
+type DNSLogger struct {
+	Interface  string
+	handle     *pcap.Handle
+	mu         sync.RWMutex
+	events     []DNSEvent
+	pending    map[string]int // query key -> index into events
+	sinceSweep int
+	stopChan   chan struct{}
+	running    bool
+}
+
+// StartDNSLogger captures DNS queries and responses on iface.
+// Requires sudo/root privileges
+func StartDNSLogger(iface string) (*DNSLogger, error) {
+	handle, err := pcap.OpenLive(iface, 1600, true, pcap.BlockForever)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open interface %s: %w (requires sudo/root)", iface, err)
+	}
+	if err := handle.SetBPFFilter("udp port 53"); err != nil {
+		handle.Close()
+		return nil, fmt.Errorf("failed to set DNS filter: %w", err)
+	}
+
+	l := newDNSLogger(iface)
+	l.handle = handle
+	l.running = true
+
+	go l.loop(gopacket.NewPacketSource(handle, handle.LinkType()).Packets())
+
+	return l, nil
+}
+
+func newDNSLogger(iface string) *DNSLogger {
+	return &DNSLogger{
+		Interface: iface,
+		pending:   make(map[string]int),
+		stopChan:  make(chan struct{}),
+	}
+}
+
+// loop processes packets until the logger is stopped
+func (l *DNSLogger) loop(packets <-chan gopacket.Packet) {
+	for {
+		select {
+		case <-l.stopChan:
+			return
+		case packet, ok := <-packets:
+			if !ok {
+				l.Stop()
+				return
+			}
+			l.handlePacket(packet)
+		}
+	}
+}
+
+// handlePacket records a DNS query or matches a response to its query
+func (l *DNSLogger) handlePacket(packet gopacket.Packet) {
+	udpLayer := packet.Layer(layers.LayerTypeUDP)
+	if udpLayer == nil {
+		return
+	}
+	udp := udpLayer.(*layers.UDP)
+
+	var srcIP, dstIP string
+	if ip4 := packet.Layer(layers.LayerTypeIPv4); ip4 != nil {
+		srcIP, dstIP = ip4.(*layers.IPv4).SrcIP.String(), ip4.(*layers.IPv4).DstIP.String()
+	} else if ip6 := packet.Layer(layers.LayerTypeIPv6); ip6 != nil {
+		srcIP, dstIP = ip6.(*layers.IPv6).SrcIP.String(), ip6.(*layers.IPv6).DstIP.String()
+	} else {
+		return
+	}
+
+	msg := new(dns.Msg)
+	if err := msg.Unpack(udp.Payload); err != nil || len(msg.Question) == 0 {
+		return
+	}
+	ts := packet.Metadata().Timestamp
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !msg.Response {
+		key := dnsQueryKey(srcIP, uint16(udp.SrcPort), msg.Id)
+		l.addEvent(DNSEvent{
+			Timestamp: ts,
+			SourceIP:  srcIP,
+			QueryName: strings.TrimSuffix(msg.Question[0].Name, "."),
+			QueryType: dns.TypeToString[msg.Question[0].Qtype],
+		}, key)
+		return
+	}
+
+	// The client is the destination of a response
+	key := dnsQueryKey(dstIP, uint16(udp.DstPort), msg.Id)
+	idx, ok := l.pending[key]
+	if ok {
+		delete(l.pending, key)
+	} else {
+		// Response to a query sent before logging started
+		l.addEvent(DNSEvent{
+			Timestamp: ts,
+			SourceIP:  dstIP,
+			QueryName: strings.TrimSuffix(msg.Question[0].Name, "."),
+			QueryType: dns.TypeToString[msg.Question[0].Qtype],
+		}, "")
+		idx = len(l.events) - 1
+	}
+
+	ev := &l.events[idx]
+	ev.ResponseCode = dns.RcodeToString[msg.Rcode]
+	ev.Answers = dnsAnswers(msg)
+	if ok {
+		ev.Latency = ts.Sub(ev.Timestamp)
+	}
+}
+
+// addEvent appends an event, trimming old events and stale pending queries.
+// Caller must hold l.mu.
+func (l *DNSLogger) addEvent(ev DNSEvent, key string) {
+	if len(l.events) >= maxDNSEvents {
+		// Drop a tenth at a time so trimming isn't paid on every packet
+		drop := maxDNSEvents / 10
+		l.events = append([]DNSEvent(nil), l.events[drop:]...)
+		for k, idx := range l.pending {
+			if idx < drop {
+				delete(l.pending, k)
+			} else {
+				l.pending[k] = idx - drop
+			}
+		}
+	}
+	l.sinceSweep++
+	if l.sinceSweep >= dnsPruneInterval {
+		l.sinceSweep = 0
+		for k, idx := range l.pending {
+			if ev.Timestamp.Sub(l.events[idx].Timestamp) > dnsPendingTimeout {
+				delete(l.pending, k)
+			}
+		}
+	}
+
+	l.events = append(l.events, ev)
+	if key != "" {
+		l.pending[key] = len(l.events) - 1
+	}
+}
+
+// dnsQueryKey identifies a query by client address and DNS ID
+func dnsQueryKey(clientIP string, clientPort uint16, id uint16) string {
+	return fmt.Sprintf("%s:%d/%d", clientIP, clientPort, id)
+}
+
+// dnsAnswers renders answer records as their type and data, e.g. "A 93.184.216.34"
+func dnsAnswers(msg *dns.Msg) []string {
+	var answers []string
+	for _, rr := range msg.Answer {
+		data := strings.TrimSpace(strings.TrimPrefix(rr.String(), rr.Header().String()))
+		answers = append(answers, dns.TypeToString[rr.Header().Rrtype]+" "+data)
+	}
+	return answers
+}
+
+// GetEvents returns a copy of logged events, oldest first
+func (l *DNSLogger) GetEvents() []DNSEvent {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	events := make([]DNSEvent, len(l.events))
+	copy(events, l.events)
+	return events
+}
+
+// Summary returns the number of queries seen per query name
+func (l *DNSLogger) Summary() map[string]int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	summary := make(map[string]int)
+	for _, ev := range l.events {
+		summary[ev.QueryName]++
+	}
+	return summary
+}
+
+// Stop halts the logger; logged events remain available
+func (l *DNSLogger) Stop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.running {
+		return
+	}
+	l.running = false
+	close(l.stopChan)
+	l.handle.Close()
+}
+
+// IsRunning returns whether the logger is currently capturing
+func (l *DNSLogger) IsRunning() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.running
+}
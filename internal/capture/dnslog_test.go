@@ -0,0 +1,88 @@
+package capture
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/pcapgo"
+)
+
+// replayPCAP feeds every packet in a pcap fixture to the logger
+func replayPCAP(t *testing.T, l *DNSLogger, path string) {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open fixture: %v", err)
+	}
+	defer f.Close()
+
+	r, err := pcapgo.NewReader(f)
+	if err != nil {
+		t.Fatalf("failed to read pcap: %v", err)
+	}
+	src := gopacket.NewPacketSource(r, r.LinkType())
+	for packet := range src.Packets() {
+		l.handlePacket(packet)
+	}
+}
+
+func TestDNSLoggerGolden(t *testing.T) {
+	l := newDNSLogger("en0")
+	replayPCAP(t, l, "testdata/dns.pcap")
+
+	events := l.GetEvents()
+	want := []DNSEvent{
+		{SourceIP: "192.168.1.10", QueryName: "example.com", QueryType: "A", ResponseCode: "NOERROR", Answers: []string{"A 93.184.216.34"}, Latency: 20 * time.Millisecond},
+		{SourceIP: "192.168.1.10", QueryName: "example.com", QueryType: "AAAA"},
+		{SourceIP: "192.168.1.10", QueryName: "nonexistent.invalid", QueryType: "A", ResponseCode: "NXDOMAIN", Latency: 15 * time.Millisecond},
+		{SourceIP: "192.168.1.10", QueryName: "www.example.org", QueryType: "A", ResponseCode: "NOERROR", Answers: []string{"CNAME example.org.", "A 93.184.216.35"}},
+	}
+
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(events), len(want), events)
+	}
+	for i := range want {
+		got := events[i]
+		got.Timestamp = time.Time{}
+		if !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("event %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	if !events[0].Timestamp.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("event 0 timestamp = %v", events[0].Timestamp)
+	}
+
+	summary := l.Summary()
+	wantSummary := map[string]int{"example.com": 2, "nonexistent.invalid": 1, "www.example.org": 1}
+	if !reflect.DeepEqual(summary, wantSummary) {
+		t.Errorf("Summary() = %v, want %v", summary, wantSummary)
+	}
+}
+
+func TestDNSLoggerTrimsOldEvents(t *testing.T) {
+	l := newDNSLogger("en0")
+	base := time.Unix(1700000000, 0)
+	for i := 0; i < maxDNSEvents+5; i++ {
+		l.addEvent(DNSEvent{Timestamp: base.Add(time.Duration(i) * time.Millisecond), QueryName: "example.com"}, dnsQueryKey("192.168.1.10", 50000, uint16(i)))
+	}
+
+	events := l.GetEvents()
+	if len(events) > maxDNSEvents {
+		t.Fatalf("got %d events, want at most %d", len(events), maxDNSEvents)
+	}
+	if !events[0].Timestamp.Equal(base.Add(time.Duration(maxDNSEvents/10) * time.Millisecond)) {
+		t.Errorf("oldest event = %v, want the oldest tenth dropped", events[0].Timestamp)
+	}
+	if last := events[len(events)-1].Timestamp; !last.Equal(base.Add(time.Duration(maxDNSEvents+4) * time.Millisecond)) {
+		t.Errorf("newest event = %v, want the last one added", last)
+	}
+	for k, idx := range l.pending {
+		if idx < 0 || idx >= len(l.events) {
+			t.Fatalf("pending %s points outside events: %d", k, idx)
+		}
+	}
+}
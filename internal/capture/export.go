@@ -0,0 +1,113 @@
+package capture
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvHeader is the column layout written by ExportCSV
+var csvHeader = []string{"timestamp", "src_ip", "src_port", "dst_ip", "dst_port", "protocol", "length", "info", "length_bucket"}
+
+// captureExport is the document written by ExportJSON
+type captureExport struct {
+	Interface       string          `json:"interface"`
+	LinkType        string          `json:"link_type"`
+	PacketCount     int             `json:"packet_count"`
+	TotalDropped    uint64          `json:"total_dropped"`
+	Stats           Stats           `json:"stats"`
+	LengthHistogram map[string]int  `json:"length_histogram"`
+	DHCPEvents      []DHCPEvent     `json:"dhcp_events,omitempty"`
+	Packets         []PacketSummary `json:"packets"`
+}
+
+// ExportJSON writes packet summaries, protocol stats, drop counts and
+// interface metadata to a JSON file
+func (s *Session) ExportJSON(path string) error {
+	packets := s.GetPackets()
+	if len(packets) == 0 {
+		return fmt.Errorf("no packets to export")
+	}
+	stats, err := s.GetStats()
+	if err != nil {
+		return err
+	}
+
+	doc := captureExport{
+		Interface:       s.Interface,
+		LinkType:        s.LinkType.String(),
+		PacketCount:     len(packets),
+		TotalDropped:    s.GetTotalDropped(),
+		Stats:           stats,
+		LengthHistogram: s.LengthHistogram(),
+		DHCPEvents:      s.GetDHCPEvents(),
+		Packets:         packets,
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write JSON: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write JSON: %w", err)
+	}
+	return nil
+}
+
+// ExportCSV writes one row per captured packet to a CSV file. Each row
+// carries its LengthHistogram bucket so counts can be pivoted per bucket.
+func (s *Session) ExportCSV(path string) error {
+	packets := s.GetPackets()
+	if len(packets) == 0 {
+		return fmt.Errorf("no packets to export")
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer f.Close()
+
+	bw := bufio.NewWriter(f)
+	w := csv.NewWriter(bw)
+	if err := w.Write(csvHeader); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	for _, p := range packets {
+		row := []string{
+			p.Timestamp.Format(time.RFC3339Nano),
+			p.SourceIP,
+			p.SourcePort,
+			p.DestIP,
+			p.DestPort,
+			p.Protocol,
+			strconv.Itoa(p.Length),
+			strings.TrimSpace(p.Info),
+			LengthBuckets[lengthBucket(p.Length)],
+		}
+		if err := w.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV: %w", err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package capture
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket/layers"
+)
+
+func exportSession(t *testing.T) *Session {
+	t.Helper()
+	sess := &Session{Interface: "en0", LinkType: layers.LinkTypeEthernet}
+	for _, p := range testPackets(t) {
+		sess.addPacket(sess.parsePacket(p), p)
+	}
+	return sess
+}
+
+func TestExportJSON(t *testing.T) {
+	sess := exportSession(t)
+	path := filepath.Join(t.TempDir(), "capture.json")
+	if err := sess.ExportJSON(path); err != nil {
+		t.Fatalf("ExportJSON: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got captureExport
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	if got.Interface != "en0" || got.LinkType != "Ethernet" {
+		t.Errorf("unexpected metadata: interface=%q link_type=%q", got.Interface, got.LinkType)
+	}
+	if got.PacketCount != 7 || len(got.Packets) != 7 {
+		t.Errorf("packet_count = %d with %d packets, want 7", got.PacketCount, len(got.Packets))
+	}
+	if want, _ := sess.GetStats(); got.Stats != want {
+		t.Errorf("stats = %+v, want %+v", got.Stats, want)
+	}
+	if !reflect.DeepEqual(got.LengthHistogram, sess.LengthHistogram()) || got.LengthHistogram["0-64"] != 6 {
+		t.Errorf("length_histogram = %v, want %v", got.LengthHistogram, sess.LengthHistogram())
+	}
+	if p := got.Packets[0]; p.SourceIP != "192.168.1.10" || p.DestPort != "22" || p.Protocol != "TCP" {
+		t.Errorf("unexpected first packet: %+v", p)
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	sess := exportSession(t)
+	path := filepath.Join(t.TempDir(), "capture.csv")
+	if err := sess.ExportCSV(path); err != nil {
+		t.Fatalf("ExportCSV: %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+
+	if len(rows) != 8 {
+		t.Fatalf("got %d rows, want header + 7", len(rows))
+	}
+	if !reflect.DeepEqual(rows[0], csvHeader) {
+		t.Errorf("header = %v, want %v", rows[0], csvHeader)
+	}
+	want := []string{time.Unix(1700000000, 0).Format(time.RFC3339Nano), "192.168.1.10", "50000", "192.168.1.1", "22", "TCP", rows[1][6], "SYN", "0-64"}
+	if !reflect.DeepEqual(rows[1], want) {
+		t.Errorf("first row = %v, want %v", rows[1], want)
+	}
+}
+
+func TestExportEmptySession(t *testing.T) {
+	sess := &Session{}
+	dir := t.TempDir()
+	if err := sess.ExportJSON(filepath.Join(dir, "a.json")); err == nil {
+		t.Error("expected error exporting an empty session to JSON")
+	}
+	if err := sess.ExportCSV(filepath.Join(dir, "a.csv")); err == nil {
+		t.Error("expected error exporting an empty session to CSV")
+	}
+}
@@ -0,0 +1,81 @@
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+)
+
+// pcapngMagic is the block type that starts every pcapng file
+var pcapngMagic = []byte{0x0a, 0x0d, 0x0d, 0x0a}
+
+// packetReader is implemented by both pcapgo.Reader and pcapgo.NgReader
+type packetReader interface {
+	gopacket.PacketDataSource
+	LinkType() layers.LinkType
+}
+
+// OpenPCAP loads a saved pcap or pcapng file into a stopped session, as if a
+// live capture had run. The session becomes the current session so it can be
+// inspected, saved and exported like any other.
+func OpenPCAP(path string) (*Session, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	r, err := newPacketReader(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	session := &Session{
+		Interface:   path,
+		LinkType:    r.LinkType(),
+		stopChan:    make(chan struct{}),
+		reassembler: NewTCPReassembler(),
+	}
+
+	source := gopacket.NewPacketSource(r, r.LinkType())
+	for {
+		packet, err := source.NextPacket()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read packet from %s: %w", path, err)
+		}
+		summary := session.parsePacket(packet)
+		session.reassembler.Assemble(packet)
+		session.addPacket(summary, packet)
+	}
+	session.reassembler.Close()
+
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	if currentSession != nil && currentSession.IsRunning() {
+		return nil, fmt.Errorf("capture session already running on %s", currentSession.Interface)
+	}
+	currentSession = session
+
+	return session, nil
+}
+
+// newPacketReader picks a pcap or pcapng reader based on the file magic
+func newPacketReader(r *bufio.Reader) (packetReader, error) {
+	magic, err := r.Peek(len(pcapngMagic))
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(magic, pcapngMagic) {
+		return pcapgo.NewNgReader(r, pcapgo.DefaultNgReaderOptions)
+	}
+	return pcapgo.NewReader(r)
+}
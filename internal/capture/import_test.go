@@ -0,0 +1,72 @@
+package capture
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenPCAP(t *testing.T) {
+	sess, err := OpenPCAP(filepath.Join("testdata", "mixed.pcap"))
+	if err != nil {
+		t.Fatalf("OpenPCAP: %v", err)
+	}
+	defer func() { currentSession = nil }()
+
+	if sess.IsRunning() {
+		t.Error("imported session should not be running")
+	}
+	if GetCurrentSession() != sess {
+		t.Error("imported session should become the current session")
+	}
+	if n := sess.GetPacketCount(); n != 7 {
+		t.Errorf("GetPacketCount() = %d, want 7", n)
+	}
+
+	want := Stats{TCP: 1, UDP: 1, ICMP: 1, ARP: 1, DNS: 1, TLS: 1, HTTP: 1}
+	if got, err := sess.GetStats(); err != nil || got != want {
+		t.Errorf("GetStats() = %+v, %v, want %+v", got, err, want)
+	}
+
+	packets := sess.GetPackets()
+	if packets[0].DestPort != "22" || packets[6].Protocol != "ARP" {
+		t.Errorf("packets out of order: first=%+v last=%+v", packets[0], packets[6])
+	}
+	if len(sess.TopTalkers(1)) != 1 {
+		t.Error("expected talkers from imported packets")
+	}
+
+	// Stopping an imported session is a no-op
+	sess.Stop()
+}
+
+func TestOpenPCAPNGRoundTrip(t *testing.T) {
+	sess, err := OpenPCAP(filepath.Join("testdata", "mixed.pcap"))
+	if err != nil {
+		t.Fatalf("OpenPCAP: %v", err)
+	}
+	defer func() { currentSession = nil }()
+
+	path := filepath.Join(t.TempDir(), "roundtrip.pcapng")
+	if err := sess.SaveToPCAP(path); err != nil {
+		t.Fatalf("SaveToPCAP: %v", err)
+	}
+
+	reopened, err := OpenPCAP(path)
+	if err != nil {
+		t.Fatalf("OpenPCAP(pcapng): %v", err)
+	}
+	reopenedStats, _ := reopened.GetStats()
+	if origStats, _ := sess.GetStats(); reopened.GetPacketCount() != 7 || reopenedStats != origStats {
+		t.Errorf("pcapng round trip changed the capture: %d packets, stats %+v",
+			reopened.GetPacketCount(), reopenedStats)
+	}
+}
+
+func TestOpenPCAPErrors(t *testing.T) {
+	if _, err := OpenPCAP(filepath.Join("testdata", "missing.pcap")); err == nil {
+		t.Error("expected error for a missing file")
+	}
+	if _, err := OpenPCAP(filepath.Join("testdata")); err == nil {
+		t.Error("expected error for a directory")
+	}
+}
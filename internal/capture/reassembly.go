@@ -0,0 +1,226 @@
+package capture
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/tcpassembly"
+	"github.com/google/gopacket/tcpassembly/tcpreader"
+)
+
+const (
+	// maxHTTPConnections bounds how many connections keep conversation state;
+	// the oldest is forgotten first
+	maxHTTPConnections = 1000
+	// streamFlushInterval is how often idle streams are checked for
+	streamFlushInterval = 30 * time.Second
+	// streamIdleTimeout is how long a stream may go without data before it is
+	// flushed and closed
+	streamIdleTimeout = 2 * time.Minute
+)
+
+// httpPort is the only port whose streams are parsed as HTTP
+var httpPort = layers.NewTCPPortEndpoint(80)
+
+// HTTPConversation is an HTTP request and, once seen, its response
+type HTTPConversation struct {
+	Client      string // client ip:port
+	Server      string // server ip:port
+	RequestLine string // e.g. "GET /index.html HTTP/1.1"; empty if the request was missed
+	Host        string
+	StatusCode  int // 0 until a response is seen
+	ContentType string
+}
+
+// httpConnection collects the messages parsed from both directions of one
+// TCP connection; requests and responses are paired by position
+type httpConnection struct {
+	client, server string
+	requests       []HTTPConversation // request fields only
+	responses      []HTTPConversation // response fields only
+}
+
+// TCPReassembler reconstructs TCP streams from captured packets and extracts
+// HTTP conversations from port 80 traffic
+type TCPReassembler struct {
+	assembler *tcpassembly.Assembler
+	lastFlush time.Time
+	wg        sync.WaitGroup
+
+	mu    sync.Mutex
+	conns map[string]*httpConnection
+	order []string // connection keys, oldest first
+}
+
+// NewTCPReassembler creates an empty reassembler
+func NewTCPReassembler() *TCPReassembler {
+	r := &TCPReassembler{conns: make(map[string]*httpConnection)}
+	r.assembler = tcpassembly.NewAssembler(tcpassembly.NewStreamPool(&httpStreamFactory{r: r}))
+	// Bound memory held for out-of-order segments
+	r.assembler.MaxBufferedPagesPerConnection = 16
+	r.assembler.MaxBufferedPagesTotal = 1024
+	return r
+}
+
+// Assemble feeds a packet into reassembly; non-HTTP packets are ignored.
+// It must not be called concurrently.
+func (r *TCPReassembler) Assemble(packet gopacket.Packet) {
+	tcpLayer := packet.Layer(layers.LayerTypeTCP)
+	if tcpLayer == nil || packet.NetworkLayer() == nil {
+		return
+	}
+	tcp := tcpLayer.(*layers.TCP)
+	transport := tcp.TransportFlow()
+	if transport.Src() != httpPort && transport.Dst() != httpPort {
+		return
+	}
+
+	ts := packet.Metadata().Timestamp
+	r.assembler.AssembleWithTimestamp(packet.NetworkLayer().NetworkFlow(), tcp, ts)
+
+	if r.lastFlush.IsZero() {
+		r.lastFlush = ts
+	} else if ts.Sub(r.lastFlush) > streamFlushInterval {
+		r.assembler.FlushOlderThan(ts.Add(-streamIdleTimeout))
+		r.lastFlush = ts
+	}
+}
+
+// Close flushes all streams and waits for pending HTTP parsing to finish
+func (r *TCPReassembler) Close() {
+	r.assembler.FlushAll()
+	r.wg.Wait()
+}
+
+// Conversations returns the HTTP conversations seen so far, oldest connection first
+func (r *TCPReassembler) Conversations() []HTTPConversation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var convs []HTTPConversation
+	for _, key := range r.order {
+		c := r.conns[key]
+		n := len(c.requests)
+		if len(c.responses) > n {
+			n = len(c.responses)
+		}
+		for i := 0; i < n; i++ {
+			conv := HTTPConversation{Client: c.client, Server: c.server}
+			if i < len(c.requests) {
+				conv.RequestLine = c.requests[i].RequestLine
+				conv.Host = c.requests[i].Host
+			}
+			if i < len(c.responses) {
+				conv.StatusCode = c.responses[i].StatusCode
+				conv.ContentType = c.responses[i].ContentType
+			}
+			convs = append(convs, conv)
+		}
+	}
+	return convs
+}
+
+// connection returns the state for a connection, creating it if needed.
+// Caller must hold r.mu.
+func (r *TCPReassembler) connection(client, server string) *httpConnection {
+	key := client + ">" + server
+	if c, ok := r.conns[key]; ok {
+		return c
+	}
+	if len(r.order) >= maxHTTPConnections {
+		delete(r.conns, r.order[0])
+		r.order = r.order[1:]
+	}
+	c := &httpConnection{client: client, server: server}
+	r.conns[key] = c
+	r.order = append(r.order, key)
+	return c
+}
+
+func (r *TCPReassembler) addRequest(client, server string, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := r.connection(client, server)
+	c.requests = append(c.requests, HTTPConversation{
+		RequestLine: fmt.Sprintf("%s %s %s", req.Method, req.RequestURI, req.Proto),
+		Host:        req.Host,
+	})
+}
+
+func (r *TCPReassembler) addResponse(client, server string, resp *http.Response) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := r.connection(client, server)
+	c.responses = append(c.responses, HTTPConversation{
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+	})
+}
+
+// httpStreamFactory starts an HTTP parser for each new stream
+type httpStreamFactory struct {
+	r *TCPReassembler
+}
+
+func (f *httpStreamFactory) New(netFlow, tcpFlow gopacket.Flow) tcpassembly.Stream {
+	stream := tcpreader.NewReaderStream()
+	src := endpointAddr(netFlow.Src(), tcpFlow.Src())
+	dst := endpointAddr(netFlow.Dst(), tcpFlow.Dst())
+
+	f.r.wg.Add(1)
+	go func() {
+		defer f.r.wg.Done()
+		buf := bufio.NewReader(&stream)
+		if tcpFlow.Dst() == httpPort {
+			f.r.readRequests(buf, src, dst)
+		} else {
+			f.r.readResponses(buf, dst, src)
+		}
+		// The assembler blocks until a stream is fully read
+		tcpreader.DiscardBytesToEOF(buf)
+	}()
+
+	return &stream
+}
+
+// readRequests parses client-to-server HTTP messages until the stream ends
+// or stops looking like HTTP
+func (r *TCPReassembler) readRequests(buf *bufio.Reader, client, server string) {
+	for {
+		req, err := http.ReadRequest(buf)
+		if err != nil {
+			return
+		}
+		io.Copy(io.Discard, req.Body)
+		req.Body.Close()
+		r.addRequest(client, server, req)
+	}
+}
+
+// readResponses parses server-to-client HTTP messages until the stream ends
+// or stops looking like HTTP
+func (r *TCPReassembler) readResponses(buf *bufio.Reader, client, server string) {
+	for {
+		resp, err := http.ReadResponse(buf, nil)
+		if err != nil {
+			return
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		r.addResponse(client, server, resp)
+	}
+}
+
+// endpointAddr formats a network and port endpoint pair as ip:port
+func endpointAddr(host, port gopacket.Endpoint) string {
+	if host.EndpointType() == layers.EndpointIPv6 {
+		return fmt.Sprintf("[%s]:%s", host, port)
+	}
+	return fmt.Sprintf("%s:%s", host, port)
+}
@@ -0,0 +1,92 @@
+package capture
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+var (
+	testClientIP = net.IP{192, 168, 1, 10}
+	testServerIP = net.IP{93, 184, 216, 34}
+)
+
+// tcpSegment builds an Ethernet/IPv4/TCP packet between the test client and server
+func tcpSegment(t *testing.T, fromClient bool, clientPort, serverPort layers.TCPPort, tcp layers.TCP, payload string) gopacket.Packet {
+	t.Helper()
+	ip := &layers.IPv4{Version: 4, TTL: 64, Protocol: layers.IPProtocolTCP, SrcIP: testClientIP, DstIP: testServerIP}
+	tcp.SrcPort, tcp.DstPort = clientPort, serverPort
+	if !fromClient {
+		ip.SrcIP, ip.DstIP = ip.DstIP, ip.SrcIP
+		tcp.SrcPort, tcp.DstPort = serverPort, clientPort
+	}
+	tcp.Window = 65535
+	tcp.SetNetworkLayerForChecksum(ip)
+
+	eth := &layers.Ethernet{SrcMAC: net.HardwareAddr{0, 1, 2, 3, 4, 5}, DstMAC: net.HardwareAddr{0, 1, 2, 3, 4, 6}, EthernetType: layers.EthernetTypeIPv4}
+	var data []byte
+	if payload != "" {
+		data = []byte(payload)
+	}
+	return buildPacket(t, data, eth, ip, &tcp)
+}
+
+func TestTCPReassemblerHTTP(t *testing.T) {
+	const (
+		req1  = "GET /hotspot-detect.html HTTP/1.1\r\nHost: captive.apple.com\r\n\r\n"
+		resp1 = "HTTP/1.1 302 Found\r\nLocation: http://portal.example/\r\nContent-Type: text/html\r\nContent-Length: 5\r\n\r\nmoved"
+		req2  = "POST /login HTTP/1.1\r\nHost: portal.example\r\nContent-Length: 4\r\n\r\nuser"
+		// The second request's response is split across two segments
+		resp2a = "HTTP/1.1 200 OK\r\nContent-Type: application/json\r\nTransfer-Encoding: chunked\r\n\r\n"
+		resp2b = "2\r\n{}\r\n0\r\n\r\n"
+	)
+	const clientPort, serverPort = 50000, 80
+	cSeq, sSeq := uint32(1000), uint32(5000)
+
+	packets := []gopacket.Packet{
+		tcpSegment(t, true, clientPort, serverPort, layers.TCP{Seq: cSeq, SYN: true}, ""),
+		tcpSegment(t, false, clientPort, serverPort, layers.TCP{Seq: sSeq, Ack: cSeq + 1, SYN: true, ACK: true}, ""),
+		tcpSegment(t, true, clientPort, serverPort, layers.TCP{Seq: cSeq + 1, ACK: true}, req1),
+		tcpSegment(t, false, clientPort, serverPort, layers.TCP{Seq: sSeq + 1, ACK: true}, resp1),
+		tcpSegment(t, true, clientPort, serverPort, layers.TCP{Seq: cSeq + 1 + uint32(len(req1)), ACK: true}, req2),
+		tcpSegment(t, false, clientPort, serverPort, layers.TCP{Seq: sSeq + 1 + uint32(len(resp1)), ACK: true}, resp2a),
+		tcpSegment(t, false, clientPort, serverPort, layers.TCP{Seq: sSeq + 1 + uint32(len(resp1)+len(resp2a)), ACK: true}, resp2b),
+		// Non-HTTP traffic is ignored
+		tcpSegment(t, true, 50001, 443, layers.TCP{Seq: 1, SYN: true}, ""),
+		tcpSegment(t, true, 50001, 443, layers.TCP{Seq: 2, ACK: true}, "GET / HTTP/1.1\r\nHost: tls.example\r\n\r\n"),
+	}
+
+	r := NewTCPReassembler()
+	for _, p := range packets {
+		r.Assemble(p)
+	}
+	r.Close()
+
+	want := []HTTPConversation{
+		{Client: "192.168.1.10:50000", Server: "93.184.216.34:80", RequestLine: "GET /hotspot-detect.html HTTP/1.1", Host: "captive.apple.com", StatusCode: 302, ContentType: "text/html"},
+		{Client: "192.168.1.10:50000", Server: "93.184.216.34:80", RequestLine: "POST /login HTTP/1.1", Host: "portal.example", StatusCode: 200, ContentType: "application/json"},
+	}
+	if got := r.Conversations(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Conversations() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestTCPReassemblerIgnoresNonHTTP(t *testing.T) {
+	r := NewTCPReassembler()
+	r.Assemble(tcpSegment(t, true, 50000, 80, layers.TCP{Seq: 1, SYN: true}, ""))
+	r.Assemble(tcpSegment(t, true, 50000, 80, layers.TCP{Seq: 2, ACK: true}, "\x16\x03\x01\x00\x05hello"))
+	r.Close()
+
+	if got := r.Conversations(); len(got) != 0 {
+		t.Errorf("expected no conversations for non-HTTP payload, got %+v", got)
+	}
+}
+
+func TestSessionGetConversationsWithoutCapture(t *testing.T) {
+	if got := (&Session{}).GetConversations(); got != nil {
+		t.Errorf("expected nil conversations, got %+v", got)
+	}
+}
@@ -0,0 +1,212 @@
+package capture
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/alexpitcher/LanAudit/internal/consent"
+	"github.com/alexpitcher/LanAudit/internal/logging"
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcap"
+)
+
+// RogueDHCPAction is the consent log action recorded for each rogue offer
+const RogueDHCPAction = "ROGUE_DHCP"
+
+// maxRogueEvents is how many rogue offers a detector keeps for display
+const maxRogueEvents = 100
+
+// RogueEvent is a DHCP offer from a server other than the legitimate one
+type RogueEvent struct {
+	ServerIP  string
+	ServerMAC string // source of the offer frame, to find the device's port
+	OfferedIP string
+	Timestamp time.Time
+}
+
+// RogueDHCPDetector watches for DHCP offers from unexpected servers
+type RogueDHCPDetector struct {
+	Interface        string
+	LegitimateServer string
+	handle           *pcap.Handle
+	mu               sync.Mutex
+	offers           int          // offers seen, legitimate or not
+	rogues           []RogueEvent // most recent last
+	events           chan RogueEvent
+	stopChan         chan struct{}
+	running          bool
+}
+
+// StartRogueDHCPDetector captures DHCP traffic on iface and reports offers
+// whose server is not legitimateServer on the Events channel and in the
+// consent log. Requires sudo/root privileges
+func StartRogueDHCPDetector(iface string, legitimateServer string) (*RogueDHCPDetector, error) {
+	if ip := net.ParseIP(legitimateServer); ip == nil || ip.To4() == nil {
+		return nil, fmt.Errorf("legitimate DHCP server %q is not an IPv4 address", legitimateServer)
+	}
+
+	handle, err := pcap.OpenLive(iface, 1600, true, pcap.BlockForever)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open interface %s: %w (requires sudo/root)", iface, err)
+	}
+	if err := handle.SetBPFFilter("udp port 67"); err != nil {
+		handle.Close()
+		return nil, fmt.Errorf("failed to set DHCP filter: %w", err)
+	}
+
+	d := newRogueDHCPDetector(iface, legitimateServer)
+	d.handle = handle
+	d.running = true
+
+	go d.loop(gopacket.NewPacketSource(handle, handle.LinkType()).Packets())
+
+	return d, nil
+}
+
+func newRogueDHCPDetector(iface, legitimateServer string) *RogueDHCPDetector {
+	return &RogueDHCPDetector{
+		Interface:        iface,
+		LegitimateServer: net.ParseIP(legitimateServer).String(),
+		events:           make(chan RogueEvent, 16),
+		stopChan:         make(chan struct{}),
+	}
+}
+
+// loop processes packets until the detector is stopped
+func (d *RogueDHCPDetector) loop(packets <-chan gopacket.Packet) {
+	defer close(d.events)
+	for {
+		select {
+		case <-d.stopChan:
+			return
+		case packet, ok := <-packets:
+			if !ok {
+				d.Stop()
+				return
+			}
+			ev, rogue := d.handlePacket(packet)
+			if !rogue {
+				continue
+			}
+			logging.Warnf("rogue DHCP server %s (%s) offered %s on %s", ev.ServerIP, ev.ServerMAC, ev.OfferedIP, d.Interface)
+			if err := consent.Log(RogueDHCPAction, map[string]string{
+				"iface":      d.Interface,
+				"server":     ev.ServerIP,
+				"server_mac": ev.ServerMAC,
+				"offered":    ev.OfferedIP,
+			}); err != nil {
+				logging.Warnf("failed to record rogue DHCP offer: %v", err)
+			}
+			// Never block capture on a slow consumer
+			select {
+			case d.events <- ev:
+			default:
+			}
+		}
+	}
+}
+
+// handlePacket checks a DHCP offer's server against the legitimate one
+func (d *RogueDHCPDetector) handlePacket(packet gopacket.Packet) (RogueEvent, bool) {
+	dhcpLayer := packet.Layer(layers.LayerTypeDHCPv4)
+	if dhcpLayer == nil {
+		return RogueEvent{}, false
+	}
+	dhcp := dhcpLayer.(*layers.DHCPv4)
+	if dhcp.Operation != layers.DHCPOpReply || dhcpMessageType(dhcp) != layers.DHCPMsgTypeOffer {
+		return RogueEvent{}, false
+	}
+
+	ev := RogueEvent{
+		ServerIP:  dhcpServer(packet, dhcp),
+		OfferedIP: dhcp.YourClientIP.String(),
+		Timestamp: packet.Metadata().Timestamp,
+	}
+	if eth := packet.Layer(layers.LayerTypeEthernet); eth != nil {
+		ev.ServerMAC = eth.(*layers.Ethernet).SrcMAC.String()
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.offers++
+	if ev.ServerIP == d.LegitimateServer {
+		return RogueEvent{}, false
+	}
+	d.rogues = append(d.rogues, ev)
+	if len(d.rogues) > maxRogueEvents {
+		d.rogues = d.rogues[len(d.rogues)-maxRogueEvents:]
+	}
+	return ev, true
+}
+
+// dhcpMessageType returns the DHCP message type option, or 0 if missing
+func dhcpMessageType(dhcp *layers.DHCPv4) layers.DHCPMsgType {
+	for _, opt := range dhcp.Options {
+		if opt.Type == layers.DHCPOptMessageType && len(opt.Data) == 1 {
+			return layers.DHCPMsgType(opt.Data[0])
+		}
+	}
+	return 0
+}
+
+// dhcpServer identifies the server behind an offer: the server identifier
+// option when present, else siaddr, else the IP source address
+func dhcpServer(packet gopacket.Packet, dhcp *layers.DHCPv4) string {
+	for _, opt := range dhcp.Options {
+		if opt.Type == layers.DHCPOptServerID && len(opt.Data) == 4 {
+			return net.IP(opt.Data).String()
+		}
+	}
+	if dhcp.NextServerIP != nil && !dhcp.NextServerIP.IsUnspecified() {
+		return dhcp.NextServerIP.String()
+	}
+	if ip4 := packet.Layer(layers.LayerTypeIPv4); ip4 != nil {
+		return ip4.(*layers.IPv4).SrcIP.String()
+	}
+	return ""
+}
+
+// Events delivers rogue offers as they are seen; it is closed when the
+// detector stops
+func (d *RogueDHCPDetector) Events() <-chan RogueEvent {
+	return d.events
+}
+
+// Rogues returns the rogue offers seen so far, oldest first
+func (d *RogueDHCPDetector) Rogues() []RogueEvent {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]RogueEvent(nil), d.rogues...)
+}
+
+// Offers returns how many DHCP offers have been checked
+func (d *RogueDHCPDetector) Offers() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.offers
+}
+
+// Stop halts the detector; rogue offers seen remain available
+func (d *RogueDHCPDetector) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.running {
+		return
+	}
+	d.running = false
+	close(d.stopChan)
+	if d.handle != nil {
+		d.handle.Close()
+	}
+}
+
+// IsRunning returns whether the detector is currently capturing
+func (d *RogueDHCPDetector) IsRunning() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.running
+}
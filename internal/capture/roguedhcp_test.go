@@ -0,0 +1,129 @@
+package capture
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// dhcpFrame fabricates a DHCP server-to-client frame
+func dhcpFrame(t *testing.T, msgType layers.DHCPMsgType, serverMAC, serverIP, serverID, offered string, ts time.Time) gopacket.Packet {
+	t.Helper()
+	eth := &layers.Ethernet{
+		SrcMAC:       mustMAC(t, serverMAC),
+		DstMAC:       net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		EthernetType: layers.EthernetTypeIPv4,
+	}
+	ip := &layers.IPv4{
+		Version:  4,
+		TTL:      64,
+		Protocol: layers.IPProtocolUDP,
+		SrcIP:    net.ParseIP(serverIP).To4(),
+		DstIP:    net.IPv4bcast.To4(),
+	}
+	udp := &layers.UDP{SrcPort: 67, DstPort: 68}
+	udp.SetNetworkLayerForChecksum(ip)
+	dhcp := &layers.DHCPv4{
+		Operation:    layers.DHCPOpReply,
+		HardwareType: layers.LinkTypeEthernet,
+		HardwareLen:  6,
+		Xid:          0x1234,
+		YourClientIP: net.ParseIP(offered).To4(),
+		ClientHWAddr: mustMAC(t, "00:11:22:33:44:55"),
+		Options: layers.DHCPOptions{
+			layers.NewDHCPOption(layers.DHCPOptMessageType, []byte{byte(msgType)}),
+		},
+	}
+	if serverID != "" {
+		dhcp.Options = append(dhcp.Options, layers.NewDHCPOption(layers.DHCPOptServerID, net.ParseIP(serverID).To4()))
+	}
+	dhcp.Options = append(dhcp.Options, layers.NewDHCPOption(layers.DHCPOptEnd, nil))
+
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buf, opts, eth, ip, udp, dhcp); err != nil {
+		t.Fatalf("failed to build DHCP frame: %v", err)
+	}
+	packet := gopacket.NewPacket(buf.Bytes(), layers.LayerTypeEthernet, gopacket.Default)
+	packet.Metadata().Timestamp = ts
+	return packet
+}
+
+func mustMAC(t *testing.T, s string) net.HardwareAddr {
+	t.Helper()
+	mac, err := net.ParseMAC(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mac
+}
+
+func TestRogueDHCPDetector(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ts := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	feed := make(chan gopacket.Packet, 8)
+	feed <- dhcpFrame(t, layers.DHCPMsgTypeOffer, "aa:aa:aa:00:00:01", "192.168.1.1", "192.168.1.1", "192.168.1.50", ts)
+	feed <- dhcpFrame(t, layers.DHCPMsgTypeOffer, "bb:bb:bb:00:00:02", "10.0.0.1", "10.0.0.1", "10.0.0.23", ts.Add(time.Second))
+	// An ACK from the rogue is not an offer
+	feed <- dhcpFrame(t, layers.DHCPMsgTypeAck, "bb:bb:bb:00:00:02", "10.0.0.1", "10.0.0.1", "10.0.0.23", ts.Add(2*time.Second))
+	// A relayed offer names the real server in its server identifier
+	feed <- dhcpFrame(t, layers.DHCPMsgTypeOffer, "cc:cc:cc:00:00:03", "192.168.1.254", "192.168.1.1", "192.168.1.51", ts.Add(3*time.Second))
+	// Without a server identifier the IP source is used
+	feed <- dhcpFrame(t, layers.DHCPMsgTypeOffer, "dd:dd:dd:00:00:04", "192.168.1.99", "", "192.168.1.52", ts.Add(4*time.Second))
+	close(feed)
+
+	d := newRogueDHCPDetector("en0", "192.168.1.1")
+	d.loop(feed)
+
+	var events []RogueEvent
+	for ev := range d.Events() {
+		events = append(events, ev)
+	}
+	want := []RogueEvent{
+		{ServerIP: "10.0.0.1", ServerMAC: "bb:bb:bb:00:00:02", OfferedIP: "10.0.0.23", Timestamp: ts.Add(time.Second)},
+		{ServerIP: "192.168.1.99", ServerMAC: "dd:dd:dd:00:00:04", OfferedIP: "192.168.1.52", Timestamp: ts.Add(4 * time.Second)},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(events), len(want), events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, events[i], want[i])
+		}
+	}
+	if got := d.Rogues(); len(got) != 2 {
+		t.Errorf("Rogues() = %+v, want 2 offers", got)
+	}
+	if got := d.Offers(); got != 4 {
+		t.Errorf("Offers() = %d, want 4", got)
+	}
+
+	data, err := os.ReadFile(filepath.Join(os.Getenv("HOME"), ".lanaudit", "consent.log"))
+	if err != nil {
+		t.Fatalf("consent log not written: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("consent log has %d lines, want 2:\n%s", len(lines), data)
+	}
+	for _, want := range []string{RogueDHCPAction, "server=10.0.0.1", "offered=10.0.0.23", "iface=en0"} {
+		if !strings.Contains(lines[0], want) {
+			t.Errorf("consent log line %q missing %q", lines[0], want)
+		}
+	}
+}
+
+func TestStartRogueDHCPDetectorRejectsServer(t *testing.T) {
+	for _, server := range []string{"", "dhcp.example.com", "2001:db8::1"} {
+		if _, err := StartRogueDHCPDetector("en0", server); err == nil {
+			t.Errorf("StartRogueDHCPDetector with server %q should fail", server)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package capture
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// Stats is a per-protocol breakdown of captured packets. Each packet is
+// counted once, under its most specific protocol. KernelDropped and
+// InterfaceDropped count packets lost before they reached the capture.
+type Stats struct {
+	TCP   uint64 `json:"tcp"`
+	UDP   uint64 `json:"udp"`
+	ICMP  uint64 `json:"icmp"`
+	ARP   uint64 `json:"arp"`
+	DNS   uint64 `json:"dns"`
+	TLS   uint64 `json:"tls"`
+	HTTP  uint64 `json:"http"`
+	Other uint64 `json:"other"`
+
+	KernelDropped    uint64 `json:"kernel_dropped"`
+	InterfaceDropped uint64 `json:"interface_dropped"`
+}
+
+// Total returns the number of packets counted
+func (s Stats) Total() uint64 {
+	return s.TCP + s.UDP + s.ICMP + s.ARP + s.DNS + s.TLS + s.HTTP + s.Other
+}
+
+// Dropped returns the packets lost by the kernel and the interface
+func (s Stats) Dropped() uint64 {
+	return s.KernelDropped + s.InterfaceDropped
+}
+
+// String returns a compact one-line breakdown, ending with the drop count
+// when packets were dropped
+func (s Stats) String() string {
+	out := fmt.Sprintf("TCP=%d UDP=%d ICMP=%d ARP=%d DNS=%d TLS=%d HTTP=%d Other=%d",
+		s.TCP, s.UDP, s.ICMP, s.ARP, s.DNS, s.TLS, s.HTTP, s.Other)
+	if s.Dropped() > 0 {
+		out += fmt.Sprintf(" Dropped=%d", s.Dropped())
+	}
+	return out
+}
+
+// protocolCounters holds the live counters behind Stats
+type protocolCounters struct {
+	tcp, udp, icmp, arp, dns, tls, http, other atomic.Uint64
+}
+
+func (c *protocolCounters) snapshot() Stats {
+	return Stats{
+		TCP:   c.tcp.Load(),
+		UDP:   c.udp.Load(),
+		ICMP:  c.icmp.Load(),
+		ARP:   c.arp.Load(),
+		DNS:   c.dns.Load(),
+		TLS:   c.tls.Load(),
+		HTTP:  c.http.Load(),
+		Other: c.other.Load(),
+	}
+}
+
+// count classifies a parsed packet and bumps the matching counter
+func (c *protocolCounters) count(packet gopacket.Packet, summary PacketSummary) {
+	hasPayload := packet.ApplicationLayer() != nil
+	onPort := func(port string) bool {
+		return summary.SourcePort == port || summary.DestPort == port
+	}
+
+	switch {
+	case packet.Layer(layers.LayerTypeARP) != nil:
+		c.arp.Add(1)
+	case onPort("53"):
+		c.dns.Add(1)
+	case summary.Protocol == "TCP" && onPort("443") && hasPayload:
+		c.tls.Add(1)
+	case summary.Protocol == "TCP" && onPort("80") && hasPayload:
+		c.http.Add(1)
+	case summary.Protocol == "TCP":
+		c.tcp.Add(1)
+	case summary.Protocol == "UDP":
+		c.udp.Add(1)
+	case summary.Protocol == "ICMP":
+		c.icmp.Add(1)
+	default:
+		c.other.Add(1)
+	}
+}
+
+// LengthBuckets are the LengthHistogram keys, smallest first
+var LengthBuckets = []string{"0-64", "65-128", "129-256", "257-512", "513-1024", "1025-1500", ">1500"}
+
+// lengthBucketMax is the largest length in each bucket but the last
+var lengthBucketMax = []int{64, 128, 256, 512, 1024, 1500}
+
+// lengthBucket returns the index into LengthBuckets for a packet length
+func lengthBucket(length int) int {
+	for i, max := range lengthBucketMax {
+		if length <= max {
+			return i
+		}
+	}
+	return len(lengthBucketMax)
+}
+
+// lengthCounters holds the live counters behind LengthHistogram
+type lengthCounters [7]atomic.Uint64
+
+func (c *lengthCounters) count(length int) {
+	c[lengthBucket(length)].Add(1)
+}
+
+func (c *lengthCounters) snapshot() map[string]int {
+	hist := make(map[string]int, len(LengthBuckets))
+	for i, name := range LengthBuckets {
+		hist[name] = int(c[i].Load())
+	}
+	return hist
+}
@@ -0,0 +1,200 @@
+package capture
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcap"
+)
+
+// buildPacket serializes layers into a decoded Ethernet packet
+func buildPacket(t *testing.T, payload []byte, ls ...gopacket.SerializableLayer) gopacket.Packet {
+	t.Helper()
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if payload != nil {
+		ls = append(ls, gopacket.Payload(payload))
+	}
+	if err := gopacket.SerializeLayers(buf, opts, ls...); err != nil {
+		t.Fatalf("SerializeLayers: %v", err)
+	}
+	p := gopacket.NewPacket(buf.Bytes(), layers.LayerTypeEthernet, gopacket.Default)
+	p.Metadata().CaptureInfo = gopacket.CaptureInfo{Timestamp: time.Unix(1700000000, 0), CaptureLength: len(buf.Bytes()), Length: len(buf.Bytes())}
+	return p
+}
+
+func ipv4Packet(t *testing.T, proto layers.IPProtocol, transport gopacket.SerializableLayer, payload []byte) gopacket.Packet {
+	t.Helper()
+	eth := &layers.Ethernet{SrcMAC: net.HardwareAddr{0, 1, 2, 3, 4, 5}, DstMAC: net.HardwareAddr{0, 1, 2, 3, 4, 6}, EthernetType: layers.EthernetTypeIPv4}
+	ip := &layers.IPv4{Version: 4, TTL: 64, Protocol: proto, SrcIP: net.IP{192, 168, 1, 10}, DstIP: net.IP{192, 168, 1, 1}}
+	if nl, ok := transport.(interface {
+		SetNetworkLayerForChecksum(gopacket.NetworkLayer) error
+	}); ok {
+		nl.SetNetworkLayerForChecksum(ip)
+	}
+	return buildPacket(t, payload, eth, ip, transport)
+}
+
+func testPackets(t *testing.T) []gopacket.Packet {
+	eth := &layers.Ethernet{SrcMAC: net.HardwareAddr{0, 1, 2, 3, 4, 5}, DstMAC: layers.EthernetBroadcast, EthernetType: layers.EthernetTypeARP}
+	arp := &layers.ARP{
+		AddrType: layers.LinkTypeEthernet, Protocol: layers.EthernetTypeIPv4, HwAddressSize: 6, ProtAddressSize: 4,
+		Operation: layers.ARPRequest, SourceHwAddress: []byte{0, 1, 2, 3, 4, 5}, SourceProtAddress: []byte{192, 168, 1, 10},
+		DstHwAddress: []byte{0, 0, 0, 0, 0, 0}, DstProtAddress: []byte{192, 168, 1, 1},
+	}
+
+	return []gopacket.Packet{
+		ipv4Packet(t, layers.IPProtocolTCP, &layers.TCP{SrcPort: 50000, DstPort: 22, SYN: true}, nil),
+		ipv4Packet(t, layers.IPProtocolTCP, &layers.TCP{SrcPort: 50001, DstPort: 443, ACK: true}, []byte("\x16\x03\x01")),
+		ipv4Packet(t, layers.IPProtocolTCP, &layers.TCP{SrcPort: 50002, DstPort: 80, ACK: true}, []byte("GET / HTTP/1.1\r\n")),
+		ipv4Packet(t, layers.IPProtocolUDP, &layers.UDP{SrcPort: 50003, DstPort: 53}, []byte{0x12, 0x34}),
+		ipv4Packet(t, layers.IPProtocolUDP, &layers.UDP{SrcPort: 50004, DstPort: 5353}, []byte{1}),
+		ipv4Packet(t, layers.IPProtocolICMPv4, &layers.ICMPv4{TypeCode: layers.CreateICMPv4TypeCode(8, 0)}, nil),
+		buildPacket(t, nil, eth, arp),
+	}
+}
+
+func TestParsePacketStats(t *testing.T) {
+	sess := &Session{}
+	for _, p := range testPackets(t) {
+		sess.parsePacket(p)
+	}
+
+	want := Stats{TCP: 1, UDP: 1, ICMP: 1, ARP: 1, DNS: 1, TLS: 1, HTTP: 1}
+	got, err := sess.GetStats()
+	if err != nil || got != want {
+		t.Errorf("GetStats() = %+v, %v, want %+v", got, err, want)
+	}
+	if total := got.Total(); total != 7 {
+		t.Errorf("Total() = %d, want 7", total)
+	}
+}
+
+// fakeStatsReader stands in for a *pcap.Handle
+type fakeStatsReader struct {
+	stats pcap.Stats
+	err   error
+}
+
+func (f *fakeStatsReader) Stats() (*pcap.Stats, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	st := f.stats
+	return &st, nil
+}
+
+func TestGetStatsDropCounts(t *testing.T) {
+	reader := &fakeStatsReader{stats: pcap.Stats{PacketsReceived: 100, PacketsDropped: 12, PacketsIfDropped: 3}}
+	sess := &Session{running: true, stopChan: make(chan struct{}), statsReader: reader}
+	for _, p := range testPackets(t) {
+		sess.addPacket(sess.parsePacket(p), p)
+	}
+
+	got, err := sess.GetStats()
+	if err != nil {
+		t.Fatalf("GetStats() error = %v", err)
+	}
+	if got.KernelDropped != 12 || got.InterfaceDropped != 3 || got.Dropped() != 15 || got.Total() != 7 {
+		t.Errorf("GetStats() = %+v, want 12 kernel and 3 interface drops", got)
+	}
+	if !strings.HasSuffix(got.String(), " Dropped=15") {
+		t.Errorf("String() = %q, want the drop count", got.String())
+	}
+
+	// Stop keeps the last reading; the closed handle is not asked again
+	reader.stats.PacketsDropped = 20
+	sess.Stop()
+	reader.err = errors.New("handle closed")
+	if got, err := sess.GetStats(); err != nil || got.KernelDropped != 20 {
+		t.Errorf("GetStats() after Stop = %+v, %v, want 20 kernel drops", got, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "capture.json")
+	if err := sess.ExportJSON(path); err != nil {
+		t.Fatalf("ExportJSON() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc captureExport
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Stats.KernelDropped != 20 || doc.Stats.InterfaceDropped != 3 {
+		t.Errorf("exported stats = %+v, want the drop counts", doc.Stats)
+	}
+}
+
+func TestGetStatsReadError(t *testing.T) {
+	sess := &Session{running: true, statsReader: &fakeStatsReader{err: errors.New("no such device")}}
+	if _, err := sess.GetStats(); err == nil {
+		t.Error("expected the stats reader's error")
+	}
+}
+
+func TestSaveToPCAPNGIncludesStats(t *testing.T) {
+	sess := &Session{Interface: "en0", LinkType: layers.LinkTypeEthernet}
+	for _, p := range testPackets(t) {
+		sess.addPacket(sess.parsePacket(p), p)
+	}
+
+	path := filepath.Join(t.TempDir(), "capture.pcapng")
+	if err := sess.SaveToPCAP(path); err != nil {
+		t.Fatalf("SaveToPCAP() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte("Protocol stats: TCP=1 UDP=1 ICMP=1 ARP=1 DNS=1 TLS=1 HTTP=1 Other=0")) {
+		t.Error("expected protocol stats in pcapng section comment")
+	}
+
+	// Classic pcap has no comment field but should still be written
+	path = filepath.Join(t.TempDir(), "capture.pcap")
+	if err := sess.SaveToPCAP(path); err != nil {
+		t.Fatalf("SaveToPCAP() error = %v", err)
+	}
+}
+
+func TestLengthHistogram(t *testing.T) {
+	tests := []struct {
+		length int
+		bucket string
+	}{
+		{0, "0-64"}, {64, "0-64"}, {65, "65-128"}, {128, "65-128"}, {200, "129-256"},
+		{512, "257-512"}, {576, "513-1024"}, {1500, "1025-1500"}, {1501, ">1500"}, {9000, ">1500"},
+	}
+	for _, tt := range tests {
+		if got := LengthBuckets[lengthBucket(tt.length)]; got != tt.bucket {
+			t.Errorf("lengthBucket(%d) = %s, want %s", tt.length, got, tt.bucket)
+		}
+	}
+
+	sess := &Session{}
+	for _, tt := range tests {
+		sess.lengths.count(tt.length)
+	}
+	hist := sess.LengthHistogram()
+	if len(hist) != len(LengthBuckets) {
+		t.Errorf("expected every bucket to be present, got %v", hist)
+	}
+	want := map[string]int{"0-64": 2, "65-128": 2, "129-256": 1, "257-512": 1, "513-1024": 1, "1025-1500": 1, ">1500": 2}
+	for k, v := range want {
+		if hist[k] != v {
+			t.Errorf("hist[%s] = %d, want %d", k, hist[k], v)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package capture
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// TalkerSummary is the traffic seen to and from a single IP address
+type TalkerSummary struct {
+	IP            string
+	BytesSent     uint64
+	BytesReceived uint64
+	PacketCount   int // packets sent or received
+}
+
+// talkerCounters holds the live counters behind a TalkerSummary
+type talkerCounters struct {
+	sent, received atomic.Uint64
+	packets        atomic.Int64
+}
+
+// counters returns the counters for ip, creating them if needed
+func (s *Session) counters(ip string) *talkerCounters {
+	if c, ok := s.talkers.Load(ip); ok {
+		return c.(*talkerCounters)
+	}
+	c, _ := s.talkers.LoadOrStore(ip, &talkerCounters{})
+	return c.(*talkerCounters)
+}
+
+// countTalkers adds a packet to the per-IP counters of both endpoints
+func (s *Session) countTalkers(summary PacketSummary) {
+	length := uint64(summary.Length)
+	if summary.SourceIP != "" {
+		c := s.counters(summary.SourceIP)
+		c.sent.Add(length)
+		c.packets.Add(1)
+	}
+	if summary.DestIP != "" && summary.DestIP != summary.SourceIP {
+		c := s.counters(summary.DestIP)
+		c.received.Add(length)
+		c.packets.Add(1)
+	}
+}
+
+// TopTalkers returns up to n IPs with the most traffic, by total bytes
+// sent and received
+func (s *Session) TopTalkers(n int) []TalkerSummary {
+	var talkers []TalkerSummary
+	s.talkers.Range(func(key, value any) bool {
+		c := value.(*talkerCounters)
+		talkers = append(talkers, TalkerSummary{
+			IP:            key.(string),
+			BytesSent:     c.sent.Load(),
+			BytesReceived: c.received.Load(),
+			PacketCount:   int(c.packets.Load()),
+		})
+		return true
+	})
+
+	sort.Slice(talkers, func(i, j int) bool {
+		ti := talkers[i].BytesSent + talkers[i].BytesReceived
+		tj := talkers[j].BytesSent + talkers[j].BytesReceived
+		if ti != tj {
+			return ti > tj
+		}
+		return talkers[i].IP < talkers[j].IP
+	})
+	if n >= 0 && len(talkers) > n {
+		talkers = talkers[:n]
+	}
+	return talkers
+}
@@ -0,0 +1,67 @@
+package capture
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTopTalkers(t *testing.T) {
+	packets := []PacketSummary{
+		{SourceIP: "192.168.1.10", DestIP: "93.184.216.34", Length: 100},
+		{SourceIP: "93.184.216.34", DestIP: "192.168.1.10", Length: 1500},
+		{SourceIP: "93.184.216.34", DestIP: "192.168.1.10", Length: 1500},
+		{SourceIP: "192.168.1.11", DestIP: "192.168.1.1", Length: 60},
+		{SourceIP: "192.168.1.1", DestIP: "192.168.1.11", Length: 60},
+		// Packets without an IP (e.g. non-IP frames) are not attributed
+		{Length: 42},
+	}
+
+	sess := &Session{}
+	for _, p := range packets {
+		sess.countTalkers(p)
+	}
+
+	tests := []struct {
+		name string
+		n    int
+		want []TalkerSummary
+	}{
+		{
+			name: "top two",
+			n:    2,
+			want: []TalkerSummary{
+				{IP: "192.168.1.10", BytesSent: 100, BytesReceived: 3000, PacketCount: 3},
+				{IP: "93.184.216.34", BytesSent: 3000, BytesReceived: 100, PacketCount: 3},
+			},
+		},
+		{
+			name: "ties ordered by IP",
+			n:    10,
+			want: []TalkerSummary{
+				{IP: "192.168.1.10", BytesSent: 100, BytesReceived: 3000, PacketCount: 3},
+				{IP: "93.184.216.34", BytesSent: 3000, BytesReceived: 100, PacketCount: 3},
+				{IP: "192.168.1.1", BytesSent: 60, BytesReceived: 60, PacketCount: 2},
+				{IP: "192.168.1.11", BytesSent: 60, BytesReceived: 60, PacketCount: 2},
+			},
+		},
+		{
+			name: "zero",
+			n:    0,
+			want: []TalkerSummary{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sess.TopTalkers(tt.n); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TopTalkers(%d) = %+v, want %+v", tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTopTalkersEmpty(t *testing.T) {
+	if got := (&Session{}).TopTalkers(5); len(got) != 0 {
+		t.Errorf("expected no talkers, got %+v", got)
+	}
+}
@@ -0,0 +1,89 @@
+package capture
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/gopacket"
+)
+
+// DefaultTriggerMaxPackets is how many packets a triggered capture records
+// when TriggerConfig.MaxPackets is unset
+const DefaultTriggerMaxPackets = 1000
+
+// TriggerConfig starts and stops recording when packets match a pattern.
+// Patterns are matched against a one-line rendering of each packet, e.g.
+// "TCP 192.168.1.10:50000 -> 93.184.216.34:80 RST ACK".
+type TriggerConfig struct {
+	StartPattern  *regexp.Regexp // required; recording starts with the first match
+	StopPattern   *regexp.Regexp // optional; recording stops after the first match
+	PreBufferSize int            // packets kept from before the trigger
+	MaxPackets    int            // packets recorded from the trigger on
+}
+
+// StartWithTrigger captures on iface, keeping only the last PreBufferSize
+// packets until StartPattern matches. From then on packets are recorded until
+// StopPattern matches or MaxPackets have been recorded.
+// Requires sudo/root privileges
+func StartWithTrigger(iface, filter string, tc TriggerConfig) (*Session, error) {
+	if tc.StartPattern == nil {
+		return nil, fmt.Errorf("start pattern is required")
+	}
+	if tc.PreBufferSize < 0 {
+		return nil, fmt.Errorf("pre-buffer size must not be negative")
+	}
+	if tc.MaxPackets <= 0 {
+		tc.MaxPackets = DefaultTriggerMaxPackets
+	}
+
+	return startSession(iface, filter, tc.MaxPackets, func(s *Session) {
+		s.initTrigger(tc)
+	})
+}
+
+// initTrigger arms the session to wait for tc.StartPattern
+func (s *Session) initTrigger(tc TriggerConfig) {
+	s.trigger = &tc
+	if tc.PreBufferSize > 0 {
+		s.initRing(tc.PreBufferSize)
+	}
+}
+
+// startRecording moves the pre-buffer into a plain slice so every packet
+// from here on is kept. Caller must hold s.mu.
+func (s *Session) startRecording() {
+	n := s.count()
+	packets := make([]PacketSummary, n, n+s.trigger.MaxPackets)
+	raw := make([]gopacket.Packet, n, n+s.trigger.MaxPackets)
+	for i := 0; i < n; i++ {
+		packets[i] = s.Packets[s.slot(i)]
+		raw[i] = s.RawPackets[s.slot(i)]
+	}
+
+	s.ringMode = false
+	s.Packets = packets
+	s.RawPackets = raw
+	s.triggered = true
+	s.triggerStart = n
+}
+
+// IsWaitingForTrigger reports whether the session is armed but its start
+// pattern has not matched yet
+func (s *Session) IsWaitingForTrigger() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.trigger != nil && !s.triggered
+}
+
+// triggerText renders a packet summary for trigger pattern matching
+func triggerText(p PacketSummary) string {
+	addr := func(ip, port string) string {
+		if port == "" {
+			return ip
+		}
+		return ip + ":" + port
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s %s -> %s %s",
+		p.Protocol, addr(p.SourceIP, p.SourcePort), addr(p.DestIP, p.DestPort), p.Info))
+}
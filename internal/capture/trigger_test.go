@@ -0,0 +1,118 @@
+package capture
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+)
+
+func triggerPacket(i int, info string) PacketSummary {
+	return PacketSummary{
+		SourceIP: "192.168.1.10", SourcePort: fmt.Sprintf("%d", 50000+i),
+		DestIP: "93.184.216.34", DestPort: "80", Protocol: "TCP", Info: info,
+	}
+}
+
+func TestTriggerText(t *testing.T) {
+	tests := []struct {
+		p    PacketSummary
+		want string
+	}{
+		{triggerPacket(0, "RST ACK "), "TCP 192.168.1.10:50000 -> 93.184.216.34:80 RST ACK"},
+		{PacketSummary{Protocol: "ARP", SourceIP: "192.168.1.10", DestIP: "192.168.1.1"}, "ARP 192.168.1.10 -> 192.168.1.1"},
+	}
+	for _, tt := range tests {
+		if got := triggerText(tt.p); got != tt.want {
+			t.Errorf("triggerText() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestTriggerPreBufferAndStopPattern(t *testing.T) {
+	tc := TriggerConfig{
+		StartPattern:  regexp.MustCompile(`\bRST\b`),
+		StopPattern:   regexp.MustCompile(`\bFIN\b`),
+		PreBufferSize: 3,
+		MaxPackets:    50,
+	}
+	sess := &Session{}
+	sess.initTrigger(tc)
+
+	for i := 0; i < 10; i++ {
+		if sess.record(triggerPacket(i, "ACK "), nil, tc.MaxPackets) {
+			t.Fatalf("capture stopped before trigger at packet %d", i)
+		}
+	}
+	if !sess.IsWaitingForTrigger() || sess.GetPacketCount() != 3 {
+		t.Fatalf("expected 3 pre-buffered packets while waiting, got %d", sess.GetPacketCount())
+	}
+
+	if sess.record(triggerPacket(10, "RST "), nil, tc.MaxPackets) {
+		t.Fatal("trigger packet should not stop the capture")
+	}
+	if sess.IsWaitingForTrigger() || sess.IsRingMode() {
+		t.Fatal("expected recording after the start pattern matched")
+	}
+	sess.record(triggerPacket(11, "ACK "), nil, tc.MaxPackets)
+	if !sess.record(triggerPacket(12, "FIN ACK "), nil, tc.MaxPackets) {
+		t.Fatal("expected stop pattern to end the capture")
+	}
+
+	packets := sess.GetPackets()
+	var ports []string
+	for _, p := range packets {
+		ports = append(ports, p.SourcePort)
+	}
+	want := []string{"50007", "50008", "50009", "50010", "50011", "50012"}
+	if fmt.Sprint(ports) != fmt.Sprint(want) {
+		t.Errorf("recorded ports = %v, want %v", ports, want)
+	}
+}
+
+func TestTriggerMaxPackets(t *testing.T) {
+	tc := TriggerConfig{StartPattern: regexp.MustCompile(`RST`), PreBufferSize: 2, MaxPackets: 5}
+	sess := &Session{}
+	sess.initTrigger(tc)
+
+	sess.record(triggerPacket(0, "ACK "), nil, tc.MaxPackets)
+	stopped := -1
+	for i := 1; i < 20; i++ {
+		info := "ACK "
+		if i == 1 {
+			info = "RST "
+		}
+		if sess.record(triggerPacket(i, info), nil, tc.MaxPackets) {
+			stopped = i
+			break
+		}
+	}
+
+	// The trigger packet and four more are recorded; the sixth ends the capture
+	if stopped != 6 {
+		t.Errorf("capture stopped at packet %d, want 6", stopped)
+	}
+	if n := sess.GetPacketCount(); n != 6 {
+		t.Errorf("GetPacketCount() = %d, want 1 pre-buffered + 5 recorded", n)
+	}
+}
+
+func TestTriggerWithoutPreBuffer(t *testing.T) {
+	tc := TriggerConfig{StartPattern: regexp.MustCompile(`RST`), MaxPackets: 10}
+	sess := &Session{}
+	sess.initTrigger(tc)
+
+	sess.record(triggerPacket(0, "ACK "), nil, tc.MaxPackets)
+	sess.record(triggerPacket(1, "RST "), nil, tc.MaxPackets)
+	if n := sess.GetPacketCount(); n != 1 {
+		t.Errorf("GetPacketCount() = %d, want only the trigger packet", n)
+	}
+}
+
+func TestStartWithTriggerValidation(t *testing.T) {
+	if _, err := StartWithTrigger("lo0", "", TriggerConfig{}); err == nil {
+		t.Error("expected error without a start pattern")
+	}
+	if _, err := StartWithTrigger("lo0", "", TriggerConfig{StartPattern: regexp.MustCompile("x"), PreBufferSize: -1}); err == nil {
+		t.Error("expected error for negative pre-buffer size")
+	}
+}
@@ -0,0 +1,248 @@
+package completion
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/alexpitcher/LanAudit/internal/logging"
+	"github.com/alexpitcher/LanAudit/internal/store"
+)
+
+// Program is the command the scripts complete
+const Program = "lanaudit"
+
+// InterfacesFlag is the flag whose values come from running
+// "lanaudit --list-interfaces" when completing
+const InterfacesFlag = "iface"
+
+// Shells are the shells Generate writes scripts for
+var Shells = []string{"bash", "zsh", "fish", "powershell"}
+
+// flagValues returns the fixed values completed for each flag
+func flagValues() map[string][]string {
+	return map[string][]string{
+		"format":        {"json", "yaml", "table"},
+		"export-format": {"json", "yaml", "csv"},
+		"log-level":     logging.Levels,
+		"log-format":    logging.Formats,
+		"theme":         store.ThemePresetNames(),
+		"completion":    Shells,
+	}
+}
+
+// completedFlag is a flag as the scripts need it
+type completedFlag struct {
+	name   string
+	usage  string
+	isBool bool
+	values []string // fixed values; nil completes files, or interfaces for InterfacesFlag
+}
+
+// Generate writes the completion script for shell covering the flags in
+// flags. Flags that libraries register, such as gopacket's
+// assembly_debug_log, are named with underscores and left out.
+func Generate(w io.Writer, shell string, flags *flag.FlagSet) error {
+	var fs []completedFlag
+	values := flagValues()
+	flags.VisitAll(func(f *flag.Flag) {
+		if strings.Contains(f.Name, "_") {
+			return
+		}
+		b, ok := f.Value.(interface{ IsBoolFlag() bool })
+		fs = append(fs, completedFlag{
+			name:   f.Name,
+			usage:  f.Usage,
+			isBool: ok && b.IsBoolFlag(),
+			values: values[f.Name],
+		})
+	})
+
+	var script string
+	switch shell {
+	case "bash":
+		script = bashScript(fs)
+	case "zsh":
+		script = zshScript(fs)
+	case "fish":
+		script = fishScript(fs)
+	case "powershell":
+		script = powershellScript(fs)
+	default:
+		return fmt.Errorf("unsupported shell %q: use one of %s", shell, strings.Join(Shells, ", "))
+	}
+	_, err := io.WriteString(w, script)
+	return err
+}
+
+func bashScript(fs []completedFlag) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `# bash completion for %[1]s
+#
+# Load it in the current shell:
+#   source <(%[1]s --completion bash)
+# or install it for every session:
+#   %[1]s --completion bash > ~/.local/share/bash-completion/completions/%[1]s
+
+_%[1]s_completions() {
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    local prev="${COMP_WORDS[COMP_CWORD-1]}"
+    case "$prev" in
+`, Program)
+
+	var names, fileFlags []string
+	for _, f := range fs {
+		names = append(names, "--"+f.name)
+		switch {
+		case f.isBool:
+		case f.name == InterfacesFlag:
+			fmt.Fprintf(&b, "        --%s)\n            COMPREPLY=($(compgen -W \"$(%s --list-interfaces 2>/dev/null)\" -- \"$cur\"))\n            return ;;\n", f.name, Program)
+		case len(f.values) > 0:
+			fmt.Fprintf(&b, "        --%s)\n            COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n            return ;;\n", f.name, strings.Join(f.values, " "))
+		default:
+			fileFlags = append(fileFlags, "--"+f.name)
+		}
+	}
+	if len(fileFlags) > 0 {
+		// Nothing offered, so bash falls back to file names
+		fmt.Fprintf(&b, "        %s)\n            COMPREPLY=()\n            return ;;\n", strings.Join(fileFlags, "|"))
+	}
+	fmt.Fprintf(&b, `    esac
+    COMPREPLY=($(compgen -W "%s" -- "$cur"))
+}
+
+complete -o default -F _%s_completions %s
+`, strings.Join(names, " "), Program, Program)
+	return b.String()
+}
+
+func zshScript(fs []completedFlag) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `#compdef %[1]s
+# zsh completion for %[1]s
+#
+# Load it in the current shell:
+#   source <(%[1]s --completion zsh)
+# or install it where compinit finds it and start a new shell:
+#   %[1]s --completion zsh > "${fpath[1]}/_%[1]s"
+
+_%[1]s_interfaces() {
+    local -a ifaces
+    ifaces=(${(f)"$(%[1]s --list-interfaces 2>/dev/null)"})
+    _describe 'interface' ifaces
+}
+
+_%[1]s() {
+    _arguments \
+`, Program)
+
+	for i, f := range fs {
+		spec := fmt.Sprintf("'--%s[%s]", f.name, zshEscape(f.usage))
+		switch {
+		case f.isBool:
+		case f.name == InterfacesFlag:
+			spec += fmt.Sprintf(":interface:_%s_interfaces", Program)
+		case len(f.values) > 0:
+			spec += fmt.Sprintf(":%s:(%s)", f.name, strings.Join(f.values, " "))
+		default:
+			spec += ":" + f.name + ":_default"
+		}
+		spec += "'"
+		if i < len(fs)-1 {
+			spec += " \\"
+		}
+		b.WriteString("        " + spec + "\n")
+	}
+	fmt.Fprintf(&b, `}
+
+if [ "$funcstack[1]" = "_%[1]s" ]; then
+    _%[1]s "$@"
+else
+    compdef _%[1]s %[1]s
+fi
+`, Program)
+	return b.String()
+}
+
+// zshEscape quotes a description for a single-quoted _arguments spec
+func zshEscape(s string) string {
+	return strings.NewReplacer("'", `'\''`, "[", `\[`, "]", `\]`, ":", `\:`).Replace(s)
+}
+
+func fishScript(fs []completedFlag) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `# fish completion for %[1]s
+#
+# Install it for every session:
+#   %[1]s --completion fish > ~/.config/fish/completions/%[1]s.fish
+
+function __%[1]s_interfaces
+    %[1]s --list-interfaces 2>/dev/null
+end
+
+`, Program)
+
+	for _, f := range fs {
+		line := fmt.Sprintf("complete -c %s -l %s -d '%s'", Program, f.name, fishEscape(f.usage))
+		switch {
+		case f.isBool:
+		case f.name == InterfacesFlag:
+			line += fmt.Sprintf(" -x -a '(__%s_interfaces)'", Program)
+		case len(f.values) > 0:
+			line += fmt.Sprintf(" -x -a '%s'", strings.Join(f.values, " "))
+		default:
+			line += " -r"
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// fishEscape quotes a description for a single-quoted fish string
+func fishEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s)
+}
+
+func powershellScript(fs []completedFlag) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `# PowerShell completion for %[1]s
+#
+# Load it in the current session:
+#   %[1]s --completion powershell | Out-String | Invoke-Expression
+# or add that line to $PROFILE to load it in every session.
+
+Register-ArgumentCompleter -Native -CommandName %[1]s -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+
+    # The word before the one being completed
+    $before = @($commandAst.CommandElements | Where-Object { $_.Extent.EndOffset -lt $cursorPosition })
+    $prev = if ($before.Count -gt 0) { $before[-1].ToString() } else { '' }
+
+    $values = switch ($prev) {
+`, Program)
+
+	var names []string
+	for _, f := range fs {
+		names = append(names, "'--"+f.name+"'")
+		switch {
+		case f.isBool:
+		case f.name == InterfacesFlag:
+			fmt.Fprintf(&b, "        '--%s' { & %s --list-interfaces 2>$null; break }\n", f.name, Program)
+		case len(f.values) > 0:
+			fmt.Fprintf(&b, "        '--%s' { '%s'; break }\n", f.name, strings.Join(f.values, "', '"))
+		default:
+			// Nothing offered, so PowerShell falls back to paths
+			fmt.Fprintf(&b, "        '--%s' { break }\n", f.name)
+		}
+	}
+	fmt.Fprintf(&b, `        default { %s }
+    }
+
+    $values | Where-Object { $_ -like "$wordToComplete*" } | ForEach-Object {
+        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+    }
+}
+`, strings.Join(names, ", "))
+	return b.String()
+}
@@ -0,0 +1,86 @@
+package completion
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+	"testing"
+)
+
+func testFlags() *flag.FlagSet {
+	fs := flag.NewFlagSet("lanaudit", flag.ContinueOnError)
+	fs.Bool("headless", false, "Run in headless mode (JSON output)")
+	fs.String("iface", "", "Network interface to use")
+	fs.String("format", "json", "Headless output format (json, yaml or table)")
+	fs.String("log-level", "info", "Least severe messages written to log.txt [debug]")
+	fs.String("theme", "", "TUI color theme (dark, light or solarized), overriding the config")
+	fs.String("output", "", "Write headless result to file instead of stdout")
+	fs.Bool("assembly_debug_log", false, "If true, the tcpassembly library will log verbose debugging information")
+	return fs
+}
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		shell string
+		want  []string
+	}{
+		{shell: "bash", want: []string{
+			"_lanaudit_completions()",
+			"complete -o default -F _lanaudit_completions lanaudit",
+			`compgen -W "$(lanaudit --list-interfaces 2>/dev/null)"`,
+			`compgen -W "json yaml table"`,
+			`compgen -W "debug info warn error"`,
+			`compgen -W "dark light solarized"`,
+			"--output)",
+		}},
+		{shell: "zsh", want: []string{
+			"#compdef lanaudit",
+			"_lanaudit()",
+			"_lanaudit_interfaces()",
+			"'--iface[Network interface to use]:interface:_lanaudit_interfaces'",
+			":format:(json yaml table)'",
+			`'--log-level[Least severe messages written to log.txt \[debug\]]:log-level:(debug info warn error)'`,
+			"'--headless[Run in headless mode (JSON output)]'",
+		}},
+		{shell: "fish", want: []string{
+			"function __lanaudit_interfaces",
+			"complete -c lanaudit -l iface -d 'Network interface to use' -x -a '(__lanaudit_interfaces)'",
+			"-l format -d 'Headless output format (json, yaml or table)' -x -a 'json yaml table'",
+			"-x -a 'dark light solarized'",
+			"-l output -d 'Write headless result to file instead of stdout' -r",
+		}},
+		{shell: "powershell", want: []string{
+			"Register-ArgumentCompleter -Native -CommandName lanaudit",
+			"'--iface' { & lanaudit --list-interfaces 2>$null; break }",
+			"'--format' { 'json', 'yaml', 'table'; break }",
+			"'--log-level' { 'debug', 'info', 'warn', 'error'; break }",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Generate(&buf, tt.shell, testFlags()); err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+			out := buf.String()
+			if !strings.HasPrefix(out, "#") || !strings.Contains(out, "--completion "+tt.shell) {
+				t.Errorf("expected a header comment with install instructions, got:\n%s", out)
+			}
+			if strings.Contains(out, "assembly_debug_log") {
+				t.Error("expected library flags to be left out")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("expected %q in the script, got:\n%s", want, out)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateUnknownShell(t *testing.T) {
+	if err := Generate(&bytes.Buffer{}, "tcsh", testFlags()); err == nil {
+		t.Error("expected an error for tcsh")
+	}
+}
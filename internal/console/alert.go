@@ -0,0 +1,103 @@
+package console
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/alexpitcher/LanAudit/internal/logging"
+)
+
+// maxAlertLine bounds the partial line kept between reads
+const maxAlertLine = 4096
+
+// alertEntry is a registered output alert
+type alertEntry struct {
+	pattern *regexp.Regexp
+	action  func(match string)
+}
+
+// alertHit is a match waiting for its action to run
+type alertHit struct {
+	name   string
+	action func(match string)
+	match  string
+}
+
+// AddAlert calls action with the matching text whenever a line received from
+// the device matches pattern, e.g. "changed state to down" or
+// "%SYS-5-CONFIG_I". Actions run on a separate goroutine, one at a time, so a
+// slow action never stalls the read loop. Adding an alert under an existing
+// name replaces it.
+func (s *Session) AddAlert(name string, pattern *regexp.Regexp, action func(match string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.alerts == nil {
+		s.alerts = make(map[string]alertEntry)
+		s.alertHits = make(chan alertHit, 64)
+		go s.alertWorker(s.alertHits)
+	}
+	s.alerts[name] = alertEntry{pattern: pattern, action: action}
+	logging.Debugf("session %s alert %s added: %s", s.id, name, pattern)
+}
+
+// RemoveAlert unregisters the named alert
+func (s *Session) RemoveAlert(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.alerts, name)
+}
+
+// alertWorker runs alert actions until the session closes
+func (s *Session) alertWorker(hits <-chan alertHit) {
+	for {
+		select {
+		case hit := <-hits:
+			hit.action(hit.match)
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// checkAlerts matches complete lines of received data against the alerts.
+// A line split across reads is held until its newline arrives.
+func (s *Session) checkAlerts(data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.alerts) == 0 {
+		s.alertLine = s.alertLine[:0]
+		return
+	}
+
+	s.alertLine = append(s.alertLine, data...)
+	for {
+		i := bytes.IndexByte(s.alertLine, '\n')
+		if i < 0 {
+			break
+		}
+		line := cleanSerialData(s.alertLine[:i], true)
+		s.alertLine = s.alertLine[i+1:]
+		s.matchAlerts(line)
+	}
+
+	if len(s.alertLine) > maxAlertLine {
+		s.alertLine = s.alertLine[len(s.alertLine)-maxAlertLine:]
+	}
+}
+
+// matchAlerts queues the action of every alert matching line
+func (s *Session) matchAlerts(line string) {
+	for name, alert := range s.alerts {
+		match := alert.pattern.FindString(line)
+		if match == "" {
+			continue
+		}
+		select {
+		case s.alertHits <- alertHit{name: name, action: alert.action, match: match}:
+		default:
+			logging.Warnf("session %s alert %s dropped: action queue full", s.id, name)
+		}
+	}
+}
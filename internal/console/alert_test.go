@@ -0,0 +1,118 @@
+package console
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// pipePort reads from an io.Pipe so tests control what the device sends
+type pipePort struct {
+	fakePort
+	r *io.PipeReader
+}
+
+func (p *pipePort) Read(b []byte) (int, error) { return p.r.Read(b) }
+
+func (p *pipePort) Close() error { return p.r.Close() }
+
+func newPipeSession(t *testing.T, cfg SessionConfig) (*Session, *io.PipeWriter) {
+	t.Helper()
+	r, w := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Session{
+		id:       "alerts",
+		config:   cfg,
+		port:     &pipePort{r: r},
+		ctx:      ctx,
+		cancel:   cancel,
+		readChan: make(chan []byte, 100),
+		errChan:  make(chan error, 10),
+		watchers: make(map[chan []byte]struct{}),
+	}
+	go s.readLoop()
+	t.Cleanup(func() {
+		w.Close()
+		s.Close()
+	})
+	return s, w
+}
+
+func TestSessionAlerts(t *testing.T) {
+	sess, device := newPipeSession(t, DefaultSessionConfig("/dev/test", 9600))
+
+	matches := make(chan string, 10)
+	sess.AddAlert("link-down", regexp.MustCompile(`Interface \S+, changed state to down`), func(match string) {
+		matches <- "link-down: " + match
+	})
+	sess.AddAlert("config", regexp.MustCompile(`%SYS-5-CONFIG_I: Configured`), func(match string) {
+		matches <- "config: " + match
+	})
+
+	expect := func(want string) {
+		t.Helper()
+		select {
+		case got := <-matches:
+			if got != want {
+				t.Errorf("alert = %q, want %q", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no alert, want %q", want)
+		}
+	}
+	expectNone := func() {
+		t.Helper()
+		select {
+		case got := <-matches:
+			t.Errorf("unexpected alert %q", got)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	// A line split across reads matches once it is complete
+	io.WriteString(device, "*Mar  1 00:01:02: %LINK-3-UPDOWN: Interface GigabitEthernet0/1, ")
+	expectNone()
+	io.WriteString(device, "changed state to down\r\n")
+	expect("link-down: Interface GigabitEthernet0/1, changed state to down")
+
+	// Escape sequences are stripped before matching
+	io.WriteString(device, "\x1b[1m%SYS-5-CONFIG_I\x1b[0m: Configured from console\r\nSwitch#")
+	expect("config: %SYS-5-CONFIG_I: Configured")
+
+	io.WriteString(device, "Interface Gi0/2, changed state to up\r\n")
+	expectNone()
+
+	sess.RemoveAlert("config")
+	io.WriteString(device, "%SYS-5-CONFIG_I: Configured from console\r\n")
+	expectNone()
+
+	// Replacing an alert keeps a single entry under the name
+	sess.AddAlert("link-down", regexp.MustCompile(`changed state to \w+`), func(match string) {
+		matches <- "state: " + match
+	})
+	io.WriteString(device, "Interface Gi0/2, changed state to up\r\n")
+	expect("state: changed state to up")
+	expectNone()
+}
+
+func TestSessionAlertSlowAction(t *testing.T) {
+	sess, device := newPipeSession(t, DefaultSessionConfig("/dev/test", 9600))
+
+	release := make(chan struct{})
+	sess.AddAlert("neighbor", regexp.MustCompile(`OSPF.*Neighbor Down`), func(string) {
+		<-release
+	})
+	defer close(release)
+
+	// A blocked action must not stop data reaching the console
+	for i := 0; i < 100; i++ {
+		io.WriteString(device, "%OSPF-5-ADJCHG: Process 1, Nbr 10.0.0.2 on Gi0/1 from FULL to DOWN, Neighbor Down\n")
+		select {
+		case <-sess.ReadChan():
+		case <-time.After(2 * time.Second):
+			t.Fatalf("read loop stalled after %d lines", i)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package console
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/alexpitcher/LanAudit/internal/console/fingerprint"
+	"github.com/alexpitcher/LanAudit/internal/logging"
+)
+
+// DefaultBaselineTimeout is the shortest wait for a baseline command; full
+// command output takes far longer than the identification probes allow
+const DefaultBaselineTimeout = 10 * time.Second
+
+// baselinePromptTimeout is how long to wait for the prompt before probing
+var baselinePromptTimeout = 2 * time.Second
+
+// baselinePromptTerminators end a read once the device shows its prompt
+var baselinePromptTerminators = [][]byte{[]byte("#"), []byte(">"), []byte("$")}
+
+var baselineFileUnsafe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// DiffOp says whether a diff line is shared, new or gone
+type DiffOp int
+
+const (
+	DiffEqual DiffOp = iota
+	DiffAdded
+	DiffRemoved
+)
+
+// DiffLine is one line of a baseline diff
+type DiffLine struct {
+	Op   DiffOp
+	Text string
+}
+
+// CaptureBaseline runs probe's command on the session and saves the output
+// as the device's baseline under ~/.lanaudit/baselines/, keyed by
+// BaselineKey. The baseline it replaces is kept as the previous one. The
+// probe only runs if the current prompt passes its guard.
+func CaptureBaseline(sess *Session, probe *fingerprint.SafeProbe) (string, error) {
+	if probe == nil {
+		return "", fmt.Errorf("no safe probe for this device")
+	}
+
+	rx, err := baselineExchange(sess, "\r", baselinePromptTimeout)
+	if err != nil {
+		return "", fmt.Errorf("failed to read prompt: %w", err)
+	}
+	prompt := fingerprint.ExtractLastPromptLine(fingerprint.Normalize(rx))
+	if probe.Guard != nil && !probe.Guard.MatchString(prompt) {
+		return "", fmt.Errorf("prompt %q does not match the %s guard", prompt, probe.Name)
+	}
+
+	timeout := time.Duration(probe.TimeoutMs) * time.Millisecond
+	if timeout < DefaultBaselineTimeout {
+		timeout = DefaultBaselineTimeout
+	}
+	raw, err := baselineExchange(sess, probe.Command+"\r", timeout)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %q output: %w", probe.Command, err)
+	}
+	output := cleanBaselineOutput(raw, probe.Command)
+
+	key := BaselineKey(probe, output)
+	path, err := baselinePath(key)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create baselines directory: %w", err)
+	}
+	if _, err := os.Stat(path); err == nil {
+		if err := os.Rename(path, previousBaselinePath(path)); err != nil {
+			return "", fmt.Errorf("failed to keep previous baseline: %w", err)
+		}
+	}
+	if err := os.WriteFile(path, []byte(output), 0644); err != nil {
+		return "", fmt.Errorf("failed to write baseline: %w", err)
+	}
+
+	logging.Infof("session %s baseline %s saved to %s", sess.id, key, path)
+	return output, nil
+}
+
+// baselineExchange sends line and reads until the prompt returns. The watcher
+// is registered first so a fast reply isn't missed.
+func baselineExchange(sess *Session, line string, timeout time.Duration) (string, error) {
+	watcher := make(chan []byte, 64)
+	sess.registerWatcher(watcher)
+	defer sess.unregisterWatcher(watcher)
+
+	if _, err := sess.Write([]byte(line)); err != nil {
+		return "", err
+	}
+	return readUntil(sess.ctx, sess.id, watcher, timeout, baselinePromptTerminators)
+}
+
+// cleanBaselineOutput drops the echoed command and the trailing prompt so
+// only the command's output is compared
+func cleanBaselineOutput(raw, command string) string {
+	lines := strings.Split(fingerprint.Normalize(raw), "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	if len(lines) > 0 && strings.HasSuffix(strings.TrimSpace(lines[0]), command) {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) > 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// BaselineKey identifies a device as <vendor>:<os>:<serial>; the serial is
+// "unknown" when the output doesn't show one
+func BaselineKey(probe *fingerprint.SafeProbe, output string) string {
+	serial := fingerprint.ScrapeSerial(output)
+	if serial == "" {
+		serial = "unknown"
+	}
+	return probe.Vendor + ":" + probe.OS + ":" + serial
+}
+
+// GetBaselinesDir returns the directory baselines are saved in
+func GetBaselinesDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".lanaudit", "baselines"), nil
+}
+
+func baselinePath(key string) (string, error) {
+	dir, err := GetBaselinesDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, baselineFileUnsafe.ReplaceAllString(key, "_")+".txt"), nil
+}
+
+func previousBaselinePath(path string) string {
+	return strings.TrimSuffix(path, ".txt") + ".prev.txt"
+}
+
+// LoadBaseline returns the latest baseline saved for key
+func LoadBaseline(key string) (string, error) {
+	path, err := baselinePath(key)
+	if err != nil {
+		return "", err
+	}
+	return readBaseline(path)
+}
+
+// LoadPreviousBaseline returns the baseline replaced by the latest capture.
+// The error wraps os.ErrNotExist if the device has been captured only once.
+func LoadPreviousBaseline(key string) (string, error) {
+	path, err := baselinePath(key)
+	if err != nil {
+		return "", err
+	}
+	return readBaseline(previousBaselinePath(path))
+}
+
+func readBaseline(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("no baseline at %s: %w", path, err)
+		}
+		return "", fmt.Errorf("failed to read baseline: %w", err)
+	}
+	return string(data), nil
+}
+
+// DiffBaseline compares current output against a baseline line by line using
+// the Myers algorithm. Removed lines are only in the baseline, added lines
+// only in the current output.
+func DiffBaseline(current, baseline string) []DiffLine {
+	return myersDiff(splitDiffLines(baseline), splitDiffLines(current))
+}
+
+func splitDiffLines(s string) []string {
+	s = strings.TrimRight(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// myersDiff returns the shortest edit script turning a into b
+func myersDiff(a, b []string) []DiffLine {
+	n, m := len(a), len(b)
+	maxD := n + m
+	offset := maxD + 1
+	v := make([]int, 2*maxD+3)
+	var trace [][]int
+
+search:
+	for d := 0; d <= maxD; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // step down: line from b
+			} else {
+				x = v[offset+k-1] + 1 // step right: line from a
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// Walk back from the end, recording the edits in reverse
+	var rev []DiffLine
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			rev = append(rev, DiffLine{Op: DiffEqual, Text: a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			rev = append(rev, DiffLine{Op: DiffAdded, Text: b[y-1]})
+			y--
+		} else {
+			rev = append(rev, DiffLine{Op: DiffRemoved, Text: a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		rev = append(rev, DiffLine{Op: DiffEqual, Text: a[x-1]})
+		x--
+		y--
+	}
+
+	out := make([]DiffLine, len(rev))
+	for i, line := range rev {
+		out[len(rev)-1-i] = line
+	}
+	return out
+}
@@ -0,0 +1,127 @@
+package console
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/alexpitcher/LanAudit/internal/console/fingerprint"
+)
+
+func TestDiffBaseline(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseline string
+		current  string
+		want     string // one line per DiffLine: op prefix then text
+	}{
+		{"unchanged", "a\nb\n", "a\nb", " a  b"},
+		{"both empty", "", "", ""},
+		{"first capture", "", "a\nb", "+a +b"},
+		{"everything gone", "a\nb", "", "-a -b"},
+		{"line added", "a\nc", "a\nb\nc", " a +b  c"},
+		{"line removed", "a\nb\nc", "a\nc", " a -b  c"},
+		{"line changed", "uptime 1w\nversion 15.2", "uptime 2w\nversion 15.2", "-uptime 1w +uptime 2w  version 15.2"},
+		{"classic example", "A\nB\nC\nA\nB\nB\nA", "C\nB\nA\nB\nA\nC", "-A -B  C +B  A  B -B  A +C"},
+	}
+
+	prefix := map[DiffOp]string{DiffEqual: " ", DiffAdded: "+", DiffRemoved: "-"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := DiffBaseline(tt.current, tt.baseline)
+
+			var parts []string
+			var before, after []string
+			for _, line := range diff {
+				parts = append(parts, prefix[line.Op]+line.Text)
+				if line.Op != DiffAdded {
+					before = append(before, line.Text)
+				}
+				if line.Op != DiffRemoved {
+					after = append(after, line.Text)
+				}
+			}
+			if got := strings.Join(parts, " "); got != tt.want {
+				t.Errorf("diff = %q, want %q", got, tt.want)
+			}
+			// The diff must reproduce both inputs
+			if got := strings.Join(before, "\n"); got != strings.TrimRight(tt.baseline, "\n") {
+				t.Errorf("baseline side = %q", got)
+			}
+			if got := strings.Join(after, "\n"); got != strings.TrimRight(tt.current, "\n") {
+				t.Errorf("current side = %q", got)
+			}
+		})
+	}
+}
+
+func TestCaptureBaseline(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	probe := fingerprint.SafeProbeFor("Cisco", "IOS")
+
+	port := newScriptedPort(map[string]string{
+		"":             "\r\nSwitch#",
+		"show version": "\r\nCisco IOS Software, C2960 Software, Version 15.2(2)E7\r\nSwitch uptime is 1 week   \r\nProcessor board ID FOC1234X5YZ\r\nSwitch#",
+	})
+	sess := newTestSession(t, port)
+
+	first, err := CaptureBaseline(sess, probe)
+	if err != nil {
+		t.Fatalf("CaptureBaseline: %v", err)
+	}
+	want := "Cisco IOS Software, C2960 Software, Version 15.2(2)E7\nSwitch uptime is 1 week\nProcessor board ID FOC1234X5YZ"
+	if first != want {
+		t.Errorf("baseline = %q, want %q", first, want)
+	}
+
+	key := BaselineKey(probe, first)
+	if key != "Cisco:IOS:FOC1234X5YZ" {
+		t.Errorf("BaselineKey() = %q", key)
+	}
+	if saved, err := LoadBaseline(key); err != nil || saved != first {
+		t.Errorf("LoadBaseline() = %q, %v", saved, err)
+	}
+	if _, err := LoadPreviousBaseline(key); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("LoadPreviousBaseline() after one capture = %v, want not-exist", err)
+	}
+
+	port.mu.Lock()
+	port.responses["show version"] = "\r\nCisco IOS Software, C2960 Software, Version 15.2(7)E8\r\nSwitch uptime is 2 weeks\r\nProcessor board ID FOC1234X5YZ\r\nSwitch#"
+	port.mu.Unlock()
+
+	second, err := CaptureBaseline(sess, probe)
+	if err != nil {
+		t.Fatalf("second CaptureBaseline: %v", err)
+	}
+	previous, err := LoadPreviousBaseline(key)
+	if err != nil || previous != first {
+		t.Fatalf("LoadPreviousBaseline() = %q, %v", previous, err)
+	}
+
+	var changed int
+	for _, line := range DiffBaseline(second, previous) {
+		if line.Op != DiffEqual {
+			changed++
+		}
+	}
+	if changed != 4 {
+		t.Errorf("expected version and uptime to change (4 diff lines), got %d", changed)
+	}
+}
+
+func TestCaptureBaselineGuard(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	port := newScriptedPort(map[string]string{"": "\r\nroot@host:~$"})
+	sess := newTestSession(t, port)
+
+	_, err := CaptureBaseline(sess, fingerprint.SafeProbeFor("Cisco", "IOS"))
+	if err == nil || !strings.Contains(err.Error(), "guard") {
+		t.Fatalf("err = %v, want guard refusal", err)
+	}
+	for _, line := range port.lines() {
+		if line == "show version" {
+			t.Error("probe command sent despite guard mismatch")
+		}
+	}
+}
@@ -18,6 +18,28 @@ const (
 	StageBoot     Stage = "bootloader"
 )
 
+const (
+	// DefaultMinProbeConfidence is the lowest candidate probability that
+	// may send a safe probe to the device
+	DefaultMinProbeConfidence = 0.55
+	// DefaultMaxEvidence is how many evidence strings a Result keeps
+	DefaultMaxEvidence = 3
+)
+
+// Config tunes when probes run and how much evidence results keep.
+type Config struct {
+	MinProbeConfidence float64
+	MaxEvidence        int
+}
+
+// DefaultConfig returns the built-in thresholds.
+func DefaultConfig() Config {
+	return Config{
+		MinProbeConfidence: DefaultMinProbeConfidence,
+		MaxEvidence:        DefaultMaxEvidence,
+	}
+}
+
 // Candidate describes a potential fingerprint match.
 type Candidate struct {
 	Vendor        string
@@ -31,14 +53,16 @@ type Candidate struct {
 
 // Result captures the final fingerprint decision.
 type Result struct {
-	Vendor     string
-	OS         string
-	Model      string
-	Prompt     string
-	Stage      Stage
-	Baud       int
-	Confidence float64
-	Evidence   []string
+	Vendor       string
+	OS           string
+	Model        string
+	ModelParts   []string // Model split into the fields it was built from
+	SerialNumber string
+	Prompt       string
+	Stage        Stage
+	Baud         int
+	Confidence   float64
+	Evidence     []string
 }
 
 // WriterReader is implemented by console sessions for safe probes.
@@ -78,7 +102,7 @@ func Analyze(rx string, lastPrompt string) (Stage, []Candidate) {
 }
 
 // MaybeProbe executes a single safe probe if the candidate qualifies.
-func MaybeProbe(sess WriterReader, cand Candidate, timeout time.Duration) (string, *Candidate, error) {
+func MaybeProbe(sess WriterReader, cand Candidate, timeout time.Duration, cfg Config) (string, *Candidate, error) {
 	if sess == nil || cand.NextSafeProbe == nil {
 		return "", nil, nil
 	}
@@ -87,7 +111,7 @@ func MaybeProbe(sess WriterReader, cand Candidate, timeout time.Duration) (strin
 		return "", nil, nil
 	}
 
-	if cand.Prob < 0.55 {
+	if cand.Prob < cfg.MinProbeConfidence {
 		return "", nil, nil
 	}
 
@@ -147,13 +171,13 @@ func MaybeProbe(sess WriterReader, cand Candidate, timeout time.Duration) (strin
 }
 
 // Finalize derives the final fingerprint result using all context.
-func Finalize(stage Stage, cands []Candidate, rx, prompt, probeOut string) Result {
+func Finalize(stage Stage, cands []Candidate, rx, prompt, probeOut string, cfg Config) Result {
 	res := Result{Stage: stage, Prompt: strings.TrimSpace(prompt)}
 
 	if len(cands) == 0 {
 		res.Vendor = "Unknown"
 		res.OS = "Unknown"
-		res.Evidence = shortlistEvidence([]string{"no candidates"})
+		res.Evidence = shortlistEvidence([]string{"no candidates"}, cfg.MaxEvidence)
 		logging.Warnf("Finalize: no candidates for provided input")
 		return res
 	}
@@ -162,19 +186,22 @@ func Finalize(stage Stage, cands []Candidate, rx, prompt, probeOut string) Resul
 	res.Vendor = top.Vendor
 	res.OS = top.OS
 	res.Confidence = clamp01(top.Prob)
-	res.Evidence = shortlistEvidence(top.Evidence)
+	res.Evidence = shortlistEvidence(top.Evidence, cfg.MaxEvidence)
 
-	if model := scrapeModel(rx, top); model != "" {
-		res.Model = model
+	parts := scrapeModel(rx, top)
+	if probeOut != "" {
+		parts = append(parts, scrapeModel(probeOut, top)...)
 	}
-	if res.Model == "" && probeOut != "" {
-		res.Model = scrapeModel(probeOut, top)
+	if len(parts) > 0 {
+		res.ModelParts = dedupeStrings(parts)
+		res.Model = strings.Join(res.ModelParts, " ")
 	}
 
 	if probeOut != "" {
-		res.Evidence = shortlistEvidence(append(res.Evidence, "probe output captured"))
+		res.SerialNumber = scrapeSerial(probeOut, top)
+		res.Evidence = shortlistEvidence(append(res.Evidence, "probe output captured"), cfg.MaxEvidence)
 	}
-	logging.Infof("Finalize result vendor=%s os=%s model=%s confidence=%.2f", res.Vendor, res.OS, res.Model, res.Confidence)
+	logging.Infof("Finalize result vendor=%s os=%s model=%s serial=%s confidence=%.2f", res.Vendor, res.OS, res.Model, res.SerialNumber, res.Confidence)
 
 	return res
 }
@@ -187,10 +214,15 @@ func (c Candidate) EvidenceString() string {
 	return strings.Join(c.Evidence, "\n")
 }
 
-func shortlistEvidence(evs []string) []string {
+// shortlistEvidence keeps the first limit distinct entries; a limit of 0
+// or less uses DefaultMaxEvidence
+func shortlistEvidence(evs []string, limit int) []string {
+	if limit <= 0 {
+		limit = DefaultMaxEvidence
+	}
 	deduped := dedupeStrings(evs)
-	if len(deduped) > 3 {
-		return deduped[:3]
+	if len(deduped) > limit {
+		return deduped[:limit]
 	}
 	return deduped
 }
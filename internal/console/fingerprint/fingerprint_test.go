@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 type fixture struct {
@@ -80,17 +81,21 @@ func TestAnalyzeFixtures(t *testing.T) {
 		{name: "Cisco ASA", fixture: "cisco_asa", wantVendor: "Cisco", wantOS: "ASA", wantStage: StagePrompt, wantMinConfidence: 0.8, wantModel: "ASA5515"},
 		{name: "Cisco NX-OS", fixture: "cisco_nxos", wantVendor: "Cisco", wantOS: "NX-OS", wantStage: StagePrompt, wantMinConfidence: 0.8, wantModel: "cisco Nexus 93180YC-FX Chassis"},
 		{name: "Cisco IOS-XR", fixture: "cisco_iosxr", wantVendor: "Cisco", wantOS: "IOS-XR", wantStage: StagePrompt, wantMinConfidence: 0.8},
+		{name: "Cisco AireOS", fixture: "cisco_aireos", wantVendor: "Cisco", wantOS: "AireOS", wantStage: StagePrompt, wantMinConfidence: 0.8, wantModel: "AIR-CT5520-K9"},
+		{name: "Arista EOS", fixture: "arista_eos", wantVendor: "Arista", wantOS: "EOS", wantStage: StagePrompt, wantMinConfidence: 0.8, wantModel: "4.28.3M"},
 		{name: "Aruba CX", fixture: "aruba_aos_cx", wantVendor: "Aruba", wantOS: "AOS-CX", wantStage: StagePrompt, wantMinConfidence: 0.8, wantModel: "Aruba 8320 Switch Series"},
 		{name: "Aruba AOS-S", fixture: "aruba_aos_s", wantVendor: "Aruba", wantOS: "AOS-S", wantStage: StagePrompt, wantMinConfidence: 0.8},
-		{name: "JUNOS", fixture: "junos", wantVendor: "Juniper", wantOS: "JUNOS", wantStage: StagePrompt, wantMinConfidence: 0.75, wantModel: "mx204"},
+		{name: "JUNOS", fixture: "junos", wantVendor: "Juniper", wantOS: "JUNOS", wantStage: StagePrompt, wantMinConfidence: 0.75, wantModel: "mx204 JUNOS 22.2R3.15"},
 		{name: "MikroTik", fixture: "mikrotik", wantVendor: "MikroTik", wantOS: "RouterOS", wantStage: StagePrompt, wantMinConfidence: 0.75, wantModel: "CRS328-24P-4S+"},
 		{name: "EdgeOS", fixture: "edgeos", wantVendor: "Ubiquiti", wantOS: "EdgeOS", wantStage: StagePrompt, wantMinConfidence: 0.7, wantModel: "EdgeRouter"},
 		{name: "FortiGate", fixture: "fortigate", wantVendor: "Fortinet", wantOS: "FortiOS", wantStage: StagePrompt, wantMinConfidence: 0.75, wantModel: "FortiGate-60E v6.4.9,build2044"},
 		{name: "Palo Alto", fixture: "paloalto", wantVendor: "PaloAlto", wantOS: "PAN-OS", wantStage: StagePrompt, wantMinConfidence: 0.75, wantModel: "PA-220"},
 		{name: "Huawei VRP", fixture: "huawei_vrp", wantVendor: "Huawei", wantOS: "VRP", wantStage: StagePrompt, wantMinConfidence: 0.75, wantModel: "S5720-28X-SI-AC"},
-		{name: "HPE Comware", fixture: "hpe_comware", wantVendor: "HPE", wantOS: "Comware", wantStage: StagePrompt, wantMinConfidence: 0.75, wantModel: "HPE 5130-24G-PoE+-4SFP+ EI"},
+		{name: "HPE Comware", fixture: "hpe_comware", wantVendor: "HPE", wantOS: "Comware", wantStage: StagePrompt, wantMinConfidence: 0.75, wantModel: "HPE 5130-24G-PoE+-4SFP+ EI 7.1.059"},
 		{name: "Dell OS10", fixture: "dell_os10", wantVendor: "Dell", wantOS: "OS10", wantStage: StagePrompt, wantMinConfidence: 0.7, wantModel: "Dell S5248F-ON"},
 		{name: "Brocade", fixture: "brocade_fastiron", wantVendor: "Brocade/Extreme", wantOS: "FastIron", wantStage: StagePrompt, wantMinConfidence: 0.75, wantModel: "ICX7250-48P"},
+		{name: "ExtremeXOS", fixture: "extreme_xos", wantVendor: "Extreme", wantOS: "ExtremeXOS", wantStage: StagePrompt, wantMinConfidence: 0.75, wantModel: "22.5.1.7"},
+		{name: "Nokia SR-OS", fixture: "nokia_sros", wantVendor: "Nokia", wantOS: "SR-OS", wantStage: StagePrompt, wantMinConfidence: 0.75, wantModel: "B-20.10.R1"},
 		{name: "VyOS", fixture: "vyos", wantVendor: "VyOS", wantOS: "VyOS", wantStage: StagePrompt, wantMinConfidence: 0.75, wantModel: "1.4-rolling-20240220"},
 		{name: "OpenWrt", fixture: "openwrt", wantVendor: "OpenWrt", wantOS: "OpenWrt", wantStage: StagePrompt, wantMinConfidence: 0.7, wantModel: "OpenWrt 22.03.0"},
 		{name: "pfSense", fixture: "pfsense", wantVendor: "pfSense/OPNsense", wantOS: "pfSense", wantStage: StagePrompt, wantMinConfidence: 0.7, wantModel: "pfSense"},
@@ -124,7 +129,7 @@ func TestAnalyzeFixtures(t *testing.T) {
 				t.Fatalf("os = %q, want %q", top.OS, tt.wantOS)
 			}
 
-			res := Finalize(stage, candidates, rx, fx.Prompt, fx.Probe)
+			res := Finalize(stage, candidates, rx, fx.Prompt, fx.Probe, DefaultConfig())
 			if res.Confidence < tt.wantMinConfidence {
 				t.Fatalf("confidence = %.2f, want >= %.2f", res.Confidence, tt.wantMinConfidence)
 			}
@@ -158,10 +163,252 @@ func TestNegativeFixtures(t *testing.T) {
 				t.Fatalf("expected StageLogin, got %s", stage)
 			}
 
-			res := Finalize(stage, candidates, rx, fx.Prompt, fx.Probe)
+			res := Finalize(stage, candidates, rx, fx.Prompt, fx.Probe, DefaultConfig())
 			if res.Confidence > 0.5 {
 				t.Fatalf("expected low confidence, got %.2f", res.Confidence)
 			}
 		})
 	}
 }
+
+func TestScrapeSerial(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		want string
+	}{
+		{"fortigate fixture", loadFixture(t, "fortigate").Probe, "FG60ETK123456789"},
+		{"cisco fixture without serial", loadFixture(t, "cisco_ios").Probe, ""},
+		{"cisco board ID", "cisco WS-C2960-24TT-L (PowerPC405) processor\nProcessor board ID FOC1234X5YZ\n", "FOC1234X5YZ"},
+		{"cisco system serial", "System serial number            : FOC1234X5YZ\n", "FOC1234X5YZ"},
+		{"aruba", "  Serial Number     : SG12ABC345\n", "SG12ABC345"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ScrapeSerial(tt.out); got != tt.want {
+				t.Errorf("ScrapeSerial() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSafeProbeIdentity(t *testing.T) {
+	probe := SafeProbeFor("Brocade/Extreme", "FastIron")
+	if probe == nil || probe.Vendor != "Brocade/Extreme" || probe.OS != "FastIron" {
+		t.Fatalf("SafeProbeFor(Brocade/Extreme, FastIron) = %+v", probe)
+	}
+	if SafeProbeFor("Acme", "RoadRunnerOS") != nil {
+		t.Error("expected no probe for an unknown device")
+	}
+}
+
+// probeRecorder answers every probe with the same output
+type probeRecorder struct {
+	writes []string
+	output string
+}
+
+func (p *probeRecorder) Write(b []byte) (int, error) {
+	p.writes = append(p.writes, string(b))
+	return len(b), nil
+}
+
+func (p *probeRecorder) ReadUntil(time.Duration, ...[]byte) (string, error) {
+	return p.output, nil
+}
+
+func TestMaybeProbeMinConfidence(t *testing.T) {
+	fx := loadFixture(t, "cisco_ios")
+	cand := Candidate{
+		Vendor:        "Cisco",
+		OS:            "IOS",
+		Prob:          0.7,
+		NextSafeProbe: SafeProbeFor("Cisco", "IOS"),
+		stage:         StagePrompt,
+		Prompt:        fx.Prompt,
+	}
+
+	tests := []struct {
+		name      string
+		minProb   float64
+		wantProbe bool
+	}{
+		{"default threshold", DefaultMinProbeConfidence, true},
+		{"strict threshold", 0.8, false},
+		{"threshold equal to probability", 0.7, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sess := &probeRecorder{output: fx.Probe}
+			cfg := Config{MinProbeConfidence: tt.minProb, MaxEvidence: DefaultMaxEvidence}
+			_, updated, err := MaybeProbe(sess, cand, time.Second, cfg)
+			if err != nil {
+				t.Fatalf("MaybeProbe: %v", err)
+			}
+			if got := len(sess.writes) > 0; got != tt.wantProbe {
+				t.Fatalf("probe sent = %v, want %v", got, tt.wantProbe)
+			}
+			if tt.wantProbe && (updated == nil || updated.Prob <= cand.Prob) {
+				t.Errorf("updated = %+v, want a higher probability", updated)
+			}
+		})
+	}
+}
+
+func TestFinalizeMaxEvidence(t *testing.T) {
+	cands := []Candidate{{Vendor: "Cisco", OS: "IOS", Prob: 0.9, Evidence: []string{"a", "b", "c", "d", "b"}}}
+
+	tests := []struct {
+		maxEvidence int
+		want        string
+	}{
+		{0, "a,b,c"},
+		{1, "a"},
+		{5, "a,b,c,d,probe output captured"},
+		{10, "a,b,c,d,probe output captured"},
+	}
+
+	for _, tt := range tests {
+		res := Finalize(StagePrompt, cands, "", "Switch#", "output", Config{MaxEvidence: tt.maxEvidence})
+		if got := strings.Join(res.Evidence, ","); got != tt.want {
+			t.Errorf("MaxEvidence %d: evidence = %q, want %q", tt.maxEvidence, got, tt.want)
+		}
+	}
+}
+
+// restoreRegistry undoes signatures registered during a test
+func restoreRegistry(t *testing.T) {
+	t.Helper()
+	saved := append([]*Signature(nil), signatureRegistry...)
+	t.Cleanup(func() { signatureRegistry = saved })
+}
+
+func TestLoadSignaturesFromFile(t *testing.T) {
+	restoreRegistry(t)
+	builtIn := len(signatureRegistry)
+
+	if err := LoadSignaturesFromFile(filepath.Join("testdata", "user_signatures.json")); err != nil {
+		t.Fatalf("LoadSignaturesFromFile: %v", err)
+	}
+	if got := len(signatureRegistry) - builtIn; got != 2 {
+		t.Fatalf("registered %d signatures, want 2", got)
+	}
+	boot := lookupSignature("Acme", "CoyoteBoot")
+	if boot == nil || !boot.UserDefined || boot.Weight != 0.1 {
+		t.Fatalf("CoyoteBoot signature = %+v", boot)
+	}
+	if sig := lookupSignature("Cisco", "IOS"); sig == nil || sig.UserDefined {
+		t.Errorf("built-in signature marked user-defined: %+v", sig)
+	}
+
+	fx := loadFixture(t, "acme_rros")
+	rx := fx.Banner + "\n" + fx.Prompt
+	stage, candidates := Analyze(rx, fx.Prompt)
+	if len(candidates) == 0 || candidates[0].Vendor != "Acme" || candidates[0].OS != "RoadRunnerOS" {
+		t.Fatalf("candidates = %+v, want Acme RoadRunnerOS first", candidates)
+	}
+	res := Finalize(stage, candidates, rx, fx.Prompt, fx.Probe, DefaultConfig())
+	if res.Model != "RR-4800X" || res.SerialNumber != "RR48-000123" {
+		t.Errorf("model, serial = %q, %q, want RR-4800X, RR48-000123", res.Model, res.SerialNumber)
+	}
+}
+
+func TestLoadSignaturesFromFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"not json", `{"vendor":`, "failed to parse"},
+		{"object instead of array", `{"vendor": "Acme"}`, "failed to parse"},
+		{"unknown field", `[{"vendor": "Acme", "os": "X", "promt": []}]`, "unknown field"},
+		{"missing os", `[{"vendor": "Acme", "prompt": [{"label": "p", "regex": "x"}]}]`, "vendor and os are required"},
+		{"no patterns", `[{"vendor": "Acme", "os": "X"}]`, "at least one"},
+		{"bad weight", `[{"vendor": "Acme", "os": "X", "weight": 2, "prompt": [{"label": "p", "regex": "x"}]}]`, "weight"},
+		{"bad prompt regex", `[{"vendor": "Acme", "os": "X", "prompt": [{"label": "broken", "regex": "(x"}]}]`, `Acme/X: invalid prompt pattern "broken"`},
+		{"bad version regex", `[{"vendor": "Acme", "os": "X", "prelogin": [{"label": "b", "regex": "x"}], "version_scrape": ["[x"]}]`, "invalid version_scrape pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restoreRegistry(t)
+			builtIn := len(signatureRegistry)
+
+			path := filepath.Join(t.TempDir(), "bad.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			err := LoadSignaturesFromFile(path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+			}
+			if len(signatureRegistry) != builtIn {
+				t.Errorf("registry grew to %d after a failed load", len(signatureRegistry))
+			}
+		})
+	}
+
+	if err := LoadSignaturesFromFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected error for a missing file")
+	}
+}
+
+func TestFinalizeModelParts(t *testing.T) {
+	tests := []struct {
+		fixture   string
+		wantParts []string
+	}{
+		{"junos", []string{"mx204", "JUNOS", "22.2R3.15"}},
+		{"hpe_comware", []string{"HPE 5130-24G-PoE+-4SFP+ EI", "7.1.059"}},
+		{"dell_os10", []string{"Dell S5248F-ON"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			fx := loadFixture(t, tt.fixture)
+			rx := strings.TrimSpace(fx.Banner + "\n" + fx.Prompt)
+			stage, candidates := Analyze(rx, fx.Prompt)
+			res := Finalize(stage, candidates, rx, fx.Prompt, fx.Probe, DefaultConfig())
+
+			if strings.Join(res.ModelParts, "|") != strings.Join(tt.wantParts, "|") {
+				t.Errorf("ModelParts = %q, want %q", res.ModelParts, tt.wantParts)
+			}
+			if want := strings.Join(tt.wantParts, " "); res.Model != want {
+				t.Errorf("Model = %q, want %q", res.Model, want)
+			}
+		})
+	}
+}
+
+func TestFinalizeSerialNumber(t *testing.T) {
+	tests := []struct {
+		vendor, os string
+		probe      string
+		want       string
+	}{
+		{"Cisco", "IOS", "cisco WS-C2960-24TT-L (PowerPC405) processor\nProcessor board ID FOC1234X5YZ\n", "FOC1234X5YZ"},
+		{"Aruba", "AOS-CX", "  System Serial Number : SG12ABC345\n", "SG12ABC345"},
+		{"Juniper", "JUNOS", "Hostname: router\nModel: mx204\nSerial#: JN1234567ABC\n", "JN1234567ABC"},
+		{"Arista", "EOS", loadFixture(t, "arista_eos").Probe, "JPE20123456"},
+		{"Fortinet", "FortiOS", loadFixture(t, "fortigate").Probe, "FG60ETK123456789"},
+		{"Extreme", "ExtremeXOS", "Switch      : 800616-00-06 1716G-20123 Rev 6.0\n", "1716G-20123"},
+		{"Huawei", "VRP", "ESN of master: 2102351931P0K8000123\n", "2102351931P0K8000123"},
+		{"Dell", "OS10", "Service Tag     : 7XYZ123\n", "7XYZ123"},
+		// Signatures without a matching pattern fall back to the generic ones
+		{"OpenWrt", "OpenWrt", "Serial Number: OW-1234\n", "OW-1234"},
+		{"Cisco", "IOS", "no serial here\n", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.vendor+" "+tt.os, func(t *testing.T) {
+			cands := []Candidate{{Vendor: tt.vendor, OS: tt.os, Prob: 0.9}}
+			res := Finalize(StagePrompt, cands, "", "", tt.probe, DefaultConfig())
+			if res.SerialNumber != tt.want {
+				t.Errorf("SerialNumber = %q, want %q", res.SerialNumber, tt.want)
+			}
+		})
+	}
+}
@@ -5,14 +5,15 @@ import (
 	"strings"
 )
 
-var ansiRegexp = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+// ANSIRegexp matches ANSI CSI escape sequences such as colour codes
+var ANSIRegexp = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
 
 // Normalize removes ANSI sequences and harmonises newlines.
 func Normalize(in string) string {
 	if in == "" {
 		return ""
 	}
-	cleaned := ansiRegexp.ReplaceAllString(in, "")
+	cleaned := ANSIRegexp.ReplaceAllString(in, "")
 	cleaned = strings.ReplaceAll(cleaned, "\r\n", "\n")
 	cleaned = strings.ReplaceAll(cleaned, "\r", "\n")
 	cleaned = strings.ReplaceAll(cleaned, "\u0000", "")
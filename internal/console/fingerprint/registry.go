@@ -18,8 +18,10 @@ type Signature struct {
 	Login         []*regexPattern
 	Prompt        []*regexPattern
 	VersionScrape []*regexp.Regexp
+	SerialScrape  []*regexp.Regexp // Chassis serial number in probe output
 	SafeProbe     *SafeProbe
 	Weight        float64
+	UserDefined   bool // Loaded by LoadSignaturesFromFile
 }
 
 var signatureRegistry []*Signature
@@ -90,15 +92,36 @@ func lookupSignature(vendor, os string) *Signature {
 	return nil
 }
 
-func scrapeModel(text string, cand Candidate) string {
+// scrapeModel returns every non-empty sub-match of the candidate's
+// VersionScrape patterns, in pattern order, so details spread over several
+// lines (model, OS, release) are all kept
+func scrapeModel(text string, cand Candidate) []string {
 	sig := lookupSignature(cand.Vendor, cand.OS)
 	if sig == nil {
-		return ""
+		return nil
 	}
+	var parts []string
 	for _, re := range sig.VersionScrape {
-		if match := re.FindStringSubmatch(text); len(match) > 1 {
-			return strings.TrimSpace(match[1])
+		for _, match := range re.FindAllStringSubmatch(text, -1) {
+			for _, group := range match[1:] {
+				if part := strings.TrimSpace(group); part != "" {
+					parts = append(parts, part)
+				}
+			}
+		}
+	}
+	return parts
+}
+
+// scrapeSerial returns the first serial number the candidate's SerialScrape
+// patterns find in text, falling back to the generic serial patterns
+func scrapeSerial(text string, cand Candidate) string {
+	if sig := lookupSignature(cand.Vendor, cand.OS); sig != nil {
+		for _, re := range sig.SerialScrape {
+			if match := re.FindStringSubmatch(text); len(match) > 1 {
+				return strings.TrimSpace(match[1])
+			}
 		}
 	}
-	return ""
+	return ScrapeSerial(text)
 }
@@ -7,6 +7,8 @@ import (
 
 // SafeProbe describes a guarded, read-only command used for device identification.
 type SafeProbe struct {
+	Vendor    string // Set from the registry key
+	OS        string
 	Name      string
 	Command   string
 	Expect    []*regexp.Regexp
@@ -39,6 +41,23 @@ func (sp *SafeProbe) ScrapeModel(out string) string {
 	return ""
 }
 
+// serialPatterns find a chassis serial number in probe output
+var serialPatterns = compileRegexps(
+	`(?m)^Processor board ID (\S+)`,
+	`(?mi)^\s*System serial number\s*:\s*(\S+)`,
+	`(?mi)^\s*serial[- ]number\s*[:=]\s*(\S+)`,
+)
+
+// ScrapeSerial returns the device serial number shown in probe output, or ""
+func ScrapeSerial(out string) string {
+	for _, re := range serialPatterns {
+		if match := re.FindStringSubmatch(out); len(match) > 1 {
+			return strings.TrimSpace(match[1])
+		}
+	}
+	return ""
+}
+
 func compileRegexps(patterns ...string) []*regexp.Regexp {
 	out := make([]*regexp.Regexp, 0, len(patterns))
 	for _, p := range patterns {
@@ -60,6 +79,8 @@ var (
 	guardVyOS       = regexp.MustCompile(`(?m)^vyos@.*[$#] ?$`)
 	guardFGT        = regexp.MustCompile(`(?m)^FGT\w*\s?[#>] ?$`)
 	guardPaloAlto   = regexp.MustCompile(`(?m)^[\w\-]+@PA-\w+[>#] ?$`)
+	guardNokia      = regexp.MustCompile(`(?m)^\*?[AB]:[A-Za-z0-9._-]+[#>] ?$`)
+	guardAireOS     = regexp.MustCompile(`(?m)^\(Cisco Controller\) ?> ?$`)
 )
 
 var safeProbes = map[string]*SafeProbe{
@@ -103,6 +124,22 @@ var safeProbes = map[string]*SafeProbe{
 		Scrape:    compileRegexps(`(?m)^Hardware\s+:\s+(.*)`),
 		TimeoutMs: 1400,
 	},
+	"Cisco:AireOS": {
+		Name:      "cisco_show_sysinfo",
+		Command:   "show sysinfo",
+		Guard:     guardAireOS,
+		Expect:    compileRegexps(`Cisco Controller`, `Product Version`),
+		Scrape:    compileRegexps(`Machine Model\.+\s+(\S+)`),
+		TimeoutMs: 1600,
+	},
+	"Arista:EOS": {
+		Name:      "arista_show_version",
+		Command:   "show version",
+		Guard:     guardCisco,
+		Expect:    compileRegexps(`Arista`),
+		Scrape:    compileRegexps(`(?m)^Arista (\S+)`),
+		TimeoutMs: 1200,
+	},
 	"Juniper:JUNOS": {
 		Name:      "junos_show_version",
 		Command:   "show version",
@@ -199,6 +236,22 @@ var safeProbes = map[string]*SafeProbe{
 		Scrape:    compileRegexps(`(?m)^System Model:\s+(.*)`),
 		TimeoutMs: 1600,
 	},
+	"Extreme:ExtremeXOS": {
+		Name:      "extreme_show_version",
+		Command:   "show version",
+		Guard:     guardCisco,
+		Expect:    compileRegexps(`ExtremeXOS`, `Serial`),
+		Scrape:    compileRegexps(`(?m)^Switch\s*: (\S+)`),
+		TimeoutMs: 1600,
+	},
+	"Nokia:SR-OS": {
+		Name:      "nokia_show_version",
+		Command:   "show version",
+		Guard:     guardNokia,
+		Expect:    compileRegexps(`TiMOS`, `Nokia`),
+		Scrape:    compileRegexps(`Nokia (\S+ \S+) Copyright`),
+		TimeoutMs: 1600,
+	},
 	"VyOS:VyOS": {
 		Name:      "vyos_show_version",
 		Command:   "show version",
@@ -225,10 +278,21 @@ var safeProbes = map[string]*SafeProbe{
 	},
 }
 
+func init() {
+	for key, probe := range safeProbes {
+		probe.Vendor, probe.OS, _ = strings.Cut(key, ":")
+	}
+}
+
 func safeProbeKey(vendor, os string) string {
 	return vendor + ":" + os
 }
 
+// SafeProbeFor returns the safe probe registered for a vendor and OS, or nil
+func SafeProbeFor(vendor, os string) *SafeProbe {
+	return getSafeProbe(vendor, os)
+}
+
 func getSafeProbe(vendor, os string) *SafeProbe {
 	if probe, ok := safeProbes[safeProbeKey(vendor, os)]; ok {
 		return probe
@@ -41,6 +41,10 @@ func init() {
 			`(?m)^[Cc]isco (Catalyst|Switch|Router)\s+([A-Z0-9-]+)`,
 			`(?m)^Model number\s+:\s+(\S+)`,
 		),
+		SerialScrape: compileRegexps(
+			`(?m)^Processor board ID (\S+)`,
+			`(?mi)^System serial number\s*:\s*(\S+)`,
+		),
 		SafeProbe: getSafeProbe("Cisco", "IOS"),
 	})
 
@@ -55,6 +59,10 @@ func init() {
 			`(?m)^Cisco (\S+) Software`,
 			`(?m)^cisco (\S+) \(`,
 		),
+		SerialScrape: compileRegexps(
+			`(?m)^Processor board ID (\S+)`,
+			`(?mi)^System serial number\s*:\s*(\S+)`,
+		),
 		SafeProbe: getSafeProbe("Cisco", "IOS-XE"),
 	})
 
@@ -65,6 +73,7 @@ func init() {
 		PreLogin:      makePatternSlice([]patternSpec{{"Cisco Nexus", `Cisco Nexus Operating System`}}),
 		Prompt:        makePatternSlice([]patternSpec{{"NX-OS prompt", `(?m)^(Nexus|switch)[#>] ?$`}}),
 		VersionScrape: makeVersionRegex(`(?m)^\s*(cisco Nexus .*?)$`, `(?m)^Hardware\s+:\s+(.*)`),
+		SerialScrape:  compileRegexps(`(?mi)^\s*Processor board ID (\S+)`),
 		SafeProbe:     getSafeProbe("Cisco", "NX-OS"),
 	})
 
@@ -75,6 +84,7 @@ func init() {
 		PreLogin:      makePatternSlice([]patternSpec{{"IOS XR", `IOS XR`}}),
 		Prompt:        makePatternSlice([]patternSpec{{"IOS XR prompt", `(?m)^RP/\d+/\S+:\S+# ?$`}}),
 		VersionScrape: makeVersionRegex(`(?m)^cisco IOS XR Software, Version ([\w.\-]+)`),
+		SerialScrape:  compileRegexps(`(?m)\bSN:\s*(\S+)`),
 		SafeProbe:     getSafeProbe("Cisco", "IOS-XR"),
 	})
 
@@ -85,9 +95,35 @@ func init() {
 		PreLogin:      makePatternSlice([]patternSpec{{"Cisco ASA", `Cisco Adaptive Security Appliance`}}),
 		Prompt:        makePatternSlice([]patternSpec{{"ASA prompt", `(?m)^ciscoasa(?:\([^\)]*\))?[#>] ?$`}}),
 		VersionScrape: makeVersionRegex(`(?m)^Hardware\s+:\s+(.*)`),
+		SerialScrape:  compileRegexps(`(?m)^Serial Number:\s+(\S+)`),
 		SafeProbe:     getSafeProbe("Cisco", "ASA"),
 	})
 
+	registerSignature(&Signature{
+		Vendor:   "Cisco",
+		OS:       "AireOS",
+		Weight:   0.05,
+		PreLogin: makePatternSlice([]patternSpec{{"WLC user prompt", `User:`}, {"Cisco Controller", `Cisco Controller`}}),
+		Prompt:   makePatternSlice([]patternSpec{{"WLC prompt", `(?m)^\(Cisco Controller\) ?[>]`}}),
+		VersionScrape: makeVersionRegex(
+			`Machine Model\.+\s+(\S+)`,
+			`Product Version\.+\s+(\S+)`,
+		),
+		SerialScrape: compileRegexps(`(?m)\bSN:\s*(\S+)`),
+		SafeProbe:    getSafeProbe("Cisco", "AireOS"),
+	})
+
+	registerSignature(&Signature{
+		Vendor:        "Arista",
+		OS:            "EOS",
+		Weight:        0.05,
+		PreLogin:      makePatternSlice([]patternSpec{{"Arista EOS", `Arista Networks EOS`}}),
+		Prompt:        makePatternSlice([]patternSpec{{"EOS prompt", `(?m)^([A-Za-z0-9._-]+)[#>] ?$`}}),
+		VersionScrape: makeVersionRegex(`Software image version: ([\d.]+[A-Z]?)`),
+		SerialScrape:  compileRegexps(`(?m)^Serial number:\s+(\S+)`),
+		SafeProbe:     getSafeProbe("Arista", "EOS"),
+	})
+
 	registerSignature(&Signature{
 		Vendor:        "Aruba",
 		OS:            "AOS-CX",
@@ -95,6 +131,7 @@ func init() {
 		PreLogin:      makePatternSlice([]patternSpec{{"ArubaOS-CX", `ArubaOS-CX`}}),
 		Prompt:        makePatternSlice([]patternSpec{{"Aruba CX prompt", `(?mi)^(mgr|admin|[A-Za-z0-9._-]+)# ?$`}}),
 		VersionScrape: makeVersionRegex(`(?m)^Platform :\s+(.*)`),
+		SerialScrape:  compileRegexps(`(?mi)^\s*(?:System|Chassis) Serial (?:Number|Nbr)\s+:\s+(\S+)`),
 		SafeProbe:     getSafeProbe("Aruba", "AOS-CX"),
 	})
 
@@ -105,6 +142,7 @@ func init() {
 		PreLogin:      makePatternSlice([]patternSpec{{"ArubaOS-S", `Aruba 2930F|ProCurve|ArubaOS-S`}}),
 		Prompt:        makePatternSlice([]patternSpec{{"Aruba AOS-S prompt", `(?m)^(HP|Aruba|ProCurve)[\w\-]*[>#] ?$`}}),
 		VersionScrape: makeVersionRegex(`(?m)^Image stamp: (.*)`, `(?m)^ROM Version : (.*)`),
+		SerialScrape:  compileRegexps(`(?mi)^\s*Serial Number\s+:\s+(\S+)`),
 		SafeProbe:     getSafeProbe("Aruba", "AOS-S"),
 	})
 
@@ -115,8 +153,12 @@ func init() {
 		PreLogin:      makePatternSlice([]patternSpec{{"JUNOS", `JUNOS`}, {"Amnesiac", `Amnesiac`}}),
 		Login:         makePatternSlice([]patternSpec{{"login:", `(?i)^login:`}}),
 		Prompt:        makePatternSlice([]patternSpec{{"Junos prompt", `(?m)^[\w\-]+@[\w\-.]+[>#] ?$`}}),
-		VersionScrape: makeVersionRegex(`(?m)^Model:\s+(\S+)`),
-		SafeProbe:     getSafeProbe("Juniper", "JUNOS"),
+		VersionScrape: makeVersionRegex(`(?m)^Model:\s+(\S+)`, `(?m)^(JUNOS) Base OS boot \[([^\]]+)\]`),
+		SerialScrape: compileRegexps(
+			`(?m)Serial#:\s+(\S+)`,
+			`(?m)^Chassis\s+(\S+)\s`,
+		),
+		SafeProbe: getSafeProbe("Juniper", "JUNOS"),
 	})
 
 	registerSignature(&Signature{
@@ -126,6 +168,7 @@ func init() {
 		PreLogin:      makePatternSlice([]patternSpec{{"RouterOS", `MikroTik RouterOS`}}),
 		Prompt:        makePatternSlice([]patternSpec{{"MikroTik prompt", `(?m)^\[[^\]]+\]\s?> ?$`}}),
 		VersionScrape: makeVersionRegex(`(?m)^\s*board-name:\s+(.*)`),
+		SerialScrape:  compileRegexps(`(?m)^\s*serial-number:\s+(\S+)`),
 		SafeProbe:     getSafeProbe("MikroTik", "RouterOS"),
 	})
 
@@ -136,6 +179,7 @@ func init() {
 		PreLogin:      makePatternSlice([]patternSpec{{"EdgeOS", `Welcome to EdgeOS`}}),
 		Prompt:        makePatternSlice([]patternSpec{{"EdgeOS prompt", `(?m)^[\w\-]+@[\w\-.]+(:~)?[$#] ?$`}}),
 		VersionScrape: makeVersionRegex(`(?m)^Linux (\S+)`),
+		SerialScrape:  compileRegexps(`(?m)^HW S/N:\s+(\S+)`),
 		SafeProbe:     getSafeProbe("Ubiquiti", "EdgeOS"),
 	})
 
@@ -147,6 +191,7 @@ func init() {
 		Login:         makePatternSlice([]patternSpec{{"FortiGate login", `FortiGate-\w+ login:`}}),
 		Prompt:        makePatternSlice([]patternSpec{{"FortiGate prompt", `(?m)^FGT\w*\s?[#>] ?$`}}),
 		VersionScrape: makeVersionRegex(`(?m)^Version:\s+(.*)`),
+		SerialScrape:  compileRegexps(`(?m)^Serial-Number:\s+(\S+)`),
 		SafeProbe:     getSafeProbe("Fortinet", "FortiOS"),
 	})
 
@@ -157,6 +202,7 @@ func init() {
 		PreLogin:      makePatternSlice([]patternSpec{{"PA banner", `PA-\d+`}}),
 		Prompt:        makePatternSlice([]patternSpec{{"PAN-OS prompt", `(?m)^[\w\-]+@PA-\w+[>#] ?$`}}),
 		VersionScrape: makeVersionRegex(`(?m)^model:\s+(\S+)`),
+		SerialScrape:  compileRegexps(`(?m)^serial:\s+(\S+)`),
 		SafeProbe:     getSafeProbe("PaloAlto", "PAN-OS"),
 	})
 
@@ -167,6 +213,7 @@ func init() {
 		PreLogin:      makePatternSlice([]patternSpec{{"Huawei VRP", `HUAWEI`}}),
 		Prompt:        makePatternSlice([]patternSpec{{"VRP prompt", `(?m)^(<[Hh][PpEe]?[^>]*>|\[[Hh].*?\])$`}}),
 		VersionScrape: makeVersionRegex(`(?m)^Product Version: (.*)`, `(?m)^Huawei Versatile Routing Platform Software \(VRP\) (.*)`),
+		SerialScrape:  compileRegexps(`(?mi)^\s*ESN(?: of [\w ]+)?\s*:\s*(\S+)`),
 		SafeProbe:     getSafeProbe("Huawei", "VRP"),
 	})
 
@@ -177,6 +224,7 @@ func init() {
 		PreLogin:      makePatternSlice([]patternSpec{{"Comware", `Comware`}}),
 		Prompt:        makePatternSlice([]patternSpec{{"Comware prompt", `(?m)^((<|\[)HPE?.*?(>|\]))$`}}),
 		VersionScrape: makeVersionRegex(`(?m)^System Name: (.*)`, `(?m)^HP Comware Platform Software, Version (.*)`),
+		SerialScrape:  compileRegexps(`(?m)DEVICE_SERIAL_NUMBER\s*:\s*(\S+)`),
 		SafeProbe:     getSafeProbe("HPE", "Comware"),
 	})
 
@@ -187,6 +235,7 @@ func init() {
 		PreLogin:      makePatternSlice([]patternSpec{{"Dell OS10", `Dell EMC Networking OS10`}}),
 		Prompt:        makePatternSlice([]patternSpec{{"Dell prompt", `(?m)^Dell\w*[#>] ?$`}, {"Generic shell", `(?m)^.*[>#] ?$`}}),
 		VersionScrape: makeVersionRegex(`(?m)^Product:\s+(.*)`),
+		SerialScrape:  compileRegexps(`(?mi)^\s*Service Tag\s*:\s*(\S+)`),
 		SafeProbe:     getSafeProbe("Dell", "OS10"),
 	})
 
@@ -197,9 +246,35 @@ func init() {
 		PreLogin:      makePatternSlice([]patternSpec{{"FastIron", `FastIron`}}),
 		Prompt:        makePatternSlice([]patternSpec{{"ICX prompt", `(?m)^(ICX|BR-CD|FastIron).*?[#>] ?$`}}),
 		VersionScrape: makeVersionRegex(`(?m)^System Model:\s+(.*)`),
+		SerialScrape:  compileRegexps(`(?m)Serial\s+#:\s*(\S+)`),
 		SafeProbe:     getSafeProbe("Brocade/Extreme", "FastIron"),
 	})
 
+	registerSignature(&Signature{
+		Vendor:        "Extreme",
+		OS:            "ExtremeXOS",
+		Weight:        0.05,
+		PreLogin:      makePatternSlice([]patternSpec{{"ExtremeXOS", `ExtremeXOS`}}),
+		Prompt:        makePatternSlice([]patternSpec{{"EXOS prompt", `(?m)^\* ?([A-Za-z0-9._-]+) [#*] ?$`}}),
+		VersionScrape: makeVersionRegex(`Image.*version ([\d.]+)`),
+		SerialScrape: compileRegexps(
+			`(?m)^Serial No\s*:\s*(\S+)`,
+			`(?m)^Switch\s*: \S+ (\S+)`,
+		),
+		SafeProbe: getSafeProbe("Extreme", "ExtremeXOS"),
+	})
+
+	registerSignature(&Signature{
+		Vendor:        "Nokia",
+		OS:            "SR-OS",
+		Weight:        0.05,
+		PreLogin:      makePatternSlice([]patternSpec{{"TiMOS banner", `TiMOS`}}),
+		Prompt:        makePatternSlice([]patternSpec{{"SR-OS prompt", `(?m)^[AB]:[A-Za-z0-9._-]+[#>] ?$`}}),
+		VersionScrape: makeVersionRegex(`TiMOS-([\S]+)`),
+		SerialScrape:  compileRegexps(`(?mi)^\s*Serial number\s*:\s*(\S+)`),
+		SafeProbe:     getSafeProbe("Nokia", "SR-OS"),
+	})
+
 	registerSignature(&Signature{
 		Vendor:        "VyOS",
 		OS:            "VyOS",
@@ -207,6 +282,7 @@ func init() {
 		PreLogin:      makePatternSlice([]patternSpec{{"VyOS login", `vyos login:`}}),
 		Prompt:        makePatternSlice([]patternSpec{{"VyOS prompt", `(?m)^vyos@.*[$#] ?$`}}),
 		VersionScrape: makeVersionRegex(`(?m)^Version: (.*)`),
+		SerialScrape:  compileRegexps(`(?m)^Hardware S/N:\s+(\S+)`),
 		SafeProbe:     getSafeProbe("VyOS", "VyOS"),
 	})
 
@@ -237,14 +313,16 @@ func init() {
 		PreLogin:      makePatternSlice([]patternSpec{{"U-Boot", `\bU-Boot\b`}}),
 		Prompt:        makePatternSlice([]patternSpec{{"U-Boot prompt", `(?m)^=> ?$`}}),
 		VersionScrape: makeVersionRegex(`U-Boot\s+(\S+)`),
+		SerialScrape:  compileRegexps(`(?m)^serial#=(\S+)`),
 	})
 
 	registerSignature(&Signature{
-		Vendor:   "Bootloader",
-		OS:       "ROMMON",
-		Weight:   0.1,
-		PreLogin: makePatternSlice([]patternSpec{{"ROMMON", `ROMMON`}, {"System Bootstrap", `System Bootstrap`}}),
-		Prompt:   makePatternSlice([]patternSpec{{"rommon prompt", `(?m)^rommon \d+ >$`}}),
+		Vendor:       "Bootloader",
+		OS:           "ROMMON",
+		Weight:       0.1,
+		PreLogin:     makePatternSlice([]patternSpec{{"ROMMON", `ROMMON`}, {"System Bootstrap", `System Bootstrap`}}),
+		Prompt:       makePatternSlice([]patternSpec{{"rommon prompt", `(?m)^rommon \d+ >$`}}),
+		SerialScrape: compileRegexps(`(?m)^\s*SYSTEM_SERIAL_NUM\s*=\s*(\S+)`),
 	})
 
 	registerSignature(&Signature{
@@ -0,0 +1,132 @@
+package fingerprint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/alexpitcher/LanAudit/internal/logging"
+)
+
+// defaultUserWeight is the base score of a user-defined signature that
+// doesn't set one, matching the built-in signatures
+const defaultUserWeight = 0.05
+
+// userPattern is a labelled regex in a signature file
+type userPattern struct {
+	Label string `json:"label"`
+	Regex string `json:"regex"`
+}
+
+// userSignature is the JSON form of a Signature
+type userSignature struct {
+	Vendor        string        `json:"vendor"`
+	OS            string        `json:"os"`
+	Weight        float64       `json:"weight,omitempty"`
+	PreLogin      []userPattern `json:"prelogin,omitempty"`
+	Login         []userPattern `json:"login,omitempty"`
+	Prompt        []userPattern `json:"prompt,omitempty"`
+	VersionScrape []string      `json:"version_scrape,omitempty"`
+	SerialScrape  []string      `json:"serial_scrape,omitempty"`
+}
+
+// LoadSignaturesFromFile adds the signatures in a JSON file to the registry,
+// after the built-in ones. The file holds an array of objects with vendor,
+// os, an optional weight, prelogin/login/prompt arrays of {label, regex}
+// and version_scrape/serial_scrape regex strings. Every pattern is checked
+// first, so a bad file adds nothing.
+func LoadSignaturesFromFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read signature file: %w", err)
+	}
+
+	var entries []userSignature
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&entries); err != nil {
+		return fmt.Errorf("failed to parse signature file %s: %w", path, err)
+	}
+
+	sigs := make([]*Signature, 0, len(entries))
+	for i, entry := range entries {
+		sig, err := entry.compile()
+		if err != nil {
+			return fmt.Errorf("signature %d in %s: %w", i+1, path, err)
+		}
+		sigs = append(sigs, sig)
+	}
+
+	for _, sig := range sigs {
+		registerSignature(sig)
+	}
+	logging.Infof("loaded %d user-defined signatures from %s", len(sigs), path)
+	return nil
+}
+
+// compile validates the entry and builds its Signature
+func (u userSignature) compile() (*Signature, error) {
+	if u.Vendor == "" || u.OS == "" {
+		return nil, fmt.Errorf("vendor and os are required")
+	}
+	if u.Weight < 0 || u.Weight > 1 {
+		return nil, fmt.Errorf("%s/%s: weight %v must be between 0 and 1", u.Vendor, u.OS, u.Weight)
+	}
+	if len(u.PreLogin)+len(u.Login)+len(u.Prompt) == 0 {
+		return nil, fmt.Errorf("%s/%s: at least one prelogin, login or prompt pattern is required", u.Vendor, u.OS)
+	}
+
+	sig := &Signature{
+		Vendor:      u.Vendor,
+		OS:          u.OS,
+		Weight:      u.Weight,
+		UserDefined: true,
+	}
+	if sig.Weight == 0 {
+		sig.Weight = defaultUserWeight
+	}
+
+	var err error
+	if sig.PreLogin, err = compileUserPatterns("prelogin", u.PreLogin); err != nil {
+		return nil, fmt.Errorf("%s/%s: %w", u.Vendor, u.OS, err)
+	}
+	if sig.Login, err = compileUserPatterns("login", u.Login); err != nil {
+		return nil, fmt.Errorf("%s/%s: %w", u.Vendor, u.OS, err)
+	}
+	if sig.Prompt, err = compileUserPatterns("prompt", u.Prompt); err != nil {
+		return nil, fmt.Errorf("%s/%s: %w", u.Vendor, u.OS, err)
+	}
+	for _, p := range u.VersionScrape {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("%s/%s: invalid version_scrape pattern %q: %w", u.Vendor, u.OS, p, err)
+		}
+		sig.VersionScrape = append(sig.VersionScrape, re)
+	}
+	for _, p := range u.SerialScrape {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("%s/%s: invalid serial_scrape pattern %q: %w", u.Vendor, u.OS, p, err)
+		}
+		sig.SerialScrape = append(sig.SerialScrape, re)
+	}
+	return sig, nil
+}
+
+func compileUserPatterns(kind string, specs []userPattern) ([]*regexPattern, error) {
+	out := make([]*regexPattern, 0, len(specs))
+	for _, spec := range specs {
+		re, err := regexp.Compile(spec.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s pattern %q: %w", kind, spec.Label, err)
+		}
+		label := spec.Label
+		if label == "" {
+			label = spec.Regex
+		}
+		out = append(out, &regexPattern{Label: label, Regex: re})
+	}
+	return out, nil
+}
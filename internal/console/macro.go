@@ -0,0 +1,365 @@
+package console
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alexpitcher/LanAudit/internal/console/fingerprint"
+	"github.com/alexpitcher/LanAudit/internal/logging"
+)
+
+// DefaultMacroStepTimeout is the shortest wait recorded for a step and the
+// wait used for steps without a timeout
+const DefaultMacroStepTimeout = 5 * time.Second
+
+var macroNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// secretPromptPattern matches prompts whose answer must not be recorded,
+// e.g. "Password:" or "Enter secret:"
+var secretPromptPattern = regexp.MustCompile(`(?i)(password|passphrase|secret)\s*:?\s*$`)
+
+// MacroStep sends one line and optionally waits for the device to answer.
+// A Secret step answers a password prompt; its line is not saved and is
+// supplied when the macro is played.
+type MacroStep struct {
+	Send      string `json:"send"`
+	Secret    bool   `json:"secret,omitempty"`
+	WaitFor   string `json:"wait_for,omitempty"`
+	TimeoutMs int    `json:"timeout_ms,omitempty"`
+}
+
+// Macro is a named sequence of console commands
+type Macro struct {
+	Name  string      `json:"name"`
+	Steps []MacroStep `json:"steps"`
+}
+
+// SecretSteps returns how many steps need a value supplied at playback
+func (m Macro) SecretSteps() int {
+	n := 0
+	for _, step := range m.Steps {
+		if step.Secret {
+			n++
+		}
+	}
+	return n
+}
+
+// MacroRecorder turns the lines typed into a session into macro steps. Each
+// step waits for the last line the device printed before the next command,
+// usually its prompt.
+type MacroRecorder struct {
+	sess    *Session
+	name    string
+	watcher chan []byte
+	done    chan struct{}
+	exited  chan struct{}
+
+	mu         sync.Mutex
+	steps      []MacroStep
+	line       []byte     // command being typed
+	typing     bool       // line has been started
+	secret     bool       // line answers a password prompt
+	lastCR     bool       // swallow the LF of a CRLF
+	pending    *MacroStep // sent command collecting its response
+	sentAt     time.Time
+	output     strings.Builder
+	lastOutput time.Time
+	stopped    bool
+}
+
+// RecordMacro starts recording the commands sent to sess
+func RecordMacro(sess *Session, name string) (*MacroRecorder, error) {
+	if !macroNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid macro name %q: use letters, digits, '.', '-' and '_'", name)
+	}
+
+	r := &MacroRecorder{
+		sess:    sess,
+		name:    name,
+		watcher: make(chan []byte, 256),
+		done:    make(chan struct{}),
+		exited:  make(chan struct{}),
+	}
+
+	sess.mu.Lock()
+	if sess.macroRecorder != nil {
+		sess.mu.Unlock()
+		return nil, fmt.Errorf("session %s is already recording a macro", sess.id)
+	}
+	sess.macroRecorder = r
+	sess.watchers[r.watcher] = struct{}{}
+	sess.mu.Unlock()
+
+	go r.collect()
+	logging.Infof("session %s recording macro %s", sess.id, name)
+	return r, nil
+}
+
+// Name returns the name of the macro being recorded
+func (r *MacroRecorder) Name() string {
+	return r.name
+}
+
+// Stop ends the recording and returns the macro. A line typed but not yet
+// sent is dropped.
+func (r *MacroRecorder) Stop() Macro {
+	r.mu.Lock()
+	if r.stopped {
+		steps := r.steps
+		r.mu.Unlock()
+		return Macro{Name: r.name, Steps: steps}
+	}
+	r.stopped = true
+	r.mu.Unlock()
+
+	r.sess.mu.Lock()
+	r.sess.macroRecorder = nil
+	delete(r.sess.watchers, r.watcher)
+	r.sess.mu.Unlock()
+
+	close(r.done)
+	<-r.exited
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.drain()
+	r.finishPending()
+	logging.Infof("session %s recorded macro %s with %d steps", r.sess.id, r.name, len(r.steps))
+	return Macro{Name: r.name, Steps: r.steps}
+}
+
+// collect gathers output mirrored from the session
+func (r *MacroRecorder) collect() {
+	defer close(r.exited)
+	for {
+		select {
+		case data := <-r.watcher:
+			r.mu.Lock()
+			r.addOutput(data)
+			r.mu.Unlock()
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// drain picks up output already mirrored but not yet collected
+func (r *MacroRecorder) drain() {
+	for {
+		select {
+		case data := <-r.watcher:
+			r.addOutput(data)
+		default:
+			return
+		}
+	}
+}
+
+func (r *MacroRecorder) addOutput(data []byte) {
+	if r.pending == nil {
+		// Echo of a command still being typed
+		return
+	}
+	r.output.Write(data)
+	r.lastOutput = time.Now()
+}
+
+// sent is called with data the user wrote to the session
+func (r *MacroRecorder) sent(data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped {
+		return
+	}
+
+	for _, b := range data {
+		if b == '\n' && r.lastCR {
+			r.lastCR = false
+			continue
+		}
+		r.lastCR = b == '\r'
+
+		// The first key of the next command ends the previous response
+		if !r.typing {
+			r.drain()
+			r.finishPending()
+			r.typing = true
+			r.secret = len(r.steps) > 0 && secretPromptPattern.MatchString(r.steps[len(r.steps)-1].WaitFor)
+		}
+
+		switch b {
+		case '\r', '\n':
+			if r.secret {
+				r.pending = &MacroStep{Secret: true}
+				clear(r.line)
+			} else {
+				r.pending = &MacroStep{Send: string(r.line)}
+			}
+			r.sentAt = time.Now()
+			r.output.Reset()
+			r.line = r.line[:0]
+			r.typing = false
+		case 0x08, 0x7f: // backspace, delete
+			if len(r.line) > 0 {
+				r.line = r.line[:len(r.line)-1]
+			}
+		default:
+			r.line = append(r.line, b)
+		}
+	}
+}
+
+// finishPending completes the step waiting for its response
+func (r *MacroRecorder) finishPending() {
+	if r.pending == nil {
+		return
+	}
+	step := *r.pending
+	r.pending = nil
+
+	step.WaitFor = fingerprint.ExtractLastPromptLine(fingerprint.Normalize(r.output.String()))
+	timeout := DefaultMacroStepTimeout
+	if r.lastOutput.After(r.sentAt) {
+		// Leave room for a slower device than the one recorded
+		if observed := 2 * r.lastOutput.Sub(r.sentAt); observed > timeout {
+			timeout = observed
+		}
+	}
+	step.TimeoutMs = int(timeout.Milliseconds())
+	r.steps = append(r.steps, step)
+}
+
+// PlayMacro sends each step's line followed by CR and waits for its WaitFor
+// pattern before moving on. Secret steps send the next value from secrets,
+// which needs m.SecretSteps() entries.
+func PlayMacro(sess *Session, m Macro, secrets []string) error {
+	if len(secrets) < m.SecretSteps() {
+		return fmt.Errorf("macro %s needs %d secrets, got %d", m.Name, m.SecretSteps(), len(secrets))
+	}
+	logging.Infof("session %s playing macro %s (%d steps)", sess.id, m.Name, len(m.Steps))
+	for i, step := range m.Steps {
+		if step.Secret {
+			step.Send, secrets = secrets[0], secrets[1:]
+		}
+		// The line itself is left out, as it may be a password
+		if err := playStep(sess, step); err != nil {
+			return fmt.Errorf("macro %s step %d: %w", m.Name, i+1, err)
+		}
+	}
+	return nil
+}
+
+func playStep(sess *Session, step MacroStep) error {
+	timeout := DefaultMacroStepTimeout
+	if step.TimeoutMs > 0 {
+		timeout = time.Duration(step.TimeoutMs) * time.Millisecond
+	}
+
+	// Watch before sending so a fast answer isn't missed
+	watcher := make(chan []byte, 32)
+	sess.registerWatcher(watcher)
+	defer sess.unregisterWatcher(watcher)
+
+	if _, err := sess.Write([]byte(step.Send + "\r")); err != nil {
+		return err
+	}
+	if step.WaitFor == "" {
+		return nil
+	}
+	_, err := readUntil(sess.ctx, sess.id, watcher, timeout, [][]byte{[]byte(step.WaitFor)})
+	return err
+}
+
+// GetMacrosDir returns the directory macros are saved in
+func GetMacrosDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".lanaudit", "macros"), nil
+}
+
+// SaveMacro writes m to ~/.lanaudit/macros/<name>.json, readable only by
+// the user
+func SaveMacro(m Macro) (string, error) {
+	if !macroNamePattern.MatchString(m.Name) {
+		return "", fmt.Errorf("invalid macro name %q", m.Name)
+	}
+	dir, err := GetMacrosDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create macros directory: %w", err)
+	}
+	if err := os.Chmod(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to restrict macros directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, m.Name+".json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write macro: %w", err)
+	}
+	// WriteFile keeps the mode of a macro saved by an older version
+	if err := os.Chmod(path, 0600); err != nil {
+		return "", fmt.Errorf("failed to restrict macro: %w", err)
+	}
+	return path, nil
+}
+
+// LoadMacro reads a saved macro by name
+func LoadMacro(name string) (*Macro, error) {
+	if !macroNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid macro name %q", name)
+	}
+	dir, err := GetMacrosDir()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, name+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read macro: %w", err)
+	}
+	var m Macro
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse macro %s: %w", name, err)
+	}
+	return &m, nil
+}
+
+// ListMacros returns the names of saved macros in alphabetical order
+func ListMacros() ([]string, error) {
+	dir, err := GetMacrosDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), ".json"); ok && !e.IsDir() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
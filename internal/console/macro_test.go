@@ -0,0 +1,333 @@
+package console
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// scriptedPort echoes typed characters and answers complete lines with a
+// canned response, like a device at its prompt
+type scriptedPort struct {
+	fakePort
+	mu        sync.Mutex
+	line      []byte
+	lastCR    bool
+	written   []string
+	responses map[string]string
+	reads     chan []byte
+	closeOnce sync.Once
+}
+
+func newScriptedPort(responses map[string]string) *scriptedPort {
+	return &scriptedPort{responses: responses, reads: make(chan []byte, 256)}
+}
+
+func (p *scriptedPort) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, c := range b {
+		if c == '\n' && p.lastCR {
+			p.lastCR = false
+			continue
+		}
+		p.lastCR = c == '\r'
+		if c == '\r' || c == '\n' {
+			line := string(p.line)
+			p.written = append(p.written, line)
+			if resp, ok := p.responses[line]; ok {
+				p.reads <- []byte(resp)
+			}
+			p.line = nil
+			continue
+		}
+		if c == 0x7f {
+			if len(p.line) > 0 {
+				p.line = p.line[:len(p.line)-1]
+			}
+			p.reads <- []byte("\b \b")
+			continue
+		}
+		p.line = append(p.line, c)
+		p.reads <- []byte{c}
+	}
+	return len(b), nil
+}
+
+func (p *scriptedPort) Read(b []byte) (int, error) {
+	data, ok := <-p.reads
+	if !ok {
+		return 0, io.EOF
+	}
+	return copy(b, data), nil
+}
+
+func (p *scriptedPort) Close() error {
+	p.closeOnce.Do(func() { close(p.reads) })
+	return nil
+}
+
+func (p *scriptedPort) lines() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.written...)
+}
+
+// newTestSession runs a session over port without opening a serial device
+func newTestSession(t *testing.T, port *scriptedPort) *Session {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Session{
+		id:       "test",
+		config:   DefaultSessionConfig("/dev/test", 9600),
+		port:     port,
+		ctx:      ctx,
+		cancel:   cancel,
+		readChan: make(chan []byte, 100),
+		errChan:  make(chan error, 10),
+		watchers: make(map[chan []byte]struct{}),
+	}
+	go s.readLoop()
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// typeLine sends a line one key at a time, the way the TUI forwards keys
+func typeLine(t *testing.T, s *Session, keys string) {
+	t.Helper()
+	for _, k := range []byte(keys) {
+		if _, err := s.Write([]byte{k}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// waitForResponse waits until the recorder has collected want
+func waitForResponse(t *testing.T, r *MacroRecorder, want string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		r.mu.Lock()
+		got := r.output.String()
+		r.mu.Unlock()
+		if strings.Contains(got, want) {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("recorder never saw %q", want)
+}
+
+func TestRecordMacro(t *testing.T) {
+	port := newScriptedPort(map[string]string{
+		"enable":            "\r\nSwitch#",
+		"show ver":          "\r\nCisco IOS Software, C2960\r\n\x1b[1mSwitch#\x1b[0m",
+		"terminal length 0": "\r\nSwitch#",
+	})
+	sess := newTestSession(t, port)
+
+	rec, err := RecordMacro(sess, "show-version")
+	if err != nil {
+		t.Fatalf("RecordMacro: %v", err)
+	}
+	if _, err := RecordMacro(sess, "other"); err == nil {
+		t.Error("expected error recording twice on one session")
+	}
+
+	typeLine(t, sess, "enable\r")
+	waitForResponse(t, rec, "Switch#")
+	typeLine(t, sess, "show verx\x7f\r\n") // typo fixed with backspace, CRLF counts once
+	waitForResponse(t, rec, "C2960")
+	typeLine(t, sess, "terminal length 0\r")
+	waitForResponse(t, rec, "Switch#")
+	typeLine(t, sess, "unfinished")
+
+	m := rec.Stop()
+	want := []MacroStep{
+		{Send: "enable", WaitFor: "Switch#", TimeoutMs: 5000},
+		{Send: "show ver", WaitFor: "Switch#", TimeoutMs: 5000},
+		{Send: "terminal length 0", WaitFor: "Switch#", TimeoutMs: 5000},
+	}
+	if m.Name != "show-version" || len(m.Steps) != len(want) {
+		t.Fatalf("recorded %+v", m)
+	}
+	for i := range want {
+		if m.Steps[i] != want[i] {
+			t.Errorf("step %d = %+v, want %+v", i, m.Steps[i], want[i])
+		}
+	}
+
+	// Stopping detaches the recorder
+	if again := rec.Stop(); len(again.Steps) != len(want) {
+		t.Errorf("second Stop returned %d steps", len(again.Steps))
+	}
+	if _, err := RecordMacro(sess, "next"); err != nil {
+		t.Errorf("recording after Stop: %v", err)
+	}
+}
+
+func TestRecordMacroSecret(t *testing.T) {
+	port := newScriptedPort(map[string]string{
+		"enable":   "\r\nPassword: ",
+		"hunter2":  "\r\nSwitch#",
+		"show ver": "\r\nCisco IOS Software\r\nSwitch#",
+	})
+	sess := newTestSession(t, port)
+
+	rec, err := RecordMacro(sess, "login")
+	if err != nil {
+		t.Fatalf("RecordMacro: %v", err)
+	}
+	typeLine(t, sess, "enable\r")
+	waitForResponse(t, rec, "Password:")
+	typeLine(t, sess, "hunter2\r")
+	waitForResponse(t, rec, "Switch#")
+	typeLine(t, sess, "show ver\r")
+	waitForResponse(t, rec, "Cisco")
+
+	m := rec.Stop()
+	want := []MacroStep{
+		{Send: "enable", WaitFor: "Password:", TimeoutMs: 5000},
+		{Secret: true, WaitFor: "Switch#", TimeoutMs: 5000},
+		{Send: "show ver", WaitFor: "Switch#", TimeoutMs: 5000},
+	}
+	if len(m.Steps) != len(want) {
+		t.Fatalf("recorded %+v", m)
+	}
+	for i := range want {
+		if m.Steps[i] != want[i] {
+			t.Errorf("step %d = %+v, want %+v", i, m.Steps[i], want[i])
+		}
+	}
+	if m.SecretSteps() != 1 {
+		t.Errorf("SecretSteps() = %d, want 1", m.SecretSteps())
+	}
+}
+
+func TestPlayMacro(t *testing.T) {
+	tests := []struct {
+		name      string
+		steps     []MacroStep
+		secrets   []string
+		wantLines []string
+		wantErr   string
+	}{
+		{
+			name: "all prompts seen",
+			steps: []MacroStep{
+				{Send: "enable", WaitFor: "Switch#"},
+				{Send: "show clock", WaitFor: "Switch#", TimeoutMs: 1000},
+				{Send: ""},
+			},
+			wantLines: []string{"enable", "show clock", ""},
+		},
+		{
+			name: "prompt never appears",
+			steps: []MacroStep{
+				{Send: "enable", WaitFor: "Switch#"},
+				{Send: "reload", WaitFor: "Switch#", TimeoutMs: 50},
+				{Send: "never sent"},
+			},
+			wantLines: []string{"enable", "reload"},
+			wantErr:   "step 2:",
+		},
+		{
+			name: "secret supplied at playback",
+			steps: []MacroStep{
+				{Send: "enable", WaitFor: "Switch#"},
+				{Secret: true, WaitFor: "Switch#", TimeoutMs: 50},
+			},
+			secrets:   []string{"wrong"},
+			wantLines: []string{"enable", "wrong"},
+			wantErr:   "step 2:",
+		},
+		{
+			name:    "secret missing",
+			steps:   []MacroStep{{Secret: true}},
+			wantErr: "needs 1 secrets",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			port := newScriptedPort(map[string]string{
+				"enable":     "\r\nSwitch#",
+				"show clock": "\r\n*12:00:00.000 UTC Mon Mar 1 1993\r\nSwitch#",
+				"reload":     "\r\nProceed with reload? [confirm]",
+			})
+			sess := newTestSession(t, port)
+
+			err := PlayMacro(sess, Macro{Name: "test", Steps: tt.steps}, tt.secrets)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("PlayMacro: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+			// Errors end up in the status bar, so never include the line sent
+			for _, line := range tt.wantLines {
+				if line != "" && err != nil && strings.Contains(err.Error(), line) {
+					t.Errorf("err = %v, includes sent line %q", err, line)
+				}
+			}
+			if got := port.lines(); strings.Join(got, "|") != strings.Join(tt.wantLines, "|") {
+				t.Errorf("device received %q, want %q", got, tt.wantLines)
+			}
+		})
+	}
+}
+
+func TestMacroPersistence(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if names, err := ListMacros(); err != nil || len(names) != 0 {
+		t.Fatalf("ListMacros() on empty home = %v, %v", names, err)
+	}
+
+	for _, name := range []string{"vlan-setup", "backup"} {
+		m := Macro{Name: name, Steps: []MacroStep{{Send: "show vlan", WaitFor: "#", TimeoutMs: 2000}}}
+		if _, err := SaveMacro(m); err != nil {
+			t.Fatalf("SaveMacro(%s): %v", name, err)
+		}
+	}
+
+	if runtime.GOOS != "windows" {
+		dir, _ := GetMacrosDir()
+		for path, want := range map[string]os.FileMode{dir: 0700, filepath.Join(dir, "backup.json"): 0600} {
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != want {
+				t.Errorf("%s mode = %v, want %v", path, info.Mode().Perm(), want)
+			}
+		}
+	}
+
+	names, err := ListMacros()
+	if err != nil || strings.Join(names, ",") != "backup,vlan-setup" {
+		t.Fatalf("ListMacros() = %v, %v", names, err)
+	}
+	m, err := LoadMacro("vlan-setup")
+	if err != nil {
+		t.Fatalf("LoadMacro: %v", err)
+	}
+	if m.Name != "vlan-setup" || len(m.Steps) != 1 || m.Steps[0].TimeoutMs != 2000 {
+		t.Errorf("loaded %+v", m)
+	}
+
+	for _, bad := range []string{"", "../escape", ".hidden", "a/b"} {
+		if _, err := SaveMacro(Macro{Name: bad}); err == nil {
+			t.Errorf("SaveMacro(%q) should fail", bad)
+		}
+		if _, err := LoadMacro(bad); err == nil {
+			t.Errorf("LoadMacro(%q) should fail", bad)
+		}
+	}
+}
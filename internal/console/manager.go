@@ -0,0 +1,137 @@
+package console
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alexpitcher/LanAudit/internal/console/fingerprint"
+	"github.com/alexpitcher/LanAudit/internal/logging"
+)
+
+// Terminal is implemented by serial and Telnet console sessions
+type Terminal interface {
+	fingerprint.WriterReader
+	ID() string
+	PortPath() string
+	ReadChan() <-chan []byte
+	ErrorChan() <-chan error
+	GetStats() (bytesRead, bytesWritten uint64, duration time.Duration)
+	SetDTR(active bool) error
+	SetRTS(active bool) error
+	SendBreak(duration time.Duration) error
+	Close() error
+}
+
+// SessionManager tracks several concurrently open console sessions, e.g. one
+// per port of a console server
+type SessionManager struct {
+	sessions sync.Map // session ID -> Terminal
+	ports    sync.Map // port path -> struct{}, claimed while a session is open or opening
+
+	// open and openTelnet create sessions; replaced in tests
+	open       func(ctx context.Context, cfg SessionConfig) (*Session, error)
+	openTelnet func(ctx context.Context, host string, port int, cfg SessionConfig) (*TelnetSession, error)
+}
+
+// NewSessionManager creates an empty session manager
+func NewSessionManager() *SessionManager {
+	return &SessionManager{open: NewSession, openTelnet: NewTelnetSession}
+}
+
+// Open starts a serial session on cfg.PortPath. Only one session per port may
+// be open.
+func (m *SessionManager) Open(ctx context.Context, cfg SessionConfig) (*Session, error) {
+	var sess *Session
+	err := m.add(cfg.PortPath, func() (Terminal, error) {
+		var err error
+		sess, err = m.open(ctx, cfg)
+		return sess, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sess, nil
+}
+
+// OpenTelnet starts a Telnet session to host:port
+func (m *SessionManager) OpenTelnet(ctx context.Context, host string, port int, cfg SessionConfig) (*TelnetSession, error) {
+	var sess *TelnetSession
+	err := m.add(net.JoinHostPort(host, strconv.Itoa(port)), func() (Terminal, error) {
+		var err error
+		sess, err = m.openTelnet(ctx, host, port, cfg)
+		return sess, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sess, nil
+}
+
+// add claims portPath, opens a session on it and registers the session
+func (m *SessionManager) add(portPath string, open func() (Terminal, error)) error {
+	if _, busy := m.ports.LoadOrStore(portPath, struct{}{}); busy {
+		return fmt.Errorf("a session is already open on %s", portPath)
+	}
+
+	sess, err := open()
+	if err != nil {
+		m.ports.Delete(portPath)
+		return err
+	}
+
+	if _, dup := m.sessions.LoadOrStore(sess.ID(), sess); dup {
+		sess.Close()
+		m.ports.Delete(portPath)
+		return fmt.Errorf("duplicate session ID %s", sess.ID())
+	}
+	logging.Infof("session manager opened %s (%d active)", sess.ID(), len(m.List()))
+	return nil
+}
+
+// Close closes and forgets the session with the given ID
+func (m *SessionManager) Close(id string) error {
+	v, ok := m.sessions.LoadAndDelete(id)
+	if !ok {
+		return fmt.Errorf("no session %s", id)
+	}
+	sess := v.(Terminal)
+	err := sess.Close()
+	m.ports.Delete(sess.PortPath())
+	return err
+}
+
+// CloseAll closes every open session
+func (m *SessionManager) CloseAll() {
+	for _, sess := range m.List() {
+		if err := m.Close(sess.ID()); err != nil {
+			logging.Warnf("failed to close session %s: %v", sess.ID(), err)
+		}
+	}
+}
+
+// List returns the open sessions ordered by ID
+func (m *SessionManager) List() []Terminal {
+	var sessions []Terminal
+	m.sessions.Range(func(_, v any) bool {
+		sessions = append(sessions, v.(Terminal))
+		return true
+	})
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].ID() < sessions[j].ID()
+	})
+	return sessions
+}
+
+// Get returns the open session with the given ID
+func (m *SessionManager) Get(id string) (Terminal, bool) {
+	v, ok := m.sessions.Load(id)
+	if !ok {
+		return nil, false
+	}
+	return v.(Terminal), true
+}
@@ -0,0 +1,282 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// fakePort is a serial.Port that only records Close calls
+type fakePort struct {
+	closed atomic.Int32
+}
+
+func (p *fakePort) SetMode(*serial.Mode) error  { return nil }
+func (p *fakePort) Read([]byte) (int, error)    { return 0, nil }
+func (p *fakePort) Write(b []byte) (int, error) { return len(b), nil }
+func (p *fakePort) Drain() error                { return nil }
+func (p *fakePort) ResetInputBuffer() error     { return nil }
+func (p *fakePort) ResetOutputBuffer() error    { return nil }
+func (p *fakePort) SetDTR(bool) error           { return nil }
+func (p *fakePort) SetRTS(bool) error           { return nil }
+func (p *fakePort) GetModemStatusBits() (*serial.ModemStatusBits, error) {
+	return &serial.ModemStatusBits{}, nil
+}
+func (p *fakePort) SetReadTimeout(time.Duration) error { return nil }
+func (p *fakePort) Break(time.Duration) error          { return nil }
+func (p *fakePort) Close() error {
+	p.closed.Add(1)
+	return nil
+}
+
+// testManager returns a manager whose sessions use fake ports. Opening a path
+// named "fail" returns an error.
+func testManager(t *testing.T) (*SessionManager, *sync.Map) {
+	t.Helper()
+	var ports sync.Map // port path -> []*fakePort
+	var mu sync.Mutex
+	m := NewSessionManager()
+	m.open = func(ctx context.Context, cfg SessionConfig) (*Session, error) {
+		if filepath.Base(cfg.PortPath) == "fail" {
+			return nil, errors.New("open failed")
+		}
+		port := &fakePort{}
+		mu.Lock()
+		prev, _ := ports.Load(cfg.PortPath)
+		list, _ := prev.([]*fakePort)
+		ports.Store(cfg.PortPath, append(list, port))
+		mu.Unlock()
+
+		sessCtx, cancel := context.WithCancel(ctx)
+		return &Session{
+			id:       filepath.Base(cfg.PortPath),
+			config:   cfg,
+			port:     port,
+			ctx:      sessCtx,
+			cancel:   cancel,
+			watchers: make(map[chan []byte]struct{}),
+		}, nil
+	}
+	return m, &ports
+}
+
+func TestSessionManagerLifecycle(t *testing.T) {
+	m, ports := testManager(t)
+	ctx := context.Background()
+
+	a, err := m.Open(ctx, DefaultSessionConfig("/dev/ttyUSB1", 9600))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	b, err := m.Open(ctx, DefaultSessionConfig("/dev/ttyUSB0", 115200))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	if _, err := m.Open(ctx, DefaultSessionConfig("/dev/ttyUSB0", 9600)); err == nil {
+		t.Error("expected error opening a port twice")
+	}
+	if _, err := m.Open(ctx, DefaultSessionConfig("/dev/fail", 9600)); err == nil {
+		t.Error("expected open error to be returned")
+	}
+
+	list := m.List()
+	if len(list) != 2 || list[0] != b || list[1] != a {
+		t.Fatalf("List() = %v, want [ttyUSB0 ttyUSB1]", list)
+	}
+	if got, ok := m.Get(a.ID()); !ok || got != a {
+		t.Errorf("Get(%q) = %v, %v", a.ID(), got, ok)
+	}
+
+	if err := m.Close(a.ID()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := m.Close(a.ID()); err == nil {
+		t.Error("expected error closing a session twice")
+	}
+	if _, ok := m.Get(a.ID()); ok {
+		t.Error("closed session still returned by Get")
+	}
+	v, _ := ports.Load("/dev/ttyUSB1")
+	if n := v.([]*fakePort)[0].closed.Load(); n != 1 {
+		t.Errorf("port closed %d times, want 1", n)
+	}
+
+	// The port is free again once its session is closed
+	if _, err := m.Open(ctx, DefaultSessionConfig("/dev/ttyUSB1", 9600)); err != nil {
+		t.Errorf("reopen after close: %v", err)
+	}
+	// A failed open doesn't leave the port claimed
+	m.open = func(ctx context.Context, cfg SessionConfig) (*Session, error) {
+		return nil, errors.New("still failing")
+	}
+	m.Open(ctx, DefaultSessionConfig("/dev/fail", 9600))
+	if _, busy := m.ports.Load("/dev/fail"); busy {
+		t.Error("failed open left the port claimed")
+	}
+
+	m.CloseAll()
+	if n := len(m.List()); n != 0 {
+		t.Errorf("%d sessions left after CloseAll", n)
+	}
+}
+
+func TestSessionManagerConcurrentOpenSamePort(t *testing.T) {
+	m, ports := testManager(t)
+	const workers = 32
+
+	var wg sync.WaitGroup
+	var opened atomic.Int32
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := m.Open(context.Background(), DefaultSessionConfig("/dev/ttyS0", 9600)); err == nil {
+				opened.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := opened.Load(); n != 1 {
+		t.Errorf("%d concurrent opens of one port succeeded, want 1", n)
+	}
+	v, _ := ports.Load("/dev/ttyS0")
+	if n := len(v.([]*fakePort)); n != 1 {
+		t.Errorf("port opened %d times, want 1", n)
+	}
+}
+
+func TestSessionManagerConcurrentOpenClose(t *testing.T) {
+	m, ports := testManager(t)
+	const workers = 16
+	const rounds = 50
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		path := fmt.Sprintf("/dev/ttyUSB%d", i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := 0; r < rounds; r++ {
+				sess, err := m.Open(context.Background(), DefaultSessionConfig(path, 9600))
+				if err != nil {
+					t.Errorf("Open(%s): %v", path, err)
+					return
+				}
+				if got, ok := m.Get(sess.ID()); !ok || got != sess {
+					t.Errorf("Get(%s) did not return the open session", sess.ID())
+				}
+				if err := m.Close(sess.ID()); err != nil {
+					t.Errorf("Close(%s): %v", sess.ID(), err)
+				}
+			}
+		}()
+	}
+
+	// Readers run alongside the writers
+	stop := make(chan struct{})
+	var readers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				for _, sess := range m.List() {
+					sess.GetStats()
+					m.Get(sess.ID())
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(stop)
+	readers.Wait()
+
+	if n := len(m.List()); n != 0 {
+		t.Errorf("%d sessions left open", n)
+	}
+	ports.Range(func(k, v any) bool {
+		opened := v.([]*fakePort)
+		if len(opened) != rounds {
+			t.Errorf("%s opened %d times, want %d", k, len(opened), rounds)
+		}
+		for _, p := range opened {
+			if n := p.closed.Load(); n != 1 {
+				t.Errorf("%s port closed %d times, want 1", k, n)
+			}
+		}
+		return true
+	})
+}
+
+func TestSessionManagerConcurrentCloseOnce(t *testing.T) {
+	m, ports := testManager(t)
+	sess, err := m.Open(context.Background(), DefaultSessionConfig("/dev/ttyS1", 9600))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	var closed atomic.Int32
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if m.Close(sess.ID()) == nil {
+				closed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := closed.Load(); n != 1 {
+		t.Errorf("%d concurrent closes succeeded, want 1", n)
+	}
+	v, _ := ports.Load("/dev/ttyS1")
+	if n := v.([]*fakePort)[0].closed.Load(); n != 1 {
+		t.Errorf("port closed %d times, want 1", n)
+	}
+}
+
+func TestSessionManagerOpenTelnet(t *testing.T) {
+	m := NewSessionManager()
+	m.openTelnet = func(ctx context.Context, host string, port int, cfg SessionConfig) (*TelnetSession, error) {
+		client, server := net.Pipe()
+		t.Cleanup(func() { server.Close() })
+		cfg.PortPath = net.JoinHostPort(host, strconv.Itoa(port))
+		return newTelnetSession(ctx, client, cfg), nil
+	}
+
+	sess, err := m.OpenTelnet(context.Background(), "10.0.0.5", 2001, DefaultSessionConfig("", 9600))
+	if err != nil {
+		t.Fatalf("OpenTelnet: %v", err)
+	}
+	if _, err := m.OpenTelnet(context.Background(), "10.0.0.5", 2001, DefaultSessionConfig("", 9600)); err == nil {
+		t.Error("expected error opening a telnet port twice")
+	}
+	if got, ok := m.Get(sess.ID()); !ok || got != sess {
+		t.Errorf("Get(%q) = %v, %v", sess.ID(), got, ok)
+	}
+	if list := m.List(); len(list) != 1 || list[0].PortPath() != "10.0.0.5:2001" {
+		t.Errorf("List() = %v", list)
+	}
+	if err := m.Close(sess.ID()); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+}
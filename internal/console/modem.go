@@ -0,0 +1,357 @@
+package console
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/alexpitcher/LanAudit/internal/logging"
+)
+
+// XMODEM/YMODEM control bytes
+const (
+	modemSOH byte = 0x01 // 128-byte block
+	modemSTX byte = 0x02 // 1024-byte block
+	modemEOT byte = 0x04
+	modemACK byte = 0x06
+	modemNAK byte = 0x15
+	modemCAN byte = 0x18
+	modemSUB byte = 0x1a // pads the last data block
+	modemCRC byte = 'C'  // receiver asks for CRC-16 mode
+)
+
+// modemMaxRetries is how often a block is resent before giving up
+const modemMaxRetries = 10
+
+var (
+	// modemStartTimeout is how long to wait for the receiver to start; it
+	// covers the time the user needs to start the receive on the device
+	modemStartTimeout = 60 * time.Second
+	// modemAckTimeout is how long to wait for a block to be acknowledged
+	modemAckTimeout = 10 * time.Second
+)
+
+var (
+	errModemTimeout   = errors.New("timed out waiting for receiver")
+	errModemCancelled = errors.New("transfer cancelled by receiver")
+)
+
+// modem is the sending side of an XMODEM or YMODEM transfer
+type modem struct {
+	w        io.Writer
+	in       <-chan []byte // bytes from the receiver
+	pending  []byte
+	crc      bool // CRC-16 instead of the 8-bit checksum
+	progress func(int)
+	sent     int // file bytes acknowledged so far
+}
+
+// modemFile is one file of a YMODEM batch
+type modemFile struct {
+	name string
+	size int64
+	r    io.Reader
+}
+
+// SendXMODEM sends a file over the session using XMODEM with 128-byte blocks.
+// progress, if not nil, is called with the number of bytes acknowledged.
+func SendXMODEM(sess *Session, path string, progress func(int)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	m, done := newSessionModem(sess, progress)
+	defer done()
+
+	logging.Infof("session %s XMODEM send %s", sess.id, path)
+	return sendXMODEM(m, f)
+}
+
+// SendYMODEM sends a batch of files over the session using YMODEM with
+// 1024-byte blocks. progress, if not nil, is called with the number of bytes
+// acknowledged across all files.
+func SendYMODEM(sess *Session, files []string, progress func(int)) error {
+	batch := make([]modemFile, 0, len(files))
+	for _, path := range files {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open file: %w", err)
+		}
+		defer f.Close()
+
+		info, err := f.Stat()
+		if err != nil {
+			return fmt.Errorf("failed to stat file: %w", err)
+		}
+		batch = append(batch, modemFile{name: filepath.Base(path), size: info.Size(), r: f})
+	}
+
+	m, done := newSessionModem(sess, progress)
+	defer done()
+
+	logging.Infof("session %s YMODEM send %d files", sess.id, len(files))
+	return sendYMODEM(m, batch)
+}
+
+// newSessionModem attaches a modem to the session's read mirror
+func newSessionModem(sess *Session, progress func(int)) (*modem, func()) {
+	watcher := make(chan []byte, 64)
+	sess.registerWatcher(watcher)
+	m := &modem{
+		w:        writerFunc(sess.writeRaw),
+		in:       watcher,
+		progress: progress,
+	}
+	return m, func() { sess.unregisterWatcher(watcher) }
+}
+
+// writerFunc adapts a write method to io.Writer
+type writerFunc func([]byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
+
+// sendXMODEM runs an XMODEM transfer of r
+func sendXMODEM(m *modem, r io.Reader) error {
+	if err := m.waitStart(); err != nil {
+		return err
+	}
+	if err := m.sendData(r, 128); err != nil {
+		return err
+	}
+	return m.sendEOT()
+}
+
+// sendYMODEM runs a YMODEM batch transfer. Each file is announced with a
+// block 0 carrying its name and size; an empty block 0 ends the batch.
+func sendYMODEM(m *modem, files []modemFile) error {
+	for _, f := range files {
+		if err := m.waitStart(); err != nil {
+			return err
+		}
+		header := []byte(f.name)
+		header = append(header, 0)
+		header = append(header, strconv.FormatInt(f.size, 10)...)
+		if err := m.sendBlock(0, header, 0); err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+
+		if err := m.waitStart(); err != nil {
+			return err
+		}
+		if err := m.sendData(f.r, 1024); err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+		if err := m.sendEOT(); err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+	}
+
+	if err := m.waitStart(); err != nil {
+		return err
+	}
+	return m.sendBlock(0, nil, 0)
+}
+
+// waitStart waits for the receiver to ask for a transfer: 'C' selects
+// CRC-16, NAK the original checksum
+func (m *modem) waitStart() error {
+	deadline := time.Now().Add(modemStartTimeout)
+	for {
+		b, err := m.readByte(time.Until(deadline))
+		if err != nil {
+			return err
+		}
+		switch b {
+		case modemCRC:
+			m.crc = true
+			return nil
+		case modemNAK:
+			m.crc = false
+			return nil
+		case modemCAN:
+			if m.cancelled() {
+				return errModemCancelled
+			}
+		}
+	}
+}
+
+// sendData sends r in blocks of blockSize, numbered from 1. YMODEM sends a
+// short tail in a 128-byte block to save padding.
+func (m *modem) sendData(r io.Reader, blockSize int) error {
+	buf := make([]byte, blockSize)
+	seq := byte(1)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			size := blockSize
+			if n <= 128 {
+				size = 128
+			}
+			if err := m.sendBlock(seq, buf[:n], size); err != nil {
+				return fmt.Errorf("block %d: %w", seq, err)
+			}
+			m.sent += n
+			if m.progress != nil {
+				m.progress(m.sent)
+			}
+			seq++
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+	}
+}
+
+// sendBlock sends one block until the receiver acknowledges it. size 0 marks
+// a YMODEM block 0, which is padded with NULs instead of SUB.
+func (m *modem) sendBlock(seq byte, data []byte, size int) error {
+	block := buildModemBlock(seq, data, size, m.crc)
+	for try := 0; try < modemMaxRetries; try++ {
+		if _, err := m.w.Write(block); err != nil {
+			return fmt.Errorf("write failed: %w", err)
+		}
+		ok, err := m.waitAck()
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		logging.Debugf("modem block %d not acknowledged, retry %d", seq, try+1)
+	}
+	return fmt.Errorf("no acknowledgement after %d attempts", modemMaxRetries)
+}
+
+// sendEOT ends a file; receivers commonly NAK the first EOT
+func (m *modem) sendEOT() error {
+	for try := 0; try < modemMaxRetries; try++ {
+		if _, err := m.w.Write([]byte{modemEOT}); err != nil {
+			return fmt.Errorf("write failed: %w", err)
+		}
+		ok, err := m.waitAck()
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("end of transmission not acknowledged")
+}
+
+// waitAck reports whether the receiver acknowledged the last send; false
+// means it asked for a resend or didn't answer in time
+func (m *modem) waitAck() (bool, error) {
+	deadline := time.Now().Add(modemAckTimeout)
+	for {
+		b, err := m.readByte(time.Until(deadline))
+		if err == errModemTimeout {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		switch b {
+		case modemACK:
+			return true, nil
+		case modemNAK:
+			return false, nil
+		case modemCAN:
+			if m.cancelled() {
+				return false, errModemCancelled
+			}
+		}
+		// Anything else is line noise or a stray 'C'
+	}
+}
+
+// cancelled reports whether a CAN is followed by a second one
+func (m *modem) cancelled() bool {
+	b, err := m.readByte(time.Second)
+	return err == nil && b == modemCAN
+}
+
+// readByte returns the next byte from the receiver
+func (m *modem) readByte(timeout time.Duration) (byte, error) {
+	if len(m.pending) == 0 {
+		if timeout <= 0 {
+			return 0, errModemTimeout
+		}
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		for len(m.pending) == 0 {
+			select {
+			case data, ok := <-m.in:
+				if !ok {
+					return 0, io.ErrClosedPipe
+				}
+				m.pending = data
+			case <-timer.C:
+				return 0, errModemTimeout
+			}
+		}
+	}
+	b := m.pending[0]
+	m.pending = m.pending[1:]
+	return b, nil
+}
+
+// buildModemBlock frames data as an XMODEM block. A size of 0 builds a
+// YMODEM block 0: 128 bytes (1024 if the header doesn't fit) padded with NUL.
+func buildModemBlock(seq byte, data []byte, size int, crc bool) []byte {
+	pad := modemSUB
+	if size == 0 {
+		size, pad = 128, 0
+		if len(data) > 128 {
+			size = 1024
+		}
+	}
+
+	header := modemSOH
+	if size == 1024 {
+		header = modemSTX
+	}
+
+	block := make([]byte, 0, 3+size+2)
+	block = append(block, header, seq, ^seq)
+	block = append(block, data...)
+	block = append(block, bytes.Repeat([]byte{pad}, size-len(data))...)
+
+	payload := block[3:]
+	if crc {
+		sum := crc16XMODEM(payload)
+		return append(block, byte(sum>>8), byte(sum))
+	}
+	var sum byte
+	for _, b := range payload {
+		sum += b
+	}
+	return append(block, sum)
+}
+
+// crc16XMODEM is CRC-16/XMODEM: polynomial 0x1021, initial value 0
+func crc16XMODEM(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
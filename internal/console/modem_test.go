@@ -0,0 +1,329 @@
+package console
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCRC16XMODEM(t *testing.T) {
+	if got := crc16XMODEM([]byte("123456789")); got != 0x31c3 {
+		t.Errorf("crc16XMODEM(check string) = %#04x, want 0x31c3", got)
+	}
+}
+
+func TestBuildModemBlock(t *testing.T) {
+	tests := []struct {
+		name    string
+		seq     byte
+		data    []byte
+		size    int
+		crc     bool
+		wantLen int
+		header  byte
+		pad     byte
+	}{
+		{"xmodem checksum", 1, []byte("abc"), 128, false, 3 + 128 + 1, modemSOH, modemSUB},
+		{"xmodem crc", 2, []byte("abc"), 128, true, 3 + 128 + 2, modemSOH, modemSUB},
+		{"1k block", 3, []byte("abc"), 1024, true, 3 + 1024 + 2, modemSTX, modemSUB},
+		{"ymodem block 0", 0, []byte("fw.bin\x00123"), 0, true, 3 + 128 + 2, modemSOH, 0},
+		{"long ymodem block 0", 0, bytes.Repeat([]byte("n"), 200), 0, true, 3 + 1024 + 2, modemSTX, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block := buildModemBlock(tt.seq, tt.data, tt.size, tt.crc)
+			if len(block) != tt.wantLen {
+				t.Fatalf("block length = %d, want %d", len(block), tt.wantLen)
+			}
+			if block[0] != tt.header || block[1] != tt.seq || block[2] != ^tt.seq {
+				t.Errorf("header = % x", block[:3])
+			}
+			if block[3+len(tt.data)] != tt.pad {
+				t.Errorf("padding byte = %#x, want %#x", block[3+len(tt.data)], tt.pad)
+			}
+		})
+	}
+}
+
+// receiverScript describes how the simulated receiver behaves
+type receiverScript struct {
+	start       byte         // 'C' or NAK; 0 never starts
+	nakBlocks   map[int]bool // NAK these blocks (counted from 1 as received)
+	dropBlocks  map[int]bool // don't answer these blocks at all
+	nakAll      bool
+	nakFirstEOT bool
+	cancelAt    int // send CAN CAN instead of answering this block
+}
+
+type receivedFile struct {
+	name string
+	data []byte
+}
+
+// runReceiver is a minimal XMODEM/YMODEM receiver
+func runReceiver(r io.Reader, w io.Writer, s receiverScript, ymodem bool) ([]receivedFile, error) {
+	if s.start == 0 {
+		io.Copy(io.Discard, r)
+		return nil, nil
+	}
+	crc := s.start == modemCRC
+	w.Write([]byte{s.start})
+
+	var files []receivedFile
+	var current *receivedFile
+	size := -1
+	lastSeq := byte(0)
+	expectHeader := ymodem
+	eots := 0
+	if !ymodem {
+		current = &receivedFile{}
+	}
+
+	for count := 1; ; count++ {
+		var h [1]byte
+		if _, err := io.ReadFull(r, h[:]); err != nil {
+			return files, err
+		}
+
+		if h[0] == modemEOT {
+			eots++
+			if s.nakFirstEOT && eots == 1 {
+				w.Write([]byte{modemNAK})
+				continue
+			}
+			w.Write([]byte{modemACK})
+			data := current.data
+			if size >= 0 {
+				data = data[:size]
+			} else {
+				data = bytes.TrimRight(data, string(modemSUB))
+			}
+			files = append(files, receivedFile{name: current.name, data: data})
+			if !ymodem {
+				return files, nil
+			}
+			expectHeader, eots, lastSeq = true, 0, 0
+			w.Write([]byte{modemCRC})
+			continue
+		}
+
+		blockSize := 128
+		if h[0] == modemSTX {
+			blockSize = 1024
+		} else if h[0] != modemSOH {
+			return files, fmt.Errorf("unexpected byte %#x", h[0])
+		}
+		trailer := 1
+		if crc {
+			trailer = 2
+		}
+		buf := make([]byte, 2+blockSize+trailer)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return files, err
+		}
+		seq, payload := buf[0], buf[2:2+blockSize]
+		if buf[1] != ^seq {
+			return files, fmt.Errorf("bad sequence complement")
+		}
+		if crc {
+			if got := uint16(buf[2+blockSize])<<8 | uint16(buf[3+blockSize]); got != crc16XMODEM(payload) {
+				return files, fmt.Errorf("bad CRC on block %d", seq)
+			}
+		}
+
+		switch {
+		case s.cancelAt == count:
+			w.Write([]byte{modemCAN, modemCAN})
+			return files, nil
+		case s.dropBlocks[count]:
+			continue
+		case s.nakAll || s.nakBlocks[count]:
+			w.Write([]byte{modemNAK})
+			continue
+		}
+
+		if expectHeader {
+			if seq != 0 {
+				return files, fmt.Errorf("expected block 0, got %d", seq)
+			}
+			w.Write([]byte{modemACK})
+			name, rest, _ := bytes.Cut(payload, []byte{0})
+			if len(name) == 0 {
+				return files, nil // end of batch
+			}
+			sizeField, _, _ := bytes.Cut(rest, []byte{0})
+			size, _ = strconv.Atoi(string(sizeField))
+			current = &receivedFile{name: string(name)}
+			expectHeader = false
+			w.Write([]byte{modemCRC})
+			continue
+		}
+
+		switch seq {
+		case lastSeq:
+			// Retransmission of a block whose ACK was lost
+		case lastSeq + 1:
+			current.data = append(current.data, payload...)
+			lastSeq = seq
+		default:
+			return files, fmt.Errorf("out of order block %d after %d", seq, lastSeq)
+		}
+		w.Write([]byte{modemACK})
+	}
+}
+
+// runTransfer connects send to a simulated receiver over io.Pipe
+func runTransfer(t *testing.T, s receiverScript, ymodem bool, send func(*modem) error) ([]receivedFile, []int, error, error) {
+	t.Helper()
+
+	origStart, origAck := modemStartTimeout, modemAckTimeout
+	modemStartTimeout, modemAckTimeout = 200*time.Millisecond, 50*time.Millisecond
+	t.Cleanup(func() { modemStartTimeout, modemAckTimeout = origStart, origAck })
+
+	toRecvR, toRecvW := io.Pipe()
+	toSendR, toSendW := io.Pipe()
+
+	in := make(chan []byte, 64)
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, err := toSendR.Read(buf)
+			if n > 0 {
+				in <- append([]byte(nil), buf[:n]...)
+			}
+			if err != nil {
+				close(in)
+				return
+			}
+		}
+	}()
+
+	type result struct {
+		files []receivedFile
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		files, err := runReceiver(toRecvR, toSendW, s, ymodem)
+		toRecvR.Close()
+		toSendW.Close()
+		done <- result{files, err}
+	}()
+
+	var progress []int
+	m := &modem{w: toRecvW, in: in, progress: func(n int) { progress = append(progress, n) }}
+	sendErr := send(m)
+	toRecvW.Close()
+
+	res := <-done
+	return res.files, progress, sendErr, res.err
+}
+
+func testPayload(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte('a' + i%26)
+	}
+	return b
+}
+
+func TestSendXMODEM(t *testing.T) {
+	payload := testPayload(300)
+
+	tests := []struct {
+		name    string
+		script  receiverScript
+		wantErr error
+		errText string
+	}{
+		{"crc", receiverScript{start: modemCRC}, nil, ""},
+		{"checksum", receiverScript{start: modemNAK}, nil, ""},
+		{"NAK retry", receiverScript{start: modemCRC, nakBlocks: map[int]bool{2: true}}, nil, ""},
+		{"lost block", receiverScript{start: modemCRC, dropBlocks: map[int]bool{1: true}}, nil, ""},
+		{"EOT NAK", receiverScript{start: modemCRC, nakFirstEOT: true}, nil, ""},
+		{"cancelled", receiverScript{start: modemCRC, cancelAt: 2}, errModemCancelled, ""},
+		{"too many NAKs", receiverScript{start: modemCRC, nakAll: true}, nil, "no acknowledgement"},
+		{"receiver never starts", receiverScript{}, errModemTimeout, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, progress, err, recvErr := runTransfer(t, tt.script, false, func(m *modem) error {
+				return sendXMODEM(m, bytes.NewReader(payload))
+			})
+
+			if tt.wantErr != nil || tt.errText != "" {
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				if tt.errText != "" && (err == nil || !strings.Contains(err.Error(), tt.errText)) {
+					t.Fatalf("err = %v, want %q", err, tt.errText)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("sendXMODEM: %v (receiver: %v)", err, recvErr)
+			}
+			if recvErr != nil {
+				t.Fatalf("receiver: %v", recvErr)
+			}
+			if len(files) != 1 || !bytes.Equal(files[0].data, payload) {
+				t.Fatalf("receiver got %v", files)
+			}
+			if want := []int{128, 256, 300}; fmt.Sprint(progress) != fmt.Sprint(want) {
+				t.Errorf("progress = %v, want %v", progress, want)
+			}
+		})
+	}
+}
+
+func TestSendYMODEM(t *testing.T) {
+	firmware := testPayload(2000) // one full 1K block and one padded one
+	config := testPayload(100)    // fits a 128-byte block
+
+	tests := []struct {
+		name   string
+		script receiverScript
+	}{
+		{"batch", receiverScript{start: modemCRC}},
+		{"header NAK", receiverScript{start: modemCRC, nakBlocks: map[int]bool{1: true}}},
+		{"EOT NAK", receiverScript{start: modemCRC, nakFirstEOT: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, progress, err, recvErr := runTransfer(t, tt.script, true, func(m *modem) error {
+				return sendYMODEM(m, []modemFile{
+					{name: "fw.bin", size: int64(len(firmware)), r: bytes.NewReader(firmware)},
+					{name: "startup-config", size: int64(len(config)), r: bytes.NewReader(config)},
+				})
+			})
+			if err != nil {
+				t.Fatalf("sendYMODEM: %v (receiver: %v)", err, recvErr)
+			}
+			if recvErr != nil {
+				t.Fatalf("receiver: %v", recvErr)
+			}
+
+			if len(files) != 2 {
+				t.Fatalf("receiver got %d files, want 2", len(files))
+			}
+			if files[0].name != "fw.bin" || !bytes.Equal(files[0].data, firmware) {
+				t.Errorf("first file = %q (%d bytes)", files[0].name, len(files[0].data))
+			}
+			if files[1].name != "startup-config" || !bytes.Equal(files[1].data, config) {
+				t.Errorf("second file = %q (%d bytes)", files[1].name, len(files[1].data))
+			}
+			if want := []int{1024, 2000, 2100}; fmt.Sprint(progress) != fmt.Sprint(want) {
+				t.Errorf("progress = %v, want %v", progress, want)
+			}
+		})
+	}
+}
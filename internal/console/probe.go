@@ -1,6 +1,7 @@
 package console
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"strings"
@@ -12,19 +13,38 @@ import (
 	"go.bug.st/serial"
 )
 
+// AllBaudRates lists the standard rates, including the legacy ones still used
+// by old UPSes, modems and rack PDUs
+var AllBaudRates = []int{300, 1200, 2400, 4800, 9600, 19200, 38400, 57600, 115200}
+
+// StripANSI returns data without ANSI escape sequences. A sequence split
+// across two reads is left as it is.
+func StripANSI(data []byte) []byte {
+	return fingerprint.ANSIRegexp.ReplaceAll(data, nil)
+}
+
+// probeBaud probes one baud rate; replaced in tests
+var probeBaud = probeSingleBaud
+
 // ProbeConfig defines parameters for baud probing
 type ProbeConfig struct {
 	BaudRates []int
 	Timeout   time.Duration
 	MaxBytes  int
+	// SmartBaud tries every rate and keeps the highest scoring response
+	// instead of stopping at the first one that looks readable
+	SmartBaud bool
+	// Fingerprint tunes how much evidence the result keeps
+	Fingerprint fingerprint.Config
 }
 
 // DefaultProbeConfig returns sensible defaults for probing
 func DefaultProbeConfig() ProbeConfig {
 	return ProbeConfig{
-		BaudRates: []int{9600, 115200},
-		Timeout:   800 * time.Millisecond,
-		MaxBytes:  2048,
+		BaudRates:   []int{9600, 115200},
+		Timeout:     800 * time.Millisecond,
+		MaxBytes:    2048,
+		Fingerprint: fingerprint.DefaultConfig(),
 	}
 }
 
@@ -37,6 +57,7 @@ type ProbeResult struct {
 	Fingerprint fingerprint.Result
 	Candidates  []fingerprint.Candidate
 	Stage       fingerprint.Stage
+	Score       float64 // How readable RawData is, from 0 to 1
 	Error       error
 }
 
@@ -49,19 +70,20 @@ func ProbePort(ctx context.Context, portPath string, config ProbeConfig) ProbeRe
 	logging.Infof("ProbePort start path=%s bauds=%v timeout=%s", portPath, config.BaudRates, config.Timeout)
 
 	// Try each baud rate in order
+	var best *ProbeResult
 	for _, baud := range config.BaudRates {
 		logging.Debugf("probing %s at %d baud", portPath, baud)
-		pr := probeSingleBaud(ctx, portPath, baud, config)
+		pr := probeBaud(ctx, portPath, baud, config)
 		if pr.Success {
-			result = pr
-			promptLine := fingerprint.ExtractLastPromptLine(result.CleanedData)
-			stage, cands := fingerprint.Analyze(result.CleanedData, promptLine)
-			result.Stage = stage
-			result.Candidates = cands
-			result.Fingerprint = fingerprint.Finalize(stage, cands, result.CleanedData, promptLine, "")
-			result.Fingerprint.Baud = baud
-			logging.Infof("probe success baud=%d stage=%s vendor=%s os=%s", baud, stage, result.Fingerprint.Vendor, result.Fingerprint.OS)
-			return result
+			if !config.SmartBaud {
+				return finishProbe(pr, config.Fingerprint)
+			}
+			logging.Debugf("probe %s baud=%d score=%.2f", portPath, baud, pr.Score)
+			// Ties keep the earlier rate
+			if best == nil || pr.Score > best.Score {
+				best = &pr
+			}
+			continue
 		}
 
 		// If we got some data but it looks like garbage, note it
@@ -70,6 +92,9 @@ func ProbePort(ctx context.Context, portPath string, config ProbeConfig) ProbeRe
 			result.CleanedData = pr.CleanedData
 		}
 	}
+	if best != nil {
+		return finishProbe(*best, config.Fingerprint)
+	}
 
 	// All baud rates failed
 	result.Error = fmt.Errorf("no response at any baud rate (%v)", config.BaudRates)
@@ -85,6 +110,18 @@ func ProbePort(ctx context.Context, portPath string, config ProbeConfig) ProbeRe
 	return result
 }
 
+// finishProbe fingerprints the data of a successful probe
+func finishProbe(result ProbeResult, cfg fingerprint.Config) ProbeResult {
+	promptLine := fingerprint.ExtractLastPromptLine(result.CleanedData)
+	stage, cands := fingerprint.Analyze(result.CleanedData, promptLine)
+	result.Stage = stage
+	result.Candidates = cands
+	result.Fingerprint = fingerprint.Finalize(stage, cands, result.CleanedData, promptLine, "", cfg)
+	result.Fingerprint.Baud = result.Baud
+	logging.Infof("probe success baud=%d stage=%s vendor=%s os=%s", result.Baud, stage, result.Fingerprint.Vendor, result.Fingerprint.OS)
+	return result
+}
+
 // probeSingleBaud tries a single baud rate
 func probeSingleBaud(ctx context.Context, portPath string, baud int, config ProbeConfig) ProbeResult {
 	result := ProbeResult{
@@ -171,7 +208,8 @@ func probeSingleBaud(ctx context.Context, portPath string, baud int, config Prob
 	result.RawData = buffer[:totalRead]
 
 	// Clean data for analysis
-	result.CleanedData = cleanSerialData(result.RawData)
+	result.CleanedData = cleanSerialData(result.RawData, false)
+	result.Score = scoreProbeData(result.RawData)
 
 	// Determine success - we got meaningful data if:
 	// 1. We have at least 10 bytes
@@ -184,8 +222,13 @@ func probeSingleBaud(ctx context.Context, portPath string, baud int, config Prob
 	return result
 }
 
-// cleanSerialData converts raw bytes to UTF-8 string, replacing non-printables
-func cleanSerialData(data []byte) string {
+// cleanSerialData converts raw bytes to UTF-8 string, replacing non-printables.
+// ANSI escape sequences are kept unless stripANSI is set.
+func cleanSerialData(data []byte, stripANSI bool) string {
+	if stripANSI {
+		data = StripANSI(data)
+	}
+
 	// First, try to convert to valid UTF-8
 	if !utf8.Valid(data) {
 		// Replace invalid sequences
@@ -217,6 +260,32 @@ func cleanSerialData(data []byte) string {
 	return b.String()
 }
 
+// scoreProbeData rates how likely data was read at the right baud rate: the
+// share of printable ASCII left by cleanSerialData, halved when the raw bytes
+// aren't valid UTF-8. A wrong rate produces framing garbage that cleans up to
+// spaces and replacement characters, so only spaces present in the raw data
+// count as printable.
+func scoreProbeData(data []byte) float64 {
+	cleaned := cleanSerialData(data, false)
+	total := utf8.RuneCountInString(cleaned)
+	if total == 0 {
+		return 0
+	}
+
+	printable := bytes.Count(data, []byte(" "))
+	for _, r := range cleaned {
+		if r == '\r' || r == '\n' || r == '\t' || (r > ' ' && r <= '~') {
+			printable++
+		}
+	}
+
+	score := float64(printable) / float64(total)
+	if !utf8.Valid(data) {
+		score /= 2
+	}
+	return score
+}
+
 // QuickProbe performs a fast probe with default settings
 func QuickProbe(portPath string) ProbeResult {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
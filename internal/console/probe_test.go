@@ -1,7 +1,9 @@
 package console
 
 import (
+	"bytes"
 	"context"
+	"io"
 	"strings"
 	"testing"
 	"time"
@@ -79,7 +81,7 @@ func TestCleanSerialData(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := cleanSerialData(tt.input)
+			got := cleanSerialData(tt.input, false)
 			if got != tt.want {
 				t.Errorf("cleanSerialData() = %q, want %q", got, tt.want)
 			}
@@ -87,6 +89,71 @@ func TestCleanSerialData(t *testing.T) {
 	}
 }
 
+func TestStripANSI(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"plain", "Switch#", "Switch#"},
+		{"colour", "\x1b[1;32mUP\x1b[0m Gi0/1", "UP Gi0/1"},
+		{"cursor movement", "\x1b[2J\x1b[HLogin: ", "Login: "},
+		{"lone escape kept", "a\x1bb", "a\x1bb"},
+		{"split sequence kept", "text\x1b[3", "text\x1b[3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := StripANSI([]byte(tt.input))
+			if !bytes.Equal(got, []byte(tt.want)) {
+				t.Errorf("StripANSI(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanSerialDataStripANSI(t *testing.T) {
+	colored := []byte("\x1b[1mSwitch\x1b[0m uptime\x00 is \x1b[33m3 weeks\x1b[0m\r\n")
+
+	if got := cleanSerialData(colored, false); got != "\x1b[1mSwitch\x1b[0m uptime  is \x1b[33m3 weeks\x1b[0m\r\n" {
+		t.Errorf("cleanSerialData(keep) = %q", got)
+	}
+	if got := cleanSerialData(colored, true); got != "Switch uptime  is 3 weeks\r\n" {
+		t.Errorf("cleanSerialData(strip) = %q", got)
+	}
+}
+
+func TestSessionStripANSI(t *testing.T) {
+	for _, strip := range []bool{false, true} {
+		cfg := DefaultSessionConfig("/dev/test", 9600)
+		cfg.StripANSI = strip
+		sess, device := newPipeSession(t, cfg)
+
+		watcher := make(chan []byte, 1)
+		sess.registerWatcher(watcher)
+
+		colored := "\x1b[31m%LINK-3-UPDOWN\x1b[0m"
+		io.WriteString(device, colored)
+
+		want := colored
+		if strip {
+			want = "%LINK-3-UPDOWN"
+		}
+		select {
+		case got := <-sess.ReadChan():
+			if string(got) != want {
+				t.Errorf("StripANSI=%v: displayed %q, want %q", strip, got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("no data from session")
+		}
+		// Watchers such as file transfers always see the raw bytes
+		if got := <-watcher; string(got) != colored {
+			t.Errorf("StripANSI=%v: watcher got %q", strip, got)
+		}
+	}
+}
+
 func TestProbeResultStructure(t *testing.T) {
 	result := ProbeResult{
 		Success:     true,
@@ -178,7 +245,7 @@ func TestCleanSerialDataUTF8(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := cleanSerialData(tt.input)
+			result := cleanSerialData(tt.input, false)
 			// Should not panic and return non-empty string
 			if result == "" {
 				t.Error("cleanSerialData() returned empty string for valid input")
@@ -207,10 +274,92 @@ func TestProbeConfigCustomBauds(t *testing.T) {
 
 func TestCleanSerialDataPreservesLineEndings(t *testing.T) {
 	input := []byte("Line1\r\nLine2\rLine3\nLine4")
-	result := cleanSerialData(input)
+	result := cleanSerialData(input, false)
 
 	// Should preserve \r, \n, and \r\n
 	if !strings.Contains(result, "\r\n") {
 		t.Error("cleanSerialData() should preserve \\r\\n")
 	}
 }
+
+func TestScoreProbeData(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		min, max float64
+	}{
+		{"empty", nil, 0, 0},
+		{"clean banner", []byte("User Access Verification\r\n\r\nUsername: "), 1, 1},
+		{"framing garbage", []byte{0x00, 0x80, 0xfe, 0x00, 0x01, 0xf8, 0x00, 0x1f}, 0, 0},
+		{"mostly garbage", []byte("\x00\x01\x02\x03\x04\x05ok"), 0.2, 0.3},
+		{"invalid UTF-8 halves the score", []byte("Router>\xff"), 0.4, 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := scoreProbeData(tt.input)
+			if got < tt.min || got > tt.max {
+				t.Errorf("scoreProbeData(%q) = %.3f, want %.2f-%.2f (cleaned %q)", tt.input, got, tt.min, tt.max, cleanSerialData(tt.input, false))
+			}
+		})
+	}
+
+	// A readable response must outrank the garbage a wrong baud produces
+	clean := scoreProbeData([]byte("Switch>\r\n"))
+	noisy := scoreProbeData([]byte("Sw\x00\x80tc\xfe>\r\n"))
+	if clean <= noisy {
+		t.Errorf("clean score %.3f should beat noisy score %.3f", clean, noisy)
+	}
+}
+
+func TestProbePortSmartBaud(t *testing.T) {
+	responses := map[int][]byte{
+		2400:   []byte("\x00\x80\xfe\xf8\x1f\x00\x80garbage"),
+		9600:   []byte("Router>\r\nRouter>\r\n"),
+		115200: []byte("Rou\x00\x01er\x02\x03\x04\r\n\x05\x06"),
+	}
+	orig := probeBaud
+	var tried []int
+	probeBaud = func(ctx context.Context, portPath string, baud int, config ProbeConfig) ProbeResult {
+		tried = append(tried, baud)
+		raw := responses[baud]
+		return ProbeResult{
+			Success:     len(raw) >= 10,
+			Baud:        baud,
+			RawData:     raw,
+			CleanedData: cleanSerialData(raw, false),
+			Score:       scoreProbeData(raw),
+		}
+	}
+	t.Cleanup(func() { probeBaud = orig })
+
+	tests := []struct {
+		name      string
+		smart     bool
+		wantBaud  int
+		wantTries int
+	}{
+		{"first success", false, 2400, 3},
+		{"highest score", true, 9600, len(AllBaudRates)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tried = nil
+			config := DefaultProbeConfig()
+			config.BaudRates = AllBaudRates
+			config.SmartBaud = tt.smart
+
+			result := ProbePort(context.Background(), "/dev/test", config)
+			if !result.Success || result.Baud != tt.wantBaud {
+				t.Fatalf("ProbePort() picked baud %d (success=%v), want %d", result.Baud, result.Success, tt.wantBaud)
+			}
+			if result.Fingerprint.Baud != tt.wantBaud {
+				t.Errorf("fingerprint baud = %d, want %d", result.Fingerprint.Baud, tt.wantBaud)
+			}
+			if len(tried) != tt.wantTries {
+				t.Errorf("tried %v, want %d rates", tried, tt.wantTries)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package console
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alexpitcher/LanAudit/internal/logging"
+)
+
+// DefaultReconnectDelay gives a USB adapter time to re-enumerate before the
+// port is opened again
+const DefaultReconnectDelay = 2 * time.Second
+
+// ReconnectEvent is sent on the session's error channel while it reopens an
+// idle or failed port
+type ReconnectEvent struct {
+	Attempt   int // 1-based
+	Max       int
+	Connected bool // This attempt reopened the port
+	GaveUp    bool // Every attempt failed; the session no longer reads
+}
+
+func (e *ReconnectEvent) Error() string {
+	switch {
+	case e.GaveUp:
+		return fmt.Sprintf("gave up reconnecting after %d attempts", e.Max)
+	case e.Connected:
+		return fmt.Sprintf("reconnected on attempt %d/%d", e.Attempt, e.Max)
+	default:
+		return fmt.Sprintf("reconnecting (attempt %d/%d)", e.Attempt, e.Max)
+	}
+}
+
+// reconnect closes the port and opens it again, up to MaxReconnects times.
+// It returns false once the session should stop reading.
+func (s *Session) reconnect() bool {
+	s.mu.RLock()
+	old := s.port
+	s.mu.RUnlock()
+	old.Close()
+
+	delay := s.config.ReconnectDelay
+	if delay <= 0 {
+		delay = DefaultReconnectDelay
+	}
+
+	maxAttempts := s.config.MaxReconnects
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		logging.Warnf("session %s reconnect attempt %d/%d to %s", s.id, attempt, maxAttempts, s.config.PortPath)
+		s.reportError(&ReconnectEvent{Attempt: attempt, Max: maxAttempts})
+
+		select {
+		case <-s.ctx.Done():
+			return false
+		case <-time.After(delay):
+		}
+
+		port, err := openSerialPort(s.config.PortPath, serialMode(s.config))
+		if err != nil {
+			logging.Warnf("session %s reconnect attempt %d/%d failed: %v", s.id, attempt, maxAttempts, err)
+			continue
+		}
+		if s.config.IdleTimeout > 0 {
+			if err := port.SetReadTimeout(s.config.IdleTimeout); err != nil {
+				logging.Warnf("session %s reconnect attempt %d/%d failed: %v", s.id, attempt, maxAttempts, err)
+				port.Close()
+				continue
+			}
+		}
+
+		s.mu.Lock()
+		if s.ctx.Err() != nil {
+			// Closed while the port was being opened
+			s.mu.Unlock()
+			port.Close()
+			return false
+		}
+		s.port = port
+		dtr, rts := s.dtrState, s.rtsState
+		s.mu.Unlock()
+
+		// Restore the control lines the user had set
+		_ = s.SetDTR(dtr)
+		_ = s.SetRTS(rts)
+
+		logging.Infof("session %s reconnected to %s on attempt %d", s.id, s.config.PortPath, attempt)
+		s.reportError(&ReconnectEvent{Attempt: attempt, Max: maxAttempts, Connected: true})
+		return true
+	}
+
+	logging.Warnf("session %s gave up reconnecting to %s after %d attempts", s.id, s.config.PortPath, maxAttempts)
+	s.reportError(&ReconnectEvent{Attempt: maxAttempts, Max: maxAttempts, GaveUp: true})
+	return false
+}
+
+// reportError queues err for ErrorChan without blocking the read loop
+func (s *Session) reportError(err error) {
+	select {
+	case s.errChan <- err:
+	default:
+	}
+}
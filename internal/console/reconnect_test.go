@@ -0,0 +1,187 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// idlePort returns queued data and honours the read timeout like a real
+// port: Read comes back empty once the timeout passes
+type idlePort struct {
+	fakePort
+	data    chan []byte
+	done    chan struct{}
+	once    sync.Once
+	timeout time.Duration
+}
+
+func newIdlePort(data ...string) *idlePort {
+	p := &idlePort{data: make(chan []byte, len(data)+1), done: make(chan struct{})}
+	for _, d := range data {
+		p.data <- []byte(d)
+	}
+	return p
+}
+
+func (p *idlePort) SetReadTimeout(t time.Duration) error {
+	p.timeout = t
+	return nil
+}
+
+func (p *idlePort) Read(b []byte) (int, error) {
+	var timeout <-chan time.Time
+	if p.timeout > 0 {
+		timeout = time.After(p.timeout)
+	}
+	select {
+	case data := <-p.data:
+		return copy(b, data), nil
+	case <-timeout:
+		return 0, nil
+	case <-p.done:
+		return 0, errors.New("port closed")
+	}
+}
+
+func (p *idlePort) Close() error {
+	p.fakePort.Close()
+	p.once.Do(func() { close(p.done) })
+	return nil
+}
+
+// stubOpen makes openSerialPort hand out ports in order; a nil entry fails
+// that open, as does every open past the end
+func stubOpen(t *testing.T, ports ...*idlePort) *atomic.Int32 {
+	t.Helper()
+	var opens atomic.Int32
+	orig := openSerialPort
+	openSerialPort = func(string, *serial.Mode) (serial.Port, error) {
+		i := int(opens.Add(1)) - 1
+		if i >= len(ports) || ports[i] == nil {
+			return nil, errors.New("no such device")
+		}
+		return ports[i], nil
+	}
+	t.Cleanup(func() { openSerialPort = orig })
+	return &opens
+}
+
+// nextReconnectEvent skips plain read errors and returns the next event
+func nextReconnectEvent(t *testing.T, sess *Session) ReconnectEvent {
+	t.Helper()
+	deadline := time.After(2 * time.Second)
+	for {
+		select {
+		case err := <-sess.ErrorChan():
+			var ev *ReconnectEvent
+			if errors.As(err, &ev) {
+				return *ev
+			}
+		case <-deadline:
+			t.Fatal("no reconnect event")
+		}
+	}
+}
+
+func reconnectConfig(idle time.Duration, maxReconnects int) SessionConfig {
+	cfg := DefaultSessionConfig("/dev/ttyUSB0", 9600)
+	cfg.IdleTimeout = idle
+	cfg.MaxReconnects = maxReconnects
+	cfg.ReconnectDelay = 5 * time.Millisecond
+	return cfg
+}
+
+func TestSessionReconnectAfterIdle(t *testing.T) {
+	first, second := newIdlePort(), newIdlePort("Switch#")
+	stubOpen(t, first, nil, second)
+
+	sess, err := NewSession(context.Background(), reconnectConfig(30*time.Millisecond, 3))
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	defer sess.Close()
+
+	want := []ReconnectEvent{
+		{Attempt: 1, Max: 3},
+		{Attempt: 2, Max: 3},
+		{Attempt: 2, Max: 3, Connected: true},
+	}
+	for _, w := range want {
+		if got := nextReconnectEvent(t, sess); got != w {
+			t.Fatalf("event = %+v, want %+v", got, w)
+		}
+	}
+
+	select {
+	case data := <-sess.ReadChan():
+		if string(data) != "Switch#" {
+			t.Errorf("read %q from reopened port", data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no data after reconnect")
+	}
+	if first.closed.Load() != 1 {
+		t.Errorf("idle port closed %d times, want 1", first.closed.Load())
+	}
+}
+
+func TestSessionReconnectAfterReadError(t *testing.T) {
+	first, second := newIdlePort(), newIdlePort("login:")
+	stubOpen(t, first, second)
+
+	sess, err := NewSession(context.Background(), reconnectConfig(0, 1))
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	defer sess.Close()
+
+	// Unplugging the adapter makes reads fail
+	first.Close()
+
+	if got := nextReconnectEvent(t, sess); got != (ReconnectEvent{Attempt: 1, Max: 1}) {
+		t.Fatalf("event = %+v", got)
+	}
+	if got := nextReconnectEvent(t, sess); !got.Connected {
+		t.Fatalf("event = %+v, want connected", got)
+	}
+	select {
+	case data := <-sess.ReadChan():
+		if string(data) != "login:" {
+			t.Errorf("read %q from reopened port", data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no data after reconnect")
+	}
+}
+
+func TestSessionReconnectGivesUp(t *testing.T) {
+	opens := stubOpen(t, newIdlePort())
+
+	sess, err := NewSession(context.Background(), reconnectConfig(20*time.Millisecond, 2))
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	defer sess.Close()
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		if got := nextReconnectEvent(t, sess); got != (ReconnectEvent{Attempt: attempt, Max: 2}) {
+			t.Fatalf("event = %+v, want attempt %d", got, attempt)
+		}
+	}
+	got := nextReconnectEvent(t, sess)
+	if !got.GaveUp || got.Error() != "gave up reconnecting after 2 attempts" {
+		t.Fatalf("event = %+v (%v), want gave up", got, &got)
+	}
+
+	// The read loop stops instead of retrying forever
+	time.Sleep(100 * time.Millisecond)
+	if n := opens.Load(); n != 3 {
+		t.Errorf("port opened %d times, want 3", n)
+	}
+}
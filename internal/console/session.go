@@ -24,6 +24,14 @@ type SessionConfig struct {
 	CRLFMode  string // "CRLF", "CR", "LF"
 	LocalEcho bool
 	LogToFile bool
+	StripANSI bool // Remove ANSI escape sequences from displayed output
+
+	// IdleTimeout reopens the port when nothing has been read for this
+	// long; 0 disables it. A read error also triggers a reconnect when
+	// MaxReconnects is set.
+	IdleTimeout    time.Duration
+	MaxReconnects  int
+	ReconnectDelay time.Duration // Wait before each reconnect attempt
 }
 
 // DefaultSessionConfig returns default session configuration
@@ -37,9 +45,16 @@ func DefaultSessionConfig(portPath string, baud int) SessionConfig {
 		CRLFMode:  "CRLF",
 		LocalEcho: false,
 		LogToFile: false,
+
+		ReconnectDelay: DefaultReconnectDelay,
 	}
 }
 
+// openSerialPort opens a serial port; replaced in tests
+var openSerialPort = func(path string, mode *serial.Mode) (serial.Port, error) {
+	return serial.Open(path, mode)
+}
+
 // Session represents an active serial console session
 type Session struct {
 	id           string
@@ -58,44 +73,30 @@ type Session struct {
 	dtrState     bool
 	rtsState     bool
 	watchers     map[chan []byte]struct{}
+
+	transcript      *os.File
+	transcriptStart time.Time // zero point for transcript timestamps
+	macroRecorder   *MacroRecorder
+
+	alerts    map[string]alertEntry
+	alertHits chan alertHit
+	alertLine []byte // received text not yet ended by a newline
 }
 
 // NewSession creates a new serial console session
 func NewSession(ctx context.Context, config SessionConfig) (*Session, error) {
-	// Convert parity string to serial.Parity
-	var parity serial.Parity
-	switch config.Parity {
-	case "N":
-		parity = serial.NoParity
-	case "O":
-		parity = serial.OddParity
-	case "E":
-		parity = serial.EvenParity
-	default:
-		parity = serial.NoParity
-	}
-
-	// Convert stop bits
-	var stopBits serial.StopBits
-	if config.StopBits == 2 {
-		stopBits = serial.TwoStopBits
-	} else {
-		stopBits = serial.OneStopBit
-	}
-
-	// Open port
-	mode := &serial.Mode{
-		BaudRate: config.Baud,
-		DataBits: config.DataBits,
-		Parity:   parity,
-		StopBits: stopBits,
-	}
-
-	port, err := serial.Open(config.PortPath, mode)
+	port, err := openSerialPort(config.PortPath, serialMode(config))
 	if err != nil {
 		logging.Errorf("Session open failed port=%s baud=%d: %v", config.PortPath, config.Baud, err)
 		return nil, fmt.Errorf("failed to open port: %w", err)
 	}
+	if config.IdleTimeout > 0 {
+		// Read returns empty-handed once the line has been idle this long
+		if err := port.SetReadTimeout(config.IdleTimeout); err != nil {
+			port.Close()
+			return nil, fmt.Errorf("failed to set read timeout: %w", err)
+		}
+	}
 
 	// Create session context
 	sessionCtx, cancel := context.WithCancel(ctx)
@@ -134,20 +135,70 @@ func NewSession(ctx context.Context, config SessionConfig) (*Session, error) {
 	return session, nil
 }
 
+// serialMode converts the session settings to a serial.Mode
+func serialMode(config SessionConfig) *serial.Mode {
+	// Convert parity string to serial.Parity
+	var parity serial.Parity
+	switch config.Parity {
+	case "N":
+		parity = serial.NoParity
+	case "O":
+		parity = serial.OddParity
+	case "E":
+		parity = serial.EvenParity
+	default:
+		parity = serial.NoParity
+	}
+
+	// Convert stop bits
+	var stopBits serial.StopBits
+	if config.StopBits == 2 {
+		stopBits = serial.TwoStopBits
+	} else {
+		stopBits = serial.OneStopBit
+	}
+
+	return &serial.Mode{
+		BaudRate: config.Baud,
+		DataBits: config.DataBits,
+		Parity:   parity,
+		StopBits: stopBits,
+	}
+}
+
 // ID returns the session identifier
 func (s *Session) ID() string {
 	return s.id
 }
 
+// PortPath returns the serial port the session is connected to
+func (s *Session) PortPath() string {
+	return s.config.PortPath
+}
+
 // Write sends data to the serial port, applying CR/LF transformation
 func (s *Session) Write(data []byte) (int, error) {
+	// Transform line endings based on CRLFMode
+	n, err := s.writeRaw(s.transformLineEndings(data))
+	if err != nil {
+		return n, err
+	}
+
+	s.mu.RLock()
+	rec := s.macroRecorder
+	s.mu.RUnlock()
+	if rec != nil {
+		rec.sent(data)
+	}
+	return n, nil
+}
+
+// writeRaw sends data to the serial port unchanged
+func (s *Session) writeRaw(data []byte) (int, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Transform line endings based on CRLFMode
-	transformed := s.transformLineEndings(data)
-
-	n, err := s.port.Write(transformed)
+	n, err := s.port.Write(data)
 	if err != nil {
 		logging.Errorf("session %s write error: %v", s.id, err)
 		return n, fmt.Errorf("serial write error: %w", err)
@@ -158,7 +209,7 @@ func (s *Session) Write(data []byte) (int, error) {
 
 	// Log to file if enabled
 	if s.logFile != nil {
-		s.logFile.Write(transformed)
+		s.logFile.Write(data)
 	}
 
 	return n, nil
@@ -303,6 +354,12 @@ func (s *Session) ReadUntil(timeout time.Duration, terminators ...[]byte) (strin
 	s.registerWatcher(watcher)
 	defer s.unregisterWatcher(watcher)
 
+	return readUntil(s.ctx, s.id, watcher, timeout, terminators)
+}
+
+// readUntil collects chunks mirrored to watcher until the output ends with one
+// of the terminators, the timeout expires or ctx is done
+func readUntil(ctx context.Context, id string, watcher <-chan []byte, timeout time.Duration, terminators [][]byte) (string, error) {
 	timer := time.NewTimer(timeout)
 	defer timer.Stop()
 
@@ -310,25 +367,25 @@ func (s *Session) ReadUntil(timeout time.Duration, terminators ...[]byte) (strin
 
 	for {
 		select {
-		case <-s.ctx.Done():
-			logging.Warnf("session %s ReadUntil aborted: context done", s.id)
+		case <-ctx.Done():
+			logging.Warnf("session %s ReadUntil aborted: context done", id)
 			return builder.String(), fmt.Errorf("session closed")
 		case <-timer.C:
-			logging.Warnf("session %s ReadUntil timeout", s.id)
+			logging.Warnf("session %s ReadUntil timeout", id)
 			return builder.String(), fmt.Errorf("probe read timeout")
 		case chunk := <-watcher:
 			if len(chunk) == 0 {
 				continue
 			}
 			builder.Write(chunk)
-			logging.Debugf("session %s ReadUntil received chunk len=%d", s.id, len(chunk))
+			logging.Debugf("session %s ReadUntil received chunk len=%d", id, len(chunk))
 
 			if len(terminators) == 0 {
 				continue
 			}
 
 			if matchesTerminator(builder.String(), terminators) {
-				logging.Debugf("session %s ReadUntil terminator matched", s.id)
+				logging.Debugf("session %s ReadUntil terminator matched", id)
 				return builder.String(), nil
 			}
 		}
@@ -370,6 +427,9 @@ func (s *Session) Close() error {
 	if s.logFileTxt != nil {
 		s.logFileTxt.Close()
 	}
+	if s.transcript != nil {
+		s.transcript.Close()
+	}
 	logging.Infof("session %s closed", s.id)
 
 	return s.port.Close()
@@ -378,6 +438,7 @@ func (s *Session) Close() error {
 // readLoop continuously reads from the serial port
 func (s *Session) readLoop() {
 	buffer := make([]byte, 4096)
+	lastRead := time.Now()
 
 	for {
 		select {
@@ -386,39 +447,45 @@ func (s *Session) readLoop() {
 		default:
 		}
 
+		// Only this goroutine replaces the port, so it can read it unlocked
 		n, err := s.port.Read(buffer)
 		if err != nil {
 			if err != io.EOF {
-				select {
-				case s.errChan <- fmt.Errorf("read error: %w", err):
-				default:
+				s.reportError(fmt.Errorf("read error: %w", err))
+			}
+			if s.config.MaxReconnects > 0 && s.ctx.Err() == nil {
+				if !s.reconnect() {
+					return
 				}
+				lastRead = time.Now()
 			}
 			continue
 		}
 
+		if n == 0 && s.config.IdleTimeout > 0 && time.Since(lastRead) >= s.config.IdleTimeout {
+			logging.Warnf("session %s idle for %s", s.id, s.config.IdleTimeout)
+			if !s.reconnect() {
+				return
+			}
+			lastRead = time.Now()
+			continue
+		}
+
 		if n > 0 {
+			lastRead = time.Now()
 			data := make([]byte, n)
 			copy(data, buffer[:n])
 
-			s.mu.Lock()
-			s.bytesRead += uint64(n)
-			logging.Debugf("session %s read %d bytes", s.id, n)
+			s.recordChunk(data, time.Now())
+			s.checkAlerts(data)
 
-			// Log to file
-			if s.logFile != nil {
-				s.logFile.Write(data)
-			}
-			if s.logFileTxt != nil {
-				// Write cleaned version
-				cleaned := cleanSerialData(data)
-				s.logFileTxt.WriteString(cleaned)
+			// Send to channel (non-blocking); watchers get the raw bytes
+			display := data
+			if s.config.StripANSI {
+				display = StripANSI(data)
 			}
-			s.mu.Unlock()
-
-			// Send to channel (non-blocking)
 			select {
-			case s.readChan <- data:
+			case s.readChan <- display:
 			default:
 				// Channel full, drop data
 			}
@@ -428,9 +495,38 @@ func (s *Session) readLoop() {
 	}
 }
 
+// recordChunk counts data read from the port and writes it to any open logs
+func (s *Session) recordChunk(data []byte, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.bytesRead += uint64(len(data))
+	logging.Debugf("session %s read %d bytes", s.id, len(data))
+
+	// Log to file
+	if s.logFile != nil {
+		s.logFile.Write(data)
+	}
+	if s.logFileTxt != nil {
+		// Write cleaned version; colour codes only clutter a text log
+		cleaned := cleanSerialData(data, true)
+		s.logFileTxt.WriteString(cleaned)
+	}
+	if s.transcript != nil {
+		if err := writeTranscriptLine(s.transcript, at.Sub(s.transcriptStart), data); err != nil {
+			logging.Warnf("session %s transcript write failed: %v", s.id, err)
+		}
+	}
+}
+
 // transformLineEndings applies CR/LF transformation based on config
 func (s *Session) transformLineEndings(data []byte) []byte {
-	if s.config.CRLFMode == "CRLF" {
+	return transformLineEndings(s.config.CRLFMode, data)
+}
+
+// transformLineEndings rewrites \n for the given CRLFMode
+func transformLineEndings(mode string, data []byte) []byte {
+	if mode == "CRLF" {
 		// Replace \n with \r\n
 		result := make([]byte, 0, len(data)*2)
 		for _, b := range data {
@@ -441,7 +537,7 @@ func (s *Session) transformLineEndings(data []byte) []byte {
 			}
 		}
 		return result
-	} else if s.config.CRLFMode == "CR" {
+	} else if mode == "CR" {
 		// Replace \n with \r
 		result := make([]byte, len(data))
 		for i, b := range data {
@@ -488,6 +584,14 @@ func (s *Session) initLogging() error {
 		return err
 	}
 
+	// Timed transcript for --replay
+	transcriptPath := filepath.Join(logDir, fmt.Sprintf("%s-%s.transcript", timestamp, baseName))
+	if err := s.RecordTranscript(transcriptPath); err != nil {
+		s.logFile.Close()
+		s.logFileTxt.Close()
+		return err
+	}
+
 	return nil
 }
 
@@ -0,0 +1,621 @@
+package console
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alexpitcher/LanAudit/internal/logging"
+)
+
+// TelnetHint marks SerialPort entries that are reached over Telnet
+const TelnetHint = "Telnet"
+
+// DefaultTelnetDialTimeout bounds the TCP connect to a console server
+const DefaultTelnetDialTimeout = 5 * time.Second
+
+// Telnet commands (RFC 854)
+const (
+	telnetSE   byte = 240
+	telnetBRK  byte = 243
+	telnetSB   byte = 250
+	telnetWILL byte = 251
+	telnetWONT byte = 252
+	telnetDO   byte = 253
+	telnetDONT byte = 254
+	telnetIAC  byte = 255
+)
+
+// Telnet options
+const (
+	optEcho    byte = 1  // RFC 857
+	optSGA     byte = 3  // RFC 858, suppress go-ahead
+	optComPort byte = 44 // RFC 2217
+)
+
+// COM-PORT-CONTROL client commands and SET-CONTROL values (RFC 2217)
+const (
+	comSetBaudRate byte = 1
+	comSetDataSize byte = 2
+	comSetParity   byte = 3
+	comSetStopSize byte = 4
+	comSetControl  byte = 5
+
+	controlBreakOn  byte = 5
+	controlBreakOff byte = 6
+	controlDTROn    byte = 8
+	controlDTROff   byte = 9
+	controlRTSOn    byte = 11
+	controlRTSOff   byte = 12
+)
+
+// TelnetSession is a console session to a serial port exposed by a console
+// server (Lantronix, Digi, ser2net) over Telnet
+type TelnetSession struct {
+	id        string
+	config    SessionConfig
+	conn      net.Conn
+	ctx       context.Context
+	cancel    context.CancelFunc
+	readChan  chan []byte
+	errChan   chan error
+	mu        sync.RWMutex
+	writeMu   sync.Mutex // serialises writes to conn
+	closeOnce sync.Once
+	closeErr  error
+
+	bytesRead    uint64
+	bytesWritten uint64
+	startTime    time.Time
+	dtrState     bool
+	rtsState     bool
+	comPort      bool // server accepted COM-PORT-CONTROL
+	watchers     map[chan []byte]struct{}
+
+	decoder telnetDecoder
+	options telnetOptions
+}
+
+// NewTelnetSession connects to host:port and negotiates a character-mode
+// terminal. cfg.PortPath is set to host:port.
+func NewTelnetSession(ctx context.Context, host string, port int, cfg SessionConfig) (*TelnetSession, error) {
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	cfg.PortPath = addr
+
+	dialer := net.Dialer{Timeout: DefaultTelnetDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		logging.Errorf("Telnet session open failed addr=%s: %v", addr, err)
+		return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+
+	sess := newTelnetSession(ctx, conn, cfg)
+	if cfg.LogToFile {
+		logging.Warnf("telnet session %s: file logging is only supported for serial sessions", sess.id)
+	}
+
+	if err := sess.send(sess.options.start()); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to negotiate telnet options: %w", err)
+	}
+
+	go sess.readLoop()
+
+	logging.Infof("Telnet session started id=%s addr=%s", sess.id, addr)
+	return sess, nil
+}
+
+// newTelnetSession wraps an established connection
+func newTelnetSession(ctx context.Context, conn net.Conn, cfg SessionConfig) *TelnetSession {
+	sessionCtx, cancel := context.WithCancel(ctx)
+	return &TelnetSession{
+		id:        fmt.Sprintf("%s-%d", cfg.PortPath, time.Now().Unix()),
+		config:    cfg,
+		conn:      conn,
+		ctx:       sessionCtx,
+		cancel:    cancel,
+		readChan:  make(chan []byte, 100),
+		errChan:   make(chan error, 10),
+		startTime: time.Now(),
+		dtrState:  true,
+		rtsState:  true,
+		watchers:  make(map[chan []byte]struct{}),
+		options:   newTelnetOptions(),
+	}
+}
+
+// ParseTelnetTarget splits a "host:port" console server address
+func ParseTelnetTarget(target string) (string, int, error) {
+	host, portStr, err := net.SplitHostPort(target)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid telnet target %q: %w", target, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 1 || port > 65535 {
+		return "", 0, fmt.Errorf("invalid telnet port in %q", target)
+	}
+	return host, port, nil
+}
+
+// TelnetPorts turns configured "host:port" targets into port list entries
+func TelnetPorts(targets []string) []SerialPort {
+	ports := make([]SerialPort, 0, len(targets))
+	for _, target := range targets {
+		if _, _, err := ParseTelnetTarget(target); err != nil {
+			logging.Warnf("skipping telnet target: %v", err)
+			continue
+		}
+		ports = append(ports, SerialPort{
+			Path:         target,
+			FriendlyName: "Telnet " + target,
+			Hints:        TelnetHint,
+		})
+	}
+	return ports
+}
+
+// ID returns the session identifier
+func (s *TelnetSession) ID() string {
+	return s.id
+}
+
+// PortPath returns the host:port the session is connected to
+func (s *TelnetSession) PortPath() string {
+	return s.config.PortPath
+}
+
+// Write sends data to the remote port, applying CR/LF transformation and
+// escaping IAC bytes
+func (s *TelnetSession) Write(data []byte) (int, error) {
+	transformed := transformLineEndings(s.config.CRLFMode, data)
+
+	escaped := make([]byte, 0, len(transformed))
+	for _, b := range transformed {
+		if b == telnetIAC {
+			escaped = append(escaped, telnetIAC)
+		}
+		escaped = append(escaped, b)
+	}
+
+	if err := s.send(escaped); err != nil {
+		logging.Errorf("telnet session %s write error: %v", s.id, err)
+		return 0, fmt.Errorf("telnet write error: %w", err)
+	}
+
+	s.mu.Lock()
+	s.bytesWritten += uint64(len(transformed))
+	s.mu.Unlock()
+	logging.Debugf("telnet session %s wrote %d bytes", s.id, len(transformed))
+	return len(transformed), nil
+}
+
+// send writes raw protocol bytes to the connection
+func (s *TelnetSession) send(b []byte) error {
+	if len(b) == 0 {
+		return nil
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_, err := s.conn.Write(b)
+	return err
+}
+
+// SendBreak sends a break for the given duration using COM-PORT-CONTROL if
+// the server supports it, or a Telnet BRK otherwise
+func (s *TelnetSession) SendBreak(duration time.Duration) error {
+	logging.Infof("telnet session %s send break duration=%s", s.id, duration)
+	if !s.hasComPort() {
+		return s.send([]byte{telnetIAC, telnetBRK})
+	}
+
+	if err := s.send(comPortCommand(comSetControl, controlBreakOn)); err != nil {
+		return fmt.Errorf("failed to send break: %w", err)
+	}
+	time.Sleep(duration)
+	return s.send(comPortCommand(comSetControl, controlBreakOff))
+}
+
+// SetDTR sets the remote DTR line via COM-PORT-CONTROL
+func (s *TelnetSession) SetDTR(active bool) error {
+	value := controlDTROff
+	if active {
+		value = controlDTROn
+	}
+	if err := s.setControl(value); err != nil {
+		logging.Errorf("telnet session %s set DTR failed: %v", s.id, err)
+		return fmt.Errorf("failed to set DTR: %w", err)
+	}
+
+	s.mu.Lock()
+	s.dtrState = active
+	s.mu.Unlock()
+	logging.Debugf("telnet session %s DTR=%v", s.id, active)
+	return nil
+}
+
+// SetRTS sets the remote RTS line via COM-PORT-CONTROL
+func (s *TelnetSession) SetRTS(active bool) error {
+	value := controlRTSOff
+	if active {
+		value = controlRTSOn
+	}
+	if err := s.setControl(value); err != nil {
+		logging.Errorf("telnet session %s set RTS failed: %v", s.id, err)
+		return fmt.Errorf("failed to set RTS: %w", err)
+	}
+
+	s.mu.Lock()
+	s.rtsState = active
+	s.mu.Unlock()
+	logging.Debugf("telnet session %s RTS=%v", s.id, active)
+	return nil
+}
+
+func (s *TelnetSession) setControl(value byte) error {
+	if !s.hasComPort() {
+		return fmt.Errorf("telnet server does not support COM-PORT-CONTROL")
+	}
+	return s.send(comPortCommand(comSetControl, value))
+}
+
+func (s *TelnetSession) hasComPort() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.comPort
+}
+
+// GetDTR returns the current DTR state
+func (s *TelnetSession) GetDTR() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.dtrState
+}
+
+// GetRTS returns the current RTS state
+func (s *TelnetSession) GetRTS() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rtsState
+}
+
+// ReadChan returns the channel for reading data from the remote port
+func (s *TelnetSession) ReadChan() <-chan []byte {
+	return s.readChan
+}
+
+// ErrorChan returns the channel for errors
+func (s *TelnetSession) ErrorChan() <-chan error {
+	return s.errChan
+}
+
+// ReadUntil reads data mirrored from the connection until a terminator or timeout.
+func (s *TelnetSession) ReadUntil(timeout time.Duration, terminators ...[]byte) (string, error) {
+	if timeout <= 0 {
+		timeout = 1200 * time.Millisecond
+	}
+
+	watcher := make(chan []byte, 32)
+	s.mu.Lock()
+	s.watchers[watcher] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.watchers, watcher)
+		s.mu.Unlock()
+	}()
+
+	return readUntil(s.ctx, s.id, watcher, timeout, terminators)
+}
+
+// GetStats returns session statistics
+func (s *TelnetSession) GetStats() (bytesRead, bytesWritten uint64, duration time.Duration) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.bytesRead, s.bytesWritten, time.Since(s.startTime)
+}
+
+// Close closes the connection and stops the session
+func (s *TelnetSession) Close() error {
+	s.closeOnce.Do(func() {
+		s.cancel()
+		s.closeErr = s.conn.Close()
+		logging.Infof("telnet session %s closed", s.id)
+	})
+	return s.closeErr
+}
+
+// readLoop decodes data from the connection until it is closed
+func (s *TelnetSession) readLoop() {
+	// Unblock Read when the parent context is cancelled
+	go func() {
+		<-s.ctx.Done()
+		s.Close()
+	}()
+
+	buffer := make([]byte, 4096)
+	for {
+		n, err := s.conn.Read(buffer)
+		if n > 0 {
+			s.handleInput(buffer[:n])
+		}
+		if err != nil {
+			if s.ctx.Err() == nil {
+				select {
+				case s.errChan <- fmt.Errorf("telnet read error: %w", err):
+				default:
+				}
+			}
+			return
+		}
+	}
+}
+
+// handleInput strips Telnet commands from raw input, answers negotiations
+// and delivers the remaining data
+func (s *TelnetSession) handleInput(raw []byte) {
+	var replies []byte
+	data := s.decoder.feed(raw, func(verb, opt byte, sub []byte) {
+		if verb == telnetSB {
+			logging.Debugf("telnet session %s subnegotiation opt=%d len=%d", s.id, opt, len(sub))
+			return
+		}
+		reply, comPortEnabled := s.options.negotiate(verb, opt)
+		replies = append(replies, reply...)
+		if comPortEnabled {
+			s.mu.Lock()
+			s.comPort = true
+			s.mu.Unlock()
+			replies = append(replies, comPortSettings(s.config)...)
+			logging.Infof("telnet session %s: server supports COM-PORT-CONTROL", s.id)
+		}
+	})
+	if err := s.send(replies); err != nil {
+		logging.Warnf("telnet session %s negotiation reply failed: %v", s.id, err)
+	}
+
+	if len(data) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	s.bytesRead += uint64(len(data))
+	s.mu.Unlock()
+	logging.Debugf("telnet session %s read %d bytes", s.id, len(data))
+
+	display := data
+	if s.config.StripANSI {
+		display = StripANSI(data)
+	}
+	select {
+	case s.readChan <- display:
+	default:
+		// Channel full, drop data
+	}
+
+	s.mu.RLock()
+	for ch := range s.watchers {
+		copyData := make([]byte, len(data))
+		copy(copyData, data)
+		select {
+		case ch <- copyData:
+		default:
+		}
+	}
+	s.mu.RUnlock()
+}
+
+// comPortCommand builds a COM-PORT-CONTROL subnegotiation, escaping IAC in
+// the payload
+func comPortCommand(cmd byte, payload ...byte) []byte {
+	b := []byte{telnetIAC, telnetSB, optComPort, cmd}
+	for _, p := range payload {
+		if p == telnetIAC {
+			b = append(b, telnetIAC)
+		}
+		b = append(b, p)
+	}
+	return append(b, telnetIAC, telnetSE)
+}
+
+// comPortSettings asks the server to apply the session's line settings
+func comPortSettings(cfg SessionConfig) []byte {
+	var b []byte
+	if cfg.Baud > 0 {
+		baud := make([]byte, 4)
+		binary.BigEndian.PutUint32(baud, uint32(cfg.Baud))
+		b = append(b, comPortCommand(comSetBaudRate, baud...)...)
+	}
+	if cfg.DataBits >= 5 && cfg.DataBits <= 8 {
+		b = append(b, comPortCommand(comSetDataSize, byte(cfg.DataBits))...)
+	}
+	switch cfg.Parity {
+	case "N":
+		b = append(b, comPortCommand(comSetParity, 1)...)
+	case "O":
+		b = append(b, comPortCommand(comSetParity, 2)...)
+	case "E":
+		b = append(b, comPortCommand(comSetParity, 3)...)
+	}
+	if cfg.StopBits == 1 || cfg.StopBits == 2 {
+		b = append(b, comPortCommand(comSetStopSize, byte(cfg.StopBits))...)
+	}
+	return b
+}
+
+// telnetOptions tracks option negotiation so that requests are answered
+// once and confirmations of our own requests are not answered at all
+type telnetOptions struct {
+	local  map[byte]bool    // options we perform, agreed by the server
+	remote map[byte]bool    // options the server performs
+	asked  map[[2]byte]bool // requests we sent that await a reply
+}
+
+func newTelnetOptions() telnetOptions {
+	return telnetOptions{
+		local:  make(map[byte]bool),
+		remote: make(map[byte]bool),
+		asked:  make(map[[2]byte]bool),
+	}
+}
+
+// start returns the requests sent when the connection opens: the server
+// should echo and suppress go-ahead, and we offer COM-PORT-CONTROL
+func (o *telnetOptions) start() []byte {
+	requests := [][2]byte{
+		{telnetDO, optEcho},
+		{telnetDO, optSGA},
+		{telnetWILL, optSGA},
+		{telnetWILL, optComPort},
+	}
+	var b []byte
+	for _, r := range requests {
+		o.asked[r] = true
+		b = append(b, telnetIAC, r[0], r[1])
+	}
+	return b
+}
+
+// negotiate handles one WILL/WONT/DO/DONT from the server and returns the
+// reply to send, plus whether COM-PORT-CONTROL has just been enabled
+func (o *telnetOptions) negotiate(verb, opt byte) ([]byte, bool) {
+	reply := func(v byte) []byte { return []byte{telnetIAC, v, opt} }
+
+	switch verb {
+	case telnetWILL:
+		if o.remote[opt] {
+			return nil, false
+		}
+		if opt != optEcho && opt != optSGA {
+			return reply(telnetDONT), false
+		}
+		o.remote[opt] = true
+		if o.asked[[2]byte{telnetDO, opt}] {
+			delete(o.asked, [2]byte{telnetDO, opt})
+			return nil, false
+		}
+		return reply(telnetDO), false
+
+	case telnetWONT:
+		delete(o.asked, [2]byte{telnetDO, opt})
+		if o.remote[opt] {
+			o.remote[opt] = false
+			return reply(telnetDONT), false
+		}
+
+	case telnetDO:
+		if o.local[opt] {
+			return nil, false
+		}
+		if opt != optSGA && opt != optComPort {
+			return reply(telnetWONT), false
+		}
+		o.local[opt] = true
+		var out []byte
+		if o.asked[[2]byte{telnetWILL, opt}] {
+			delete(o.asked, [2]byte{telnetWILL, opt})
+		} else {
+			out = reply(telnetWILL)
+		}
+		return out, opt == optComPort
+
+	case telnetDONT:
+		delete(o.asked, [2]byte{telnetWILL, opt})
+		if o.local[opt] {
+			o.local[opt] = false
+			return reply(telnetWONT), false
+		}
+	}
+	return nil, false
+}
+
+// Decoder states
+const (
+	decodeData = iota
+	decodeCR
+	decodeIAC
+	decodeOption
+	decodeSub
+	decodeSubIAC
+)
+
+// telnetDecoder separates data from Telnet commands across reads
+type telnetDecoder struct {
+	state int
+	verb  byte
+	sub   []byte
+}
+
+// feed decodes raw input, calling onCommand for every negotiation (verb is
+// WILL/WONT/DO/DONT) and subnegotiation (verb is SB), and returns the data
+func (d *telnetDecoder) feed(in []byte, onCommand func(verb, opt byte, sub []byte)) []byte {
+	out := make([]byte, 0, len(in))
+	for i := 0; i < len(in); i++ {
+		b := in[i]
+		switch d.state {
+		case decodeCR:
+			// NVT sends a bare carriage return as CR NUL
+			d.state = decodeData
+			if b == 0 {
+				continue
+			}
+			i--
+
+		case decodeData:
+			switch b {
+			case telnetIAC:
+				d.state = decodeIAC
+			case '\r':
+				out = append(out, b)
+				d.state = decodeCR
+			default:
+				out = append(out, b)
+			}
+
+		case decodeIAC:
+			switch b {
+			case telnetIAC:
+				out = append(out, b)
+				d.state = decodeData
+			case telnetWILL, telnetWONT, telnetDO, telnetDONT:
+				d.verb = b
+				d.state = decodeOption
+			case telnetSB:
+				d.sub = d.sub[:0]
+				d.state = decodeSub
+			default:
+				// NOP, GA and friends carry no data
+				d.state = decodeData
+			}
+
+		case decodeOption:
+			onCommand(d.verb, b, nil)
+			d.state = decodeData
+
+		case decodeSub:
+			if b == telnetIAC {
+				d.state = decodeSubIAC
+			} else {
+				d.sub = append(d.sub, b)
+			}
+
+		case decodeSubIAC:
+			switch b {
+			case telnetIAC:
+				d.sub = append(d.sub, b)
+				d.state = decodeSub
+			case telnetSE:
+				if len(d.sub) > 0 {
+					onCommand(telnetSB, d.sub[0], d.sub[1:])
+				}
+				d.state = decodeData
+			default:
+				d.state = decodeData
+			}
+		}
+	}
+	return out
+}
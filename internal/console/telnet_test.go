@@ -0,0 +1,240 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestTelnetDecoder(t *testing.T) {
+	type command struct {
+		verb, opt byte
+		sub       string
+	}
+
+	tests := []struct {
+		name     string
+		chunks   []string
+		wantData string
+		wantCmds []command
+	}{
+		{"plain data", []string{"Switch>"}, "Switch>", nil},
+		{"escaped IAC", []string{"a\xff\xffb"}, "a\xffb", nil},
+		{"CR NUL", []string{"login:\r\x00\r\n"}, "login:\r\r\n", nil},
+		{"CR NUL split", []string{"x\r", "\x00y"}, "x\ry", nil},
+		{"negotiation", []string{"\xff\xfb\x01hi\xff\xfd\x2c"}, "hi", []command{{telnetWILL, optEcho, ""}, {telnetDO, optComPort, ""}}},
+		{"negotiation split", []string{"\xff", "\xfb", "\x03ok"}, "ok", []command{{telnetWILL, optSGA, ""}}},
+		{"subnegotiation", []string{"\xff\xfa\x2c\x6d\x00\x01\xff\xff\xff\xf0done"}, "done", []command{{telnetSB, optComPort, "\x6d\x00\x01\xff"}}},
+		{"go-ahead dropped", []string{"a\xff\xf9b"}, "ab", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d telnetDecoder
+			var data []byte
+			var cmds []command
+			for _, chunk := range tt.chunks {
+				data = append(data, d.feed([]byte(chunk), func(verb, opt byte, sub []byte) {
+					cmds = append(cmds, command{verb, opt, string(sub)})
+				})...)
+			}
+			if string(data) != tt.wantData {
+				t.Errorf("data = %q, want %q", data, tt.wantData)
+			}
+			if len(cmds) != len(tt.wantCmds) {
+				t.Fatalf("commands = %v, want %v", cmds, tt.wantCmds)
+			}
+			for i := range cmds {
+				if cmds[i] != tt.wantCmds[i] {
+					t.Errorf("command %d = %v, want %v", i, cmds[i], tt.wantCmds[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTelnetNegotiation(t *testing.T) {
+	const optTermType byte = 24
+
+	tests := []struct {
+		name        string
+		verb, opt   byte
+		wantReply   []byte
+		wantComPort bool
+	}{
+		{"server confirms echo", telnetWILL, optEcho, nil, false},
+		{"server confirms SGA", telnetWILL, optSGA, nil, false},
+		{"echo already on", telnetWILL, optEcho, nil, false},
+		{"unsupported server option", telnetWILL, optTermType, []byte{telnetIAC, telnetDONT, optTermType}, false},
+		{"unsupported local option", telnetDO, optTermType, []byte{telnetIAC, telnetWONT, optTermType}, false},
+		{"we don't echo", telnetDO, optEcho, []byte{telnetIAC, telnetWONT, optEcho}, false},
+		{"server accepts COM-PORT", telnetDO, optComPort, nil, true},
+		{"COM-PORT already on", telnetDO, optComPort, nil, false},
+		{"server stops echoing", telnetWONT, optEcho, []byte{telnetIAC, telnetDONT, optEcho}, false},
+		{"server asks echo again", telnetWILL, optEcho, []byte{telnetIAC, telnetDO, optEcho}, false},
+		{"server disables COM-PORT", telnetDONT, optComPort, []byte{telnetIAC, telnetWONT, optComPort}, false},
+		{"disabled option stays quiet", telnetDONT, optComPort, nil, false},
+	}
+
+	// The cases run in order against one connection's state
+	opts := newTelnetOptions()
+	opts.start()
+	for _, tt := range tests {
+		reply, comPort := opts.negotiate(tt.verb, tt.opt)
+		if !bytes.Equal(reply, tt.wantReply) {
+			t.Errorf("%s: reply = %v, want %v", tt.name, reply, tt.wantReply)
+		}
+		if comPort != tt.wantComPort {
+			t.Errorf("%s: COM-PORT enabled = %v, want %v", tt.name, comPort, tt.wantComPort)
+		}
+	}
+}
+
+// initialNegotiation is what a client sends as soon as it connects
+var initialNegotiation = []byte{
+	telnetIAC, telnetDO, optEcho,
+	telnetIAC, telnetDO, optSGA,
+	telnetIAC, telnetWILL, optSGA,
+	telnetIAC, telnetWILL, optComPort,
+}
+
+// telnetServer accepts one connection and hands it to the test
+func telnetServer(t *testing.T) (string, int, <-chan net.Conn) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	conns := make(chan net.Conn, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			close(conns)
+			return
+		}
+		t.Cleanup(func() { conn.Close() })
+		conns <- conn
+	}()
+	addr := ln.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, conns
+}
+
+// expectBytes reads exactly len(want) bytes from conn
+func expectBytes(t *testing.T, conn net.Conn, want []byte, what string) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	got := make([]byte, len(want))
+	if _, err := io.ReadFull(conn, got); err != nil {
+		t.Fatalf("%s: %v", what, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("%s = %v, want %v", what, got, want)
+	}
+}
+
+func TestTelnetSessionComPort(t *testing.T) {
+	host, port, conns := telnetServer(t)
+	cfg := DefaultSessionConfig("", 115200)
+	sess, err := NewTelnetSession(context.Background(), host, port, cfg)
+	if err != nil {
+		t.Fatalf("NewTelnetSession: %v", err)
+	}
+	defer sess.Close()
+
+	server := <-conns
+	expectBytes(t, server, initialNegotiation, "initial negotiation")
+
+	// Accept COM-PORT-CONTROL and send some output
+	server.Write([]byte("\xff\xfd\x2cUser\xff\xffname:\r\x00"))
+	settings := comPortSettings(sess.config)
+	if !bytes.HasPrefix(settings, []byte{telnetIAC, telnetSB, optComPort, comSetBaudRate, 0x00, 0x01, 0xc2, 0x00, telnetIAC, telnetSE}) {
+		t.Errorf("line settings don't start with SET-BAUDRATE 115200: %v", settings)
+	}
+	expectBytes(t, server, settings, "line settings")
+
+	select {
+	case data := <-sess.ReadChan():
+		if string(data) != "User\xffname:\r" {
+			t.Errorf("read %q", data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no data from session")
+	}
+
+	if err := sess.SetDTR(false); err != nil {
+		t.Fatalf("SetDTR: %v", err)
+	}
+	expectBytes(t, server, []byte{telnetIAC, telnetSB, optComPort, comSetControl, controlDTROff, telnetIAC, telnetSE}, "DTR off")
+	if sess.GetDTR() {
+		t.Error("DTR state not updated")
+	}
+
+	if _, err := sess.Write([]byte("a\xff\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	expectBytes(t, server, []byte("a\xff\xff\r\n"), "escaped write")
+
+	read, written, _ := sess.GetStats()
+	if read != 11 || written != 4 {
+		t.Errorf("stats read=%d written=%d, want 11 and 4", read, written)
+	}
+	if sess.PortPath() != net.JoinHostPort(host, strconv.Itoa(port)) {
+		t.Errorf("PortPath() = %q", sess.PortPath())
+	}
+}
+
+func TestTelnetSessionWithoutComPort(t *testing.T) {
+	host, port, conns := telnetServer(t)
+	sess, err := NewTelnetSession(context.Background(), host, port, DefaultSessionConfig("", 9600))
+	if err != nil {
+		t.Fatalf("NewTelnetSession: %v", err)
+	}
+
+	server := <-conns
+	expectBytes(t, server, initialNegotiation, "initial negotiation")
+
+	// Refuse COM-PORT-CONTROL; DTR can't be mapped, break falls back to BRK
+	server.Write([]byte{telnetIAC, telnetDONT, optComPort})
+	time.Sleep(50 * time.Millisecond)
+	if err := sess.SetRTS(false); err == nil {
+		t.Error("expected SetRTS to fail without COM-PORT-CONTROL")
+	}
+	if err := sess.SendBreak(time.Millisecond); err != nil {
+		t.Fatalf("SendBreak: %v", err)
+	}
+	expectBytes(t, server, []byte{telnetIAC, telnetBRK}, "break")
+
+	// The server closing the connection is reported
+	server.Close()
+	select {
+	case err := <-sess.ErrorChan():
+		if err == nil {
+			t.Error("expected a read error")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("connection close not reported")
+	}
+	if err := sess.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+}
+
+func TestTelnetPorts(t *testing.T) {
+	ports := TelnetPorts([]string{"10.0.0.5:2001", "bad", "host:0", "[fe80::1]:7001"})
+	if len(ports) != 2 {
+		t.Fatalf("got %d ports, want 2: %v", len(ports), ports)
+	}
+	if ports[0].Path != "10.0.0.5:2001" || ports[0].Hints != TelnetHint {
+		t.Errorf("unexpected port %+v", ports[0])
+	}
+	host, port, err := ParseTelnetTarget(ports[1].Path)
+	if err != nil || host != "fe80::1" || port != 7001 {
+		t.Errorf("ParseTelnetTarget(%q) = %q, %d, %v", ports[1].Path, host, port, err)
+	}
+}
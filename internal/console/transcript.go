@@ -0,0 +1,119 @@
+package console
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexpitcher/LanAudit/internal/logging"
+)
+
+// transcriptSleep waits between replayed chunks; replaced in tests
+var transcriptSleep = time.Sleep
+
+// RecordTranscript starts writing every chunk read from the port to path, one
+// line per chunk: "<us_since_start> <hex_bytes> <printable_text>"
+func (s *Session) RecordTranscript(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create transcript: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.transcript != nil {
+		s.transcript.Close()
+	}
+	s.transcript = f
+	s.transcriptStart = time.Now()
+	logging.Infof("session %s recording transcript to %s", s.id, path)
+	return nil
+}
+
+// GetTranscriptPath returns the path of the transcript being recorded, if any
+func (s *Session) GetTranscriptPath() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.transcript != nil {
+		return s.transcript.Name()
+	}
+	return ""
+}
+
+// writeTranscriptLine appends one chunk to a transcript
+func writeTranscriptLine(w io.Writer, elapsed time.Duration, data []byte) error {
+	_, err := fmt.Fprintf(w, "%d %s %s\n", elapsed.Microseconds(), hex.EncodeToString(data), printableText(data))
+	return err
+}
+
+// printableText renders data for humans reading a transcript; anything that
+// isn't printable ASCII, including newlines, becomes '.'
+func printableText(data []byte) string {
+	b := make([]byte, len(data))
+	for i, c := range data {
+		if c >= 0x20 && c < 0x7f {
+			b[i] = c
+		} else {
+			b[i] = '.'
+		}
+	}
+	return string(b)
+}
+
+// ReplayTranscript writes the data recorded in a transcript to w, keeping the
+// original timing between chunks scaled by speed: 1.0 replays in real time,
+// 2.0 twice as fast and 0.0 without any delay.
+func ReplayTranscript(path string, speed float64, w io.Writer) error {
+	if speed < 0 {
+		return fmt.Errorf("replay speed must not be negative")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open transcript: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	// The hex and text columns triple a 4 KiB read chunk
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var last time.Duration
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) < 2 {
+			return fmt.Errorf("transcript line %d: expected timestamp and data", lineNo)
+		}
+		us, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("transcript line %d: invalid timestamp: %w", lineNo, err)
+		}
+		data, err := hex.DecodeString(fields[1])
+		if err != nil {
+			return fmt.Errorf("transcript line %d: invalid data: %w", lineNo, err)
+		}
+
+		at := time.Duration(us) * time.Microsecond
+		if speed > 0 && at > last {
+			transcriptSleep(time.Duration(float64(at-last) / speed))
+		}
+		last = at
+
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,115 @@
+package console
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubTranscriptSleep records requested delays instead of sleeping
+func stubTranscriptSleep(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var delays []time.Duration
+	orig := transcriptSleep
+	transcriptSleep = func(d time.Duration) { delays = append(delays, d) }
+	t.Cleanup(func() { transcriptSleep = orig })
+	return &delays
+}
+
+func TestReplayTranscriptGolden(t *testing.T) {
+	want, err := os.ReadFile(filepath.Join("testdata", "session.out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		speed  float64
+		delays []time.Duration
+	}{
+		{"real time", 1.0, []time.Duration{250 * time.Millisecond, 1250 * time.Millisecond, 500 * time.Microsecond}},
+		{"double speed", 2.0, []time.Duration{125 * time.Millisecond, 625 * time.Millisecond, 250 * time.Microsecond}},
+		{"instant", 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delays := stubTranscriptSleep(t)
+			var out bytes.Buffer
+			if err := ReplayTranscript(filepath.Join("testdata", "session.transcript"), tt.speed, &out); err != nil {
+				t.Fatalf("ReplayTranscript: %v", err)
+			}
+			if !bytes.Equal(out.Bytes(), want) {
+				t.Errorf("replayed output = %q, want %q", out.Bytes(), want)
+			}
+			if len(*delays) != len(tt.delays) {
+				t.Fatalf("delays = %v, want %v", *delays, tt.delays)
+			}
+			for i := range tt.delays {
+				if (*delays)[i] != tt.delays[i] {
+					t.Errorf("delay %d = %v, want %v", i, (*delays)[i], tt.delays[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRecordTranscriptRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rec.transcript")
+	s := &Session{id: "test"}
+	if err := s.RecordTranscript(path); err != nil {
+		t.Fatalf("RecordTranscript: %v", err)
+	}
+	if s.GetTranscriptPath() != path {
+		t.Errorf("GetTranscriptPath() = %q, want %q", s.GetTranscriptPath(), path)
+	}
+
+	start := s.transcriptStart
+	s.recordChunk([]byte("Switch>\x1b[K"), start.Add(1500*time.Microsecond))
+	s.recordChunk([]byte(" enable\r\n"), start.Add(2*time.Second))
+	s.transcript.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	wantLines := []string{
+		"1500 5377697463683e1b5b4b Switch>.[K",
+		"2000000 20656e61626c650d0a  enable..",
+	}
+	if strings.Join(lines, "\n") != strings.Join(wantLines, "\n") {
+		t.Errorf("transcript =\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(wantLines, "\n"))
+	}
+	if read, _, _ := s.GetStats(); read != 19 {
+		t.Errorf("bytesRead = %d, want 19", read)
+	}
+
+	stubTranscriptSleep(t)
+	var out bytes.Buffer
+	if err := ReplayTranscript(path, 1.0, &out); err != nil {
+		t.Fatalf("ReplayTranscript: %v", err)
+	}
+	if out.String() != "Switch>\x1b[K enable\r\n" {
+		t.Errorf("replayed %q", out.String())
+	}
+}
+
+func TestReplayTranscriptErrors(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.transcript")
+	os.WriteFile(bad, []byte("0 zz ..\n"), 0644)
+
+	if err := ReplayTranscript(bad, 0, &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("expected line number in error, got %v", err)
+	}
+	if err := ReplayTranscript(filepath.Join(dir, "missing"), 0, &bytes.Buffer{}); err == nil {
+		t.Error("expected error for a missing transcript")
+	}
+	if err := ReplayTranscript(bad, -1, &bytes.Buffer{}); err == nil {
+		t.Error("expected error for a negative speed")
+	}
+}
@@ -0,0 +1,71 @@
+package diagnostics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// captiveProbe is a well-known endpoint with a fixed, unauthenticated response
+type captiveProbe struct {
+	URL        string
+	WantStatus int
+	WantBody   string // exact body after trimming whitespace
+}
+
+// captiveProbes are checked in order until one gives a definite answer
+var captiveProbes = []captiveProbe{
+	{
+		URL:        "http://connectivitycheck.gstatic.com/generate_204",
+		WantStatus: http.StatusNoContent,
+		WantBody:   "",
+	},
+	{
+		URL:        "http://captive.apple.com/hotspot-detect.html",
+		WantStatus: http.StatusOK,
+		WantBody:   "<HTML><HEAD><TITLE>Success</TITLE></HEAD><BODY>Success</BODY></HTML>",
+	},
+}
+
+// maxCaptiveBody bounds how much of a probe response is read
+const maxCaptiveBody = 64 * 1024
+
+// detectCaptivePortal fetches the reference endpoints over plain HTTP without
+// following redirects. Any response that differs from the reference means
+// something on the path is intercepting traffic. Returns the portal URL when
+// one can be determined (the redirect target, or the probe URL otherwise).
+func detectCaptivePortal(ctx context.Context, transport http.RoundTripper, probes []captiveProbe) (bool, string) {
+	client := &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	for _, probe := range probes {
+		req, err := http.NewRequestWithContext(ctx, "GET", probe.URL, nil)
+		if err != nil {
+			continue
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			// Endpoint unreachable: inconclusive, try the next one
+			continue
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxCaptiveBody))
+		resp.Body.Close()
+
+		if resp.StatusCode == probe.WantStatus && strings.TrimSpace(string(body)) == probe.WantBody {
+			return false, ""
+		}
+
+		if loc := resp.Header.Get("Location"); loc != "" {
+			return true, loc
+		}
+		return true, probe.URL
+	}
+
+	return false, ""
+}
@@ -0,0 +1,97 @@
+package diagnostics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	netpkg "github.com/alexpitcher/LanAudit/internal/net"
+	"github.com/alexpitcher/LanAudit/internal/store"
+)
+
+func TestDetectCaptivePortal(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		want     bool
+		wantURL  string
+		relative bool // wantURL is relative to the test server
+	}{
+		{
+			name: "clean network",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			},
+			want: false,
+		},
+		{
+			name: "redirecting portal",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "http://portal.example/login", http.StatusFound)
+			},
+			want:    true,
+			wantURL: "http://portal.example/login",
+		},
+		{
+			name: "portal page served in place",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("<html>Please accept the terms</html>"))
+			},
+			want:     true,
+			wantURL:  "/generate_204",
+			relative: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.handler)
+			defer srv.Close()
+
+			probes := []captiveProbe{{URL: srv.URL + "/generate_204", WantStatus: http.StatusNoContent}}
+			got, url := detectCaptivePortal(context.Background(), nil, probes)
+			if got != tt.want {
+				t.Fatalf("detectCaptivePortal() = %v, want %v", got, tt.want)
+			}
+
+			wantURL := tt.wantURL
+			if tt.relative {
+				wantURL = srv.URL + tt.wantURL
+			}
+			if url != wantURL {
+				t.Errorf("portal URL = %q, want %q", url, wantURL)
+			}
+		})
+	}
+}
+
+func TestDetectCaptivePortalUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close() // nothing listening
+
+	probes := []captiveProbe{{URL: srv.URL, WantStatus: http.StatusNoContent}}
+	if got, _ := detectCaptivePortal(context.Background(), nil, probes); got {
+		t.Error("unreachable probe endpoint should be inconclusive, not a portal")
+	}
+}
+
+func TestRunWithDepsCaptivePortal(t *testing.T) {
+	details := &netpkg.InterfaceDetails{LinkUp: true, DefaultGateways: []string{"192.168.1.1"}}
+	prober := &mockHTTPSProber{result: HTTPSResult{OK: true, Status: 200, CaptivePortal: true, CaptivePortalURL: "http://portal.example/login"}}
+
+	result, err := RunWithDeps(context.Background(), details, &store.Config{}, &mockPinger{}, &mockDNSResolver{}, prober, nil, nil)
+	if err != nil {
+		t.Fatalf("RunWithDeps() error = %v", err)
+	}
+
+	for _, s := range result.Suggestions {
+		if strings.Contains(s, "All diagnostics passed") {
+			t.Errorf("captive portal should not report healthy network")
+		}
+	}
+	if !strings.Contains(strings.Join(result.Suggestions, "\n"), "portal.example/login") {
+		t.Errorf("expected captive portal suggestion, got %v", result.Suggestions)
+	}
+}
@@ -3,53 +3,132 @@ package diagnostics
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
 	"os/exec"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/miekg/dns"
 	netpkg "github.com/alexpitcher/LanAudit/internal/net"
 	"github.com/alexpitcher/LanAudit/internal/store"
+	"golang.org/x/sync/errgroup"
 )
 
 // Result contains diagnostics test results
 type Result struct {
+	Timestamp   time.Time
+	Interface   string
 	LinkUp      bool
 	Gateway     string
 	Ping        PingResult
+	RemotePings map[string]PingResult // public servers, by address
 	DNS         DNSResult
 	HTTPS       HTTPSResult
+	MTU         *MTUProbeResult // nil unless config.ProbeMTU is set
+	Trace       *TraceResult    // nil unless config.Traceroute is set
+	NTP         *NTPResult      // nil unless config.CheckNTP is set
 	Suggestions []string
 }
 
-// PingResult contains ping test results
+// PingResult contains ping test results. The RTTs are the distribution of
+// the replies' round trip times.
 type PingResult struct {
-	Loss      float64
-	MedianRTT time.Duration
-	Err       string
+	Loss   float64
+	RTTMin time.Duration
+	RTTP50 time.Duration
+	RTTP95 time.Duration
+	RTTMax time.Duration
+	Err    string
+}
+
+// UnmarshalJSON reads results stored before the distribution was recorded,
+// taking their MedianRTT as RTTP50
+func (p *PingResult) UnmarshalJSON(data []byte) error {
+	type plain PingResult
+	var v struct {
+		plain
+		MedianRTT time.Duration
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*p = PingResult(v.plain)
+	if p.RTTP50 == 0 {
+		p.RTTP50 = v.MedianRTT
+	}
+	return nil
+}
+
+// RTTSummary formats the RTT distribution for display
+func (p PingResult) RTTSummary() string {
+	return fmt.Sprintf("min %v, p50 %v, p95 %v, max %v", p.RTTMin, p.RTTP50, p.RTTP95, p.RTTMax)
+}
+
+// setRTTs fills in the distribution from the individual samples
+func (p *PingResult) setRTTs(samples []time.Duration) {
+	if len(samples) == 0 {
+		return
+	}
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	p.RTTMin = sorted[0]
+	p.RTTP50 = rttPercentile(sorted, 50)
+	p.RTTP95 = rttPercentile(sorted, 95)
+	p.RTTMax = sorted[len(sorted)-1]
+}
+
+// rttPercentile returns the nearest-rank percentile of sorted samples
+func rttPercentile(sorted []time.Duration, pct int) time.Duration {
+	rank := (pct*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
 }
 
 // DNSResult contains DNS test results
 type DNSResult struct {
-	SystemOK  bool
-	AltOK     bool
-	AltTried  []string
-	Err       string
+	SystemOK bool
+	AltOK    bool
+	AltTried []string
+	DOH      DOHResult
+	DNSSEC   DNSSECResult
+	Err      string
 }
 
 // HTTPSResult contains HTTPS test results
 type HTTPSResult struct {
-	OK     bool
-	Status int
-	TLSOK  bool
-	Err    string
+	OK               bool
+	Target           string
+	Status           int
+	TLSOK            bool
+	CaptivePortal    bool
+	CaptivePortalURL string
+	CertExpiry       time.Time
+	CertIssuer       string
+	CertDaysLeft     int
+	CertExpiringSoon bool
+	Err              string
 }
 
+// DefaultHTTPSTarget is probed when Config.ProbeTargets is empty
+const DefaultHTTPSTarget = "https://example.com"
+
+// DefaultCertWarnDays is used when Config.CertWarnDays is unset
+const DefaultCertWarnDays = 30
+
+// DefaultRemotePingTargets are public servers pinged alongside the gateway
+// to tell local problems from upstream ones
+var DefaultRemotePingTargets = []string{"8.8.8.8", "1.1.1.1", "208.67.222.222"}
+
 // Pinger interface for testing
 type Pinger interface {
 	Ping(ctx context.Context, host string, count int) (PingResult, error)
@@ -80,15 +159,25 @@ func Run(ctx context.Context, details *netpkg.InterfaceDetails, config *store.Co
 	pinger := &DefaultPinger{}
 	resolver := &DefaultDNSResolver{}
 	prober := &DefaultHTTPSProber{}
+	mtuProber := &DefaultMTUProber{}
+	tracer := &DefaultTracer{}
 
-	return RunWithDeps(ctx, details, config, pinger, resolver, prober)
+	return RunWithDeps(ctx, details, config, pinger, resolver, prober, mtuProber, tracer)
 }
 
 // RunWithDeps runs diagnostics with injected dependencies for testing
-func RunWithDeps(ctx context.Context, details *netpkg.InterfaceDetails, config *store.Config, pinger Pinger, resolver DNSResolver, prober HTTPSProber) (*Result, error) {
+func RunWithDeps(ctx context.Context, details *netpkg.InterfaceDetails, config *store.Config, pinger Pinger, resolver DNSResolver, prober HTTPSProber, mtuProber MTUProber, tracer Tracer) (*Result, error) {
+	// Prefer the IPv4 gateway, falling back to IPv6 on v6-only networks
+	gateway := details.IPv4Gateway()
+	if gateway == "" {
+		gateway = details.IPv6Gateway()
+	}
+
 	result := &Result{
-		LinkUp:  details.LinkUp,
-		Gateway: details.DefaultGateway,
+		Timestamp: time.Now(),
+		Interface: details.Name,
+		LinkUp:    details.LinkUp,
+		Gateway:   gateway,
 	}
 
 	// Check link status
@@ -97,40 +186,76 @@ func RunWithDeps(ctx context.Context, details *netpkg.InterfaceDetails, config *
 		return result, nil
 	}
 
-	// Ping gateway
-	if details.DefaultGateway != "" {
-		pingRes, err := pinger.Ping(ctx, details.DefaultGateway, 4)
-		if err != nil {
-			result.Ping.Err = err.Error()
-		} else {
-			result.Ping = pingRes
+	plainAlts, dohAlts := splitDNSAlternates(config.DNSAlternates)
+
+	// Ping, DNS and HTTPS are independent, so run them side by side unless
+	// disabled. Each test writes only its own field of result.
+	tests := []func(){
+		func() {
+			if gateway == "" {
+				return
+			}
+			// The remote servers are IPv4, so they would all fail on an
+			// IPv6-only network
+			targets := []string{gateway}
+			if gateway == details.IPv4Gateway() {
+				targets = append(targets, DefaultRemotePingTargets...)
+			}
+			pings := PingAll(ctx, targets, 4, pinger)
+			result.Ping = pings[gateway]
+			delete(pings, gateway)
+			if len(pings) > 0 {
+				result.RemotePings = pings
+			}
+		},
+		func() { result.DNS = runDNS(ctx, resolver, details.DNSServers, plainAlts, dohAlts) },
+		func() { result.HTTPS = runHTTPS(ctx, prober, config.ProbeTargets) },
+	}
+	if config.CheckNTP {
+		tests = append(tests, func() {
+			ntpRes := ntpCheckFunc(ctx, DefaultNTPServer)
+			result.NTP = &ntpRes
+		})
+	}
+	if config.Concurrent {
+		var g errgroup.Group
+		for _, test := range tests {
+			test := test
+			g.Go(func() error {
+				test()
+				return nil
+			})
 		}
+		g.Wait()
+	} else {
+		for _, test := range tests {
+			test()
+		}
+	}
 
+	if gateway != "" {
 		if result.Ping.Loss > 50 {
 			result.Suggestions = append(result.Suggestions, "High packet loss to gateway. Check network cable or Wi-Fi signal strength.")
 		} else if result.Ping.Loss > 0 {
 			result.Suggestions = append(result.Suggestions, "Some packet loss detected. Network may be congested.")
 		}
+		if pingSucceeded(result.Ping) && len(result.RemotePings) > 0 && !anyPingSucceeded(result.RemotePings) {
+			result.Suggestions = append(result.Suggestions, "Local gateway reachable but internet unreachable—check ISP")
+		}
 	} else {
 		result.Suggestions = append(result.Suggestions, "No default gateway configured. Check DHCP or static IP configuration.")
 	}
 
-	// DNS tests
-	dnsErr := resolver.ResolveSystem(ctx, "example.com")
-	result.DNS.SystemOK = dnsErr == nil
-	if dnsErr != nil {
-		result.DNS.Err = dnsErr.Error()
+	if !result.DNS.SystemOK && result.DNS.AltOK {
+		result.Suggestions = append(result.Suggestions, fmt.Sprintf("System DNS failed but alternative DNS (%s) worked. Consider changing DNS servers.", plainAlts[0]))
 	}
 
-	// Try alternative DNS servers if system DNS fails
-	if !result.DNS.SystemOK && len(config.DNSAlternates) > 0 {
-		altErr := resolver.ResolveAlt(ctx, "example.com", config.DNSAlternates)
-		result.DNS.AltOK = altErr == nil
-		result.DNS.AltTried = config.DNSAlternates
+	if !result.DNS.SystemOK && !result.DNS.AltOK && result.DNS.DOH.OK {
+		result.Suggestions = append(result.Suggestions, fmt.Sprintf("Plain DNS is failing but DNS-over-HTTPS (%s) works. Port 53 may be blocked.", result.DNS.DOH.Server))
+	}
 
-		if result.DNS.AltOK {
-			result.Suggestions = append(result.Suggestions, fmt.Sprintf("System DNS failed but alternative DNS (%s) worked. Consider changing DNS servers.", config.DNSAlternates[0]))
-		}
+	if result.DNS.DNSSEC.Server != "" && result.DNS.DNSSEC.Err == "" && !result.DNS.DNSSEC.ChainOK {
+		result.Suggestions = append(result.Suggestions, "DNS resolver does not validate DNSSEC.")
 	}
 
 	if !result.DNS.SystemOK && !result.DNS.AltOK {
@@ -141,18 +266,59 @@ func RunWithDeps(ctx context.Context, details *netpkg.InterfaceDetails, config *
 		}
 	}
 
-	// HTTPS probe
-	httpsRes, err := prober.ProbeHTTPS(ctx, "https://example.com")
-	if err != nil {
-		result.HTTPS.Err = err.Error()
-	} else {
-		result.HTTPS = httpsRes
+	if result.HTTPS.CaptivePortal {
+		result.Suggestions = append(result.Suggestions, fmt.Sprintf("Captive portal detected (%s). Sign in through a browser before relying on other results.", result.HTTPS.CaptivePortalURL))
 	}
 
 	if !result.HTTPS.OK && result.Ping.Loss == 0 && result.DNS.SystemOK {
 		result.Suggestions = append(result.Suggestions, "Network connectivity OK but HTTPS failing. Check for proxy, firewall, or captive portal.")
 	}
 
+	if !result.HTTPS.CertExpiry.IsZero() {
+		warnDays := config.CertWarnDays
+		if warnDays <= 0 {
+			warnDays = DefaultCertWarnDays
+		}
+		if result.HTTPS.CertDaysLeft < warnDays {
+			result.HTTPS.CertExpiringSoon = true
+			result.Suggestions = append(result.Suggestions, fmt.Sprintf("TLS certificate expires in %d days (issuer: %s).", result.HTTPS.CertDaysLeft, result.HTTPS.CertIssuer))
+		}
+	}
+
+	if result.NTP != nil && result.NTP.Err == "" && !result.NTP.OK {
+		result.Suggestions = append(result.Suggestions, fmt.Sprintf("System clock is off by %v. Clock skew breaks TLS and authentication; enable time synchronization.", result.NTP.Offset.Round(time.Millisecond)))
+	}
+
+	// Optional path MTU discovery
+	if config.ProbeMTU && mtuProber != nil {
+		start := details.MTU
+		if start <= 0 {
+			start = 1500
+		}
+		mtuRes := mtuProber.ProbePathMTU(ctx, DefaultMTUProbeTarget, start, MinProbeMTU)
+		result.MTU = &mtuRes
+
+		if mtuRes.Err == "" && mtuRes.MaxMTU < start {
+			result.Suggestions = append(result.Suggestions, fmt.Sprintf("Path MTU is %d, below interface MTU %d. Check for tunnels/PPPoE or enable MSS clamping.", mtuRes.MaxMTU, start))
+		}
+	}
+
+	// Optional traceroute
+	if config.Traceroute && tracer != nil {
+		traceRes, err := tracer.Trace(ctx, DefaultTraceTarget, DefaultMaxHops)
+		if err != nil {
+			traceRes.Err = err.Error()
+		}
+		result.Trace = &traceRes
+
+		if err == nil && !traceRes.Reached && len(traceRes.Hops) > 0 {
+			last := lastRespondingHop(traceRes.Hops)
+			if last != nil {
+				result.Suggestions = append(result.Suggestions, fmt.Sprintf("Traceroute stopped after hop %d (%s). Packets are being dropped beyond this point.", last.TTL, last.IP))
+			}
+		}
+	}
+
 	if len(result.Suggestions) == 0 && result.HTTPS.OK {
 		result.Suggestions = append(result.Suggestions, "All diagnostics passed. Network connectivity is healthy.")
 	}
@@ -160,9 +326,140 @@ func RunWithDeps(ctx context.Context, details *netpkg.InterfaceDetails, config *
 	return result, nil
 }
 
+// runPing pings one target
+func runPing(ctx context.Context, pinger Pinger, target string, count int) PingResult {
+	pingRes, err := pinger.Ping(ctx, target, count)
+	if err != nil {
+		return PingResult{Err: err.Error()}
+	}
+	return pingRes
+}
+
+// PingAll pings every target at once, sending count pings to each, and
+// returns the results by target
+func PingAll(ctx context.Context, targets []string, count int, pinger Pinger) map[string]PingResult {
+	results := make(map[string]PingResult, len(targets))
+	var mu sync.Mutex
+	var g errgroup.Group
+	for _, target := range targets {
+		target := target
+		g.Go(func() error {
+			res := runPing(ctx, pinger, target, count)
+			mu.Lock()
+			results[target] = res
+			mu.Unlock()
+			return nil
+		})
+	}
+	g.Wait()
+	return results
+}
+
+// pingSucceeded reports whether any reply came back
+func pingSucceeded(p PingResult) bool {
+	return p.Err == "" && p.Loss < 100
+}
+
+// anyPingSucceeded reports whether any of the targets replied
+func anyPingSucceeded(pings map[string]PingResult) bool {
+	for _, p := range pings {
+		if pingSucceeded(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// runDNS checks system DNS, falling back to the alternates when it fails
+func runDNS(ctx context.Context, resolver DNSResolver, servers, plainAlts, dohAlts []string) DNSResult {
+	var res DNSResult
+
+	dnsErr := resolver.ResolveSystem(ctx, "example.com")
+	res.SystemOK = dnsErr == nil
+	if dnsErr != nil {
+		res.Err = dnsErr.Error()
+	}
+
+	// Try alternative DNS servers if system DNS fails
+	if !res.SystemOK && len(plainAlts) > 0 {
+		altErr := resolver.ResolveAlt(ctx, "example.com", plainAlts)
+		res.AltOK = altErr == nil
+		res.AltTried = plainAlts
+	}
+
+	// DNS-over-HTTPS still works on networks that block port 53
+	if len(dohAlts) > 0 {
+		res.DOH = dohProbeFunc(ctx, "example.com", dohAlts[0])
+	}
+
+	// A resolver that doesn't validate DNSSEC can't detect spoofed answers
+	if res.SystemOK && len(servers) > 0 {
+		res.DNSSEC = checkDNSSEC(ctx, servers[0])
+	}
+
+	return res
+}
+
+// runHTTPS probes each target in order, returning the first success or the
+// last failure
+func runHTTPS(ctx context.Context, prober HTTPSProber, targets []string) HTTPSResult {
+	if len(targets) == 0 {
+		targets = []string{DefaultHTTPSTarget}
+	}
+
+	var last HTTPSResult
+	for _, target := range targets {
+		httpsRes, err := prober.ProbeHTTPS(ctx, target)
+		if err == nil {
+			httpsRes.Target = target
+			return httpsRes
+		}
+		last = HTTPSResult{
+			Target:           target,
+			CaptivePortal:    httpsRes.CaptivePortal,
+			CaptivePortalURL: httpsRes.CaptivePortalURL,
+			Err:              err.Error(),
+		}
+	}
+	return last
+}
+
+// OptionalTimeout returns the extra time budget needed by the optional
+// tests enabled in config, to be added to the diagnostics timeout
+func OptionalTimeout(config *store.Config) time.Duration {
+	var extra time.Duration
+	if config == nil {
+		return extra
+	}
+	if config.ProbeMTU {
+		// Two bound checks plus a ~10 step binary search, 1s per probe
+		extra += 12 * time.Second
+	}
+	if config.Traceroute {
+		extra += time.Duration(DefaultMaxHops) * hopTimeout
+	}
+	if config.CheckNTP {
+		extra += ntpTimeout
+	}
+	return extra
+}
+
+// lastRespondingHop returns the furthest hop that answered, or nil
+func lastRespondingHop(hops []HopResult) *HopResult {
+	for i := len(hops) - 1; i >= 0; i-- {
+		if hops[i].Err == "" {
+			return &hops[i]
+		}
+	}
+	return nil
+}
+
 // Ping executes ping command (macOS implementation)
 func (p *DefaultPinger) Ping(ctx context.Context, host string, count int) (PingResult, error) {
 	cmd := exec.CommandContext(ctx, "ping", "-c", strconv.Itoa(count), "-W", "1000", host)
+	if strings.Contains(host, ":") {
+		cmd = exec.CommandContext(ctx, "ping6", "-c", strconv.Itoa(count), host)
+	}
 	output, err := cmd.Output()
 	if err != nil {
 		return PingResult{Err: err.Error()}, err
@@ -182,11 +479,32 @@ func parsePingOutput(output string) (PingResult, error) {
 		result.Loss = loss
 	}
 
-	// Extract RTT (use avg as median approximation)
-	rttRe := regexp.MustCompile(`min/avg/max/stddev = ([\d.]+)/([\d.]+)/([\d.]+)/([\d.]+) ms`)
-	if matches := rttRe.FindStringSubmatch(output); len(matches) >= 3 {
-		avg, _ := strconv.ParseFloat(matches[2], 64)
-		result.MedianRTT = time.Duration(avg * float64(time.Millisecond))
+	// One sample per reply line: "icmp_seq=1 ttl=64 time=2.345 ms"
+	sampleRe := regexp.MustCompile(`icmp_seq=\d+.*\btime[=<]([\d.]+) ?ms`)
+	var samples []time.Duration
+	for _, matches := range sampleRe.FindAllStringSubmatch(output, -1) {
+		ms, err := strconv.ParseFloat(matches[1], 64)
+		if err == nil {
+			samples = append(samples, time.Duration(ms*float64(time.Millisecond)))
+		}
+	}
+	if len(samples) > 0 {
+		result.setRTTs(samples)
+		return result, nil
+	}
+
+	// Without reply lines, fall back to the summary, taking avg as p50 and
+	// max as p95
+	rttRe := regexp.MustCompile(`min/avg/max/(?:stddev|mdev) = ([\d.]+)/([\d.]+)/([\d.]+)/([\d.]+) ms`)
+	if matches := rttRe.FindStringSubmatch(output); len(matches) >= 4 {
+		ms := func(s string) time.Duration {
+			v, _ := strconv.ParseFloat(s, 64)
+			return time.Duration(v * float64(time.Millisecond))
+		}
+		result.RTTMin = ms(matches[1])
+		result.RTTP50 = ms(matches[2])
+		result.RTTP95 = ms(matches[3])
+		result.RTTMax = ms(matches[3])
 	}
 
 	return result, nil
@@ -227,6 +545,20 @@ func (r *DefaultDNSResolver) ResolveAlt(ctx context.Context, host string, server
 	return fmt.Errorf("all alternative DNS servers failed")
 }
 
+// setCertInfo records the leaf certificate's issuer and expiry
+func setCertInfo(result *HTTPSResult, certs []*x509.Certificate, now time.Time) {
+	if len(certs) == 0 {
+		return
+	}
+	leaf := certs[0]
+	result.CertExpiry = leaf.NotAfter
+	result.CertIssuer = leaf.Issuer.CommonName
+	if result.CertIssuer == "" && len(leaf.Issuer.Organization) > 0 {
+		result.CertIssuer = leaf.Issuer.Organization[0]
+	}
+	result.CertDaysLeft = int(leaf.NotAfter.Sub(now).Hours() / 24)
+}
+
 // ProbeHTTPS performs HTTPS connectivity test
 func (p *DefaultHTTPSProber) ProbeHTTPS(ctx context.Context, url string) (HTTPSResult, error) {
 	result := HTTPSResult{TLSOK: true}
@@ -252,12 +584,18 @@ func (p *DefaultHTTPSProber) ProbeHTTPS(ctx context.Context, url string) (HTTPSR
 		if strings.Contains(err.Error(), "certificate") {
 			result.TLSOK = false
 		}
+		// Portals commonly break TLS, so check for one on failure too
+		result.CaptivePortal, result.CaptivePortalURL = detectCaptivePortal(ctx, client.Transport, captiveProbes)
 		return result, err
 	}
 	defer resp.Body.Close()
 
 	result.OK = true
 	result.Status = resp.StatusCode
+	if resp.TLS != nil {
+		setCertInfo(&result, resp.TLS.PeerCertificates, time.Now())
+	}
+	result.CaptivePortal, result.CaptivePortalURL = detectCaptivePortal(ctx, client.Transport, captiveProbes)
 
 	return result, nil
 }
@@ -2,6 +2,13 @@ package diagnostics
 
 import (
 	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -11,12 +18,37 @@ import (
 
 // Mock implementations for testing
 type mockPinger struct {
-	result PingResult
-	err    error
+	result  PingResult
+	rtts    []time.Duration       // when set, fill in the result's RTT distribution
+	results map[string]PingResult // per-host results, overriding result
+	err     error
+
+	mu    sync.Mutex
+	hosts []string
 }
 
 func (m *mockPinger) Ping(ctx context.Context, host string, count int) (PingResult, error) {
-	return m.result, m.err
+	m.mu.Lock()
+	m.hosts = append(m.hosts, host)
+	m.mu.Unlock()
+	result := m.result
+	if r, ok := m.results[host]; ok {
+		result = r
+	}
+	result.setRTTs(m.rtts)
+	return result, m.err
+}
+
+// pinged reports whether host was pinged
+func (m *mockPinger) pinged(host string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, h := range m.hosts {
+		if h == host {
+			return true
+		}
+	}
+	return false
 }
 
 type mockDNSResolver struct {
@@ -41,12 +73,31 @@ func (m *mockHTTPSProber) ProbeHTTPS(ctx context.Context, url string) (HTTPSResu
 	return m.result, m.err
 }
 
+type mockMTUProber struct {
+	result MTUProbeResult
+	called bool
+}
+
+func (m *mockMTUProber) ProbePathMTU(ctx context.Context, dest string, start, min int) MTUProbeResult {
+	m.called = true
+	return m.result
+}
+
+type mockTracer struct {
+	result TraceResult
+	err    error
+}
+
+func (m *mockTracer) Trace(ctx context.Context, dest string, maxHops int) (TraceResult, error) {
+	return m.result, m.err
+}
+
 func TestParsePingOutput(t *testing.T) {
 	tests := []struct {
-		name       string
-		output     string
-		wantLoss   float64
-		wantRTT    time.Duration
+		name     string
+		output   string
+		wantLoss float64
+		want     PingResult // RTT fields only
 	}{
 		{
 			name: "successful ping",
@@ -60,15 +111,28 @@ func TestParsePingOutput(t *testing.T) {
 4 packets transmitted, 4 received, 0.0% packet loss, time 3005ms
 rtt min/avg/max/stddev = 1.234/1.759/2.345/0.456 ms`,
 			wantLoss: 0.0,
-			wantRTT:  1759 * time.Microsecond,
+			want:     PingResult{RTTMin: 1234 * time.Microsecond, RTTP50: 1567 * time.Microsecond, RTTP95: 2345 * time.Microsecond, RTTMax: 2345 * time.Microsecond},
+		},
+		{
+			name: "linux ping with a lost reply",
+			output: `PING 192.168.1.1 (192.168.1.1) 56(84) bytes of data.
+64 bytes from 192.168.1.1: icmp_seq=1 ttl=64 time=0.412 ms
+64 bytes from 192.168.1.1: icmp_seq=3 ttl=64 time=9.80 ms
+64 bytes from 192.168.1.1: icmp_seq=4 ttl=64 time=0.388 ms
+
+--- 192.168.1.1 ping statistics ---
+4 packets transmitted, 3 received, 25% packet loss, time 3004ms
+rtt min/avg/max/mdev = 0.388/3.533/9.800/4.431 ms`,
+			wantLoss: 25,
+			want:     PingResult{RTTMin: 388 * time.Microsecond, RTTP50: 412 * time.Microsecond, RTTP95: 9800 * time.Microsecond, RTTMax: 9800 * time.Microsecond},
 		},
 		{
-			name: "partial loss",
+			name: "summary only",
 			output: `--- 192.168.1.1 ping statistics ---
 4 packets transmitted, 2 received, 50.0% packet loss, time 3005ms
 rtt min/avg/max/stddev = 1.234/2.500/3.456/1.111 ms`,
 			wantLoss: 50.0,
-			wantRTT:  2500 * time.Microsecond,
+			want:     PingResult{RTTMin: 1234 * time.Microsecond, RTTP50: 2500 * time.Microsecond, RTTP95: 3456 * time.Microsecond, RTTMax: 3456 * time.Microsecond},
 		},
 	}
 
@@ -81,13 +145,45 @@ rtt min/avg/max/stddev = 1.234/2.500/3.456/1.111 ms`,
 			if result.Loss != tt.wantLoss {
 				t.Errorf("Loss = %v, want %v", result.Loss, tt.wantLoss)
 			}
-			if result.MedianRTT != tt.wantRTT {
-				t.Errorf("MedianRTT = %v, want %v", result.MedianRTT, tt.wantRTT)
+			if result.RTTMin != tt.want.RTTMin || result.RTTP50 != tt.want.RTTP50 || result.RTTP95 != tt.want.RTTP95 || result.RTTMax != tt.want.RTTMax {
+				t.Errorf("RTTs = %s, want %s", result.RTTSummary(), tt.want.RTTSummary())
 			}
 		})
 	}
 }
 
+func TestSetRTTs(t *testing.T) {
+	// 20 samples of 1ms..20ms, out of order
+	var samples []time.Duration
+	for i := 20; i >= 1; i-- {
+		samples = append(samples, time.Duration(i)*time.Millisecond)
+	}
+	var result PingResult
+	result.setRTTs(samples)
+	if result.RTTMin != time.Millisecond || result.RTTP50 != 10*time.Millisecond || result.RTTP95 != 19*time.Millisecond || result.RTTMax != 20*time.Millisecond {
+		t.Errorf("RTTs = %s, want min 1ms, p50 10ms, p95 19ms, max 20ms", result.RTTSummary())
+	}
+	if samples[0] != 20*time.Millisecond {
+		t.Error("setRTTs reordered the caller's samples")
+	}
+
+	result = PingResult{}
+	result.setRTTs([]time.Duration{7 * time.Millisecond})
+	if result.RTTMin != 7*time.Millisecond || result.RTTP95 != 7*time.Millisecond {
+		t.Errorf("single sample RTTs = %s", result.RTTSummary())
+	}
+}
+
+func TestPingResultReadsMedianRTT(t *testing.T) {
+	var result PingResult
+	if err := json.Unmarshal([]byte(`{"Loss":25,"MedianRTT":1500000,"Err":""}`), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Loss != 25 || result.RTTP50 != 1500*time.Microsecond {
+		t.Errorf("stored result read as %+v", result)
+	}
+}
+
 func TestRunWithDeps(t *testing.T) {
 	ctx := context.Background()
 
@@ -102,10 +198,10 @@ func TestRunWithDeps(t *testing.T) {
 		{
 			name: "all healthy",
 			details: &netpkg.InterfaceDetails{
-				LinkUp:         true,
-				DefaultGateway: "192.168.1.1",
+				LinkUp:          true,
+				DefaultGateways: []string{"192.168.1.1"},
 			},
-			pinger:          &mockPinger{result: PingResult{Loss: 0, MedianRTT: 1 * time.Millisecond}},
+			pinger:          &mockPinger{rtts: []time.Duration{time.Millisecond}},
 			resolver:        &mockDNSResolver{systemErr: nil, altErr: nil},
 			prober:          &mockHTTPSProber{result: HTTPSResult{OK: true, Status: 200, TLSOK: true}},
 			wantSuggestions: 1,
@@ -113,8 +209,8 @@ func TestRunWithDeps(t *testing.T) {
 		{
 			name: "link down",
 			details: &netpkg.InterfaceDetails{
-				LinkUp:         false,
-				DefaultGateway: "192.168.1.1",
+				LinkUp:          false,
+				DefaultGateways: []string{"192.168.1.1"},
 			},
 			pinger:          &mockPinger{},
 			resolver:        &mockDNSResolver{},
@@ -124,8 +220,8 @@ func TestRunWithDeps(t *testing.T) {
 		{
 			name: "high packet loss",
 			details: &netpkg.InterfaceDetails{
-				LinkUp:         true,
-				DefaultGateway: "192.168.1.1",
+				LinkUp:          true,
+				DefaultGateways: []string{"192.168.1.1"},
 			},
 			pinger:          &mockPinger{result: PingResult{Loss: 75}},
 			resolver:        &mockDNSResolver{systemErr: nil},
@@ -140,7 +236,7 @@ func TestRunWithDeps(t *testing.T) {
 				DNSAlternates: []string{"1.1.1.1", "8.8.8.8"},
 			}
 
-			result, err := RunWithDeps(ctx, tt.details, config, tt.pinger, tt.resolver, tt.prober)
+			result, err := RunWithDeps(ctx, tt.details, config, tt.pinger, tt.resolver, tt.prober, nil, nil)
 			if err != nil {
 				t.Fatalf("RunWithDeps() error = %v", err)
 			}
@@ -152,3 +248,313 @@ func TestRunWithDeps(t *testing.T) {
 		})
 	}
 }
+
+func TestPingAll(t *testing.T) {
+	pinger := &mockPinger{
+		result:  PingResult{Loss: 0},
+		results: map[string]PingResult{"1.1.1.1": {Loss: 100}},
+	}
+	results := PingAll(context.Background(), []string{"192.168.1.1", "8.8.8.8", "1.1.1.1"}, 4, pinger)
+	if len(results) != 3 || results["1.1.1.1"].Loss != 100 || results["8.8.8.8"].Loss != 0 {
+		t.Errorf("PingAll() = %+v", results)
+	}
+
+	pinger = &mockPinger{err: errors.New("exit status 2")}
+	results = PingAll(context.Background(), []string{"8.8.8.8"}, 4, pinger)
+	if results["8.8.8.8"].Err != "exit status 2" {
+		t.Errorf("PingAll() with a failing pinger = %+v", results)
+	}
+}
+
+func TestRunWithDepsRemotePings(t *testing.T) {
+	const isp = "Local gateway reachable but internet unreachable—check ISP"
+	unreachable := PingResult{Loss: 100, Err: "exit status 2"}
+	tests := []struct {
+		name     string
+		gateways []string
+		results  map[string]PingResult
+		wantISP  bool
+		wantPing int // remote servers pinged
+	}{
+		{name: "all reachable", gateways: []string{"192.168.1.1"}, wantPing: 3},
+		{name: "internet down", gateways: []string{"192.168.1.1"}, results: map[string]PingResult{
+			"8.8.8.8": unreachable, "1.1.1.1": unreachable, "208.67.222.222": unreachable,
+		}, wantISP: true, wantPing: 3},
+		{name: "one server up", gateways: []string{"192.168.1.1"}, results: map[string]PingResult{
+			"8.8.8.8": unreachable, "208.67.222.222": unreachable,
+		}, wantPing: 3},
+		{name: "gateway down too", gateways: []string{"192.168.1.1"}, results: map[string]PingResult{
+			"192.168.1.1": unreachable, "8.8.8.8": unreachable, "1.1.1.1": unreachable, "208.67.222.222": unreachable,
+		}, wantPing: 3},
+		{name: "ipv6 only", gateways: []string{"fe80::1%en0"}, wantPing: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pinger := &mockPinger{results: tt.results}
+			details := &netpkg.InterfaceDetails{LinkUp: true, DefaultGateways: tt.gateways}
+			result, err := RunWithDeps(context.Background(), details, &store.Config{}, pinger, &mockDNSResolver{}, &mockHTTPSProber{result: HTTPSResult{OK: true}}, nil, nil)
+			if err != nil {
+				t.Fatalf("RunWithDeps() error = %v", err)
+			}
+			if len(result.RemotePings) != tt.wantPing {
+				t.Errorf("RemotePings = %+v, want %d servers", result.RemotePings, tt.wantPing)
+			}
+			if _, ok := result.RemotePings[result.Gateway]; ok {
+				t.Error("expected the gateway only in Ping, not RemotePings")
+			}
+			found := false
+			for _, s := range result.Suggestions {
+				found = found || s == isp
+			}
+			if found != tt.wantISP {
+				t.Errorf("ISP suggestion = %v, want %v: %v", found, tt.wantISP, result.Suggestions)
+			}
+		})
+	}
+}
+
+func TestRunWithDepsGatewaySelection(t *testing.T) {
+	tests := []struct {
+		name     string
+		gateways []string
+		want     string
+	}{
+		{name: "ipv4 only", gateways: []string{"192.168.1.1"}, want: "192.168.1.1"},
+		{name: "ipv4 preferred", gateways: []string{"192.168.1.1", "fe80::1%en0"}, want: "192.168.1.1"},
+		{name: "ipv6 only", gateways: []string{"fe80::1%en0"}, want: "fe80::1%en0"},
+		{name: "none", gateways: nil, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pinger := &mockPinger{}
+			details := &netpkg.InterfaceDetails{LinkUp: true, DefaultGateways: tt.gateways}
+
+			result, err := RunWithDeps(context.Background(), details, &store.Config{}, pinger, &mockDNSResolver{}, &mockHTTPSProber{}, nil, nil)
+			if err != nil {
+				t.Fatalf("RunWithDeps() error = %v", err)
+			}
+
+			if result.Gateway != tt.want {
+				t.Errorf("Gateway = %q, want %q", result.Gateway, tt.want)
+			}
+			if tt.want == "" && len(pinger.hosts) != 0 || tt.want != "" && !pinger.pinged(tt.want) {
+				t.Errorf("pinged %v, want %q", pinger.hosts, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunWithDepsMTUProbe(t *testing.T) {
+	details := &netpkg.InterfaceDetails{LinkUp: true, MTU: 1500, DefaultGateways: []string{"192.168.1.1"}}
+	ok := &mockHTTPSProber{result: HTTPSResult{OK: true}}
+
+	// Disabled by default
+	prober := &mockMTUProber{result: MTUProbeResult{MaxMTU: 1400}}
+	result, err := RunWithDeps(context.Background(), details, &store.Config{}, &mockPinger{}, &mockDNSResolver{}, ok, prober, nil)
+	if err != nil {
+		t.Fatalf("RunWithDeps() error = %v", err)
+	}
+	if prober.called || result.MTU != nil {
+		t.Error("MTU probe should not run unless ProbeMTU is enabled")
+	}
+
+	// Enabled with a reduced path MTU
+	result, err = RunWithDeps(context.Background(), details, &store.Config{ProbeMTU: true}, &mockPinger{}, &mockDNSResolver{}, ok, prober, nil)
+	if err != nil {
+		t.Fatalf("RunWithDeps() error = %v", err)
+	}
+	if result.MTU == nil || result.MTU.MaxMTU != 1400 {
+		t.Fatalf("MTU = %+v, want MaxMTU 1400", result.MTU)
+	}
+
+	found := false
+	for _, s := range result.Suggestions {
+		if strings.Contains(s, "Path MTU is 1400") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected path MTU suggestion, got %v", result.Suggestions)
+	}
+}
+
+func TestRunWithDepsTrace(t *testing.T) {
+	details := &netpkg.InterfaceDetails{LinkUp: true, DefaultGateways: []string{"192.168.1.1"}}
+	config := &store.Config{Traceroute: true}
+	tracer := &mockTracer{result: TraceResult{
+		Dest: "example.com",
+		Hops: []HopResult{
+			{TTL: 1, IP: "192.168.1.1", RTT: time.Millisecond},
+			{TTL: 2, IP: "10.0.0.1", RTT: 8 * time.Millisecond},
+			{TTL: 3, IP: "*", Err: "timeout"},
+		},
+	}}
+
+	result, err := RunWithDeps(context.Background(), details, config, &mockPinger{}, &mockDNSResolver{}, &mockHTTPSProber{}, nil, tracer)
+	if err != nil {
+		t.Fatalf("RunWithDeps() error = %v", err)
+	}
+	if result.Trace == nil || len(result.Trace.Hops) != 3 {
+		t.Fatalf("Trace = %+v, want 3 hops", result.Trace)
+	}
+
+	found := false
+	for _, s := range result.Suggestions {
+		if strings.Contains(s, "hop 2 (10.0.0.1)") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected traceroute suggestion, got %v", result.Suggestions)
+	}
+}
+
+func TestSetCertInfo(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	leaf := &x509.Certificate{
+		NotAfter: now.Add(10*24*time.Hour + time.Hour),
+		Issuer:   pkix.Name{CommonName: "Test CA", Organization: []string{"Test Org"}},
+	}
+
+	var result HTTPSResult
+	setCertInfo(&result, []*x509.Certificate{leaf}, now)
+
+	if result.CertIssuer != "Test CA" {
+		t.Errorf("CertIssuer = %q, want Test CA", result.CertIssuer)
+	}
+	if result.CertDaysLeft != 10 {
+		t.Errorf("CertDaysLeft = %d, want 10", result.CertDaysLeft)
+	}
+	if !result.CertExpiry.Equal(leaf.NotAfter) {
+		t.Errorf("CertExpiry = %v, want %v", result.CertExpiry, leaf.NotAfter)
+	}
+
+	leaf.Issuer.CommonName = ""
+	setCertInfo(&result, []*x509.Certificate{leaf}, now)
+	if result.CertIssuer != "Test Org" {
+		t.Errorf("CertIssuer = %q, want organization fallback", result.CertIssuer)
+	}
+}
+
+func TestRunWithDepsCertExpiry(t *testing.T) {
+	details := &netpkg.InterfaceDetails{LinkUp: true, DefaultGateways: []string{"192.168.1.1"}}
+	expiry := time.Now().Add(20 * 24 * time.Hour)
+
+	tests := []struct {
+		name     string
+		warnDays int
+		daysLeft int
+		wantSoon bool
+	}{
+		{name: "default threshold", warnDays: 0, daysLeft: 20, wantSoon: true},
+		{name: "below custom threshold", warnDays: 10, daysLeft: 20, wantSoon: false},
+		{name: "healthy", warnDays: 30, daysLeft: 90, wantSoon: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prober := &mockHTTPSProber{result: HTTPSResult{OK: true, Status: 200, CertExpiry: expiry, CertIssuer: "Test CA", CertDaysLeft: tt.daysLeft}}
+			config := &store.Config{CertWarnDays: tt.warnDays}
+
+			result, err := RunWithDeps(context.Background(), details, config, &mockPinger{}, &mockDNSResolver{}, prober, nil, nil)
+			if err != nil {
+				t.Fatalf("RunWithDeps() error = %v", err)
+			}
+			if result.HTTPS.CertExpiringSoon != tt.wantSoon {
+				t.Errorf("CertExpiringSoon = %v, want %v", result.HTTPS.CertExpiringSoon, tt.wantSoon)
+			}
+
+			found := false
+			for _, s := range result.Suggestions {
+				if strings.Contains(s, fmt.Sprintf("TLS certificate expires in %d days", tt.daysLeft)) {
+					found = true
+				}
+			}
+			if found != tt.wantSoon {
+				t.Errorf("expiry suggestion present = %v, want %v: %v", found, tt.wantSoon, result.Suggestions)
+			}
+		})
+	}
+}
+
+func TestRunWithDepsConcurrent(t *testing.T) {
+	details := &netpkg.InterfaceDetails{LinkUp: true, DefaultGateways: []string{"192.168.1.1"}}
+	pinger := &mockPinger{rtts: []time.Duration{2 * time.Millisecond, 3 * time.Millisecond, 2 * time.Millisecond}}
+	resolver := &mockDNSResolver{systemErr: errors.New("timeout")}
+	prober := &mockHTTPSProber{result: HTTPSResult{OK: true, Status: 200}}
+
+	for _, concurrent := range []bool{true, false} {
+		t.Run(fmt.Sprintf("concurrent=%v", concurrent), func(t *testing.T) {
+			config := &store.Config{DNSAlternates: []string{"1.1.1.1"}, Concurrent: concurrent}
+
+			result, err := RunWithDeps(context.Background(), details, config, pinger, resolver, prober, nil, nil)
+			if err != nil {
+				t.Fatalf("RunWithDeps() error = %v", err)
+			}
+
+			if !pinger.pinged("192.168.1.1") || result.Ping.RTTP50 != 2*time.Millisecond || result.Ping.RTTMax != 3*time.Millisecond {
+				t.Errorf("ping not populated: hosts %v, result %+v", pinger.hosts, result.Ping)
+			}
+			if result.DNS.SystemOK || !result.DNS.AltOK || len(result.DNS.AltTried) != 1 {
+				t.Errorf("DNS not populated: %+v", result.DNS)
+			}
+			if !result.HTTPS.OK || result.HTTPS.Status != 200 {
+				t.Errorf("HTTPS not populated: %+v", result.HTTPS)
+			}
+			if len(result.Suggestions) == 0 || !strings.Contains(result.Suggestions[0], "alternative DNS (1.1.1.1)") {
+				t.Errorf("unexpected suggestions: %v", result.Suggestions)
+			}
+		})
+	}
+}
+
+type targetProber struct {
+	failing map[string]bool
+	tried   []string
+}
+
+func (p *targetProber) ProbeHTTPS(ctx context.Context, url string) (HTTPSResult, error) {
+	p.tried = append(p.tried, url)
+	if p.failing[url] {
+		return HTTPSResult{}, errors.New("blocked: " + url)
+	}
+	return HTTPSResult{OK: true, Status: 200}, nil
+}
+
+func TestRunHTTPSTargets(t *testing.T) {
+	tests := []struct {
+		name       string
+		targets    []string
+		failing    map[string]bool
+		wantOK     bool
+		wantTarget string
+		wantTried  int
+	}{
+		{name: "default target", targets: nil, wantOK: true, wantTarget: DefaultHTTPSTarget, wantTried: 1},
+		{name: "first succeeds", targets: []string{"https://a", "https://b"}, wantOK: true, wantTarget: "https://a", wantTried: 1},
+		{name: "falls back", targets: []string{"https://a", "https://b"}, failing: map[string]bool{"https://a": true}, wantOK: true, wantTarget: "https://b", wantTried: 2},
+		{name: "all fail", targets: []string{"https://a", "https://b"}, failing: map[string]bool{"https://a": true, "https://b": true}, wantOK: false, wantTarget: "https://b", wantTried: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prober := &targetProber{failing: tt.failing}
+			got := runHTTPS(context.Background(), prober, tt.targets)
+
+			if got.OK != tt.wantOK {
+				t.Errorf("OK = %v, want %v", got.OK, tt.wantOK)
+			}
+			if got.Target != tt.wantTarget {
+				t.Errorf("Target = %q, want %q", got.Target, tt.wantTarget)
+			}
+			if len(prober.tried) != tt.wantTried {
+				t.Errorf("tried %v, want %d targets", prober.tried, tt.wantTried)
+			}
+			if !tt.wantOK && got.Err != "blocked: https://b" {
+				t.Errorf("Err = %q, want last error", got.Err)
+			}
+		})
+	}
+}
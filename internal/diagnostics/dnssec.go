@@ -0,0 +1,69 @@
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	// dnssecSignedDomain has a valid DNSSEC chain, so a validating resolver sets AD
+	dnssecSignedDomain = "cloudflare.com"
+	// dnssecBogusDomain is deliberately mis-signed, so a validating resolver returns SERVFAIL
+	dnssecBogusDomain = "dnssec-failed.org"
+)
+
+// DNSSECResult contains DNSSEC validation check results
+type DNSSECResult struct {
+	Server    string
+	Validated bool // resolver authenticated the signed domain and rejected the bogus one
+	ChainOK   bool // AD bit was set for the signed domain
+	Err       string
+}
+
+// dnssecExchange sends a query to a DNS server; replaced in tests
+var dnssecExchange = func(ctx context.Context, msg *dns.Msg, server string) (*dns.Msg, error) {
+	client := &dns.Client{Timeout: 2 * time.Second}
+	resp, _, err := client.ExchangeContext(ctx, msg, server)
+	return resp, err
+}
+
+// checkDNSSEC reports whether the resolver at server validates DNSSEC
+func checkDNSSEC(ctx context.Context, server string) DNSSECResult {
+	result := DNSSECResult{Server: server}
+	addr := net.JoinHostPort(server, "53")
+
+	signed, err := dnssecExchange(ctx, dnssecQuery(dnssecSignedDomain), addr)
+	if err != nil {
+		result.Err = err.Error()
+		return result
+	}
+	if signed.Rcode != dns.RcodeSuccess {
+		result.Err = fmt.Sprintf("%s lookup failed: %s", dnssecSignedDomain, dns.RcodeToString[signed.Rcode])
+		return result
+	}
+	result.ChainOK = signed.AuthenticatedData
+
+	bogus, err := dnssecExchange(ctx, dnssecQuery(dnssecBogusDomain), addr)
+	if err != nil {
+		result.Err = err.Error()
+		return result
+	}
+	result.Validated = result.ChainOK && bogus.Rcode == dns.RcodeServerFailure
+
+	return result
+}
+
+// dnssecQuery builds an A query requesting DNSSEC processing
+func dnssecQuery(host string) *dns.Msg {
+	msg := &dns.Msg{}
+	msg.SetQuestion(dns.Fqdn(host), dns.TypeA)
+	msg.RecursionDesired = true
+	msg.CheckingDisabled = false
+	msg.AuthenticatedData = true
+	msg.SetEdns0(4096, true)
+	return msg
+}
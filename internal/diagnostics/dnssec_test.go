@@ -0,0 +1,98 @@
+package diagnostics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	netpkg "github.com/alexpitcher/LanAudit/internal/net"
+	"github.com/alexpitcher/LanAudit/internal/store"
+	"github.com/miekg/dns"
+)
+
+// stubDNSSECExchange answers queries for the signed and bogus test domains
+func stubDNSSECExchange(t *testing.T, signedAD bool, bogusRcode int, err error) func() {
+	t.Helper()
+	orig := dnssecExchange
+	dnssecExchange = func(ctx context.Context, msg *dns.Msg, server string) (*dns.Msg, error) {
+		if err != nil {
+			return nil, err
+		}
+		if opt := msg.IsEdns0(); opt == nil || !opt.Do() {
+			t.Errorf("query for %s missing DO bit", msg.Question[0].Name)
+		}
+		if !msg.RecursionDesired || msg.CheckingDisabled {
+			t.Errorf("unexpected query flags: RD=%v CD=%v", msg.RecursionDesired, msg.CheckingDisabled)
+		}
+
+		resp := new(dns.Msg)
+		resp.SetReply(msg)
+		switch msg.Question[0].Name {
+		case dns.Fqdn(dnssecSignedDomain):
+			resp.AuthenticatedData = signedAD
+		case dns.Fqdn(dnssecBogusDomain):
+			resp.Rcode = bogusRcode
+		}
+		return resp, nil
+	}
+	return func() { dnssecExchange = orig }
+}
+
+func TestCheckDNSSEC(t *testing.T) {
+	tests := []struct {
+		name          string
+		signedAD      bool
+		bogusRcode    int
+		err           error
+		wantChainOK   bool
+		wantValidated bool
+		wantErr       bool
+	}{
+		{name: "validating resolver", signedAD: true, bogusRcode: dns.RcodeServerFailure, wantChainOK: true, wantValidated: true},
+		{name: "non-validating resolver", signedAD: false, bogusRcode: dns.RcodeSuccess},
+		{name: "ad set but bogus answered", signedAD: true, bogusRcode: dns.RcodeSuccess, wantChainOK: true},
+		{name: "exchange error", err: errors.New("i/o timeout"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer stubDNSSECExchange(t, tt.signedAD, tt.bogusRcode, tt.err)()
+
+			got := checkDNSSEC(context.Background(), "192.168.1.1")
+			if got.Server != "192.168.1.1" {
+				t.Errorf("Server = %q", got.Server)
+			}
+			if (got.Err != "") != tt.wantErr {
+				t.Errorf("Err = %q, wantErr %v", got.Err, tt.wantErr)
+			}
+			if got.ChainOK != tt.wantChainOK || got.Validated != tt.wantValidated {
+				t.Errorf("ChainOK = %v, Validated = %v; want %v, %v", got.ChainOK, got.Validated, tt.wantChainOK, tt.wantValidated)
+			}
+		})
+	}
+}
+
+func TestRunWithDepsDNSSECSuggestion(t *testing.T) {
+	defer stubDNSSECExchange(t, false, dns.RcodeSuccess, nil)()
+
+	details := &netpkg.InterfaceDetails{LinkUp: true, DefaultGateways: []string{"192.168.1.1"}, DNSServers: []string{"192.168.1.1"}}
+	result, err := RunWithDeps(context.Background(), details, &store.Config{}, &mockPinger{}, &mockDNSResolver{}, &mockHTTPSProber{result: HTTPSResult{OK: true}}, nil, nil)
+	if err != nil {
+		t.Fatalf("RunWithDeps() error = %v", err)
+	}
+
+	if result.DNS.DNSSEC.Server != "192.168.1.1" || result.DNS.DNSSEC.ChainOK {
+		t.Errorf("DNSSEC = %+v, want failed check against system resolver", result.DNS.DNSSEC)
+	}
+
+	found := false
+	for _, s := range result.Suggestions {
+		if strings.Contains(s, "DNS resolver does not validate DNSSEC") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected DNSSEC suggestion, got %v", result.Suggestions)
+	}
+}
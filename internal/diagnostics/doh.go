@@ -0,0 +1,96 @@
+package diagnostics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DOHResult contains DNS-over-HTTPS probe results
+type DOHResult struct {
+	OK      bool
+	Server  string
+	Latency time.Duration
+	Err     string
+}
+
+// dohProbeFunc is replaced in tests
+var dohProbeFunc = probeDNSOverHTTPS
+
+// dohResponse is the subset of the application/dns-json format we need
+type dohResponse struct {
+	Status int `json:"Status"`
+	Answer []struct {
+		Type int    `json:"type"`
+		Data string `json:"data"`
+	} `json:"Answer"`
+}
+
+// probeDNSOverHTTPS resolves host's A record through a JSON DoH endpoint
+func probeDNSOverHTTPS(ctx context.Context, host, dohURL string) DOHResult {
+	result := DOHResult{Server: dohURL}
+
+	u, err := url.Parse(dohURL)
+	if err != nil {
+		result.Err = err.Error()
+		return result
+	}
+	q := u.Query()
+	q.Set("name", host)
+	q.Set("type", "A")
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		result.Err = err.Error()
+		return result
+	}
+	req.Header.Set("Accept", "application/dns-json")
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Err = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+	result.Latency = time.Since(start)
+
+	if resp.StatusCode != http.StatusOK {
+		result.Err = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+		return result
+	}
+
+	var body dohResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		result.Err = fmt.Sprintf("invalid response: %v", err)
+		return result
+	}
+
+	for _, ans := range body.Answer {
+		if ans.Type == 1 { // A record
+			result.OK = true
+			return result
+		}
+	}
+
+	result.Err = fmt.Sprintf("no A records returned (status %d)", body.Status)
+	return result
+}
+
+// splitDNSAlternates separates plain DNS servers from https:// DoH endpoints
+func splitDNSAlternates(alternates []string) (plain, doh []string) {
+	for _, alt := range alternates {
+		if strings.HasPrefix(alt, "https://") {
+			doh = append(doh, alt)
+		} else {
+			plain = append(plain, alt)
+		}
+	}
+	return plain, doh
+}
@@ -0,0 +1,131 @@
+package diagnostics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	netpkg "github.com/alexpitcher/LanAudit/internal/net"
+	"github.com/alexpitcher/LanAudit/internal/store"
+)
+
+func TestProbeDNSOverHTTPS(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantOK  bool
+		wantErr string
+	}{
+		{
+			name:   "a record returned",
+			status: http.StatusOK,
+			body:   `{"Status":0,"Answer":[{"name":"example.com","type":1,"TTL":300,"data":"93.184.215.14"}]}`,
+			wantOK: true,
+		},
+		{
+			name:    "only cname returned",
+			status:  http.StatusOK,
+			body:    `{"Status":0,"Answer":[{"name":"example.com","type":5,"data":"alias.example."}]}`,
+			wantErr: "no A records",
+		},
+		{
+			name:    "nxdomain",
+			status:  http.StatusOK,
+			body:    `{"Status":3}`,
+			wantErr: "status 3",
+		},
+		{
+			name:    "server error",
+			status:  http.StatusBadGateway,
+			wantErr: "unexpected status 502",
+		},
+		{
+			name:    "not json",
+			status:  http.StatusOK,
+			body:    "<html>blocked</html>",
+			wantErr: "invalid response",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("Accept"); got != "application/dns-json" {
+					t.Errorf("Accept = %q, want application/dns-json", got)
+				}
+				if r.URL.Query().Get("name") != "example.com" || r.URL.Query().Get("type") != "A" {
+					t.Errorf("unexpected query %q", r.URL.RawQuery)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			got := probeDNSOverHTTPS(context.Background(), "example.com", srv.URL+"/dns-query")
+			if got.OK != tt.wantOK {
+				t.Errorf("OK = %v, want %v (err %q)", got.OK, tt.wantOK, got.Err)
+			}
+			if got.Server != srv.URL+"/dns-query" {
+				t.Errorf("Server = %q", got.Server)
+			}
+			if tt.wantErr != "" && !strings.Contains(got.Err, tt.wantErr) {
+				t.Errorf("Err = %q, want it to contain %q", got.Err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSplitDNSAlternates(t *testing.T) {
+	plain, doh := splitDNSAlternates([]string{"1.1.1.1", "https://dns.example/dns-query", "8.8.8.8"})
+
+	if want := []string{"1.1.1.1", "8.8.8.8"}; !reflect.DeepEqual(plain, want) {
+		t.Errorf("plain = %v, want %v", plain, want)
+	}
+	if want := []string{"https://dns.example/dns-query"}; !reflect.DeepEqual(doh, want) {
+		t.Errorf("doh = %v, want %v", doh, want)
+	}
+}
+
+func TestRunWithDepsDOH(t *testing.T) {
+	var probed string
+	orig := dohProbeFunc
+	dohProbeFunc = func(ctx context.Context, host, dohURL string) DOHResult {
+		probed = dohURL
+		return DOHResult{OK: true, Server: dohURL}
+	}
+	defer func() { dohProbeFunc = orig }()
+
+	details := &netpkg.InterfaceDetails{LinkUp: true}
+	config := &store.Config{DNSAlternates: []string{"1.1.1.1", "https://dns.example/dns-query"}}
+	resolver := &mockDNSResolver{systemErr: errors.New("timeout"), altErr: errors.New("timeout")}
+
+	result, err := RunWithDeps(context.Background(), details, config, &mockPinger{}, resolver, &mockHTTPSProber{}, nil, nil)
+	if err != nil {
+		t.Fatalf("RunWithDeps() error = %v", err)
+	}
+
+	if probed != "https://dns.example/dns-query" {
+		t.Errorf("DoH probed %q, want configured server", probed)
+	}
+	if !result.DNS.DOH.OK {
+		t.Error("expected DOH result to be OK")
+	}
+	if !reflect.DeepEqual(result.DNS.AltTried, []string{"1.1.1.1"}) {
+		t.Errorf("AltTried = %v, DoH servers should not be passed to ResolveAlt", result.DNS.AltTried)
+	}
+
+	found := false
+	for _, s := range result.Suggestions {
+		if strings.Contains(s, "Port 53") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected port 53 suggestion, got %v", result.Suggestions)
+	}
+}
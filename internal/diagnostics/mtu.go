@@ -0,0 +1,93 @@
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+)
+
+const (
+	// ipv4ICMPOverhead is the IPv4 + ICMP header size added to a ping payload
+	ipv4ICMPOverhead = 28
+	// DefaultMTUProbeTarget is probed when PMTUD is enabled
+	DefaultMTUProbeTarget = "example.com"
+	// MinProbeMTU is the smallest MTU every IPv4 path must support
+	MinProbeMTU = 576
+)
+
+// MTUProbeResult contains path MTU discovery results
+type MTUProbeResult struct {
+	MaxMTU int
+	Err    string
+}
+
+// MTUProber interface for testing
+type MTUProber interface {
+	ProbePathMTU(ctx context.Context, dest string, start, min int) MTUProbeResult
+}
+
+// DefaultMTUProber implements the MTUProber interface
+type DefaultMTUProber struct{}
+
+// ProbePathMTU finds the largest packet that reaches dest unfragmented
+func (p *DefaultMTUProber) ProbePathMTU(ctx context.Context, dest string, start, min int) MTUProbeResult {
+	return ProbePathMTU(ctx, dest, start, min)
+}
+
+// dfPingFunc sends a single don't-fragment echo of the given total packet size
+// and reports whether a reply came back. Replaced in tests.
+var dfPingFunc = pingDF
+
+// ProbePathMTU binary-searches between min and start for the largest
+// don't-fragment ICMP echo that dest answers. Oversized probes are dropped
+// with an ICMP "fragmentation needed" reply, which ping reports as a failure.
+func ProbePathMTU(ctx context.Context, dest string, start, min int) MTUProbeResult {
+	if min <= ipv4ICMPOverhead || start < min {
+		return MTUProbeResult{Err: fmt.Sprintf("invalid probe range %d-%d", min, start)}
+	}
+
+	if dfPingFunc(ctx, dest, start) {
+		return MTUProbeResult{MaxMTU: start}
+	}
+	if ctx.Err() != nil {
+		return MTUProbeResult{Err: ctx.Err().Error()}
+	}
+	if !dfPingFunc(ctx, dest, min) {
+		return MTUProbeResult{Err: fmt.Sprintf("%s unreachable at minimum MTU %d", dest, min)}
+	}
+
+	// Invariant: lo succeeds, hi fails
+	lo, hi := min, start
+	for hi-lo > 1 {
+		if ctx.Err() != nil {
+			return MTUProbeResult{MaxMTU: lo, Err: ctx.Err().Error()}
+		}
+		mid := (lo + hi) / 2
+		if dfPingFunc(ctx, dest, mid) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	return MTUProbeResult{MaxMTU: lo}
+}
+
+// pingDF runs the system ping with the don't-fragment bit set
+func pingDF(ctx context.Context, dest string, size int) bool {
+	payload := strconv.Itoa(size - ipv4ICMPOverhead)
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "linux":
+		cmd = exec.CommandContext(ctx, "ping", "-c", "1", "-W", "1", "-M", "do", "-s", payload, dest)
+	case "windows":
+		cmd = exec.CommandContext(ctx, "ping", "-n", "1", "-w", "1000", "-f", "-l", payload, dest)
+	default:
+		cmd = exec.CommandContext(ctx, "ping", "-c", "1", "-W", "1000", "-D", "-s", payload, dest)
+	}
+
+	return cmd.Run() == nil
+}
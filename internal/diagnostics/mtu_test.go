@@ -0,0 +1,45 @@
+package diagnostics
+
+import (
+	"context"
+	"testing"
+)
+
+func TestProbePathMTU(t *testing.T) {
+	tests := []struct {
+		name    string
+		pathMTU int // largest size the fake path accepts; 0 = unreachable
+		want    int
+		wantErr bool
+	}{
+		{name: "full size path", pathMTU: 1500, want: 1500},
+		{name: "pppoe path", pathMTU: 1492, want: 1492},
+		{name: "tunnel path", pathMTU: 1380, want: 1380},
+		{name: "unreachable", pathMTU: 0, wantErr: true},
+	}
+
+	orig := dfPingFunc
+	defer func() { dfPingFunc = orig }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dfPingFunc = func(ctx context.Context, dest string, size int) bool {
+				return size <= tt.pathMTU
+			}
+
+			res := ProbePathMTU(context.Background(), "example.com", 1500, MinProbeMTU)
+			if (res.Err != "") != tt.wantErr {
+				t.Fatalf("ProbePathMTU() err = %q, wantErr %v", res.Err, tt.wantErr)
+			}
+			if res.MaxMTU != tt.want {
+				t.Errorf("ProbePathMTU() MaxMTU = %d, want %d", res.MaxMTU, tt.want)
+			}
+		})
+	}
+}
+
+func TestProbePathMTUInvalidRange(t *testing.T) {
+	if res := ProbePathMTU(context.Background(), "example.com", 500, 1000); res.Err == "" {
+		t.Error("expected error for start < min")
+	}
+}
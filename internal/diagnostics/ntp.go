@@ -0,0 +1,134 @@
+package diagnostics
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+)
+
+// DefaultNTPServer is queried when config.CheckNTP is set
+const DefaultNTPServer = "pool.ntp.org"
+
+// MaxClockOffset is the clock skew above which the NTP check fails
+const MaxClockOffset = 5 * time.Second
+
+const (
+	ntpTimeout = 2 * time.Second
+	// ntpEpochOffset is the number of seconds between 1900-01-01 and 1970-01-01
+	ntpEpochOffset = 2208988800
+	ntpPacketLen   = 48
+)
+
+// NTPResult contains clock synchronization check results
+type NTPResult struct {
+	Offset time.Duration
+	Server string
+	OK     bool
+	Err    string
+}
+
+// ntpCheckFunc is replaced in tests
+var ntpCheckFunc = checkNTP
+
+// ntpListen opens the UDP socket used for NTP queries
+var ntpListen = func() (net.PacketConn, error) {
+	return net.ListenPacket("udp", ":0")
+}
+
+// checkNTP measures the local clock offset against an NTP server (RFC 5905)
+func checkNTP(ctx context.Context, server string) NTPResult {
+	addr, err := net.ResolveUDPAddr("udp", net.JoinHostPort(server, "123"))
+	if err != nil {
+		return NTPResult{Server: server, Err: err.Error()}
+	}
+
+	conn, err := ntpListen()
+	if err != nil {
+		return NTPResult{Server: server, Err: err.Error()}
+	}
+	defer conn.Close()
+
+	return queryNTP(ctx, conn, addr, server)
+}
+
+// queryNTP sends a single client request over conn and computes the offset
+// from the four NTP timestamps
+func queryNTP(ctx context.Context, conn net.PacketConn, addr net.Addr, server string) NTPResult {
+	result := NTPResult{Server: server}
+
+	deadline := time.Now().Add(ntpTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	req := make([]byte, ntpPacketLen)
+	req[0] = 0<<6 | 4<<3 | 3 // LI 0, version 4, mode 3 (client)
+
+	t1 := time.Now()
+	putNTPTime(req[40:], t1)
+	if _, err := conn.WriteTo(req, addr); err != nil {
+		result.Err = err.Error()
+		return result
+	}
+
+	resp := make([]byte, 512)
+	for {
+		n, _, err := conn.ReadFrom(resp)
+		if err != nil {
+			result.Err = err.Error()
+			return result
+		}
+		t4 := time.Now()
+		if n < ntpPacketLen {
+			continue
+		}
+		// Ignore anything that isn't the reply to our request
+		if binary.BigEndian.Uint64(resp[24:32]) != binary.BigEndian.Uint64(req[40:48]) {
+			continue
+		}
+
+		if mode := resp[0] & 0x7; mode != 4 {
+			result.Err = fmt.Sprintf("unexpected NTP mode %d", mode)
+			return result
+		}
+		if resp[0]>>6 == 3 {
+			result.Err = "server clock is not synchronized"
+			return result
+		}
+		if resp[1] == 0 {
+			result.Err = fmt.Sprintf("kiss-of-death from server (%s)", string(resp[12:16]))
+			return result
+		}
+
+		t2 := ntpTime(resp[32:40])
+		t3 := ntpTime(resp[40:48])
+		result.Offset = (t2.Sub(t1) + t3.Sub(t4)) / 2
+		result.OK = absDuration(result.Offset) <= MaxClockOffset
+		return result
+	}
+}
+
+// putNTPTime writes t as a 64-bit NTP timestamp
+func putNTPTime(b []byte, t time.Time) {
+	secs := uint64(t.Unix() + ntpEpochOffset)
+	frac := uint64(t.Nanosecond()) << 32 / 1e9
+	binary.BigEndian.PutUint64(b, secs<<32|frac)
+}
+
+// ntpTime decodes a 64-bit NTP timestamp
+func ntpTime(b []byte) time.Time {
+	v := binary.BigEndian.Uint64(b)
+	secs := int64(v>>32) - ntpEpochOffset
+	nanos := int64((v & 0xffffffff) * 1e9 >> 32)
+	return time.Unix(secs, nanos)
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,154 @@
+package diagnostics
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	netpkg "github.com/alexpitcher/LanAudit/internal/net"
+	"github.com/alexpitcher/LanAudit/internal/store"
+)
+
+// fakeNTPConn answers a client request with a server whose clock runs skew
+// ahead of the local one
+type fakeNTPConn struct {
+	net.PacketConn
+	skew    time.Duration
+	reply   []byte
+	mutate  func(resp []byte)
+	noReply bool
+}
+
+func (c *fakeNTPConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	now := time.Now().Add(c.skew)
+	resp := make([]byte, ntpPacketLen)
+	resp[0] = 0<<6 | 4<<3 | 4 // server mode
+	resp[1] = 2               // stratum
+	copy(resp[24:32], b[40:48])
+	putNTPTime(resp[32:40], now)
+	putNTPTime(resp[40:48], now)
+	if c.mutate != nil {
+		c.mutate(resp)
+	}
+	c.reply = resp
+	return len(b), nil
+}
+
+func (c *fakeNTPConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	if c.noReply || c.reply == nil {
+		return 0, nil, os.ErrDeadlineExceeded
+	}
+	n := copy(b, c.reply)
+	c.reply = nil
+	return n, &net.UDPAddr{}, nil
+}
+
+func (c *fakeNTPConn) SetDeadline(t time.Time) error { return nil }
+
+func TestQueryNTP(t *testing.T) {
+	tests := []struct {
+		name    string
+		conn    *fakeNTPConn
+		wantOK  bool
+		wantErr string
+		skew    time.Duration
+	}{
+		{name: "in sync", conn: &fakeNTPConn{}, wantOK: true},
+		{name: "small skew", conn: &fakeNTPConn{skew: 2 * time.Second}, wantOK: true, skew: 2 * time.Second},
+		{name: "clock behind", conn: &fakeNTPConn{skew: 30 * time.Second}, wantOK: false, skew: 30 * time.Second},
+		{name: "clock ahead", conn: &fakeNTPConn{skew: -10 * time.Second}, wantOK: false, skew: -10 * time.Second},
+		{
+			name:    "kiss of death",
+			conn:    &fakeNTPConn{mutate: func(r []byte) { r[1] = 0; copy(r[12:16], "RATE") }},
+			wantErr: "kiss-of-death from server (RATE)",
+		},
+		{
+			name:    "unsynchronized server",
+			conn:    &fakeNTPConn{mutate: func(r []byte) { r[0] |= 3 << 6 }},
+			wantErr: "not synchronized",
+		},
+		{name: "timeout", conn: &fakeNTPConn{noReply: true}, wantErr: "timeout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := queryNTP(context.Background(), tt.conn, &net.UDPAddr{}, "ntp.test")
+
+			if got.Server != "ntp.test" {
+				t.Errorf("Server = %q", got.Server)
+			}
+			if tt.wantErr != "" {
+				if !strings.Contains(got.Err, tt.wantErr) {
+					t.Errorf("Err = %q, want it to contain %q", got.Err, tt.wantErr)
+				}
+				return
+			}
+			if got.Err != "" {
+				t.Fatalf("unexpected error %q", got.Err)
+			}
+			if got.OK != tt.wantOK {
+				t.Errorf("OK = %v, want %v", got.OK, tt.wantOK)
+			}
+			if diff := absDuration(got.Offset - tt.skew); diff > 50*time.Millisecond {
+				t.Errorf("Offset = %v, want ~%v", got.Offset, tt.skew)
+			}
+		})
+	}
+}
+
+func TestQueryNTPIgnoresUnrelatedReply(t *testing.T) {
+	conn := &fakeNTPConn{mutate: func(r []byte) { binary.BigEndian.PutUint64(r[24:32], 1) }}
+	got := queryNTP(context.Background(), conn, &net.UDPAddr{}, "ntp.test")
+	if got.OK || !strings.Contains(got.Err, "timeout") {
+		t.Errorf("expected reply with wrong origin timestamp to be ignored, got %+v", got)
+	}
+}
+
+func TestNTPTimeRoundTrip(t *testing.T) {
+	want := time.Date(2025, 6, 1, 12, 30, 45, 123456789, time.UTC)
+	b := make([]byte, 8)
+	putNTPTime(b, want)
+	if got := ntpTime(b); absDuration(got.Sub(want)) > time.Microsecond {
+		t.Errorf("ntpTime() = %v, want %v", got, want)
+	}
+}
+
+func TestRunWithDepsNTP(t *testing.T) {
+	orig := ntpCheckFunc
+	ntpCheckFunc = func(ctx context.Context, server string) NTPResult {
+		return NTPResult{Server: server, Offset: 42 * time.Second}
+	}
+	defer func() { ntpCheckFunc = orig }()
+
+	details := &netpkg.InterfaceDetails{LinkUp: true, DefaultGateways: []string{"192.168.1.1"}}
+
+	result, err := RunWithDeps(context.Background(), details, &store.Config{}, &mockPinger{}, &mockDNSResolver{}, &mockHTTPSProber{}, nil, nil)
+	if err != nil {
+		t.Fatalf("RunWithDeps() error = %v", err)
+	}
+	if result.NTP != nil {
+		t.Errorf("NTP check should not run unless enabled, got %+v", result.NTP)
+	}
+
+	result, err = RunWithDeps(context.Background(), details, &store.Config{CheckNTP: true}, &mockPinger{}, &mockDNSResolver{}, &mockHTTPSProber{}, nil, nil)
+	if err != nil {
+		t.Fatalf("RunWithDeps() error = %v", err)
+	}
+	if result.NTP == nil || result.NTP.Server != DefaultNTPServer {
+		t.Fatalf("NTP = %+v, want result from %s", result.NTP, DefaultNTPServer)
+	}
+
+	found := false
+	for _, s := range result.Suggestions {
+		if strings.Contains(s, "System clock is off by 42s") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected clock skew suggestion, got %v", result.Suggestions)
+	}
+}
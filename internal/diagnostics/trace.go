@@ -0,0 +1,204 @@
+package diagnostics
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"net"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	netpkg "github.com/alexpitcher/LanAudit/internal/net"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+const (
+	// DefaultTraceTarget is traced when traceroute is enabled
+	DefaultTraceTarget = "example.com"
+	// DefaultMaxHops bounds the traceroute TTL
+	DefaultMaxHops = 30
+	// hopTimeout is how long to wait for each hop to answer
+	hopTimeout = time.Second
+)
+
+// TraceResult contains traceroute results
+type TraceResult struct {
+	Dest    string
+	Reached bool
+	Hops    []HopResult
+	Err     string
+}
+
+// HopResult is a single traceroute hop
+type HopResult struct {
+	TTL int
+	IP  string
+	RTT time.Duration
+	Err string
+}
+
+// Tracer interface for testing
+type Tracer interface {
+	Trace(ctx context.Context, dest string, maxHops int) (TraceResult, error)
+}
+
+// DefaultTracer implements the Tracer interface. It sends raw ICMP echo
+// probes when running as root and otherwise falls back to the system
+// traceroute, which uses unprivileged UDP probes.
+type DefaultTracer struct{}
+
+// Trace walks TTL 1..maxHops towards dest
+func (t *DefaultTracer) Trace(ctx context.Context, dest string, maxHops int) (TraceResult, error) {
+	if netpkg.IsRoot() {
+		return traceICMP(ctx, dest, maxHops)
+	}
+	return traceSystem(ctx, dest, maxHops)
+}
+
+// traceICMP performs traceroute with raw ICMP echo requests (requires root)
+func traceICMP(ctx context.Context, dest string, maxHops int) (TraceResult, error) {
+	result := TraceResult{Dest: dest}
+
+	dst, err := net.ResolveIPAddr("ip4", dest)
+	if err != nil {
+		return result, err
+	}
+
+	conn, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0")
+	if err != nil {
+		return result, err
+	}
+	defer conn.Close()
+
+	id := os.Getpid() & 0xffff
+	buf := make([]byte, 1500)
+
+	for ttl := 1; ttl <= maxHops; ttl++ {
+		if ctx.Err() != nil {
+			result.Err = ctx.Err().Error()
+			break
+		}
+
+		if err := conn.IPv4PacketConn().SetTTL(ttl); err != nil {
+			return result, err
+		}
+
+		msg := icmp.Message{
+			Type: ipv4.ICMPTypeEcho,
+			Body: &icmp.Echo{ID: id, Seq: ttl, Data: []byte("lanaudit")},
+		}
+		wb, err := msg.Marshal(nil)
+		if err != nil {
+			return result, err
+		}
+
+		start := time.Now()
+		if _, err := conn.WriteTo(wb, dst); err != nil {
+			result.Hops = append(result.Hops, HopResult{TTL: ttl, Err: err.Error()})
+			continue
+		}
+
+		hop := HopResult{TTL: ttl, IP: "*", Err: "timeout"}
+		deadline := start.Add(hopTimeout)
+		conn.SetReadDeadline(deadline)
+		for {
+			n, peer, err := conn.ReadFrom(buf)
+			if err != nil {
+				break
+			}
+			reply, err := icmp.ParseMessage(1, buf[:n])
+			if err != nil {
+				continue
+			}
+
+			matched := false
+			switch body := reply.Body.(type) {
+			case *icmp.TimeExceeded:
+				matched = echoMatches(body.Data, id, ttl)
+			case *icmp.Echo:
+				matched = reply.Type == ipv4.ICMPTypeEchoReply && body.ID == id && body.Seq == ttl
+			}
+			if matched {
+				hop = HopResult{TTL: ttl, IP: peer.String(), RTT: time.Since(start)}
+				break
+			}
+		}
+
+		result.Hops = append(result.Hops, hop)
+		if hop.IP == dst.IP.String() {
+			result.Reached = true
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// echoMatches checks whether the original datagram quoted in an ICMP error
+// is our echo request
+func echoMatches(quoted []byte, id, seq int) bool {
+	if len(quoted) < 20 {
+		return false
+	}
+	ihl := int(quoted[0]&0x0f) * 4
+	if len(quoted) < ihl+8 {
+		return false
+	}
+	echo := quoted[ihl:]
+	return int(binary.BigEndian.Uint16(echo[4:6])) == id && int(binary.BigEndian.Uint16(echo[6:8])) == seq
+}
+
+// traceSystem runs the system traceroute (unprivileged UDP probes)
+func traceSystem(ctx context.Context, dest string, maxHops int) (TraceResult, error) {
+	result := TraceResult{Dest: dest}
+
+	cmd := exec.CommandContext(ctx, "traceroute", "-n", "-q", "1", "-w", "1", "-m", strconv.Itoa(maxHops), dest)
+	output, err := cmd.Output()
+	result.Hops = parseTracerouteOutput(string(output))
+	if err != nil && len(result.Hops) == 0 {
+		return result, err
+	}
+
+	if ips, lookupErr := net.DefaultResolver.LookupHost(ctx, dest); lookupErr == nil && len(result.Hops) > 0 {
+		last := result.Hops[len(result.Hops)-1].IP
+		for _, ip := range ips {
+			if ip == last {
+				result.Reached = true
+			}
+		}
+	}
+
+	return result, nil
+}
+
+var tracerouteHopRe = regexp.MustCompile(`^\s*(\d+)\s+(\S+)(?:\s+([\d.]+)\s+ms)?`)
+
+// parseTracerouteOutput parses `traceroute -n -q 1` output
+func parseTracerouteOutput(output string) []HopResult {
+	var hops []HopResult
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		m := tracerouteHopRe.FindStringSubmatch(scanner.Text())
+		if len(m) < 3 {
+			continue
+		}
+
+		ttl, _ := strconv.Atoi(m[1])
+		hop := HopResult{TTL: ttl, IP: m[2]}
+		if hop.IP == "*" {
+			hop.Err = "timeout"
+		} else if m[3] != "" {
+			ms, _ := strconv.ParseFloat(m[3], 64)
+			hop.RTT = time.Duration(ms * float64(time.Millisecond))
+		}
+		hops = append(hops, hop)
+	}
+
+	return hops
+}
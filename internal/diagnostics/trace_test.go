@@ -0,0 +1,49 @@
+package diagnostics
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+func TestParseTracerouteOutput(t *testing.T) {
+	output := `traceroute to example.com (93.184.216.34), 30 hops max, 60 byte packets
+ 1  192.168.1.1  1.234 ms
+ 2  10.20.0.1  8.901 ms
+ 3  *
+ 4  93.184.216.34  24.5 ms
+`
+	hops := parseTracerouteOutput(output)
+	if len(hops) != 4 {
+		t.Fatalf("expected 4 hops, got %d: %+v", len(hops), hops)
+	}
+
+	want := []HopResult{
+		{TTL: 1, IP: "192.168.1.1", RTT: 1234 * time.Microsecond},
+		{TTL: 2, IP: "10.20.0.1", RTT: 8901 * time.Microsecond},
+		{TTL: 3, IP: "*", Err: "timeout"},
+		{TTL: 4, IP: "93.184.216.34", RTT: 24500 * time.Microsecond},
+	}
+	for i := range want {
+		if hops[i] != want[i] {
+			t.Errorf("hop %d = %+v, want %+v", i, hops[i], want[i])
+		}
+	}
+}
+
+func TestEchoMatches(t *testing.T) {
+	quoted := make([]byte, 28)
+	quoted[0] = 0x45 // IPv4, 20 byte header
+	binary.BigEndian.PutUint16(quoted[24:], 1234)
+	binary.BigEndian.PutUint16(quoted[26:], 7)
+
+	if !echoMatches(quoted, 1234, 7) {
+		t.Error("expected quoted echo to match")
+	}
+	if echoMatches(quoted, 1234, 8) {
+		t.Error("expected sequence mismatch")
+	}
+	if echoMatches(quoted[:10], 1234, 7) {
+		t.Error("expected short packet to be rejected")
+	}
+}
@@ -1,56 +1,291 @@
 package logging
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// Level is a log severity; messages below the current level are dropped
+type Level int32
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// DefaultLevel is the level used until SetLevel is called
+const DefaultLevel = LevelInfo
+
+const (
+	// LogFile is the log written in the working directory
+	LogFile = "log.txt"
+
+	// DefaultMaxLogBytes is the size at which the log is rotated
+	DefaultMaxLogBytes = 10 * 1024 * 1024
+
+	// DefaultMaxLogFiles is how many rotated logs (log.txt.1, log.txt.2, ...)
+	// are kept
+	DefaultMaxLogFiles = 5
+)
+
+// Log line formats accepted by SetFormat
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Levels are the names SetLevel accepts, most verbose first
+var Levels = []string{"debug", "info", "warn", "error"}
+
+// Formats are the names SetFormat accepts
+var Formats = []string{FormatText, FormatJSON}
+
 var (
 	logger *log.Logger
 	once   sync.Once
+	output *rotatingWriter // nil when logging to stderr
+
+	currentLevel atomic.Int32
+	jsonFormat   atomic.Bool
+
+	// stderr receives a copy of each message at debug level; nil while the
+	// TUI owns the terminal
+	stderrMu sync.Mutex
+	stderr   io.Writer = os.Stderr
+
+	// secondary, when set, receives every entry written to the log
+	secondaryMu sync.Mutex
+	secondary   levelWriter
 )
 
+// levelWriter is an output, such as syslog, that records the level of each
+// entry itself
+type levelWriter interface {
+	WriteLevel(level Level, msg string) error
+	Close() error
+}
+
+func init() {
+	currentLevel.Store(int32(DefaultLevel))
+}
+
 func initLogger() {
-	file, err := os.OpenFile("log.txt", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	w, err := newRotatingWriter(LogFile, DefaultMaxLogBytes, DefaultMaxLogFiles)
 	if err != nil {
 		log.Printf("logging: failed to open log file, using stderr: %v", err)
 		logger = log.New(os.Stderr, "lanaudit ", log.LstdFlags|log.Lmicroseconds)
 		return
 	}
-	logger = log.New(file, "", log.LstdFlags|log.Lmicroseconds)
+	output = w
+	logger = log.New(w, "", log.LstdFlags|log.Lmicroseconds)
+}
+
+// SetRotation sets the size at which the log is rotated and how many
+// rotated logs are kept; zero leaves a setting at its default
+func SetRotation(maxBytes, maxFiles int) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxLogBytes
+	}
+	if maxFiles <= 0 {
+		maxFiles = DefaultMaxLogFiles
+	}
+	ensureLogger()
+	if output != nil {
+		output.setLimits(int64(maxBytes), maxFiles)
+	}
+}
+
+// setSecondary replaces the secondary writer, closing the previous one
+func setSecondary(w levelWriter) {
+	secondaryMu.Lock()
+	defer secondaryMu.Unlock()
+	if secondary != nil {
+		secondary.Close()
+	}
+	secondary = w
 }
 
 func ensureLogger() {
 	once.Do(initLogger)
 }
 
-func logf(level, format string, args ...interface{}) {
+// ParseLevel converts a level name ("debug", "info", "warn" or "error") to
+// a Level
+func ParseLevel(name string) (Level, error) {
+	for i, l := range Levels {
+		if strings.EqualFold(name, l) {
+			return Level(i), nil
+		}
+	}
+	return DefaultLevel, fmt.Errorf("unknown log level %q: use one of %s", name, strings.Join(Levels, ", "))
+}
+
+// SetLevel sets the minimum level written to the log. At debug level
+// messages are also copied to stderr.
+func SetLevel(level string) error {
+	l, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	currentLevel.Store(int32(l))
+	return nil
+}
+
+// SetStderr sets where debug-level messages are copied, or stops copying
+// them when w is nil. The TUI turns copying off so messages don't draw over
+// the screen.
+func SetStderr(w io.Writer) {
+	stderrMu.Lock()
+	defer stderrMu.Unlock()
+	stderr = w
+}
+
+// ValidFormat reports whether format is one of Formats
+func ValidFormat(format string) bool {
+	for _, f := range Formats {
+		if strings.EqualFold(format, f) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetFormat selects how log lines are written: "text" ("[INFO] message")
+// or "json" (one object per line with ts, level, pkg and msg fields)
+func SetFormat(format string) error {
+	if !ValidFormat(format) {
+		return fmt.Errorf("unknown log format %q: use one of %s", format, strings.Join(Formats, ", "))
+	}
+	jsonFormat.Store(strings.EqualFold(format, FormatJSON))
+	return nil
+}
+
+// CurrentLevel returns the minimum level being written
+func CurrentLevel() Level {
+	return Level(currentLevel.Load())
+}
+
+// String returns the level's name as written in log lines
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return fmt.Sprintf("LEVEL%d", int(l))
+	}
+	return strings.ToUpper(Levels[l])
+}
+
+// jsonEntry is one line of JSON-format output
+type jsonEntry struct {
+	TS    string `json:"ts"`
+	Level string `json:"level"`
+	Pkg   string `json:"pkg,omitempty"`
+	Msg   string `json:"msg"`
+}
+
+func logf(pkg string, level Level, format string, args ...interface{}) {
+	current := CurrentLevel()
+	if level < current {
+		return
+	}
 	ensureLogger()
 	if logger == nil {
 		return
 	}
 	msg := fmt.Sprintf(format, args...)
-	logger.Printf("[%s] %s", level, msg)
+	body := msg
+	if pkg != "" {
+		body = pkg + ": " + msg
+	}
+	text := fmt.Sprintf("[%s] %s", level, body)
+
+	if jsonFormat.Load() {
+		line, err := json.Marshal(jsonEntry{
+			TS:    time.Now().UTC().Format(time.RFC3339Nano),
+			Level: level.String(),
+			Pkg:   pkg,
+			Msg:   msg,
+		})
+		if err != nil {
+			logger.Print(text)
+		} else {
+			// Written directly so the logger's timestamp prefix is left off
+			logger.Writer().Write(append(line, '\n'))
+		}
+	} else {
+		logger.Print(text)
+	}
+
+	// A failed write has nowhere to be reported
+	secondaryMu.Lock()
+	if secondary != nil {
+		secondary.WriteLevel(level, body)
+	}
+	secondaryMu.Unlock()
+
+	if current == LevelDebug {
+		stderrMu.Lock()
+		if stderr != nil {
+			fmt.Fprintln(stderr, text)
+		}
+		stderrMu.Unlock()
+	}
+}
+
+// Logger logs on behalf of one package, adding its name to each message
+type Logger struct {
+	pkg string
+}
+
+// WithPkg returns a Logger whose messages carry the package name, as the
+// "pkg" field in JSON format or a "pkg: " prefix in text format
+func WithPkg(pkg string) *Logger {
+	return &Logger{pkg: pkg}
+}
+
+// Infof logs an informational message.
+func (l *Logger) Infof(format string, args ...interface{}) {
+	logf(l.pkg, LevelInfo, format, args...)
+}
+
+// Warnf logs a warning message.
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	logf(l.pkg, LevelWarn, format, args...)
+}
+
+// Errorf logs an error message.
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	logf(l.pkg, LevelError, format, args...)
+}
+
+// Debugf logs a debug message.
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	logf(l.pkg, LevelDebug, format, args...)
 }
 
 // Infof logs an informational message.
 func Infof(format string, args ...interface{}) {
-	logf("INFO", format, args...)
+	logf("", LevelInfo, format, args...)
 }
 
 // Warnf logs a warning message.
 func Warnf(format string, args ...interface{}) {
-	logf("WARN", format, args...)
+	logf("", LevelWarn, format, args...)
 }
 
 // Errorf logs an error message.
 func Errorf(format string, args ...interface{}) {
-	logf("ERROR", format, args...)
+	logf("", LevelError, format, args...)
 }
 
 // Debugf logs a debug message.
 func Debugf(format string, args ...interface{}) {
-	logf("DEBUG", format, args...)
+	logf("", LevelDebug, format, args...)
 }
@@ -2,11 +2,29 @@ package logging
 
 import (
 	"bytes"
+	"encoding/json"
+	"io"
 	"log"
 	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
+// useLevel sets the log level and stderr copy for one test
+func useLevel(t *testing.T, level string, w io.Writer) {
+	t.Helper()
+	origLevel, origStderr := CurrentLevel(), stderr
+	t.Cleanup(func() {
+		currentLevel.Store(int32(origLevel))
+		stderr = origStderr
+	})
+	stderr = w
+	if err := SetLevel(level); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestLogging(t *testing.T) {
 	// Capture output
 	var buf bytes.Buffer
@@ -19,6 +37,7 @@ func TestLogging(t *testing.T) {
 	originalLogger := logger
 	defer func() { logger = originalLogger }()
 	logger = log.New(&buf, "", 0)
+	useLevel(t, "debug", io.Discard)
 
 	tests := []struct {
 		name    string
@@ -89,3 +108,120 @@ func TestInitLogger(t *testing.T) {
 		t.Error("log.txt should be created")
 	}
 }
+
+func TestSetLevel(t *testing.T) {
+	var buf, errBuf bytes.Buffer
+	ensureLogger()
+	originalLogger := logger
+	defer func() { logger = originalLogger }()
+	logger = log.New(&buf, "", 0)
+
+	useLevel(t, "error", &errBuf)
+	Infof("hidden")
+	Warnf("hidden")
+	Debugf("hidden")
+	Errorf("shown")
+	if got := buf.String(); got != "[ERROR] shown\n" {
+		t.Errorf("at error level log = %q, want only the error", got)
+	}
+	if errBuf.Len() != 0 {
+		t.Errorf("stderr = %q, want nothing below debug level", errBuf.String())
+	}
+
+	buf.Reset()
+	useLevel(t, "DEBUG", &errBuf)
+	Infof("info")
+	Debugf("debug")
+	if got := buf.String(); got != "[INFO] info\n[DEBUG] debug\n" {
+		t.Errorf("at debug level log = %q", got)
+	}
+	if !strings.Contains(errBuf.String(), "[DEBUG] debug") {
+		t.Errorf("debug level should copy to stderr, got %q", errBuf.String())
+	}
+
+	// With the copy turned off, as in the TUI, only the log gets the message
+	errBuf.Reset()
+	SetStderr(nil)
+	Debugf("quiet")
+	if errBuf.Len() != 0 || !strings.Contains(buf.String(), "[DEBUG] quiet") {
+		t.Errorf("SetStderr(nil): stderr = %q, log = %q", errBuf.String(), buf.String())
+	}
+
+	if err := SetLevel("verbose"); err == nil {
+		t.Error("SetLevel(\"verbose\") should fail")
+	}
+	if CurrentLevel() != LevelDebug {
+		t.Errorf("a rejected level changed the level to %v", CurrentLevel())
+	}
+}
+
+func TestDefaultLevelSkipsDebug(t *testing.T) {
+	var buf bytes.Buffer
+	ensureLogger()
+	originalLogger := logger
+	defer func() { logger = originalLogger }()
+	logger = log.New(&buf, "", 0)
+	useLevel(t, "info", io.Discard)
+
+	Debugf("noise")
+	Infof("kept")
+	if got := buf.String(); got != "[INFO] kept\n" {
+		t.Errorf("log = %q, want only the info line", got)
+	}
+}
+
+func TestJSONFormat(t *testing.T) {
+	var buf bytes.Buffer
+	ensureLogger()
+	originalLogger := logger
+	defer func() { logger = originalLogger }()
+	// The timestamp prefix must not reach JSON lines
+	logger = log.New(&buf, "", log.LstdFlags)
+	useLevel(t, "info", io.Discard)
+	t.Cleanup(func() { jsonFormat.Store(false) })
+
+	if err := SetFormat("json"); err != nil {
+		t.Fatal(err)
+	}
+	Warnf("quoted \"value\" and\nnewline")
+	WithPkg("scan").Errorf("port %d closed", 22)
+	WithPkg("scan").Debugf("hidden at info level")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), buf.String())
+	}
+	want := []jsonEntry{
+		{Level: "WARN", Msg: "quoted \"value\" and\nnewline"},
+		{Level: "ERROR", Pkg: "scan", Msg: "port 22 closed"},
+	}
+	for i, line := range lines {
+		var got jsonEntry
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("line %d is not valid JSON: %v: %q", i, err, line)
+		}
+		if _, err := time.Parse(time.RFC3339Nano, got.TS); err != nil {
+			t.Errorf("line %d ts %q: %v", i, got.TS, err)
+		}
+		got.TS = ""
+		if got != want[i] {
+			t.Errorf("line %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+	if strings.Contains(lines[0], `"pkg"`) {
+		t.Errorf("pkg should be omitted without WithPkg: %s", lines[0])
+	}
+
+	if err := SetFormat("xml"); err == nil {
+		t.Error("SetFormat(\"xml\") should fail")
+	}
+	buf.Reset()
+	if err := SetFormat("text"); err != nil {
+		t.Fatal(err)
+	}
+	logger.SetFlags(0)
+	WithPkg("scan").Infof("back to text")
+	if got := buf.String(); got != "[INFO] scan: back to text\n" {
+		t.Errorf("text log = %q", got)
+	}
+}
@@ -0,0 +1,96 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// rotatingWriter appends to a file, renaming it to path.1 (and older
+// copies to path.2 and so on) once a write would take it past maxBytes
+type rotatingWriter struct {
+	mu       sync.Mutex
+	path     string
+	file     *os.File
+	size     int64
+	maxBytes int64
+	maxFiles int
+}
+
+func newRotatingWriter(path string, maxBytes int64, maxFiles int) (*rotatingWriter, error) {
+	w := &rotatingWriter{path: path, maxBytes: maxBytes, maxFiles: maxFiles}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// open opens the log for appending, counting what is already in it
+func (w *rotatingWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+func (w *rotatingWriter) setLimits(maxBytes int64, maxFiles int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.maxBytes = maxBytes
+	w.maxFiles = maxFiles
+}
+
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// A single write larger than the limit still goes into one file
+	if w.size > 0 && w.size+int64(len(p)) > w.maxBytes {
+		if err := w.rotate(); err != nil {
+			fmt.Fprintf(os.Stderr, "logging: failed to rotate %s: %v\n", w.path, err)
+		}
+	}
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// rotate shifts path.N-1 to path.N down to path to path.1, dropping the
+// oldest, and starts a new file. The new file is opened even if a rename
+// fails, so logging carries on.
+func (w *rotatingWriter) rotate() error {
+	var firstErr error
+	keep := func(err error) {
+		if err != nil && !os.IsNotExist(err) && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	keep(w.file.Close())
+	w.file = nil
+	keep(os.Remove(w.backupName(w.maxFiles)))
+	for i := w.maxFiles - 1; i >= 1; i-- {
+		keep(os.Rename(w.backupName(i), w.backupName(i+1)))
+	}
+	keep(os.Rename(w.path, w.backupName(1)))
+
+	keep(w.open())
+	return firstErr
+}
+
+func (w *rotatingWriter) backupName(n int) string {
+	return fmt.Sprintf("%s.%d", w.path, n)
+}
@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.txt")
+
+	// Existing content counts toward the limit
+	if err := os.WriteFile(path, []byte("old line 000000000\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := newRotatingWriter(path, 50, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { w.file.Close() }()
+
+	// Each line is 20 bytes, so every file holds two
+	for i := 1; i <= 7; i++ {
+		line := fmt.Sprintf("%s line %d\n", strings.Repeat("x", 12), i)
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatalf("Write %d: %v", i, err)
+		}
+	}
+
+	// old,1 | 2,3 | 4,5 | 6,7: the oldest two files were dropped
+	want := map[string]string{
+		path:        "line 6\n",
+		path + ".1": "line 4\n",
+		path + ".2": "line 2\n",
+	}
+	for name, first := range want {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("%s: %v", filepath.Base(name), err)
+		}
+		if len(data) > 50 {
+			t.Errorf("%s is %d bytes, want at most 50", filepath.Base(name), len(data))
+		}
+		if lines := strings.SplitAfter(string(data), "\n"); !strings.HasSuffix(lines[0], first) {
+			t.Errorf("%s starts with %q, want %q", filepath.Base(name), lines[0], first)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("log.txt.3 exists, want only 2 rotated logs")
+	}
+}
+
+func TestRotatingWriterOversizedWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.txt")
+	w, err := newRotatingWriter(path, 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { w.file.Close() }()
+
+	// A write larger than the limit is kept whole rather than split
+	long := strings.Repeat("y", 25) + "\n"
+	if n, err := w.Write([]byte(long)); err != nil || n != len(long) {
+		t.Fatalf("Write = %d, %v", n, err)
+	}
+	if _, err := w.Write([]byte("next\n")); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path + ".1"); string(data) != long {
+		t.Errorf("log.txt.1 = %q, want the long line", data)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "next\n" {
+		t.Errorf("log.txt = %q, want %q", data, "next\n")
+	}
+}
@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+const (
+	// SyslogTag identifies LanAudit's messages in syslog
+	SyslogTag = "lanaudit"
+
+	// DefaultSyslogFacility is used when no facility is configured
+	DefaultSyslogFacility = "user"
+)
+
+// syslogFacilities maps facility names to their syslog codes
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3,
+	"auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// SyslogFacilityNames returns the facility names ConfigureSyslog accepts,
+// sorted
+func SyslogFacilityNames() []string {
+	names := make([]string, 0, len(syslogFacilities))
+	for name := range syslogFacilities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidSyslogFacility reports whether name is a known facility
+func ValidSyslogFacility(name string) bool {
+	_, ok := syslogFacilities[strings.ToLower(name)]
+	return ok
+}
+
+// ParseSyslogAddress splits a syslog address into the network and address
+// for syslog.Dial. "host:port" and "udp://host:port" use UDP,
+// "tcp://host:port" uses TCP, and "" means the local syslog daemon.
+func ParseSyslogAddress(address string) (network, addr string, err error) {
+	if address == "" {
+		return "", "", nil
+	}
+	network, addr = "udp", address
+	if scheme, rest, ok := strings.Cut(address, "://"); ok {
+		network, addr = strings.ToLower(scheme), rest
+	}
+	if network != "udp" && network != "tcp" {
+		return "", "", fmt.Errorf("syslog address %q: network must be udp or tcp", address)
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return "", "", fmt.Errorf("syslog address %q: %w", address, err)
+	}
+	return network, addr, nil
+}
+
+// ConfigureSyslog sends every log entry to syslog as well as log.txt, using
+// the address and facility from the config
+func ConfigureSyslog(address, facility string) error {
+	network, addr, err := ParseSyslogAddress(address)
+	if err != nil {
+		return err
+	}
+	if facility == "" {
+		facility = DefaultSyslogFacility
+	}
+	code, ok := syslogFacilities[strings.ToLower(facility)]
+	if !ok {
+		return fmt.Errorf("unknown syslog facility %q: use one of %s", facility, strings.Join(SyslogFacilityNames(), ", "))
+	}
+	return dialSyslog(network, addr, code, SyslogTag)
+}
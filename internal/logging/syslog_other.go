@@ -0,0 +1,10 @@
+//go:build windows || plan9
+
+package logging
+
+import "fmt"
+
+// dialSyslog is not implemented on this platform
+func dialSyslog(network, addr string, facility int, tag string) error {
+	return fmt.Errorf("syslog not supported on this platform")
+}
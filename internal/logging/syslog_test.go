@@ -0,0 +1,40 @@
+package logging
+
+import "testing"
+
+func TestParseSyslogAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		network string
+		addr    string
+		wantErr bool
+	}{
+		{address: "", network: "", addr: ""},
+		{address: "siem.example.com:514", network: "udp", addr: "siem.example.com:514"},
+		{address: "udp://10.0.0.5:514", network: "udp", addr: "10.0.0.5:514"},
+		{address: "TCP://[2001:db8::1]:601", network: "tcp", addr: "[2001:db8::1]:601"},
+		{address: "siem.example.com", wantErr: true},
+		{address: "http://siem.example.com:514", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			network, addr, err := ParseSyslogAddress(tt.address)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSyslogAddress(%q) error = %v, wantErr %v", tt.address, err, tt.wantErr)
+			}
+			if network != tt.network || addr != tt.addr {
+				t.Errorf("ParseSyslogAddress(%q) = %q, %q, want %q, %q", tt.address, network, addr, tt.network, tt.addr)
+			}
+		})
+	}
+}
+
+func TestConfigureSyslogRejectsUnknownFacility(t *testing.T) {
+	if err := ConfigureSyslog("127.0.0.1:514", "local9"); err == nil {
+		t.Error("ConfigureSyslog with facility local9 should fail")
+	}
+	if !ValidSyslogFacility("LOCAL3") || ValidSyslogFacility("local9") {
+		t.Error("ValidSyslogFacility should accept local0-7 in any case and nothing else")
+	}
+}
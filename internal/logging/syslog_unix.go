@@ -0,0 +1,46 @@
+//go:build !windows && !plan9
+
+package logging
+
+import (
+	"fmt"
+	"log/syslog"
+)
+
+// EnableSyslog connects to syslog and sends it every log entry alongside
+// log.txt, at the severity matching the entry's level. An empty network
+// and addr use the local syslog daemon.
+func EnableSyslog(network, addr string, priority syslog.Priority, tag string) error {
+	w, err := syslog.Dial(network, addr, priority, tag)
+	if err != nil {
+		return fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	setSecondary(syslogWriter{w})
+	return nil
+}
+
+func dialSyslog(network, addr string, facility int, tag string) error {
+	return EnableSyslog(network, addr, syslog.Priority(facility<<3)|syslog.LOG_INFO, tag)
+}
+
+// syslogWriter maps log levels to syslog severities
+type syslogWriter struct {
+	w *syslog.Writer
+}
+
+func (s syslogWriter) WriteLevel(level Level, msg string) error {
+	switch level {
+	case LevelDebug:
+		return s.w.Debug(msg)
+	case LevelInfo:
+		return s.w.Info(msg)
+	case LevelWarn:
+		return s.w.Warning(msg)
+	default:
+		return s.w.Err(msg)
+	}
+}
+
+func (s syslogWriter) Close() error {
+	return s.w.Close()
+}
@@ -0,0 +1,60 @@
+//go:build !windows && !plan9
+
+package logging
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"log/syslog"
+	"net"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestEnableSyslog(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on UDP: %v", err)
+	}
+	defer conn.Close()
+
+	var buf bytes.Buffer
+	ensureLogger()
+	originalLogger := logger
+	defer func() { logger = originalLogger }()
+	logger = log.New(&buf, "", 0)
+	useLevel(t, "info", io.Discard)
+	t.Cleanup(func() { setSecondary(nil) })
+
+	if err := EnableSyslog("udp", conn.LocalAddr().String(), syslog.LOG_LOCAL0|syslog.LOG_INFO, "lanaudit-test"); err != nil {
+		t.Fatal(err)
+	}
+	Debugf("below the level, not sent")
+	WithPkg("scan").Warnf("port %d closed", 22)
+
+	// <PRI>TIMESTAMP HOSTNAME TAG[PID]: MSG
+	header := regexp.MustCompile(`^<(\d+)>(\S+) \S+ lanaudit-test\[\d+\]: scan: port 22 closed\n?$`)
+	packet := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := conn.ReadFrom(packet)
+	if err != nil {
+		t.Fatalf("no syslog message received: %v", err)
+	}
+	m := header.FindStringSubmatch(string(packet[:n]))
+	if m == nil {
+		t.Fatalf("syslog message %q does not match %s", packet[:n], header)
+	}
+	// local0 (16) * 8 + warning (4)
+	if pri, _ := strconv.Atoi(m[1]); pri != 132 {
+		t.Errorf("priority = %d, want 132 (local0.warning)", pri)
+	}
+	if _, err := time.Parse(time.RFC3339, m[2]); err != nil {
+		t.Errorf("timestamp %q is not RFC 3339: %v", m[2], err)
+	}
+	if got := buf.String(); got != "[WARN] scan: port 22 closed\n" {
+		t.Errorf("log.txt = %q, want the entry there too", got)
+	}
+}
@@ -0,0 +1,283 @@
+package mdns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	// ServicesQuery is the DNS-SD meta-query that lists every service type
+	// announced on the link
+	ServicesQuery = "_services._dns-sd._udp.local."
+
+	// DefaultDuration is how long Discover listens for responses
+	DefaultDuration = 3 * time.Second
+)
+
+// mdnsAddr is where queries are sent; tests point it at a local responder
+var mdnsAddr = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// readSlice bounds each read so a cancelled context is noticed promptly
+const readSlice = 200 * time.Millisecond
+
+// MDNSEntry is a service instance announced over mDNS
+type MDNSEntry struct {
+	Name string // instance name, e.g. "Office Printer"
+	Type string // service type, e.g. "_ipp._tcp.local"
+	Host string // target host, e.g. "printer.local"
+	Port int
+	IPs  []string
+	TXT  map[string]string
+}
+
+// Discover browses for service instances for duration. service is a type
+// such as "_ipp._tcp"; empty browses every type the link announces via
+// _services._dns-sd._udp.local. Queries go from an ephemeral port, so
+// responders answer by unicast and port 5353 need not be free.
+func Discover(ctx context.Context, service string, duration time.Duration) ([]MDNSEntry, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4zero})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mDNS socket: %w", err)
+	}
+	defer conn.Close()
+
+	c := newCollector()
+	first := ServicesQuery
+	if service != "" {
+		first = serviceType(service)
+		c.types[first] = true
+	}
+	if err := sendQuery(conn, question(first, dns.TypePTR)); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(duration)
+	buf := make([]byte, 9000)
+	for time.Now().Before(deadline) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		slice := time.Now().Add(readSlice)
+		if slice.After(deadline) {
+			slice = deadline
+		}
+		conn.SetReadDeadline(slice)
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read mDNS response: %w", err)
+		}
+
+		msg := new(dns.Msg)
+		if err := msg.Unpack(buf[:n]); err != nil || !msg.Response {
+			continue
+		}
+		// Follow up on newly learned types and on instances whose SRV or
+		// TXT record was not included
+		if qs := c.add(msg); len(qs) > 0 {
+			if err := sendQuery(conn, qs...); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return c.entries(), nil
+}
+
+// serviceType returns a service type as a fully qualified .local name
+func serviceType(service string) string {
+	service = strings.TrimSuffix(service, ".")
+	if !strings.HasSuffix(service, ".local") {
+		service += ".local"
+	}
+	return service + "."
+}
+
+func question(name string, qtype uint16) dns.Question {
+	return dns.Question{Name: name, Qtype: qtype, Qclass: dns.ClassINET}
+}
+
+func sendQuery(conn *net.UDPConn, qs ...dns.Question) error {
+	msg := new(dns.Msg)
+	msg.Id = dns.Id()
+	msg.Question = qs
+	data, err := msg.Pack()
+	if err != nil {
+		return fmt.Errorf("failed to build mDNS query: %w", err)
+	}
+	if _, err := conn.WriteToUDP(data, mdnsAddr); err != nil {
+		return fmt.Errorf("failed to send mDNS query: %w", err)
+	}
+	return nil
+}
+
+// srvTarget is the host and port from an SRV record
+type srvTarget struct {
+	host string
+	port int
+}
+
+// collector gathers records from responses until entries are built
+type collector struct {
+	types     map[string]bool      // service types queried
+	instances map[string]string    // instance name -> service type
+	srv       map[string]srvTarget // instance name -> target
+	txt       map[string]map[string]string
+	addrs     map[string][]string // host -> addresses
+	asked     map[string]bool     // instances already queried for SRV/TXT
+}
+
+func newCollector() *collector {
+	return &collector{
+		types:     make(map[string]bool),
+		instances: make(map[string]string),
+		srv:       make(map[string]srvTarget),
+		txt:       make(map[string]map[string]string),
+		addrs:     make(map[string][]string),
+		asked:     make(map[string]bool),
+	}
+}
+
+// add records a response's answers and additional records, and returns the
+// follow-up questions it calls for
+func (c *collector) add(msg *dns.Msg) []dns.Question {
+	records := append(append([]dns.RR(nil), msg.Answer...), msg.Extra...)
+	var newTypes, newInstances []string
+	for _, rr := range records {
+		name := strings.ToLower(rr.Header().Name)
+		switch r := rr.(type) {
+		case *dns.PTR:
+			if name == ServicesQuery {
+				t := strings.ToLower(r.Ptr)
+				if !c.types[t] {
+					c.types[t] = true
+					newTypes = append(newTypes, t)
+				}
+			} else if c.types[name] {
+				if _, ok := c.instances[r.Ptr]; !ok {
+					c.instances[r.Ptr] = name
+					newInstances = append(newInstances, r.Ptr)
+				}
+			}
+		case *dns.SRV:
+			c.srv[name] = srvTarget{host: strings.ToLower(r.Target), port: int(r.Port)}
+		case *dns.TXT:
+			c.txt[name] = parseTXT(r.Txt)
+		case *dns.A:
+			c.addAddr(name, r.A.String())
+		case *dns.AAAA:
+			c.addAddr(name, r.AAAA.String())
+		}
+	}
+
+	var qs []dns.Question
+	for _, t := range newTypes {
+		qs = append(qs, question(t, dns.TypePTR))
+	}
+	for _, inst := range newInstances {
+		key := strings.ToLower(inst)
+		if _, ok := c.srv[key]; ok {
+			continue
+		}
+		if !c.asked[key] {
+			c.asked[key] = true
+			qs = append(qs, question(inst, dns.TypeSRV), question(inst, dns.TypeTXT))
+		}
+	}
+	return qs
+}
+
+func (c *collector) addAddr(host, addr string) {
+	for _, a := range c.addrs[host] {
+		if a == addr {
+			return
+		}
+	}
+	c.addrs[host] = append(c.addrs[host], addr)
+}
+
+// entries builds the discovered instances, sorted by type and name
+func (c *collector) entries() []MDNSEntry {
+	entries := make([]MDNSEntry, 0, len(c.instances))
+	for inst, typ := range c.instances {
+		key := strings.ToLower(inst)
+		e := MDNSEntry{
+			Name: instanceName(inst, typ),
+			Type: strings.TrimSuffix(typ, "."),
+			TXT:  c.txt[key],
+		}
+		if srv, ok := c.srv[key]; ok {
+			e.Host = strings.TrimSuffix(srv.host, ".")
+			e.Port = srv.port
+			e.IPs = append([]string(nil), c.addrs[srv.host]...)
+			sort.Strings(e.IPs)
+		}
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Type != entries[j].Type {
+			return entries[i].Type < entries[j].Type
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+// instanceName strips the service type from an instance's DNS name and
+// undoes DNS escaping, so "Office\ Printer._ipp._tcp.local." becomes
+// "Office Printer"
+func instanceName(inst, typ string) string {
+	label := inst
+	if len(inst) > len(typ) && strings.EqualFold(inst[len(inst)-len(typ):], typ) {
+		label = strings.TrimSuffix(inst[:len(inst)-len(typ)], ".")
+	}
+	return unescape(label)
+}
+
+// unescape reverses miekg/dns label escaping: \X for special characters
+// and \DDD for other bytes
+func unescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+3 < len(s) {
+			if n, err := strconv.Atoi(s[i+1 : i+4]); err == nil && n < 256 {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i+1])
+		i++
+	}
+	return b.String()
+}
+
+// parseTXT splits DNS-SD key=value strings; a key without '=' maps to ""
+func parseTXT(txt []string) map[string]string {
+	if len(txt) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(txt))
+	for _, kv := range txt {
+		if kv == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(kv, "=")
+		m[k] = v
+	}
+	return m
+}
@@ -0,0 +1,161 @@
+package mdns
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+func mustRR(t *testing.T, s string) dns.RR {
+	t.Helper()
+	rr, err := dns.NewRR(s)
+	if err != nil {
+		t.Fatalf("NewRR(%q): %v", s, err)
+	}
+	return rr
+}
+
+// startResponder answers each query on a loopback socket from records, the
+// way a responder answers a query sent from an ephemeral port
+func startResponder(t *testing.T, records map[dns.Question][]dns.RR) {
+	t.Helper()
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	prev := mdnsAddr
+	mdnsAddr = conn.LocalAddr().(*net.UDPAddr)
+	t.Cleanup(func() { mdnsAddr = prev })
+
+	go func() {
+		buf := make([]byte, 9000)
+		for {
+			n, from, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			query := new(dns.Msg)
+			if err := query.Unpack(buf[:n]); err != nil {
+				continue
+			}
+			resp := new(dns.Msg)
+			resp.SetReply(query)
+			for _, q := range query.Question {
+				resp.Answer = append(resp.Answer, records[q]...)
+			}
+			if len(resp.Answer) == 0 {
+				continue
+			}
+			data, err := resp.Pack()
+			if err != nil {
+				continue
+			}
+			conn.WriteToUDP(data, from)
+		}
+	}()
+}
+
+func TestDiscover(t *testing.T) {
+	startResponder(t, map[dns.Question][]dns.RR{
+		question(ServicesQuery, dns.TypePTR): {
+			mustRR(t, "_services._dns-sd._udp.local. 4500 IN PTR _ipp._tcp.local."),
+		},
+		question("_ipp._tcp.local.", dns.TypePTR): {
+			mustRR(t, `_ipp._tcp.local. 4500 IN PTR Office\ Printer._ipp._tcp.local.`),
+			mustRR(t, `_ipp._tcp.local. 4500 IN PTR Lab._ipp._tcp.local.`),
+			// Records for the first instance come in the same response
+			mustRR(t, `Office\ Printer._ipp._tcp.local. 120 IN SRV 0 0 631 printer.local.`),
+			mustRR(t, `Office\ Printer._ipp._tcp.local. 4500 IN TXT "rp=ipp/print" "ty=Office Printer" "Color"`),
+			mustRR(t, "printer.local. 120 IN A 192.168.1.50"),
+			mustRR(t, "printer.local. 120 IN AAAA fe80::1"),
+		},
+		// The second needs a follow-up query
+		question("Lab._ipp._tcp.local.", dns.TypeSRV): {
+			mustRR(t, "Lab._ipp._tcp.local. 120 IN SRV 0 0 631 lab.local."),
+			mustRR(t, "lab.local. 120 IN A 192.168.1.51"),
+		},
+	})
+
+	entries, err := Discover(context.Background(), "", 500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+
+	want := []MDNSEntry{
+		{Name: "Lab", Type: "_ipp._tcp.local", Host: "lab.local", Port: 631, IPs: []string{"192.168.1.51"}},
+		{
+			Name: "Office Printer", Type: "_ipp._tcp.local", Host: "printer.local", Port: 631,
+			IPs: []string{"192.168.1.50", "fe80::1"},
+			TXT: map[string]string{"rp": "ipp/print", "ty": "Office Printer", "Color": ""},
+		},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("Discover() = %+v, want %+v", entries, want)
+	}
+}
+
+func TestDiscoverService(t *testing.T) {
+	startResponder(t, map[dns.Question][]dns.RR{
+		question("_ssh._tcp.local.", dns.TypePTR): {
+			mustRR(t, "_ssh._tcp.local. 4500 IN PTR nas._ssh._tcp.local."),
+			mustRR(t, "nas._ssh._tcp.local. 120 IN SRV 0 0 22 nas.local."),
+			mustRR(t, "nas.local. 120 IN A 10.0.0.5"),
+		},
+	})
+
+	entries, err := Discover(context.Background(), "_ssh._tcp", 300*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name != "nas" || entries[0].Port != 22 || entries[0].Host != "nas.local" {
+		t.Errorf("Discover() = %+v, want nas on port 22", entries)
+	}
+}
+
+func TestDiscoverCancelled(t *testing.T) {
+	startResponder(t, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Discover(ctx, "", time.Second); err != context.Canceled {
+		t.Errorf("Discover() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestServiceType(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"_ipp._tcp", "_ipp._tcp.local."},
+		{"_ipp._tcp.local", "_ipp._tcp.local."},
+		{"_ipp._tcp.local.", "_ipp._tcp.local."},
+	}
+	for _, tt := range tests {
+		if got := serviceType(tt.in); got != tt.want {
+			t.Errorf("serviceType(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestInstanceName(t *testing.T) {
+	tests := []struct {
+		inst string
+		want string
+	}{
+		{`Office\ Printer._ipp._tcp.local.`, "Office Printer"},
+		{`Lab\.Printer._ipp._tcp.local.`, "Lab.Printer"},
+		{`Caf\195\169._ipp._tcp.local.`, "Café"},
+		{`_ipp._tcp.local.`, "_ipp._tcp.local."},
+	}
+	for _, tt := range tests {
+		if got := instanceName(tt.inst, "_ipp._tcp.local."); got != tt.want {
+			t.Errorf("instanceName(%q) = %q, want %q", tt.inst, got, tt.want)
+		}
+	}
+}
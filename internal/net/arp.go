@@ -0,0 +1,129 @@
+package net
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ARPEntry is a single neighbour from the system ARP cache
+type ARPEntry struct {
+	IP        string `json:"ip"`
+	MAC       string `json:"mac"`
+	Interface string `json:"interface"`
+	State     string `json:"state"`
+}
+
+// GetARPTable returns the system ARP cache sorted by IP address
+func GetARPTable() ([]ARPEntry, error) {
+	entries, err := getARPTable()
+	if err != nil {
+		return nil, err
+	}
+	sortARPEntries(entries)
+	return entries, nil
+}
+
+// sortARPEntries orders entries numerically by IP address
+func sortARPEntries(entries []ARPEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := net.ParseIP(entries[i].IP), net.ParseIP(entries[j].IP)
+		if a == nil || b == nil {
+			return entries[i].IP < entries[j].IP
+		}
+		return bytes.Compare(a.To16(), b.To16()) < 0
+	})
+}
+
+// parseProcNetARP parses /proc/net/arp content (Linux)
+func parseProcNetARP(content string) ([]ARPEntry, error) {
+	var entries []ARPEntry
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// IP address, HW type, Flags, HW address, Mask, Device
+		if len(fields) < 6 || fields[0] == "IP" {
+			continue
+		}
+
+		flags, err := strconv.ParseUint(strings.TrimPrefix(fields[2], "0x"), 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid flags %q: %w", fields[2], err)
+		}
+
+		entries = append(entries, ARPEntry{
+			IP:        fields[0],
+			MAC:       fields[3],
+			Interface: fields[5],
+			State:     arpFlagsState(flags),
+		})
+	}
+
+	return entries, scanner.Err()
+}
+
+// arpFlagsState maps ATF_* flags from /proc/net/arp to a display state
+func arpFlagsState(flags uint64) string {
+	const (
+		atfCom  = 0x02
+		atfPerm = 0x04
+	)
+	switch {
+	case flags&atfPerm != 0:
+		return "permanent"
+	case flags&atfCom != 0:
+		return "reachable"
+	default:
+		return "incomplete"
+	}
+}
+
+var arpAnRe = regexp.MustCompile(`^\S+ \(([^)]+)\) at (\S+)(?: on (\S+))?(.*)$`)
+
+// parseArpAn parses `arp -an` output (macOS)
+func parseArpAn(output string) []ARPEntry {
+	var entries []ARPEntry
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		m := arpAnRe.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if len(m) != 5 {
+			continue
+		}
+
+		entry := ARPEntry{IP: m[1], Interface: m[3], State: "reachable"}
+		switch {
+		case m[2] == "(incomplete)":
+			entry.State = "incomplete"
+		default:
+			entry.MAC = normalizeMAC(m[2])
+		}
+		if strings.Contains(m[4], "permanent") {
+			entry.State = "permanent"
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// normalizeMAC zero-pads octets, e.g. "0:1b:2:..." -> "00:1b:02:..."
+func normalizeMAC(mac string) string {
+	parts := strings.Split(mac, ":")
+	if len(parts) != 6 {
+		return mac
+	}
+	for i, p := range parts {
+		if len(p) == 1 {
+			parts[i] = "0" + p
+		}
+	}
+	return strings.ToLower(strings.Join(parts, ":"))
+}
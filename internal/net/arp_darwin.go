@@ -0,0 +1,14 @@
+//go:build darwin
+
+package net
+
+import "os/exec"
+
+// getARPTable reads the ARP cache via arp -an (macOS implementation)
+func getARPTable() ([]ARPEntry, error) {
+	output, err := exec.Command("arp", "-an").Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseArpAn(string(output)), nil
+}
@@ -0,0 +1,14 @@
+//go:build linux
+
+package net
+
+import "os"
+
+// getARPTable reads the ARP cache from /proc/net/arp (Linux implementation)
+func getARPTable() ([]ARPEntry, error) {
+	data, err := os.ReadFile("/proc/net/arp")
+	if err != nil {
+		return nil, err
+	}
+	return parseProcNetARP(string(data))
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package net
+
+import "fmt"
+
+// getARPTable is not implemented on this platform
+func getARPTable() ([]ARPEntry, error) {
+	return nil, fmt.Errorf("ARP table not supported on this platform")
+}
@@ -0,0 +1,38 @@
+package net
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseProcNetARP(t *testing.T) {
+	entries, err := parseProcNetARP(readFixture(t, "proc_net_arp.txt"))
+	if err != nil {
+		t.Fatalf("parseProcNetARP() error = %v", err)
+	}
+	sortARPEntries(entries)
+
+	want := []ARPEntry{
+		{IP: "10.0.0.1", MAC: "de:ad:be:ef:00:01", Interface: "eth1", State: "permanent"},
+		{IP: "192.168.1.1", MAC: "aa:bb:cc:dd:ee:ff", Interface: "eth0", State: "reachable"},
+		{IP: "192.168.1.9", MAC: "00:00:00:00:00:00", Interface: "eth0", State: "incomplete"},
+		{IP: "192.168.1.20", MAC: "11:22:33:44:55:66", Interface: "eth0", State: "reachable"},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("parseProcNetARP() = %+v, want %+v", entries, want)
+	}
+}
+
+func TestParseArpAn(t *testing.T) {
+	entries := parseArpAn(readFixture(t, "arp_an.txt"))
+
+	want := []ARPEntry{
+		{IP: "192.168.1.1", MAC: "aa:bb:cc:dd:ee:ff", Interface: "en0", State: "reachable"},
+		{IP: "192.168.1.20", MAC: "00:1b:02:44:55:66", Interface: "en0", State: "reachable"},
+		{IP: "192.168.1.9", MAC: "", Interface: "en0", State: "incomplete"},
+		{IP: "224.0.0.251", MAC: "01:00:5e:00:00:fb", Interface: "en0", State: "permanent"},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("parseArpAn() = %+v, want %+v", entries, want)
+	}
+}
@@ -0,0 +1,134 @@
+package net
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// Points BestInterface gives an interface for each property
+const (
+	scoreLinkUp  = 10
+	scoreIPv4    = 5
+	scoreGateway = 5
+	scoreDNS     = 3
+	scoreBusiest = 1 // the most bytes sent and received of the candidates
+)
+
+// userInterfaceDetails returns the details of every user interface; tests
+// replace it
+var userInterfaceDetails = func() ([]*InterfaceDetails, error) {
+	ifaces, err := ListUserInterfaces()
+	if err != nil {
+		return nil, err
+	}
+	details := make([]*InterfaceDetails, 0, len(ifaces))
+	for _, iface := range ifaces {
+		d, err := GetInterfaceDetails(iface.Name)
+		if err != nil {
+			continue
+		}
+		details = append(details, d)
+	}
+	return details, nil
+}
+
+// BestInterface returns the user interface most likely to be the one in use
+func BestInterface() (string, error) {
+	return BestInterfaceWithPrefix("")
+}
+
+// BestInterfaceWithPrefix returns the best user interface whose name starts
+// with prefix. Interfaces score for being up, having a routable IPv4
+// address, a default gateway and DNS servers, and the busiest gets a point
+// more. Ties go to the busier interface, then the first name.
+func BestInterfaceWithPrefix(prefix string) (string, error) {
+	candidates, err := userInterfaceDetails()
+	if err != nil {
+		return "", fmt.Errorf("failed to list interfaces: %w", err)
+	}
+	return bestInterface(candidates, prefix)
+}
+
+// bestInterface picks the highest scoring of the candidates named with prefix
+func bestInterface(candidates []*InterfaceDetails, prefix string) (string, error) {
+	var matching []*InterfaceDetails
+	for _, d := range candidates {
+		if strings.HasPrefix(d.Name, prefix) {
+			matching = append(matching, d)
+		}
+	}
+	if len(matching) == 0 {
+		if prefix != "" {
+			return "", fmt.Errorf("no interface name starts with %q", prefix)
+		}
+		return "", fmt.Errorf("no interfaces found")
+	}
+
+	var busiest uint64
+	for _, d := range matching {
+		if traffic(d) > busiest {
+			busiest = traffic(d)
+		}
+	}
+	scores := make(map[string]int, len(matching))
+	for _, d := range matching {
+		scores[d.Name] = interfaceScore(d)
+		if busiest > 0 && traffic(d) == busiest {
+			scores[d.Name] += scoreBusiest
+		}
+	}
+
+	sort.SliceStable(matching, func(i, j int) bool {
+		a, b := matching[i], matching[j]
+		if scores[a.Name] != scores[b.Name] {
+			return scores[a.Name] > scores[b.Name]
+		}
+		if traffic(a) != traffic(b) {
+			return traffic(a) > traffic(b)
+		}
+		return a.Name < b.Name
+	})
+	return matching[0].Name, nil
+}
+
+// interfaceScore totals the points for everything but traffic
+func interfaceScore(d *InterfaceDetails) int {
+	score := 0
+	if d.LinkUp {
+		score += scoreLinkUp
+	}
+	if hasRoutableIPv4(d.IPs) {
+		score += scoreIPv4
+	}
+	if len(d.DefaultGateways) > 0 {
+		score += scoreGateway
+	}
+	if len(d.DNSServers) > 0 {
+		score += scoreDNS
+	}
+	return score
+}
+
+// hasRoutableIPv4 reports whether any address is IPv4 and not link-local
+// (169.254.0.0/16) or loopback
+func hasRoutableIPv4(ips []string) bool {
+	for _, s := range ips {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			ip, _, _ = net.ParseCIDR(s)
+		}
+		if ip == nil || ip.To4() == nil {
+			continue
+		}
+		if !ip.IsLinkLocalUnicast() && !ip.IsLoopback() {
+			return true
+		}
+	}
+	return false
+}
+
+func traffic(d *InterfaceDetails) uint64 {
+	return d.BytesRx + d.BytesTx
+}
@@ -0,0 +1,76 @@
+package net
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBestInterface(t *testing.T) {
+	wired := &InterfaceDetails{
+		Name:            "en0",
+		IPs:             []string{"192.168.1.10", "fe80::1"},
+		LinkUp:          true,
+		DefaultGateways: []string{"192.168.1.1"},
+		DNSServers:      []string{"192.168.1.1"},
+		BytesRx:         100,
+	}
+	wifi := &InterfaceDetails{
+		Name:            "en1",
+		IPs:             []string{"10.0.0.5/24"},
+		LinkUp:          true,
+		DefaultGateways: []string{"10.0.0.1"},
+		DNSServers:      []string{"10.0.0.1"},
+		BytesRx:         500,
+		BytesTx:         500,
+	}
+	selfAssigned := &InterfaceDetails{
+		Name:            "eth0",
+		IPs:             []string{"169.254.10.20"},
+		LinkUp:          true,
+		DefaultGateways: []string{"192.168.1.1"},
+		DNSServers:      []string{"192.168.1.1"},
+		BytesRx:         1 << 30,
+	}
+	down := &InterfaceDetails{Name: "en2", IPs: []string{"192.168.2.10"}}
+	idleTwin := &InterfaceDetails{Name: "en3", IPs: []string{"192.168.3.10"}, LinkUp: true}
+	idleTwinB := &InterfaceDetails{Name: "en4", IPs: []string{"192.168.4.10"}, LinkUp: true}
+
+	tests := []struct {
+		name       string
+		candidates []*InterfaceDetails
+		prefix     string
+		want       string
+		wantErr    bool
+	}{
+		{name: "busiest breaks a tie", candidates: []*InterfaceDetails{wired, wifi}, want: "en1"},
+		{name: "link-local address loses to routable", candidates: []*InterfaceDetails{selfAssigned, wired}, want: "en0"},
+		{name: "down interface loses", candidates: []*InterfaceDetails{down, idleTwin}, want: "en3"},
+		{name: "equal scores and traffic go to the first name", candidates: []*InterfaceDetails{idleTwinB, idleTwin}, want: "en3"},
+		{name: "prefix filter", candidates: []*InterfaceDetails{wired, wifi, selfAssigned}, prefix: "eth", want: "eth0"},
+		{name: "prefix matches nothing", candidates: []*InterfaceDetails{wired}, prefix: "wl", wantErr: true},
+		{name: "no interfaces", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := bestInterface(tt.candidates, tt.prefix)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("bestInterface() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("bestInterface() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBestInterfaceListError(t *testing.T) {
+	orig := userInterfaceDetails
+	defer func() { userInterfaceDetails = orig }()
+	userInterfaceDetails = func() ([]*InterfaceDetails, error) {
+		return nil, errors.New("permission denied")
+	}
+	if _, err := BestInterface(); err == nil {
+		t.Error("expected the listing error")
+	}
+}
@@ -0,0 +1,210 @@
+package net
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/pcap"
+)
+
+// CDPNeighbor represents a Cisco Discovery Protocol neighbor device
+type CDPNeighbor struct {
+	DeviceID        string
+	Platform        string
+	Capabilities    string // comma separated, e.g. "Router, Switch"
+	PortID          string
+	SoftwareVersion string
+	Addresses       []string
+	TTL             uint8
+	Discovered      time.Time
+}
+
+// CDP frames are 802.3 with an LLC/SNAP header: DSAP/SSAP 0xAA, control
+// 0x03, Cisco OUI 00:00:0C and protocol ID 0x2000 at offset 20
+const (
+	cdpSNAPProtocolOffset = 20
+	cdpHeaderOffset       = 22
+	cdpProtocolID         = 0x2000
+)
+
+// CDP TLV types
+const (
+	cdpTLVDeviceID     = 1
+	cdpTLVAddresses    = 2
+	cdpTLVPortID       = 3
+	cdpTLVCapabilities = 4
+	cdpTLVVersion      = 5
+	cdpTLVPlatform     = 6
+)
+
+// DiscoverCDP performs passive CDP discovery on the specified interface
+// Listens for CDP packets for the specified duration
+func DiscoverCDP(iface string, duration time.Duration) ([]CDPNeighbor, error) {
+	// Open interface for passive capture
+	handle, err := pcap.OpenLive(iface, 1600, true, pcap.BlockForever)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open interface %s: %w (requires sudo/root)", iface, err)
+	}
+	defer handle.Close()
+
+	// Filter for CDP packets (SNAP protocol ID 0x2000)
+	if err := handle.SetBPFFilter("ether multicast and ether[20:2] == 0x2000"); err != nil {
+		return nil, fmt.Errorf("failed to set CDP filter: %w", err)
+	}
+
+	neighbors := make(map[string]*CDPNeighbor)
+	packetSource := gopacket.NewPacketSource(handle, handle.LinkType())
+
+	// Set timeout for listening
+	timeout := time.After(duration)
+	packetChan := packetSource.Packets()
+
+	for {
+		select {
+		case <-timeout:
+			result := make([]CDPNeighbor, 0, len(neighbors))
+			for _, n := range neighbors {
+				result = append(result, *n)
+			}
+			sort.Slice(result, func(i, j int) bool {
+				if result[i].DeviceID != result[j].DeviceID {
+					return result[i].DeviceID < result[j].DeviceID
+				}
+				return result[i].PortID < result[j].PortID
+			})
+			return result, nil
+
+		case packet := <-packetChan:
+			if packet == nil {
+				continue
+			}
+
+			neighbor := parseCDPFrame(packet.Data())
+			if neighbor != nil {
+				// Use DeviceID + PortID as unique key
+				key := fmt.Sprintf("%s:%s", neighbor.DeviceID, neighbor.PortID)
+				neighbors[key] = neighbor
+			}
+		}
+	}
+}
+
+// parseCDPFrame extracts CDP information from a raw Ethernet frame, or
+// returns nil if it is not a CDP frame
+func parseCDPFrame(data []byte) *CDPNeighbor {
+	if len(data) < cdpHeaderOffset+4 {
+		return nil
+	}
+	if binary.BigEndian.Uint16(data[cdpSNAPProtocolOffset:cdpHeaderOffset]) != cdpProtocolID {
+		return nil
+	}
+
+	// Header: version, TTL, checksum; TLVs follow
+	neighbor := &CDPNeighbor{
+		TTL:        data[cdpHeaderOffset+1],
+		Discovered: time.Now(),
+	}
+
+	tlvs := data[cdpHeaderOffset+4:]
+	for len(tlvs) >= 4 {
+		tlvType := binary.BigEndian.Uint16(tlvs[0:2])
+		// The length includes the 4-byte type and length fields
+		tlvLen := int(binary.BigEndian.Uint16(tlvs[2:4]))
+		if tlvLen < 4 || tlvLen > len(tlvs) {
+			break
+		}
+		value := tlvs[4:tlvLen]
+
+		switch tlvType {
+		case cdpTLVDeviceID:
+			neighbor.DeviceID = string(value)
+		case cdpTLVAddresses:
+			neighbor.Addresses = parseCDPAddresses(value)
+		case cdpTLVPortID:
+			neighbor.PortID = string(value)
+		case cdpTLVCapabilities:
+			neighbor.Capabilities = parseCDPCapabilities(value)
+		case cdpTLVVersion:
+			neighbor.SoftwareVersion = strings.TrimSpace(string(value))
+		case cdpTLVPlatform:
+			neighbor.Platform = string(value)
+		}
+		tlvs = tlvs[tlvLen:]
+	}
+
+	if neighbor.DeviceID == "" {
+		return nil
+	}
+	return neighbor
+}
+
+// parseCDPAddresses decodes the Addresses TLV: a 32-bit count, then for each
+// address a protocol type and length, the protocol, and a 16-bit address
+// length and address. IPv4 (NLPID 0xCC) and IPv6 (802.2 with EtherType
+// 0x86DD) are recognised.
+func parseCDPAddresses(data []byte) []string {
+	if len(data) < 4 {
+		return nil
+	}
+	count := int(binary.BigEndian.Uint32(data[0:4]))
+	data = data[4:]
+
+	var addrs []string
+	for i := 0; i < count && len(data) >= 2; i++ {
+		protoLen := int(data[1])
+		if len(data) < 2+protoLen+2 {
+			break
+		}
+		proto := data[2 : 2+protoLen]
+		addrLen := int(binary.BigEndian.Uint16(data[2+protoLen : 4+protoLen]))
+		if len(data) < 4+protoLen+addrLen {
+			break
+		}
+		addr := data[4+protoLen : 4+protoLen+addrLen]
+
+		switch {
+		case len(proto) == 1 && proto[0] == 0xcc && addrLen == 4:
+			addrs = append(addrs, net.IP(addr).String())
+		case len(proto) == 8 && binary.BigEndian.Uint16(proto[6:8]) == 0x86dd && addrLen == 16:
+			addrs = append(addrs, net.IP(addr).String())
+		}
+		data = data[4+protoLen+addrLen:]
+	}
+	return addrs
+}
+
+// cdpCapabilityNames are the CDP capability bits, lowest first
+var cdpCapabilityNames = []string{
+	"Router",
+	"Transparent Bridge",
+	"Source Route Bridge",
+	"Switch",
+	"Host",
+	"IGMP",
+	"Repeater",
+	"Phone",
+	"Remotely Managed",
+	"CVTA",
+	"Two-port MAC Relay",
+}
+
+// parseCDPCapabilities converts the 32-bit capability bitmap to names
+func parseCDPCapabilities(data []byte) string {
+	if len(data) < 4 {
+		return ""
+	}
+	caps := binary.BigEndian.Uint32(data[0:4])
+
+	var names []string
+	for bit, name := range cdpCapabilityNames {
+		if caps&(1<<uint(bit)) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
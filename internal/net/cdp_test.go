@@ -0,0 +1,109 @@
+package net
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// loadHexFrame reads a frame dumped as hex, ignoring '#' comments and
+// whitespace
+func loadHexFrame(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var digits strings.Builder
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		digits.WriteString(strings.Join(strings.Fields(line), ""))
+	}
+	frame, err := hex.DecodeString(digits.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return frame
+}
+
+func TestParseCDPFrame(t *testing.T) {
+	frame := loadHexFrame(t, "cdp_frame.hex")
+
+	n := parseCDPFrame(frame)
+	if n == nil {
+		t.Fatal("parseCDPFrame() = nil for a CDP frame")
+	}
+	if n.DeviceID != "sw-core-01.example.com" {
+		t.Errorf("DeviceID = %q", n.DeviceID)
+	}
+	if n.PortID != "GigabitEthernet0/24" {
+		t.Errorf("PortID = %q", n.PortID)
+	}
+	if n.Platform != "cisco WS-C2960-24TT-L" {
+		t.Errorf("Platform = %q", n.Platform)
+	}
+	if n.Capabilities != "Router, Switch, IGMP" {
+		t.Errorf("Capabilities = %q, want %q", n.Capabilities, "Router, Switch, IGMP")
+	}
+	if !strings.HasPrefix(n.SoftwareVersion, "Cisco IOS Software, C2960 Software") || !strings.Contains(n.SoftwareVersion, "\nTechnical Support") {
+		t.Errorf("SoftwareVersion = %q", n.SoftwareVersion)
+	}
+	if want := []string{"192.168.1.2", "2001:db8::2"}; !reflect.DeepEqual(n.Addresses, want) {
+		t.Errorf("Addresses = %v, want %v", n.Addresses, want)
+	}
+	if n.TTL != 180 {
+		t.Errorf("TTL = %d, want 180", n.TTL)
+	}
+}
+
+func TestParseCDPFrameRejects(t *testing.T) {
+	frame := loadHexFrame(t, "cdp_frame.hex")
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "too short", data: frame[:20]},
+		{name: "other SNAP protocol", data: func() []byte {
+			d := append([]byte(nil), frame...)
+			d[20], d[21] = 0x20, 0x04 // VTP
+			return d
+		}()},
+		{name: "no device ID", data: frame[:26]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if n := parseCDPFrame(tt.data); n != nil {
+				t.Errorf("parseCDPFrame() = %+v, want nil", n)
+			}
+		})
+	}
+
+	// A TLV running past the end stops parsing without panicking
+	truncated := frame[:60]
+	if n := parseCDPFrame(truncated); n == nil || n.DeviceID != "sw-core-01.example.com" || n.Addresses != nil {
+		t.Errorf("truncated frame = %+v, want only the device ID", n)
+	}
+}
+
+func TestParseCDPCapabilities(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want string
+	}{
+		{data: []byte{0x00, 0x00, 0x00, 0x01}, want: "Router"},
+		{data: []byte{0x00, 0x00, 0x00, 0x90}, want: "Host, Phone"},
+		{data: []byte{0x00, 0x00, 0x00, 0x00}, want: ""},
+		{data: []byte{0x01}, want: ""},
+	}
+	for _, tt := range tests {
+		if got := parseCDPCapabilities(tt.data); got != tt.want {
+			t.Errorf("parseCDPCapabilities(%x) = %q, want %q", tt.data, got, tt.want)
+		}
+	}
+}
@@ -26,19 +26,50 @@ type Iface struct {
 
 // InterfaceDetails contains detailed information about an interface
 type InterfaceDetails struct {
-	Name           string
-	IPs            []string
-	MAC            string
-	MTU            int
-	DefaultGateway string
-	DNSServers     []string
-	LinkUp         bool
-	BytesRx        uint64
-	BytesTx        uint64
-	PacketsRx      uint64
-	PacketsTx      uint64
-	Speed          string
-	Type           string
+	Name            string
+	IPs             []string
+	MAC             string
+	MTU             int
+	DefaultGateways []string // IPv4 gateway first, then IPv6
+	DNSServers      []string
+	LinkUp          bool
+	BytesRx         uint64
+	BytesTx         uint64
+	PacketsRx       uint64
+	PacketsTx       uint64
+	RxErrors        uint64
+	RxDropped       uint64
+	TxErrors        uint64
+	TxDropped       uint64
+	Speed           string
+	Type            string
+	Wifi            *WifiInfo // nil unless the interface is associated Wi-Fi
+	Routes          []Route   // routing table entries leaving through this interface
+}
+
+// IPv4Gateway returns the first IPv4 default gateway, or "" if none
+func (d *InterfaceDetails) IPv4Gateway() string {
+	for _, gw := range d.DefaultGateways {
+		if !isIPv6Address(gw) {
+			return gw
+		}
+	}
+	return ""
+}
+
+// IPv6Gateway returns the first IPv6 default gateway, or "" if none
+func (d *InterfaceDetails) IPv6Gateway() string {
+	for _, gw := range d.DefaultGateways {
+		if isIPv6Address(gw) {
+			return gw
+		}
+	}
+	return ""
+}
+
+// isIPv6Address reports whether addr (optionally with a %zone) is IPv6
+func isIPv6Address(addr string) bool {
+	return strings.Contains(addr, ":")
 }
 
 // ListInterfaces returns all network interfaces
@@ -178,10 +209,7 @@ func GetInterfaceDetails(name string) (*InterfaceDetails, error) {
 		}
 	}
 
-	gateway, err := getDefaultGateway()
-	if err != nil {
-		gateway = ""
-	}
+	gateways := getDefaultGateways()
 
 	dns, err := getDNSServers()
 	if err != nil {
@@ -193,20 +221,30 @@ func GetInterfaceDetails(name string) (*InterfaceDetails, error) {
 	// Get stats
 	stats, _ := getInterfaceStats(name)
 
+	var routes []Route
+	if all, err := getRoutingTable(); err == nil {
+		routes = routesFor(all, name)
+	}
+
 	return &InterfaceDetails{
-		Name:           name,
-		IPs:            ips,
-		MAC:            iface.HardwareAddr.String(),
-		MTU:            iface.MTU,
-		DefaultGateway: gateway,
-		DNSServers:     dns,
-		LinkUp:         linkUp,
-		BytesRx:        stats.BytesRx,
-		BytesTx:        stats.BytesTx,
-		PacketsRx:      stats.PacketsRx,
-		PacketsTx:      stats.PacketsTx,
-		Speed:          "", // Loaded asynchronously
-		Type:           "", // Loaded asynchronously
+		Name:            name,
+		IPs:             ips,
+		MAC:             iface.HardwareAddr.String(),
+		MTU:             iface.MTU,
+		DefaultGateways: gateways,
+		DNSServers:      dns,
+		LinkUp:          linkUp,
+		BytesRx:         stats.BytesRx,
+		BytesTx:         stats.BytesTx,
+		PacketsRx:       stats.PacketsRx,
+		PacketsTx:       stats.PacketsTx,
+		RxErrors:        stats.RxErrors,
+		RxDropped:       stats.RxDropped,
+		TxErrors:        stats.TxErrors,
+		TxDropped:       stats.TxDropped,
+		Routes:          routes,
+		Speed:           "", // Loaded asynchronously
+		Type:            "", // Loaded asynchronously
 	}, nil
 }
 
@@ -243,15 +281,16 @@ func isVirtualInterface(name, mac string) bool {
 	return false
 }
 
-// getDefaultGateway retrieves the default gateway (macOS implementation)
-func getDefaultGateway() (string, error) {
-	cmd := exec.Command("route", "-n", "get", "default")
-	output, err := cmd.Output()
-	if err != nil {
-		return "", err
+// getDefaultGateways returns the IPv4 and IPv6 default gateways that are present
+func getDefaultGateways() []string {
+	gateways := []string{}
+	if gw, err := getDefaultGateway(); err == nil && gw != "" {
+		gateways = append(gateways, gw)
 	}
-
-	return parseDefaultGateway(string(output))
+	if gw, err := getDefaultGatewayIPv6(); err == nil && gw != "" {
+		gateways = append(gateways, gw)
+	}
+	return gateways
 }
 
 // parseDefaultGateway extracts gateway IP from route output
@@ -0,0 +1,27 @@
+//go:build darwin
+
+package net
+
+import "os/exec"
+
+// getDefaultGateway retrieves the default gateway (macOS implementation)
+func getDefaultGateway() (string, error) {
+	cmd := exec.Command("route", "-n", "get", "default")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	return parseDefaultGateway(string(output))
+}
+
+// getDefaultGatewayIPv6 retrieves the IPv6 default gateway (macOS implementation)
+func getDefaultGatewayIPv6() (string, error) {
+	cmd := exec.Command("route", "-n", "get", "-inet6", "default")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	return parseDefaultGateway(string(output))
+}
@@ -0,0 +1,79 @@
+//go:build linux
+
+package net
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// getDefaultGateway retrieves the default gateway (Linux implementation)
+func getDefaultGateway() (string, error) {
+	if data, err := os.ReadFile("/proc/net/route"); err == nil {
+		if gw, err := parseProcNetRoute(string(data)); err == nil {
+			return gw, nil
+		}
+	}
+
+	// Fall back to iproute2
+	output, err := exec.Command("ip", "route", "show", "default").Output()
+	if err != nil {
+		return "", err
+	}
+	return parseIPRouteDefault(string(output))
+}
+
+// getDefaultGatewayIPv6 retrieves the IPv6 default gateway (Linux implementation)
+func getDefaultGatewayIPv6() (string, error) {
+	output, err := exec.Command("ip", "-6", "route", "show", "default").Output()
+	if err != nil {
+		return "", err
+	}
+	return parseIPRouteDefault(string(output))
+}
+
+// parseProcNetRoute extracts the default gateway from /proc/net/route content.
+// Destination and Gateway columns are little-endian hex encoded IPv4 addresses.
+func parseProcNetRoute(content string) (string, error) {
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[0] == "Iface" {
+			continue
+		}
+		if fields[1] != "00000000" {
+			continue
+		}
+
+		raw, err := hex.DecodeString(fields[2])
+		if err != nil || len(raw) != 4 {
+			continue
+		}
+		ip := make(net.IP, 4)
+		binary.BigEndian.PutUint32(ip, binary.LittleEndian.Uint32(raw))
+		if ip.IsUnspecified() {
+			continue
+		}
+		return ip.String(), nil
+	}
+	return "", fmt.Errorf("gateway not found in /proc/net/route")
+}
+
+// parseIPRouteDefault extracts the gateway from `ip route show default` output
+func parseIPRouteDefault(output string) (string, error) {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		for i := 0; i+1 < len(fields); i++ {
+			if fields[i] == "via" {
+				return fields[i+1], nil
+			}
+		}
+	}
+	return "", fmt.Errorf("gateway not found in ip route output")
+}
@@ -0,0 +1,83 @@
+//go:build linux
+
+package net
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseProcNetRoute(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "proc_net_route.txt"))
+	if err != nil {
+		t.Fatalf("failed to read test data: %v", err)
+	}
+
+	gw, err := parseProcNetRoute(string(data))
+	if err != nil {
+		t.Fatalf("parseProcNetRoute() error = %v", err)
+	}
+	if gw != "192.168.1.1" {
+		t.Errorf("parseProcNetRoute() = %q, want 192.168.1.1", gw)
+	}
+
+	if _, err := parseProcNetRoute("Iface\tDestination\tGateway\n"); err == nil {
+		t.Error("expected error when no default route present")
+	}
+}
+
+func TestParseIPRouteDefault(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "ipv4 default",
+			input: "default via 10.0.0.1 dev eth0 proto dhcp metric 100\n",
+			want:  "10.0.0.1",
+		},
+		{
+			name:  "ipv6 default",
+			input: "default via fe80::1 dev eth0 proto ra metric 1024 pref medium\n",
+			want:  "fe80::1",
+		},
+		{
+			name:    "no default route",
+			input:   "",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseIPRouteDefault(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseIPRouteDefault() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseIPRouteDefault() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadInterfaceStats(t *testing.T) {
+	stats := readInterfaceStats(filepath.Join("testdata", "sys_statistics"))
+
+	want := InterfaceStats{
+		BytesRx:   1024,
+		BytesTx:   2048,
+		PacketsRx: 10,
+		PacketsTx: 20,
+		RxErrors:  3,
+		RxDropped: 4,
+		TxErrors:  5,
+		TxDropped: 6,
+	}
+	if *stats != want {
+		t.Errorf("readInterfaceStats() = %+v, want %+v", *stats, want)
+	}
+}
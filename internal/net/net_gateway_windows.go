@@ -0,0 +1,109 @@
+//go:build windows
+
+package net
+
+import (
+	"fmt"
+	"net"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	modiphlpapi            = windows.NewLazySystemDLL("iphlpapi.dll")
+	procGetIpForwardTable2 = modiphlpapi.NewProc("GetIpForwardTable2")
+	procFreeMibTable       = modiphlpapi.NewProc("FreeMibTable")
+)
+
+// sockaddrInet mirrors the SOCKADDR_INET union (28 bytes)
+type sockaddrInet struct {
+	Family uint16
+	Port   uint16
+	Data   [24]byte
+}
+
+// ip returns the address stored in the union, or nil for other families
+func (s *sockaddrInet) ip() net.IP {
+	switch s.Family {
+	case windows.AF_INET:
+		return net.IP(append([]byte(nil), s.Data[0:4]...))
+	case windows.AF_INET6:
+		// sin6_flowinfo precedes the 16-byte address
+		return net.IP(append([]byte(nil), s.Data[4:20]...))
+	}
+	return nil
+}
+
+// ipAddressPrefix mirrors IP_ADDRESS_PREFIX
+type ipAddressPrefix struct {
+	Prefix       sockaddrInet
+	PrefixLength uint8
+	_            [3]byte
+}
+
+// mibIPForwardRow2 mirrors MIB_IPFORWARD_ROW2 (104 bytes)
+type mibIPForwardRow2 struct {
+	InterfaceLuid        uint64
+	InterfaceIndex       uint32
+	DestinationPrefix    ipAddressPrefix
+	NextHop              sockaddrInet
+	SitePrefixLength     uint8
+	ValidLifetime        uint32
+	PreferredLifetime    uint32
+	Metric               uint32
+	Protocol             uint32
+	Loopback             uint8
+	AutoconfigureAddress uint8
+	Publish              uint8
+	Immortal             uint8
+	Age                  uint32
+	Origin               uint32
+}
+
+// getDefaultGateway retrieves the IPv4 default gateway (Windows implementation)
+func getDefaultGateway() (string, error) {
+	return defaultGatewayForFamily(windows.AF_INET)
+}
+
+// getDefaultGatewayIPv6 retrieves the IPv6 default gateway (Windows implementation)
+func getDefaultGatewayIPv6() (string, error) {
+	return defaultGatewayForFamily(windows.AF_INET6)
+}
+
+// defaultGatewayForFamily walks GetIpForwardTable2 and returns the next hop of
+// the lowest-metric default route for the given address family
+func defaultGatewayForFamily(family uint16) (string, error) {
+	var table unsafe.Pointer
+	r0, _, _ := procGetIpForwardTable2.Call(uintptr(family), uintptr(unsafe.Pointer(&table)))
+	if r0 != 0 {
+		return "", fmt.Errorf("GetIpForwardTable2 failed: %w", windows.Errno(r0))
+	}
+	defer procFreeMibTable.Call(uintptr(table))
+
+	// MIB_IPFORWARD_TABLE2: ULONG NumEntries followed by 8-byte aligned rows
+	n := *(*uint32)(table)
+	rows := unsafe.Slice((*mibIPForwardRow2)(unsafe.Add(table, 8)), n)
+
+	best := ""
+	bestMetric := ^uint32(0)
+	for i := range rows {
+		row := &rows[i]
+		if row.DestinationPrefix.PrefixLength != 0 {
+			continue
+		}
+		hop := row.NextHop.ip()
+		if hop == nil || hop.IsUnspecified() {
+			continue
+		}
+		if row.Metric < bestMetric {
+			best = hop.String()
+			bestMetric = row.Metric
+		}
+	}
+
+	if best == "" {
+		return "", fmt.Errorf("gateway not found in route table")
+	}
+	return best, nil
+}
@@ -29,48 +29,37 @@ func getExtendedInterfaceInfo(name string) (speed string, ifaceType string, err
 	return speed, ifaceType, nil
 }
 
-// InterfaceStats holds interface statistics
-type InterfaceStats struct {
-	BytesRx   uint64
-	BytesTx   uint64
-	PacketsRx uint64
-	PacketsTx uint64
-}
-
 // getInterfaceStats retrieves network statistics for an interface on Linux
 func getInterfaceStats(name string) (*InterfaceStats, error) {
-	stats := &InterfaceStats{}
-
 	// Read from /sys/class/net/<interface>/statistics/
-	basePath := filepath.Join("/sys/class/net", name, "statistics")
+	return readInterfaceStats(filepath.Join("/sys/class/net", name, "statistics")), nil
+}
 
-	// Read RX bytes
-	if data, err := os.ReadFile(filepath.Join(basePath, "rx_bytes")); err == nil {
-		if val, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64); err == nil {
-			stats.BytesRx = val
-		}
-	}
+// readInterfaceStats reads counters from a sysfs statistics directory.
+// Missing or unreadable counters are left at zero.
+func readInterfaceStats(basePath string) *InterfaceStats {
+	stats := &InterfaceStats{}
 
-	// Read TX bytes
-	if data, err := os.ReadFile(filepath.Join(basePath, "tx_bytes")); err == nil {
-		if val, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64); err == nil {
-			stats.BytesTx = val
-		}
+	counters := map[string]*uint64{
+		"rx_bytes":   &stats.BytesRx,
+		"tx_bytes":   &stats.BytesTx,
+		"rx_packets": &stats.PacketsRx,
+		"tx_packets": &stats.PacketsTx,
+		"rx_errors":  &stats.RxErrors,
+		"rx_dropped": &stats.RxDropped,
+		"tx_errors":  &stats.TxErrors,
+		"tx_dropped": &stats.TxDropped,
 	}
 
-	// Read RX packets
-	if data, err := os.ReadFile(filepath.Join(basePath, "rx_packets")); err == nil {
-		if val, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64); err == nil {
-			stats.PacketsRx = val
+	for file, dst := range counters {
+		data, err := os.ReadFile(filepath.Join(basePath, file))
+		if err != nil {
+			continue
 		}
-	}
-
-	// Read TX packets
-	if data, err := os.ReadFile(filepath.Join(basePath, "tx_packets")); err == nil {
 		if val, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64); err == nil {
-			stats.PacketsTx = val
+			*dst = val
 		}
 	}
 
-	return stats, nil
+	return stats
 }
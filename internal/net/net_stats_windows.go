@@ -0,0 +1,84 @@
+//go:build windows
+
+package net
+
+import (
+	"fmt"
+	"net"
+
+	"golang.org/x/sys/windows"
+)
+
+// Interface types from ipifcons.h
+const (
+	ifTypeEthernet  = 6
+	ifTypeIEEE80211 = 71
+)
+
+// getExtendedInterfaceInfo returns speed and type
+func getExtendedInterfaceInfo(name string) (speed string, ifaceType string, err error) {
+	speed = "Unknown"
+	ifaceType = "Unknown"
+
+	row, err := getIfEntry(name)
+	if err != nil {
+		return speed, ifaceType, nil
+	}
+
+	if row.TransmitLinkSpeed > 0 {
+		speed = formatLinkSpeed(row.TransmitLinkSpeed)
+	}
+
+	switch row.Type {
+	case ifTypeEthernet:
+		ifaceType = "Ethernet"
+	case ifTypeIEEE80211:
+		ifaceType = "Wi-Fi"
+	}
+
+	return speed, ifaceType, nil
+}
+
+// formatLinkSpeed converts a link speed in bits per second to a display string
+func formatLinkSpeed(bps uint64) string {
+	mbps := bps / 1000000
+	if mbps >= 1000 && mbps%1000 == 0 {
+		return fmt.Sprintf("%d Gbps", mbps/1000)
+	}
+	return fmt.Sprintf("%d Mbps", mbps)
+}
+
+// getInterfaceStats retrieves network statistics for an interface on Windows
+func getInterfaceStats(name string) (*InterfaceStats, error) {
+	stats := &InterfaceStats{}
+
+	row, err := getIfEntry(name)
+	if err != nil {
+		return stats, nil // Return empty stats if the lookup fails
+	}
+
+	stats.BytesRx = row.InOctets
+	stats.BytesTx = row.OutOctets
+	stats.PacketsRx = row.InUcastPkts + row.InNUcastPkts
+	stats.PacketsTx = row.OutUcastPkts + row.OutNUcastPkts
+	stats.RxErrors = row.InErrors
+	stats.RxDropped = row.InDiscards
+	stats.TxErrors = row.OutErrors
+	stats.TxDropped = row.OutDiscards
+
+	return stats, nil
+}
+
+// getIfEntry fetches the MIB_IF_ROW2 for an interface via GetIfEntry2
+func getIfEntry(name string) (*windows.MibIfRow2, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	row := &windows.MibIfRow2{InterfaceIndex: uint32(iface.Index)}
+	if err := windows.GetIfEntry2Ex(windows.MibIfEntryNormal, row); err != nil {
+		return nil, fmt.Errorf("GetIfEntry2 failed for %s: %w", name, err)
+	}
+	return row, nil
+}
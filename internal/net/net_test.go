@@ -23,6 +23,16 @@ destination: default
 			want:    "192.168.1.1",
 			wantErr: false,
 		},
+		{
+			name: "macOS inet6 route output",
+			input: `   route to: ::
+destination: ::
+       mask: default
+    gateway: fe80::1%en0
+  interface: en0`,
+			want:    "fe80::1%en0",
+			wantErr: false,
+		},
 		{
 			name:    "no gateway found",
 			input:   "some random output",
@@ -45,6 +55,21 @@ destination: default
 	}
 }
 
+func TestInterfaceDetailsGateways(t *testing.T) {
+	d := &InterfaceDetails{DefaultGateways: []string{"192.168.1.1", "fe80::1%en0"}}
+	if got := d.IPv4Gateway(); got != "192.168.1.1" {
+		t.Errorf("IPv4Gateway() = %q, want 192.168.1.1", got)
+	}
+	if got := d.IPv6Gateway(); got != "fe80::1%en0" {
+		t.Errorf("IPv6Gateway() = %q, want fe80::1%%en0", got)
+	}
+
+	v6only := &InterfaceDetails{DefaultGateways: []string{"2001:db8::1"}}
+	if got := v6only.IPv4Gateway(); got != "" {
+		t.Errorf("IPv4Gateway() = %q, want empty", got)
+	}
+}
+
 func TestParseScutilDNS(t *testing.T) {
 	data, err := os.ReadFile("testdata/scutil_dns.txt")
 	if err != nil {
@@ -131,3 +156,28 @@ func TestListInterfaces(t *testing.T) {
 		}
 	}
 }
+
+func TestParseNetstatInterface(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "netstat_ibd.txt"))
+	if err != nil {
+		t.Fatalf("failed to read test data: %v", err)
+	}
+
+	stats := parseNetstatInterface(string(data), "en0")
+
+	want := InterfaceStats{
+		BytesRx:   9876543210,
+		BytesTx:   1234567890,
+		PacketsRx: 8123456,
+		PacketsTx: 4567890,
+		RxErrors:  12,
+		TxErrors:  3,
+		TxDropped: 7,
+	}
+	if *stats != want {
+		t.Errorf("parseNetstatInterface() = %+v, want %+v", *stats, want)
+	}
+	if !stats.HasErrors() {
+		t.Error("HasErrors() = false, want true")
+	}
+}
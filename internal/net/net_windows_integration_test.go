@@ -0,0 +1,48 @@
+//go:build windows && integration
+
+package net
+
+import (
+	"net"
+	"testing"
+	"unsafe"
+)
+
+func TestMibIPForwardRow2Size(t *testing.T) {
+	if got := unsafe.Sizeof(mibIPForwardRow2{}); got != 104 {
+		t.Fatalf("sizeof(mibIPForwardRow2) = %d, want 104", got)
+	}
+}
+
+func TestWindowsDefaultGateway(t *testing.T) {
+	gw, err := getDefaultGateway()
+	if err != nil {
+		t.Skipf("no IPv4 default route: %v", err)
+	}
+	if net.ParseIP(gw) == nil {
+		t.Errorf("getDefaultGateway() = %q, not a valid IP", gw)
+	}
+}
+
+func TestWindowsInterfaceStats(t *testing.T) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		t.Fatalf("net.Interfaces() error = %v", err)
+	}
+
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		if _, err := getIfEntry(iface.Name); err != nil {
+			t.Errorf("getIfEntry(%q) error = %v", iface.Name, err)
+		}
+		stats, err := getInterfaceStats(iface.Name)
+		if err != nil {
+			t.Errorf("getInterfaceStats(%q) error = %v", iface.Name, err)
+		}
+		t.Logf("%s: rx=%d tx=%d", iface.Name, stats.BytesRx, stats.BytesTx)
+		return
+	}
+	t.Skip("no active non-loopback interface")
+}
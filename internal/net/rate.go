@@ -0,0 +1,82 @@
+package net
+
+import "time"
+
+// DefaultRateWindow is the number of samples averaged by a RateTracker
+const DefaultRateWindow = 10
+
+// InterfaceRate is the current throughput of an interface in bits per second
+// and, when packet counters were sampled, packets per second
+type InterfaceRate struct {
+	RxBps float64 `json:"rx_bps"`
+	TxBps float64 `json:"tx_bps"`
+	RxPps float64 `json:"rx_pps,omitempty"`
+	TxPps float64 `json:"tx_pps,omitempty"`
+}
+
+// RateBetween computes throughput from two byte counter readings
+func RateBetween(prevRx, prevTx, rx, tx uint64, elapsed time.Duration) InterfaceRate {
+	secs := elapsed.Seconds()
+	if secs <= 0 || rx < prevRx || tx < prevTx {
+		return InterfaceRate{}
+	}
+	return InterfaceRate{
+		RxBps: float64(rx-prevRx) * 8 / secs,
+		TxBps: float64(tx-prevTx) * 8 / secs,
+	}
+}
+
+// RateTracker turns successive byte counter readings into a smoothed rate
+// using a moving average over the last window samples
+type RateTracker struct {
+	window   int
+	samples  []InterfaceRate
+	prevRx   uint64
+	prevTx   uint64
+	prevTime time.Time
+	seeded   bool
+}
+
+// NewRateTracker creates a tracker averaging over window samples
+func NewRateTracker(window int) *RateTracker {
+	if window < 1 {
+		window = 1
+	}
+	return &RateTracker{window: window}
+}
+
+// Update records a counter reading and returns the averaged rate.
+// ok is false until at least two readings have been recorded.
+func (r *RateTracker) Update(bytesRx, bytesTx uint64, at time.Time) (rate InterfaceRate, ok bool) {
+	if !r.seeded || bytesRx < r.prevRx || bytesTx < r.prevTx {
+		// First reading, or counters reset (interface bounced)
+		r.prevRx, r.prevTx, r.prevTime = bytesRx, bytesTx, at
+		r.samples = r.samples[:0]
+		r.seeded = true
+		return InterfaceRate{}, false
+	}
+
+	r.samples = append(r.samples, RateBetween(r.prevRx, r.prevTx, bytesRx, bytesTx, at.Sub(r.prevTime)))
+	if len(r.samples) > r.window {
+		r.samples = r.samples[len(r.samples)-r.window:]
+	}
+	r.prevRx, r.prevTx, r.prevTime = bytesRx, bytesTx, at
+
+	return r.Average(), true
+}
+
+// Average returns the mean of the recorded samples
+func (r *RateTracker) Average() InterfaceRate {
+	if len(r.samples) == 0 {
+		return InterfaceRate{}
+	}
+	var sum InterfaceRate
+	for _, s := range r.samples {
+		sum.RxBps += s.RxBps
+		sum.TxBps += s.TxBps
+		sum.RxPps += s.RxPps
+		sum.TxPps += s.TxPps
+	}
+	n := float64(len(r.samples))
+	return InterfaceRate{RxBps: sum.RxBps / n, TxBps: sum.TxBps / n, RxPps: sum.RxPps / n, TxPps: sum.TxPps / n}
+}
@@ -0,0 +1,127 @@
+package net
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// interfaceStats reads the counters sampled by an InterfaceRateMonitor and
+// monitorNow times each reading; tests replace them
+var (
+	interfaceStats = GetInterfaceStats
+	monitorNow     = time.Now
+)
+
+// InterfaceRateMonitor samples an interface's counters at a fixed interval
+// and delivers the throughput between consecutive samples
+type InterfaceRateMonitor struct {
+	iface    string
+	interval time.Duration
+	read     func(string) (*InterfaceStats, error)
+	now      func() time.Time
+	rates    chan InterfaceRate
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewInterfaceRateMonitor takes a first reading of iface and starts sampling
+// it every interval. Call Stop to end sampling.
+func NewInterfaceRateMonitor(iface string, interval time.Duration) (*InterfaceRateMonitor, error) {
+	if iface == "" {
+		return nil, fmt.Errorf("no interface given")
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("sampling interval must be positive, got %s", interval)
+	}
+
+	m := &InterfaceRateMonitor{
+		iface:    iface,
+		interval: interval,
+		read:     interfaceStats,
+		now:      monitorNow,
+		rates:    make(chan InterfaceRate, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	first, err := m.read(iface)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stats for %s: %w", iface, err)
+	}
+
+	go m.run(first, m.now())
+	return m, nil
+}
+
+// Rates delivers the rate over each sampling interval. Only the newest rate
+// is kept if the reader falls behind. The channel is closed by Stop.
+func (m *InterfaceRateMonitor) Rates() <-chan InterfaceRate {
+	return m.rates
+}
+
+// Stop ends sampling and closes the Rates channel. It is safe to call more
+// than once.
+func (m *InterfaceRateMonitor) Stop() {
+	m.stopOnce.Do(func() { close(m.stop) })
+	<-m.done
+}
+
+// run samples the counters until Stop. Rates are computed over the time
+// between successful readings, which spans several ticks after a failed
+// read and absorbs ticker jitter.
+func (m *InterfaceRateMonitor) run(prev *InterfaceStats, prevAt time.Time) {
+	defer close(m.done)
+	defer close(m.rates)
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+		}
+
+		cur, err := m.read(m.iface)
+		if err != nil {
+			continue
+		}
+		at := m.now()
+		rate, ok := rateOver(prev, cur, at.Sub(prevAt))
+		prev, prevAt = cur, at
+		if !ok {
+			continue
+		}
+
+		select {
+		case m.rates <- rate:
+		default:
+			// Replace the unread rate with the newer one
+			select {
+			case <-m.rates:
+			default:
+			}
+			m.rates <- rate
+		}
+	}
+}
+
+// rateOver computes the rate between two readings taken elapsed apart,
+// adding packet rates to RateBetween's bit rates. ok is false if a counter
+// went backwards (the interface was reset) or no time passed.
+func rateOver(prev, cur *InterfaceStats, elapsed time.Duration) (rate InterfaceRate, ok bool) {
+	if elapsed <= 0 {
+		return InterfaceRate{}, false
+	}
+	if cur.BytesRx < prev.BytesRx || cur.BytesTx < prev.BytesTx ||
+		cur.PacketsRx < prev.PacketsRx || cur.PacketsTx < prev.PacketsTx {
+		return InterfaceRate{}, false
+	}
+	rate = RateBetween(prev.BytesRx, prev.BytesTx, cur.BytesRx, cur.BytesTx, elapsed)
+	secs := elapsed.Seconds()
+	rate.RxPps = float64(cur.PacketsRx-prev.PacketsRx) / secs
+	rate.TxPps = float64(cur.PacketsTx-prev.PacketsTx) / secs
+	return rate, true
+}
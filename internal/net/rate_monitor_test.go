@@ -0,0 +1,154 @@
+package net
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeStats replaces interfaceStats and the monitor's clock. Every reading
+// advances the clock by tick and the counters by step, so traffic is steady;
+// readings for which fail returns true report an error instead.
+func fakeStats(t *testing.T, step InterfaceStats, tick time.Duration, fail func(reading int) bool) {
+	t.Helper()
+	origStats, origNow := interfaceStats, monitorNow
+	t.Cleanup(func() { interfaceStats, monitorNow = origStats, origNow })
+
+	var mu sync.Mutex
+	var cur InterfaceStats
+	var clock time.Time
+	reading := 0
+	interfaceStats = func(string) (*InterfaceStats, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		s := cur
+		n := reading
+		reading++
+		cur.BytesRx += step.BytesRx
+		cur.BytesTx += step.BytesTx
+		cur.PacketsRx += step.PacketsRx
+		cur.PacketsTx += step.PacketsTx
+		clock = clock.Add(tick)
+		if fail != nil && fail(n) {
+			return nil, errors.New("device busy")
+		}
+		return &s, nil
+	}
+	monitorNow = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return clock
+	}
+}
+
+func TestRateOver(t *testing.T) {
+	prev := &InterfaceStats{BytesRx: 1000, BytesTx: 500, PacketsRx: 10, PacketsTx: 5}
+
+	tests := []struct {
+		name    string
+		cur     InterfaceStats
+		elapsed time.Duration
+		want    InterfaceRate
+		wantOK  bool
+	}{
+		{
+			name:    "one second",
+			cur:     InterfaceStats{BytesRx: 2250000, BytesTx: 250500, PacketsRx: 1010, PacketsTx: 205},
+			elapsed: time.Second,
+			want:    InterfaceRate{RxBps: 17992000, TxBps: 2000000, RxPps: 1000, TxPps: 200},
+			wantOK:  true,
+		},
+		{
+			name:    "divides by the interval",
+			cur:     InterfaceStats{BytesRx: 2000, BytesTx: 500, PacketsRx: 30, PacketsTx: 5},
+			elapsed: 2 * time.Second,
+			want:    InterfaceRate{RxBps: 4000, RxPps: 10},
+			wantOK:  true,
+		},
+		{
+			name:    "no time passed",
+			cur:     InterfaceStats{BytesRx: 2000, BytesTx: 500, PacketsRx: 30, PacketsTx: 5},
+			elapsed: 0,
+		},
+		{
+			name:    "counter reset",
+			cur:     InterfaceStats{BytesRx: 10, BytesTx: 600, PacketsRx: 1, PacketsTx: 6},
+			elapsed: time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := rateOver(prev, &tt.cur, tt.elapsed)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("rateOver() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestInterfaceRateMonitor(t *testing.T) {
+	fakeStats(t, InterfaceStats{BytesRx: 1250, BytesTx: 250, PacketsRx: 10, PacketsTx: 2}, 10*time.Millisecond, nil)
+
+	mon, err := NewInterfaceRateMonitor("en0", 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewInterfaceRateMonitor() error = %v", err)
+	}
+
+	select {
+	case rate := <-mon.Rates():
+		want := InterfaceRate{RxBps: 1e6, TxBps: 2e5, RxPps: 1000, TxPps: 200}
+		if rate != want {
+			t.Errorf("rate = %+v, want %+v", rate, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no rate delivered")
+	}
+
+	mon.Stop()
+	mon.Stop()
+	for range mon.Rates() {
+		// Drain any rate sampled before Stop
+	}
+}
+
+func TestInterfaceRateMonitorFailedRead(t *testing.T) {
+	// The second reading fails, so the first rate spans two ticks
+	fakeStats(t, InterfaceStats{BytesRx: 1250, BytesTx: 250, PacketsRx: 10, PacketsTx: 2}, 10*time.Millisecond,
+		func(reading int) bool { return reading == 1 })
+
+	mon, err := NewInterfaceRateMonitor("en0", 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewInterfaceRateMonitor() error = %v", err)
+	}
+	defer mon.Stop()
+
+	select {
+	case rate := <-mon.Rates():
+		want := InterfaceRate{RxBps: 1e6, TxBps: 2e5, RxPps: 1000, TxPps: 200}
+		if rate != want {
+			t.Errorf("rate after a failed read = %+v, want %+v", rate, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no rate delivered")
+	}
+}
+
+func TestInterfaceRateMonitorErrors(t *testing.T) {
+	if _, err := NewInterfaceRateMonitor("", time.Second); err == nil {
+		t.Error("expected an error without an interface")
+	}
+	if _, err := NewInterfaceRateMonitor("en0", 0); err == nil {
+		t.Error("expected an error for a zero interval")
+	}
+
+	orig := interfaceStats
+	defer func() { interfaceStats = orig }()
+	interfaceStats = func(string) (*InterfaceStats, error) {
+		return nil, errors.New("no such device")
+	}
+	if _, err := NewInterfaceRateMonitor("en0", time.Second); err == nil {
+		t.Error("expected the first reading's error")
+	}
+}
@@ -0,0 +1,44 @@
+package net
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateBetween(t *testing.T) {
+	rate := RateBetween(0, 0, 1250000, 250000, time.Second)
+	if rate.RxBps != 10e6 || rate.TxBps != 2e6 {
+		t.Errorf("RateBetween() = %+v, want 10 Mbps / 2 Mbps", rate)
+	}
+
+	if rate := RateBetween(100, 100, 50, 200, time.Second); rate != (InterfaceRate{}) {
+		t.Errorf("expected zero rate on counter reset, got %+v", rate)
+	}
+}
+
+func TestRateTrackerMovingAverage(t *testing.T) {
+	rt := NewRateTracker(2)
+	start := time.Unix(0, 0)
+
+	if _, ok := rt.Update(0, 0, start); ok {
+		t.Fatal("expected first reading to only seed the tracker")
+	}
+
+	// 1000 bytes/s then 3000 bytes/s -> average 2000 bytes/s = 16000 bps
+	rt.Update(1000, 0, start.Add(time.Second))
+	rate, ok := rt.Update(4000, 0, start.Add(2*time.Second))
+	if !ok || rate.RxBps != 16000 {
+		t.Errorf("Update() = %+v (ok=%v), want RxBps 16000", rate, ok)
+	}
+
+	// Window of 2 drops the oldest sample: (3000 + 5000) / 2 bytes/s
+	rate, _ = rt.Update(9000, 0, start.Add(3*time.Second))
+	if rate.RxBps != 32000 {
+		t.Errorf("Update() RxBps = %v, want 32000", rate.RxBps)
+	}
+
+	// Counter reset re-seeds
+	if _, ok := rt.Update(10, 0, start.Add(4*time.Second)); ok {
+		t.Error("expected counter reset to re-seed the tracker")
+	}
+}
@@ -0,0 +1,235 @@
+package net
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Route is one entry of the system routing table. Gateway is empty for
+// routes to directly connected networks.
+type Route struct {
+	Destination string `json:"destination"`
+	Gateway     string `json:"gateway"`
+	Mask        string `json:"mask"`
+	Interface   string `json:"interface"`
+	Metric      int    `json:"metric"`
+}
+
+// GetRoutingTable returns the system routing table
+func GetRoutingTable() ([]Route, error) {
+	return getRoutingTable()
+}
+
+// Prefix returns the destination in CIDR form, or "default" for a default
+// route
+func (r Route) Prefix() string {
+	ip, mask := net.ParseIP(r.Destination), net.ParseIP(r.Mask)
+	if ip == nil || mask == nil {
+		return r.Destination
+	}
+	if ip.To4() != nil {
+		mask = mask.To4()
+	}
+	ones, _ := net.IPMask(mask).Size()
+	if ones == 0 && ip.IsUnspecified() {
+		return "default"
+	}
+	return fmt.Sprintf("%s/%d", r.Destination, ones)
+}
+
+// routesFor returns the routes that leave through iface
+func routesFor(routes []Route, iface string) []Route {
+	var matching []Route
+	for _, r := range routes {
+		if r.Interface == iface {
+			matching = append(matching, r)
+		}
+	}
+	return matching
+}
+
+// parseProcNetRoutes parses /proc/net/route content (Linux). Destination,
+// Gateway and Mask are little-endian hex encoded IPv4 addresses.
+func parseProcNetRoutes(content string) ([]Route, error) {
+	var routes []Route
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// Iface, Destination, Gateway, Flags, RefCnt, Use, Metric, Mask, ...
+		if len(fields) < 8 || fields[0] == "Iface" {
+			continue
+		}
+
+		var addrs [3]net.IP
+		for i, col := range []int{1, 2, 7} {
+			raw, err := hex.DecodeString(fields[col])
+			if err != nil || len(raw) != 4 {
+				return nil, fmt.Errorf("invalid address %q in /proc/net/route", fields[col])
+			}
+			addrs[i] = make(net.IP, 4)
+			binary.BigEndian.PutUint32(addrs[i], binary.LittleEndian.Uint32(raw))
+		}
+		metric, err := strconv.Atoi(fields[6])
+		if err != nil {
+			return nil, fmt.Errorf("invalid metric %q in /proc/net/route: %w", fields[6], err)
+		}
+
+		routes = append(routes, Route{
+			Destination: addrs[0].String(),
+			Gateway:     onLinkGateway(addrs[1]),
+			Mask:        addrs[2].String(),
+			Interface:   fields[0],
+			Metric:      metric,
+		})
+	}
+
+	return routes, scanner.Err()
+}
+
+// parseProcNetIPv6Route parses /proc/net/ipv6_route content (Linux). Each
+// line holds the destination and its prefix length, the source and its
+// prefix length, the next hop, metric, reference count, use count, flags
+// and interface, with addresses and numbers in hex.
+func parseProcNetIPv6Route(content string) ([]Route, error) {
+	var routes []Route
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+
+		dest, err := parseHexIPv6(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		prefix, err := strconv.ParseUint(fields[1], 16, 8)
+		if err != nil || prefix > 128 {
+			return nil, fmt.Errorf("invalid prefix length %q in /proc/net/ipv6_route", fields[1])
+		}
+		nextHop, err := parseHexIPv6(fields[4])
+		if err != nil {
+			return nil, err
+		}
+		metric, err := strconv.ParseUint(fields[5], 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid metric %q in /proc/net/ipv6_route: %w", fields[5], err)
+		}
+
+		routes = append(routes, Route{
+			Destination: dest.String(),
+			Gateway:     onLinkGateway(nextHop),
+			Mask:        net.IP(net.CIDRMask(int(prefix), 128)).String(),
+			Interface:   fields[9],
+			Metric:      int(metric),
+		})
+	}
+
+	return routes, scanner.Err()
+}
+
+// parseHexIPv6 decodes a 32 digit hex IPv6 address
+func parseHexIPv6(s string) (net.IP, error) {
+	raw, err := hex.DecodeString(s)
+	if err != nil || len(raw) != net.IPv6len {
+		return nil, fmt.Errorf("invalid address %q in /proc/net/ipv6_route", s)
+	}
+	return net.IP(raw), nil
+}
+
+// onLinkGateway returns "" for the unspecified address, which marks a
+// directly connected route
+func onLinkGateway(ip net.IP) string {
+	if ip.IsUnspecified() {
+		return ""
+	}
+	return ip.String()
+}
+
+// parseNetstatRoutes parses `netstat -rn -f inet` output (macOS).
+// Destinations may be abbreviated ("192.168.1" for 192.168.1.0/24),
+// gateways of directly connected routes are "link#N" or a MAC address, and
+// no metric is shown.
+func parseNetstatRoutes(output string) ([]Route, error) {
+	var routes []Route
+	netifCol := -1
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "Destination" {
+			for i, f := range fields {
+				if f == "Netif" {
+					netifCol = i
+				}
+			}
+			continue
+		}
+		if netifCol < 0 || len(fields) <= netifCol {
+			continue
+		}
+
+		dest, mask, err := parseNetstatDestination(fields[0], strings.Contains(fields[2], "H"))
+		if err != nil {
+			continue
+		}
+		gateway := ""
+		if ip := net.ParseIP(fields[1]); ip != nil {
+			gateway = onLinkGateway(ip)
+		}
+
+		routes = append(routes, Route{
+			Destination: dest,
+			Gateway:     gateway,
+			Mask:        mask,
+			Interface:   fields[netifCol],
+		})
+	}
+
+	return routes, scanner.Err()
+}
+
+// parseNetstatDestination expands a netstat destination to an address and
+// mask. Without an explicit prefix length, host routes are /32 and other
+// destinations get 8 bits per octet given.
+func parseNetstatDestination(dest string, host bool) (string, string, error) {
+	if dest == "default" {
+		return "0.0.0.0", "0.0.0.0", nil
+	}
+
+	addr, bits, hasBits := strings.Cut(dest, "/")
+	octets := strings.Split(addr, ".")
+	if len(octets) > 4 {
+		return "", "", fmt.Errorf("invalid destination %q", dest)
+	}
+	for len(octets) < 4 {
+		octets = append(octets, "0")
+	}
+	ip := net.ParseIP(strings.Join(octets, ".")).To4()
+	if ip == nil {
+		return "", "", fmt.Errorf("invalid destination %q", dest)
+	}
+
+	prefix := 8 * len(strings.Split(addr, "."))
+	if host {
+		prefix = 32
+	}
+	if hasBits {
+		n, err := strconv.Atoi(bits)
+		if err != nil || n < 0 || n > 32 {
+			return "", "", fmt.Errorf("invalid prefix length in %q", dest)
+		}
+		prefix = n
+	}
+	return ip.String(), net.IP(net.CIDRMask(prefix, 32)).String(), nil
+}
@@ -0,0 +1,15 @@
+//go:build darwin
+
+package net
+
+import "os/exec"
+
+// getRoutingTable reads the IPv4 routes via netstat -rn -f inet (macOS
+// implementation)
+func getRoutingTable() ([]Route, error) {
+	output, err := exec.Command("netstat", "-rn", "-f", "inet").Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseNetstatRoutes(string(output))
+}
@@ -0,0 +1,28 @@
+//go:build linux
+
+package net
+
+import "os"
+
+// getRoutingTable reads the IPv4 and IPv6 routes from /proc/net/route and
+// /proc/net/ipv6_route (Linux implementation)
+func getRoutingTable() ([]Route, error) {
+	data, err := os.ReadFile("/proc/net/route")
+	if err != nil {
+		return nil, err
+	}
+	routes, err := parseProcNetRoutes(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	// IPv6 may be disabled, leaving only the IPv4 table
+	if data, err := os.ReadFile("/proc/net/ipv6_route"); err == nil {
+		v6, err := parseProcNetIPv6Route(string(data))
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, v6...)
+	}
+	return routes, nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package net
+
+import "fmt"
+
+// getRoutingTable is not implemented on this platform
+func getRoutingTable() ([]Route, error) {
+	return nil, fmt.Errorf("routing table not supported on this platform")
+}
@@ -0,0 +1,86 @@
+package net
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// checkGolden compares routes with the JSON golden file name in testdata
+func checkGolden(t *testing.T, name string, routes []Route) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		data, err := json.MarshalIndent(routes, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var want []Route
+	if err := json.Unmarshal([]byte(readFixture(t, name)), &want); err != nil {
+		t.Fatalf("invalid golden file %s: %v", name, err)
+	}
+	if !reflect.DeepEqual(routes, want) {
+		got, _ := json.MarshalIndent(routes, "", "  ")
+		t.Errorf("routes differ from %s, got:\n%s", name, got)
+	}
+}
+
+func TestParseProcNetRoutes(t *testing.T) {
+	routes, err := parseProcNetRoutes(readFixture(t, "proc_net_route.txt"))
+	if err != nil {
+		t.Fatalf("parseProcNetRoutes() error = %v", err)
+	}
+	checkGolden(t, "proc_net_route.golden.json", routes)
+
+	if _, err := parseProcNetRoutes("eth0\tZZ000000\t00000000\t0001\t0\t0\t100\t00FFFFFF\n"); err == nil {
+		t.Error("expected an error for a malformed destination")
+	}
+}
+
+func TestParseProcNetIPv6Route(t *testing.T) {
+	routes, err := parseProcNetIPv6Route(readFixture(t, "proc_net_ipv6_route.txt"))
+	if err != nil {
+		t.Fatalf("parseProcNetIPv6Route() error = %v", err)
+	}
+	checkGolden(t, "proc_net_ipv6_route.golden.json", routes)
+}
+
+func TestParseNetstatRoutes(t *testing.T) {
+	routes, err := parseNetstatRoutes(readFixture(t, "netstat_rn.txt"))
+	if err != nil {
+		t.Fatalf("parseNetstatRoutes() error = %v", err)
+	}
+	checkGolden(t, "netstat_rn.golden.json", routes)
+}
+
+func TestRoutePrefix(t *testing.T) {
+	tests := []struct {
+		route Route
+		want  string
+	}{
+		{Route{Destination: "0.0.0.0", Mask: "0.0.0.0"}, "default"},
+		{Route{Destination: "192.168.1.0", Mask: "255.255.255.0"}, "192.168.1.0/24"},
+		{Route{Destination: "::", Mask: "::"}, "default"},
+		{Route{Destination: "2001:db8:1::", Mask: "ffff:ffff:ffff:ffff::"}, "2001:db8:1::/64"},
+	}
+	for _, tt := range tests {
+		if got := tt.route.Prefix(); got != tt.want {
+			t.Errorf("Prefix(%+v) = %q, want %q", tt.route, got, tt.want)
+		}
+	}
+
+	routes := []Route{{Interface: "en0"}, {Interface: "utun3"}, {Interface: "en0", Metric: 5}}
+	if got := routesFor(routes, "en0"); len(got) != 2 || got[1].Metric != 5 {
+		t.Errorf("routesFor(en0) = %+v", got)
+	}
+}
@@ -0,0 +1,65 @@
+package net
+
+import (
+	"strconv"
+	"strings"
+)
+
+// InterfaceStats holds interface statistics
+type InterfaceStats struct {
+	BytesRx   uint64
+	BytesTx   uint64
+	PacketsRx uint64
+	PacketsTx uint64
+	RxErrors  uint64
+	RxDropped uint64
+	TxErrors  uint64
+	TxDropped uint64
+}
+
+// GetInterfaceStats reads the traffic counters of an interface
+func GetInterfaceStats(name string) (*InterfaceStats, error) {
+	return getInterfaceStats(name)
+}
+
+// HasErrors reports whether any error or drop counter is non-zero
+func (s *InterfaceStats) HasErrors() bool {
+	return s.RxErrors > 0 || s.RxDropped > 0 || s.TxErrors > 0 || s.TxDropped > 0
+}
+
+// parseNetstatInterface parses `netstat -I <iface> -b -d` output (macOS).
+// Format: Name Mtu Network Address Ipkts Ierrs Ibytes Opkts Oerrs Obytes Coll Drop
+// The Address column is blank on some rows, so counters are read from the end.
+func parseNetstatInterface(output, name string) *InterfaceStats {
+	stats := &InterfaceStats{}
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 11 || fields[0] != name {
+			continue
+		}
+
+		tail := fields[len(fields)-8:]
+		counters := []*uint64{
+			&stats.PacketsRx, // Ipkts
+			&stats.RxErrors,  // Ierrs
+			&stats.BytesRx,   // Ibytes
+			&stats.PacketsTx, // Opkts
+			&stats.TxErrors,  // Oerrs
+			&stats.BytesTx,   // Obytes
+			nil,              // Coll
+			&stats.TxDropped, // Drop
+		}
+		for i, dst := range counters {
+			if dst == nil {
+				continue
+			}
+			if val, err := strconv.ParseUint(tail[i], 10, 64); err == nil {
+				*dst = val
+			}
+		}
+		break
+	}
+
+	return stats
+}
@@ -0,0 +1,137 @@
+package net
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/pcap"
+)
+
+// STPFrame is a configuration BPDU heard from a neighboring bridge
+type STPFrame struct {
+	Protocol       string // "STP", "RSTP" or "MSTP"
+	RootBridgeID   string // priority and MAC, e.g. "32769.00:11:22:33:44:55"
+	RootPathCost   uint32
+	BridgeID       string // sender, in the same form as RootBridgeID
+	PortID         uint16
+	MessageAge     uint16 // seconds
+	MaxAge         uint16 // seconds
+	HelloTime      uint16 // seconds
+	ForwardDelay   uint16 // seconds
+	TopologyChange bool
+	Discovered     time.Time
+}
+
+// BPDUs are 802.3 frames to the bridge group address with an LLC header of
+// DSAP/SSAP 0x42 and control 0x03; the BPDU follows at offset 17
+const (
+	stpLLCOffset  = 14
+	stpBPDUOffset = 17
+	stpBPDULen    = 35 // configuration BPDU, also the start of RST/MST BPDUs
+)
+
+// STP BPDU types
+const (
+	stpTypeConfig = 0x00
+	stpTypeRST    = 0x02
+)
+
+var stpGroupAddress = []byte{0x01, 0x80, 0xc2, 0x00, 0x00, 0x00}
+
+// ListenSTP passively collects spanning tree BPDUs on iface for duration
+// and returns the latest from each sending bridge port
+func ListenSTP(iface string, duration time.Duration) ([]STPFrame, error) {
+	handle, err := pcap.OpenLive(iface, 1600, true, pcap.BlockForever)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open interface %s: %w (requires sudo/root)", iface, err)
+	}
+	defer handle.Close()
+
+	if err := handle.SetBPFFilter("ether dst 01:80:c2:00:00:00"); err != nil {
+		return nil, fmt.Errorf("failed to set STP filter: %w", err)
+	}
+
+	frames := make(map[string]STPFrame)
+	packetSource := gopacket.NewPacketSource(handle, handle.LinkType())
+	timeout := time.After(duration)
+	packetChan := packetSource.Packets()
+
+	for {
+		select {
+		case <-timeout:
+			result := make([]STPFrame, 0, len(frames))
+			for _, f := range frames {
+				result = append(result, f)
+			}
+			sort.Slice(result, func(i, j int) bool {
+				if result[i].BridgeID != result[j].BridgeID {
+					return result[i].BridgeID < result[j].BridgeID
+				}
+				return result[i].PortID < result[j].PortID
+			})
+			return result, nil
+
+		case packet := <-packetChan:
+			if packet == nil {
+				continue
+			}
+			if frame := parseSTPFrame(packet.Data()); frame != nil {
+				frames[fmt.Sprintf("%s:%d", frame.BridgeID, frame.PortID)] = *frame
+			}
+		}
+	}
+}
+
+// parseSTPFrame extracts a configuration or RST/MST BPDU from a raw Ethernet
+// frame, or returns nil for anything else, including topology change
+// notifications
+func parseSTPFrame(data []byte) *STPFrame {
+	if len(data) < stpBPDUOffset+stpBPDULen {
+		return nil
+	}
+	if !bytes.Equal(data[:6], stpGroupAddress) || !bytes.Equal(data[stpLLCOffset:stpBPDUOffset], []byte{0x42, 0x42, 0x03}) {
+		return nil
+	}
+	bpdu := data[stpBPDUOffset:]
+	if binary.BigEndian.Uint16(bpdu[0:2]) != 0 {
+		return nil
+	}
+
+	var protocol string
+	switch {
+	case bpdu[3] == stpTypeConfig:
+		protocol = "STP"
+	case bpdu[3] == stpTypeRST && bpdu[2] == 2:
+		protocol = "RSTP"
+	case bpdu[3] == stpTypeRST && bpdu[2] >= 3:
+		protocol = "MSTP"
+	default:
+		return nil
+	}
+
+	return &STPFrame{
+		Protocol:       protocol,
+		TopologyChange: bpdu[4]&0x01 != 0,
+		RootBridgeID:   formatBridgeID(bpdu[5:13]),
+		RootPathCost:   binary.BigEndian.Uint32(bpdu[13:17]),
+		BridgeID:       formatBridgeID(bpdu[17:25]),
+		PortID:         binary.BigEndian.Uint16(bpdu[25:27]),
+		// Timers are in 1/256ths of a second
+		MessageAge:   binary.BigEndian.Uint16(bpdu[27:29]) / 256,
+		MaxAge:       binary.BigEndian.Uint16(bpdu[29:31]) / 256,
+		HelloTime:    binary.BigEndian.Uint16(bpdu[31:33]) / 256,
+		ForwardDelay: binary.BigEndian.Uint16(bpdu[33:35]) / 256,
+		Discovered:   time.Now(),
+	}
+}
+
+// formatBridgeID renders an 8-byte bridge ID as its priority (including
+// the system ID extension) and MAC
+func formatBridgeID(id []byte) string {
+	return fmt.Sprintf("%d.%s", binary.BigEndian.Uint16(id[0:2]), net.HardwareAddr(id[2:8]))
+}
@@ -0,0 +1,75 @@
+package net
+
+import "testing"
+
+// stpHeader is the 802.3 and LLC header of a BPDU from 00:1b:21:aa:bb:cc
+var stpHeader = []byte{
+	0x01, 0x80, 0xc2, 0x00, 0x00, 0x00, // bridge group address
+	0x00, 0x1b, 0x21, 0xaa, 0xbb, 0xcc,
+	0x00, 0x26, // 802.3 length
+	0x42, 0x42, 0x03, // LLC
+}
+
+func TestParseSTPFrame(t *testing.T) {
+	config := append(append([]byte(nil), stpHeader...),
+		0x00, 0x00, // protocol ID
+		0x00,                                           // version: STP
+		0x00,                                           // type: configuration
+		0x01,                                           // flags: topology change
+		0x80, 0x01, 0x00, 0x11, 0x22, 0x33, 0x44, 0x55, // root: 32769, 00:11:22:33:44:55
+		0x00, 0x00, 0x00, 0x04, // root path cost
+		0x80, 0x01, 0x00, 0x1b, 0x21, 0xaa, 0xbb, 0x00, // bridge: 32769, 00:1b:21:aa:bb:00
+		0x80, 0x02, // port ID
+		0x01, 0x00, // message age 1s
+		0x14, 0x00, // max age 20s
+		0x02, 0x00, // hello 2s
+		0x0f, 0x00, // forward delay 15s
+	)
+
+	f := parseSTPFrame(config)
+	if f == nil {
+		t.Fatal("parseSTPFrame returned nil for a configuration BPDU")
+	}
+	if f.Protocol != "STP" || !f.TopologyChange {
+		t.Errorf("Protocol = %q, TopologyChange = %v", f.Protocol, f.TopologyChange)
+	}
+	if f.RootBridgeID != "32769.00:11:22:33:44:55" || f.BridgeID != "32769.00:1b:21:aa:bb:00" {
+		t.Errorf("RootBridgeID = %q, BridgeID = %q", f.RootBridgeID, f.BridgeID)
+	}
+	if f.RootPathCost != 4 || f.PortID != 0x8002 {
+		t.Errorf("RootPathCost = %d, PortID = %#x", f.RootPathCost, f.PortID)
+	}
+	if f.MessageAge != 1 || f.MaxAge != 20 || f.HelloTime != 2 || f.ForwardDelay != 15 {
+		t.Errorf("timers = %d/%d/%d/%d, want 1/20/2/15", f.MessageAge, f.MaxAge, f.HelloTime, f.ForwardDelay)
+	}
+
+	// An RST BPDU from the root itself, with the version 1 length byte
+	rst := append(append([]byte(nil), stpHeader...),
+		0x00, 0x00, 0x02, 0x02, 0x3c,
+		0x10, 0x00, 0x00, 0x11, 0x22, 0x33, 0x44, 0x55,
+		0x00, 0x00, 0x00, 0x00,
+		0x10, 0x00, 0x00, 0x11, 0x22, 0x33, 0x44, 0x55,
+		0x80, 0x01,
+		0x00, 0x00, 0x14, 0x00, 0x02, 0x00, 0x0f, 0x00,
+		0x00,
+	)
+	f = parseSTPFrame(rst)
+	if f == nil || f.Protocol != "RSTP" || f.TopologyChange || f.RootBridgeID != "4096.00:11:22:33:44:55" || f.RootPathCost != 0 {
+		t.Errorf("parseSTPFrame(rst) = %+v", f)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"topology change notification", append(append([]byte(nil), stpHeader...), 0x00, 0x00, 0x00, 0x80)},
+		{"truncated", config[:40]},
+		{"wrong destination", append([]byte{0x01, 0x80, 0xc2, 0x00, 0x00, 0x0e}, config[6:]...)},
+		{"not LLC 0x42", append(append(append([]byte(nil), config[:14]...), 0xaa, 0xaa, 0x03), config[17:]...)},
+	}
+	for _, tt := range tests {
+		if f := parseSTPFrame(tt.data); f != nil {
+			t.Errorf("%s: parseSTPFrame = %+v, want nil", tt.name, f)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package net
+
+import (
+	"context"
+	"time"
+)
+
+// watchDebounce coalesces bursts of link events (an adapter being plugged in
+// typically produces several) into a single refresh
+const watchDebounce = 500 * time.Millisecond
+
+// WatchInterfaces delivers the user-selectable interface list (as returned by
+// ListUserInterfaces) whenever an interface is added, removed, or changes
+// link state. The channel is closed when ctx is cancelled.
+func WatchInterfaces(ctx context.Context) (<-chan []Iface, error) {
+	events, err := watchLinkEvents(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	last, _ := ListUserInterfaces()
+	out := make(chan []Iface, 1)
+
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-events:
+				if !ok {
+					return
+				}
+			}
+
+			// Drain any events that arrive during the debounce window
+			timer := time.NewTimer(watchDebounce)
+		drain:
+			for {
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-events:
+				case <-timer.C:
+					break drain
+				}
+			}
+
+			ifaces, err := ListUserInterfaces()
+			if err != nil || !interfacesChanged(last, ifaces) {
+				continue
+			}
+			last = ifaces
+
+			select {
+			case out <- ifaces:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// interfacesChanged reports whether two interface lists differ in membership,
+// addressing, or link state. Traffic counters are ignored.
+func interfacesChanged(a, b []Iface) bool {
+	if len(a) != len(b) {
+		return true
+	}
+	for i := range a {
+		if a[i].Name != b[i].Name ||
+			a[i].HardwareAddr != b[i].HardwareAddr ||
+			a[i].MTU != b[i].MTU ||
+			a[i].Flags != b[i].Flags {
+			return true
+		}
+	}
+	return false
+}
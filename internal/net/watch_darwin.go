@@ -0,0 +1,51 @@
+//go:build darwin
+
+package net
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+)
+
+// watchLinkEvents listens on a PF_ROUTE socket for interface notifications.
+// This is the kernel feed SystemConfiguration's network change events are
+// built on, and avoids pulling in cgo.
+func watchLinkEvents(ctx context.Context) (<-chan struct{}, error) {
+	fd, err := syscall.Socket(syscall.AF_ROUTE, syscall.SOCK_RAW, syscall.AF_UNSPEC)
+	if err != nil {
+		return nil, fmt.Errorf("route socket: %w", err)
+	}
+
+	// A receive timeout lets the reader notice ctx cancellation
+	tv := syscall.Timeval{Sec: 1}
+	if err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("route socket timeout: %w", err)
+	}
+
+	events := make(chan struct{}, 1)
+	go func() {
+		defer close(events)
+		defer syscall.Close(fd)
+
+		buf := make([]byte, 2048)
+		for ctx.Err() == nil {
+			n, err := syscall.Read(fd, buf)
+			if err != nil || n < 4 {
+				continue
+			}
+
+			// rt_msghdr: msglen (2), version (1), type (1)
+			switch buf[3] {
+			case syscall.RTM_IFINFO, syscall.RTM_NEWADDR, syscall.RTM_DELADDR:
+				select {
+				case events <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
@@ -0,0 +1,36 @@
+//go:build !linux && !darwin
+
+package net
+
+import (
+	"context"
+	"time"
+)
+
+// watchPollInterval is how often the interface list is re-read on platforms
+// without a link notification source
+const watchPollInterval = 5 * time.Second
+
+// watchLinkEvents polls on a ticker; WatchInterfaces suppresses no-op updates
+func watchLinkEvents(ctx context.Context) (<-chan struct{}, error) {
+	events := make(chan struct{}, 1)
+	go func() {
+		defer close(events)
+		ticker := time.NewTicker(watchPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				select {
+				case events <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
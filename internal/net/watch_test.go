@@ -0,0 +1,75 @@
+package net
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestInterfacesChanged(t *testing.T) {
+	base := []Iface{
+		{Name: "en0", HardwareAddr: "aa:bb:cc:dd:ee:ff", MTU: 1500, Flags: net.FlagUp, BytesRx: 10},
+	}
+
+	tests := []struct {
+		name  string
+		other []Iface
+		want  bool
+	}{
+		{
+			name:  "identical apart from counters",
+			other: []Iface{{Name: "en0", HardwareAddr: "aa:bb:cc:dd:ee:ff", MTU: 1500, Flags: net.FlagUp, BytesRx: 9999}},
+			want:  false,
+		},
+		{
+			name:  "link went down",
+			other: []Iface{{Name: "en0", HardwareAddr: "aa:bb:cc:dd:ee:ff", MTU: 1500}},
+			want:  true,
+		},
+		{
+			name: "adapter added",
+			other: []Iface{
+				{Name: "en0", HardwareAddr: "aa:bb:cc:dd:ee:ff", MTU: 1500, Flags: net.FlagUp},
+				{Name: "en7", HardwareAddr: "11:22:33:44:55:66", MTU: 1500, Flags: net.FlagUp},
+			},
+			want: true,
+		},
+		{
+			name:  "adapter removed",
+			other: nil,
+			want:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := interfacesChanged(base, tt.other); got != tt.want {
+				t.Errorf("interfacesChanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatchInterfacesClosesOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ch, err := WatchInterfaces(ctx)
+	if err != nil {
+		cancel()
+		t.Skipf("link watching unavailable: %v", err)
+	}
+	cancel()
+
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("channel not closed after cancel")
+		}
+	}
+}
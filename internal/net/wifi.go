@@ -0,0 +1,179 @@
+package net
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// WifiInfo contains association details for a wireless interface
+type WifiInfo struct {
+	SSID     string
+	BSSID    string
+	RSSI     int // dBm
+	Channel  int
+	Standard string  // e.g. "802.11ac"
+	TxRate   float64 // Mbps, last transmit rate
+}
+
+// GetWifiInfo returns wireless association details for an interface.
+// It returns an error if the interface is not wireless or not associated.
+func GetWifiInfo(name string) (*WifiInfo, error) {
+	return getWifiInfo(name)
+}
+
+// parseAirportInfo parses `airport -I` output (macOS)
+func parseAirportInfo(output string) (*WifiInfo, error) {
+	info := &WifiInfo{}
+	found := false
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "SSID":
+			info.SSID = value
+			found = true
+		case "BSSID":
+			info.BSSID = value
+		case "agrCtlRSSI":
+			info.RSSI, _ = strconv.Atoi(value)
+		case "channel":
+			// e.g. "36,80" (channel, width)
+			ch, _, _ := strings.Cut(value, ",")
+			info.Channel, _ = strconv.Atoi(ch)
+		case "lastTxRate":
+			info.TxRate, _ = strconv.ParseFloat(value, 64)
+		case "AirPort":
+			if value == "Off" {
+				return nil, fmt.Errorf("wi-fi is off")
+			}
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("not associated")
+	}
+	return info, nil
+}
+
+// parseWifiPorts returns the devices listed as the Wi-Fi hardware port in
+// `networksetup -listallhardwareports` output (macOS). Older releases name
+// the port "AirPort".
+func parseWifiPorts(output string) []string {
+	var devices []string
+	port := ""
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "Hardware Port":
+			port = strings.TrimSpace(value)
+		case "Device":
+			if port == "Wi-Fi" || port == "AirPort" {
+				devices = append(devices, strings.TrimSpace(value))
+			}
+			port = ""
+		}
+	}
+	return devices
+}
+
+var iwChannelRe = regexp.MustCompile(`channel\s+(\d+)`)
+
+// parseIwInfo parses `iw dev <iface> info` output (Linux)
+func parseIwInfo(output string, info *WifiInfo) {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "ssid "):
+			info.SSID = strings.TrimPrefix(line, "ssid ")
+		case strings.HasPrefix(line, "channel "):
+			if m := iwChannelRe.FindStringSubmatch(line); len(m) == 2 {
+				info.Channel, _ = strconv.Atoi(m[1])
+			}
+		}
+	}
+}
+
+var (
+	iwConnectedRe = regexp.MustCompile(`^Connected to ([0-9a-fA-F:]{17})`)
+	iwSignalRe    = regexp.MustCompile(`signal:\s+(-?\d+)\s+dBm`)
+	iwBitrateRe   = regexp.MustCompile(`tx bitrate:\s+([\d.]+)\s+MBit/s(.*)`)
+)
+
+// parseIwLink parses `iw dev <iface> link` output (Linux)
+func parseIwLink(output string, info *WifiInfo) error {
+	if strings.HasPrefix(strings.TrimSpace(output), "Not connected") {
+		return fmt.Errorf("not associated")
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if m := iwConnectedRe.FindStringSubmatch(line); len(m) == 2 {
+			info.BSSID = m[1]
+		}
+		if info.SSID == "" && strings.HasPrefix(line, "SSID: ") {
+			info.SSID = strings.TrimPrefix(line, "SSID: ")
+		}
+		if m := iwSignalRe.FindStringSubmatch(line); len(m) == 2 && info.RSSI == 0 {
+			info.RSSI, _ = strconv.Atoi(m[1])
+		}
+		if m := iwBitrateRe.FindStringSubmatch(line); len(m) == 3 {
+			info.TxRate, _ = strconv.ParseFloat(m[1], 64)
+			info.Standard = standardFromBitrateFlags(m[2])
+		}
+	}
+	return nil
+}
+
+// standardFromBitrateFlags infers the PHY standard from iw's tx bitrate flags
+func standardFromBitrateFlags(flags string) string {
+	switch {
+	case strings.Contains(flags, "EHT-MCS"):
+		return "802.11be"
+	case strings.Contains(flags, "HE-MCS"):
+		return "802.11ax"
+	case strings.Contains(flags, "VHT-MCS"):
+		return "802.11ac"
+	case strings.Contains(flags, "MCS"):
+		return "802.11n"
+	}
+	return ""
+}
+
+// parseProcNetWireless returns the signal level (dBm) for an interface from
+// /proc/net/wireless content
+func parseProcNetWireless(content, name string) (int, error) {
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		iface, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok || strings.TrimSpace(iface) != name {
+			continue
+		}
+		// Fields: status link level noise ...
+		fields := strings.Fields(rest)
+		if len(fields) < 3 {
+			break
+		}
+		level, err := strconv.ParseFloat(strings.TrimSuffix(fields[2], "."), 64)
+		if err != nil {
+			return 0, err
+		}
+		return int(level), nil
+	}
+	return 0, fmt.Errorf("%s not found in /proc/net/wireless", name)
+}
@@ -0,0 +1,29 @@
+//go:build darwin
+
+package net
+
+import (
+	"fmt"
+	"os/exec"
+	"slices"
+)
+
+const airportPath = "/System/Library/PrivateFrameworks/Apple80211.framework/Versions/Current/Resources/airport"
+
+// getWifiInfo reads association details via airport -I (macOS implementation).
+// airport only reports the Wi-Fi port, so other interfaces are rejected.
+func getWifiInfo(name string) (*WifiInfo, error) {
+	ports, err := exec.Command("networksetup", "-listallhardwareports").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hardware ports: %w", err)
+	}
+	if !slices.Contains(parseWifiPorts(string(ports)), name) {
+		return nil, fmt.Errorf("%s is not a wireless interface", name)
+	}
+
+	output, err := exec.Command(airportPath, "-I").Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseAirportInfo(string(output))
+}
@@ -0,0 +1,39 @@
+//go:build linux
+
+package net
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// getWifiInfo reads association details via iw and /proc/net/wireless (Linux implementation)
+func getWifiInfo(name string) (*WifiInfo, error) {
+	if _, err := os.Stat(filepath.Join("/sys/class/net", name, "wireless")); err != nil {
+		return nil, fmt.Errorf("%s is not a wireless interface", name)
+	}
+
+	info := &WifiInfo{}
+
+	if output, err := exec.Command("iw", "dev", name, "info").Output(); err == nil {
+		parseIwInfo(string(output), info)
+	}
+
+	if data, err := os.ReadFile("/proc/net/wireless"); err == nil {
+		if level, err := parseProcNetWireless(string(data), name); err == nil {
+			info.RSSI = level
+		}
+	}
+
+	output, err := exec.Command("iw", "dev", name, "link").Output()
+	if err != nil {
+		return nil, err
+	}
+	if err := parseIwLink(string(output), info); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
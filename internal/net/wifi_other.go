@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package net
+
+import "fmt"
+
+// getWifiInfo is not implemented on this platform
+func getWifiInfo(name string) (*WifiInfo, error) {
+	return nil, fmt.Errorf("wi-fi details not supported on this platform")
+}
@@ -0,0 +1,91 @@
+package net
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read test data: %v", err)
+	}
+	return string(data)
+}
+
+func TestParseAirportInfo(t *testing.T) {
+	info, err := parseAirportInfo(readFixture(t, "airport_info.txt"))
+	if err != nil {
+		t.Fatalf("parseAirportInfo() error = %v", err)
+	}
+
+	want := WifiInfo{
+		SSID:    "OfficeNet",
+		BSSID:   "a0:b1:c2:d3:e4:f5",
+		RSSI:    -58,
+		Channel: 36,
+		TxRate:  866,
+	}
+	if *info != want {
+		t.Errorf("parseAirportInfo() = %+v, want %+v", *info, want)
+	}
+
+	if _, err := parseAirportInfo("AirPort: Off\n"); err == nil {
+		t.Error("expected error when Wi-Fi is off")
+	}
+}
+
+func TestParseWifiPorts(t *testing.T) {
+	got := parseWifiPorts(readFixture(t, "networksetup_hardwareports.txt"))
+	if want := []string{"en1"}; !slices.Equal(got, want) {
+		t.Errorf("parseWifiPorts() = %v, want %v", got, want)
+	}
+
+	got = parseWifiPorts("Hardware Port: AirPort\nDevice: en1\n")
+	if want := []string{"en1"}; !slices.Equal(got, want) {
+		t.Errorf("parseWifiPorts(AirPort) = %v, want %v", got, want)
+	}
+}
+
+func TestParseIwOutput(t *testing.T) {
+	info := &WifiInfo{}
+	parseIwInfo(readFixture(t, "iw_info.txt"), info)
+	if err := parseIwLink(readFixture(t, "iw_link.txt"), info); err != nil {
+		t.Fatalf("parseIwLink() error = %v", err)
+	}
+
+	want := WifiInfo{
+		SSID:     "OfficeNet",
+		BSSID:    "a0:b1:c2:d3:e4:f5",
+		RSSI:     -61,
+		Channel:  36,
+		Standard: "802.11ac",
+		TxRate:   866.7,
+	}
+	if *info != want {
+		t.Errorf("parsed = %+v, want %+v", *info, want)
+	}
+
+	if err := parseIwLink("Not connected.\n", &WifiInfo{}); err == nil {
+		t.Error("expected error when not connected")
+	}
+}
+
+func TestParseProcNetWireless(t *testing.T) {
+	content := readFixture(t, "proc_net_wireless.txt")
+
+	level, err := parseProcNetWireless(content, "wlan0")
+	if err != nil {
+		t.Fatalf("parseProcNetWireless() error = %v", err)
+	}
+	if level != -61 {
+		t.Errorf("parseProcNetWireless() = %d, want -61", level)
+	}
+
+	if _, err := parseProcNetWireless(content, "wlan1"); err == nil {
+		t.Error("expected error for missing interface")
+	}
+}
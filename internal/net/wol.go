@@ -0,0 +1,62 @@
+package net
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+)
+
+// DefaultWoLBroadcast is where magic packets go when no address is given
+const DefaultWoLBroadcast = "255.255.255.255:9"
+
+// wolPort is the discard port magic packets are conventionally sent to
+const wolPort = "9"
+
+// SendWakeOnLAN sends a Wake-on-LAN magic packet for mac as a UDP
+// broadcast. broadcast is an address with an optional port, such as
+// "192.168.1.255" or "192.168.1.255:7"; empty means 255.255.255.255:9.
+func SendWakeOnLAN(mac string, broadcast string) error {
+	hw, err := net.ParseMAC(mac)
+	if err != nil {
+		return fmt.Errorf("invalid MAC address %q: %w", mac, err)
+	}
+	if len(hw) != 6 {
+		return fmt.Errorf("invalid MAC address %q: Wake-on-LAN needs a 6-byte Ethernet address", mac)
+	}
+
+	addr := wolAddress(broadcast)
+	raddr, err := net.ResolveUDPAddr("udp4", addr)
+	if err != nil {
+		return fmt.Errorf("invalid broadcast address %q: %w", broadcast, err)
+	}
+	conn, err := net.DialUDP("udp4", nil, raddr)
+	if err != nil {
+		return fmt.Errorf("failed to open UDP socket to %s: %w", addr, err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write(magicPacket(hw)); err != nil {
+		return fmt.Errorf("failed to send magic packet to %s: %w", addr, err)
+	}
+	return nil
+}
+
+// wolAddress adds the default port to a broadcast address without one
+func wolAddress(broadcast string) string {
+	if broadcast == "" {
+		return DefaultWoLBroadcast
+	}
+	if _, _, err := net.SplitHostPort(broadcast); err == nil {
+		return broadcast
+	}
+	return net.JoinHostPort(broadcast, wolPort)
+}
+
+// magicPacket returns 6 bytes of 0xFF followed by mac 16 times
+func magicPacket(mac net.HardwareAddr) []byte {
+	packet := bytes.Repeat([]byte{0xff}, 6)
+	for i := 0; i < 16; i++ {
+		packet = append(packet, mac...)
+	}
+	return packet
+}
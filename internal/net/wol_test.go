@@ -0,0 +1,64 @@
+package net
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSendWakeOnLAN(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Skipf("cannot listen on UDP: %v", err)
+	}
+	defer conn.Close()
+
+	if err := SendWakeOnLAN("00-11-22-AA-BB-CC", conn.LocalAddr().String()); err != nil {
+		t.Fatalf("SendWakeOnLAN() error = %v", err)
+	}
+
+	buf := make([]byte, 256)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("no packet received: %v", err)
+	}
+	got := buf[:n]
+	if len(got) != 102 {
+		t.Fatalf("packet is %d bytes, want 102", len(got))
+	}
+	if !bytes.Equal(got[:6], []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}) {
+		t.Errorf("packet starts with %x, want six 0xff bytes", got[:6])
+	}
+	mac := []byte{0x00, 0x11, 0x22, 0xaa, 0xbb, 0xcc}
+	for i := 0; i < 16; i++ {
+		if rep := got[6+i*6 : 12+i*6]; !bytes.Equal(rep, mac) {
+			t.Fatalf("repetition %d = %x, want %x", i, rep, mac)
+		}
+	}
+}
+
+func TestSendWakeOnLANRejectsBadMAC(t *testing.T) {
+	for _, mac := range []string{"", "00:11:22:33:44", "zz:11:22:33:44:55", "00:00:5e:10:00:00:00:01"} {
+		if err := SendWakeOnLAN(mac, "127.0.0.1:9"); err == nil {
+			t.Errorf("SendWakeOnLAN(%q) should fail", mac)
+		}
+	}
+}
+
+func TestWoLAddress(t *testing.T) {
+	tests := []struct {
+		broadcast string
+		want      string
+	}{
+		{"", "255.255.255.255:9"},
+		{"192.168.1.255", "192.168.1.255:9"},
+		{"192.168.1.255:7", "192.168.1.255:7"},
+	}
+	for _, tt := range tests {
+		if got := wolAddress(tt.broadcast); got != tt.want {
+			t.Errorf("wolAddress(%q) = %q, want %q", tt.broadcast, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+//go:build ignore
+
+// gen_oui downloads the IEEE MA-L registry into oui.csv. Run it with
+// "go generate ./internal/oui".
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const registryURL = "https://standards-oui.ieee.org/oui/oui.csv"
+
+// minRecords guards against saving an error page or a truncated download;
+// the registry has well over 30,000 assignments
+const minRecords = 30000
+
+func main() {
+	if err := run("oui.csv"); err != nil {
+		fmt.Fprintln(os.Stderr, "gen_oui:", err)
+		os.Exit(1)
+	}
+}
+
+func run(path string) error {
+	data, err := fetch(registryURL)
+	if err != nil {
+		return err
+	}
+	if err := validate(data); err != nil {
+		return err
+	}
+
+	// Write beside the target and rename, so a failed run keeps the old file
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".oui-*.csv")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write registry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write registry: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write registry: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func fetch(url string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	// The IEEE server rejects requests without a browser-like user agent
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; lanaudit-gen-oui)")
+
+	client := &http.Client{Timeout: 2 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	return data, nil
+}
+
+// validate checks data is the MA-L CSV and includes Cisco's first OUI
+func validate(data []byte) error {
+	cr := csv.NewReader(bytes.NewReader(data))
+	cr.FieldsPerRecord = -1
+	records, err := cr.ReadAll()
+	if err != nil {
+		return fmt.Errorf("failed to parse registry: %w", err)
+	}
+	if len(records) == 0 || len(records[0]) < 3 || records[0][1] != "Assignment" {
+		return fmt.Errorf("unexpected registry header")
+	}
+	if len(records) < minRecords {
+		return fmt.Errorf("registry has only %d records, want at least %d", len(records), minRecords)
+	}
+	for _, rec := range records[1:] {
+		if len(rec) >= 2 && rec[1] == "00000C" {
+			return nil
+		}
+	}
+	return fmt.Errorf("registry is missing 00000C (Cisco)")
+}
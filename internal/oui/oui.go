@@ -0,0 +1,114 @@
+package oui
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Unknown is returned for prefixes missing from the registry
+const Unknown = "Unknown"
+
+// registryCSV is in the IEEE MA-L CSV format (Registry, Assignment,
+// Organization Name, Organization Address). "go generate" replaces it with
+// the full registry from https://standards-oui.ieee.org/oui/oui.csv.
+//
+//go:generate go run gen_oui.go
+//go:embed oui.csv
+var registryCSV []byte
+
+var (
+	loadOnce sync.Once
+	vendors  map[string]string // "00000C" -> "Cisco Systems"
+	loadErr  error
+)
+
+// Lookup returns the vendor registered for a MAC address's first three
+// octets, or Unknown. mac may be a full address or just the prefix, with
+// ':', '-' or '.' separators.
+func Lookup(mac string) (string, error) {
+	prefix, err := prefixOf(mac)
+	if err != nil {
+		return "", err
+	}
+	loadOnce.Do(func() {
+		vendors, loadErr = parseRegistry(bytes.NewReader(registryCSV))
+	})
+	if loadErr != nil {
+		return "", loadErr
+	}
+	if v, ok := vendors[prefix]; ok {
+		return v, nil
+	}
+	return Unknown, nil
+}
+
+// prefixOf returns the first three octets of mac as six upper-case hex
+// digits
+func prefixOf(mac string) (string, error) {
+	digits := strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '-', '.':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(mac))
+	if len(digits) < 6 || len(digits) > 12 || len(digits)%2 != 0 {
+		return "", fmt.Errorf("invalid MAC address %q", mac)
+	}
+	for _, r := range digits {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return "", fmt.Errorf("invalid MAC address %q", mac)
+		}
+	}
+	return strings.ToUpper(digits[:6]), nil
+}
+
+// parseRegistry reads an IEEE OUI CSV into a map of prefix to vendor
+func parseRegistry(r io.Reader) (map[string]string, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OUI registry: %w", err)
+	}
+	m := make(map[string]string, len(records))
+	for i, rec := range records {
+		if i == 0 && len(rec) > 1 && rec[1] == "Assignment" {
+			continue
+		}
+		if len(rec) < 3 || len(rec[1]) != 6 {
+			continue
+		}
+		m[strings.ToUpper(rec[1])] = cleanName(rec[2])
+	}
+	return m, nil
+}
+
+// corporateSuffixes are dropped from the end of registered names
+var corporateSuffixes = map[string]bool{
+	"inc": true, "inc.": true, "incorporated": true,
+	"ltd": true, "ltd.": true, "co.,ltd": true, "co.,ltd.": true,
+	"corp": true, "corp.": true, "llc": true, "gmbh": true, "ab": true, "bv": true,
+}
+
+// cleanName trims corporate suffixes, so "Cisco Systems, Inc" becomes
+// "Cisco Systems"
+func cleanName(name string) string {
+	words := strings.Fields(name)
+	for len(words) > 1 {
+		last := strings.ToLower(strings.TrimSuffix(words[len(words)-1], ","))
+		if !corporateSuffixes[last] {
+			break
+		}
+		words = words[:len(words)-1]
+	}
+	if len(words) > 0 {
+		words[len(words)-1] = strings.TrimSuffix(words[len(words)-1], ",")
+	}
+	return strings.Join(words, " ")
+}
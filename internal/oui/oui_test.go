@@ -0,0 +1,49 @@
+package oui
+
+import "testing"
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		mac     string
+		want    string
+		wantErr bool
+	}{
+		{mac: "00:00:0C", want: "Cisco Systems"},
+		{mac: "00:00:0c:9f:f0:01", want: "Cisco Systems"},
+		{mac: "b8-27-eb-12-34-56", want: "Raspberry Pi Foundation"},
+		{mac: "0050.5612.3456", want: "VMware"},
+		{mac: "02:00:00:00:00:01", want: Unknown},
+		{mac: "00:00", wantErr: true},
+		{mac: "zz:00:0c", wantErr: true},
+		{mac: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Lookup(tt.mac)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Lookup(%q) error = %v, wantErr %v", tt.mac, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Lookup(%q) = %q, want %q", tt.mac, got, tt.want)
+		}
+	}
+}
+
+func TestCleanName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Cisco Systems, Inc", "Cisco Systems"},
+		{"HUAWEI TECHNOLOGIES CO.,LTD", "HUAWEI TECHNOLOGIES"},
+		{"MOXA TECHNOLOGIES CORP., LTD.", "MOXA TECHNOLOGIES"},
+		{"Axis Communications AB", "Axis Communications"},
+		{"Intel Corporate", "Intel Corporate"},
+		{"Inc", "Inc"},
+	}
+	for _, tt := range tests {
+		if got := cleanName(tt.name); got != tt.want {
+			t.Errorf("cleanName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package scan
+
+import (
+	"net"
+	"time"
+)
+
+// tcpProbes holds a request for services that wait for the client to speak
+// first. Ports without one are read straight away, which covers SSH, FTP,
+// SMTP and other protocols that greet on connect.
+var tcpProbes = map[int][]byte{
+	80: []byte("HEAD / HTTP/1.0\r\n\r\n"),
+}
+
+// dialTCP connects to one TCP port; replaced in tests
+var dialTCP = net.DialTimeout
+
+// grabBanner sends the port's probe, if any, and returns the printable start
+// of whatever the service replies within timeout
+func grabBanner(conn net.Conn, port int, timeout time.Duration) string {
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	if probe, ok := tcpProbes[port]; ok {
+		if _, err := conn.Write(probe); err != nil {
+			return ""
+		}
+	}
+
+	buf := make([]byte, 512)
+	n, _ := conn.Read(buf)
+	return printableBanner(buf[:n])
+}
@@ -0,0 +1,108 @@
+package scan
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// bannerServer accepts one connection, optionally waits for a request line
+// and then writes banner. The request line it read is sent on the channel.
+func bannerServer(t *testing.T, waitForRequest bool, banner string) (string, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	requests := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if waitForRequest {
+			line, _ := bufio.NewReader(conn).ReadString('\n')
+			requests <- line
+		}
+		conn.Write([]byte(banner))
+		time.Sleep(500 * time.Millisecond)
+	}()
+	return ln.Addr().String(), requests
+}
+
+// stubDialTCP sends every dial to addr, whatever port scanPort asked for
+func stubDialTCP(t *testing.T, addr string) {
+	t.Helper()
+	orig := dialTCP
+	dialTCP = func(network, _ string, timeout time.Duration) (net.Conn, error) {
+		return net.DialTimeout(network, addr, timeout)
+	}
+	t.Cleanup(func() { dialTCP = orig })
+}
+
+func TestScanPortBanner(t *testing.T) {
+	tests := []struct {
+		name        string
+		port        int
+		waitForReq  bool
+		banner      string
+		wantBanner  string
+		wantRequest string
+	}{
+		{
+			name:       "ssh greeting",
+			port:       22,
+			banner:     "SSH-2.0-OpenSSH_9.6p1 Ubuntu-3ubuntu13\r\n",
+			wantBanner: "SSH-2.0-OpenSSH_9.6p1 Ubuntu-3ubuntu13",
+		},
+		{
+			name:        "http head",
+			port:        80,
+			waitForReq:  true,
+			banner:      "HTTP/1.0 200 OK\r\nServer: nginx/1.24.0\r\n\r\n",
+			wantBanner:  "HTTP/1.0 200 OK Server: nginx/1.24.0",
+			wantRequest: "HEAD / HTTP/1.0\r\n",
+		},
+		{
+			name:       "ftp greeting",
+			port:       21,
+			banner:     "220 (vsFTPd 3.0.5)\r\n",
+			wantBanner: "220 (vsFTPd 3.0.5)",
+		},
+		{
+			name:       "long banner",
+			port:       23,
+			banner:     "\xff\xfb\x01" + strings.Repeat("x", 200),
+			wantBanner: strings.Repeat("x", 120),
+		},
+		{
+			name: "silent service",
+			port: 3306,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, requests := bannerServer(t, tt.waitForReq, tt.banner)
+			stubDialTCP(t, addr)
+
+			service := scanPort("192.0.2.1", tt.port, 200*time.Millisecond)
+			if service.State != "open" {
+				t.Fatalf("State = %q, want open", service.State)
+			}
+			if service.Banner != tt.wantBanner {
+				t.Errorf("Banner = %q, want %q", service.Banner, tt.wantBanner)
+			}
+			if tt.waitForReq {
+				if got := <-requests; got != tt.wantRequest {
+					t.Errorf("request = %q, want %q", got, tt.wantRequest)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package scan
+
+import (
+	"bytes"
+	"net"
+	"sort"
+)
+
+// ScanDiff is what changed between two audits of the same network
+type ScanDiff struct {
+	NewHosts    []string // active now but not before
+	GoneHosts   []string // active before but not now
+	PortChanges []PortChange
+}
+
+// PortChange is a port on a host seen in both audits whose state differs.
+// A port missing from one audit has the state "closed".
+type PortChange struct {
+	Host     string
+	Port     int
+	Protocol string
+	OldState string
+	NewState string
+}
+
+// Empty reports whether the audits found the same hosts and ports
+func (d ScanDiff) Empty() bool {
+	return len(d.NewHosts) == 0 && len(d.GoneHosts) == 0 && len(d.PortChanges) == 0
+}
+
+// DiffResults compares the active hosts of two audits and the ports of the
+// hosts they share
+func DiffResults(oldRes, newRes *ScanResult) ScanDiff {
+	var diff ScanDiff
+	oldHosts := activeHosts(oldRes)
+	newHosts := activeHosts(newRes)
+
+	for ip, nh := range newHosts {
+		oh, ok := oldHosts[ip]
+		if !ok {
+			diff.NewHosts = append(diff.NewHosts, ip)
+			continue
+		}
+
+		oldPorts, newPorts := portStates(oh), portStates(nh)
+		for key, newState := range newPorts {
+			if oldState := oldPorts[key]; oldState != newState {
+				diff.PortChanges = append(diff.PortChanges, portChange(ip, key, oldState, newState))
+			}
+		}
+		for key, oldState := range oldPorts {
+			if _, ok := newPorts[key]; !ok {
+				diff.PortChanges = append(diff.PortChanges, portChange(ip, key, oldState, ""))
+			}
+		}
+	}
+	for ip := range oldHosts {
+		if _, ok := newHosts[ip]; !ok {
+			diff.GoneHosts = append(diff.GoneHosts, ip)
+		}
+	}
+
+	sort.Slice(diff.NewHosts, func(i, j int) bool { return ipLess(diff.NewHosts[i], diff.NewHosts[j]) })
+	sort.Slice(diff.GoneHosts, func(i, j int) bool { return ipLess(diff.GoneHosts[i], diff.GoneHosts[j]) })
+	sort.Slice(diff.PortChanges, func(i, j int) bool {
+		a, b := diff.PortChanges[i], diff.PortChanges[j]
+		if a.Host != b.Host {
+			return ipLess(a.Host, b.Host)
+		}
+		if a.Port != b.Port {
+			return a.Port < b.Port
+		}
+		return a.Protocol < b.Protocol
+	})
+	return diff
+}
+
+// isActive reports whether a host counts towards ScanResult.ActiveHosts
+func isActive(h HostResult) bool {
+	return h.Error == nil && (len(h.Services) > 0 || hasOpenUDP(h.UDPServices) || h.SNMP != nil)
+}
+
+func activeHosts(res *ScanResult) map[string]HostResult {
+	hosts := make(map[string]HostResult)
+	if res == nil {
+		return hosts
+	}
+	for _, h := range res.Hosts {
+		if isActive(h) {
+			hosts[h.IP] = h
+		}
+	}
+	return hosts
+}
+
+// portKey is a port number and its protocol
+type portKey struct {
+	port     int
+	protocol string
+}
+
+func portStates(h HostResult) map[portKey]string {
+	states := make(map[portKey]string)
+	for _, s := range h.Services {
+		states[portKey{s.Port, "tcp"}] = s.State
+	}
+	for _, s := range h.UDPServices {
+		states[portKey{s.Port, "udp"}] = s.State
+	}
+	return states
+}
+
+func portChange(host string, key portKey, oldState, newState string) PortChange {
+	if oldState == "" {
+		oldState = "closed"
+	}
+	if newState == "" {
+		newState = "closed"
+	}
+	return PortChange{Host: host, Port: key.port, Protocol: key.protocol, OldState: oldState, NewState: newState}
+}
+
+// ipLess orders addresses numerically, falling back to string order
+func ipLess(a, b string) bool {
+	ipA, ipB := net.ParseIP(a), net.ParseIP(b)
+	if ipA == nil || ipB == nil {
+		return a < b
+	}
+	return bytes.Compare(ipA.To16(), ipB.To16()) < 0
+}
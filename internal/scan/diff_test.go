@@ -0,0 +1,51 @@
+package scan
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffResults(t *testing.T) {
+	tcp := func(port int, state string) ServiceInfo {
+		return ServiceInfo{Port: port, Protocol: "tcp", State: state}
+	}
+	udp := func(port int, state string) ServiceInfo {
+		return ServiceInfo{Port: port, Protocol: "udp", State: state}
+	}
+
+	old := &ScanResult{Hosts: []HostResult{
+		{IP: "192.168.1.1", Services: []ServiceInfo{tcp(22, "open"), tcp(80, "open")}},
+		{IP: "192.168.1.10", Services: []ServiceInfo{tcp(3389, "open")}},
+		{IP: "192.168.1.20", UDPServices: []ServiceInfo{udp(161, "open")}},
+		{IP: "192.168.1.30"}, // alive but nothing open: not active
+	}}
+	cur := &ScanResult{Hosts: []HostResult{
+		{IP: "192.168.1.1", Services: []ServiceInfo{tcp(22, "open"), tcp(443, "open")}},
+		{IP: "192.168.1.20", UDPServices: []ServiceInfo{udp(161, "open|filtered"), udp(123, "open")}},
+		{IP: "192.168.1.30", Services: []ServiceInfo{tcp(8080, "open")}},
+		{IP: "192.168.1.9", SNMP: &SNMPResult{SysName: "ap1"}},
+	}}
+
+	want := ScanDiff{
+		NewHosts:  []string{"192.168.1.9", "192.168.1.30"},
+		GoneHosts: []string{"192.168.1.10"},
+		PortChanges: []PortChange{
+			{Host: "192.168.1.1", Port: 80, Protocol: "tcp", OldState: "open", NewState: "closed"},
+			{Host: "192.168.1.1", Port: 443, Protocol: "tcp", OldState: "closed", NewState: "open"},
+			{Host: "192.168.1.20", Port: 123, Protocol: "udp", OldState: "closed", NewState: "open"},
+			{Host: "192.168.1.20", Port: 161, Protocol: "udp", OldState: "open", NewState: "open|filtered"},
+		},
+	}
+
+	got := DiffResults(old, cur)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffResults() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if !DiffResults(cur, cur).Empty() {
+		t.Error("diff of a result with itself should be empty")
+	}
+	if d := DiffResults(nil, cur); len(d.NewHosts) != 4 {
+		t.Errorf("diff against nil = %+v, want every active host new", d)
+	}
+}
@@ -0,0 +1,57 @@
+package scan
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// ttlPattern finds the TTL in ping output ("ttl=64" or Windows "TTL=128")
+var ttlPattern = regexp.MustCompile(`(?i)\bttl=(\d+)`)
+
+// pingTTL returns the IP TTL of an echo reply from host; replaced in tests.
+// A connected TCP socket can't report the TTL of the packets it receives
+// without raw socket access, so the system ping, which is setuid or uses
+// unprivileged ICMP sockets, reads it instead.
+var pingTTL = func(host string, timeout time.Duration) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout+time.Second)
+	defer cancel()
+
+	countFlag := "-c"
+	if runtime.GOOS == "windows" {
+		countFlag = "-n"
+	}
+	output, err := exec.CommandContext(ctx, "ping", countFlag, "1", host).Output()
+	if err != nil {
+		return 0, err
+	}
+	return parseTTL(string(output))
+}
+
+// parseTTL extracts the reply TTL from ping output
+func parseTTL(output string) (int, error) {
+	match := ttlPattern.FindStringSubmatch(output)
+	if match == nil {
+		return 0, fmt.Errorf("no ttl in ping output")
+	}
+	return strconv.Atoi(match[1])
+}
+
+// osHintFromTTL maps a reply TTL to the OS family whose initial TTL it is
+// closest to. Hosts on the local subnet answer with the TTL unchanged.
+func osHintFromTTL(ttl int) string {
+	switch {
+	case ttl >= 60 && ttl <= 65:
+		return "Linux/macOS"
+	case ttl >= 120 && ttl <= 130:
+		return "Windows"
+	case ttl >= 250 && ttl <= 255:
+		return "Network device"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,51 @@
+package scan
+
+import "testing"
+
+func TestParseTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    int
+		wantErr bool
+	}{
+		{"linux", "64 bytes from 192.168.1.1: icmp_seq=1 ttl=64 time=0.512 ms\n", 64, false},
+		{"macos", "64 bytes from 192.168.1.20: icmp_seq=0 ttl=255 time=3.1 ms\n", 255, false},
+		{"windows", "Reply from 192.168.1.30: bytes=32 time<1ms TTL=128\r\n", 128, false},
+		{"no reply", "1 packets transmitted, 0 received, 100% packet loss\n", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTTL(tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTTL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseTTL() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOsHintFromTTL(t *testing.T) {
+	tests := []struct {
+		ttl  int
+		want string
+	}{
+		{64, "Linux/macOS"},
+		{60, "Linux/macOS"},
+		{128, "Windows"},
+		{121, "Windows"},
+		{255, "Network device"},
+		{250, "Network device"},
+		{100, ""},
+		{32, ""},
+	}
+
+	for _, tt := range tests {
+		if got := osHintFromTTL(tt.ttl); got != tt.want {
+			t.Errorf("osHintFromTTL(%d) = %q, want %q", tt.ttl, got, tt.want)
+		}
+	}
+}
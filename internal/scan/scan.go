@@ -4,11 +4,15 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/alexpitcher/LanAudit/internal/consent"
+	netpkg "github.com/alexpitcher/LanAudit/internal/net"
+	"github.com/alexpitcher/LanAudit/internal/oui"
+	"golang.org/x/time/rate"
 )
 
 // ServiceInfo represents a discovered service on a host
@@ -25,11 +29,59 @@ type ServiceInfo struct {
 type HostResult struct {
 	IP       string
 	Hostname string
-	Latency  time.Duration
+	// MAC comes from the ARP cache, so it is empty for hosts beyond the
+	// local subnet; Vendor is its OUI registrant
+	MAC     string
+	Vendor  string
+	Latency time.Duration
+	// OsHint guesses the host's OS family from the TTL of its replies
+	OsHint   string
 	Services []ServiceInfo
-	Error    error
+	// UDPServices lists UDP ports that answered ("open") or stayed silent
+	// without an ICMP port unreachable ("open|filtered")
+	UDPServices []ServiceInfo
+	// SNMP is the system group of a host that answered an SNMP GET
+	SNMP  *SNMPResult
+	Error error `json:"-" yaml:"-"`
 }
 
+// ScanConfig controls what AuditGateway probes on each host
+type ScanConfig struct {
+	Ports   []int         // TCP ports; CommonPorts when empty
+	Timeout time.Duration // Per connection; 500ms when zero
+	ScanUDP bool
+	// UDPPorts are probed when ScanUDP is set; DefaultUDPPorts when empty
+	UDPPorts []int
+	// TrySNMP queries each host's system group over SNMPv2c, which also
+	// finds devices with no TCP ports open
+	TrySNMP bool
+	// SNMPCommunity is used by TrySNMP; DefaultSNMPCommunities when empty
+	SNMPCommunity string
+	// ScanRatePerSecond caps how many hosts are started each second;
+	// DefaultScanRate when zero
+	ScanRatePerSecond int
+}
+
+// DefaultScanRate is the number of hosts an audit starts per second unless
+// ScanConfig says otherwise; the --scan-rate flag overrides it
+var DefaultScanRate = 100
+
+// clock paces rate-limited scans; tests replace it to avoid sleeping
+type clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time        { return time.Now() }
+func (realClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+var scanClock clock = realClock{}
+
+// probeHost scans one host; replaced in tests
+var probeHost = scanHost
+
 // ScanResult represents the complete gateway audit results
 type ScanResult struct {
 	Gateway     string
@@ -45,24 +97,38 @@ var CommonPorts = []int{
 	21, 22, 23, 25, 53, 80, 110, 143, 443, 445, 3306, 3389, 5432, 5900, 8080, 8443,
 }
 
-// AuditGateway performs a network scan of the gateway subnet
+// MaxCIDRHosts caps how many addresses ExpandCIDR will generate
+const MaxCIDRHosts = 65536
+
+// AuditGateway performs a network scan of cidr, or of the /24 around the
+// gateway when cidr is empty
 // This requires explicit user consent via the SCAN-YES token
-func AuditGateway(gateway string, ports []int, timeout time.Duration) (*ScanResult, error) {
+func AuditGateway(gateway, cidr string, config ScanConfig) (*ScanResult, error) {
 	// Require explicit consent
 	if err := consent.Confirm("SCAN-YES", "SCAN-YES"); err != nil {
 		return nil, fmt.Errorf("gateway audit requires consent: %w", err)
 	}
 
+	if len(config.Ports) == 0 {
+		config.Ports = CommonPorts
+	}
+
 	consent.Log(fmt.Sprintf("Gateway audit started on %s", gateway), map[string]string{
 		"gateway": gateway,
+		"cidr":    cidr,
+		"ports":   FormatPorts(config.Ports),
 	})
 
-	if len(ports) == 0 {
-		ports = CommonPorts
+	if config.Timeout == 0 {
+		config.Timeout = 500 * time.Millisecond
+	}
+
+	if config.ScanRatePerSecond <= 0 {
+		config.ScanRatePerSecond = DefaultScanRate
 	}
 
-	if timeout == 0 {
-		timeout = 500 * time.Millisecond
+	if config.ScanUDP && len(config.UDPPorts) == 0 {
+		config.UDPPorts = DefaultUDPPorts
 	}
 
 	result := &ScanResult{
@@ -72,17 +138,47 @@ func AuditGateway(gateway string, ports []int, timeout time.Duration) (*ScanResu
 	}
 
 	// Parse gateway to determine subnet
-	hosts, err := expandSubnet(gateway)
-	if err != nil {
-		return nil, fmt.Errorf("invalid gateway: %w", err)
+	var hosts []string
+	var err error
+	if cidr != "" {
+		hosts, err = ExpandCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cidr: %w", err)
+		}
+	} else {
+		hosts, err = expandSubnet(gateway)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gateway: %w", err)
+		}
 	}
 
 	result.TotalHosts = len(hosts)
 
-	// Scan hosts concurrently with a worker pool
+	for _, hostResult := range scanHosts(hosts, config) {
+		if isActive(hostResult) {
+			result.ActiveHosts++
+		}
+		result.Hosts = append(result.Hosts, hostResult)
+	}
+
+	result.EndTime = time.Now()
+
+	consent.Log(fmt.Sprintf("Gateway audit completed: %d active hosts found", result.ActiveHosts), map[string]string{
+		"active_hosts": fmt.Sprintf("%d", result.ActiveHosts),
+		"total_hosts":  fmt.Sprintf("%d", result.TotalHosts),
+	})
+
+	return result, nil
+}
+
+// scanHosts scans hosts concurrently with a worker pool. A shared token
+// bucket paces the workers so they start at most ScanRatePerSecond hosts
+// a second instead of all firing at once.
+func scanHosts(hosts []string, config ScanConfig) []HostResult {
 	var wg sync.WaitGroup
 	hostChan := make(chan string, len(hosts))
 	resultChan := make(chan HostResult, len(hosts))
+	limiter := rate.NewLimiter(rate.Limit(config.ScanRatePerSecond), 1)
 
 	// Start workers
 	numWorkers := 50
@@ -91,19 +187,17 @@ func AuditGateway(gateway string, ports []int, timeout time.Duration) (*ScanResu
 		go func() {
 			defer wg.Done()
 			for host := range hostChan {
-				hostResult := scanHost(host, ports, timeout)
-				resultChan <- hostResult
+				waitForToken(limiter)
+				resultChan <- probeHost(host, config)
 			}
 		}()
 	}
 
 	// Send hosts to workers
-	go func() {
-		for _, host := range hosts {
-			hostChan <- host
-		}
-		close(hostChan)
-	}()
+	for _, host := range hosts {
+		hostChan <- host
+	}
+	close(hostChan)
 
 	// Collect results
 	go func() {
@@ -111,21 +205,17 @@ func AuditGateway(gateway string, ports []int, timeout time.Duration) (*ScanResu
 		close(resultChan)
 	}()
 
+	results := make([]HostResult, 0, len(hosts))
 	for hostResult := range resultChan {
-		if hostResult.Error == nil && len(hostResult.Services) > 0 {
-			result.ActiveHosts++
-		}
-		result.Hosts = append(result.Hosts, hostResult)
+		results = append(results, hostResult)
 	}
+	return results
+}
 
-	result.EndTime = time.Now()
-
-	consent.Log(fmt.Sprintf("Gateway audit completed: %d active hosts found", result.ActiveHosts), map[string]string{
-		"active_hosts": fmt.Sprintf("%d", result.ActiveHosts),
-		"total_hosts":  fmt.Sprintf("%d", result.TotalHosts),
-	})
-
-	return result, nil
+// waitForToken blocks until the limiter allows another host to be scanned
+func waitForToken(limiter *rate.Limiter) {
+	now := scanClock.Now()
+	scanClock.Sleep(limiter.ReserveN(now, 1).DelayFrom(now))
 }
 
 // expandSubnet converts a gateway IP to a list of hosts to scan
@@ -152,8 +242,52 @@ func expandSubnet(gateway string) ([]string, error) {
 	return hosts, nil
 }
 
+// FormatPorts joins port numbers with commas
+func FormatPorts(ports []int) string {
+	parts := make([]string, len(ports))
+	for i, p := range ports {
+		parts[i] = strconv.Itoa(p)
+	}
+	return strings.Join(parts, ",")
+}
+
+// ExpandCIDR lists the host addresses in an IPv4 CIDR, leaving out the
+// network and broadcast addresses. /31 and /32 have no such addresses and
+// yield every address in the block.
+func ExpandCIDR(cidr string) ([]string, error) {
+	_, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIDR %q: %w", cidr, err)
+	}
+
+	network := ipnet.IP.To4()
+	if network == nil {
+		return nil, fmt.Errorf("IPv6 not supported yet")
+	}
+
+	ones, bits := ipnet.Mask.Size()
+	size := uint64(1) << uint(bits-ones)
+	first, last := uint64(0), size-1
+	if size > 2 {
+		first, last = 1, size-2
+	}
+	if count := last - first + 1; count > MaxCIDRHosts {
+		return nil, fmt.Errorf("%s has %d hosts, more than the limit of %d", cidr, count, MaxCIDRHosts)
+	}
+
+	base := uint64(network[0])<<24 | uint64(network[1])<<16 | uint64(network[2])<<8 | uint64(network[3])
+	hosts := make([]string, 0, last-first+1)
+	for i := first; i <= last; i++ {
+		addr := base + i
+		hosts = append(hosts, fmt.Sprintf("%d.%d.%d.%d", byte(addr>>24), byte(addr>>16), byte(addr>>8), byte(addr)))
+	}
+
+	return hosts, nil
+}
+
 // scanHost performs a port scan on a single host
-func scanHost(host string, ports []int, timeout time.Duration) HostResult {
+func scanHost(host string, config ScanConfig) HostResult {
+	timeout := config.Timeout
 	result := HostResult{
 		IP:       host,
 		Services: make([]ServiceInfo, 0),
@@ -161,19 +295,40 @@ func scanHost(host string, ports []int, timeout time.Duration) HostResult {
 
 	// Quick ping check first
 	start := time.Now()
+	alive := false
 	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:80", host), timeout)
-	if err == nil {
-		conn.Close()
-		result.Latency = time.Since(start)
-	} else {
+	if err != nil {
 		// Try one more port to confirm host is down
 		conn, err = net.DialTimeout("tcp", fmt.Sprintf("%s:443", host), timeout)
-		if err != nil {
-			// Host appears down, skip detailed scan
-			return result
-		}
+	}
+	if err == nil {
 		conn.Close()
 		result.Latency = time.Since(start)
+		alive = true
+	}
+
+	// Switches and access points often answer SNMP with no TCP port open
+	if config.TrySNMP {
+		if snmp, err := SNMPProbe(host, config.SNMPCommunity, timeout); err == nil {
+			result.SNMP = snmp
+			alive = true
+		}
+	}
+
+	if !alive {
+		// Host appears down, skip detailed scan
+		return result
+	}
+
+	if ttl, err := pingTTL(host, timeout); err == nil {
+		result.OsHint = osHintFromTTL(ttl)
+	}
+
+	if mac := macForIP(host); mac != "" {
+		result.MAC = mac
+		if vendor, err := oui.Lookup(mac); err == nil {
+			result.Vendor = vendor
+		}
 	}
 
 	// Reverse DNS lookup
@@ -183,16 +338,50 @@ func scanHost(host string, ports []int, timeout time.Duration) HostResult {
 	}
 
 	// Scan each port
-	for _, port := range ports {
+	for _, port := range config.Ports {
 		service := scanPort(host, port, timeout)
 		if service.State == "open" {
 			result.Services = append(result.Services, service)
 		}
 	}
 
+	if config.ScanUDP {
+		for _, port := range config.UDPPorts {
+			service := scanUDPPort(host, port, timeout)
+			if service.State != "closed" {
+				result.UDPServices = append(result.UDPServices, service)
+			}
+		}
+	}
+
 	return result
 }
 
+// macForIP returns a host's MAC from the ARP cache, which the probes that
+// found the host alive have just filled in
+var macForIP = func(ip string) string {
+	entries, err := netpkg.GetARPTable()
+	if err != nil {
+		return ""
+	}
+	for _, e := range entries {
+		if e.IP == ip && e.State != "incomplete" {
+			return e.MAC
+		}
+	}
+	return ""
+}
+
+// hasOpenUDP reports whether any UDP service actually replied
+func hasOpenUDP(services []ServiceInfo) bool {
+	for _, s := range services {
+		if s.State == "open" {
+			return true
+		}
+	}
+	return false
+}
+
 // scanPort checks if a specific port is open and gathers service info
 func scanPort(host string, port int, timeout time.Duration) ServiceInfo {
 	service := ServiceInfo{
@@ -202,7 +391,7 @@ func scanPort(host string, port int, timeout time.Duration) ServiceInfo {
 	}
 
 	address := net.JoinHostPort(host, fmt.Sprintf("%d", port))
-	conn, err := net.DialTimeout("tcp", address, timeout)
+	conn, err := dialTCP("tcp", address, timeout)
 	if err != nil {
 		return service
 	}
@@ -211,8 +400,8 @@ func scanPort(host string, port int, timeout time.Duration) ServiceInfo {
 	service.State = "open"
 	service.Service = getServiceName(port)
 
-	// Try TLS handshake for common TLS ports
-	if port == 443 || port == 8443 || port == 22 {
+	// Try TLS handshake for common TLS ports, otherwise read a banner
+	if port == 443 || port == 8443 {
 		tlsConn := tls.Client(conn, &tls.Config{
 			InsecureSkipVerify: true,
 			ServerName:         host,
@@ -229,6 +418,8 @@ func scanPort(host string, port int, timeout time.Duration) ServiceInfo {
 			}
 		}
 		tlsConn.Close()
+	} else {
+		service.Banner = grabBanner(conn, port, timeout)
 	}
 
 	return service
@@ -242,11 +433,15 @@ func getServiceName(port int) string {
 		23:   "Telnet",
 		25:   "SMTP",
 		53:   "DNS",
+		69:   "TFTP",
 		80:   "HTTP",
 		110:  "POP3",
+		123:  "NTP",
 		143:  "IMAP",
+		161:  "SNMP",
 		443:  "HTTPS",
 		445:  "SMB",
+		514:  "Syslog",
 		3306: "MySQL",
 		3389: "RDP",
 		5432: "PostgreSQL",
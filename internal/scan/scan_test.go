@@ -1,6 +1,8 @@
 package scan
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 )
@@ -65,6 +67,86 @@ func TestExpandSubnet(t *testing.T) {
 	}
 }
 
+func TestExpandCIDR(t *testing.T) {
+	tests := []struct {
+		name      string
+		cidr      string
+		wantCount int
+		wantFirst string
+		wantLast  string
+		wantError bool
+	}{
+		{
+			name:      "/22",
+			cidr:      "192.168.12.0/22",
+			wantCount: 1022,
+			wantFirst: "192.168.12.1",
+			wantLast:  "192.168.15.254",
+		},
+		{
+			name:      "/22 not on a boundary",
+			cidr:      "192.168.10.0/22",
+			wantCount: 1022,
+			wantFirst: "192.168.8.1",
+			wantLast:  "192.168.11.254",
+		},
+		{
+			name:      "/30",
+			cidr:      "10.0.0.4/30",
+			wantCount: 2,
+			wantFirst: "10.0.0.5",
+			wantLast:  "10.0.0.6",
+		},
+		{
+			name:      "/32",
+			cidr:      "10.1.2.3/32",
+			wantCount: 1,
+			wantFirst: "10.1.2.3",
+			wantLast:  "10.1.2.3",
+		},
+		{
+			name:      "/16 at the cap",
+			cidr:      "172.16.0.0/16",
+			wantCount: 65534,
+			wantFirst: "172.16.0.1",
+			wantLast:  "172.16.255.254",
+		},
+		{
+			name:      "/8 over the cap",
+			cidr:      "10.0.0.0/8",
+			wantError: true,
+		},
+		{
+			name:      "missing mask",
+			cidr:      "192.168.1.1",
+			wantError: true,
+		},
+		{
+			name:      "IPv6 not supported",
+			cidr:      "2001:db8::/120",
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hosts, err := ExpandCIDR(tt.cidr)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("ExpandCIDR() error = %v, wantError %v", err, tt.wantError)
+			}
+			if tt.wantError {
+				return
+			}
+			if len(hosts) != tt.wantCount {
+				t.Fatalf("ExpandCIDR() returned %d hosts, want %d", len(hosts), tt.wantCount)
+			}
+			if hosts[0] != tt.wantFirst || hosts[len(hosts)-1] != tt.wantLast {
+				t.Errorf("ExpandCIDR() = %s..%s, want %s..%s", hosts[0], hosts[len(hosts)-1], tt.wantFirst, tt.wantLast)
+			}
+		})
+	}
+}
+
 func TestGetServiceName(t *testing.T) {
 	tests := []struct {
 		port int
@@ -142,3 +224,49 @@ func TestScanPortTimeout(t *testing.T) {
 		t.Errorf("Expected State 'closed' for unreachable host, got %s", service.State)
 	}
 }
+
+// fakeClock never sleeps; it records the longest wait so a test can tell
+// when the last host would have started
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	longest time.Duration
+	waits   int
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.waits++
+	if d > c.longest {
+		c.longest = d
+	}
+}
+
+func TestScanHostsRateLimit(t *testing.T) {
+	clk := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	origClock, origProbe := scanClock, probeHost
+	scanClock = clk
+	probeHost = func(host string, _ ScanConfig) HostResult { return HostResult{IP: host} }
+	t.Cleanup(func() { scanClock, probeHost = origClock, origProbe })
+
+	hosts := make([]string, 10)
+	for i := range hosts {
+		hosts[i] = fmt.Sprintf("192.168.1.%d", i+1)
+	}
+
+	results := scanHosts(hosts, ScanConfig{ScanRatePerSecond: 2})
+	if len(results) != len(hosts) {
+		t.Fatalf("scanned %d hosts, want %d", len(results), len(hosts))
+	}
+	if clk.waits != len(hosts) {
+		t.Errorf("waited for %d tokens, want %d", clk.waits, len(hosts))
+	}
+	// The first host starts at once and each of the other nine waits
+	// another half second
+	if clk.longest < 4500*time.Millisecond {
+		t.Errorf("last host started after %v, want at least 4.5s", clk.longest)
+	}
+}
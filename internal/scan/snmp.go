@@ -0,0 +1,527 @@
+package scan
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SNMPResult holds the system group of a device that answered an SNMP GET
+type SNMPResult struct {
+	Community   string
+	SysDescr    string
+	SysObjectID string
+	SysName     string
+	SysLocation string
+}
+
+// SNMPVar is one variable returned by SNMPGet or SNMPWalk
+type SNMPVar struct {
+	OID   string
+	Value string
+}
+
+// DefaultSNMPCommunities are tried in order when no community is given
+var DefaultSNMPCommunities = []string{"public", "private"}
+
+// MaxSNMPWalkVars caps the variables one SNMPWalk returns
+const MaxSNMPWalkVars = 500
+
+// SNMPSystemOID is the root of the system group (sysDescr, sysName, ...)
+const SNMPSystemOID = "1.3.6.1.2.1.1"
+
+// snmpSystemOIDs are sysDescr, sysObjectID, sysUpTime, sysContact, sysName
+// and sysLocation
+var snmpSystemOIDs = [][]int{
+	{1, 3, 6, 1, 2, 1, 1, 1, 0},
+	{1, 3, 6, 1, 2, 1, 1, 2, 0},
+	{1, 3, 6, 1, 2, 1, 1, 3, 0},
+	{1, 3, 6, 1, 2, 1, 1, 4, 0},
+	{1, 3, 6, 1, 2, 1, 1, 5, 0},
+	{1, 3, 6, 1, 2, 1, 1, 6, 0},
+}
+
+// BER tags used by SNMP
+const (
+	berInteger     = 0x02
+	berOctetString = 0x04
+	berNull        = 0x05
+	berOID         = 0x06
+	berSequence    = 0x30
+	berIPAddress   = 0x40
+	berCounter32   = 0x41
+	berGauge32     = 0x42
+	berTimeTicks   = 0x43
+	berCounter64   = 0x46
+	snmpGetRequest = 0xa0
+	snmpGetNext    = 0xa1
+	snmpResponse   = 0xa2
+
+	// Varbind exceptions in SNMPv2c responses
+	snmpNoSuchObject   = 0x80
+	snmpNoSuchInstance = 0x81
+	snmpEndOfMibView   = 0x82
+)
+
+// SNMPProbe sends an SNMPv2c GET for the system group to host:161. An empty
+// community tries DefaultSNMPCommunities in turn, each with the full
+// timeout, since agents silently drop requests with the wrong community.
+func SNMPProbe(host string, community string, timeout time.Duration) (*SNMPResult, error) {
+	communities := []string{community}
+	if community == "" {
+		communities = DefaultSNMPCommunities
+	}
+
+	var lastErr error
+	for _, c := range communities {
+		res, err := snmpGet(host, c, timeout)
+		if err == nil {
+			return res, nil
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("snmp probe of %s failed: %w", host, lastErr)
+}
+
+// snmpGet performs one GET request for the system group with the given
+// community
+func snmpGet(host, community string, timeout time.Duration) (*SNMPResult, error) {
+	conn, addr, err := dialSNMP(host, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	varbinds, err := snmpExchange(conn, addr, encodeSNMPGet, community, snmpSystemOIDs, timeout)
+	if err != nil {
+		return nil, err
+	}
+	res := systemResult(varbinds)
+	res.Community = community
+	return res, nil
+}
+
+// SNMPGet fetches oids (dotted, e.g. "1.3.6.1.2.1.1.5.0") from host:161 in
+// one SNMPv2c GET. An empty community uses the first of
+// DefaultSNMPCommunities. OIDs the agent lacks come back with the value
+// "noSuchObject" or "noSuchInstance".
+func SNMPGet(host, community string, oids []string, timeout time.Duration) ([]SNMPVar, error) {
+	if len(oids) == 0 {
+		return nil, errors.New("no OIDs given")
+	}
+	parsed := make([][]int, 0, len(oids))
+	for _, o := range oids {
+		oid, err := parseOID(o)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, oid)
+	}
+	if community == "" {
+		community = DefaultSNMPCommunities[0]
+	}
+
+	conn, addr, err := dialSNMP(host, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	varbinds, err := snmpExchange(conn, addr, encodeSNMPGet, community, parsed, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("snmp get from %s failed: %w", host, err)
+	}
+	vars := make([]SNMPVar, 0, len(varbinds))
+	for _, vb := range varbinds {
+		vars = append(vars, SNMPVar{OID: vb.oid, Value: formatSNMPValue(vb.tag, vb.value)})
+	}
+	return vars, nil
+}
+
+// SNMPWalk returns the variables under rootOID on host:161, issuing
+// SNMPv2c GETNEXT requests until the agent returns an OID outside the
+// subtree or MaxSNMPWalkVars have been read. An empty community uses the
+// first of DefaultSNMPCommunities.
+func SNMPWalk(host string, community string, rootOID string, timeout time.Duration) ([]SNMPVar, error) {
+	root, err := parseOID(rootOID)
+	if err != nil {
+		return nil, err
+	}
+	if community == "" {
+		community = DefaultSNMPCommunities[0]
+	}
+	prefix := joinOID(root) + "."
+
+	conn, addr, err := dialSNMP(host, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var vars []SNMPVar
+	current := root
+	for len(vars) < MaxSNMPWalkVars {
+		varbinds, err := snmpExchange(conn, addr, encodeSNMPGetNext, community, [][]int{current}, timeout)
+		if err != nil {
+			return vars, fmt.Errorf("snmp walk of %s failed: %w", host, err)
+		}
+		if len(varbinds) != 1 {
+			return vars, fmt.Errorf("snmp walk of %s failed: %d varbinds in response", host, len(varbinds))
+		}
+		vb := varbinds[0]
+		if vb.tag == snmpEndOfMibView || !strings.HasPrefix(vb.oid, prefix) {
+			break
+		}
+		next, err := parseOID(vb.oid)
+		if err != nil {
+			return vars, err
+		}
+		// A broken agent answering with the same or an earlier OID would
+		// loop forever
+		if compareOID(next, current) <= 0 {
+			return vars, fmt.Errorf("snmp walk of %s failed: OID %s does not increase", host, vb.oid)
+		}
+		vars = append(vars, SNMPVar{OID: vb.oid, Value: formatSNMPValue(vb.tag, vb.value)})
+		current = next
+	}
+	return vars, nil
+}
+
+// dialSNMP opens a socket for requests to host's SNMP agent
+func dialSNMP(host string, timeout time.Duration) (net.PacketConn, net.Addr, error) {
+	address := net.JoinHostPort(host, "161")
+	addr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn, err := dialUDP(address, timeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	return conn, addr, nil
+}
+
+// snmpExchange sends one request built by encode and returns the varbinds
+// of the matching response
+func snmpExchange(conn net.PacketConn, addr net.Addr, encode func(string, int32, [][]int) []byte, community string, oids [][]int, timeout time.Duration) ([]snmpVarbind, error) {
+	requestID := rand.Int31()
+	if _, err := conn.WriteTo(encode(community, requestID, oids), addr); err != nil {
+		return nil, err
+	}
+
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	buf := make([]byte, 4096)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return nil, err
+		}
+		varbinds, id, err := decodeSNMPResponse(buf[:n])
+		if err != nil {
+			return nil, err
+		}
+		// Ignore late replies to an earlier request
+		if id != requestID {
+			continue
+		}
+		return varbinds, nil
+	}
+}
+
+// encodeSNMPGet builds an SNMPv2c GetRequest message for oids
+func encodeSNMPGet(community string, requestID int32, oids [][]int) []byte {
+	return encodeSNMPRequest(snmpGetRequest, community, requestID, oids)
+}
+
+// encodeSNMPGetNext builds an SNMPv2c GetNextRequest message for oids
+func encodeSNMPGetNext(community string, requestID int32, oids [][]int) []byte {
+	return encodeSNMPRequest(snmpGetNext, community, requestID, oids)
+}
+
+// encodeSNMPRequest builds an SNMPv2c request of the given PDU type
+func encodeSNMPRequest(pduType byte, community string, requestID int32, oids [][]int) []byte {
+	var varbinds []byte
+	for _, oid := range oids {
+		varbinds = append(varbinds, berTLV(berSequence, append(berTLV(berOID, encodeOID(oid)), berNull, 0x00))...)
+	}
+
+	var pdu []byte
+	pdu = append(pdu, berTLV(berInteger, encodeInt(int64(requestID)))...)
+	pdu = append(pdu, berTLV(berInteger, encodeInt(0))...) // error-status
+	pdu = append(pdu, berTLV(berInteger, encodeInt(0))...) // error-index
+	pdu = append(pdu, berTLV(berSequence, varbinds)...)
+
+	var msg []byte
+	msg = append(msg, berTLV(berInteger, encodeInt(1))...) // version: v2c
+	msg = append(msg, berTLV(berOctetString, []byte(community))...)
+	msg = append(msg, berTLV(pduType, pdu)...)
+	return berTLV(berSequence, msg)
+}
+
+// snmpVarbind is one variable from a response, value still encoded
+type snmpVarbind struct {
+	oid   string
+	tag   byte
+	value []byte
+}
+
+// decodeSNMPResponse parses a GetResponse and returns its varbinds along
+// with its request ID
+func decodeSNMPResponse(data []byte) ([]snmpVarbind, int32, error) {
+	tag, msg, _, err := readTLV(data)
+	if err != nil || tag != berSequence {
+		return nil, 0, errors.New("malformed snmp message")
+	}
+	// version and community
+	for i := 0; i < 2; i++ {
+		if _, _, msg, err = readTLV(msg); err != nil {
+			return nil, 0, err
+		}
+	}
+	tag, pdu, _, err := readTLV(msg)
+	if err != nil || tag != snmpResponse {
+		return nil, 0, errors.New("not an snmp response")
+	}
+
+	var fields [3]int64 // request-id, error-status, error-index
+	for i := range fields {
+		var content []byte
+		if tag, content, pdu, err = readTLV(pdu); err != nil || tag != berInteger {
+			return nil, 0, errors.New("malformed snmp pdu")
+		}
+		fields[i] = decodeInt(content)
+	}
+	requestID := int32(fields[0])
+	if fields[1] != 0 {
+		return nil, requestID, fmt.Errorf("snmp error status %d", fields[1])
+	}
+
+	tag, list, _, err := readTLV(pdu)
+	if err != nil || tag != berSequence {
+		return nil, 0, errors.New("malformed snmp varbind list")
+	}
+
+	var varbinds []snmpVarbind
+	for len(list) > 0 {
+		var vb, oid, value []byte
+		var valueTag byte
+		if tag, vb, list, err = readTLV(list); err != nil || tag != berSequence {
+			return nil, 0, errors.New("malformed snmp varbind")
+		}
+		if tag, oid, vb, err = readTLV(vb); err != nil || tag != berOID {
+			return nil, 0, errors.New("malformed snmp varbind oid")
+		}
+		if valueTag, value, _, err = readTLV(vb); err != nil {
+			return nil, 0, err
+		}
+		varbinds = append(varbinds, snmpVarbind{oid: decodeOID(oid), tag: valueTag, value: value})
+	}
+	return varbinds, requestID, nil
+}
+
+// systemResult picks the system group fields out of varbinds
+func systemResult(varbinds []snmpVarbind) *SNMPResult {
+	res := &SNMPResult{}
+	for _, vb := range varbinds {
+		// noSuchObject and friends carry other tags and are skipped
+		if vb.tag != berOctetString && vb.tag != berOID {
+			continue
+		}
+		text := formatSNMPValue(vb.tag, vb.value)
+		switch vb.oid {
+		case "1.3.6.1.2.1.1.1.0":
+			res.SysDescr = text
+		case "1.3.6.1.2.1.1.2.0":
+			res.SysObjectID = text
+		case "1.3.6.1.2.1.1.5.0":
+			res.SysName = text
+		case "1.3.6.1.2.1.1.6.0":
+			res.SysLocation = text
+		}
+	}
+	return res
+}
+
+// formatSNMPValue renders a varbind value as text: strings as is (hex when
+// not printable), numbers in decimal, TimeTicks as a duration
+func formatSNMPValue(tag byte, value []byte) string {
+	switch tag {
+	case berOctetString:
+		for _, b := range value {
+			if (b < 0x20 || b > 0x7e) && b != '\n' && b != '\r' && b != '\t' {
+				return fmt.Sprintf("% x", value)
+			}
+		}
+		return string(value)
+	case berInteger:
+		return strconv.FormatInt(decodeInt(value), 10)
+	case berOID:
+		return decodeOID(value)
+	case berIPAddress:
+		if len(value) == 4 {
+			return net.IP(value).String()
+		}
+		return fmt.Sprintf("% x", value)
+	case berCounter32, berGauge32, berCounter64:
+		return strconv.FormatUint(decodeUint(value), 10)
+	case berTimeTicks:
+		ticks := decodeUint(value)
+		return fmt.Sprintf("%d (%s)", ticks, time.Duration(ticks)*10*time.Millisecond)
+	case berNull:
+		return ""
+	case snmpNoSuchObject:
+		return "noSuchObject"
+	case snmpNoSuchInstance:
+		return "noSuchInstance"
+	case snmpEndOfMibView:
+		return "endOfMibView"
+	}
+	return fmt.Sprintf("% x", value)
+}
+
+// parseOID reads a dotted object identifier such as "1.3.6.1.2.1.1"; a
+// leading dot is allowed
+func parseOID(s string) ([]int, error) {
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(s), "."), ".")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid OID %q", s)
+	}
+	oid := make([]int, len(parts))
+	for i, p := range parts {
+		arc, err := strconv.Atoi(p)
+		if err != nil || arc < 0 {
+			return nil, fmt.Errorf("invalid OID %q", s)
+		}
+		oid[i] = arc
+	}
+	if oid[0] > 2 || (oid[0] < 2 && oid[1] >= 40) {
+		return nil, fmt.Errorf("invalid OID %q", s)
+	}
+	return oid, nil
+}
+
+// joinOID renders oid in dotted form
+func joinOID(oid []int) string {
+	parts := make([]string, len(oid))
+	for i, arc := range oid {
+		parts[i] = strconv.Itoa(arc)
+	}
+	return strings.Join(parts, ".")
+}
+
+// compareOID orders OIDs lexicographically by arc
+func compareOID(a, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(a) - len(b)
+}
+
+// berTLV wraps content in a tag and definite length
+func berTLV(tag byte, content []byte) []byte {
+	n := len(content)
+	var out []byte
+	switch {
+	case n < 0x80:
+		out = []byte{tag, byte(n)}
+	case n <= 0xff:
+		out = []byte{tag, 0x81, byte(n)}
+	default:
+		out = []byte{tag, 0x82, byte(n >> 8), byte(n)}
+	}
+	return append(out, content...)
+}
+
+// readTLV splits the first element off data
+func readTLV(data []byte) (tag byte, content, rest []byte, err error) {
+	if len(data) < 2 {
+		return 0, nil, nil, errors.New("truncated ber element")
+	}
+	tag = data[0]
+	length := int(data[1])
+	offset := 2
+	if length&0x80 != 0 {
+		octets := length & 0x7f
+		if octets == 0 || octets > 2 || len(data) < 2+octets {
+			return 0, nil, nil, errors.New("unsupported ber length")
+		}
+		length = 0
+		for _, b := range data[2 : 2+octets] {
+			length = length<<8 | int(b)
+		}
+		offset += octets
+	}
+	if len(data) < offset+length {
+		return 0, nil, nil, errors.New("truncated ber element")
+	}
+	return tag, data[offset : offset+length], data[offset+length:], nil
+}
+
+// encodeInt returns the minimal two's complement encoding of v
+func encodeInt(v int64) []byte {
+	out := []byte{byte(v)}
+	for v > 0x7f || v < -0x80 {
+		v >>= 8
+		out = append([]byte{byte(v)}, out...)
+	}
+	return out
+}
+
+func decodeUint(content []byte) uint64 {
+	var v uint64
+	for _, b := range content {
+		v = v<<8 | uint64(b)
+	}
+	return v
+}
+
+func decodeInt(content []byte) int64 {
+	var v int64
+	for i, b := range content {
+		if i == 0 && b&0x80 != 0 {
+			v = -1
+		}
+		v = v<<8 | int64(b)
+	}
+	return v
+}
+
+// encodeOID packs an object identifier, first two arcs combined
+func encodeOID(oid []int) []byte {
+	out := []byte{byte(oid[0]*40 + oid[1])}
+	for _, arc := range oid[2:] {
+		var enc []byte
+		enc = append(enc, byte(arc&0x7f))
+		for arc >>= 7; arc > 0; arc >>= 7 {
+			enc = append([]byte{byte(arc&0x7f | 0x80)}, enc...)
+		}
+		out = append(out, enc...)
+	}
+	return out
+}
+
+// decodeOID renders an encoded object identifier in dotted form
+func decodeOID(content []byte) string {
+	if len(content) == 0 {
+		return ""
+	}
+	parts := []string{strconv.Itoa(int(content[0]) / 40), strconv.Itoa(int(content[0]) % 40)}
+	arc := 0
+	for _, b := range content[1:] {
+		arc = arc<<7 | int(b&0x7f)
+		if b&0x80 == 0 {
+			parts = append(parts, strconv.Itoa(arc))
+			arc = 0
+		}
+	}
+	return strings.Join(parts, ".")
+}
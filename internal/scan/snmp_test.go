@@ -0,0 +1,251 @@
+package scan
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// snmpResponder answers GetRequests carrying its community with the values
+// in sys, keyed by dotted OID; other OIDs get noSuchObject. GetNextRequests
+// get the first OID in sys after the one asked for, or endOfMibView.
+// Requests with a different community are dropped, as a real agent does.
+// Every community seen is sent on the returned channel.
+func snmpResponder(t *testing.T, community string, sys map[string][]byte) <-chan string {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { pc.Close() })
+
+	orig := dialUDP
+	dialUDP = func(_ string, timeout time.Duration) (net.PacketConn, error) {
+		return orig(pc.LocalAddr().String(), timeout)
+	}
+	t.Cleanup(func() { dialUDP = orig })
+
+	seen := make(chan string, 16)
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, from, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			_, msg, _, _ := readTLV(buf[:n])
+			_, _, msg, _ = readTLV(msg)
+			_, got, msg, _ := readTLV(msg)
+			// Long walks send more requests than the channel holds
+			select {
+			case seen <- string(got):
+			default:
+			}
+			if string(got) != community {
+				continue
+			}
+			pduType, pdu, _, _ := readTLV(msg)
+			_, id, pdu, _ := readTLV(pdu)
+			_, _, pdu, _ = readTLV(pdu)
+			_, _, pdu, _ = readTLV(pdu)
+			_, varbinds, _, _ := readTLV(pdu)
+
+			var out []byte
+			for len(varbinds) > 0 {
+				var vb, oid []byte
+				_, vb, varbinds, _ = readTLV(varbinds)
+				_, oid, _, _ = readTLV(vb)
+				value := []byte{snmpNoSuchObject, 0x00}
+				if pduType == snmpGetNext {
+					oid, value = nextSNMPVar(sys, decodeOID(oid))
+				} else if v, ok := sys[decodeOID(oid)]; ok {
+					value = v
+				}
+				out = append(out, berTLV(berSequence, append(berTLV(berOID, oid), value...))...)
+			}
+
+			var respPDU []byte
+			respPDU = append(respPDU, berTLV(berInteger, id)...)
+			respPDU = append(respPDU, berTLV(berInteger, []byte{0})...)
+			respPDU = append(respPDU, berTLV(berInteger, []byte{0})...)
+			respPDU = append(respPDU, berTLV(berSequence, out)...)
+			var resp []byte
+			resp = append(resp, berTLV(berInteger, []byte{1})...)
+			resp = append(resp, berTLV(berOctetString, got)...)
+			resp = append(resp, berTLV(snmpResponse, respPDU)...)
+			pc.WriteTo(berTLV(berSequence, resp), from)
+		}
+	}()
+	return seen
+}
+
+// nextSNMPVar returns the encoded OID and value following after in sys
+func nextSNMPVar(sys map[string][]byte, after string) ([]byte, []byte) {
+	from, _ := parseOID(after)
+	var best []int
+	for k := range sys {
+		oid, _ := parseOID(k)
+		if compareOID(oid, from) > 0 && (best == nil || compareOID(oid, best) < 0) {
+			best = oid
+		}
+	}
+	if best == nil {
+		return encodeOID(from), []byte{snmpEndOfMibView, 0x00}
+	}
+	return encodeOID(best), sys[joinOID(best)]
+}
+
+func TestSNMPProbe(t *testing.T) {
+	descr := "Cisco IOS Software, C2960X Software (C2960X-UNIVERSALK9-M), Version 15.2(7)E8, RELEASE SOFTWARE (fc2)"
+	seen := snmpResponder(t, "private", map[string][]byte{
+		"1.3.6.1.2.1.1.1.0": berTLV(berOctetString, []byte(descr)),
+		"1.3.6.1.2.1.1.2.0": berTLV(berOID, encodeOID([]int{1, 3, 6, 1, 4, 1, 9, 1, 1208})),
+		"1.3.6.1.2.1.1.3.0": berTLV(0x43, []byte{0x01, 0x02, 0x03}),
+		"1.3.6.1.2.1.1.5.0": berTLV(berOctetString, []byte("access-sw1")),
+	})
+
+	res, err := SNMPProbe("127.0.0.1", "", 300*time.Millisecond)
+	if err != nil {
+		t.Fatalf("SNMPProbe: %v", err)
+	}
+	want := SNMPResult{
+		Community:   "private",
+		SysDescr:    descr,
+		SysObjectID: "1.3.6.1.4.1.9.1.1208",
+		SysName:     "access-sw1",
+	}
+	if *res != want {
+		t.Errorf("result = %+v, want %+v", *res, want)
+	}
+	for _, want := range []string{"public", "private"} {
+		if got := <-seen; got != want {
+			t.Errorf("community tried = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestSNMPProbeWrongCommunity(t *testing.T) {
+	snmpResponder(t, "s3cret", map[string][]byte{
+		"1.3.6.1.2.1.1.5.0": berTLV(berOctetString, []byte("core-rtr")),
+	})
+
+	if res, err := SNMPProbe("127.0.0.1", "public", 100*time.Millisecond); err == nil {
+		t.Fatalf("expected timeout, got %+v", res)
+	}
+
+	res, err := SNMPProbe("127.0.0.1", "s3cret", 300*time.Millisecond)
+	if err != nil {
+		t.Fatalf("SNMPProbe: %v", err)
+	}
+	if res.SysName != "core-rtr" || res.SysLocation != "" {
+		t.Errorf("result = %+v", *res)
+	}
+}
+
+func TestEncodeSNMPGet(t *testing.T) {
+	msg := encodeSNMPGet("public", 1, snmpSystemOIDs[:1])
+	// Must match the fixed v1 sysDescr probe apart from the version,
+	// request ID and PDU type
+	want := []byte{
+		0x30, 0x26, 0x02, 0x01, 0x01, 0x04, 0x06, 'p', 'u', 'b', 'l', 'i', 'c',
+		0xa0, 0x19, 0x02, 0x01, 0x01, 0x02, 0x01, 0x00, 0x02, 0x01, 0x00,
+		0x30, 0x0e, 0x30, 0x0c, 0x06, 0x08, 0x2b, 0x06, 0x01, 0x02, 0x01, 0x01, 0x01, 0x00,
+		0x05, 0x00,
+	}
+	if string(msg) != string(want) {
+		t.Errorf("encoded % x\nwant    % x", msg, want)
+	}
+}
+
+func TestSNMPGet(t *testing.T) {
+	snmpResponder(t, "public", map[string][]byte{
+		"1.3.6.1.2.1.1.5.0": berTLV(berOctetString, []byte("core-rtr")),
+		"1.3.6.1.2.1.1.3.0": berTLV(berTimeTicks, []byte{0x01, 0x00}),
+	})
+
+	vars, err := SNMPGet("127.0.0.1", "", []string{".1.3.6.1.2.1.1.5.0", "1.3.6.1.2.1.1.3.0", "1.3.6.1.2.1.1.9.0"}, 300*time.Millisecond)
+	if err != nil {
+		t.Fatalf("SNMPGet: %v", err)
+	}
+	want := []SNMPVar{
+		{OID: "1.3.6.1.2.1.1.5.0", Value: "core-rtr"},
+		{OID: "1.3.6.1.2.1.1.3.0", Value: "256 (2.56s)"},
+		{OID: "1.3.6.1.2.1.1.9.0", Value: "noSuchObject"},
+	}
+	if !reflect.DeepEqual(vars, want) {
+		t.Errorf("SNMPGet() = %v, want %v", vars, want)
+	}
+
+	if _, err := SNMPGet("127.0.0.1", "public", []string{"1.3.x"}, 100*time.Millisecond); err == nil {
+		t.Error("expected an error for an invalid OID")
+	}
+}
+
+func TestSNMPWalk(t *testing.T) {
+	snmpResponder(t, "public", map[string][]byte{
+		"1.3.6.1.2.1.1.1.0":     berTLV(berOctetString, []byte("Linux gw 6.1.0")),
+		"1.3.6.1.2.1.1.2.0":     berTLV(berOID, encodeOID([]int{1, 3, 6, 1, 4, 1, 8072, 3, 2, 10})),
+		"1.3.6.1.2.1.1.7.0":     berTLV(berInteger, []byte{72}),
+		"1.3.6.1.2.1.1.9.1.2.1": berTLV(berOID, encodeOID([]int{1, 3, 6, 1, 6, 3, 1})),
+		// Past the end of the system group
+		"1.3.6.1.2.1.2.1.0": berTLV(berInteger, []byte{4}),
+	})
+
+	vars, err := SNMPWalk("127.0.0.1", "public", SNMPSystemOID, 300*time.Millisecond)
+	if err != nil {
+		t.Fatalf("SNMPWalk: %v", err)
+	}
+	want := []SNMPVar{
+		{OID: "1.3.6.1.2.1.1.1.0", Value: "Linux gw 6.1.0"},
+		{OID: "1.3.6.1.2.1.1.2.0", Value: "1.3.6.1.4.1.8072.3.2.10"},
+		{OID: "1.3.6.1.2.1.1.7.0", Value: "72"},
+		{OID: "1.3.6.1.2.1.1.9.1.2.1", Value: "1.3.6.1.6.3.1"},
+	}
+	if !reflect.DeepEqual(vars, want) {
+		t.Errorf("SNMPWalk() = %v, want %v", vars, want)
+	}
+
+	// The walk also ends at endOfMibView
+	vars, err = SNMPWalk("127.0.0.1", "public", "1.3.6.1.2.1.2", 300*time.Millisecond)
+	if err != nil || len(vars) != 1 || vars[0].Value != "4" {
+		t.Errorf("SNMPWalk(ifNumber) = %v, %v", vars, err)
+	}
+}
+
+func TestSNMPWalkLimit(t *testing.T) {
+	sys := make(map[string][]byte)
+	for i := 1; i <= MaxSNMPWalkVars+10; i++ {
+		sys[fmt.Sprintf("1.3.6.1.2.1.2.2.1.1.%d", i)] = berTLV(berInteger, encodeInt(int64(i)))
+	}
+	snmpResponder(t, "public", sys)
+
+	vars, err := SNMPWalk("127.0.0.1", "public", "1.3.6.1.2.1.2.2", 300*time.Millisecond)
+	if err != nil {
+		t.Fatalf("SNMPWalk: %v", err)
+	}
+	if len(vars) != MaxSNMPWalkVars {
+		t.Errorf("SNMPWalk() returned %d vars, want %d", len(vars), MaxSNMPWalkVars)
+	}
+}
+
+func TestFormatSNMPValue(t *testing.T) {
+	tests := []struct {
+		tag   byte
+		value []byte
+		want  string
+	}{
+		{berOctetString, []byte("ap-lobby"), "ap-lobby"},
+		{berOctetString, []byte{0x00, 0x1b, 0x21}, "00 1b 21"},
+		{berInteger, []byte{0xff}, "-1"},
+		{berIPAddress, []byte{192, 168, 1, 1}, "192.168.1.1"},
+		{berCounter32, []byte{0x00, 0xff, 0xff, 0xff, 0xff}, "4294967295"},
+		{snmpEndOfMibView, nil, "endOfMibView"},
+	}
+	for _, tt := range tests {
+		if got := formatSNMPValue(tt.tag, tt.value); got != tt.want {
+			t.Errorf("formatSNMPValue(%#x, % x) = %q, want %q", tt.tag, tt.value, got, tt.want)
+		}
+	}
+}
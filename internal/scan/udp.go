@@ -0,0 +1,120 @@
+package scan
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+)
+
+// DefaultUDPPorts are the UDP services checked when ScanUDP is set without a
+// port list: TFTP, NTP, SNMP and syslog
+var DefaultUDPPorts = []int{69, 123, 161, 514}
+
+// udpReadTimeout is how long to wait for a UDP reply
+const udpReadTimeout = 200 * time.Millisecond
+
+// udpPayloads holds a probe for services that ignore empty datagrams
+var udpPayloads = map[int][]byte{
+	// NTP v3 client request
+	123: append([]byte{0x1b}, make([]byte, 47)...),
+	// SNMPv1 GetRequest for sysDescr.0 with community "public"
+	161: {
+		0x30, 0x26, 0x02, 0x01, 0x00, 0x04, 0x06, 'p', 'u', 'b', 'l', 'i', 'c',
+		0xa0, 0x19, 0x02, 0x01, 0x01, 0x02, 0x01, 0x00, 0x02, 0x01, 0x00,
+		0x30, 0x0e, 0x30, 0x0c, 0x06, 0x08, 0x2b, 0x06, 0x01, 0x02, 0x01, 0x01, 0x01, 0x00,
+		0x05, 0x00,
+	},
+}
+
+// dialUDP opens a socket for probing one UDP port; replaced in tests
+var dialUDP = func(address string, timeout time.Duration) (net.PacketConn, error) {
+	conn, err := net.DialTimeout("udp", address, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return connectedUDP{conn.(*net.UDPConn)}, nil
+}
+
+// connectedUDP sends to the dialled address, which lets the kernel report an
+// ICMP port unreachable as ECONNREFUSED on the next read
+type connectedUDP struct {
+	*net.UDPConn
+}
+
+func (c connectedUDP) WriteTo(b []byte, _ net.Addr) (int, error) {
+	return c.Write(b)
+}
+
+// scanUDPPort sends the port's probe and reports "open" if anything comes
+// back, "closed" on ICMP port unreachable and "open|filtered" on silence
+func scanUDPPort(host string, port int, timeout time.Duration) ServiceInfo {
+	service := ServiceInfo{
+		Port:     port,
+		Protocol: "udp",
+		State:    "closed",
+		Service:  getServiceName(port),
+	}
+
+	address := net.JoinHostPort(host, fmt.Sprintf("%d", port))
+	addr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return service
+	}
+	conn, err := dialUDP(address, timeout)
+	if err != nil {
+		return service
+	}
+	defer conn.Close()
+
+	if _, err := conn.WriteTo(udpPayloads[port], addr); err != nil {
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return service
+		}
+		service.State = "open|filtered"
+		return service
+	}
+
+	conn.SetReadDeadline(time.Now().Add(udpReadTimeout))
+	buf := make([]byte, 512)
+	n, _, err := conn.ReadFrom(buf)
+	switch {
+	case err == nil:
+		service.State = "open"
+		service.Banner = printableBanner(buf[:n])
+	case errors.Is(err, syscall.ECONNREFUSED):
+		// ICMP port unreachable
+	default:
+		service.State = "open|filtered"
+	}
+	return service
+}
+
+// printableBanner keeps the first 120 printable ASCII characters of a reply,
+// with each run of line breaks and tabs turned into a single space
+func printableBanner(data []byte) string {
+	out := make([]byte, 0, 121)
+	space := false
+	for _, b := range data {
+		switch {
+		case b == '\r' || b == '\n' || b == '\t':
+			space = len(out) > 0
+			continue
+		case b < 0x20 || b >= 0x7f:
+			continue
+		}
+		if space && b != ' ' {
+			out = append(out, ' ')
+		}
+		space = false
+		out = append(out, b)
+		if len(out) >= 120 {
+			break
+		}
+	}
+	if len(out) > 120 {
+		out = out[:120]
+	}
+	return string(out)
+}
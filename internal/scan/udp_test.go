@@ -0,0 +1,124 @@
+package scan
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakePacketConn answers a probe with reply, or fails the read with readErr
+type fakePacketConn struct {
+	reply   []byte
+	readErr error
+	sent    []byte
+	closed  bool
+}
+
+func (c *fakePacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	if c.readErr != nil {
+		return 0, nil, c.readErr
+	}
+	return copy(b, c.reply), &net.UDPAddr{}, nil
+}
+
+func (c *fakePacketConn) WriteTo(b []byte, _ net.Addr) (int, error) {
+	c.sent = append([]byte(nil), b...)
+	return len(b), nil
+}
+
+func (c *fakePacketConn) Close() error                     { c.closed = true; return nil }
+func (c *fakePacketConn) LocalAddr() net.Addr              { return &net.UDPAddr{} }
+func (c *fakePacketConn) SetDeadline(time.Time) error      { return nil }
+func (c *fakePacketConn) SetReadDeadline(time.Time) error  { return nil }
+func (c *fakePacketConn) SetWriteDeadline(time.Time) error { return nil }
+
+func stubDialUDP(t *testing.T, conn *fakePacketConn) *[]string {
+	t.Helper()
+	var dialled []string
+	orig := dialUDP
+	dialUDP = func(address string, _ time.Duration) (net.PacketConn, error) {
+		dialled = append(dialled, address)
+		return conn, nil
+	}
+	t.Cleanup(func() { dialUDP = orig })
+	return &dialled
+}
+
+func TestScanUDPPort(t *testing.T) {
+	tests := []struct {
+		name        string
+		port        int
+		conn        *fakePacketConn
+		wantState   string
+		wantBanner  string
+		wantPayload []byte
+	}{
+		{
+			name:        "snmp reply",
+			port:        161,
+			conn:        &fakePacketConn{reply: []byte("\x30\x2b\x02\x01\x00\x04\x06public\xa2\x1e Cisco IOS Software")},
+			wantState:   "open",
+			wantBanner:  "0+public Cisco IOS Software",
+			wantPayload: udpPayloads[161],
+		},
+		{
+			name:      "port unreachable",
+			port:      69,
+			conn:      &fakePacketConn{readErr: &net.OpError{Op: "read", Err: os.NewSyscallError("recvfrom", syscall.ECONNREFUSED)}},
+			wantState: "closed",
+		},
+		{
+			name:        "no reply",
+			port:        123,
+			conn:        &fakePacketConn{readErr: os.ErrDeadlineExceeded},
+			wantState:   "open|filtered",
+			wantPayload: udpPayloads[123],
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialled := stubDialUDP(t, tt.conn)
+
+			service := scanUDPPort("192.0.2.1", tt.port, 100*time.Millisecond)
+			if service.State != tt.wantState {
+				t.Errorf("State = %q, want %q", service.State, tt.wantState)
+			}
+			if service.Protocol != "udp" || service.Port != tt.port {
+				t.Errorf("service = %+v", service)
+			}
+			if service.Banner != tt.wantBanner {
+				t.Errorf("Banner = %q, want %q", service.Banner, tt.wantBanner)
+			}
+			if !bytes.Equal(tt.conn.sent, tt.wantPayload) {
+				t.Errorf("sent % x, want % x", tt.conn.sent, tt.wantPayload)
+			}
+			if len(*dialled) != 1 || (*dialled)[0] != net.JoinHostPort("192.0.2.1", strconv.Itoa(tt.port)) {
+				t.Errorf("dialled %v", *dialled)
+			}
+			if !tt.conn.closed {
+				t.Error("socket left open")
+			}
+		})
+	}
+}
+
+func TestSNMPPayloadLength(t *testing.T) {
+	p := udpPayloads[161]
+	if !bytes.HasPrefix(p, []byte{0x30, 0x26, 0x02, 0x01}) || int(p[1]) != len(p)-2 {
+		t.Errorf("SNMP probe header % x does not match its %d byte length", p[:4], len(p))
+	}
+}
+
+func TestHasOpenUDP(t *testing.T) {
+	if hasOpenUDP([]ServiceInfo{{Port: 123, State: "open|filtered"}}) {
+		t.Error("open|filtered alone should not count as open")
+	}
+	if !hasOpenUDP([]ServiceInfo{{Port: 123, State: "open|filtered"}, {Port: 161, State: "open"}}) {
+		t.Error("expected an open UDP service")
+	}
+}
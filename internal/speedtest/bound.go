@@ -0,0 +1,69 @@
+package speedtest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	netpkg "github.com/alexpitcher/LanAudit/internal/net"
+	"github.com/showwin/speedtest-go/speedtest"
+)
+
+// getInterfaceDetails is replaced in tests
+var getInterfaceDetails = netpkg.GetInterfaceDetails
+
+// RunBound performs a speedtest whose connections leave from ifaceName's
+// IPv4 address, so multi-homed hosts test the chosen interface rather than
+// the default route. The whole test must finish within timeout.
+func RunBound(ctx context.Context, ifaceName string, timeout time.Duration) (*Result, error) {
+	details, err := getInterfaceDetails(ifaceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get interface %s: %w", ifaceName, err)
+	}
+	boundIP := firstUsableIPv4(details.IPs)
+	if boundIP == nil {
+		return nil, fmt.Errorf("no usable IPv4 address on %s", ifaceName)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := speedtest.New(speedtest.WithDoer(&http.Client{Transport: boundTransport(boundIP)}))
+	return runTest(ctx, client)
+}
+
+// firstUsableIPv4 returns the first IPv4 address that isn't link-local or
+// loopback
+func firstUsableIPv4(ips []string) net.IP {
+	for _, s := range ips {
+		ip := net.ParseIP(s).To4()
+		if ip == nil || ip.IsLinkLocalUnicast() || ip.IsLoopback() {
+			continue
+		}
+		return ip
+	}
+	return nil
+}
+
+// boundTransport is an HTTP transport whose connections use ip as their
+// source address. It only dials IPv4, which ip can reach.
+func boundTransport(ip net.IP) *http.Transport {
+	dialer := &net.Dialer{
+		LocalAddr: &net.TCPAddr{IP: ip},
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: func(ctx context.Context, _, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "tcp4", addr)
+		},
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
@@ -0,0 +1,68 @@
+package speedtest
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	netpkg "github.com/alexpitcher/LanAudit/internal/net"
+)
+
+func TestFirstUsableIPv4(t *testing.T) {
+	tests := []struct {
+		name string
+		ips  []string
+		want string
+	}{
+		{name: "ipv4 after ipv6", ips: []string{"fe80::1", "2001:db8::5", "192.168.1.20"}, want: "192.168.1.20"},
+		{name: "skips link-local", ips: []string{"169.254.10.2", "10.0.0.7"}, want: "10.0.0.7"},
+		{name: "skips loopback", ips: []string{"127.0.0.1"}},
+		{name: "no addresses", ips: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := firstUsableIPv4(tt.ips)
+			if (got == nil && tt.want != "") || (got != nil && got.String() != tt.want) {
+				t.Errorf("firstUsableIPv4(%v) = %v, want %q", tt.ips, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBoundTransportUsesSourceAddress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.RemoteAddr)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: boundTransport(net.ParseIP("127.0.0.1"))}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET through bound transport failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if host, _, _ := net.SplitHostPort(string(body)); host != "127.0.0.1" {
+		t.Errorf("server saw connection from %q, want 127.0.0.1", body)
+	}
+}
+
+func TestRunBoundNeedsIPv4(t *testing.T) {
+	orig := getInterfaceDetails
+	t.Cleanup(func() { getInterfaceDetails = orig })
+
+	getInterfaceDetails = func(name string) (*netpkg.InterfaceDetails, error) {
+		return &netpkg.InterfaceDetails{Name: name, IPs: []string{"fe80::1", "169.254.3.4"}}, nil
+	}
+
+	_, err := RunBound(context.Background(), "en7", time.Second)
+	if err == nil || !strings.Contains(err.Error(), "no usable IPv4 address on en7") {
+		t.Errorf("RunBound() error = %v, want no usable IPv4 address", err)
+	}
+}
@@ -0,0 +1,109 @@
+package speedtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// Backends a speedtest can run against
+const (
+	BackendSpeedtestNet = "speedtest.net"
+	BackendIperf3       = "iperf3"
+)
+
+const (
+	// DefaultIperf3Port is the port iperf3 servers listen on
+	DefaultIperf3Port = 5201
+	// DefaultIperf3Duration is how long each direction of an iperf3 test
+	// transmits
+	DefaultIperf3Duration = 10 * time.Second
+)
+
+// lookPath and runIperf3Command are replaced in tests
+var (
+	lookPath = exec.LookPath
+
+	runIperf3Command = func(ctx context.Context, path string, args ...string) ([]byte, error) {
+		return exec.CommandContext(ctx, path, args...).Output()
+	}
+)
+
+// iperf3Output is the part of iperf3 -J output we use
+type iperf3Output struct {
+	End struct {
+		SumReceived struct {
+			BitsPerSecond float64 `json:"bits_per_second"`
+		} `json:"sum_received"`
+	} `json:"end"`
+	Error string `json:"error"`
+}
+
+// RunIperf3 measures throughput against an iperf3 server, for networks that
+// block speedtest.net. iperf3 only sends one way per run, so upload is a
+// normal run and download a reverse (-R) run, each lasting duration. A
+// port <= 0 uses DefaultIperf3Port.
+func RunIperf3(ctx context.Context, server string, port int, duration time.Duration) (*Result, error) {
+	if port <= 0 {
+		port = DefaultIperf3Port
+	}
+	secs := int(duration.Round(time.Second) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+
+	path, err := lookPath("iperf3")
+	if err != nil {
+		return nil, fmt.Errorf("iperf3 not found in PATH: install it (e.g. apt install iperf3 or brew install iperf3) or clear iperf3_server to use speedtest.net")
+	}
+
+	args := []string{"-c", server, "-p", strconv.Itoa(port), "-t", strconv.Itoa(secs), "-J"}
+	upload, err := runIperf3(ctx, path, args...)
+	if err != nil {
+		return nil, fmt.Errorf("upload: %w", err)
+	}
+	download, err := runIperf3(ctx, path, append(args, "-R")...)
+	if err != nil {
+		return nil, fmt.Errorf("download: %w", err)
+	}
+
+	return &Result{
+		Timestamp:    time.Now(),
+		DownloadMbps: download,
+		UploadMbps:   upload,
+		ServerName:   server,
+		ServerHost:   fmt.Sprintf("%s:%d", server, port),
+		Backend:      BackendIperf3,
+	}, nil
+}
+
+// runIperf3 runs one iperf3 test and returns its throughput in Mbps
+func runIperf3(ctx context.Context, path string, args ...string) (float64, error) {
+	out, runErr := runIperf3Command(ctx, path, args...)
+	mbps, err := parseIperf3Output(out)
+	if err != nil {
+		if runErr != nil {
+			return 0, fmt.Errorf("iperf3 failed: %w", runErr)
+		}
+		return 0, err
+	}
+	return mbps, nil
+}
+
+// parseIperf3Output reads the throughput in Mbps from iperf3 -J output, as
+// measured by the receiving side (sum_received), whichever way the run
+// sent. iperf3 reports failures such as a refused connection in the JSON
+// error field.
+func parseIperf3Output(data []byte) (float64, error) {
+	var out iperf3Output
+	if err := json.Unmarshal(data, &out); err != nil {
+		return 0, fmt.Errorf("failed to parse iperf3 output: %w", err)
+	}
+	if out.Error != "" {
+		return 0, fmt.Errorf("iperf3: %s", out.Error)
+	}
+	return out.End.SumReceived.BitsPerSecond / 1000000.0, nil
+}
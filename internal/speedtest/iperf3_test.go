@@ -0,0 +1,115 @@
+package speedtest
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseIperf3Output(t *testing.T) {
+	tests := []struct {
+		file string
+		want float64
+	}{
+		{file: "iperf3_upload.json", want: 939.05},   // receiver side of a normal run
+		{file: "iperf3_download.json", want: 612.40}, // receiver side of a -R run
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			mbps, err := parseIperf3Output(data)
+			if err != nil {
+				t.Fatalf("parseIperf3Output() error = %v", err)
+			}
+			if math.Abs(mbps-tt.want) > 0.01 {
+				t.Errorf("parseIperf3Output() = %.2f Mbps, want %.2f", mbps, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseIperf3OutputErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{name: "server error", data: `{"start":{},"end":{},"error":"unable to connect to server: Connection refused"}`, want: "Connection refused"},
+		{name: "not json", data: "iperf3: parameter error", want: "failed to parse"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseIperf3Output([]byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("parseIperf3Output() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunIperf3(t *testing.T) {
+	origLook, origRun := lookPath, runIperf3Command
+	t.Cleanup(func() { lookPath, runIperf3Command = origLook, origRun })
+
+	upload, err := os.ReadFile("testdata/iperf3_upload.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	download, err := os.ReadFile("testdata/iperf3_download.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var gotArgs []string
+	lookPath = func(string) (string, error) { return "/usr/bin/iperf3", nil }
+	runIperf3Command = func(_ context.Context, _ string, args ...string) ([]byte, error) {
+		gotArgs = append(gotArgs, strings.Join(args, " "))
+		if args[len(args)-1] == "-R" {
+			return download, nil
+		}
+		return upload, nil
+	}
+
+	res, err := RunIperf3(context.Background(), "192.168.1.10", 0, 5*time.Second)
+	if err != nil {
+		t.Fatalf("RunIperf3() error = %v", err)
+	}
+	want := []string{"-c 192.168.1.10 -p 5201 -t 5 -J", "-c 192.168.1.10 -p 5201 -t 5 -J -R"}
+	if strings.Join(gotArgs, "|") != strings.Join(want, "|") {
+		t.Errorf("iperf3 runs = %q, want %q", gotArgs, want)
+	}
+	if math.Abs(res.UploadMbps-939.05) > 0.01 || math.Abs(res.DownloadMbps-612.40) > 0.01 {
+		t.Errorf("upload/download = %.2f/%.2f Mbps, want 939.05/612.40", res.UploadMbps, res.DownloadMbps)
+	}
+	if res.Backend != BackendIperf3 {
+		t.Errorf("Backend = %q, want %q", res.Backend, BackendIperf3)
+	}
+	if res.ServerHost != "192.168.1.10:5201" {
+		t.Errorf("ServerHost = %q, want 192.168.1.10:5201", res.ServerHost)
+	}
+	if !strings.Contains(FormatResult(res), "iperf3") {
+		t.Errorf("FormatResult() should name the iperf3 backend, got:\n%s", FormatResult(res))
+	}
+}
+
+func TestRunIperf3NotInstalled(t *testing.T) {
+	origLook := lookPath
+	t.Cleanup(func() { lookPath = origLook })
+
+	lookPath = func(string) (string, error) { return "", errors.New("executable file not found in $PATH") }
+
+	_, err := RunIperf3(context.Background(), "192.168.1.10", 5201, time.Second)
+	if err == nil || !strings.Contains(err.Error(), "iperf3 not found") {
+		t.Errorf("RunIperf3() error = %v, want an iperf3 not found message", err)
+	}
+}
@@ -1,6 +1,7 @@
 package speedtest
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 
 // Result contains speedtest results
 type Result struct {
+	Timestamp    time.Time
 	DownloadMbps float64
 	UploadMbps   float64
 	Latency      time.Duration
@@ -18,6 +20,7 @@ type Result struct {
 	ServerHost   string
 	Distance     float64
 	IsStub       bool
+	Backend      string // BackendSpeedtestNet or BackendIperf3
 }
 
 // Run performs a real speedtest using the speedtest-go library
@@ -27,13 +30,19 @@ func Run() (*Result, error) {
 
 // RunWithTimeout performs a speedtest with a custom timeout
 func RunWithTimeout(timeout time.Duration) (*Result, error) {
+	return runTest(context.Background(), speedtest.New())
+}
+
+// runTest measures latency, download and upload against the closest server
+// found by client
+func runTest(ctx context.Context, client *speedtest.Speedtest) (*Result, error) {
 	// Fetch server list
-	user, err := speedtest.FetchUserInfo()
+	user, err := client.FetchUserInfoContext(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch user info: %w", err)
 	}
 
-	serverList, err := speedtest.FetchServers()
+	serverList, err := client.FetchServerListContext(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch servers: %w", err)
 	}
@@ -52,25 +61,26 @@ func RunWithTimeout(timeout time.Duration) (*Result, error) {
 	server := targets[0]
 
 	// Test latency
-	err = server.PingTest(nil)
+	err = server.PingTestContext(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("ping test failed: %w", err)
 	}
 
 	// Test download speed
-	err = server.DownloadTest()
+	err = server.DownloadTestContext(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("download test failed: %w", err)
 	}
 
 	// Test upload speed
-	err = server.UploadTest()
+	err = server.UploadTestContext(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("upload test failed: %w", err)
 	}
 
 	// Build result
 	result := &Result{
+		Timestamp: time.Now(),
 		// Library returns raw bits per second, convert to Mbps
 		DownloadMbps: float64(server.DLSpeed) / 1000000.0,
 		UploadMbps:   float64(server.ULSpeed) / 1000000.0,
@@ -80,6 +90,7 @@ func RunWithTimeout(timeout time.Duration) (*Result, error) {
 		ServerHost:   server.Host,
 		Distance:     server.Distance,
 		IsStub:       false,
+		Backend:      BackendSpeedtestNet,
 	}
 
 	// Calculate jitter if available
@@ -108,6 +119,17 @@ func FormatResult(r *Result) string {
 		return "Speedtest not available (stub mode)"
 	}
 
+	if r.Backend == BackendIperf3 {
+		return fmt.Sprintf(`Speedtest Results (iperf3):
+  Server: %s
+  Download: %.2f Mbps
+  Upload: %.2f Mbps`,
+			r.ServerHost,
+			r.DownloadMbps,
+			r.UploadMbps,
+		)
+	}
+
 	return fmt.Sprintf(`Speedtest Results:
   Server: %s (%s)
   Distance: %.2f km
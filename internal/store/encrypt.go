@@ -0,0 +1,122 @@
+package store
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// EncryptedSnapshotExt is appended to the file name of encrypted snapshots
+	EncryptedSnapshotExt = ".enc"
+
+	snapshotSaltSize      = 16
+	snapshotKeySize       = 32 // AES-256
+	snapshotKDFIterations = 600000
+)
+
+var (
+	// ErrPassphraseRequired is returned when encrypt_snapshots is set but no
+	// passphrase was configured or supplied
+	ErrPassphraseRequired = errors.New("snapshot encryption is enabled but no passphrase is set")
+
+	// ErrDecrypt is returned for a wrong passphrase or a damaged file, which
+	// GCM cannot tell apart
+	ErrDecrypt = errors.New("failed to decrypt snapshot: wrong passphrase or corrupted file")
+)
+
+// SaveEncryptedSnapshot saves a snapshot encrypted with passphrase, whatever
+// the encrypt_snapshots setting, for when the passphrase was entered
+// interactively rather than stored in the config
+func SaveEncryptedSnapshot(snap *Snapshot, passphrase string) (string, error) {
+	if passphrase == "" {
+		return "", ErrPassphraseRequired
+	}
+	return saveSnapshot(snap, passphrase)
+}
+
+// LoadEncryptedSnapshot reads and decrypts a snapshot written with
+// encryption enabled
+func LoadEncryptedSnapshot(path, passphrase string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := decryptSnapshotData(data, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(plain, &snap); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+	return &snap, nil
+}
+
+// deriveSnapshotKey stretches a passphrase into an AES-256 key
+func deriveSnapshotKey(passphrase string, salt []byte) []byte {
+	return pbkdf2.Key([]byte(passphrase), salt, snapshotKDFIterations, snapshotKeySize, sha256.New)
+}
+
+// encryptSnapshotData seals data with AES-256-GCM under a key derived from
+// passphrase and a fresh salt. The result is salt + nonce + ciphertext.
+func encryptSnapshotData(data []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, snapshotSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	gcm, err := newSnapshotGCM(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	out := make([]byte, 0, len(salt)+len(nonce)+len(data)+gcm.Overhead())
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, data, nil), nil
+}
+
+// decryptSnapshotData reverses encryptSnapshotData
+func decryptSnapshotData(data []byte, passphrase string) ([]byte, error) {
+	if len(data) < snapshotSaltSize {
+		return nil, ErrDecrypt
+	}
+	salt := data[:snapshotSaltSize]
+	gcm, err := newSnapshotGCM(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	rest := data[snapshotSaltSize:]
+	if len(rest) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, ErrDecrypt
+	}
+	nonce, ciphertext := rest[:gcm.NonceSize()], rest[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plain, nil
+}
+
+func newSnapshotGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(deriveSnapshotKey(passphrase, salt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return gcm, nil
+}
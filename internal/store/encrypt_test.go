@@ -0,0 +1,94 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncryptedSnapshotRoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	config := DefaultConfig()
+	config.EncryptSnapshots = true
+	config.EncryptPassphrase = "correct horse battery staple"
+	snap := &Snapshot{
+		Timestamp: time.Date(2024, 3, 1, 9, 30, 15, 0, time.UTC),
+		Hostname:  "field-laptop",
+		Interface: "en0",
+		Settings:  config,
+	}
+
+	path, err := SaveSnapshot(snap)
+	if err != nil {
+		t.Fatalf("SaveSnapshot() error = %v", err)
+	}
+	if !strings.HasSuffix(path, ".json"+EncryptedSnapshotExt) {
+		t.Errorf("path = %s, want a .json.enc file", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("field-laptop")) || bytes.Contains(data, []byte(config.EncryptPassphrase)) {
+		t.Error("encrypted file contains plaintext")
+	}
+
+	got, err := LoadEncryptedSnapshot(path, config.EncryptPassphrase)
+	if err != nil {
+		t.Fatalf("LoadEncryptedSnapshot() error = %v", err)
+	}
+	if got.Hostname != snap.Hostname || !got.Timestamp.Equal(snap.Timestamp) {
+		t.Errorf("decrypted snapshot = %+v, want %+v", got, snap)
+	}
+	if got.Settings == nil || !got.Settings.EncryptSnapshots || got.Settings.EncryptPassphrase != "" {
+		t.Errorf("decrypted settings = %+v, want encryption on and no passphrase", got.Settings)
+	}
+
+	if _, err := LoadEncryptedSnapshot(path, "wrong"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("LoadEncryptedSnapshot() with wrong passphrase error = %v, want ErrDecrypt", err)
+	}
+}
+
+func TestSaveSnapshotPassphraseRequired(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	config := DefaultConfig()
+	config.EncryptSnapshots = true
+	snap := &Snapshot{Timestamp: time.Now(), Interface: "en0", Settings: config}
+
+	if _, err := SaveSnapshot(snap); !errors.Is(err, ErrPassphraseRequired) {
+		t.Errorf("SaveSnapshot() error = %v, want ErrPassphraseRequired", err)
+	}
+
+	path, err := SaveEncryptedSnapshot(snap, "typed at the prompt")
+	if err != nil {
+		t.Fatalf("SaveEncryptedSnapshot() error = %v", err)
+	}
+	if _, err := LoadEncryptedSnapshot(path, "typed at the prompt"); err != nil {
+		t.Errorf("LoadEncryptedSnapshot() error = %v", err)
+	}
+}
+
+func TestDecryptSnapshotDataRejectsDamage(t *testing.T) {
+	sealed, err := encryptSnapshotData([]byte(`{"hostname":"x"}`), "pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := append([]byte(nil), sealed...)
+	tampered[len(tampered)-1] ^= 0x01
+
+	for name, data := range map[string][]byte{
+		"truncated": sealed[:snapshotSaltSize+4],
+		"tampered":  tampered,
+		"empty":     nil,
+	} {
+		if _, err := decryptSnapshotData(data, "pass"); !errors.Is(err, ErrDecrypt) {
+			t.Errorf("%s: error = %v, want ErrDecrypt", name, err)
+		}
+	}
+}
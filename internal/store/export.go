@@ -0,0 +1,91 @@
+package store
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// snapshotCSVHeader is the column layout written by ExportSnapshotCSV
+var snapshotCSVHeader = []string{
+	"timestamp_utc", "hostname", "interface", "ips", "gateway", "dns_servers",
+	"packets_rx", "packets_tx", "bytes_rx", "bytes_tx", "redacted",
+}
+
+// ExportSnapshotJSON writes a snapshot as indented JSON, the format
+// SaveSnapshot uses on disk
+func ExportSnapshotJSON(snap *Snapshot, w io.Writer) error {
+	if snap.Redacted {
+		snap = redactSnapshot(snap)
+	}
+	snap = withoutSecrets(snap)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(snap); err != nil {
+		return fmt.Errorf("failed to write JSON: %w", err)
+	}
+	return nil
+}
+
+// ExportSnapshotYAML writes a snapshot as YAML
+func ExportSnapshotYAML(snap *Snapshot, w io.Writer) error {
+	if snap.Redacted {
+		snap = redactSnapshot(snap)
+	}
+	snap = withoutSecrets(snap)
+	enc := yaml.NewEncoder(w)
+	if err := enc.Encode(snap); err != nil {
+		return fmt.Errorf("failed to write YAML: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("failed to write YAML: %w", err)
+	}
+	return nil
+}
+
+// ExportSnapshotCSV writes the key fields of a snapshot as a header row and a
+// single data row. Lists are joined with "; " so each stays in one cell.
+func ExportSnapshotCSV(snap *Snapshot, w io.Writer) error {
+	if snap.Redacted {
+		snap = redactSnapshot(snap)
+	}
+	details := snapshotDetails(snap)
+
+	gateway := details.IPv4Gateway()
+	if gateway == "" && len(details.DefaultGateways) > 0 {
+		gateway = details.DefaultGateways[0]
+	}
+
+	row := []string{
+		// Spreadsheets parse this layout as a date, unlike RFC 3339
+		snap.Timestamp.UTC().Format("2006-01-02 15:04:05"),
+		snap.Hostname,
+		snap.Interface,
+		strings.Join(details.IPs, "; "),
+		gateway,
+		strings.Join(details.DNSServers, "; "),
+		strconv.FormatUint(details.PacketsRx, 10),
+		strconv.FormatUint(details.PacketsTx, 10),
+		strconv.FormatUint(details.BytesRx, 10),
+		strconv.FormatUint(details.BytesTx, 10),
+		strconv.FormatBool(snap.Redacted),
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(snapshotCSVHeader); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	if err := cw.Write(row); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
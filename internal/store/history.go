@@ -0,0 +1,118 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexpitcher/LanAudit/internal/logging"
+)
+
+// DiagDir holds per-interface diagnostic history files
+const DiagDir = "diag"
+
+// GetDiagDir returns the diagnostic history directory path
+func GetDiagDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, DefaultConfigDir, DiagDir), nil
+}
+
+// diagHistoryPath returns the JSONL history file for an interface
+func diagHistoryPath(iface string) (string, error) {
+	dir, err := GetDiagDir()
+	if err != nil {
+		return "", err
+	}
+	// Interface names never contain separators, but don't trust that
+	name := strings.NewReplacer("/", "_", "\\", "_").Replace(iface)
+	return filepath.Join(dir, name+".jsonl"), nil
+}
+
+// SaveDiagnosticResult appends a diagnostics result to the interface's history.
+// The result is taken as interface{} since diagnostics imports store.
+func SaveDiagnosticResult(iface string, r interface{}) error {
+	path, err := diagHistoryPath(iface)
+	if err != nil {
+		return err
+	}
+	return appendJSONL(path, r)
+}
+
+// LoadDiagnosticHistory returns up to limit of the most recent results for an
+// interface, oldest first. A limit <= 0 returns the full history.
+func LoadDiagnosticHistory[T any](iface string, limit int) ([]*T, error) {
+	path, err := diagHistoryPath(iface)
+	if err != nil {
+		return nil, err
+	}
+	return loadJSONL[T](path, limit)
+}
+
+// appendJSONL appends v as one line of a JSONL file, creating it if needed
+func appendJSONL(path string, v interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		logging.Errorf("appendJSONL: marshal error: %v", err)
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		logging.Errorf("appendJSONL: open error: %v", err)
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		logging.Errorf("appendJSONL: write error: %v", err)
+		return err
+	}
+	return nil
+}
+
+// loadJSONL reads up to limit of the last entries in a JSONL file, oldest
+// first. A missing file is an empty history.
+func loadJSONL[T any](path string, limit int) ([]*T, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var history []*T
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		entry := new(T)
+		if err := json.Unmarshal(line, entry); err != nil {
+			// A partially written line shouldn't hide the rest of the history
+			logging.Warnf("loadJSONL: skipping malformed entry in %s: %v", path, err)
+			continue
+		}
+		history = append(history, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if limit > 0 && len(history) > limit {
+		history = history[len(history)-limit:]
+	}
+	return history, nil
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type testDiagResult struct {
+	Gateway string
+	Loss    float64
+}
+
+func TestDiagnosticHistoryRoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	for i := 0; i < 7; i++ {
+		if err := SaveDiagnosticResult("en0", &testDiagResult{Gateway: "192.168.1.1", Loss: float64(i * 10)}); err != nil {
+			t.Fatalf("SaveDiagnosticResult() error = %v", err)
+		}
+	}
+	if err := SaveDiagnosticResult("en1", &testDiagResult{Loss: 99}); err != nil {
+		t.Fatalf("SaveDiagnosticResult() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		limit    int
+		wantLoss []float64
+	}{
+		{name: "limited", limit: 3, wantLoss: []float64{40, 50, 60}},
+		{name: "limit above size", limit: 20, wantLoss: []float64{0, 10, 20, 30, 40, 50, 60}},
+		{name: "unlimited", limit: 0, wantLoss: []float64{0, 10, 20, 30, 40, 50, 60}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history, err := LoadDiagnosticHistory[testDiagResult]("en0", tt.limit)
+			if err != nil {
+				t.Fatalf("LoadDiagnosticHistory() error = %v", err)
+			}
+			if len(history) != len(tt.wantLoss) {
+				t.Fatalf("got %d entries, want %d", len(history), len(tt.wantLoss))
+			}
+			for i, want := range tt.wantLoss {
+				if history[i].Loss != want || history[i].Gateway != "192.168.1.1" {
+					t.Errorf("entry %d = %+v, want loss %v", i, history[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadDiagnosticHistoryMissingAndMalformed(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	history, err := LoadDiagnosticHistory[testDiagResult]("en0", 5)
+	if err != nil || history != nil {
+		t.Fatalf("missing history = %v, %v; want nil, nil", history, err)
+	}
+
+	dir := filepath.Join(home, DefaultConfigDir, DiagDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	data := "{\"Loss\":1}\n{\"Loss\":\n\n{\"Loss\":2}\n"
+	if err := os.WriteFile(filepath.Join(dir, "en0.jsonl"), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err = LoadDiagnosticHistory[testDiagResult]("en0", 0)
+	if err != nil {
+		t.Fatalf("LoadDiagnosticHistory() error = %v", err)
+	}
+	if len(history) != 2 || history[0].Loss != 1 || history[1].Loss != 2 {
+		t.Errorf("history = %+v, want the two valid entries", history)
+	}
+}
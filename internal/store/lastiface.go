@@ -0,0 +1,48 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LastIfaceFile records the interface picked in the last session
+const LastIfaceFile = "last_iface"
+
+// GetLastInterfacePath returns the path of the last-interface file
+func GetLastInterfacePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, DefaultConfigDir, LastIfaceFile), nil
+}
+
+// SaveLastInterface records the interface to preselect next launch
+func SaveLastInterface(name string) error {
+	path, err := GetLastInterfacePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(name+"\n"), 0644)
+}
+
+// LoadLastInterface returns the interface saved by SaveLastInterface, or ""
+// if none was saved
+func LoadLastInterface() (string, error) {
+	path, err := GetLastInterfacePath()
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,46 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLastInterfaceRoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	name, err := LoadLastInterface()
+	if err != nil || name != "" {
+		t.Fatalf("LoadLastInterface() with no file = %q, %v; want \"\", nil", name, err)
+	}
+
+	if err := SaveLastInterface("en7"); err != nil {
+		t.Fatalf("SaveLastInterface() error = %v", err)
+	}
+	if name, err := LoadLastInterface(); err != nil || name != "en7" {
+		t.Errorf("LoadLastInterface() = %q, %v; want en7", name, err)
+	}
+}
+
+func TestRememberInterfaceDefaultsOn(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path, err := GetConfigPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// A config written before the setting existed
+	if err := os.WriteFile(path, []byte(`{"redact": true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !config.RememberInterface {
+		t.Error("RememberInterface should default to true for older configs")
+	}
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ProfilesDir holds named configs, one <name>.json per network segment
+const ProfilesDir = "profiles"
+
+// profileNamePattern keeps profile names usable as file names
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// activeProfile is the profile LoadConfig and SaveConfig use instead of
+// config.json; empty means config.json
+var activeProfile string
+
+// GetProfilesDir returns the profiles directory path
+func GetProfilesDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, DefaultConfigDir, ProfilesDir), nil
+}
+
+// GetProfilePath returns the path of a named profile
+func GetProfilePath(name string) (string, error) {
+	if !profileNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid profile name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+	dir, err := GetProfilesDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+".json"), nil
+}
+
+// SaveProfile saves a config as a named profile
+func SaveProfile(name string, c *Config) error {
+	path, err := GetProfilePath(name)
+	if err != nil {
+		return err
+	}
+	return saveConfigFile(path, c)
+}
+
+// LoadProfile loads a named profile, or the default config if there is no
+// profile of that name
+func LoadProfile(name string) (*Config, error) {
+	path, err := GetProfilePath(name)
+	if err != nil {
+		return nil, err
+	}
+	return loadConfigFile(path)
+}
+
+// ListProfiles returns the names of the saved profiles, sorted
+func ListProfiles() ([]string, error) {
+	dir, err := GetProfilesDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), ".json")
+		if !e.IsDir() && name != e.Name() && profileNamePattern.MatchString(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// SetActiveProfile makes LoadConfig and SaveConfig use the named profile
+// rather than config.json. An empty name switches back to config.json.
+func SetActiveProfile(name string) error {
+	if name != "" && !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+	activeProfile = name
+	return nil
+}
+
+// ActiveProfile returns the profile in use, or "" for config.json
+func ActiveProfile() string {
+	return activeProfile
+}
@@ -0,0 +1,102 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestProfileRoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	lab := DefaultConfig()
+	lab.DNSAlternates = []string{"9.9.9.9"}
+	lab.Console.DefaultBauds = []int{9600}
+	lab.Redact = true
+	if err := SaveProfile("lab", lab); err != nil {
+		t.Fatalf("SaveProfile() error = %v", err)
+	}
+
+	got, err := LoadProfile("lab")
+	if err != nil {
+		t.Fatalf("LoadProfile() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, lab) {
+		t.Errorf("LoadProfile() = %+v, want %+v", got, lab)
+	}
+}
+
+func TestLoadProfileMissingUsesDefaults(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	got, err := LoadProfile("nowhere")
+	if err != nil {
+		t.Fatalf("LoadProfile() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, DefaultConfig()) {
+		t.Errorf("LoadProfile() = %+v, want DefaultConfig()", got)
+	}
+}
+
+func TestProfileNames(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	for _, name := range []string{"", "../config", ".hidden", "a/b", "with space"} {
+		if err := SaveProfile(name, DefaultConfig()); err == nil {
+			t.Errorf("SaveProfile(%q) accepted an invalid name", name)
+		}
+	}
+
+	for _, name := range []string{"office", "dc-2", "branch_01"} {
+		if err := SaveProfile(name, DefaultConfig()); err != nil {
+			t.Fatalf("SaveProfile(%q) error = %v", name, err)
+		}
+	}
+	dir, _ := GetProfilesDir()
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := ListProfiles()
+	if err != nil {
+		t.Fatalf("ListProfiles() error = %v", err)
+	}
+	if want := []string{"branch_01", "dc-2", "office"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("ListProfiles() = %v, want %v", names, want)
+	}
+}
+
+func TestActiveProfileRedirectsConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Cleanup(func() { SetActiveProfile("") })
+
+	if err := SetActiveProfile("lab"); err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultConfig()
+	config.DiagnosticsTimeout = 5000
+	if err := SaveConfig(config); err != nil {
+		t.Fatalf("SaveConfig() error = %v", err)
+	}
+
+	lab, err := LoadProfile("lab")
+	if err != nil || lab.DiagnosticsTimeout != 5000 {
+		t.Errorf("profile timeout = %v (err %v), want 5000", lab, err)
+	}
+
+	if err := SetActiveProfile(""); err != nil {
+		t.Fatal(err)
+	}
+	main, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if main.DiagnosticsTimeout != DefaultConfig().DiagnosticsTimeout {
+		t.Errorf("config.json timeout = %d, want the default", main.DiagnosticsTimeout)
+	}
+
+	if err := SetActiveProfile("../escape"); err == nil {
+		t.Error("SetActiveProfile() accepted an invalid name")
+	}
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/alexpitcher/LanAudit/internal/logging"
+)
+
+// RetentionEnabled reports whether either snapshot limit is set
+func (c *Config) RetentionEnabled() bool {
+	return c.SnapshotMaxAge > 0 || c.SnapshotMaxCount > 0
+}
+
+// PruneSnapshots deletes the snapshots that fall outside the configured
+// SnapshotMaxAge and SnapshotMaxCount, then rewrites the index without them.
+// Nothing is deleted when neither limit is set.
+func PruneSnapshots() (deleted int, err error) {
+	config, err := LoadConfig()
+	if err != nil {
+		return 0, err
+	}
+	if !config.RetentionEnabled() {
+		return 0, nil
+	}
+
+	snapsDir, err := GetSnapshotsDir()
+	if err != nil {
+		return 0, err
+	}
+	indexPath := filepath.Join(snapsDir, IndexFile)
+
+	data, err := os.ReadFile(indexPath)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read snapshot index: %w", err)
+	}
+	var index SnapshotIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return 0, fmt.Errorf("failed to parse snapshot index: %w", err)
+	}
+
+	keep, drop := snapshotsToPrune(index.Snapshots, config.SnapshotMaxAge, config.SnapshotMaxCount, time.Now())
+	if len(drop) == 0 {
+		return 0, nil
+	}
+
+	// An entry whose file can't be removed stays indexed so a later prune
+	// retries it
+	var removeErr error
+	for _, s := range drop {
+		path := filepath.Join(snapsDir, filepath.Base(s.Filename))
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			logging.Warnf("PruneSnapshots: failed to remove %s: %v", path, err)
+			if removeErr == nil {
+				removeErr = fmt.Errorf("failed to remove snapshot %s: %w", s.Filename, err)
+			}
+			keep = append(keep, s)
+			continue
+		}
+		deleted++
+	}
+	sort.SliceStable(keep, func(i, j int) bool { return keep[i].Timestamp.Before(keep[j].Timestamp) })
+
+	index.Snapshots = keep
+	data, err = json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return deleted, err
+	}
+	if err := os.WriteFile(indexPath, data, 0644); err != nil {
+		return deleted, fmt.Errorf("failed to write snapshot index: %w", err)
+	}
+	logging.Infof("PruneSnapshots: deleted %d snapshots, %d remain", deleted, len(keep))
+
+	return deleted, removeErr
+}
+
+// snapshotsToPrune splits index entries into those to keep and those older
+// than maxAge or beyond the newest maxCount. A zero limit is not applied.
+// Both slices are ordered oldest first.
+func snapshotsToPrune(entries []SnapshotSummary, maxAge time.Duration, maxCount int, now time.Time) (keep, drop []SnapshotSummary) {
+	sorted := make([]SnapshotSummary, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp.Before(sorted[j].Timestamp) })
+
+	firstKept := 0
+	if maxCount > 0 && len(sorted) > maxCount {
+		firstKept = len(sorted) - maxCount
+	}
+	for i, s := range sorted {
+		if i < firstKept || (maxAge > 0 && now.Sub(s.Timestamp) > maxAge) {
+			drop = append(drop, s)
+		} else {
+			keep = append(keep, s)
+		}
+	}
+	return keep, drop
+}
@@ -0,0 +1,157 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// writeSnapshotIndex creates an empty snapshot file for each entry and an
+// index listing them
+func writeSnapshotIndex(t *testing.T, entries []SnapshotSummary) string {
+	t.Helper()
+	dir, err := GetSnapshotsDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if err := os.WriteFile(filepath.Join(dir, e.Filename), []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := json.Marshal(SnapshotIndex{Snapshots: entries})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, IndexFile), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func snapshotFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		if e.Name() != IndexFile {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestPruneSnapshots(t *testing.T) {
+	now := time.Now()
+	entry := func(name string, age time.Duration) SnapshotSummary {
+		return SnapshotSummary{Timestamp: now.Add(-age), Filename: name, Interface: "en0"}
+	}
+	// Listed out of order, as an index edited by hand might be
+	entries := []SnapshotSummary{
+		entry("d.json", 2*time.Hour),
+		entry("a.json", 30*24*time.Hour),
+		entry("e.json", time.Hour),
+		entry("b.json", 10*24*time.Hour),
+		entry("c.json", 3*24*time.Hour),
+	}
+
+	tests := []struct {
+		name     string
+		maxAge   time.Duration
+		maxCount int
+		want     []string
+	}{
+		{name: "no limits", want: []string{"a.json", "b.json", "c.json", "d.json", "e.json"}},
+		{name: "max age", maxAge: 7 * 24 * time.Hour, want: []string{"c.json", "d.json", "e.json"}},
+		{name: "max count", maxCount: 2, want: []string{"d.json", "e.json"}},
+		{name: "count under limit", maxCount: 10, want: []string{"a.json", "b.json", "c.json", "d.json", "e.json"}},
+		{name: "both", maxAge: 5 * time.Hour, maxCount: 3, want: []string{"d.json", "e.json"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			config := DefaultConfig()
+			config.SnapshotMaxAge = tt.maxAge
+			config.SnapshotMaxCount = tt.maxCount
+			if err := SaveConfig(config); err != nil {
+				t.Fatal(err)
+			}
+			dir := writeSnapshotIndex(t, entries)
+
+			deleted, err := PruneSnapshots()
+			if err != nil {
+				t.Fatalf("PruneSnapshots() error = %v", err)
+			}
+			if want := len(entries) - len(tt.want); deleted != want {
+				t.Errorf("deleted = %d, want %d", deleted, want)
+			}
+			if got := snapshotFiles(t, dir); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("remaining files = %v, want %v", got, tt.want)
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, IndexFile))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var index SnapshotIndex
+			if err := json.Unmarshal(data, &index); err != nil {
+				t.Fatal(err)
+			}
+			var indexed []string
+			for _, s := range index.Snapshots {
+				indexed = append(indexed, s.Filename)
+			}
+			if deleted == 0 {
+				return // index left untouched
+			}
+			if !reflect.DeepEqual(indexed, tt.want) {
+				t.Errorf("index = %v, want %v", indexed, tt.want)
+			}
+		})
+	}
+}
+
+func TestPruneSnapshotsMissingFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	config := DefaultConfig()
+	config.SnapshotMaxCount = 1
+	if err := SaveConfig(config); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	dir := writeSnapshotIndex(t, []SnapshotSummary{
+		{Timestamp: now.Add(-2 * time.Hour), Filename: "old.json"},
+		{Timestamp: now, Filename: "new.json"},
+	})
+	// Already deleted by hand; pruning still drops it from the index
+	if err := os.Remove(filepath.Join(dir, "old.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := PruneSnapshots(); err != nil {
+		t.Fatalf("PruneSnapshots() error = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, IndexFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var index SnapshotIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Snapshots) != 1 || index.Snapshots[0].Filename != "new.json" {
+		t.Errorf("index = %+v, want only new.json", index.Snapshots)
+	}
+}
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alexpitcher/LanAudit/internal/logging"
+	"github.com/alexpitcher/LanAudit/internal/scan"
+)
+
+// ScansDir holds saved gateway audit results
+const ScansDir = "scans"
+
+// GetScansDir returns the saved audit directory path
+func GetScansDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, DefaultConfigDir, ScansDir), nil
+}
+
+// scanFilePrefix is the start of every saved audit file for a gateway
+func scanFilePrefix(gateway string) string {
+	return strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(gateway) + "-"
+}
+
+// SaveScanResult writes an audit to scans/<gateway>-<timestamp>.json
+func SaveScanResult(result *scan.ScanResult) error {
+	dir, err := GetScansDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	ts := result.StartTime
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	path := filepath.Join(dir, scanFilePrefix(result.Gateway)+ts.Format("20060102-150405")+".json")
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		logging.Errorf("SaveScanResult: marshal error: %v", err)
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		logging.Errorf("SaveScanResult: write error: %v", err)
+		return err
+	}
+	logging.Infof("SaveScanResult: wrote %s", path)
+	return nil
+}
+
+// LoadScanHistory returns up to limit of the most recent saved audits of a
+// gateway, oldest first. A limit <= 0 returns them all.
+func LoadScanHistory(gateway string, limit int) ([]*scan.ScanResult, error) {
+	dir, err := GetScansDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Timestamps sort lexically, so the newest files are last
+	prefix := scanFilePrefix(gateway)
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), prefix) && strings.HasSuffix(e.Name(), ".json") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	if limit > 0 && len(names) > limit {
+		names = names[len(names)-limit:]
+	}
+
+	results := make([]*scan.ScanResult, 0, len(names))
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		var res scan.ScanResult
+		if err := json.Unmarshal(data, &res); err != nil {
+			logging.Warnf("LoadScanHistory: skipping malformed audit %s: %v", name, err)
+			continue
+		}
+		results = append(results, &res)
+	}
+	return results, nil
+}
+
+// LoadLastScanResult returns the most recent saved audit of a gateway, or nil
+// if there is none
+func LoadLastScanResult(gateway string) (*scan.ScanResult, error) {
+	results, err := LoadScanHistory(gateway, 1)
+	if err != nil || len(results) == 0 {
+		return nil, err
+	}
+	return results[0], nil
+}
@@ -0,0 +1,56 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alexpitcher/LanAudit/internal/scan"
+)
+
+func TestScanResultRoundTrip(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	if res, err := LoadLastScanResult("192.168.1.1"); err != nil || res != nil {
+		t.Fatalf("LoadLastScanResult() with no saved audits = %v, %v; want nil, nil", res, err)
+	}
+
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		res := &scan.ScanResult{
+			Gateway:     "192.168.1.1",
+			StartTime:   start.Add(time.Duration(i) * time.Hour),
+			ActiveHosts: i,
+			Hosts:       []scan.HostResult{{IP: "192.168.1.1", OsHint: "Network device"}},
+		}
+		if err := SaveScanResult(res); err != nil {
+			t.Fatalf("SaveScanResult() error = %v", err)
+		}
+	}
+	// Another gateway whose address starts with the same digits
+	if err := SaveScanResult(&scan.ScanResult{Gateway: "192.168.1.10", StartTime: start.Add(5 * time.Hour), ActiveHosts: 99}); err != nil {
+		t.Fatalf("SaveScanResult() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(home, DefaultConfigDir, ScansDir, "192.168.1.1-20240301-090000.json")); err != nil {
+		t.Errorf("expected audit file: %v", err)
+	}
+
+	last, err := LoadLastScanResult("192.168.1.1")
+	if err != nil {
+		t.Fatalf("LoadLastScanResult() error = %v", err)
+	}
+	if last == nil || last.ActiveHosts != 2 || len(last.Hosts) != 1 || last.Hosts[0].OsHint != "Network device" {
+		t.Errorf("LoadLastScanResult() = %+v, want the 11:00 audit", last)
+	}
+
+	history, err := LoadScanHistory("192.168.1.1", 2)
+	if err != nil {
+		t.Fatalf("LoadScanHistory() error = %v", err)
+	}
+	if len(history) != 2 || history[0].ActiveHosts != 1 || history[1].ActiveHosts != 2 {
+		t.Errorf("LoadScanHistory() = %+v, want the last two audits oldest first", history)
+	}
+}
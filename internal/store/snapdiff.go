@@ -0,0 +1,214 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/alexpitcher/LanAudit/internal/logging"
+	netpkg "github.com/alexpitcher/LanAudit/internal/net"
+	"github.com/alexpitcher/LanAudit/internal/scan"
+)
+
+// SnapshotDiff is what changed on an interface between two snapshots
+type SnapshotDiff struct {
+	From           string          `json:"from"` // timestamps of the compared snapshots
+	To             string          `json:"to"`
+	IPsAdded       []string        `json:"ips_added,omitempty"`
+	IPsRemoved     []string        `json:"ips_removed,omitempty"`
+	GatewayChanged bool            `json:"gateway_changed"`
+	OldGateway     string          `json:"old_gateway,omitempty"`
+	NewGateway     string          `json:"new_gateway,omitempty"`
+	DNSChanged     bool            `json:"dns_changed"`
+	OldDNS         []string        `json:"old_dns,omitempty"`
+	NewDNS         []string        `json:"new_dns,omitempty"`
+	PortsAdded     []ServiceChange `json:"ports_added,omitempty"`
+	PortsRemoved   []ServiceChange `json:"ports_removed,omitempty"`
+}
+
+// ServiceChange is an open port that appeared or disappeared between the
+// audits recorded in two snapshots
+type ServiceChange struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"`
+	Service  string `json:"service,omitempty"`
+}
+
+// Empty reports whether the snapshots recorded the same network state
+func (d *SnapshotDiff) Empty() bool {
+	return len(d.IPsAdded) == 0 && len(d.IPsRemoved) == 0 && !d.GatewayChanged && !d.DNSChanged &&
+		len(d.PortsAdded) == 0 && len(d.PortsRemoved) == 0
+}
+
+// DiffSnapshots compares the interface details and audit of snapshot a with
+// those of the later snapshot b
+func DiffSnapshots(a, b *Snapshot) *SnapshotDiff {
+	diff := &SnapshotDiff{
+		From: a.Timestamp.Format("2006-01-02 15:04:05"),
+		To:   b.Timestamp.Format("2006-01-02 15:04:05"),
+	}
+	da, db := snapshotDetails(a), snapshotDetails(b)
+
+	diff.IPsAdded = missingFrom(db.IPs, da.IPs)
+	diff.IPsRemoved = missingFrom(da.IPs, db.IPs)
+
+	if oldGW, newGW := da.IPv4Gateway(), db.IPv4Gateway(); oldGW != newGW {
+		diff.GatewayChanged = true
+		diff.OldGateway, diff.NewGateway = oldGW, newGW
+	}
+
+	// Resolver order matters, so any reordering counts as a change
+	if !reflect.DeepEqual(da.DNSServers, db.DNSServers) && (len(da.DNSServers) > 0 || len(db.DNSServers) > 0) {
+		diff.DNSChanged = true
+		diff.OldDNS, diff.NewDNS = da.DNSServers, db.DNSServers
+	}
+
+	oldPorts, newPorts := openServices(a.Audit), openServices(b.Audit)
+	for key, svc := range newPorts {
+		if _, ok := oldPorts[key]; !ok {
+			diff.PortsAdded = append(diff.PortsAdded, svc)
+		}
+	}
+	for key, svc := range oldPorts {
+		if _, ok := newPorts[key]; !ok {
+			diff.PortsRemoved = append(diff.PortsRemoved, svc)
+		}
+	}
+	sortServiceChanges(diff.PortsAdded)
+	sortServiceChanges(diff.PortsRemoved)
+
+	return diff
+}
+
+// snapshotDetails recovers the interface details of a snapshot. Details is
+// an interface{} that a loaded snapshot holds as a generic map, so it is
+// re-marshaled into its concrete type.
+func snapshotDetails(snap *Snapshot) netpkg.InterfaceDetails {
+	var details netpkg.InterfaceDetails
+	if snap.Details == nil {
+		return details
+	}
+	data, err := json.Marshal(snap.Details)
+	if err != nil {
+		logging.Warnf("snapshotDetails: marshal error: %v", err)
+		return details
+	}
+	if err := json.Unmarshal(data, &details); err != nil {
+		logging.Warnf("snapshotDetails: details of %s snapshot unreadable: %v", snap.Interface, err)
+	}
+	return details
+}
+
+// missingFrom returns the entries of a not in b, in a's order
+func missingFrom(a, b []string) []string {
+	seen := make(map[string]bool, len(b))
+	for _, s := range b {
+		seen[s] = true
+	}
+	var out []string
+	for _, s := range a {
+		if !seen[s] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// serviceKey identifies a port on a host
+type serviceKey struct {
+	host     string
+	port     int
+	protocol string
+}
+
+// openServices indexes the open ports of an audit
+func openServices(res *scan.ScanResult) map[serviceKey]ServiceChange {
+	services := make(map[serviceKey]ServiceChange)
+	if res == nil {
+		return services
+	}
+	for _, h := range res.Hosts {
+		for _, s := range h.Services {
+			if s.State == "open" {
+				services[serviceKey{h.IP, s.Port, "tcp"}] = ServiceChange{Host: h.IP, Port: s.Port, Protocol: "tcp", Service: s.Service}
+			}
+		}
+		for _, s := range h.UDPServices {
+			if s.State == "open" {
+				services[serviceKey{h.IP, s.Port, "udp"}] = ServiceChange{Host: h.IP, Port: s.Port, Protocol: "udp", Service: s.Service}
+			}
+		}
+	}
+	return services
+}
+
+func sortServiceChanges(changes []ServiceChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.Host != b.Host {
+			return hostLess(a.Host, b.Host)
+		}
+		if a.Port != b.Port {
+			return a.Port < b.Port
+		}
+		return a.Protocol < b.Protocol
+	})
+}
+
+// hostLess orders addresses numerically, falling back to string order
+func hostLess(a, b string) bool {
+	ipA, ipB := net.ParseIP(a), net.ParseIP(b)
+	if ipA == nil || ipB == nil {
+		return a < b
+	}
+	return bytes.Compare(ipA.To16(), ipB.To16()) < 0
+}
+
+// LoadRecentSnapshots returns up to limit of the most recent saved
+// snapshots, oldest first
+func LoadRecentSnapshots(limit int) ([]*Snapshot, error) {
+	dir, err := GetSnapshotsDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Snapshot files are named by timestamp, so the newest sort last
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && e.Name() != IndexFile && strings.HasSuffix(e.Name(), ".json") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	if limit > 0 && len(names) > limit {
+		names = names[len(names)-limit:]
+	}
+
+	snaps := make([]*Snapshot, 0, len(names))
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		var snap Snapshot
+		if err := json.Unmarshal(data, &snap); err != nil {
+			logging.Warnf("LoadRecentSnapshots: skipping malformed snapshot %s: %v", name, err)
+			continue
+		}
+		snaps = append(snaps, &snap)
+	}
+	return snaps, nil
+}
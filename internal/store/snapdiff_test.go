@@ -0,0 +1,140 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	netpkg "github.com/alexpitcher/LanAudit/internal/net"
+	"github.com/alexpitcher/LanAudit/internal/scan"
+)
+
+// snapPair returns two snapshots of en0 a day apart with the given details
+// and audits
+func snapPair(oldDetails, newDetails *netpkg.InterfaceDetails, oldAudit, newAudit *scan.ScanResult) (*Snapshot, *Snapshot) {
+	t0 := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	a := &Snapshot{Timestamp: t0, Interface: "en0", Details: oldDetails, Audit: oldAudit}
+	b := &Snapshot{Timestamp: t0.Add(24 * time.Hour), Interface: "en0", Details: newDetails, Audit: newAudit}
+	return a, b
+}
+
+func auditWith(ip string, services ...scan.ServiceInfo) *scan.ScanResult {
+	return &scan.ScanResult{Hosts: []scan.HostResult{{IP: ip, Services: services}}}
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	base := netpkg.InterfaceDetails{
+		IPs:             []string{"192.168.1.20", "fe80::1"},
+		DefaultGateways: []string{"192.168.1.1"},
+		DNSServers:      []string{"192.168.1.1", "1.1.1.1"},
+	}
+	ssh := scan.ServiceInfo{Port: 22, State: "open", Service: "SSH"}
+	https := scan.ServiceInfo{Port: 443, State: "open", Service: "HTTPS"}
+	telnet := scan.ServiceInfo{Port: 23, State: "closed", Service: "Telnet"}
+
+	tests := []struct {
+		name   string
+		mutate func(d *netpkg.InterfaceDetails)
+		audits [2]*scan.ScanResult
+		check  func(t *testing.T, d *SnapshotDiff)
+	}{
+		{
+			name: "unchanged",
+			check: func(t *testing.T, d *SnapshotDiff) {
+				if !d.Empty() {
+					t.Errorf("expected no changes, got %+v", d)
+				}
+			},
+		},
+		{
+			name:   "address renumbered",
+			mutate: func(d *netpkg.InterfaceDetails) { d.IPs = []string{"192.168.1.57", "fe80::1"} },
+			check: func(t *testing.T, d *SnapshotDiff) {
+				if !reflect.DeepEqual(d.IPsAdded, []string{"192.168.1.57"}) || !reflect.DeepEqual(d.IPsRemoved, []string{"192.168.1.20"}) {
+					t.Errorf("IPsAdded = %v, IPsRemoved = %v", d.IPsAdded, d.IPsRemoved)
+				}
+			},
+		},
+		{
+			name:   "gateway changed",
+			mutate: func(d *netpkg.InterfaceDetails) { d.DefaultGateways = []string{"192.168.1.254"} },
+			check: func(t *testing.T, d *SnapshotDiff) {
+				if !d.GatewayChanged || d.OldGateway != "192.168.1.1" || d.NewGateway != "192.168.1.254" {
+					t.Errorf("gateway change = %v %q -> %q", d.GatewayChanged, d.OldGateway, d.NewGateway)
+				}
+				if d.DNSChanged {
+					t.Error("DNS should be unchanged")
+				}
+			},
+		},
+		{
+			name:   "dns reordered",
+			mutate: func(d *netpkg.InterfaceDetails) { d.DNSServers = []string{"1.1.1.1", "192.168.1.1"} },
+			check: func(t *testing.T, d *SnapshotDiff) {
+				if !d.DNSChanged || !reflect.DeepEqual(d.NewDNS, []string{"1.1.1.1", "192.168.1.1"}) {
+					t.Errorf("DNS change = %v %v -> %v", d.DNSChanged, d.OldDNS, d.NewDNS)
+				}
+			},
+		},
+		{
+			name:   "ports opened and closed",
+			audits: [2]*scan.ScanResult{auditWith("192.168.1.1", ssh, telnet), auditWith("192.168.1.1", https, telnet)},
+			check: func(t *testing.T, d *SnapshotDiff) {
+				wantAdded := []ServiceChange{{Host: "192.168.1.1", Port: 443, Protocol: "tcp", Service: "HTTPS"}}
+				wantRemoved := []ServiceChange{{Host: "192.168.1.1", Port: 22, Protocol: "tcp", Service: "SSH"}}
+				if !reflect.DeepEqual(d.PortsAdded, wantAdded) || !reflect.DeepEqual(d.PortsRemoved, wantRemoved) {
+					t.Errorf("PortsAdded = %+v, PortsRemoved = %+v", d.PortsAdded, d.PortsRemoved)
+				}
+			},
+		},
+		{
+			name:   "audit only in newer snapshot",
+			audits: [2]*scan.ScanResult{nil, auditWith("192.168.1.1", ssh)},
+			check: func(t *testing.T, d *SnapshotDiff) {
+				if len(d.PortsAdded) != 1 || len(d.PortsRemoved) != 0 {
+					t.Errorf("PortsAdded = %+v, PortsRemoved = %+v", d.PortsAdded, d.PortsRemoved)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldDetails, newDetails := base, base
+			if tt.mutate != nil {
+				tt.mutate(&newDetails)
+			}
+			a, b := snapPair(&oldDetails, &newDetails, tt.audits[0], tt.audits[1])
+			tt.check(t, DiffSnapshots(a, b))
+		})
+	}
+}
+
+func TestLoadRecentSnapshotsDiffsLoadedDetails(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	a, b := snapPair(
+		&netpkg.InterfaceDetails{IPs: []string{"10.0.0.5"}, DefaultGateways: []string{"10.0.0.1"}},
+		&netpkg.InterfaceDetails{IPs: []string{"10.0.0.5"}, DefaultGateways: []string{"10.0.0.254"}},
+		nil, nil,
+	)
+	for _, snap := range []*Snapshot{a, b} {
+		if _, err := SaveSnapshot(snap); err != nil {
+			t.Fatalf("SaveSnapshot() error = %v", err)
+		}
+	}
+
+	snaps, err := LoadRecentSnapshots(2)
+	if err != nil {
+		t.Fatalf("LoadRecentSnapshots() error = %v", err)
+	}
+	if len(snaps) != 2 || !snaps[0].Timestamp.Equal(a.Timestamp) {
+		t.Fatalf("loaded %d snapshots, want the two saved oldest first", len(snaps))
+	}
+
+	// Loaded details are generic maps; the diff must still see the gateway
+	diff := DiffSnapshots(snaps[0], snaps[1])
+	if !diff.GatewayChanged || diff.NewGateway != "10.0.0.254" || len(diff.IPsAdded) != 0 {
+		t.Errorf("unexpected diff of loaded snapshots: %+v", diff)
+	}
+}
@@ -0,0 +1,44 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/alexpitcher/LanAudit/internal/speedtest"
+)
+
+// SpeedtestHistoryFile holds past speedtest results, one JSON object per line
+const SpeedtestHistoryFile = "speedtest.jsonl"
+
+// GetSpeedtestHistoryPath returns the speedtest history file path
+func GetSpeedtestHistoryPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, DefaultConfigDir, SpeedtestHistoryFile), nil
+}
+
+// SaveSpeedtestResult appends a speedtest result to the history, stamping it
+// with the current time if it has none
+func SaveSpeedtestResult(r *speedtest.Result) error {
+	path, err := GetSpeedtestHistoryPath()
+	if err != nil {
+		return err
+	}
+	if r.Timestamp.IsZero() {
+		r.Timestamp = time.Now()
+	}
+	return appendJSONL(path, r)
+}
+
+// LoadSpeedtestHistory returns up to limit of the most recent speedtest
+// results, oldest first. A limit <= 0 returns the full history.
+func LoadSpeedtestHistory(limit int) ([]*speedtest.Result, error) {
+	path, err := GetSpeedtestHistoryPath()
+	if err != nil {
+		return nil, err
+	}
+	return loadJSONL[speedtest.Result](path, limit)
+}
@@ -0,0 +1,40 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/alexpitcher/LanAudit/internal/speedtest"
+)
+
+func TestSpeedtestHistoryRoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	history, err := LoadSpeedtestHistory(10)
+	if err != nil || history != nil {
+		t.Fatalf("missing history = %v, %v; want nil, nil", history, err)
+	}
+
+	for i := 1; i <= 12; i++ {
+		r := &speedtest.Result{DownloadMbps: float64(i * 100), UploadMbps: 20, Backend: speedtest.BackendIperf3}
+		if err := SaveSpeedtestResult(r); err != nil {
+			t.Fatalf("SaveSpeedtestResult() error = %v", err)
+		}
+		if r.Timestamp.IsZero() {
+			t.Fatal("SaveSpeedtestResult() should stamp results without a timestamp")
+		}
+	}
+
+	history, err = LoadSpeedtestHistory(10)
+	if err != nil {
+		t.Fatalf("LoadSpeedtestHistory() error = %v", err)
+	}
+	if len(history) != 10 {
+		t.Fatalf("got %d results, want 10", len(history))
+	}
+	if history[0].DownloadMbps != 300 || history[9].DownloadMbps != 1200 {
+		t.Errorf("history spans %v..%v Mbps, want 300..1200", history[0].DownloadMbps, history[9].DownloadMbps)
+	}
+	if history[0].Backend != speedtest.BackendIperf3 || history[0].Timestamp.IsZero() {
+		t.Errorf("history[0] = %+v, want backend and timestamp preserved", history[0])
+	}
+}
@@ -2,14 +2,23 @@ package store
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/alexpitcher/LanAudit/internal/capture"
+	"github.com/alexpitcher/LanAudit/internal/console"
 	"github.com/alexpitcher/LanAudit/internal/logging"
+	netpkg "github.com/alexpitcher/LanAudit/internal/net"
+	"github.com/alexpitcher/LanAudit/internal/scan"
 )
 
 const (
@@ -19,17 +28,94 @@ const (
 	IndexFile        = "index.json"
 )
 
+// How much of an IP address redaction masks
+const (
+	RedactLastOctet = 1 // 192.168.1.xxx, and the last 4 IPv6 groups
+	RedactTwoOctets = 2 // 192.168.xxx.xxx, and the last 6 IPv6 groups
+	RedactFull      = 3 // xxx.xxx.xxx.xxx, and every IPv6 group
+)
+
 var (
-	ipPattern  = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
-	macPattern = regexp.MustCompile(`\b[0-9A-Fa-f]{2}(?::[0-9A-Fa-f]{2}){5}\b`)
+	ipPattern   = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+	ipv6Pattern = regexp.MustCompile(`(?:[0-9A-Fa-f]{0,4}:){2,7}[0-9A-Fa-f]{0,4}`)
+	macPattern  = regexp.MustCompile(`\b[0-9A-Fa-f]{2}(?::[0-9A-Fa-f]{2}){5}\b`)
 )
 
 // Config holds application configuration
 type Config struct {
-	DNSAlternates      []string      `json:"dns_alternates"`
-	DiagnosticsTimeout int           `json:"diagnostics_timeout_ms"`
-	Redact             bool          `json:"redact"`
-	Console            ConsoleConfig `json:"console"`
+	DNSAlternates      []string          `json:"dns_alternates"`
+	DiagnosticsTimeout int               `json:"diagnostics_timeout_ms"`
+	Redact             bool              `json:"redact"`
+	RedactLevel        int               `json:"redact_level,omitempty"` // RedactLastOctet..RedactFull; 0 means RedactLastOctet
+	ProbeMTU           bool              `json:"probe_mtu"`
+	Traceroute         bool              `json:"traceroute"`
+	CheckNTP           bool              `json:"check_ntp"`
+	CertWarnDays       int               `json:"cert_warn_days"`
+	Concurrent         bool              `json:"concurrent"`
+	ProbeTargets       []string          `json:"probe_targets"`
+	Console            ConsoleConfig     `json:"console"`
+	Fingerprint        FingerprintConfig `json:"fingerprint"`
+	// ScanPorts are the TCP ports a gateway audit checks; empty uses
+	// scan.CommonPorts
+	ScanPorts []int `json:"scan_ports,omitempty"`
+	// Iperf3Server, when set, runs speedtests against this iperf3 server
+	// instead of speedtest.net; a zero Iperf3Port uses iperf3's default
+	Iperf3Server string `json:"iperf3_server,omitempty"`
+	Iperf3Port   int    `json:"iperf3_port,omitempty"`
+	// SnapshotMaxAge and SnapshotMaxCount bound how many saved snapshots
+	// PruneSnapshots keeps; zero means no limit. The age is stored in
+	// nanoseconds, as JSON encodes a time.Duration.
+	SnapshotMaxAge   time.Duration `json:"snapshot_max_age,omitempty"`
+	SnapshotMaxCount int           `json:"snapshot_max_count,omitempty"`
+	// EncryptSnapshots saves snapshots AES-256-GCM encrypted with a key
+	// derived from EncryptPassphrase; the TUI asks for the passphrase when
+	// it isn't set here
+	EncryptSnapshots  bool   `json:"encrypt_snapshots,omitempty"`
+	EncryptPassphrase string `json:"encrypt_passphrase,omitempty"`
+	// Theme sets the TUI colors
+	Theme ThemeConfig `json:"theme"`
+	// RememberInterface preselects the last interface used in the picker
+	RememberInterface bool `json:"remember_interface"`
+	// LogLevel is the least severe level written to the log: debug, info,
+	// warn or error; empty means info
+	LogLevel string `json:"log_level,omitempty"`
+	// LogFormat is how log lines are written: text or json; empty means text
+	LogFormat string `json:"log_format,omitempty"`
+	// LogMaxBytes is the size at which log.txt is rotated; 0 means 10 MB
+	LogMaxBytes int `json:"log_max_bytes,omitempty"`
+	// LogMaxFiles is how many rotated logs are kept; 0 means 5
+	LogMaxFiles int `json:"log_max_files,omitempty"`
+	// Syslog forwards log entries to a syslog server as well as log.txt
+	Syslog SyslogConfig `json:"syslog"`
+	// APIToken is the bearer token the HTTP API (--api) requires on every
+	// endpoint but /api/interfaces; empty disables those endpoints
+	APIToken string `json:"api_token,omitempty"`
+	// WebhookURL receives a JSON POST when diagnostics fail and
+	// WebhookOnFailure is set; WebhookSecret, when set, signs the body with
+	// HMAC-SHA256 in the X-LanAudit-Signature header
+	WebhookURL       string `json:"webhook_url,omitempty"`
+	WebhookOnFailure bool   `json:"webhook_on_failure,omitempty"`
+	WebhookSecret    string `json:"webhook_secret,omitempty"`
+}
+
+// SyslogConfig sets where log entries are forwarded
+type SyslogConfig struct {
+	Enabled bool `json:"enabled"`
+	// Address is host:port (UDP), udp://host:port or tcp://host:port; empty
+	// means the local syslog daemon
+	Address string `json:"address,omitempty"`
+	// Facility is a syslog facility name such as user or local0; empty
+	// means user
+	Facility string `json:"facility,omitempty"`
+}
+
+// FingerprintConfig tunes console device identification
+type FingerprintConfig struct {
+	// MinProbeConfidence is the probability, from 0 to 1, a candidate needs
+	// before a safe probe command is sent to the device
+	MinProbeConfidence float64 `json:"min_probe_confidence"`
+	// MaxEvidence caps the evidence lines kept per result; 0 uses the default
+	MaxEvidence int `json:"max_evidence"`
 }
 
 // ConsoleConfig holds serial console settings
@@ -40,19 +126,36 @@ type ConsoleConfig struct {
 	LogByDefault           bool   `json:"log_by_default"`
 	BreakDurationMs        int    `json:"break_ms"`
 	AllowProbeInConfigMode bool   `json:"allow_probe_in_config_mode"`
+	StripANSI              bool   `json:"strip_ansi"`
+
+	// IdleTimeoutMs reopens a serial port that has been silent this long,
+	// up to MaxReconnects times; 0 disables it
+	IdleTimeoutMs int `json:"idle_timeout_ms,omitempty"`
+	MaxReconnects int `json:"max_reconnects,omitempty"`
+
+	// TelnetTargets are "host:port" console server ports listed next to
+	// local serial ports
+	TelnetTargets []string `json:"telnet_targets,omitempty"`
+
+	// Alerts maps an alert name to a regular expression matched against
+	// each line of console output
+	Alerts map[string]string `json:"alerts,omitempty"`
 }
 
 // Snapshot represents a point-in-time capture of network state
 type Snapshot struct {
-	Timestamp   time.Time        `json:"timestamp"`
-	Hostname    string           `json:"hostname"`
-	Interface   string           `json:"interface"`
-	Details     interface{}      `json:"details"`
-	Diagnostics interface{}      `json:"diagnostics,omitempty"`
-	VLANResults interface{}      `json:"vlan_results,omitempty"`
-	Console     *ConsoleSnapshot `json:"console,omitempty"`
-	Settings    *Config          `json:"settings"`
-	Redacted    bool             `json:"redacted"`
+	Timestamp   time.Time         `json:"timestamp" yaml:"timestamp"`
+	Hostname    string            `json:"hostname" yaml:"hostname"`
+	Interface   string            `json:"interface" yaml:"interface"`
+	Details     interface{}       `json:"details" yaml:"details"`
+	Diagnostics interface{}       `json:"diagnostics,omitempty" yaml:"diagnostics,omitempty"`
+	VLANResults interface{}       `json:"vlan_results,omitempty" yaml:"vlan_results,omitempty"`
+	ARPTable    []netpkg.ARPEntry `json:"arp_table,omitempty" yaml:"arp_table,omitempty"`
+	Audit       *scan.ScanResult  `json:"audit,omitempty" yaml:"audit,omitempty"` // open ports, compared by DiffSnapshots
+	Console     *ConsoleSnapshot  `json:"console,omitempty" yaml:"console,omitempty"`
+	Capture     *capture.Stats    `json:"capture,omitempty" yaml:"capture,omitempty"` // protocol breakdown of a capture taken in the TUI
+	Settings    *Config           `json:"settings" yaml:"settings"`
+	Redacted    bool              `json:"redacted" yaml:"redacted"`
 }
 
 // ConsoleSnapshot captures console session summary
@@ -70,15 +173,16 @@ type ConsoleSnapshot struct {
 
 // ConsoleFingerprint captures structured identification data for the console target.
 type ConsoleFingerprint struct {
-	Vendor     string    `json:"vendor"`
-	OS         string    `json:"os"`
-	Model      string    `json:"model,omitempty"`
-	Stage      string    `json:"stage"`
-	Prompt     string    `json:"prompt,omitempty"`
-	Baud       int       `json:"baud"`
-	Confidence float64   `json:"confidence"`
-	Evidence   []string  `json:"evidence,omitempty"`
-	Timestamp  time.Time `json:"timestamp"`
+	Vendor       string    `json:"vendor"`
+	OS           string    `json:"os"`
+	Model        string    `json:"model,omitempty"`
+	SerialNumber string    `json:"serial_number,omitempty"`
+	Stage        string    `json:"stage"`
+	Prompt       string    `json:"prompt,omitempty"`
+	Baud         int       `json:"baud"`
+	Confidence   float64   `json:"confidence"`
+	Evidence     []string  `json:"evidence,omitempty"`
+	Timestamp    time.Time `json:"timestamp"`
 }
 
 // SnapshotIndex tracks all snapshots
@@ -94,8 +198,12 @@ type SnapshotSummary struct {
 	Hostname  string    `json:"hostname"`
 }
 
-// GetConfigPath returns the full path to config file
+// GetConfigPath returns the full path to config file, or to the active
+// profile if one is set
 func GetConfigPath() (string, error) {
+	if activeProfile != "" {
+		return GetProfilePath(activeProfile)
+	}
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
@@ -112,42 +220,214 @@ func GetSnapshotsDir() (string, error) {
 	return filepath.Join(home, DefaultConfigDir, SnapshotsDir), nil
 }
 
-// LoadConfig loads configuration from disk
+// LoadConfig loads configuration from disk, from the active profile if set
 func LoadConfig() (*Config, error) {
 	configPath, err := GetConfigPath()
 	if err != nil {
 		logging.Errorf("LoadConfig: failed to resolve path: %v", err)
 		return nil, err
 	}
+	return loadConfigFile(configPath)
+}
 
+// loadConfigFile reads a config or profile file
+func loadConfigFile(configPath string) (*Config, error) {
 	// Return defaults if config doesn't exist
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		logging.Warnf("LoadConfig: config missing, using defaults")
+		logging.Warnf("loadConfigFile: %s missing, using defaults", configPath)
 		return DefaultConfig(), nil
 	}
 
 	data, err := os.ReadFile(configPath)
 	if err != nil {
-		logging.Errorf("LoadConfig: read error: %v", err)
+		logging.Errorf("loadConfigFile: read error: %v", err)
 		return nil, err
 	}
 
-	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
-		logging.Errorf("LoadConfig: parse error: %v", err)
+	// Start from defaults so settings missing from older files keep their default
+	config := DefaultConfig()
+	if err := json.Unmarshal(data, config); err != nil {
+		logging.Errorf("loadConfigFile: parse error in %s: %v", configPath, err)
 		return nil, err
 	}
-	logging.Infof("LoadConfig: loaded settings from %s", configPath)
+	// Problems are reported but not fatal, so the settings view can show them
+	for _, e := range config.Validate() {
+		logging.Warnf("loadConfigFile: %v", e)
+	}
+	logging.Infof("loadConfigFile: loaded settings from %s", configPath)
+
+	return config, nil
+}
 
-	return &config, nil
+// ConfigError describes a config setting that can't be used. Field is the
+// setting's key in the config file, with nested keys joined by dots.
+type ConfigError struct {
+	Field   string
+	Message string
 }
 
-// SaveConfig saves configuration to disk
+func (e ConfigError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Field, e.Message)
+}
+
+// validCRLFModes are the line ending modes a console session supports
+var validCRLFModes = []string{"CRLF", "CR", "LF"}
+
+// Validate checks that configured values are usable and returns every
+// problem found
+func (c *Config) Validate() []ConfigError {
+	var errs []ConfigError
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, ConfigError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, alt := range c.DNSAlternates {
+		if strings.HasPrefix(alt, "https://") {
+			if u, err := url.Parse(alt); err != nil || u.Host == "" {
+				add("dns_alternates", "%q is not a valid DoH URL", alt)
+			}
+			continue
+		}
+		ip := net.ParseIP(alt)
+		if ip == nil {
+			add("dns_alternates", "%q is not an IP address or https:// DoH URL", alt)
+			continue
+		}
+		// Alternates are stored in snapshots, so keep LAN addresses out of
+		// unredacted ones
+		if ip.IsPrivate() && !c.Redact {
+			add("dns_alternates", "%s is a private address; enable redact to store it", alt)
+		}
+	}
+	if c.RedactLevel < 0 || c.RedactLevel > RedactFull {
+		add("redact_level", "%d must be between %d and %d", c.RedactLevel, RedactLastOctet, RedactFull)
+	}
+	if c.DiagnosticsTimeout <= 0 {
+		add("diagnostics_timeout_ms", "%d must be positive", c.DiagnosticsTimeout)
+	} else if ValidateTimeout(time.Duration(c.DiagnosticsTimeout)*time.Millisecond) != nil {
+		add("diagnostics_timeout_ms", "%d must be between %d and %d", c.DiagnosticsTimeout, MinDiagnosticsTimeout.Milliseconds(), MaxDiagnosticsTimeout.Milliseconds())
+	}
+	for _, target := range c.ProbeTargets {
+		u, err := url.Parse(target)
+		if err != nil {
+			add("probe_targets", "%q: %v", target, err)
+			continue
+		}
+		if u.Scheme != "https" || u.Host == "" {
+			add("probe_targets", "%q must be an https:// URL", target)
+		}
+	}
+	if c.Fingerprint.MinProbeConfidence < 0 || c.Fingerprint.MinProbeConfidence > 1 {
+		add("fingerprint.min_probe_confidence", "%v must be between 0.0 and 1.0", c.Fingerprint.MinProbeConfidence)
+	}
+	if c.Fingerprint.MaxEvidence < 0 {
+		add("fingerprint.max_evidence", "%d must not be negative", c.Fingerprint.MaxEvidence)
+	}
+	for _, port := range c.ScanPorts {
+		if port < 1 || port > 65535 {
+			add("scan_ports", "%d must be between 1 and 65535", port)
+		}
+	}
+	if c.Iperf3Port < 0 || c.Iperf3Port > 65535 {
+		add("iperf3_port", "%d must be between 1 and 65535", c.Iperf3Port)
+	}
+	if c.SnapshotMaxAge < 0 {
+		add("snapshot_max_age", "%v must not be negative", c.SnapshotMaxAge)
+	}
+	if c.SnapshotMaxCount < 0 {
+		add("snapshot_max_count", "%d must not be negative", c.SnapshotMaxCount)
+	}
+	for _, baud := range c.Console.DefaultBauds {
+		if baud <= 0 || !containsInt(console.AllBaudRates, baud) {
+			add("console.default_bauds", "%d is not a standard baud rate", baud)
+		}
+	}
+	if !containsString(validCRLFModes, c.Console.CRLFMode) {
+		add("console.crlf_mode", "%q must be one of %s", c.Console.CRLFMode, strings.Join(validCRLFModes, ", "))
+	}
+	// Sorted so the errors come out in a stable order
+	names := make([]string, 0, len(c.Console.Alerts))
+	for name := range c.Console.Alerts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := regexp.Compile(c.Console.Alerts[name]); err != nil {
+			add("console.alerts", "%q: %v", name, err)
+		}
+	}
+	if c.LogLevel != "" {
+		if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+			add("log_level", "%q must be one of %s", c.LogLevel, strings.Join(logging.Levels, ", "))
+		}
+	}
+	if c.LogFormat != "" && !logging.ValidFormat(c.LogFormat) {
+		add("log_format", "%q must be one of %s", c.LogFormat, strings.Join(logging.Formats, ", "))
+	}
+	if c.LogMaxBytes < 0 {
+		add("log_max_bytes", "%d must not be negative", c.LogMaxBytes)
+	}
+	if c.LogMaxFiles < 0 {
+		add("log_max_files", "%d must not be negative", c.LogMaxFiles)
+	}
+	if _, _, err := logging.ParseSyslogAddress(c.Syslog.Address); err != nil {
+		add("syslog.address", "%q must be host:port, udp://host:port or tcp://host:port", c.Syslog.Address)
+	}
+	if c.Syslog.Facility != "" && !logging.ValidSyslogFacility(c.Syslog.Facility) {
+		add("syslog.facility", "%q must be one of %s", c.Syslog.Facility, strings.Join(logging.SyslogFacilityNames(), ", "))
+	}
+	if c.WebhookURL != "" {
+		if u, err := url.Parse(c.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("webhook_url", "%q is not an http:// or https:// URL", c.WebhookURL)
+		}
+	} else if c.WebhookOnFailure {
+		add("webhook_url", "must be set when webhook_on_failure is true")
+	}
+	c.Theme.validate(add)
+	return errs
+}
+
+// joinConfigErrors combines validation errors into a single error
+func joinConfigErrors(errs []ConfigError) error {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+	}
+	return errors.New(strings.Join(msgs, "; "))
+}
+
+func containsInt(list []int, v int) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, v string) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// SaveConfig saves configuration to disk, to the active profile if set
 func SaveConfig(config *Config) error {
 	configPath, err := GetConfigPath()
 	if err != nil {
 		return err
 	}
+	return saveConfigFile(configPath, config)
+}
+
+// saveConfigFile validates a config and writes it to a config or profile file
+func saveConfigFile(configPath string, config *Config) error {
+	if errs := config.Validate(); len(errs) > 0 {
+		return joinConfigErrors(errs)
+	}
 
 	// Ensure directory exists
 	configDir := filepath.Dir(configPath)
@@ -157,20 +437,23 @@ func SaveConfig(config *Config) error {
 
 	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
-		logging.Errorf("SaveConfig: marshal error: %v", err)
+		logging.Errorf("saveConfigFile: marshal error: %v", err)
 		return err
 	}
 
-	logging.Infof("SaveConfig: writing config to %s", configPath)
+	logging.Infof("saveConfigFile: writing config to %s", configPath)
 	return os.WriteFile(configPath, data, 0644)
 }
 
 // DefaultConfig returns default configuration
 func DefaultConfig() *Config {
 	return &Config{
-		DNSAlternates:      []string{"1.1.1.1", "8.8.8.8"},
+		DNSAlternates:      []string{"1.1.1.1", "8.8.8.8", "https://cloudflare-dns.com/dns-query"},
 		DiagnosticsTimeout: 1500,
 		Redact:             false,
+		CertWarnDays:       30,
+		Concurrent:         true,
+		ProbeTargets:       []string{"https://example.com", "https://www.apple.com/library/test/success.html"},
 		Console: ConsoleConfig{
 			DefaultBauds:           []int{9600, 115200},
 			CRLFMode:               "CRLF",
@@ -178,12 +461,38 @@ func DefaultConfig() *Config {
 			LogByDefault:           false,
 			BreakDurationMs:        250,
 			AllowProbeInConfigMode: false,
+			StripANSI:              false,
+			Alerts: map[string]string{
+				"link-down":     `Interface \S+, changed state to (administratively )?down`,
+				"ospf-neighbor": `%OSPF-\d-ADJCHG:.*Neighbor Down`,
+				"config-change": `%SYS-5-CONFIG_I`,
+			},
+		},
+		Fingerprint: FingerprintConfig{
+			MinProbeConfidence: 0.55,
+			MaxEvidence:        3,
 		},
+		Theme:             ThemeConfig{Preset: DefaultThemePreset},
+		RememberInterface: true,
 	}
 }
 
-// SaveSnapshot saves a snapshot to disk
+// SaveSnapshot saves a snapshot to disk, encrypted when its settings enable
+// encrypt_snapshots
 func SaveSnapshot(snap *Snapshot) (string, error) {
+	passphrase := ""
+	if snap.Settings != nil && snap.Settings.EncryptSnapshots {
+		if snap.Settings.EncryptPassphrase == "" {
+			return "", ErrPassphraseRequired
+		}
+		passphrase = snap.Settings.EncryptPassphrase
+	}
+	return saveSnapshot(snap, passphrase)
+}
+
+// saveSnapshot writes a snapshot as JSON, or encrypted with passphrase if it
+// isn't empty, and adds it to the index
+func saveSnapshot(snap *Snapshot, passphrase string) (string, error) {
 	snapsDir, err := GetSnapshotsDir()
 	if err != nil {
 		return "", err
@@ -196,12 +505,16 @@ func SaveSnapshot(snap *Snapshot) (string, error) {
 
 	// Generate filename
 	filename := fmt.Sprintf("%s.json", snap.Timestamp.Format("20060102-150405"))
+	if passphrase != "" {
+		filename += EncryptedSnapshotExt
+	}
 	filepath := filepath.Join(snapsDir, filename)
 
 	// Redact if requested
 	if snap.Redacted {
 		snap = redactSnapshot(snap)
 	}
+	snap = withoutSecrets(snap)
 
 	data, err := json.MarshalIndent(snap, "", "  ")
 	if err != nil {
@@ -209,6 +522,13 @@ func SaveSnapshot(snap *Snapshot) (string, error) {
 		return "", err
 	}
 
+	if passphrase != "" {
+		if data, err = encryptSnapshotData(data, passphrase); err != nil {
+			logging.Errorf("SaveSnapshot: encrypt error: %v", err)
+			return "", err
+		}
+	}
+
 	if err := os.WriteFile(filepath, data, 0644); err != nil {
 		logging.Errorf("SaveSnapshot: write error: %v", err)
 		return "", err
@@ -261,25 +581,41 @@ func updateIndex(snap *Snapshot, filename string) error {
 	return nil
 }
 
-// redactSnapshot anonymizes sensitive data
+// redactSnapshot anonymizes sensitive data, masking addresses at the level
+// in the snapshot's settings
 func redactSnapshot(snap *Snapshot) *Snapshot {
 	// Create a deep copy to avoid modifying original
 	redacted := *snap
 	logging.Debugf("redactSnapshot: start for %s", snap.Hostname)
+	level := snap.Settings.ipRedactLevel()
 
-	// Redact would process the details/diagnostics to mask IP octets and MAC addresses
-	// For now, we set a flag indicating redaction was applied
+	// Diagnostics are kept as recorded; the flag records that redaction was applied
 	redacted.Redacted = true
 
+	if snap.Details != nil {
+		details := RedactDetails(snapshotDetails(snap), level)
+		redacted.Details = &details
+	}
+
+	if len(snap.ARPTable) > 0 {
+		arp := make([]netpkg.ARPEntry, len(snap.ARPTable))
+		for i, e := range snap.ARPTable {
+			e.IP = RedactIP(e.IP, level)
+			e.MAC = RedactMAC(e.MAC)
+			arp[i] = e
+		}
+		redacted.ARPTable = arp
+	}
+
 	if snap.Console != nil {
 		consoleCopy := *snap.Console
-		consoleCopy.Fingerprint = scrubSensitive(consoleCopy.Fingerprint)
+		consoleCopy.Fingerprint = scrubSensitive(consoleCopy.Fingerprint, level)
 
 		if snap.Console.Detail != nil {
 			detailCopy := *snap.Console.Detail
-			detailCopy.Model = scrubSensitive(detailCopy.Model)
-			detailCopy.Prompt = scrubSensitive(detailCopy.Prompt)
-			detailCopy.Evidence = redactEvidence(detailCopy.Evidence)
+			detailCopy.Model = scrubSensitive(detailCopy.Model, level)
+			detailCopy.Prompt = scrubSensitive(detailCopy.Prompt, level)
+			detailCopy.Evidence = redactEvidence(detailCopy.Evidence, level)
 			consoleCopy.Detail = &detailCopy
 		}
 
@@ -289,34 +625,157 @@ func redactSnapshot(snap *Snapshot) *Snapshot {
 	return &redacted
 }
 
-func scrubSensitive(input string) string {
+// ipRedactLevel returns the configured redaction level, defaulting to
+// RedactLastOctet for a nil config or unset level
+func (c *Config) ipRedactLevel() int {
+	if c == nil || c.RedactLevel < RedactLastOctet || c.RedactLevel > RedactFull {
+		return RedactLastOctet
+	}
+	return c.RedactLevel
+}
+
+// RedactDetails masks the addresses of an interface: its IPs, gateways and
+// DNS servers at the given level, and its MAC and Wi-Fi BSSID
+func RedactDetails(details netpkg.InterfaceDetails, level int) netpkg.InterfaceDetails {
+	details.IPs = redactIPs(details.IPs, level)
+	details.MAC = RedactMAC(details.MAC)
+	details.DefaultGateways = redactIPs(details.DefaultGateways, level)
+	details.DNSServers = redactIPs(details.DNSServers, level)
+	if details.Wifi != nil {
+		wifi := *details.Wifi
+		wifi.BSSID = RedactMAC(wifi.BSSID)
+		details.Wifi = &wifi
+	}
+	return details
+}
+
+func redactIPs(ips []string, level int) []string {
+	if len(ips) == 0 {
+		return ips
+	}
+	out := make([]string, len(ips))
+	for i, ip := range ips {
+		out[i] = RedactIP(ip, level)
+	}
+	return out
+}
+
+// withoutSecrets returns snap with the snapshot passphrase, API token,
+// webhook signing secret and any credentials in the webhook URL removed from
+// its settings, so they never end up inside a snapshot or export
+func withoutSecrets(snap *Snapshot) *Snapshot {
+	if snap.Settings == nil {
+		return snap
+	}
+	webhookURL := withoutUserinfo(snap.Settings.WebhookURL)
+	if snap.Settings.EncryptPassphrase == "" && snap.Settings.APIToken == "" &&
+		snap.Settings.WebhookSecret == "" && webhookURL == snap.Settings.WebhookURL {
+		return snap
+	}
+	settings := *snap.Settings
+	settings.EncryptPassphrase = ""
+	settings.APIToken = ""
+	settings.WebhookSecret = ""
+	settings.WebhookURL = webhookURL
+	clean := *snap
+	clean.Settings = &settings
+	return &clean
+}
+
+// withoutUserinfo drops the user and password from a URL. Unparseable URLs
+// are dropped entirely rather than risk keeping credentials.
+func withoutUserinfo(rawURL string) string {
+	if rawURL == "" {
+		return ""
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	if u.User == nil {
+		return rawURL
+	}
+	u.User = nil
+	return u.String()
+}
+
+func scrubSensitive(input string, level int) string {
 	if input == "" {
 		return input
 	}
-	s := ipPattern.ReplaceAllString(input, "[REDACTED-IP]")
+	s := ipPattern.ReplaceAllStringFunc(input, func(ip string) string { return RedactIP(ip, level) })
 	s = macPattern.ReplaceAllString(s, "[REDACTED-MAC]")
+	s = ipv6Pattern.ReplaceAllStringFunc(s, func(candidate string) string {
+		// Skip times and bare "::" as in C++ names, which also match
+		groups := 0
+		for _, g := range strings.Split(candidate, ":") {
+			if g != "" {
+				groups++
+			}
+		}
+		if groups < 2 || net.ParseIP(candidate) == nil {
+			return candidate
+		}
+		return RedactIP(candidate, level)
+	})
 	return s
 }
 
-func redactEvidence(lines []string) []string {
+func redactEvidence(lines []string, level int) []string {
 	if len(lines) == 0 {
 		return lines
 	}
 	result := make([]string, len(lines))
 	for i, line := range lines {
-		result[i] = scrubSensitive(line)
+		result[i] = scrubSensitive(line, level)
 	}
 	return result
 }
 
-// RedactIP masks the last octet of an IP address
-func RedactIP(ip string) string {
-	parts := strings.Split(ip, ".")
-	if len(parts) == 4 {
-		parts[3] = "xxx"
-		return strings.Join(parts, ".")
+// RedactIP masks the trailing part of an IPv4 or IPv6 address, keeping any
+// prefix length or zone. level is RedactLastOctet, RedactTwoOctets or
+// RedactFull. Strings that aren't addresses are returned unchanged.
+func RedactIP(ip string, level int) string {
+	addr, suffix := ip, ""
+	if i := strings.IndexAny(ip, "/%"); i >= 0 {
+		addr, suffix = ip[:i], ip[i:]
+	}
+	parsed := net.ParseIP(addr)
+	if parsed == nil {
+		return ip
+	}
+	if level < RedactLastOctet || level > RedactFull {
+		level = RedactLastOctet
+	}
+
+	if v4 := parsed.To4(); v4 != nil && !strings.Contains(addr, ":") {
+		keep := 4 - level
+		if level == RedactFull {
+			keep = 0
+		}
+		parts := make([]string, 4)
+		for i := range parts {
+			if i < keep {
+				parts[i] = strconv.Itoa(int(v4[i]))
+			} else {
+				parts[i] = "xxx"
+			}
+		}
+		return strings.Join(parts, ".") + suffix
+	}
+
+	// IPv6 groups are written out in full, as masked groups can't be
+	// compressed with ::
+	masked := map[int]int{RedactLastOctet: 4, RedactTwoOctets: 6, RedactFull: 8}[level]
+	groups := make([]string, 8)
+	for i := range groups {
+		if i < 8-masked {
+			groups[i] = strconv.FormatUint(uint64(parsed[2*i])<<8|uint64(parsed[2*i+1]), 16)
+		} else {
+			groups[i] = "xxxx"
+		}
 	}
-	return ip
+	return strings.Join(groups, ":") + suffix
 }
 
 // RedactMAC masks parts of a MAC address
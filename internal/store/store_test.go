@@ -2,31 +2,64 @@ package store
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
+
+	netpkg "github.com/alexpitcher/LanAudit/internal/net"
 )
 
 func TestRedactIP(t *testing.T) {
 	tests := []struct {
 		input string
+		level int
 		want  string
 	}{
-		{"192.168.1.100", "192.168.1.xxx"},
-		{"10.0.0.1", "10.0.0.xxx"},
-		{"invalid", "invalid"},
+		{"192.168.1.100", RedactLastOctet, "192.168.1.xxx"},
+		{"10.0.0.1", RedactLastOctet, "10.0.0.xxx"},
+		{"192.168.1.100", RedactTwoOctets, "192.168.xxx.xxx"},
+		{"192.168.1.100", RedactFull, "xxx.xxx.xxx.xxx"},
+		{"192.168.1.100/24", RedactTwoOctets, "192.168.xxx.xxx/24"},
+		{"192.168.1.100", 0, "192.168.1.xxx"},
+		{"2001:db8:85a3::8a2e:370:7334", RedactLastOctet, "2001:db8:85a3:0:xxxx:xxxx:xxxx:xxxx"},
+		{"2001:db8:85a3::8a2e:370:7334", RedactTwoOctets, "2001:db8:xxxx:xxxx:xxxx:xxxx:xxxx:xxxx"},
+		{"2001:db8:85a3::8a2e:370:7334", RedactFull, "xxxx:xxxx:xxxx:xxxx:xxxx:xxxx:xxxx:xxxx"},
+		{"fe80::1%en0", RedactLastOctet, "fe80:0:0:0:xxxx:xxxx:xxxx:xxxx%en0"},
+		{"invalid", RedactLastOctet, "invalid"},
+		{"invalid", RedactFull, "invalid"},
 	}
 
 	for _, tt := range tests {
-		t.Run(tt.input, func(t *testing.T) {
-			if got := RedactIP(tt.input); got != tt.want {
-				t.Errorf("RedactIP(%s) = %s, want %s", tt.input, got, tt.want)
+		t.Run(fmt.Sprintf("%s/%d", tt.input, tt.level), func(t *testing.T) {
+			if got := RedactIP(tt.input, tt.level); got != tt.want {
+				t.Errorf("RedactIP(%s, %d) = %s, want %s", tt.input, tt.level, got, tt.want)
 			}
 		})
 	}
 }
 
+func TestScrubSensitive(t *testing.T) {
+	tests := []struct {
+		input string
+		level int
+		want  string
+	}{
+		{"R1 at 10.1.2.3 uptime 12:30:45", RedactLastOctet, "R1 at 10.1.2.xxx uptime 12:30:45"},
+		{"R1 at 10.1.2.3", RedactFull, "R1 at xxx.xxx.xxx.xxx"},
+		{"link fe80::1 mac 00:11:22:33:44:55", RedactTwoOctets, "link fe80:0:xxxx:xxxx:xxxx:xxxx:xxxx:xxxx mac [REDACTED-MAC]"},
+		{"std::string", RedactFull, "std::string"},
+	}
+	for _, tt := range tests {
+		if got := scrubSensitive(tt.input, tt.level); got != tt.want {
+			t.Errorf("scrubSensitive(%q, %d) = %q, want %q", tt.input, tt.level, got, tt.want)
+		}
+	}
+}
+
 func TestRedactMAC(t *testing.T) {
 	tests := []struct {
 		input string
@@ -124,3 +157,309 @@ func TestSnapshotSerialization(t *testing.T) {
 		t.Errorf("Interface = %s, want %s", loaded.Interface, snap.Interface)
 	}
 }
+
+func TestRedactSnapshotARPTable(t *testing.T) {
+	snap := &Snapshot{
+		Hostname: "test-host",
+		ARPTable: []netpkg.ARPEntry{
+			{IP: "192.168.1.1", MAC: "aa:bb:cc:dd:ee:ff", Interface: "en0", State: "reachable"},
+		},
+	}
+
+	redacted := redactSnapshot(snap)
+
+	got := redacted.ARPTable[0]
+	if got.IP != "192.168.1.xxx" || got.MAC != "aa:bb:cc:dd:xx:xx" {
+		t.Errorf("ARP entry not redacted: %+v", got)
+	}
+	if snap.ARPTable[0].IP != "192.168.1.1" {
+		t.Error("original snapshot should not be modified")
+	}
+}
+
+func TestRedactSnapshotLevels(t *testing.T) {
+	for _, tt := range []struct {
+		level   int
+		wantIP  string
+		wantARP string
+	}{
+		{RedactLastOctet, "192.168.1.xxx", "192.168.1.xxx"},
+		{RedactTwoOctets, "192.168.xxx.xxx", "192.168.xxx.xxx"},
+		{RedactFull, "xxx.xxx.xxx.xxx", "xxx.xxx.xxx.xxx"},
+	} {
+		config := DefaultConfig()
+		config.RedactLevel = tt.level
+		snap := &Snapshot{
+			Details: &netpkg.InterfaceDetails{
+				IPs:             []string{"192.168.1.20", "fe80::1"},
+				MAC:             "aa:bb:cc:dd:ee:ff",
+				DefaultGateways: []string{"192.168.1.1"},
+			},
+			ARPTable: []netpkg.ARPEntry{{IP: "192.168.1.1", MAC: "aa:bb:cc:dd:ee:01"}},
+			Settings: config,
+		}
+
+		redacted := redactSnapshot(snap)
+		details := snapshotDetails(redacted)
+		if details.IPs[0] != tt.wantIP || details.DefaultGateways[0] != tt.wantIP {
+			t.Errorf("level %d: details = %v via %v, want %s", tt.level, details.IPs, details.DefaultGateways, tt.wantIP)
+		}
+		if strings.HasPrefix(details.IPs[1], "fe80::") || details.MAC != "aa:bb:cc:dd:xx:xx" {
+			t.Errorf("level %d: IPv6 %s or MAC %s not redacted", tt.level, details.IPs[1], details.MAC)
+		}
+		if redacted.ARPTable[0].IP != tt.wantARP {
+			t.Errorf("level %d: ARP IP = %s, want %s", tt.level, redacted.ARPTable[0].IP, tt.wantARP)
+		}
+		if snap.Details.(*netpkg.InterfaceDetails).IPs[0] != "192.168.1.20" {
+			t.Fatal("original snapshot should not be modified")
+		}
+	}
+}
+
+func TestLoadConfigKeepsDefaultsForMissingFields(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dir := filepath.Join(home, DefaultConfigDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ConfigFile), []byte(`{"diagnostics_timeout_ms": 2000}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	if config.DiagnosticsTimeout != 2000 {
+		t.Errorf("DiagnosticsTimeout = %d, want 2000", config.DiagnosticsTimeout)
+	}
+	if !config.Concurrent {
+		t.Error("expected Concurrent to default to true when absent from file")
+	}
+}
+
+func TestConfigValidateProbeTargets(t *testing.T) {
+	tests := []struct {
+		name    string
+		targets []string
+		wantErr bool
+	}{
+		{name: "defaults", targets: DefaultConfig().ProbeTargets},
+		{name: "empty", targets: nil},
+		{name: "internal health check", targets: []string{"https://health.corp.example:8443/ping"}},
+		{name: "plain http", targets: []string{"http://example.com"}, wantErr: true},
+		{name: "missing host", targets: []string{"https://"}, wantErr: true},
+		{name: "not a url", targets: []string{"example.com"}, wantErr: true},
+		{name: "unparseable", targets: []string{"https://exa mple.com/%zz"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.ProbeTargets = tt.targets
+			errs := config.Validate()
+			if (len(errs) > 0) != tt.wantErr {
+				t.Errorf("Validate() errors = %v, wantErr %v", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidateFingerprint(t *testing.T) {
+	tests := []struct {
+		name    string
+		fp      FingerprintConfig
+		wantErr bool
+	}{
+		{name: "defaults", fp: DefaultConfig().Fingerprint},
+		{name: "zero", fp: FingerprintConfig{}},
+		{name: "strict", fp: FingerprintConfig{MinProbeConfidence: 1, MaxEvidence: 10}},
+		{name: "negative confidence", fp: FingerprintConfig{MinProbeConfidence: -0.1}, wantErr: true},
+		{name: "confidence above one", fp: FingerprintConfig{MinProbeConfidence: 1.5}, wantErr: true},
+		{name: "negative evidence", fp: FingerprintConfig{MaxEvidence: -1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.Fingerprint = tt.fp
+			errs := config.Validate()
+			if (len(errs) > 0) != tt.wantErr {
+				t.Errorf("Validate() errors = %v, wantErr %v", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidateScanPorts(t *testing.T) {
+	tests := []struct {
+		name    string
+		ports   []int
+		wantErr bool
+	}{
+		{name: "empty", ports: nil},
+		{name: "bounds", ports: []int{1, 22, 65535}},
+		{name: "zero", ports: []int{22, 0}, wantErr: true},
+		{name: "too large", ports: []int{65536}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.ScanPorts = tt.ports
+			errs := config.Validate()
+			if (len(errs) > 0) != tt.wantErr {
+				t.Errorf("Validate() errors = %v, wantErr %v", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidateIperf3Port(t *testing.T) {
+	config := DefaultConfig()
+	for _, port := range []int{0, 5201, 65535} {
+		config.Iperf3Port = port
+		if errs := config.Validate(); len(errs) > 0 {
+			t.Errorf("Validate() with iperf3 port %d: %v", port, errs)
+		}
+	}
+	for _, port := range []int{-1, 65536} {
+		config.Iperf3Port = port
+		if errs := config.Validate(); len(errs) == 0 {
+			t.Errorf("Validate() accepted iperf3 port %d", port)
+		}
+	}
+}
+
+func TestConfigValidateSnapshotRetention(t *testing.T) {
+	config := DefaultConfig()
+	config.SnapshotMaxAge = -time.Hour
+	if errs := config.Validate(); len(errs) == 0 {
+		t.Error("Validate() accepted negative snapshot_max_age")
+	}
+	config = DefaultConfig()
+	config.SnapshotMaxCount = -1
+	if errs := config.Validate(); len(errs) == 0 {
+		t.Error("Validate() accepted negative snapshot_max_count")
+	}
+	config = DefaultConfig()
+	config.SnapshotMaxAge, config.SnapshotMaxCount = 30*24*time.Hour, 50
+	if errs := config.Validate(); len(errs) > 0 {
+		t.Errorf("Validate() = %v", errs)
+	}
+}
+
+func TestConfigValidateConsoleAlerts(t *testing.T) {
+	if errs := DefaultConfig().Validate(); len(errs) > 0 {
+		t.Fatalf("default alerts invalid: %v", errs)
+	}
+	config := DefaultConfig()
+	config.Console.Alerts["broken"] = `changed state to (down`
+	errs := config.Validate()
+	if len(errs) != 1 || errs[0].Field != "console.alerts" || !strings.Contains(errs[0].Message, "broken") {
+		t.Errorf("Validate() errors = %v, want one error naming the alert", errs)
+	}
+}
+
+func TestConfigValidateFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		fields []string
+	}{
+		{name: "defaults", modify: func(c *Config) {}},
+		{name: "bad dns alternate", modify: func(c *Config) { c.DNSAlternates = []string{"1.1.1.1", "dns.example"} }, fields: []string{"dns_alternates"}},
+		{name: "bad doh url", modify: func(c *Config) { c.DNSAlternates = []string{"https://"} }, fields: []string{"dns_alternates"}},
+		{name: "ipv6 dns alternate", modify: func(c *Config) { c.DNSAlternates = []string{"2606:4700:4700::1111"} }},
+		{name: "private dns unredacted", modify: func(c *Config) { c.DNSAlternates = []string{"192.168.1.1"} }, fields: []string{"dns_alternates"}},
+		{name: "private dns redacted", modify: func(c *Config) { c.DNSAlternates = []string{"192.168.1.1"}; c.Redact = true }},
+		{name: "zero timeout", modify: func(c *Config) { c.DiagnosticsTimeout = 0 }, fields: []string{"diagnostics_timeout_ms"}},
+		{name: "short timeout", modify: func(c *Config) { c.DiagnosticsTimeout = 50 }, fields: []string{"diagnostics_timeout_ms"}},
+		{name: "long timeout", modify: func(c *Config) { c.DiagnosticsTimeout = 600000 }, fields: []string{"diagnostics_timeout_ms"}},
+		{name: "nonstandard baud", modify: func(c *Config) { c.Console.DefaultBauds = []int{9600, 12345} }, fields: []string{"console.default_bauds"}},
+		{name: "negative baud", modify: func(c *Config) { c.Console.DefaultBauds = []int{-9600} }, fields: []string{"console.default_bauds"}},
+		{name: "crlf modes", modify: func(c *Config) { c.Console.CRLFMode = "LF" }},
+		{name: "lowercase crlf", modify: func(c *Config) { c.Console.CRLFMode = "crlf" }, fields: []string{"console.crlf_mode"}},
+		{name: "log level", modify: func(c *Config) { c.LogLevel = "warn" }},
+		{name: "bad log level", modify: func(c *Config) { c.LogLevel = "trace" }, fields: []string{"log_level"}},
+		{name: "json log format", modify: func(c *Config) { c.LogFormat = "json" }},
+		{name: "bad log format", modify: func(c *Config) { c.LogFormat = "xml" }, fields: []string{"log_format"}},
+		{name: "syslog", modify: func(c *Config) {
+			c.Syslog = SyslogConfig{Enabled: true, Address: "tcp://siem.example.com:601", Facility: "local0"}
+		}},
+		{name: "bad syslog", modify: func(c *Config) {
+			c.Syslog = SyslogConfig{Enabled: true, Address: "siem.example.com", Facility: "local9"}
+		}, fields: []string{"syslog.address", "syslog.facility"}},
+		{name: "log rotation", modify: func(c *Config) { c.LogMaxBytes = 1 << 20; c.LogMaxFiles = 3 }},
+		{name: "negative log rotation", modify: func(c *Config) { c.LogMaxBytes = -1; c.LogMaxFiles = -1 }, fields: []string{"log_max_bytes", "log_max_files"}},
+		{name: "webhook", modify: func(c *Config) { c.WebhookURL = "https://hooks.example.com/lanaudit"; c.WebhookOnFailure = true }},
+		{name: "webhook not http", modify: func(c *Config) { c.WebhookURL = "ftp://hooks.example.com" }, fields: []string{"webhook_url"}},
+		{name: "webhook on failure without url", modify: func(c *Config) { c.WebhookOnFailure = true }, fields: []string{"webhook_url"}},
+		{
+			name: "several",
+			modify: func(c *Config) {
+				c.DiagnosticsTimeout = -1
+				c.Console.CRLFMode = ""
+				c.ScanPorts = []int{0}
+			},
+			fields: []string{"diagnostics_timeout_ms", "scan_ports", "console.crlf_mode"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			tt.modify(config)
+			var fields []string
+			for _, e := range config.Validate() {
+				fields = append(fields, e.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("Validate() fields = %v, want %v", fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestSaveConfigRejectsInvalid(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	config := DefaultConfig()
+	config.DNSAlternates = []string{"10.0.0.53"}
+
+	err := SaveConfig(config)
+	if err == nil || !strings.Contains(err.Error(), "dns_alternates") {
+		t.Fatalf("SaveConfig() error = %v, want dns_alternates error", err)
+	}
+	path, _ := GetConfigPath()
+	if _, statErr := os.Stat(path); !os.IsNotExist(statErr) {
+		t.Error("expected no config file to be written")
+	}
+
+	config.Redact = true
+	if err := SaveConfig(config); err != nil {
+		t.Errorf("SaveConfig() with redact on: %v", err)
+	}
+}
+
+func TestLoadConfigWarnsOnInvalid(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, DefaultConfigDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ConfigFile), []byte(`{"diagnostics_timeout_ms": 0}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if errs := config.Validate(); len(errs) != 1 || errs[0].Field != "diagnostics_timeout_ms" {
+		t.Errorf("Validate() = %v, want the timeout error", errs)
+	}
+}
@@ -0,0 +1,131 @@
+package store
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultThemePreset is the theme used when none is configured
+const DefaultThemePreset = "dark"
+
+// ThemeConfig sets the TUI colors. Colors are ANSI codes ("0"-"255") or hex
+// ("#268bd2"); empty fields take the value from the preset.
+type ThemeConfig struct {
+	Preset      string `json:"preset,omitempty"`
+	StatusFg    string `json:"status_fg,omitempty"`
+	StatusBg    string `json:"status_bg,omitempty"`
+	HeaderFg    string `json:"header_fg,omitempty"`
+	HeaderBg    string `json:"header_bg,omitempty"`
+	HighlightFg string `json:"highlight_fg,omitempty"`
+	HighlightBg string `json:"highlight_bg,omitempty"`
+	ErrorFg     string `json:"error_fg,omitempty"`
+	WarningFg   string `json:"warning_fg,omitempty"`
+	SuccessFg   string `json:"success_fg,omitempty"`
+}
+
+// ThemePresets are the built-in themes, by name
+var ThemePresets = map[string]ThemeConfig{
+	"dark": {
+		StatusFg:    "240",
+		HeaderFg:    "63",
+		HighlightFg: "15",
+		HighlightBg: "63",
+		ErrorFg:     "9",
+		WarningFg:   "11",
+		SuccessFg:   "10",
+	},
+	"light": {
+		StatusFg:    "242",
+		HeaderFg:    "55",
+		HighlightFg: "15",
+		HighlightBg: "55",
+		ErrorFg:     "160",
+		WarningFg:   "130",
+		SuccessFg:   "28",
+	},
+	"solarized": {
+		StatusFg:    "#93a1a1",
+		StatusBg:    "#073642",
+		HeaderFg:    "#268bd2",
+		HighlightFg: "#fdf6e3",
+		HighlightBg: "#268bd2",
+		ErrorFg:     "#dc322f",
+		WarningFg:   "#b58900",
+		SuccessFg:   "#859900",
+	},
+}
+
+var hexColorPattern = regexp.MustCompile(`^#(?:[0-9A-Fa-f]{3}|[0-9A-Fa-f]{6})$`)
+
+// ThemePresetNames returns the built-in theme names, sorted
+func ThemePresetNames() []string {
+	names := make([]string, 0, len(ThemePresets))
+	for name := range ThemePresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve returns the theme with empty colors filled in from its preset, or
+// from the default preset when the preset is empty or unknown
+func (t ThemeConfig) Resolve() ThemeConfig {
+	base, ok := ThemePresets[t.Preset]
+	if !ok {
+		base = ThemePresets[DefaultThemePreset]
+	}
+	resolved := t
+	fields := resolved.colorFields()
+	for i, f := range base.colorFields() {
+		if *fields[i].value == "" {
+			*fields[i].value = *f.value
+		}
+	}
+	if resolved.Preset == "" {
+		resolved.Preset = DefaultThemePreset
+	}
+	return resolved
+}
+
+// validate checks the preset name and color values
+func (t *ThemeConfig) validate(add func(field, format string, args ...interface{})) {
+	if _, ok := ThemePresets[t.Preset]; t.Preset != "" && !ok {
+		add("theme.preset", "%q must be one of %s", t.Preset, strings.Join(ThemePresetNames(), ", "))
+	}
+	for _, f := range t.colorFields() {
+		if *f.value != "" && !validColor(*f.value) {
+			add("theme."+f.name, "%q is not an ANSI color (0-255) or hex color (#rgb or #rrggbb)", *f.value)
+		}
+	}
+}
+
+type themeColorField struct {
+	name  string
+	value *string
+}
+
+// colorFields lists the color settings in a fixed order, by JSON name
+func (t *ThemeConfig) colorFields() []themeColorField {
+	return []themeColorField{
+		{"status_fg", &t.StatusFg},
+		{"status_bg", &t.StatusBg},
+		{"header_fg", &t.HeaderFg},
+		{"header_bg", &t.HeaderBg},
+		{"highlight_fg", &t.HighlightFg},
+		{"highlight_bg", &t.HighlightBg},
+		{"error_fg", &t.ErrorFg},
+		{"warning_fg", &t.WarningFg},
+		{"success_fg", &t.SuccessFg},
+	}
+}
+
+// validColor reports whether s is an ANSI color code or a hex color
+func validColor(s string) bool {
+	if hexColorPattern.MatchString(s) {
+		return true
+	}
+	n, err := strconv.Atoi(s)
+	return err == nil && n >= 0 && n <= 255 && strconv.Itoa(n) == s
+}
@@ -0,0 +1,64 @@
+package store
+
+import "testing"
+
+func TestThemeResolve(t *testing.T) {
+	got := ThemeConfig{}.Resolve()
+	if want := ThemePresets[DefaultThemePreset]; got.Preset != DefaultThemePreset || got.ErrorFg != want.ErrorFg || got.StatusFg != want.StatusFg {
+		t.Errorf("empty theme resolved to %+v, want the %s preset", got, DefaultThemePreset)
+	}
+
+	// Set colors override the preset, the rest come from it
+	got = ThemeConfig{Preset: "solarized", ErrorFg: "#ff0000"}.Resolve()
+	if got.ErrorFg != "#ff0000" {
+		t.Errorf("ErrorFg = %q, want the override", got.ErrorFg)
+	}
+	if got.HeaderFg != ThemePresets["solarized"].HeaderFg {
+		t.Errorf("HeaderFg = %q, want solarized's %q", got.HeaderFg, ThemePresets["solarized"].HeaderFg)
+	}
+}
+
+func TestThemePresetsValid(t *testing.T) {
+	for _, name := range ThemePresetNames() {
+		config := DefaultConfig()
+		config.Theme = ThemePresets[name]
+		config.Theme.Preset = name
+		if errs := config.Validate(); len(errs) != 0 {
+			t.Errorf("preset %s: Validate() = %v", name, errs)
+		}
+	}
+}
+
+func TestThemeValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		theme ThemeConfig
+		field string // "" for valid
+	}{
+		{name: "ansi", theme: ThemeConfig{StatusFg: "240", ErrorFg: "0"}},
+		{name: "hex", theme: ThemeConfig{HeaderFg: "#268bd2", HighlightBg: "#FFF"}},
+		{name: "bad hex", theme: ThemeConfig{HeaderFg: "#268bd"}, field: "theme.header_fg"},
+		{name: "hex without hash", theme: ThemeConfig{StatusBg: "268bd2"}, field: "theme.status_bg"},
+		{name: "non-hex digits", theme: ThemeConfig{WarningFg: "#gggggg"}, field: "theme.warning_fg"},
+		{name: "ansi out of range", theme: ThemeConfig{ErrorFg: "256"}, field: "theme.error_fg"},
+		{name: "color name", theme: ThemeConfig{SuccessFg: "green"}, field: "theme.success_fg"},
+		{name: "unknown preset", theme: ThemeConfig{Preset: "neon"}, field: "theme.preset"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.Theme = tt.theme
+			errs := config.Validate()
+			if tt.field == "" {
+				if len(errs) != 0 {
+					t.Errorf("Validate() = %v, want no errors", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || errs[0].Field != tt.field {
+				t.Errorf("Validate() = %v, want one error for %s", errs, tt.field)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// MinDiagnosticsTimeout and MaxDiagnosticsTimeout bound the diagnostics
+	// timeout, whether configured or given with --timeout
+	MinDiagnosticsTimeout = 100 * time.Millisecond
+	MaxDiagnosticsTimeout = 5 * time.Minute
+
+	// FallbackDiagnosticsTimeout is used when the configured timeout is unset
+	FallbackDiagnosticsTimeout = 5 * time.Second
+)
+
+// timeoutOverride, when non-zero, replaces diagnostics_timeout_ms for this
+// session
+var timeoutOverride atomic.Int64
+
+// ValidateTimeout checks that d is within the diagnostics timeout bounds
+func ValidateTimeout(d time.Duration) error {
+	if d < MinDiagnosticsTimeout || d > MaxDiagnosticsTimeout {
+		return fmt.Errorf("timeout %s must be between %s and %s", d, MinDiagnosticsTimeout, MaxDiagnosticsTimeout)
+	}
+	return nil
+}
+
+// SetTimeoutOverride replaces the configured diagnostics timeout for the rest
+// of the session without saving it; zero restores the configured timeout
+func SetTimeoutOverride(d time.Duration) error {
+	if d != 0 {
+		if err := ValidateTimeout(d); err != nil {
+			return err
+		}
+	}
+	timeoutOverride.Store(int64(d))
+	return nil
+}
+
+// TimeoutOverride returns the session timeout set by SetTimeoutOverride, or
+// zero if none is set
+func TimeoutOverride() time.Duration {
+	return time.Duration(timeoutOverride.Load())
+}
+
+// DiagnosticsTimeoutDuration returns the timeout diagnostics run with: the
+// session override if set, else diagnostics_timeout_ms, else
+// FallbackDiagnosticsTimeout
+func (c *Config) DiagnosticsTimeoutDuration() time.Duration {
+	if d := TimeoutOverride(); d > 0 {
+		return d
+	}
+	if c != nil && c.DiagnosticsTimeout > 0 {
+		return time.Duration(c.DiagnosticsTimeout) * time.Millisecond
+	}
+	return FallbackDiagnosticsTimeout
+}
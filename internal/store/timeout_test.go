@@ -0,0 +1,44 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiagnosticsTimeoutDuration(t *testing.T) {
+	defer SetTimeoutOverride(0)
+
+	var nilConfig *Config
+	if got := nilConfig.DiagnosticsTimeoutDuration(); got != FallbackDiagnosticsTimeout {
+		t.Errorf("nil config timeout = %s, want %s", got, FallbackDiagnosticsTimeout)
+	}
+	config := DefaultConfig()
+	config.DiagnosticsTimeout = 2500
+	if got := config.DiagnosticsTimeoutDuration(); got != 2500*time.Millisecond {
+		t.Errorf("configured timeout = %s, want 2.5s", got)
+	}
+
+	if err := SetTimeoutOverride(30 * time.Second); err != nil {
+		t.Fatalf("SetTimeoutOverride() error = %v", err)
+	}
+	if got := config.DiagnosticsTimeoutDuration(); got != 30*time.Second {
+		t.Errorf("overridden timeout = %s, want 30s", got)
+	}
+	if config.DiagnosticsTimeout != 2500 {
+		t.Errorf("override changed the config to %d", config.DiagnosticsTimeout)
+	}
+
+	for _, d := range []time.Duration{50 * time.Millisecond, 6 * time.Minute, -time.Second} {
+		if err := SetTimeoutOverride(d); err == nil {
+			t.Errorf("SetTimeoutOverride(%s) succeeded, want an error", d)
+		}
+	}
+	if got := TimeoutOverride(); got != 30*time.Second {
+		t.Errorf("a rejected override replaced the session timeout: %s", got)
+	}
+
+	SetTimeoutOverride(0)
+	if got := config.DiagnosticsTimeoutDuration(); got != 2500*time.Millisecond {
+		t.Errorf("timeout after clearing the override = %s, want 2.5s", got)
+	}
+}
@@ -0,0 +1,650 @@
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alexpitcher/LanAudit/internal/capture"
+	"github.com/alexpitcher/LanAudit/internal/diagnostics"
+	"github.com/alexpitcher/LanAudit/internal/logging"
+	netpkg "github.com/alexpitcher/LanAudit/internal/net"
+	"github.com/alexpitcher/LanAudit/internal/scan"
+	"github.com/alexpitcher/LanAudit/internal/speedtest"
+	"github.com/alexpitcher/LanAudit/internal/store"
+	"github.com/alexpitcher/LanAudit/internal/webhook"
+	"gopkg.in/yaml.v3"
+)
+
+// HeadlessReport is the structured result emitted by headless mode
+type HeadlessReport struct {
+	Timestamp   time.Time                `json:"timestamp" yaml:"timestamp"`
+	Interface   *netpkg.InterfaceDetails `json:"interface" yaml:"interface"`
+	Gateways    []string                 `json:"gateways" yaml:"gateways"`
+	DNSServers  []string                 `json:"dns_servers" yaml:"dns_servers"`
+	Rate        *netpkg.InterfaceRate    `json:"rate,omitempty" yaml:"rate,omitempty"`
+	Diagnostics *diagnostics.Result      `json:"diagnostics,omitempty" yaml:"diagnostics,omitempty"`
+	Capture     *capture.Stats           `json:"capture,omitempty" yaml:"capture,omitempty"`
+	Audit       *scan.ScanResult         `json:"audit,omitempty" yaml:"audit,omitempty"`
+	ScanPorts   []int                    `json:"scan_ports" yaml:"scan_ports"` // TCP ports a gateway audit checks
+}
+
+// HeadlessOptions controls how the headless report is rendered
+type HeadlessOptions struct {
+	Format string // "json" (default), "yaml" or "table"
+	Pretty bool   // indent JSON output
+
+	// Timeout bounds the whole run, plus the time the enabled optional
+	// checks are given; zero leaves it unbounded
+	Timeout time.Duration
+}
+
+// RunHeadless runs diagnostics for an interface and writes a structured report to w.
+// If opts.Timeout passes first, the partial report is still written and the
+// error wraps context.DeadlineExceeded.
+func RunHeadless(ctx context.Context, w io.Writer, ifaceName string, opts HeadlessOptions) error {
+	config, configErr := store.LoadConfig()
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout+diagnostics.OptionalTimeout(config))
+		defer cancel()
+	}
+
+	report, err := buildReport(ctx, ifaceName)
+	if err != nil {
+		return err
+	}
+	if configErr == nil && config.Redact {
+		report = redactHeadlessReport(report, config.RedactLevel)
+	}
+	if err := writeHeadlessReport(w, report, opts); err != nil {
+		return err
+	}
+	if err := ctx.Err(); errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("diagnostics did not finish within the %s timeout: %w", opts.Timeout, err)
+	}
+	return nil
+}
+
+// WatchReport is one line of --watch output: a headless report plus the run
+// count, for alerting on repeated failures
+type WatchReport struct {
+	RunNumber           int    `json:"run_number"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	Error               string `json:"error,omitempty"`
+	*HeadlessReport
+}
+
+// RunHeadlessWatch runs diagnostics every interval, writing one JSON object
+// per run to w (NDJSON). A run fails when the report can't be built or its
+// diagnostics find a problem. Cancelling ctx stops the loop once the current
+// run is written; runs themselves are bounded by opts.Timeout.
+func RunHeadlessWatch(ctx context.Context, w io.Writer, ifaceName string, interval time.Duration, opts HeadlessOptions) error {
+	if opts.Format != "" && opts.Format != "json" {
+		return fmt.Errorf("watch mode writes JSON lines; format %q is not supported", opts.Format)
+	}
+	if interval <= 0 {
+		return fmt.Errorf("watch interval must be positive")
+	}
+
+	enc := json.NewEncoder(w)
+	failures := 0
+	for run := 1; ; run++ {
+		start := time.Now()
+		line := runWatchOnce(context.WithoutCancel(ctx), ifaceName, opts.Timeout)
+		line.RunNumber = run
+		// A report is always present when there is no error
+		if line.Error != "" || line.Diagnostics == nil || len(webhook.Failures(line.Diagnostics)) > 0 {
+			failures++
+		} else {
+			failures = 0
+		}
+		line.ConsecutiveFailures = failures
+		if err := enc.Encode(line); err != nil {
+			return err
+		}
+
+		wait := time.NewTimer(time.Until(start.Add(interval)))
+		select {
+		case <-ctx.Done():
+			wait.Stop()
+			return nil
+		case <-wait.C:
+		}
+	}
+}
+
+// runWatchOnce builds one report for RunHeadlessWatch, redacted as
+// configured
+func runWatchOnce(ctx context.Context, ifaceName string, timeout time.Duration) WatchReport {
+	config, configErr := store.LoadConfig()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout+diagnostics.OptionalTimeout(config))
+		defer cancel()
+	}
+
+	report, err := buildReport(ctx, ifaceName)
+	if err != nil {
+		return WatchReport{Error: err.Error()}
+	}
+	if configErr == nil && config.Redact {
+		report = redactHeadlessReport(report, config.RedactLevel)
+	}
+	line := WatchReport{HeadlessReport: report}
+	if err := ctx.Err(); errors.Is(err, context.DeadlineExceeded) {
+		line.Error = fmt.Sprintf("diagnostics did not finish within the %s timeout", timeout)
+	}
+	return line
+}
+
+// redactHeadlessReport masks the interface addresses in a report at the
+// configured redaction level. Diagnostics are kept as measured, as in
+// redacted snapshots.
+func redactHeadlessReport(report *HeadlessReport, level int) *HeadlessReport {
+	redacted := *report
+	if report.Interface != nil {
+		details := store.RedactDetails(*report.Interface, level)
+		redacted.Interface = &details
+		redacted.Gateways = details.DefaultGateways
+		redacted.DNSServers = details.DNSServers
+	}
+	return &redacted
+}
+
+// buildReport builds the report for RunHeadless and watch runs; tests replace it
+var buildReport = buildHeadlessReport
+
+// buildHeadlessReport gathers interface details and diagnostics
+func buildHeadlessReport(ctx context.Context, ifaceName string) (*HeadlessReport, error) {
+	details, err := netpkg.GetInterfaceDetails(ifaceName)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := store.LoadConfig()
+	if err != nil {
+		config = store.DefaultConfig()
+	}
+
+	timeout := config.DiagnosticsTimeoutDuration() + diagnostics.OptionalTimeout(config)
+	diagCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	report := &HeadlessReport{
+		Timestamp:  time.Now(),
+		Interface:  details,
+		Gateways:   details.DefaultGateways,
+		DNSServers: details.DNSServers,
+		ScanPorts:  config.ScanPorts,
+	}
+	if len(report.ScanPorts) == 0 {
+		report.ScanPorts = scan.CommonPorts
+	}
+
+	// Sample throughput before diagnostics add traffic of their own
+	report.Rate = sampleRate(diagCtx, ifaceName, headlessRateWindow)
+
+	res, err := diagnostics.Run(diagCtx, details, config)
+	if err != nil {
+		logging.Warnf("headless diagnostics failed: %v", err)
+	}
+	report.Diagnostics = res
+	if res != nil {
+		if err := store.SaveDiagnosticResult(ifaceName, res); err != nil {
+			logging.Warnf("headless diagnostics history save failed: %v", err)
+		}
+		if _, err := webhook.NotifyIfFailed(config, res); err != nil {
+			logging.Warnf("headless diagnostics webhook failed: %v", err)
+		}
+	}
+
+	// Include the protocol breakdown of a capture made earlier in this process
+	if sess := capture.GetCurrentSession(); sess != nil {
+		st, err := sess.GetStats()
+		if err != nil {
+			logging.Warnf("failed to read capture drop counts: %v", err)
+		}
+		report.Capture = &st
+	}
+
+	// The last saved audit of the gateway, including per-host OS hints
+	report.Audit = lastGatewayAudit(details)
+
+	return report, nil
+}
+
+// headlessRateWindow is how long headless mode samples an interface's
+// counters to report its current throughput
+const headlessRateWindow = time.Second
+
+// headlessStats reads the counters sampled by sampleRate; tests replace it
+var headlessStats = netpkg.GetInterfaceStats
+
+// sampleRate measures an interface's throughput from two counter readings
+// window apart. It returns nil if either reading fails or ctx ends first.
+func sampleRate(ctx context.Context, ifaceName string, window time.Duration) *netpkg.InterfaceRate {
+	before, err := headlessStats(ifaceName)
+	if err != nil {
+		logging.Warnf("failed to read interface counters: %v", err)
+		return nil
+	}
+	start := time.Now()
+
+	timer := time.NewTimer(window)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-timer.C:
+	}
+
+	after, err := headlessStats(ifaceName)
+	if err != nil {
+		logging.Warnf("failed to read interface counters: %v", err)
+		return nil
+	}
+	rate := netpkg.RateBetween(before.BytesRx, before.BytesTx, after.BytesRx, after.BytesTx, time.Since(start))
+	return &rate
+}
+
+// lastGatewayAudit loads the most recent audit saved for the interface's
+// IPv4 gateway, or nil if it has never been audited
+func lastGatewayAudit(details *netpkg.InterfaceDetails) *scan.ScanResult {
+	gw := details.IPv4Gateway()
+	if gw == "" {
+		return nil
+	}
+	audit, err := store.LoadLastScanResult(gw)
+	if err != nil {
+		logging.Warnf("failed to load the last gateway audit: %v", err)
+	}
+	return audit
+}
+
+// RunHeadlessSnapshot records a snapshot of an interface, saves it to the
+// snapshots directory and writes it to w as json, yaml or csv. It returns
+// the path the snapshot was saved to.
+func RunHeadlessSnapshot(ctx context.Context, w io.Writer, ifaceName, format string) (string, error) {
+	var export func(*store.Snapshot, io.Writer) error
+	switch format {
+	case "", "json":
+		export = store.ExportSnapshotJSON
+	case "yaml":
+		export = store.ExportSnapshotYAML
+	case "csv":
+		export = store.ExportSnapshotCSV
+	default:
+		return "", fmt.Errorf("unsupported export format %q", format)
+	}
+
+	snap, err := buildSnapshot(ctx, ifaceName)
+	if err != nil {
+		return "", err
+	}
+	path, err := store.SaveSnapshot(snap)
+	if err != nil {
+		return path, fmt.Errorf("failed to save snapshot: %w", err)
+	}
+	return path, export(snap, w)
+}
+
+// buildSnapshot captures the interface, diagnostics, ARP cache and the stats
+// of any capture taken in this process
+func buildSnapshot(ctx context.Context, ifaceName string) (*store.Snapshot, error) {
+	report, err := buildReport(ctx, ifaceName)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := store.LoadConfig()
+	if err != nil {
+		config = store.DefaultConfig()
+	}
+	hostname, _ := os.Hostname()
+
+	snap := &store.Snapshot{
+		Timestamp: report.Timestamp,
+		Hostname:  hostname,
+		Interface: ifaceName,
+		Details:   report.Interface,
+		Audit:     report.Audit,
+		Capture:   report.Capture,
+		Settings:  config,
+		Redacted:  config.Redact,
+	}
+	// Leave out a missing result rather than storing a typed nil
+	if report.Diagnostics != nil {
+		snap.Diagnostics = report.Diagnostics
+	}
+	if arp, err := netpkg.GetARPTable(); err == nil {
+		snap.ARPTable = arp
+	} else {
+		logging.Warnf("snapshot ARP table unavailable: %v", err)
+	}
+	return snap, nil
+}
+
+// RunHeadlessHistory writes the last limit stored diagnostic results for an interface to w
+func RunHeadlessHistory(w io.Writer, ifaceName string, limit int, opts HeadlessOptions) error {
+	history, err := store.LoadDiagnosticHistory[diagnostics.Result](ifaceName, limit)
+	if err != nil {
+		return err
+	}
+	if history == nil {
+		history = []*diagnostics.Result{}
+	}
+	return writeHeadlessHistory(w, history, opts)
+}
+
+// writeHeadlessHistory serializes stored results in the requested format
+func writeHeadlessHistory(w io.Writer, history []*diagnostics.Result, opts HeadlessOptions) error {
+	switch opts.Format {
+	case "", "json":
+		enc := json.NewEncoder(w)
+		if opts.Pretty {
+			enc.SetIndent("", "  ")
+		}
+		return enc.Encode(history)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		defer enc.Close()
+		return enc.Encode(history)
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "Timestamp\tGateway\tPing Loss\tRTT p50\tRTT p95\tDNS\tHTTPS")
+		for _, r := range history {
+			fmt.Fprintf(tw, "%s\t%s\t%.0f%%\t%s\t%s\t%s\t%s\n",
+				r.Timestamp.Format(time.RFC3339), r.Gateway, r.Ping.Loss, r.Ping.RTTP50, r.Ping.RTTP95,
+				okFail(r.DNS.SystemOK), okFail(r.HTTPS.OK))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unsupported output format %q", opts.Format)
+	}
+}
+
+// RunHeadlessSpeedtestHistory writes the last limit stored speedtest results to w
+func RunHeadlessSpeedtestHistory(w io.Writer, limit int, opts HeadlessOptions) error {
+	history, err := store.LoadSpeedtestHistory(limit)
+	if err != nil {
+		return err
+	}
+	if history == nil {
+		history = []*speedtest.Result{}
+	}
+	return writeHeadlessSpeedtestHistory(w, history, opts)
+}
+
+// writeHeadlessSpeedtestHistory serializes stored speedtest results in the
+// requested format
+func writeHeadlessSpeedtestHistory(w io.Writer, history []*speedtest.Result, opts HeadlessOptions) error {
+	switch opts.Format {
+	case "", "json":
+		enc := json.NewEncoder(w)
+		if opts.Pretty {
+			enc.SetIndent("", "  ")
+		}
+		return enc.Encode(history)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		defer enc.Close()
+		return enc.Encode(history)
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "Timestamp\tBackend\tServer\tDownload\tUpload\tLatency")
+		for _, r := range history {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%.2f Mbps\t%.2f Mbps\t%s\n",
+				r.Timestamp.Format(time.RFC3339), r.Backend, r.ServerHost, r.DownloadMbps, r.UploadMbps, r.Latency)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unsupported output format %q", opts.Format)
+	}
+}
+
+// HeadlessDiff is what --diff reports: the changes between the last two
+// gateway audits and between the last two snapshots, whichever exist
+type HeadlessDiff struct {
+	Audit    *scan.ScanDiff      `json:"audit,omitempty" yaml:"audit,omitempty"`
+	Snapshot *store.SnapshotDiff `json:"snapshot,omitempty" yaml:"snapshot,omitempty"`
+}
+
+// RunHeadlessDiff writes the changes between the last two saved audits of the
+// interface's gateway, and between the last two snapshots, to w
+func RunHeadlessDiff(w io.Writer, ifaceName string, opts HeadlessOptions) error {
+	var diff HeadlessDiff
+	var missing []string
+
+	auditDiff, err := lastAuditDiff(ifaceName)
+	if err != nil {
+		missing = append(missing, err.Error())
+	} else {
+		diff.Audit = auditDiff
+	}
+
+	snaps, err := store.LoadRecentSnapshots(2)
+	if err != nil {
+		return err
+	}
+	if len(snaps) == 2 {
+		diff.Snapshot = store.DiffSnapshots(snaps[0], snaps[1])
+	} else {
+		missing = append(missing, fmt.Sprintf("need two snapshots to diff, found %d", len(snaps)))
+	}
+
+	if diff.Audit == nil && diff.Snapshot == nil {
+		return fmt.Errorf("nothing to diff: %s", strings.Join(missing, "; "))
+	}
+	return writeHeadlessDiff(w, diff, opts)
+}
+
+// lastAuditDiff compares the last two saved audits of the interface's gateway
+func lastAuditDiff(ifaceName string) (*scan.ScanDiff, error) {
+	details, err := netpkg.GetInterfaceDetails(ifaceName)
+	if err != nil {
+		return nil, err
+	}
+	gateway := details.IPv4Gateway()
+	if gateway == "" {
+		return nil, fmt.Errorf("no IPv4 gateway on %s", ifaceName)
+	}
+
+	results, err := store.LoadScanHistory(gateway, 2)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) < 2 {
+		return nil, fmt.Errorf("need two saved audits of %s to diff, found %d", gateway, len(results))
+	}
+	diff := scan.DiffResults(results[0], results[1])
+	return &diff, nil
+}
+
+// writeHeadlessDiff serializes audit and snapshot diffs in the requested format
+func writeHeadlessDiff(w io.Writer, diff HeadlessDiff, opts HeadlessOptions) error {
+	switch opts.Format {
+	case "", "json":
+		enc := json.NewEncoder(w)
+		if opts.Pretty {
+			enc.SetIndent("", "  ")
+		}
+		return enc.Encode(diff)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		defer enc.Close()
+		return enc.Encode(diff)
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "Change\tHost\tPort\tOld\tNew")
+		if a := diff.Audit; a != nil {
+			for _, ip := range a.NewHosts {
+				fmt.Fprintf(tw, "new host\t%s\t\t\t\n", ip)
+			}
+			for _, ip := range a.GoneHosts {
+				fmt.Fprintf(tw, "gone host\t%s\t\t\t\n", ip)
+			}
+			for _, c := range a.PortChanges {
+				fmt.Fprintf(tw, "port\t%s\t%d/%s\t%s\t%s\n", c.Host, c.Port, c.Protocol, c.OldState, c.NewState)
+			}
+		}
+		if sd := diff.Snapshot; sd != nil {
+			for _, ip := range sd.IPsAdded {
+				fmt.Fprintf(tw, "address added\t%s\t\t\t\n", ip)
+			}
+			for _, ip := range sd.IPsRemoved {
+				fmt.Fprintf(tw, "address removed\t%s\t\t\t\n", ip)
+			}
+			if sd.GatewayChanged {
+				fmt.Fprintf(tw, "gateway\t\t\t%s\t%s\n", sd.OldGateway, sd.NewGateway)
+			}
+			if sd.DNSChanged {
+				fmt.Fprintf(tw, "dns\t\t\t%s\t%s\n", strings.Join(sd.OldDNS, ","), strings.Join(sd.NewDNS, ","))
+			}
+			for _, c := range sd.PortsAdded {
+				fmt.Fprintf(tw, "port opened\t%s\t%d/%s\t\t%s\n", c.Host, c.Port, c.Protocol, c.Service)
+			}
+			for _, c := range sd.PortsRemoved {
+				fmt.Fprintf(tw, "port closed\t%s\t%d/%s\t%s\t\n", c.Host, c.Port, c.Protocol, c.Service)
+			}
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unsupported output format %q", opts.Format)
+	}
+}
+
+// writeHeadlessReport serializes a report in the requested format
+func writeHeadlessReport(w io.Writer, report *HeadlessReport, opts HeadlessOptions) error {
+	switch opts.Format {
+	case "", "json":
+		enc := json.NewEncoder(w)
+		if opts.Pretty {
+			enc.SetIndent("", "  ")
+		}
+		return enc.Encode(report)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		defer enc.Close()
+		return enc.Encode(report)
+	case "table":
+		return writeHeadlessTable(w, report)
+	default:
+		return fmt.Errorf("unsupported output format %q", opts.Format)
+	}
+}
+
+// writeHeadlessTable renders a report as aligned key/value rows
+func writeHeadlessTable(w io.Writer, report *HeadlessReport) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	row := func(key, value string) {
+		fmt.Fprintf(tw, "%s\t%s\n", key, value)
+	}
+
+	row("Timestamp", report.Timestamp.Format(time.RFC3339))
+	if report.Interface != nil {
+		row("Interface", report.Interface.Name)
+		row("IPs", strings.Join(report.Interface.IPs, ", "))
+		row("MAC", report.Interface.MAC)
+		row("MTU", fmt.Sprintf("%d", report.Interface.MTU))
+		row("Link", upDown(report.Interface.LinkUp))
+		row("Errors", fmt.Sprintf("rx %d / tx %d", report.Interface.RxErrors, report.Interface.TxErrors))
+		row("Dropped", fmt.Sprintf("rx %d / tx %d", report.Interface.RxDropped, report.Interface.TxDropped))
+	}
+	row("Gateways", strings.Join(report.Gateways, ", "))
+	row("DNS Servers", strings.Join(report.DNSServers, ", "))
+	if report.Rate != nil {
+		row("Rate", fmt.Sprintf("rx %.0f bps / tx %.0f bps", report.Rate.RxBps, report.Rate.TxBps))
+	}
+	if report.Capture != nil {
+		row("Capture", report.Capture.String())
+	}
+	if len(report.ScanPorts) > 0 {
+		row("Scan Ports", scan.FormatPorts(report.ScanPorts))
+	}
+	if report.Audit != nil {
+		row("Audit", fmt.Sprintf("%d of %d hosts active", report.Audit.ActiveHosts, report.Audit.TotalHosts))
+		for _, host := range report.Audit.Hosts {
+			if host.OsHint != "" {
+				row("", fmt.Sprintf("%s: %s", host.IP, host.OsHint))
+			}
+		}
+	}
+
+	if d := report.Diagnostics; d != nil {
+		row("Ping Loss", fmt.Sprintf("%.0f%%", d.Ping.Loss))
+		row("Ping RTT", d.Ping.RTTSummary())
+		for i, line := range remotePingLines(d.RemotePings) {
+			key := ""
+			if i == 0 {
+				key = "Remote Pings"
+			}
+			row(key, line)
+		}
+		row("DNS System", okFail(d.DNS.SystemOK))
+		if len(d.DNS.AltTried) > 0 {
+			row("DNS Alternates", okFail(d.DNS.AltOK))
+		}
+		if d.DNS.DOH.Server != "" {
+			row("DNS-over-HTTPS", fmt.Sprintf("%s (%s)", okFail(d.DNS.DOH.OK), d.DNS.DOH.Server))
+		}
+		if d.DNS.DNSSEC.Server != "" {
+			if d.DNS.DNSSEC.Err != "" {
+				row("DNSSEC", "error: "+d.DNS.DNSSEC.Err)
+			} else {
+				row("DNSSEC", fmt.Sprintf("%s (%s)", okFail(d.DNS.DNSSEC.Validated), d.DNS.DNSSEC.Server))
+			}
+		}
+		row("HTTPS", fmt.Sprintf("%s (status %d, %s)", okFail(d.HTTPS.OK), d.HTTPS.Status, d.HTTPS.Target))
+		if d.HTTPS.CaptivePortal {
+			row("Captive Portal", d.HTTPS.CaptivePortalURL)
+		}
+		if !d.HTTPS.CertExpiry.IsZero() {
+			row("TLS Certificate", fmt.Sprintf("%s, expires %s (%d days)", d.HTTPS.CertIssuer, d.HTTPS.CertExpiry.Format("2006-01-02"), d.HTTPS.CertDaysLeft))
+		}
+		if d.NTP != nil {
+			if d.NTP.Err != "" {
+				row("Clock Offset", "error: "+d.NTP.Err)
+			} else {
+				row("Clock Offset", fmt.Sprintf("%s (%v, %s)", okFail(d.NTP.OK), d.NTP.Offset.Round(time.Millisecond), d.NTP.Server))
+			}
+		}
+		if d.MTU != nil {
+			if d.MTU.Err != "" {
+				row("Path MTU", "error: "+d.MTU.Err)
+			} else {
+				row("Path MTU", fmt.Sprintf("%d", d.MTU.MaxMTU))
+			}
+		}
+		for i, s := range d.Suggestions {
+			key := ""
+			if i == 0 {
+				key = "Suggestions"
+			}
+			row(key, s)
+		}
+	}
+
+	return tw.Flush()
+}
+
+func upDown(up bool) string {
+	if up {
+		return "UP"
+	}
+	return "DOWN"
+}
+
+func okFail(ok bool) string {
+	if ok {
+		return "OK"
+	}
+	return "FAIL"
+}
@@ -0,0 +1,445 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexpitcher/LanAudit/internal/capture"
+	"github.com/alexpitcher/LanAudit/internal/diagnostics"
+	netpkg "github.com/alexpitcher/LanAudit/internal/net"
+	"github.com/alexpitcher/LanAudit/internal/scan"
+	"github.com/alexpitcher/LanAudit/internal/speedtest"
+	"github.com/alexpitcher/LanAudit/internal/store"
+	"gopkg.in/yaml.v3"
+)
+
+func sampleHeadlessReport() *HeadlessReport {
+	details := &netpkg.InterfaceDetails{
+		Name:            "en0",
+		IPs:             []string{"192.168.1.10/24"},
+		MAC:             "aa:bb:cc:dd:ee:ff",
+		MTU:             1500,
+		DefaultGateways: []string{"192.168.1.1", "fe80::1%en0"},
+		DNSServers:      []string{"1.1.1.1", "8.8.8.8"},
+		LinkUp:          true,
+		BytesRx:         1024,
+		BytesTx:         2048,
+		RxErrors:        3,
+		TxDropped:       1,
+	}
+
+	return &HeadlessReport{
+		Timestamp:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Interface:  details,
+		Gateways:   details.DefaultGateways,
+		DNSServers: details.DNSServers,
+		Rate:       &netpkg.InterfaceRate{RxBps: 12.4e6, TxBps: 3.1e6},
+		Diagnostics: &diagnostics.Result{
+			LinkUp:  true,
+			Gateway: "192.168.1.1",
+			Ping:    diagnostics.PingResult{Loss: 0, RTTMin: 10 * time.Millisecond, RTTP50: 12 * time.Millisecond, RTTP95: 20 * time.Millisecond, RTTMax: 21 * time.Millisecond},
+			DNS:     diagnostics.DNSResult{SystemOK: false, AltOK: true, AltTried: []string{"1.1.1.1"}, Err: "timeout"},
+			HTTPS:   diagnostics.HTTPSResult{OK: true, Status: 200, TLSOK: true},
+			Suggestions: []string{
+				"System DNS failing but alternates work",
+			},
+		},
+	}
+}
+
+func TestWriteHeadlessReportJSONRoundTrip(t *testing.T) {
+	for _, pretty := range []bool{false, true} {
+		report := sampleHeadlessReport()
+
+		var buf bytes.Buffer
+		if err := writeHeadlessReport(&buf, report, HeadlessOptions{Format: "json", Pretty: pretty}); err != nil {
+			t.Fatalf("writeHeadlessReport failed: %v", err)
+		}
+
+		if !strings.Contains(buf.String(), `"RxErrors":3`) && !strings.Contains(buf.String(), `"RxErrors": 3`) {
+			t.Errorf("expected error counters in JSON output, got: %s", buf.String())
+		}
+
+		if pretty && !strings.Contains(buf.String(), "\n  \"") {
+			t.Errorf("expected indented output, got: %s", buf.String())
+		}
+
+		var decoded HeadlessReport
+		if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+			t.Fatalf("json.Unmarshal failed: %v", err)
+		}
+
+		if !reflect.DeepEqual(&decoded, report) {
+			t.Errorf("round trip mismatch (pretty=%v):\n got %+v\nwant %+v", pretty, decoded, *report)
+		}
+	}
+}
+
+func TestWriteHeadlessReportYAML(t *testing.T) {
+	report := sampleHeadlessReport()
+
+	var buf bytes.Buffer
+	if err := writeHeadlessReport(&buf, report, HeadlessOptions{Format: "yaml"}); err != nil {
+		t.Fatalf("writeHeadlessReport failed: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "- 192.168.1.1") {
+		t.Errorf("expected gateway in YAML output, got: %s", buf.String())
+	}
+
+	var decoded HeadlessReport
+	if err := yaml.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("yaml.Unmarshal failed: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.Gateways, report.Gateways) || !reflect.DeepEqual(decoded.DNSServers, report.DNSServers) {
+		t.Errorf("unexpected YAML decode: %+v", decoded)
+	}
+}
+
+func TestWriteHeadlessReportUnknownFormat(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeHeadlessReport(&buf, sampleHeadlessReport(), HeadlessOptions{Format: "xml"}); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
+
+func TestWriteHeadlessReportTable(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeHeadlessReport(&buf, sampleHeadlessReport(), HeadlessOptions{Format: "table"}); err != nil {
+		t.Fatalf("writeHeadlessReport failed: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"Interface", "en0", "Gateways", "192.168.1.1, fe80::1%en0", "DNS System", "FAIL", "HTTPS", "status 200", "p50 12ms, p95 20ms"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected table output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteHeadlessReportAudit(t *testing.T) {
+	report := sampleHeadlessReport()
+	report.ScanPorts = []int{22, 8080}
+	report.Audit = &scan.ScanResult{
+		Gateway:     "192.168.1.1",
+		TotalHosts:  254,
+		ActiveHosts: 2,
+		Hosts: []scan.HostResult{
+			{IP: "192.168.1.1", OsHint: "Network device"},
+			{IP: "192.168.1.20", OsHint: "Windows"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeHeadlessReport(&buf, report, HeadlessOptions{Format: "json"}); err != nil {
+		t.Fatalf("writeHeadlessReport failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"scan_ports":[22,8080]`) {
+		t.Errorf("expected scan ports in JSON output, got: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), `"OsHint":"Windows"`) {
+		t.Errorf("expected OS hint in JSON output, got: %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := writeHeadlessReport(&buf, report, HeadlessOptions{Format: "table"}); err != nil {
+		t.Fatalf("writeHeadlessReport failed: %v", err)
+	}
+	for _, want := range []string{"2 of 254 hosts active", "192.168.1.20: Windows", "Scan Ports", "22,8080"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected table output to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestWriteHeadlessHistory(t *testing.T) {
+	history := []*diagnostics.Result{
+		{Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), Gateway: "192.168.1.1", Ping: diagnostics.PingResult{Loss: 25}},
+		sampleHeadlessReport().Diagnostics,
+	}
+
+	var buf bytes.Buffer
+	if err := writeHeadlessHistory(&buf, history, HeadlessOptions{Format: "json"}); err != nil {
+		t.Fatalf("writeHeadlessHistory failed: %v", err)
+	}
+	var decoded []diagnostics.Result
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v", err)
+	}
+	if len(decoded) != 2 || decoded[0].Ping.Loss != 25 {
+		t.Errorf("unexpected JSON history: %+v", decoded)
+	}
+
+	buf.Reset()
+	if err := writeHeadlessHistory(&buf, history, HeadlessOptions{Format: "table"}); err != nil {
+		t.Fatalf("writeHeadlessHistory failed: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "2024-01-02T03:04:05Z") || !strings.Contains(out, "25%") {
+		t.Errorf("unexpected table history:\n%s", out)
+	}
+}
+
+func TestWriteHeadlessSpeedtestHistory(t *testing.T) {
+	history := []*speedtest.Result{
+		{Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), DownloadMbps: 412.5, UploadMbps: 38.2, Backend: speedtest.BackendSpeedtestNet},
+		{Timestamp: time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC), DownloadMbps: 87.1, UploadMbps: 30, Backend: speedtest.BackendIperf3, ServerHost: "10.0.0.5:5201"},
+	}
+
+	var buf bytes.Buffer
+	if err := writeHeadlessSpeedtestHistory(&buf, history, HeadlessOptions{Format: "json"}); err != nil {
+		t.Fatalf("writeHeadlessSpeedtestHistory failed: %v", err)
+	}
+	var decoded []speedtest.Result
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v", err)
+	}
+	if len(decoded) != 2 || decoded[1].DownloadMbps != 87.1 || !decoded[0].Timestamp.Equal(history[0].Timestamp) {
+		t.Errorf("unexpected JSON history: %+v", decoded)
+	}
+
+	buf.Reset()
+	if err := writeHeadlessSpeedtestHistory(&buf, history, HeadlessOptions{Format: "table"}); err != nil {
+		t.Fatalf("writeHeadlessSpeedtestHistory failed: %v", err)
+	}
+	for _, want := range []string{"2024-01-02T15:04:05Z", "iperf3", "10.0.0.5:5201", "412.50 Mbps"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected table output to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestRunHeadlessSnapshotRejectsFormat(t *testing.T) {
+	_, err := RunHeadlessSnapshot(context.Background(), io.Discard, "en0", "xml")
+	if err == nil || !strings.Contains(err.Error(), "unsupported export format") {
+		t.Errorf("RunHeadlessSnapshot() error = %v, want unsupported export format", err)
+	}
+}
+
+func TestWriteHeadlessDiff(t *testing.T) {
+	diff := HeadlessDiff{
+		Audit: &scan.ScanDiff{
+			NewHosts:    []string{"192.168.1.50"},
+			GoneHosts:   []string{"192.168.1.10"},
+			PortChanges: []scan.PortChange{{Host: "192.168.1.1", Port: 23, Protocol: "tcp", OldState: "closed", NewState: "open"}},
+		},
+		Snapshot: &store.SnapshotDiff{
+			From:           "2024-03-01 09:00:00",
+			To:             "2024-03-02 09:00:00",
+			IPsAdded:       []string{"192.168.1.57"},
+			GatewayChanged: true,
+			OldGateway:     "192.168.1.1",
+			NewGateway:     "192.168.1.254",
+			PortsAdded:     []store.ServiceChange{{Host: "192.168.1.254", Port: 8443, Protocol: "tcp", Service: "HTTPS-Alt"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeHeadlessDiff(&buf, diff, HeadlessOptions{Format: "json"}); err != nil {
+		t.Fatalf("writeHeadlessDiff failed: %v", err)
+	}
+	var decoded HeadlessDiff
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, diff) {
+		t.Errorf("round trip = %+v, want %+v", decoded, diff)
+	}
+
+	buf.Reset()
+	if err := writeHeadlessDiff(&buf, diff, HeadlessOptions{Format: "table"}); err != nil {
+		t.Fatalf("writeHeadlessDiff failed: %v", err)
+	}
+	for _, want := range []string{"new host", "192.168.1.50", "gone host", "23/tcp", "closed", "open",
+		"address added", "192.168.1.57", "gateway", "192.168.1.254", "port opened", "8443/tcp"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected table output to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+
+	// Either half may be missing
+	buf.Reset()
+	if err := writeHeadlessDiff(&buf, HeadlessDiff{Snapshot: diff.Snapshot}, HeadlessOptions{Format: "json"}); err != nil {
+		t.Fatalf("writeHeadlessDiff failed: %v", err)
+	}
+	if strings.Contains(buf.String(), `"audit"`) {
+		t.Errorf("expected no audit key without an audit diff, got %s", buf.String())
+	}
+}
+
+func TestRedactHeadlessReport(t *testing.T) {
+	report := sampleHeadlessReport()
+	redacted := redactHeadlessReport(report, store.RedactTwoOctets)
+
+	if got := redacted.Interface.IPs; !reflect.DeepEqual(got, []string{"192.168.xxx.xxx/24"}) {
+		t.Errorf("IPs = %v", got)
+	}
+	if got, want := redacted.Gateways, []string{"192.168.xxx.xxx", "fe80:0:xxxx:xxxx:xxxx:xxxx:xxxx:xxxx%en0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Gateways = %v, want %v", got, want)
+	}
+	if got := redacted.DNSServers; !reflect.DeepEqual(got, []string{"1.1.xxx.xxx", "8.8.xxx.xxx"}) {
+		t.Errorf("DNSServers = %v", got)
+	}
+	if redacted.Interface.MAC != "aa:bb:cc:dd:xx:xx" {
+		t.Errorf("MAC = %s", redacted.Interface.MAC)
+	}
+	if report.Interface.IPs[0] != "192.168.1.10/24" || report.Gateways[0] != "192.168.1.1" {
+		t.Error("original report should not be modified")
+	}
+}
+
+func TestLastGatewayAudit(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	details := &netpkg.InterfaceDetails{Name: "en0", DefaultGateways: []string{"fe80::1", "192.168.1.1"}}
+	if audit := lastGatewayAudit(details); audit != nil {
+		t.Fatalf("expected no audit before one is saved, got %+v", audit)
+	}
+
+	saved := &scan.ScanResult{
+		Gateway:   "192.168.1.1",
+		StartTime: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Hosts:     []scan.HostResult{{IP: "192.168.1.1", OsHint: "network device"}},
+	}
+	if err := store.SaveScanResult(saved); err != nil {
+		t.Fatal(err)
+	}
+
+	audit := lastGatewayAudit(details)
+	if audit == nil || len(audit.Hosts) != 1 || audit.Hosts[0].OsHint != "network device" {
+		t.Fatalf("lastGatewayAudit() = %+v, want the saved audit", audit)
+	}
+	report := sampleHeadlessReport()
+	report.Audit = audit
+	var buf bytes.Buffer
+	if err := writeHeadlessReport(&buf, report, HeadlessOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"OsHint":"network device"`) {
+		t.Errorf("expected the OS hint in headless JSON, got:\n%s", buf.String())
+	}
+}
+
+func TestSampleRate(t *testing.T) {
+	defer func() { headlessStats = netpkg.GetInterfaceStats }()
+	readings := 0
+	headlessStats = func(string) (*netpkg.InterfaceStats, error) {
+		readings++
+		if readings == 1 {
+			return &netpkg.InterfaceStats{BytesRx: 1000, BytesTx: 1000}, nil
+		}
+		return &netpkg.InterfaceStats{BytesRx: 1000 + 12500, BytesTx: 1000 + 2500}, nil
+	}
+
+	start := time.Now()
+	rate := sampleRate(context.Background(), "en0", 20*time.Millisecond)
+	elapsed := time.Since(start)
+	if rate == nil || readings != 2 {
+		t.Fatalf("sampleRate() = %v after %d readings", rate, readings)
+	}
+	// 12,500 bytes in at least 20ms is at most 5 Mbps; both directions
+	// share the window
+	if rate.RxBps <= 0 || rate.RxBps > 5e6 || rate.RxBps < 12500*8/elapsed.Seconds() {
+		t.Errorf("RxBps = %.0f over %s", rate.RxBps, elapsed)
+	}
+	if ratio := rate.RxBps / rate.TxBps; ratio < 4.99 || ratio > 5.01 {
+		t.Errorf("RxBps/TxBps = %.3f, want 5", ratio)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if rate := sampleRate(ctx, "en0", time.Second); rate != nil {
+		t.Errorf("sampleRate() after cancel = %+v, want nil", rate)
+	}
+
+	headlessStats = func(string) (*netpkg.InterfaceStats, error) { return nil, errors.New("no such device") }
+	if rate := sampleRate(context.Background(), "en0", time.Millisecond); rate != nil {
+		t.Errorf("sampleRate() without counters = %+v, want nil", rate)
+	}
+}
+
+func TestBuildSnapshotIncludesCapture(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	buildReport = func(ctx context.Context, ifaceName string) (*HeadlessReport, error) {
+		report := sampleHeadlessReport()
+		report.Capture = &capture.Stats{TCP: 5, DNS: 2, KernelDropped: 1}
+		return report, nil
+	}
+	defer func() { buildReport = buildHeadlessReport }()
+
+	snap, err := buildSnapshot(context.Background(), "en0")
+	if err != nil {
+		t.Fatalf("buildSnapshot() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if err := store.ExportSnapshotJSON(snap, &buf); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Capture *capture.Stats `json:"capture"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Capture == nil || doc.Capture.TCP != 5 || doc.Capture.DNS != 2 || doc.Capture.KernelDropped != 1 {
+		t.Errorf("capture in snapshot = %+v, want the session stats", doc.Capture)
+	}
+}
+
+func TestRunHeadlessWatch(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := 0
+	buildReport = func(ctx context.Context, ifaceName string) (*HeadlessReport, error) {
+		calls++
+		if calls == 2 {
+			// Stopping mid-run still lets the run finish and be written
+			cancel()
+			if ctx.Err() != nil {
+				t.Error("expected the run's context to outlive the watch")
+			}
+			return nil, errors.New("interface en0 not found")
+		}
+		return sampleHeadlessReport(), nil
+	}
+	defer func() { buildReport = buildHeadlessReport }()
+
+	var buf bytes.Buffer
+	if err := RunHeadlessWatch(ctx, &buf, "en0", 10*time.Millisecond, HeadlessOptions{}); err != nil {
+		t.Fatalf("RunHeadlessWatch() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 JSON lines, got %d:\n%s", len(lines), buf.String())
+	}
+	var runs []map[string]interface{}
+	for _, line := range lines {
+		var run map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &run); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		runs = append(runs, run)
+	}
+
+	// The sample report's system DNS failure counts as a failed run
+	if runs[0]["run_number"] != 1.0 || runs[0]["consecutive_failures"] != 1.0 || runs[0]["diagnostics"] == nil {
+		t.Errorf("first run = %v", runs[0])
+	}
+	if runs[1]["run_number"] != 2.0 || runs[1]["consecutive_failures"] != 2.0 || runs[1]["error"] != "interface en0 not found" {
+		t.Errorf("second run = %v", runs[1])
+	}
+}
+
+func TestRunHeadlessWatchRejectsTable(t *testing.T) {
+	if err := RunHeadlessWatch(context.Background(), io.Discard, "en0", time.Second, HeadlessOptions{Format: "table"}); err == nil {
+		t.Error("expected an error for table output")
+	}
+}
@@ -0,0 +1,65 @@
+package tui
+
+import (
+	"github.com/alexpitcher/LanAudit/internal/store"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ThemePreset, when set, replaces the configured theme with a built-in
+// preset for this run (the --theme flag)
+var ThemePreset string
+
+// StyleSet holds the lipgloss styles the views render with
+type StyleSet struct {
+	Status    lipgloss.Style // status bar
+	Header    lipgloss.Style // titles
+	Box       lipgloss.Style // bordered overlays: help and input prompts
+	Highlight lipgloss.Style // row under the mouse
+	Error     lipgloss.Style
+	Warning   lipgloss.Style
+	Success   lipgloss.Style
+}
+
+// NewStyleSet builds the styles for a theme, filling unset colors from its
+// preset
+func NewStyleSet(tc store.ThemeConfig) StyleSet {
+	tc = tc.Resolve()
+	fg := func(color string) lipgloss.Style {
+		return withColors(lipgloss.NewStyle(), color, "")
+	}
+	return StyleSet{
+		Status: withColors(lipgloss.NewStyle(), tc.StatusFg, tc.StatusBg),
+		Header: withColors(lipgloss.NewStyle().Bold(true), tc.HeaderFg, tc.HeaderBg),
+		Box: lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			Padding(1, 2).
+			BorderForeground(lipgloss.Color(tc.HeaderFg)),
+		Highlight: withColors(lipgloss.NewStyle(), tc.HighlightFg, tc.HighlightBg),
+		Error:     fg(tc.ErrorFg),
+		Warning:   fg(tc.WarningFg),
+		Success:   fg(tc.SuccessFg),
+	}
+}
+
+// themeStyles returns the styles for a config, honouring ThemePreset
+func themeStyles(config *store.Config) StyleSet {
+	tc := store.ThemeConfig{}
+	if config != nil {
+		tc = config.Theme
+	}
+	if ThemePreset != "" {
+		tc = store.ThemeConfig{Preset: ThemePreset}
+	}
+	return NewStyleSet(tc)
+}
+
+// withColors sets the foreground and background colors that are not empty
+func withColors(style lipgloss.Style, fg, bg string) lipgloss.Style {
+	if fg != "" {
+		style = style.Foreground(lipgloss.Color(fg))
+	}
+	if bg != "" {
+		style = style.Background(lipgloss.Color(bg))
+	}
+	return style
+}
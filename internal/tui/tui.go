@@ -2,22 +2,32 @@ package tui
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
+	"unicode"
 
+	"github.com/alexpitcher/LanAudit/internal/arpscan"
 	"github.com/alexpitcher/LanAudit/internal/capture"
 	"github.com/alexpitcher/LanAudit/internal/console"
 	fingerprint "github.com/alexpitcher/LanAudit/internal/console/fingerprint"
 	"github.com/alexpitcher/LanAudit/internal/diagnostics"
 	"github.com/alexpitcher/LanAudit/internal/logging"
+	"github.com/alexpitcher/LanAudit/internal/mdns"
 	netpkg "github.com/alexpitcher/LanAudit/internal/net"
 	"github.com/alexpitcher/LanAudit/internal/scan"
 	"github.com/alexpitcher/LanAudit/internal/speedtest"
 	"github.com/alexpitcher/LanAudit/internal/store"
 	"github.com/alexpitcher/LanAudit/internal/vlan"
+	"github.com/alexpitcher/LanAudit/internal/webhook"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -37,6 +47,15 @@ const (
 	ViewLLDP
 	ViewSpeedtest
 	ViewConsole
+	ViewARP
+	ViewARPMonitor
+	ViewDNSLog
+	ViewSnapDiff
+	ViewARPScan
+	ViewCDP
+	ViewWoL
+	ViewMDNS
+	ViewSTP
 )
 
 // Model is the main TUI model
@@ -45,10 +64,14 @@ type Model struct {
 	selectedIface string
 	interfaces    []netpkg.Iface
 	// Menu state
-	selectedIndex int // cursor for interface picker
-	modeIndex     int // cursor for mode selection
+	selectedIndex int    // cursor for interface picker
+	modeIndex     int    // cursor for mode selection
+	hoverIndex    int    // row under the mouse in the picker or mode menu; -1 for none
+	pickerFilter  string // picker search; matches interface names and descriptions
+	scrollOffset  int    // first picker entry on screen
 	layer         MenuLayer
 	config        *store.Config
+	styles        StyleSet
 	details       *netpkg.InterfaceDetails
 	statusMsg     string
 	width         int
@@ -61,10 +84,15 @@ type Model struct {
 	inputActive    bool
 	inputPrompt    string
 	inputValue     string
+	inputMasked    bool // echo '*' for passphrases
 	inputSubmit    func(*Model, string) tea.Cmd
 
 	// Help overlay
 	helpActive bool
+	helpScroll int // first line of the key table shown
+
+	// Interface hotplug notifications from netpkg.WatchInterfaces
+	ifaceUpdates <-chan []netpkg.Iface
 
 	// Sub-models for each view
 	detailsView   *DetailsView
@@ -77,6 +105,15 @@ type Model struct {
 	speedtestView *SpeedtestView
 	lldpView      *LLDPView
 	consoleView   *ConsoleView
+	arpView       *ARPView
+	snapDiffView  *SnapDiffView
+	arpMonView    *ARPMonitorView
+	dnsLogView    *DNSLogView
+	arpScanView   *ARPScanView
+	cdpView       *CDPView
+	wolView       *WoLView
+	mdnsView      *MDNSView
+	stpView       *STPView
 }
 
 // DetailsView handles the details tab
@@ -84,12 +121,70 @@ type DetailsView struct {
 	details     *netpkg.InterfaceDetails
 	lastUpdate  time.Time
 	autoRefresh bool
+	rates       *netpkg.RateTracker
+	rate        netpkg.InterfaceRate
+	rateReady   bool
+	monitor     *netpkg.InterfaceRateMonitor // nil when sampling on tick instead
+}
+
+// rateMonitorInterval is how often the details view samples the counters
+const rateMonitorInterval = time.Second
+
+// startRateMonitor replaces any running rate monitor with one for iface.
+// Without a monitor, rates are computed from the tick refreshes.
+func (v *DetailsView) startRateMonitor(iface string) {
+	v.stopRateMonitor()
+	mon, err := netpkg.NewInterfaceRateMonitor(iface, rateMonitorInterval)
+	if err != nil {
+		logging.Warnf("rate monitor unavailable for %s: %v", iface, err)
+		return
+	}
+	v.monitor = mon
+}
+
+// stopRateMonitor stops the rate monitor, if one is running
+func (v *DetailsView) stopRateMonitor() {
+	if v != nil && v.monitor != nil {
+		v.monitor.Stop()
+		v.monitor = nil
+	}
+}
+
+// readRateMonitor takes the latest rate from the monitor without blocking.
+// It reports false when no monitor is running.
+func (v *DetailsView) readRateMonitor() bool {
+	if v.monitor == nil {
+		return false
+	}
+	select {
+	case rate, ok := <-v.monitor.Rates():
+		if !ok {
+			v.monitor = nil
+			return false
+		}
+		v.rate = rate
+		v.rateReady = true
+	default:
+	}
+	return true
+}
+
+// updateRate feeds the latest byte counters into the rate tracker
+func (v *DetailsView) updateRate(details *netpkg.InterfaceDetails, at time.Time) {
+	if v.rates == nil {
+		v.rates = netpkg.NewRateTracker(netpkg.DefaultRateWindow)
+	}
+	if rate, ok := v.rates.Update(details.BytesRx, details.BytesTx, at); ok {
+		v.rate = rate
+		v.rateReady = true
+	}
 }
 
 // DiagnoseView handles the diagnostics tab
 type DiagnoseView struct {
 	running       bool
 	result        *diagnostics.Result
+	history       []*diagnostics.Result // most recent runs, oldest first
 	lastRun       time.Time
 	err           error
 	statusMessage string
@@ -98,10 +193,12 @@ type DiagnoseView struct {
 // VLANView handles the VLAN tester tab
 type VLANView struct {
 	running       bool
+	discovering   bool
 	results       []vlan.LeaseResult
 	statusMessage string
 	err           error
 	vlans         []int
+	rangeStr      string // as typed, e.g. "10,20,100-110"
 	keep          bool
 	consentToken  string
 }
@@ -116,16 +213,33 @@ type SnapView struct {
 
 // SettingsView handles settings
 type SettingsView struct {
-	config *store.Config
+	config   *store.Config
+	profiles []string // saved profile names, besides config.json
 }
 
 // CaptureView handles packet capture
 type CaptureView struct {
 	running       bool
 	filter        string
+	filterError   string // why the last filter entered was rejected
+	ringCapacity  int    // 0 disables ring mode
+	showHTTP      bool   // list HTTP conversations instead of packets
 	statusMessage string
+
+	dhcpDetector *capture.RogueDHCPDetector // nil until 'R' starts one
+	dhcpServer   string                     // legitimate DHCP server last entered
+	rogueDHCP    []capture.RogueEvent       // most recent last
 }
 
+// maxRogueDHCPAlerts is how many rogue DHCP offers the capture view lists
+const maxRogueDHCPAlerts = 5
+
+// captureRingCapacities are the ring buffer sizes cycled by the 'r' key
+var captureRingCapacities = []int{0, 1000, 5000, 10000}
+
+// captureTopTalkers is how many IPs the running capture view lists
+const captureTopTalkers = 5
+
 // AuditView handles gateway audit
 type AuditView struct {
 	running       bool
@@ -133,6 +247,14 @@ type AuditView struct {
 	err           error
 	statusMessage string
 	consentToken  string
+	scanUDP       bool           // Also probe scan.DefaultUDPPorts
+	trySNMP       bool           // Also query each host's SNMP system group
+	diff          *scan.ScanDiff // Changes since the previous saved audit
+	showDiff      bool
+	snmpWalking   bool
+	snmpHost      string         // Gateway of the last SNMP walk
+	snmpVars      []scan.SNMPVar // System group from the last SNMP walk
+	snmpErr       error
 }
 
 // SpeedtestView handles speedtest
@@ -142,6 +264,7 @@ type SpeedtestView struct {
 	err           error
 	statusMessage string
 	lastRun       time.Time
+	history       []*speedtest.Result // most recent runs, oldest first
 }
 
 // LLDPView handles LLDP discovery
@@ -153,12 +276,107 @@ type LLDPView struct {
 	duration      time.Duration
 }
 
+// CDPView handles CDP discovery
+type CDPView struct {
+	running       bool
+	neighbors     []netpkg.CDPNeighbor
+	err           error
+	statusMessage string
+}
+
+// STPView listens for spanning tree BPDUs
+type STPView struct {
+	running       bool
+	frames        []netpkg.STPFrame
+	err           error
+	statusMessage string
+}
+
+// MDNSView browses mDNS/DNS-SD services
+type MDNSView struct {
+	running       bool
+	entries       []mdns.MDNSEntry
+	err           error
+	statusMessage string
+}
+
+// WoLView sends Wake-on-LAN magic packets
+type WoLView struct {
+	target        string // last input: MAC and optional broadcast address
+	sending       bool
+	err           error
+	statusMessage string
+	lastSent      time.Time
+}
+
+// ARPView shows the system ARP cache
+type ARPView struct {
+	entries       []netpkg.ARPEntry
+	lastUpdate    time.Time
+	err           error
+	statusMessage string
+}
+
+// ARPScanView runs an active ARP sweep of a subnet
+type ARPScanView struct {
+	running       bool
+	iface         string // interface cidr was taken from
+	cidr          string
+	hosts         []arpscan.ARPHost
+	err           error
+	statusMessage string
+	lastRun       time.Time
+}
+
+// SnapDiffView compares the two most recent snapshots
+type SnapDiffView struct {
+	diff          *store.SnapshotDiff
+	err           error
+	statusMessage string
+}
+
+// ARPMonitorView shows live ARP rates per sender from capture.ARPMonitor
+type ARPMonitorView struct {
+	running       bool
+	threshold     int
+	events        <-chan capture.ARPEvent
+	senders       []capture.ARPEvent
+	alerts        []capture.ARPEvent // most recent last
+	statusMessage string
+}
+
+// arpMonitorThreshold is the per-sender ARP request rate that triggers an alert
+const arpMonitorThreshold = 50
+
+// maxARPAlerts is how many recent alerts the ARP monitor view keeps
+const maxARPAlerts = 10
+
+// cdpListenDuration covers the 60 second default CDP announcement interval
+const cdpListenDuration = 60 * time.Second
+
+// stpListenDuration covers several 2 second BPDU hello intervals
+const stpListenDuration = 10 * time.Second
+
+// DNSLogView shows DNS queries seen by capture.DNSLogger
+type DNSLogView struct {
+	logger        *capture.DNSLogger
+	events        []capture.DNSEvent
+	summary       map[string]int
+	statusMessage string
+}
+
+const (
+	// dnsLogRows is how many recent queries the DNS log view shows
+	dnsLogRows = 15
+	// dnsLogTopNames is how many of the most queried names are listed
+	dnsLogTopNames = 5
+)
+
 // ConsoleView handles serial console
 type ConsoleView struct {
 	ports                  []interface{} // Serial ports
 	selectedPort           int
-	session                interface{} // Active session
-	buffer                 []string    // Console output buffer
+	session                interface{} // Focused session, nil while the port list is shown
 	statusMessage          string
 	dtrState               bool
 	rtsState               bool
@@ -166,18 +384,78 @@ type ConsoleView struct {
 	fingerprint            *fingerprint.Result
 	allowProbeInConfigMode bool
 	probeStatus            string
+
+	manager      *console.SessionManager
+	buffers      map[string][]string            // Console output per session ID
+	fingerprints map[string]*fingerprint.Result // Probe results per port path
+	transfer     *consoleTransfer               // Running file upload, if any
+
+	macros        []string               // Saved macro names shown in the macro panel
+	showMacros    bool                   // Macro panel is open
+	selectedMacro int                    // Highlighted macro in the panel
+	recorder      *console.MacroRecorder // Macro being recorded, if any
+	recordingID   string                 // Session the recorder is attached to
+	playingMacro  string                 // Macro being played, if any
+
+	alerts chan consoleAlertMsg // Matches reported by session alerts
+
+	baseline *consoleBaselineMsg // Last baseline comparison
+}
+
+// consoleTransfer tracks a YMODEM upload; sent is updated from the transfer
+// goroutine
+type consoleTransfer struct {
+	path  string
+	total int64
+	sent  atomic.Int64
+}
+
+// nextSession returns the session after the focused one, or nil for the port
+// list once the last session has been passed
+func (v *ConsoleView) nextSession() interface{} {
+	sessions := v.manager.List()
+	current, _ := v.session.(console.Terminal)
+	if current == nil {
+		if len(sessions) == 0 {
+			return nil
+		}
+		return sessions[0]
+	}
+	for i, sess := range sessions {
+		if sess.ID() == current.ID() && i+1 < len(sessions) {
+			return sessions[i+1]
+		}
+	}
+	return nil
+}
+
+// stopRecording ends the macro recording, saves it and returns a status line
+func (v *ConsoleView) stopRecording() string {
+	macro := v.recorder.Stop()
+	v.recorder, v.recordingID = nil, ""
+	if len(macro.Steps) == 0 {
+		return fmt.Sprintf("Macro %s discarded: no commands recorded", macro.Name)
+	}
+	path, err := console.SaveMacro(macro)
+	if err != nil {
+		logging.Errorf("failed to save macro %s: %v", macro.Name, err)
+		return fmt.Sprintf("Failed to save macro %s: %v", macro.Name, err)
+	}
+	return fmt.Sprintf("Saved macro %s (%d steps) to %s", macro.Name, len(macro.Steps), path)
 }
 
 type tickMsg time.Time
 
 type diagnoseResultMsg struct {
-	res *diagnostics.Result
-	err error
+	res     *diagnostics.Result
+	history []*diagnostics.Result
+	err     error
 }
 
 type speedtestResultMsg struct {
-	res *speedtest.Result
-	err error
+	res     *speedtest.Result
+	history []*speedtest.Result
+	err     error
 }
 
 type vlanResultMsg struct {
@@ -185,21 +463,59 @@ type vlanResultMsg struct {
 	err     error
 }
 
+type vlanDiscoverMsg struct {
+	vlans []int
+	err   error
+}
+
 type extendedDetailsMsg struct {
 	speed     string
 	ifaceType string
+	wifi      *netpkg.WifiInfo
 	err       error
 }
 
 type auditResultMsg struct {
 	result *scan.ScanResult
+	diff   *scan.ScanDiff // nil when there was no earlier audit to compare
 	err    error
 }
 
+type snmpWalkResultMsg struct {
+	host string
+	vars []scan.SNMPVar
+	err  error
+}
+
 type startCaptureMsg struct {
 	err error
 }
 
+type startARPMonitorMsg struct {
+	events <-chan capture.ARPEvent
+	err    error
+}
+
+type startDNSLogMsg struct {
+	logger *capture.DNSLogger
+	err    error
+}
+
+type startRogueDHCPMsg struct {
+	detector *capture.RogueDHCPDetector
+	err      error
+}
+
+type rogueDHCPMsg struct {
+	event  capture.RogueEvent
+	closed bool
+}
+
+type arpEventMsg struct {
+	event  capture.ARPEvent
+	closed bool
+}
+
 type stopCaptureMsg struct {
 	err error
 }
@@ -214,6 +530,33 @@ type lldpResultMsg struct {
 	err       error
 }
 
+type wolResultMsg struct {
+	mac       string
+	broadcast string
+	err       error
+}
+
+type stpResultMsg struct {
+	frames []netpkg.STPFrame
+	err    error
+}
+
+type mdnsResultMsg struct {
+	entries []mdns.MDNSEntry
+	err     error
+}
+
+type cdpResultMsg struct {
+	neighbors []netpkg.CDPNeighbor
+	err       error
+}
+
+type arpScanResultMsg struct {
+	cidr  string
+	hosts []arpscan.ARPHost
+	err   error
+}
+
 type snapshotResultMsg struct {
 	path string
 	err  error
@@ -225,18 +568,55 @@ type consolePortsMsg struct {
 }
 
 type consoleSessionMsg struct {
-	session *console.Session
+	session console.Terminal
 	err     error
 }
 
 type consoleProbeMsg struct {
+	port   string
 	result console.ProbeResult
 }
 
+type consoleTransferMsg struct {
+	path string
+	err  error
+}
+
+type consoleMacroMsg struct {
+	name string
+	err  error
+}
+
+type consoleBaselineMsg struct {
+	port  string // Port the baseline was captured on
+	key   string
+	diff  []console.DiffLine
+	first bool // No earlier baseline to compare with
+	err   error
+}
+
+type consoleReconnectMsg struct {
+	id    string
+	event console.ReconnectEvent
+}
+
+type consoleAlertMsg struct {
+	id    string // Session the alert fired on
+	name  string
+	match string
+	at    time.Time
+}
+
 type consoleDataMsg struct {
+	id   string // Session the data was read from
 	data []byte
 }
 
+type ifaceUpdateMsg struct {
+	ifaces []netpkg.Iface
+	closed bool
+}
+
 // MenuLayer represents which layer of the UI is active
 type MenuLayer int
 
@@ -251,9 +631,71 @@ func (m Model) Init() tea.Cmd {
 	return tea.Batch(
 		tea.EnterAltScreen,
 		tick(),
+		waitForIfaceUpdate(m.ifaceUpdates),
 	)
 }
 
+// waitForARPEvent blocks on the next alert from the ARP monitor
+// waitForRogueDHCP blocks on the next rogue DHCP offer
+func waitForRogueDHCP(ch <-chan capture.RogueEvent) tea.Cmd {
+	if ch == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		ev, ok := <-ch
+		return rogueDHCPMsg{event: ev, closed: !ok}
+	}
+}
+
+func waitForARPEvent(ch <-chan capture.ARPEvent) tea.Cmd {
+	if ch == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		ev, ok := <-ch
+		return arpEventMsg{event: ev, closed: !ok}
+	}
+}
+
+// waitForConsoleAlert blocks on the next console alert match
+func waitForConsoleAlert(ch <-chan consoleAlertMsg) tea.Cmd {
+	if ch == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		return <-ch
+	}
+}
+
+// addConsoleAlerts registers the configured alerts on sess, reporting matches
+// on ch
+func addConsoleAlerts(sess *console.Session, patterns map[string]string, ch chan<- consoleAlertMsg) {
+	for name, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			logging.Warnf("skipping console alert %s: %v", name, err)
+			continue
+		}
+		sess.AddAlert(name, re, func(match string) {
+			select {
+			case ch <- consoleAlertMsg{id: sess.ID(), name: name, match: match, at: time.Now()}:
+			default:
+			}
+		})
+	}
+}
+
+// waitForIfaceUpdate blocks on the next interface list from the watcher
+func waitForIfaceUpdate(ch <-chan []netpkg.Iface) tea.Cmd {
+	if ch == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		ifaces, ok := <-ch
+		return ifaceUpdateMsg{ifaces: ifaces, closed: !ok}
+	}
+}
+
 func tick() tea.Cmd {
 	return tea.Tick(2*time.Second, func(t time.Time) tea.Msg {
 		return tickMsg(t)
@@ -266,12 +708,65 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		logging.Infof("key pressed: %q (layer=%d mode=%d)", msg.String(), m.layer, m.mode)
+		m.hoverIndex = -1
 		return m.handleKeys(msg)
 
+	case tea.MouseMsg:
+		return m.handleMouse(msg)
+
+	case vlanResultMsg:
+		if m.vlanView != nil {
+			m.vlanView.running = false
+			m.vlanView.results = msg.results
+			m.vlanView.err = msg.err
+			if msg.err != nil {
+				m.vlanView.statusMessage = fmt.Sprintf("VLAN test failed: %v", msg.err)
+			} else {
+				m.vlanView.statusMessage = fmt.Sprintf("Tested %d VLANs.", len(msg.results))
+			}
+		}
+		return m, nil
+
+	case snapshotResultMsg:
+		if m.snapView != nil {
+			m.snapView.running = false
+			m.snapView.err = msg.err
+			if msg.err != nil {
+				m.snapView.statusMessage = fmt.Sprintf("Snapshot failed: %v", msg.err)
+				logging.Warnf(m.snapView.statusMessage)
+			} else {
+				m.snapView.lastSnapshot = msg.path
+				m.snapView.statusMessage = "Snapshot saved. Press 's' to save another."
+				logging.Infof("snapshot saved to %s", msg.path)
+			}
+		}
+		return m, nil
+
+	case vlanDiscoverMsg:
+		if m.vlanView != nil {
+			m.vlanView.discovering = false
+			m.vlanView.err = msg.err
+			switch {
+			case msg.err != nil:
+				m.vlanView.statusMessage = fmt.Sprintf("VLAN discovery failed: %v", msg.err)
+				logging.Warnf(m.vlanView.statusMessage)
+			case len(msg.vlans) == 0:
+				m.vlanView.statusMessage = "No tagged traffic seen."
+			default:
+				m.vlanView.vlans = msg.vlans
+				m.vlanView.rangeStr = vlan.FormatVLANRange(msg.vlans)
+				m.vlanView.statusMessage = fmt.Sprintf("Discovered %d VLANs. Press 's' to test them.", len(msg.vlans))
+				logging.Infof("VLAN discovery on %s found %s", m.selectedIface, m.vlanView.rangeStr)
+			}
+		}
+		return m, nil
+
 	case auditResultMsg:
 		if m.auditView != nil {
 			m.auditView.running = false
 			m.auditView.result = msg.result
+			m.auditView.diff = msg.diff
+			m.auditView.showDiff = false
 			m.auditView.err = msg.err
 			if msg.err != nil {
 				m.auditView.statusMessage = fmt.Sprintf("Audit failed: %v", msg.err)
@@ -281,6 +776,23 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case snmpWalkResultMsg:
+		if m.auditView == nil {
+			m.auditView = &AuditView{}
+		}
+		m.auditView.snmpWalking = false
+		m.auditView.snmpHost = msg.host
+		m.auditView.snmpVars = msg.vars
+		m.auditView.snmpErr = msg.err
+		if msg.err != nil {
+			m.auditView.statusMessage = fmt.Sprintf("SNMP walk of %s failed: %v", msg.host, msg.err)
+			logging.Warnf(m.auditView.statusMessage)
+		} else {
+			m.auditView.statusMessage = fmt.Sprintf("SNMP walk of %s complete. %d variables.", msg.host, len(msg.vars))
+			logging.Infof("SNMP walk of %s returned %d variables", msg.host, len(msg.vars))
+		}
+		return m, nil
+
 	case diagnoseResultMsg:
 		if m.diagnoseView == nil {
 			m.diagnoseView = &DiagnoseView{}
@@ -288,6 +800,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.diagnoseView.running = false
 		m.diagnoseView.lastRun = time.Now()
 		m.diagnoseView.result = msg.res
+		m.diagnoseView.history = msg.history
 		m.diagnoseView.err = msg.err
 		if msg.err != nil {
 			m.diagnoseView.statusMessage = fmt.Sprintf("Diagnostics failed: %v", msg.err)
@@ -308,6 +821,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			} else {
 				m.details.Speed = msg.speed
 				m.details.Type = msg.ifaceType
+				m.details.Wifi = msg.wifi
 			}
 			m.detailsView.lastUpdate = time.Now()
 		}
@@ -331,6 +845,97 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case startARPMonitorMsg:
+		if m.arpMonView == nil {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.arpMonView.running = false
+			m.arpMonView.statusMessage = fmt.Sprintf("ARP monitor failed: %v", msg.err)
+			m.statusMsg = m.arpMonView.statusMessage
+			logging.Warnf("ARP monitor failed to start: %v", msg.err)
+			return m, nil
+		}
+		m.arpMonView.running = true
+		m.arpMonView.events = msg.events
+		m.arpMonView.statusMessage = fmt.Sprintf("Monitoring ARP (alert above %d req/s)", m.arpMonView.threshold)
+		logging.Infof("ARP monitor started")
+		return m, waitForARPEvent(msg.events)
+
+	case startDNSLogMsg:
+		if m.dnsLogView == nil {
+			if msg.logger != nil {
+				msg.logger.Stop()
+			}
+			return m, nil
+		}
+		if msg.err != nil {
+			m.dnsLogView.statusMessage = fmt.Sprintf("DNS logger failed: %v", msg.err)
+			m.statusMsg = m.dnsLogView.statusMessage
+			logging.Warnf("DNS logger failed to start: %v", msg.err)
+			return m, nil
+		}
+		m.dnsLogView.logger = msg.logger
+		m.dnsLogView.statusMessage = fmt.Sprintf("Logging DNS queries on %s", msg.logger.Interface)
+		logging.Infof("DNS logger started on %s", msg.logger.Interface)
+		return m, nil
+
+	case startRogueDHCPMsg:
+		if m.captureView == nil {
+			if msg.detector != nil {
+				msg.detector.Stop()
+			}
+			return m, nil
+		}
+		if msg.err != nil {
+			m.captureView.statusMessage = fmt.Sprintf("Rogue DHCP detection failed: %v", msg.err)
+			m.statusMsg = m.captureView.statusMessage
+			logging.Warnf("rogue DHCP detector failed to start: %v", msg.err)
+			return m, nil
+		}
+		m.captureView.dhcpDetector = msg.detector
+		m.captureView.rogueDHCP = nil
+		m.captureView.statusMessage = fmt.Sprintf("Watching for DHCP offers not from %s", msg.detector.LegitimateServer)
+		logging.Infof("rogue DHCP detector started on %s, legitimate server %s", msg.detector.Interface, msg.detector.LegitimateServer)
+		return m, waitForRogueDHCP(msg.detector.Events())
+
+	case rogueDHCPMsg:
+		if m.captureView == nil {
+			return m, nil
+		}
+		if msg.closed {
+			m.captureView.statusMessage = "Rogue DHCP detection stopped"
+			logging.Infof("rogue DHCP detector stopped")
+			return m, nil
+		}
+		m.captureView.rogueDHCP = append(m.captureView.rogueDHCP, msg.event)
+		if len(m.captureView.rogueDHCP) > maxRogueDHCPAlerts {
+			m.captureView.rogueDHCP = m.captureView.rogueDHCP[len(m.captureView.rogueDHCP)-maxRogueDHCPAlerts:]
+		}
+		m.statusMsg = fmt.Sprintf("Rogue DHCP server %s offered %s", msg.event.ServerIP, msg.event.OfferedIP)
+		if m.captureView.dhcpDetector == nil {
+			return m, nil
+		}
+		return m, waitForRogueDHCP(m.captureView.dhcpDetector.Events())
+
+	case arpEventMsg:
+		if m.arpMonView == nil {
+			return m, nil
+		}
+		if msg.closed {
+			m.arpMonView.running = false
+			m.arpMonView.events = nil
+			m.arpMonView.statusMessage = "ARP monitor stopped"
+			logging.Infof("ARP monitor stopped")
+			return m, nil
+		}
+		m.arpMonView.alerts = append(m.arpMonView.alerts, msg.event)
+		if len(m.arpMonView.alerts) > maxARPAlerts {
+			m.arpMonView.alerts = m.arpMonView.alerts[len(m.arpMonView.alerts)-maxARPAlerts:]
+		}
+		logging.Warnf("ARP storm: %s (%s) at %.0f req/s", msg.event.SenderIP, msg.event.SenderMAC, msg.event.Rate)
+		return m, waitForARPEvent(m.arpMonView.events)
+
 	case stopCaptureMsg:
 		if m.captureView != nil {
 			m.captureView.running = false
@@ -369,6 +974,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.speedtestView.lastRun = time.Now()
 		m.speedtestView.result = msg.res
 		m.speedtestView.err = msg.err
+		if msg.history != nil {
+			m.speedtestView.history = msg.history
+		}
 		if msg.err != nil {
 			m.speedtestView.statusMessage = fmt.Sprintf("Speedtest failed: %v", msg.err)
 			logging.Warnf(m.speedtestView.statusMessage)
@@ -395,18 +1003,37 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.details != nil && m.details.Name == details.Name {
 					details.Speed = m.details.Speed
 					details.Type = m.details.Type
+					details.Wifi = m.details.Wifi
 				}
 
 				m.details = details
 				if m.detailsView != nil {
 					m.detailsView.details = details
 					m.detailsView.lastUpdate = time.Now()
+					if !m.detailsView.readRateMonitor() {
+						m.detailsView.updateRate(details, m.detailsView.lastUpdate)
+					}
 					logging.Debugf("auto-refreshed details for %s", m.selectedIface)
 				}
 			} else {
 				logging.Warnf("failed to refresh interface details: %v", err)
 			}
 		}
+		// Auto-refresh ARP table if active
+		if m.mode == ViewARP && m.layer == LayerView {
+			m.refreshARP()
+		}
+		// Refresh ARP monitor counters
+		if m.arpMonView != nil && m.arpMonView.running {
+			if mon := capture.GetARPMonitor(); mon != nil {
+				m.arpMonView.senders = mon.Senders()
+			}
+		}
+		// Refresh DNS query log
+		if m.dnsLogView != nil && m.dnsLogView.logger != nil {
+			m.dnsLogView.events = m.dnsLogView.logger.GetEvents()
+			m.dnsLogView.summary = m.dnsLogView.logger.Summary()
+		}
 		// Sync capture state
 		if m.captureView != nil && m.captureView.running {
 			sess := capture.GetCurrentSession()
@@ -418,6 +1045,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, tick()
 
+	case ifaceUpdateMsg:
+		if msg.closed {
+			logging.Infof("interface watcher stopped")
+			return m, nil
+		}
+		m.applyInterfaceUpdate(msg.ifaces)
+		return m, waitForIfaceUpdate(m.ifaceUpdates)
+
 	case consolePortsMsg:
 		if m.consoleView != nil {
 			if msg.err != nil {
@@ -443,7 +1078,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.consoleView.statusMessage = fmt.Sprintf("Connection failed: %v", msg.err)
 			} else {
 				m.consoleView.session = msg.session
+				m.consoleView.buffers[msg.session.ID()] = make([]string, 0)
 				m.consoleView.statusMessage = fmt.Sprintf("Connected to %s", msg.session.ID())
+				if sess, ok := msg.session.(*console.Session); ok && m.config != nil {
+					addConsoleAlerts(sess, m.config.Console.Alerts, m.consoleView.alerts)
+				}
 				// Start reading data
 				return m, readConsoleDataCmd(msg.session)
 			}
@@ -451,30 +1090,94 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case consoleDataMsg:
-		if m.consoleView != nil && m.consoleView.session != nil {
-			// Append valid UTF-8 string to buffer
-			text := string(msg.data) // Simplified; real impl should sanitise
-			lines := strings.Split(text, "\n")
-			for _, line := range lines {
-				if line != "" {
-					m.consoleView.buffer = append(m.consoleView.buffer, line)
-				}
-			}
-			// Keep buffer size reasonable
-			if len(m.consoleView.buffer) > 1000 {
-				m.consoleView.buffer = m.consoleView.buffer[len(m.consoleView.buffer)-1000:]
+		if m.consoleView == nil {
+			return m, nil
+		}
+		// Stop polling once the session has been closed
+		sess, ok := m.consoleView.manager.Get(msg.id)
+		buffer, open := m.consoleView.buffers[msg.id]
+		if !ok || !open {
+			return m, nil
+		}
+		// Append valid UTF-8 string to buffer
+		text := string(msg.data) // Simplified; real impl should sanitise
+		lines := strings.Split(text, "\n")
+		for _, line := range lines {
+			if line != "" {
+				buffer = append(buffer, line)
 			}
-			// Continue reading
-			return m, readConsoleDataCmd(m.consoleView.session.(*console.Session))
 		}
-		return m, nil
+		// Keep buffer size reasonable
+		if len(buffer) > 1000 {
+			buffer = buffer[len(buffer)-1000:]
+		}
+		m.consoleView.buffers[msg.id] = buffer
+		// Continue reading
+		return m, readConsoleDataCmd(sess)
 
-	case consoleProbeMsg:
-		if m.consoleView != nil {
-			m.consoleView.probeStatus = "Done"
+	case consoleBaselineMsg:
+		if m.consoleView == nil {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.consoleView.statusMessage = fmt.Sprintf("Baseline failed: %v", msg.err)
+			return m, nil
+		}
+		m.consoleView.baseline = &msg
+		m.consoleView.statusMessage = fmt.Sprintf("Baseline %s captured", msg.key)
+		return m, nil
+
+	case consoleReconnectMsg:
+		if m.consoleView == nil {
+			return m, nil
+		}
+		m.consoleView.statusMessage = formatReconnectStatus(msg.id, msg.event)
+		sess, ok := m.consoleView.manager.Get(msg.id)
+		if !ok || msg.event.GaveUp {
+			return m, nil
+		}
+		return m, readConsoleDataCmd(sess)
+
+	case consoleAlertMsg:
+		if m.consoleView == nil {
+			return m, nil
+		}
+		if buffer, open := m.consoleView.buffers[msg.id]; open {
+			m.consoleView.buffers[msg.id] = append(buffer, formatConsoleAlert(msg.at, msg.name, msg.match))
+		}
+		m.consoleView.statusMessage = fmt.Sprintf("Alert %s on %s", msg.name, msg.id)
+		logging.Warnf("console alert %s on %s: %s", msg.name, msg.id, msg.match)
+		return m, waitForConsoleAlert(m.consoleView.alerts)
+
+	case consoleTransferMsg:
+		if m.consoleView != nil {
+			m.consoleView.transfer = nil
+			if msg.err != nil {
+				m.consoleView.statusMessage = fmt.Sprintf("Transfer of %s failed: %v", filepath.Base(msg.path), msg.err)
+			} else {
+				m.consoleView.statusMessage = fmt.Sprintf("Sent %s", filepath.Base(msg.path))
+			}
+		}
+		return m, nil
+
+	case consoleMacroMsg:
+		if m.consoleView != nil {
+			m.consoleView.playingMacro = ""
+			if msg.err != nil {
+				m.consoleView.statusMessage = fmt.Sprintf("Macro failed: %v", msg.err)
+			} else {
+				m.consoleView.statusMessage = fmt.Sprintf("Macro %s finished", msg.name)
+			}
+		}
+		return m, nil
+
+	case consoleProbeMsg:
+		if m.consoleView != nil {
+			m.consoleView.probeStatus = "Done"
 			if msg.result.Success {
 				fp := msg.result.Fingerprint
 				m.consoleView.fingerprint = &fp
+				m.consoleView.fingerprints[msg.port] = &fp
 				m.consoleView.statusMessage = fmt.Sprintf("Probe success: %s", fp.Vendor)
 			} else {
 				m.consoleView.statusMessage = fmt.Sprintf("Probe failed: %v", msg.result.Error)
@@ -498,6 +1201,93 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case wolResultMsg:
+		if m.wolView == nil {
+			m.wolView = &WoLView{}
+		}
+		m.wolView.sending = false
+		m.wolView.err = msg.err
+		if msg.err != nil {
+			m.wolView.statusMessage = fmt.Sprintf("Wake-on-LAN failed: %v", msg.err)
+			logging.Warnf(m.wolView.statusMessage)
+		} else {
+			broadcast := msg.broadcast
+			if broadcast == "" {
+				broadcast = netpkg.DefaultWoLBroadcast
+			}
+			m.wolView.lastSent = time.Now()
+			m.wolView.statusMessage = fmt.Sprintf("Magic packet for %s sent to %s", msg.mac, broadcast)
+			logging.Infof("Wake-on-LAN packet for %s sent to %s", msg.mac, broadcast)
+		}
+		m.statusMsg = m.wolView.statusMessage
+		return m, nil
+
+	case stpResultMsg:
+		if m.stpView == nil {
+			m.stpView = &STPView{}
+		}
+		m.stpView.running = false
+		m.stpView.err = msg.err
+		if msg.err != nil {
+			m.stpView.statusMessage = fmt.Sprintf("STP listen failed: %v", msg.err)
+			logging.Warnf(m.stpView.statusMessage)
+		} else {
+			m.stpView.frames = msg.frames
+			m.stpView.statusMessage = fmt.Sprintf("Listen complete. Heard %d bridge ports.", len(msg.frames))
+			logging.Infof("STP listen complete, heard %d bridge ports", len(msg.frames))
+		}
+		return m, nil
+
+	case mdnsResultMsg:
+		if m.mdnsView == nil {
+			m.mdnsView = &MDNSView{}
+		}
+		m.mdnsView.running = false
+		m.mdnsView.err = msg.err
+		if msg.err != nil {
+			m.mdnsView.statusMessage = fmt.Sprintf("mDNS discovery failed: %v", msg.err)
+			logging.Warnf(m.mdnsView.statusMessage)
+		} else {
+			m.mdnsView.entries = msg.entries
+			m.mdnsView.statusMessage = fmt.Sprintf("Discovery complete. Found %d services.", len(msg.entries))
+			logging.Infof("mDNS discovery complete, found %d services", len(msg.entries))
+		}
+		return m, nil
+
+	case cdpResultMsg:
+		if m.cdpView == nil {
+			m.cdpView = &CDPView{}
+		}
+		m.cdpView.running = false
+		m.cdpView.err = msg.err
+		if msg.err != nil {
+			m.cdpView.statusMessage = fmt.Sprintf("CDP discovery failed: %v", msg.err)
+			logging.Warnf(m.cdpView.statusMessage)
+		} else {
+			m.cdpView.neighbors = msg.neighbors
+			m.cdpView.statusMessage = fmt.Sprintf("Discovery complete. Found %d neighbors.", len(msg.neighbors))
+			logging.Infof("CDP discovery complete, found %d neighbors", len(msg.neighbors))
+		}
+		return m, nil
+
+	case arpScanResultMsg:
+		if m.arpScanView == nil {
+			m.arpScanView = &ARPScanView{}
+		}
+		m.arpScanView.running = false
+		m.arpScanView.err = msg.err
+		m.arpScanView.lastRun = time.Now()
+		if msg.err != nil {
+			m.arpScanView.statusMessage = fmt.Sprintf("ARP scan failed: %v", msg.err)
+			logging.Warnf(m.arpScanView.statusMessage)
+		} else {
+			m.arpScanView.hosts = msg.hosts
+			m.arpScanView.statusMessage = fmt.Sprintf("Scan of %s complete. %d hosts replied.", msg.cidr, len(msg.hosts))
+			logging.Infof("ARP scan of %s complete, %d hosts replied", msg.cidr, len(msg.hosts))
+		}
+		m.statusMsg = "ARP Scan"
+		return m, nil
+
 	case error:
 		logging.Errorf("tui received error: %v", msg)
 		m.err = msg
@@ -514,13 +1304,19 @@ func (m Model) handleKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		switch msg.Type {
 		case tea.KeyEnter:
 			m.inputActive = false
+			value := m.inputValue
+			if m.inputMasked {
+				m.inputValue = ""
+				m.inputMasked = false
+			}
 			if m.inputSubmit != nil {
-				return m, m.inputSubmit(&m, m.inputValue)
+				return m, m.inputSubmit(&m, value)
 			}
 			return m, nil
 		case tea.KeyEsc:
 			m.inputActive = false
 			m.inputValue = ""
+			m.inputMasked = false
 			m.inputPrompt = ""
 			m.statusMsg = "Input cancelled"
 			return m, nil
@@ -536,16 +1332,43 @@ func (m Model) handleKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	if m.helpActive && msg.String() != "ctrl+c" {
+		return m.handleHelpKeys(msg)
+	}
+
+	if m.mode == ViewConsole && m.layer == LayerView && m.consoleView != nil && m.consoleView.showMacros && msg.String() != "ctrl+c" {
+		return m.handleMacroKeys(msg)
+	}
+
+	if m.layer == LayerInterface {
+		if updated, ok := m.handlePickerSearch(msg); ok {
+			return updated, nil
+		}
+	}
+
 	switch msg.String() {
 	case "ctrl+c":
 		logging.Infof("key ctrl+c -> quit")
 		return m, tea.Quit
 
+	case "?", "f1":
+		// A focused console session gets '?' for the device's own help
+		if msg.String() == "?" && m.consoleSessionFocused() {
+			sess := m.consoleView.session.(console.Terminal)
+			return m, sendConsoleDataCmd(sess, []byte("?"))
+		}
+		m.helpActive = true
+		m.helpScroll = 0
+		return m, nil
+
 	case "esc", "q":
 		// Step back a layer; quit if at top
 		logging.Infof("key %q -> back navigation (layer=%d)", msg.String(), m.layer)
 		switch m.layer {
 		case LayerView:
+			if m.mode == ViewDetails {
+				m.detailsView.stopRateMonitor()
+			}
 			m.layer = LayerMode
 			m.statusMsg = "Select a mode"
 			logging.Debugf("switched to mode selection layer")
@@ -561,6 +1384,14 @@ func (m Model) handleKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 
 	case "d":
+		if m.mode == ViewVLAN && m.layer == LayerView && m.vlanView != nil {
+			if m.vlanView.running || m.vlanView.discovering {
+				break
+			}
+			m.vlanView.discovering = true
+			m.vlanView.statusMessage = "Listening for 802.1Q tagged frames..."
+			return m, runVLANDiscoverCmd(m.selectedIface, 15*time.Second)
+		}
 		if m.layer == LayerView {
 			break
 		}
@@ -594,6 +1425,14 @@ func (m Model) handleKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 
 	case "n":
+		if m.mode == ViewAudit && m.layer == LayerView {
+			if m.auditView == nil {
+				m.auditView = &AuditView{}
+			}
+			m.auditView.trySNMP = !m.auditView.trySNMP
+			m.statusMsg = fmt.Sprintf("SNMP probe: %v", m.auditView.trySNMP)
+			return m, nil
+		}
 		if m.layer == LayerView {
 			break
 		}
@@ -604,13 +1443,43 @@ func (m Model) handleKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			logging.Infof("key 'n' -> ViewSnap (%s)", m.selectedIface)
 		}
 
+	case "h":
+		if m.mode == ViewCapture && m.layer == LayerView && m.captureView != nil {
+			m.captureView.showHTTP = !m.captureView.showHTTP
+			if m.captureView.showHTTP {
+				m.statusMsg = "Showing HTTP conversations"
+			} else {
+				m.statusMsg = "Showing packets"
+			}
+			return m, nil
+		}
+
 	case "r":
+		if m.mode == ViewCapture && m.layer == LayerView && m.captureView != nil {
+			if m.captureView.running {
+				m.statusMsg = "Stop the capture before changing ring mode"
+				return m, nil
+			}
+			next := captureRingCapacities[0]
+			for i, c := range captureRingCapacities {
+				if c == m.captureView.ringCapacity {
+					next = captureRingCapacities[(i+1)%len(captureRingCapacities)]
+					break
+				}
+			}
+			m.captureView.ringCapacity = next
+			if next == 0 {
+				m.statusMsg = "Ring buffer: off"
+			} else {
+				m.statusMsg = fmt.Sprintf("Ring buffer: %d packets", next)
+			}
+			return m, nil
+		}
+
 		if m.mode == ViewSettings && m.layer == LayerView && m.config != nil {
 			m.config.Redact = !m.config.Redact
 			m.statusMsg = fmt.Sprintf("Redact mode: %v", m.config.Redact)
-			if err := store.SaveConfig(m.config); err != nil {
-				logging.Errorf("failed to save config: %v", err)
-			}
+			m.saveConfig()
 			return m, nil
 		}
 
@@ -633,33 +1502,178 @@ func (m Model) handleKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.diagnoseView.statusMessage = "Running diagnostics..."
 			m.statusMsg = m.diagnoseView.statusMessage
 			logging.Infof("starting diagnostics for %s", m.selectedIface)
-			var timeout time.Duration
-			if m.config != nil && m.config.DiagnosticsTimeout > 0 {
-				timeout = time.Duration(m.config.DiagnosticsTimeout) * time.Millisecond
+			return m, runDiagnosticsCmd(m.selectedIface, m.config.DiagnosticsTimeoutDuration(), m.config)
+		}
+
+	case "m":
+		if m.mode == ViewSettings && m.layer == LayerView && m.config != nil {
+			m.config.ProbeMTU = !m.config.ProbeMTU
+			m.statusMsg = fmt.Sprintf("Path MTU probe: %v", m.config.ProbeMTU)
+			m.saveConfig()
+			return m, nil
+		}
+		if m.mode == ViewConsole && m.layer == LayerView && m.consoleView != nil && m.consoleView.session != nil {
+			if m.consoleView.recorder != nil {
+				m.consoleView.statusMessage = m.consoleView.stopRecording()
+				return m, nil
+			}
+			if _, ok := m.consoleView.session.(*console.Session); !ok {
+				m.consoleView.statusMessage = "Macros need a serial session"
+				return m, nil
+			}
+			names, err := console.ListMacros()
+			if err != nil {
+				m.consoleView.statusMessage = fmt.Sprintf("Failed to list macros: %v", err)
+				return m, nil
 			}
-			return m, runDiagnosticsCmd(m.selectedIface, timeout, m.config)
+			m.consoleView.macros = names
+			m.consoleView.selectedMacro = 0
+			m.consoleView.showMacros = true
+			return m, nil
+		}
+		if m.layer == LayerView {
+			break
+		}
+		m = m.activateMode(ViewMDNS)
+		m.layer = LayerView
+		logging.Infof("key 'm' -> ViewMDNS")
+
+	case "e":
+		if m.mode == ViewCapture && m.layer == LayerView && m.captureView != nil {
+			return m.exportCapture("csv")
+		}
+		if m.mode == ViewSettings && m.layer == LayerView && m.config != nil {
+			m.config.Traceroute = !m.config.Traceroute
+			m.statusMsg = fmt.Sprintf("Traceroute: %v", m.config.Traceroute)
+			m.saveConfig()
+			return m, nil
+		}
+
+	case "y":
+		if m.mode == ViewSettings && m.layer == LayerView && m.config != nil {
+			m.config.CheckNTP = !m.config.CheckNTP
+			m.statusMsg = fmt.Sprintf("NTP check: %v", m.config.CheckNTP)
+			m.saveConfig()
+			return m, nil
 		}
 
 	case "t":
 		if m.mode == ViewSettings && m.layer == LayerView && m.config != nil {
-			timeouts := []int{1000, 2000, 5000, 10000}
-			current := m.config.DiagnosticsTimeout
-			next := timeouts[0]
-			for i, t := range timeouts {
-				if current == t && i < len(timeouts)-1 {
-					next = timeouts[i+1]
-					break
+			m.inputActive = true
+			m.inputPrompt = fmt.Sprintf("Diagnostics timeout (%s to %s, e.g. 1500ms or 10s): ", store.MinDiagnosticsTimeout, store.MaxDiagnosticsTimeout)
+			m.inputValue = m.config.DiagnosticsTimeoutDuration().String()
+			m.inputSubmit = func(m *Model, val string) tea.Cmd {
+				timeout, err := parseTimeout(val)
+				if err != nil {
+					m.statusMsg = err.Error()
+					return nil
 				}
-			}
-			m.config.DiagnosticsTimeout = next
-			m.statusMsg = fmt.Sprintf("Diagnostics timeout set to %dms", next)
-			if err := store.SaveConfig(m.config); err != nil {
-				logging.Errorf("failed to save config: %v", err)
+				m.config.DiagnosticsTimeout = int(timeout.Milliseconds())
+				// A value set here replaces the --timeout flag for the session
+				store.SetTimeoutOverride(0)
+				m.statusMsg = fmt.Sprintf("Diagnostics timeout set to %s", timeout)
+				m.saveConfig()
+				return nil
 			}
 			return m, nil
 		}
 
 	case "s":
+		if m.mode == ViewWoL && m.layer == LayerView && m.wolView != nil {
+			return m.promptWoL(), nil
+		}
+		if m.mode == ViewARPScan && m.layer == LayerView && m.arpScanView != nil {
+			if m.arpScanView.running {
+				break
+			}
+			m.inputActive = true
+			m.inputPrompt = fmt.Sprintf("Range to scan (IPv4 CIDR, at most %d hosts): ", arpscan.MaxHosts)
+			m.inputValue = m.arpScanView.cidr
+			m.inputSubmit = func(m *Model, val string) tea.Cmd {
+				val = strings.TrimSpace(val)
+				m.arpScanView.cidr = val
+				m.arpScanView.running = true
+				m.arpScanView.statusMessage = fmt.Sprintf("Scanning %s...", val)
+				m.statusMsg = "Running ARP scan..."
+				logging.Infof("ARP scan of %s on %s started", val, m.selectedIface)
+				return runARPScanCmd(m.selectedIface, val, arpscan.DefaultTimeout)
+			}
+			return m, nil
+		}
+		if m.mode == ViewSnap && m.layer == LayerView && m.snapView != nil {
+			if m.snapView.running {
+				break
+			}
+			if m.config != nil && m.config.EncryptSnapshots && m.config.EncryptPassphrase == "" {
+				m.inputActive = true
+				m.inputMasked = true
+				m.inputPrompt = "Passphrase to encrypt the snapshot: "
+				m.inputValue = ""
+				m.inputSubmit = func(m *Model, passphrase string) tea.Cmd {
+					if passphrase == "" {
+						m.snapView.statusMessage = "A passphrase is required while encrypt_snapshots is on."
+						return nil
+					}
+					m.snapView.running = true
+					m.snapView.statusMessage = "Saving encrypted snapshot..."
+					return runSnapshotCmd(m.selectedIface, passphrase)
+				}
+				return m, nil
+			}
+			m.snapView.running = true
+			m.snapView.statusMessage = "Saving snapshot..."
+			return m, runSnapshotCmd(m.selectedIface, "")
+		}
+		if m.mode == ViewVLAN && m.layer == LayerView && m.vlanView != nil {
+			if m.vlanView.running || m.vlanView.discovering {
+				break
+			}
+			m.inputActive = true
+			m.inputPrompt = fmt.Sprintf("VLANs to test (e.g. 10,20,100-110, at most %d): ", vlan.MaxVLANRange)
+			m.inputValue = m.vlanView.rangeStr
+			m.inputSubmit = func(m *Model, val string) tea.Cmd {
+				vlans, err := vlan.ParseVLANRange(val)
+				if err != nil {
+					m.vlanView.statusMessage = err.Error()
+					return nil
+				}
+				m.vlanView.vlans = vlans
+				m.vlanView.rangeStr = val
+
+				// Creating interfaces needs explicit consent
+				m.inputActive = true
+				m.inputPrompt = fmt.Sprintf("Type %s to create %d VLAN interfaces on %s: ", vlan.ConsentToken, len(vlans), m.selectedIface)
+				m.inputValue = ""
+				m.inputSubmit = func(m *Model, token string) tea.Cmd {
+					m.vlanView.consentToken = token
+					m.vlanView.running = true
+					m.vlanView.results = nil
+					m.vlanView.statusMessage = fmt.Sprintf("Testing %d VLANs...", len(m.vlanView.vlans))
+					logging.Infof("starting VLAN test on %s: %s", m.selectedIface, m.vlanView.rangeStr)
+					return runVLANCmd(m.selectedIface, m.vlanView.rangeStr, m.vlanView.keep, token)
+				}
+				return nil
+			}
+			return m, nil
+		}
+		if m.mode == ViewDNSLog && m.layer == LayerView && m.dnsLogView != nil {
+			if m.dnsLogView.logger != nil && m.dnsLogView.logger.IsRunning() {
+				break
+			}
+			m.dnsLogView.statusMessage = "Starting DNS logger..."
+			m.statusMsg = m.dnsLogView.statusMessage
+			logging.Infof("starting DNS logger on %s", m.selectedIface)
+			return m, startDNSLogCmd(m.selectedIface)
+		}
+		if m.mode == ViewARPMonitor && m.layer == LayerView && m.arpMonView != nil {
+			if m.arpMonView.running {
+				break
+			}
+			m.arpMonView.statusMessage = "Starting ARP monitor..."
+			m.statusMsg = m.arpMonView.statusMessage
+			logging.Infof("starting ARP monitor on %s", m.selectedIface)
+			return m, startARPMonitorCmd(m.selectedIface, m.arpMonView.threshold)
+		}
 		if m.mode == ViewCapture && m.layer == LayerView {
 			if m.captureView == nil {
 				m.captureView = &CaptureView{}
@@ -684,7 +1698,7 @@ func (m Model) handleKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.captureView.statusMessage = "Starting capture..."
 			m.statusMsg = m.captureView.statusMessage
 			logging.Infof("starting capture on %s", m.selectedIface)
-			return m, startCaptureCmd(m.selectedIface, m.captureView.filter)
+			return m, startCaptureCmd(m.selectedIface, m.captureView.filter, m.captureView.ringCapacity)
 		}
 		if m.mode == ViewSpeedtest && m.layer == LayerView {
 			if m.speedtestView == nil {
@@ -699,8 +1713,8 @@ func (m Model) handleKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.speedtestView.err = nil
 			m.speedtestView.statusMessage = "Starting speedtest..."
 			m.statusMsg = m.speedtestView.statusMessage
-			logging.Infof("starting speedtest")
-			return m, runSpeedtestCmd()
+			logging.Infof("starting speedtest (%s)", speedtestBackend(m.selectedIface, m.config))
+			return m, runSpeedtestCmd(m.selectedIface, m.config)
 		}
 		if m.mode == ViewAudit && m.layer == LayerView {
 			if m.auditView == nil {
@@ -714,9 +1728,17 @@ func (m Model) handleKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.statusMsg = "Running Audit..."
 			gateway := ""
 			if m.details != nil {
-				gateway = m.details.DefaultGateway
+				gateway = m.details.IPv4Gateway()
+			}
+			config := scan.ScanConfig{
+				Timeout: 500 * time.Millisecond, // fast timeout per host
+				ScanUDP: m.auditView.scanUDP,
+				TrySNMP: m.auditView.trySNMP,
+			}
+			if m.config != nil {
+				config.Ports = m.config.ScanPorts
 			}
-			return m, runAuditCmd(gateway)
+			return m, runAuditCmd(gateway, config)
 		}
 		if m.mode == ViewLLDP && m.layer == LayerView {
 			if m.lldpView == nil {
@@ -730,6 +1752,33 @@ func (m Model) handleKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.statusMsg = "Running LLDP Discovery..."
 			return m, runLLDPCmd(m.selectedIface, 30*time.Second)
 		}
+		if m.mode == ViewCDP && m.layer == LayerView && m.cdpView != nil {
+			if m.cdpView.running {
+				break
+			}
+			m.cdpView.running = true
+			m.cdpView.statusMessage = "Listening for CDP packets..."
+			m.statusMsg = "Running CDP Discovery..."
+			return m, runCDPCmd(m.selectedIface, cdpListenDuration)
+		}
+		if m.mode == ViewSTP && m.layer == LayerView && m.stpView != nil {
+			if m.stpView.running {
+				break
+			}
+			m.stpView.running = true
+			m.stpView.statusMessage = "Listening for BPDUs..."
+			m.statusMsg = "Running STP Listener..."
+			return m, runSTPCmd(m.selectedIface, stpListenDuration)
+		}
+		if m.mode == ViewMDNS && m.layer == LayerView && m.mdnsView != nil {
+			if m.mdnsView.running {
+				break
+			}
+			m.mdnsView.running = true
+			m.mdnsView.statusMessage = "Browsing mDNS services..."
+			m.statusMsg = "Running mDNS Discovery..."
+			return m, runMDNSCmd(mdns.DefaultDuration)
+		}
 		if m.layer == LayerView {
 			break
 		}
@@ -739,12 +1788,33 @@ func (m Model) handleKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		logging.Infof("key 's' -> ViewSettings")
 
 	case "f":
+		if m.mode == ViewSettings && m.layer == LayerView && m.config != nil {
+			thresholds := []float64{0.55, 0.7, 0.8, 0.9}
+			current := m.config.Fingerprint.MinProbeConfidence
+			next := thresholds[0]
+			for i, t := range thresholds {
+				if current == t && i < len(thresholds)-1 {
+					next = thresholds[i+1]
+					break
+				}
+			}
+			m.config.Fingerprint.MinProbeConfidence = next
+			m.statusMsg = fmt.Sprintf("Probe confidence threshold set to %.2f", next)
+			m.saveConfig()
+			return m, nil
+		}
 		if m.mode == ViewCapture && m.layer == LayerView {
 			m.inputActive = true
 			m.inputPrompt = "BPF Filter (e.g. 'tcp port 80'): "
 			m.inputValue = m.captureView.filter
 			m.inputSubmit = func(m *Model, val string) tea.Cmd {
+				if err := capture.ValidateFilter(val); err != nil {
+					m.captureView.filterError = err.Error()
+					m.statusMsg = fmt.Sprintf("Filter not set: %v", err)
+					return nil
+				}
 				m.captureView.filter = val
+				m.captureView.filterError = ""
 				m.statusMsg = fmt.Sprintf("Filter set to: %s", val)
 				return nil
 			}
@@ -754,7 +1824,7 @@ func (m Model) handleKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if m.mode == ViewConsole && m.layer == LayerView {
 			if m.consoleView != nil {
 				m.consoleView.statusMessage = "Refreshing ports..."
-				return m, discoverPortsCmd()
+				return m, discoverPortsCmd(m.telnetTargets())
 			}
 		}
 
@@ -776,6 +1846,19 @@ func (m Model) handleKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 
 	case "x":
+		if m.mode == ViewDNSLog && m.layer == LayerView && m.dnsLogView != nil && m.dnsLogView.logger != nil {
+			m.dnsLogView.logger.Stop()
+			m.dnsLogView.statusMessage = "DNS logger stopped"
+			logging.Infof("DNS logger stopped")
+			return m, nil
+		}
+		if m.mode == ViewARPMonitor && m.layer == LayerView && m.arpMonView != nil && m.arpMonView.running {
+			if err := capture.StopARPMonitor(); err != nil {
+				logging.Warnf("failed to stop ARP monitor: %v", err)
+			}
+			m.arpMonView.statusMessage = "Stopping ARP monitor..."
+			return m, nil
+		}
 		if m.mode == ViewCapture && m.layer == LayerView {
 			// Stop capture
 			if m.captureView != nil && m.captureView.running {
@@ -795,11 +1878,15 @@ func (m Model) handleKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 		}
 		if m.mode == ViewConsole && m.layer == LayerView && m.consoleView != nil && m.consoleView.session != nil {
-			// Close console session
-			sess := m.consoleView.session.(*console.Session)
-			m.consoleView.session = nil
-			m.consoleView.statusMessage = "Session closed"
-			return m, closeConsoleSessionCmd(sess)
+			// Close the focused session and move on to the next one, if any
+			id := m.consoleView.session.(console.Terminal).ID()
+			m.consoleView.session = m.consoleView.nextSession()
+			delete(m.consoleView.buffers, id)
+			m.consoleView.statusMessage = fmt.Sprintf("Session %s closed", id)
+			if m.consoleView.recorder != nil && m.consoleView.recordingID == id {
+				m.consoleView.statusMessage += "; " + m.consoleView.stopRecording()
+			}
+			return m, closeConsoleSessionCmd(m.consoleView.manager, id)
 		}
 
 	case "w":
@@ -809,11 +1896,18 @@ func (m Model) handleKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 					m.captureView.statusMessage = "No packets to save"
 					break
 				}
-				filename := fmt.Sprintf("capture_%s.pcap", time.Now().Format("20060102_150405"))
+				filename := fmt.Sprintf("capture_%s.pcapng", time.Now().Format("20060102_150405"))
 				m.captureView.statusMessage = fmt.Sprintf("Saving to %s...", filename)
 				return m, saveCaptureCmd(filename)
 			}
 		}
+		if m.layer == LayerView {
+			break
+		}
+		m = m.activateMode(ViewWoL)
+		m.layer = LayerView
+		logging.Infof("key 'w' -> ViewWoL")
+		return m.promptWoL(), nil
 
 	case "a":
 		if m.layer == LayerView {
@@ -835,13 +1929,37 @@ func (m Model) handleKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "p":
 		if m.mode == ViewConsole && m.layer == LayerView {
 			if m.consoleView != nil && len(m.consoleView.ports) > 0 {
-				port := m.consoleView.ports[m.consoleView.selectedPort].(console.SerialPort).Path
+				selected := m.consoleView.ports[m.consoleView.selectedPort].(console.SerialPort)
+				if selected.Hints == console.TelnetHint {
+					m.consoleView.statusMessage = "Baud probing is not available for Telnet ports"
+					break
+				}
+				port := selected.Path
 				m.consoleView.statusMessage = fmt.Sprintf("Probing %s...", port)
 				m.consoleView.probeStatus = "Running..."
-				return m, probePortCmd(context.Background(), port)
+				return m, probePortCmd(context.Background(), port, m.fingerprintConfig())
 			}
 			break
 		}
+		if m.mode == ViewSettings && m.layer == LayerView && m.settingsView != nil {
+			prev := store.ActiveProfile()
+			next := nextProfile(prev, m.settingsView.profiles)
+			if err := store.SetActiveProfile(next); err != nil {
+				m.statusMsg = err.Error()
+				return m, nil
+			}
+			config, err := store.LoadConfig()
+			if err != nil {
+				store.SetActiveProfile(prev)
+				m.statusMsg = fmt.Sprintf("Failed to load profile: %v", err)
+				return m, nil
+			}
+			m.config = config
+			m.styles = themeStyles(config)
+			m.statusMsg = fmt.Sprintf("Profile: %s", profileLabel(next))
+			logging.Infof("switched to profile %s", profileLabel(next))
+			return m, nil
+		}
 
 		if m.layer == LayerView {
 			break
@@ -876,6 +1994,46 @@ func (m Model) handleKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			logging.Infof("key 'l' -> LLDP (%s)", m.selectedIface)
 		}
 
+	case "T":
+		if m.layer == LayerView {
+			break
+		}
+		m = m.activateMode(ViewSTP)
+		m.layer = LayerView
+		logging.Infof("key 'T' -> ViewSTP (%s)", m.selectedIface)
+
+	case "C":
+		if m.layer == LayerView {
+			break
+		}
+		m = m.activateMode(ViewCDP)
+		m.layer = LayerView
+		logging.Infof("key 'C' -> ViewCDP (%s)", m.selectedIface)
+
+	case "b":
+		if m.layer == LayerView {
+			break
+		}
+		m = m.activateMode(ViewARP)
+		m.layer = LayerView
+		logging.Infof("key 'b' -> ViewARP")
+
+	case "M":
+		if m.layer == LayerView {
+			break
+		}
+		m = m.activateMode(ViewARPMonitor)
+		m.layer = LayerView
+		logging.Infof("key 'M' -> ViewARPMonitor")
+
+	case "Q":
+		if m.layer == LayerView {
+			break
+		}
+		m = m.activateMode(ViewDNSLog)
+		m.layer = LayerView
+		logging.Infof("key 'Q' -> ViewDNSLog")
+
 	case "o":
 		if m.layer == LayerView && m.mode != ViewConsole {
 			break
@@ -887,52 +2045,229 @@ func (m Model) handleKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.consoleView = &ConsoleView{
 				ports:                  make([]interface{}, 0),
 				selectedPort:           0,
-				buffer:                 make([]string, 0),
 				statusMessage:          "Press 'f' to discover ports",
 				dtrState:               true,
 				rtsState:               true,
 				logging:                false,
 				allowProbeInConfigMode: m.config != nil && m.config.Console.AllowProbeInConfigMode,
+				manager:                console.NewSessionManager(),
+				buffers:                make(map[string][]string),
+				fingerprints:           make(map[string]*fingerprint.Result),
+				alerts:                 make(chan consoleAlertMsg, 64),
 			}
-			return m, discoverPortsCmd()
+			return m, tea.Batch(discoverPortsCmd(m.telnetTargets()), waitForConsoleAlert(m.consoleView.alerts))
 		}
 		m.statusMsg = "Serial Console"
 		logging.Infof("key 'o' -> ViewConsole")
 
-	case "P":
-		if m.mode == ViewConsole && m.consoleView != nil {
-			m.consoleView.probeStatus = "Safe probe requested"
-			m.statusMsg = "Safe probe requested"
-			logging.Infof("console safe probe requested")
+	case "u":
+		if m.mode == ViewAudit && m.layer == LayerView {
+			if m.auditView == nil {
+				m.auditView = &AuditView{}
+			}
+			m.auditView.scanUDP = !m.auditView.scanUDP
+			m.statusMsg = fmt.Sprintf("UDP scan: %v", m.auditView.scanUDP)
+			return m, nil
 		}
-
-	case "A":
-		if m.mode == ViewConsole && m.consoleView != nil {
-			m.consoleView.allowProbeInConfigMode = !m.consoleView.allowProbeInConfigMode
-			if m.config != nil {
-				m.config.Console.AllowProbeInConfigMode = m.consoleView.allowProbeInConfigMode
-				if err := store.SaveConfig(m.config); err != nil {
-					logging.Errorf("failed to save config: %v", err)
-				}
+		if m.mode == ViewConsole && m.layer == LayerView && m.consoleView != nil && m.consoleView.session != nil {
+			if m.consoleView.transfer != nil {
+				m.consoleView.statusMessage = "A transfer is already running"
+				break
 			}
-			if m.consoleView.allowProbeInConfigMode {
-				m.statusMsg = "Config-mode probes enabled"
-				logging.Warnf("config-mode probes enabled by user")
-			} else {
-				m.statusMsg = "Config-mode probes disabled"
-				logging.Infof("config-mode probes disabled by user")
+			sess, ok := m.consoleView.session.(*console.Session)
+			if !ok {
+				m.consoleView.statusMessage = "File transfer needs a serial session"
+				break
+			}
+			m.inputActive = true
+			m.inputPrompt = "File to send with YMODEM: "
+			m.inputValue = ""
+			m.inputSubmit = func(m *Model, val string) tea.Cmd {
+				path := strings.TrimSpace(val)
+				info, err := os.Stat(path)
+				if err != nil {
+					m.consoleView.statusMessage = fmt.Sprintf("Cannot send %s: %v", path, err)
+					return nil
+				}
+				m.consoleView.transfer = &consoleTransfer{path: path, total: info.Size()}
+				m.consoleView.statusMessage = fmt.Sprintf("Waiting for receiver to start YMODEM for %s...", filepath.Base(path))
+				return sendYMODEMCmd(sess, m.consoleView.transfer)
 			}
+			m.statusMsg = "Enter file path..."
+			return m, nil
 		}
 
-	default:
-		// Forward typing to console session if active
-		if m.mode == ViewConsole && m.consoleView != nil && m.consoleView.session != nil {
-			// Filter out navigation keys that shouldn't be forwarded if handled above
-			// But since we are directly in case default, these are keys NOT handled above.
+	case "+", "-":
+		if m.mode == ViewSettings && m.layer == LayerView && m.config != nil {
+			add := msg.String() == "+"
+			m.inputActive = true
+			m.inputValue = ""
+			if add {
+				m.inputPrompt = "Add audit ports (e.g. 8081,9100): "
+			} else {
+				m.inputPrompt = "Remove audit ports: "
+			}
+			m.inputSubmit = func(m *Model, val string) tea.Cmd {
+				ports, err := parsePortList(val)
+				if err != nil {
+					m.statusMsg = err.Error()
+					return nil
+				}
+				current := m.config.ScanPorts
+				if len(current) == 0 {
+					current = scan.CommonPorts
+				}
+				updated := editPortList(current, ports, add)
+				if len(updated) == 0 {
+					m.statusMsg = "At least one audit port is required"
+					return nil
+				}
+				m.config.ScanPorts = updated
+				m.statusMsg = fmt.Sprintf("Audit ports: %s", scan.FormatPorts(updated))
+				m.saveConfig()
+				return nil
+			}
+			return m, nil
+		}
+
+	case "D":
+		if m.mode == ViewAudit && m.layer == LayerView && m.auditView != nil && m.auditView.diff != nil {
+			m.auditView.showDiff = !m.auditView.showDiff
+			return m, nil
+		}
+		if m.layer == LayerView {
+			break
+		}
+		m = m.activateMode(ViewSnapDiff)
+		m.layer = LayerView
+		logging.Infof("key 'D' -> ViewSnapDiff")
+
+	case "S":
+		if m.mode == ViewAudit && m.layer == LayerView && m.auditView != nil {
+			if m.auditView.snmpWalking {
+				break
+			}
+			gateway := ""
+			if m.details != nil {
+				gateway = m.details.IPv4Gateway()
+			}
+			if gateway == "" {
+				m.auditView.statusMessage = "No gateway to query"
+				return m, nil
+			}
+			m.inputActive = true
+			m.inputPrompt = "SNMP community: "
+			m.inputValue = scan.DefaultSNMPCommunities[0]
+			m.inputSubmit = func(m *Model, val string) tea.Cmd {
+				m.auditView.snmpWalking = true
+				m.auditView.statusMessage = fmt.Sprintf("Walking the SNMP system group of %s...", gateway)
+				return runSNMPWalkCmd(gateway, strings.TrimSpace(val))
+			}
+			return m, nil
+		}
+
+	case "B":
+		if m.mode == ViewConsole && m.layer == LayerView && m.consoleView != nil && m.consoleView.session != nil {
+			sess, ok := m.consoleView.session.(*console.Session)
+			if !ok {
+				m.consoleView.statusMessage = "Baselines need a serial session"
+				break
+			}
+			if m.consoleView.transfer != nil || m.consoleView.playingMacro != "" {
+				m.consoleView.statusMessage = "Wait for the running macro or transfer to finish"
+				break
+			}
+			fp := m.consoleView.fingerprints[sess.PortPath()]
+			if fp == nil {
+				m.consoleView.statusMessage = "Probe the port first so the device can be identified"
+				break
+			}
+			probe := fingerprint.SafeProbeFor(fp.Vendor, fp.OS)
+			if probe == nil {
+				m.consoleView.statusMessage = fmt.Sprintf("No safe probe for %s / %s", fp.Vendor, fp.OS)
+				break
+			}
+			if strings.Contains(strings.ToLower(fp.Prompt), "(config") && !m.consoleView.allowProbeInConfigMode {
+				m.consoleView.statusMessage = "Prompt is in configuration mode; press 'A' to allow probes"
+				break
+			}
+			m.consoleView.statusMessage = fmt.Sprintf("Running %q for baseline...", probe.Command)
+			return m, captureBaselineCmd(sess, probe)
+		}
+		if m.layer == LayerView {
+			break
+		}
+		m = m.activateMode(ViewARPScan)
+		m.layer = LayerView
+		logging.Infof("key 'B' -> ViewARPScan")
+
+	case "R":
+		if m.mode == ViewCapture && m.layer == LayerView && m.captureView != nil {
+			if d := m.captureView.dhcpDetector; d != nil && d.IsRunning() {
+				d.Stop()
+				m.captureView.statusMessage = "Stopping rogue DHCP detection..."
+				return m, nil
+			}
+			// The gateway is usually also the DHCP server on small networks
+			server := m.captureView.dhcpServer
+			if server == "" && m.details != nil {
+				server = m.details.IPv4Gateway()
+			}
+			m.inputActive = true
+			m.inputPrompt = "Legitimate DHCP server IP: "
+			m.inputValue = server
+			m.inputSubmit = func(m *Model, val string) tea.Cmd {
+				val = strings.TrimSpace(val)
+				m.captureView.dhcpServer = val
+				m.captureView.statusMessage = "Starting rogue DHCP detection..."
+				return startRogueDHCPCmd(m.selectedIface, val)
+			}
+			return m, nil
+		}
+
+	case "P":
+		if m.mode == ViewConsole && m.consoleView != nil {
+			m.consoleView.probeStatus = "Safe probe requested"
+			m.statusMsg = "Safe probe requested"
+			logging.Infof("console safe probe requested")
+		}
+
+	case "A":
+		if m.mode == ViewConsole && m.consoleView != nil {
+			m.consoleView.allowProbeInConfigMode = !m.consoleView.allowProbeInConfigMode
+			if m.config != nil {
+				m.config.Console.AllowProbeInConfigMode = m.consoleView.allowProbeInConfigMode
+				m.saveConfig()
+			}
+			if m.consoleView.allowProbeInConfigMode {
+				m.statusMsg = "Config-mode probes enabled"
+				logging.Warnf("config-mode probes enabled by user")
+			} else {
+				m.statusMsg = "Config-mode probes disabled"
+				logging.Infof("config-mode probes disabled by user")
+			}
+		}
+
+	case "tab":
+		if m.mode == ViewConsole && m.layer == LayerView && m.consoleView != nil {
+			m.consoleView.session = m.consoleView.nextSession()
+			if sess, ok := m.consoleView.session.(console.Terminal); ok {
+				m.consoleView.statusMessage = fmt.Sprintf("Switched to %s", sess.ID())
+			} else {
+				m.consoleView.statusMessage = "Port list"
+			}
+		}
+
+	default:
+		// Forward typing to console session if active; a running transfer
+		// owns the line
+		if m.mode == ViewConsole && m.consoleView != nil && m.consoleView.session != nil && m.consoleView.transfer == nil {
+			// Filter out navigation keys that shouldn't be forwarded if handled above
+			// But since we are directly in case default, these are keys NOT handled above.
 			// However, bubbletea keys like "enter", "up", etc are separate from runes.
 			// We only want to forward runes or specific control keys.
 			if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
-				sess := m.consoleView.session.(*console.Session)
+				sess := m.consoleView.session.(console.Terminal)
 				return m, sendConsoleDataCmd(sess, []byte(msg.String()))
 			} else if msg.Type == tea.KeyEnter {
 				// Enter is handled in separate case "enter" below...
@@ -951,14 +2286,7 @@ func (m Model) handleKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		if m.layer == LayerInterface {
-			displayCount := len(m.interfaces)
-			if displayCount > 8 {
-				displayCount = 8
-			}
-			if displayCount > 0 {
-				m.selectedIndex = (m.selectedIndex - 1 + displayCount) % displayCount
-				logging.Debugf("interface cursor moved to index %d", m.selectedIndex)
-			}
+			m.moveInterfaceCursor(-1)
 		} else if m.layer == LayerMode {
 			// Move up in mode list
 			modes := m.availableModes()
@@ -969,6 +2297,9 @@ func (m Model) handleKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 
 	case "down", "j":
+		if msg.String() == "j" && m.mode == ViewCapture && m.layer == LayerView && m.captureView != nil {
+			return m.exportCapture("json")
+		}
 		if m.mode == ViewConsole && m.layer == LayerView {
 			if m.consoleView != nil && len(m.consoleView.ports) > 0 && m.consoleView.session == nil {
 				count := len(m.consoleView.ports)
@@ -977,14 +2308,7 @@ func (m Model) handleKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		if m.layer == LayerInterface {
-			displayCount := len(m.interfaces)
-			if displayCount > 8 {
-				displayCount = 8
-			}
-			if displayCount > 0 {
-				m.selectedIndex = (m.selectedIndex + 1) % displayCount
-				logging.Debugf("interface cursor moved to index %d", m.selectedIndex)
-			}
+			m.moveInterfaceCursor(1)
 		} else if m.layer == LayerMode {
 			modes := m.availableModes()
 			if len(modes) > 0 {
@@ -996,43 +2320,15 @@ func (m Model) handleKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "1", "2", "3", "4", "5", "6", "7", "8", "9":
 		if m.layer == LayerInterface {
 			idx := int(msg.Runes[0]-'0') - 1
-			displayCount := len(m.interfaces)
-			if displayCount > 8 {
-				displayCount = 8
-			}
-			if idx >= 0 && idx < displayCount {
-				iface := m.interfaces[idx]
-				m.selectedIface = iface.Name
-				logging.Infof("digit %s -> interface %s", msg.String(), iface.Name)
-				details, err := netpkg.GetInterfaceDetails(iface.Name)
-				if err == nil {
-					m.details = details
-					m.detailsView = &DetailsView{
-						details:     details,
-						lastUpdate:  time.Now(),
-						autoRefresh: true,
-					}
-					logging.Debugf("loaded details for %s", iface.Name)
-				} else {
-					logging.Warnf("failed to load details for %s: %v", iface.Name, err)
-				}
-				m.layer = LayerMode
-				m.modeIndex = 0
-				m.statusMsg = "Select a mode"
+			if visible := m.visibleInterfaces(); idx >= 0 && idx < len(visible) {
+				logging.Infof("digit %s -> interface %s", msg.String(), visible[idx].Name)
+				m = m.selectInterface(idx)
 			}
 		} else if m.layer == LayerMode {
 			idx := int(msg.Runes[0]-'0') - 1
-			modes := m.availableModes()
-			if idx >= 0 && idx < len(modes) {
-				sel := modes[idx]
-				m = m.activateMode(sel.mode)
-				m.layer = LayerView
-				logging.Infof("digit %s -> activate mode %v", msg.String(), sel.mode)
-
-				// Trigger extended details if entering Details view
-				if sel.mode == ViewDetails && m.selectedIface != "" {
-					return m, getExtendedDetailsCmd(m.selectedIface)
-				}
+			if idx >= 0 && idx < len(m.availableModes()) {
+				logging.Infof("digit %s -> mode %d", msg.String(), idx)
+				return m.selectMode(idx)
 			}
 		}
 
@@ -1040,50 +2336,44 @@ func (m Model) handleKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if m.mode == ViewConsole && m.layer == LayerView {
 			// If session is active, forward Enter
 			if m.consoleView != nil && m.consoleView.session != nil {
-				sess := m.consoleView.session.(*console.Session)
+				if m.consoleView.transfer != nil {
+					return m, nil
+				}
+				sess := m.consoleView.session.(console.Terminal)
 				// Send CR (or CRLF depending on config, but usually CR)
 				return m, sendConsoleDataCmd(sess, []byte("\r"))
 			}
 
-			// Connect to selected port
+			// Connect to selected port, or focus its session if one is already open
 			if m.consoleView != nil && len(m.consoleView.ports) > 0 && m.consoleView.session == nil {
 				port := m.consoleView.ports[m.consoleView.selectedPort].(console.SerialPort)
+				for _, sess := range m.consoleView.manager.List() {
+					if sess.PortPath() == port.Path {
+						m.consoleView.session = sess
+						m.consoleView.statusMessage = fmt.Sprintf("Switched to %s", sess.ID())
+						return m, nil
+					}
+				}
 				m.consoleView.statusMessage = fmt.Sprintf("Connecting to %s...", port.Path)
-				return m, openConsoleSessionCmd(context.Background(), port.Path, 115200) // Default baud
+				if port.Hints == console.TelnetHint {
+					return m, openTelnetSessionCmd(context.Background(), m.consoleView.manager, m.sessionConfig(port.Path, 115200))
+				}
+				return m, openConsoleSessionCmd(context.Background(), m.consoleView.manager, m.sessionConfig(port.Path, 115200)) // Default baud
 			}
 			return m, nil
 		}
 
 		if m.layer == LayerInterface {
 			// Select the currently highlighted interface
-			displayCount := len(m.interfaces)
-			if displayCount > 8 {
-				displayCount = 8
-			}
-			if displayCount == 0 {
+			visible := m.visibleInterfaces()
+			if len(visible) == 0 {
 				break
 			}
-			if m.selectedIndex < 0 || m.selectedIndex >= displayCount {
+			if m.selectedIndex < 0 || m.selectedIndex >= len(visible) {
 				m.selectedIndex = 0
 			}
-			iface := m.interfaces[m.selectedIndex]
-			m.selectedIface = iface.Name
-			logging.Infof("enter -> interface %s", iface.Name)
-			details, err := netpkg.GetInterfaceDetails(iface.Name)
-			if err == nil {
-				m.details = details
-				m.detailsView = &DetailsView{
-					details:     details,
-					lastUpdate:  time.Now(),
-					autoRefresh: true,
-				}
-				logging.Debugf("loaded details for %s", iface.Name)
-			} else {
-				logging.Warnf("failed to load details for %s: %v", iface.Name, err)
-			}
-			m.layer = LayerMode
-			m.modeIndex = 0
-			m.statusMsg = "Select a mode"
+			logging.Infof("enter -> interface %s", visible[m.selectedIndex].Name)
+			m = m.selectInterface(m.selectedIndex)
 		} else if m.layer == LayerMode {
 			modes := m.availableModes()
 			if len(modes) == 0 {
@@ -1092,15 +2382,8 @@ func (m Model) handleKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			if m.modeIndex < 0 || m.modeIndex >= len(modes) {
 				m.modeIndex = 0
 			}
-			sel := modes[m.modeIndex]
-			m = m.activateMode(sel.mode)
-			m.layer = LayerView
-			logging.Infof("enter -> activate mode %v", sel.mode)
-
-			// Trigger extended details if entering Details view
-			if sel.mode == ViewDetails && m.selectedIface != "" {
-				return m, getExtendedDetailsCmd(m.selectedIface)
-			}
+			logging.Infof("enter -> mode %d", m.modeIndex)
+			return m.selectMode(m.modeIndex)
 		}
 	}
 
@@ -1113,26 +2396,313 @@ func (m Model) handleKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// filterInterfaces returns the interfaces whose name or description
+// contains filter, ignoring case
+func filterInterfaces(ifaces []netpkg.Iface, filter string) []netpkg.Iface {
+	if filter == "" {
+		return ifaces
+	}
+	filter = strings.ToLower(filter)
+	var matched []netpkg.Iface
+	for _, iface := range ifaces {
+		if strings.Contains(strings.ToLower(iface.Name), filter) ||
+			strings.Contains(strings.ToLower(iface.Description), filter) {
+			matched = append(matched, iface)
+		}
+	}
+	return matched
+}
+
+// visibleInterfaces is the picker list after the search filter; the picker
+// cursor indexes into it
+func (m Model) visibleInterfaces() []netpkg.Iface {
+	return filterInterfaces(m.interfaces, m.pickerFilter)
+}
+
+// Picker rows above the first interface (title and search) and below the
+// last (key hints)
+const (
+	pickerHeaderRows = 4
+	pickerFooterRows = 4
+)
+
+// pickerPageSize is how many interfaces fit in the picker, two rows each
+func (m Model) pickerPageSize() int {
+	if m.height == 0 {
+		return 8 // size not known yet
+	}
+	if n := (m.height - pickerHeaderRows - pickerFooterRows) / 2; n > 1 {
+		return n
+	}
+	return 1
+}
+
+// moveInterfaceCursor moves the picker cursor by delta, wrapping around,
+// and scrolls to keep it on screen
+func (m *Model) moveInterfaceCursor(delta int) {
+	count := len(m.visibleInterfaces())
+	if count == 0 {
+		return
+	}
+	m.selectedIndex = ((m.selectedIndex+delta)%count + count) % count
+	m.scrollToCursor()
+	logging.Debugf("interface cursor moved to index %d", m.selectedIndex)
+}
+
+// scrollToCursor adjusts scrollOffset so the picker cursor is on screen
+func (m *Model) scrollToCursor() {
+	page := m.pickerPageSize()
+	if m.selectedIndex < m.scrollOffset {
+		m.scrollOffset = m.selectedIndex
+	} else if m.selectedIndex >= m.scrollOffset+page {
+		m.scrollOffset = m.selectedIndex - page + 1
+	}
+	if m.scrollOffset < 0 {
+		m.scrollOffset = 0
+	}
+}
+
+// setPickerFilter changes the picker search, moving the cursor to the
+// first match
+func (m *Model) setPickerFilter(filter string) {
+	m.pickerFilter = filter
+	m.selectedIndex = 0
+	m.scrollOffset = 0
+	m.hoverIndex = -1
+}
+
+// handlePickerSearch edits the picker search. A letter starts a search;
+// once one is under way digits and the punctuation used in interface names
+// extend it too. esc clears it before it quits.
+func (m Model) handlePickerSearch(msg tea.KeyMsg) (Model, bool) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		if m.pickerFilter == "" {
+			return m, false
+		}
+		m.setPickerFilter("")
+		return m, true
+	case tea.KeyBackspace:
+		if m.pickerFilter == "" {
+			return m, false
+		}
+		r := []rune(m.pickerFilter)
+		m.setPickerFilter(string(r[:len(r)-1]))
+		return m, true
+	case tea.KeyRunes:
+		if msg.Alt || len(msg.Runes) != 1 {
+			return m, false
+		}
+		r := msg.Runes[0]
+		if unicode.IsLetter(r) || (m.pickerFilter != "" && (unicode.IsDigit(r) || strings.ContainsRune(".-_:", r))) {
+			m.setPickerFilter(m.pickerFilter + string(r))
+			return m, true
+		}
+	}
+	return m, false
+}
+
+// selectInterface picks the interface at idx in the picker list and moves
+// on to the mode menu
+func (m Model) selectInterface(idx int) Model {
+	iface := m.visibleInterfaces()[idx]
+	m.selectedIndex = idx
+	m.selectedIface = iface.Name
+	if m.config != nil && m.config.RememberInterface {
+		if err := store.SaveLastInterface(iface.Name); err != nil {
+			logging.Warnf("failed to save last interface: %v", err)
+		}
+	}
+	details, err := netpkg.GetInterfaceDetails(iface.Name)
+	if err == nil {
+		m.details = details
+		m.detailsView.stopRateMonitor()
+		m.detailsView = &DetailsView{
+			details:     details,
+			lastUpdate:  time.Now(),
+			autoRefresh: true,
+		}
+		logging.Debugf("loaded details for %s", iface.Name)
+	} else {
+		logging.Warnf("failed to load details for %s: %v", iface.Name, err)
+	}
+	m.layer = LayerMode
+	m.modeIndex = 0
+	m.hoverIndex = -1
+	m.statusMsg = "Select a mode"
+	return m
+}
+
+// selectMode activates the mode at idx in the mode menu
+func (m Model) selectMode(idx int) (Model, tea.Cmd) {
+	sel := m.availableModes()[idx]
+	m.modeIndex = idx
+	m = m.activateMode(sel.mode)
+	m.layer = LayerView
+	m.hoverIndex = -1
+
+	// Trigger extended details if entering Details view
+	if sel.mode == ViewDetails && m.selectedIface != "" {
+		return m, getExtendedDetailsCmd(m.selectedIface)
+	}
+	return m, nil
+}
+
+// Rows above the first entry in the mode menu
+const menuHeaderRows = 3
+
+// menuRowAt maps a screen row to an entry in the picker or mode menu, or -1
+func (m Model) menuRowAt(y int) int {
+	switch m.layer {
+	case LayerInterface:
+		// Each interface takes two rows: name and traffic
+		if y < pickerHeaderRows {
+			return -1
+		}
+		row := (y - pickerHeaderRows) / 2
+		if idx := m.scrollOffset + row; row < m.pickerPageSize() && idx < len(m.visibleInterfaces()) {
+			return idx
+		}
+	case LayerMode:
+		if y < menuHeaderRows {
+			return -1
+		}
+		if idx := y - menuHeaderRows; idx < len(m.availableModes()) {
+			return idx
+		}
+	}
+	return -1
+}
+
+// handleMouse lets the picker and mode menu be driven by mouse: hover
+// highlights a row, a left click selects it and the wheel moves the cursor
+func (m Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if m.layer == LayerView || m.helpActive {
+		return m, nil
+	}
+
+	switch msg.Button {
+	case tea.MouseButtonWheelUp, tea.MouseButtonWheelDown:
+		delta := 1
+		if msg.Button == tea.MouseButtonWheelUp {
+			delta = -1
+		}
+		if m.layer == LayerInterface {
+			m.moveInterfaceCursor(delta)
+		} else if count := len(m.availableModes()); count > 0 {
+			m.modeIndex = (m.modeIndex + delta + count) % count
+		}
+		return m, nil
+	}
+
+	row := m.menuRowAt(msg.Y)
+	m.hoverIndex = row
+	if row < 0 || msg.Button != tea.MouseButtonLeft || msg.Action != tea.MouseActionPress {
+		return m, nil
+	}
+
+	if m.layer == LayerInterface {
+		logging.Infof("click -> interface %s", m.visibleInterfaces()[row].Name)
+		return m.selectInterface(row), nil
+	}
+	logging.Infof("click -> mode %d", row)
+	return m.selectMode(row)
+}
+
 // View renders the TUI
+// handleMacroKeys drives the console macro panel: run or record macros
+func (m Model) handleMacroKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	v := m.consoleView
+	switch msg.String() {
+	case "up", "k":
+		if v.selectedMacro > 0 {
+			v.selectedMacro--
+		}
+	case "down", "j":
+		if v.selectedMacro < len(v.macros)-1 {
+			v.selectedMacro++
+		}
+	case "enter":
+		sess, ok := v.session.(*console.Session)
+		if !ok || len(v.macros) == 0 {
+			break
+		}
+		if v.playingMacro != "" || v.transfer != nil {
+			v.statusMessage = "Wait for the running macro or transfer to finish"
+			break
+		}
+		name := v.macros[v.selectedMacro]
+		macro, err := console.LoadMacro(name)
+		if err != nil {
+			v.statusMessage = fmt.Sprintf("Failed to load macro: %v", err)
+			break
+		}
+		v.showMacros = false
+		return m, m.askMacroSecrets(sess, *macro, nil)
+	case "r":
+		sess, ok := v.session.(*console.Session)
+		if !ok {
+			break
+		}
+		v.showMacros = false
+		m.inputActive = true
+		m.inputPrompt = "Macro name: "
+		m.inputValue = ""
+		m.inputSubmit = func(m *Model, val string) tea.Cmd {
+			name := strings.TrimSpace(val)
+			rec, err := console.RecordMacro(sess, name)
+			if err != nil {
+				m.consoleView.statusMessage = fmt.Sprintf("Cannot record macro: %v", err)
+				return nil
+			}
+			m.consoleView.recorder = rec
+			m.consoleView.recordingID = sess.ID()
+			m.consoleView.statusMessage = fmt.Sprintf("Recording macro %s; press m to stop", name)
+			return nil
+		}
+		m.statusMsg = "Enter macro name..."
+	case "m", "esc", "q":
+		v.showMacros = false
+	}
+	return m, nil
+}
+
+// askMacroSecrets prompts for the value of each secret step in turn, then
+// plays the macro
+func (m *Model) askMacroSecrets(sess *console.Session, macro console.Macro, secrets []string) tea.Cmd {
+	if len(secrets) >= macro.SecretSteps() {
+		m.consoleView.playingMacro = macro.Name
+		m.consoleView.statusMessage = fmt.Sprintf("Running macro %s...", macro.Name)
+		return playMacroCmd(sess, macro, secrets)
+	}
+	m.inputActive = true
+	m.inputMasked = true
+	m.inputPrompt = fmt.Sprintf("Secret %d of %d for macro %s: ", len(secrets)+1, macro.SecretSteps(), macro.Name)
+	m.inputValue = ""
+	m.inputSubmit = func(m *Model, val string) tea.Cmd {
+		return m.askMacroSecrets(sess, macro, append(secrets, val))
+	}
+	return nil
+}
+
 func (m Model) View() string {
 	switch m.layer {
 	case LayerInterface:
-		return m.renderPicker()
+		return m.withHelp(m.renderPicker())
 	case LayerMode:
-		return m.renderModeMenu()
+		return m.withHelp(m.renderModeMenu())
 	case LayerView:
 		content := lipgloss.JoinVertical(lipgloss.Left,
 			m.renderContent(),
 			m.renderStatus(),
 		)
-		if m.inputActive {
+		if m.inputActive && !m.helpActive {
 			// Overlay input box
-			inputStyle := lipgloss.NewStyle().
-				Border(lipgloss.RoundedBorder()).
-				Padding(1, 2).
-				BorderForeground(lipgloss.Color("63"))
-
-			inputBox := inputStyle.Render(fmt.Sprintf("%s\n%s_", m.inputPrompt, m.inputValue))
+			value := m.inputValue
+			if m.inputMasked {
+				value = strings.Repeat("*", len(value))
+			}
+			inputBox := m.styles.Box.Render(fmt.Sprintf("%s\n%s_", m.inputPrompt, value))
 
 			// Center the input box (rough approximation)
 			return lipgloss.Place(m.width, m.height,
@@ -1141,19 +2711,19 @@ func (m Model) View() string {
 				lipgloss.WithWhitespaceChars(" "),
 				// lipgloss.WithWhitespaceForeground(lipgloss.NoColor), // Removed to fix type error
 			)
-		} else if m.helpActive {
-			// Overlay Help
-			helpBox := m.renderHelp()
-			return lipgloss.Place(m.width, m.height,
-				lipgloss.Center, lipgloss.Center,
-				helpBox,
-				lipgloss.WithWhitespaceChars(" "),
-			)
 		}
-		return content
+		return m.withHelp(content)
 	default:
-		return m.renderPicker()
+		return m.withHelp(m.renderPicker())
+	}
+}
+
+// withHelp draws the help overlay over view when it is open
+func (m Model) withHelp(view string) string {
+	if !m.helpActive {
+		return view
 	}
+	return overlayCenter(view, m.renderHelp(), m.width, m.height)
 }
 
 func (m Model) renderPicker() string {
@@ -1162,10 +2732,33 @@ func (m Model) renderPicker() string {
 	s += "║              LanAudit - Select Network Interface                 ║\n"
 	s += "╠══════════════════════════════════════════════════════════════════╣\n"
 
-	for i, iface := range m.interfaces {
-		if i >= 8 {
-			break
-		}
+	visible := m.visibleInterfaces()
+	start := m.scrollOffset
+	if start > len(visible) {
+		start = len(visible)
+	}
+	end := start + m.pickerPageSize()
+	if end > len(visible) {
+		end = len(visible)
+	}
+
+	search := "Search: (type to filter)"
+	count := fmt.Sprintf("%d interfaces", len(m.interfaces))
+	if m.pickerFilter != "" {
+		search = "Search: " + m.pickerFilter + "_"
+		count = fmt.Sprintf("%d of %d match", len(visible), len(m.interfaces))
+	}
+	if end-start < len(visible) {
+		count += fmt.Sprintf(", showing %d-%d", start+1, end)
+	}
+	s += fmt.Sprintf("║ %-30s %33s ║\n", search, count)
+
+	if len(visible) == 0 {
+		s += fmt.Sprintf("║ %-64s ║\n", "  No interfaces match")
+	}
+
+	for i := start; i < end; i++ {
+		iface := visible[i]
 
 		// Get IP address if available
 		details, err := netpkg.GetInterfaceDetails(iface.Name)
@@ -1188,10 +2781,10 @@ func (m Model) renderPicker() string {
 		txMB := float64(iface.BytesTx) / 1024 / 1024
 
 		status := "UP  "
-		statusStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("10")) // Green
+		statusStyle := m.styles.Success
 		if iface.Flags&net.FlagUp == 0 {
 			status = "DOWN"
-			statusStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("9")) // Red
+			statusStyle = m.styles.Error
 		}
 		statusStr := statusStyle.Render(status)
 
@@ -1205,20 +2798,23 @@ func (m Model) renderPicker() string {
 		}
 
 		line1 := fmt.Sprintf("%d. %-8s [%s]  %s%s", i+1, iface.Name, statusStr, ipAddr, strings.Repeat(" ", padding))
+		// Line 2: Traffic stats (aligned)
+		line2 := fmt.Sprintf("   RX: %8.1f MB  TX: %8.1f MB", rxMB, txMB)
+		if i == m.hoverIndex {
+			line1 = m.styles.Highlight.Render(fmt.Sprintf("%-63s", line1Raw))
+			line2 = m.styles.Highlight.Render(fmt.Sprintf("%-63s", line2))
+		}
 		marker := ' '
 		if i == m.selectedIndex {
 			marker = '>'
 		}
 		s += fmt.Sprintf("║ %c%-63s ║\n", marker, line1)
-
-		// Line 2: Traffic stats (aligned)
-		line2 := fmt.Sprintf("   RX: %8.1f MB  TX: %8.1f MB", rxMB, txMB)
 		s += fmt.Sprintf("║  %-63s ║\n", line2)
 	}
 
 	s += "╠══════════════════════════════════════════════════════════════════╣\n"
 	s += "║ Arrow keys: Navigate  |  1-9: Quick select  |  ENTER: Select     ║\n"
-	s += "║ q/esc: Back/quit                                                 ║\n"
+	s += "║ Type to search  |  esc: Clear search/quit  |  ?: Help            ║\n"
 	s += "╚══════════════════════════════════════════════════════════════════╝\n"
 
 	return s
@@ -1235,12 +2831,21 @@ func (m Model) availableModes() []struct {
 	}{
 		{"[d] Details", ViewDetails},
 		{"[g] Diagnose", ViewDiagnose},
-		{"[v] VLAN [WIP]", ViewVLAN},
-		{"[n] Snap [WIP]", ViewSnap},
+		{"[v] VLAN", ViewVLAN},
+		{"[n] Snapshots", ViewSnap},
 		{"[s] Settings", ViewSettings},
 		{"[c] Capture", ViewCapture},
 		{"[a] Audit", ViewAudit},
 		{"[p] Speedtest", ViewSpeedtest},
+		{"[b] ARP Table", ViewARP},
+		{"[M] ARP Monitor", ViewARPMonitor},
+		{"[Q] DNS Log", ViewDNSLog},
+		{"[D] Snapshot Diff", ViewSnapDiff},
+		{"[B] ARP Scan", ViewARPScan},
+		{"[C] CDP", ViewCDP},
+		{"[w] Wake-on-LAN", ViewWoL},
+		{"[m] mDNS", ViewMDNS},
+		{"[T] Spanning Tree", ViewSTP},
 		{"[o] Console", ViewConsole},
 	}
 }
@@ -1265,8 +2870,11 @@ func (m Model) renderModeMenu() string {
 				clean = strings.TrimSpace(clean[idx+1:])
 			}
 		}
-		line := fmt.Sprintf("%d. %s", i+1, clean)
-		s += fmt.Sprintf("║ %c%-63s ║\n", marker, line)
+		line := fmt.Sprintf("%-63s", fmt.Sprintf("%d. %s", i+1, clean))
+		if i == m.hoverIndex {
+			line = m.styles.Highlight.Render(line)
+		}
+		s += fmt.Sprintf("║ %c%s ║\n", marker, line)
 	}
 
 	s += "╠══════════════════════════════════════════════════════════════════╣\n"
@@ -1277,6 +2885,9 @@ func (m Model) renderModeMenu() string {
 
 // activateMode sets up and switches to a given view mode
 func (m Model) activateMode(mode ViewMode) Model {
+	if m.mode == ViewDetails && mode != ViewDetails {
+		m.detailsView.stopRateMonitor()
+	}
 	m.mode = mode
 	logging.Infof("activateMode -> %v", mode)
 	switch mode {
@@ -1288,11 +2899,13 @@ func (m Model) activateMode(mode ViewMode) Model {
 				}
 			}
 			if m.details != nil {
+				m.detailsView.stopRateMonitor()
 				m.detailsView = &DetailsView{
 					details:     m.details,
 					lastUpdate:  time.Now(),
 					autoRefresh: true,
 				}
+				m.detailsView.startRateMonitor(m.selectedIface)
 			}
 		}
 		m.statusMsg = "Viewing Details"
@@ -1301,12 +2914,26 @@ func (m Model) activateMode(mode ViewMode) Model {
 		m.statusMsg = "Viewing Diagnostics"
 
 	case ViewVLAN:
+		if m.vlanView == nil {
+			m.vlanView = &VLANView{statusMessage: "Press 's' to enter VLANs to test."}
+		}
 		m.statusMsg = "VLAN Tester"
 
 	case ViewSnap:
+		if m.snapView == nil {
+			m.snapView = &SnapView{statusMessage: "Press 's' to save a snapshot."}
+		}
 		m.statusMsg = "Snapshots"
 
 	case ViewSettings:
+		if m.settingsView == nil {
+			m.settingsView = &SettingsView{}
+		}
+		profiles, err := store.ListProfiles()
+		if err != nil {
+			logging.Warnf("failed to list profiles: %v", err)
+		}
+		m.settingsView.profiles = profiles
 		m.statusMsg = "Settings"
 
 	case ViewCapture:
@@ -1330,6 +2957,11 @@ func (m Model) activateMode(mode ViewMode) Model {
 			m.speedtestView = &SpeedtestView{
 				statusMessage: "Press 's' to start speedtest.",
 			}
+			history, err := store.LoadSpeedtestHistory(speedtestHistoryLen)
+			if err != nil {
+				logging.Warnf("Speedtest history load failed: %v", err)
+			}
+			m.speedtestView.history = history
 		}
 		m.statusMsg = "Speedtest"
 
@@ -1338,23 +2970,139 @@ func (m Model) activateMode(mode ViewMode) Model {
 			m.consoleView = &ConsoleView{
 				ports:         make([]interface{}, 0),
 				selectedPort:  -1,
-				buffer:        make([]string, 0),
 				statusMessage: "Discovering serial ports...",
 				dtrState:      true,
 				rtsState:      true,
 				logging:       false,
+				manager:       console.NewSessionManager(),
+				buffers:       make(map[string][]string),
+				fingerprints:  make(map[string]*fingerprint.Result),
 			}
 		}
 		m.statusMsg = "Serial Console"
-	}
-	return m
-}
 
-func (m Model) renderContent() string {
-	switch m.mode {
-	case ViewDetails:
-		return m.renderDetailsView()
-	case ViewDiagnose:
+	case ViewARP:
+		if m.arpView == nil {
+			m.arpView = &ARPView{}
+		}
+		m.refreshARP()
+		m.statusMsg = "ARP Table"
+
+	case ViewARPMonitor:
+		if m.arpMonView == nil {
+			m.arpMonView = &ARPMonitorView{
+				threshold:     arpMonitorThreshold,
+				statusMessage: "Press 's' to start monitoring ARP (requires sudo/root).",
+			}
+		}
+		m.statusMsg = "ARP Monitor"
+
+	case ViewDNSLog:
+		if m.dnsLogView == nil {
+			m.dnsLogView = &DNSLogView{
+				statusMessage: "Press 's' to start logging DNS queries (requires sudo/root).",
+			}
+		}
+		m.statusMsg = "DNS Log"
+
+	case ViewSnapDiff:
+		m.snapDiffView = loadSnapDiff()
+		m.statusMsg = "Snapshot Diff"
+
+	case ViewWoL:
+		if m.wolView == nil {
+			m.wolView = &WoLView{
+				statusMessage: "Press 's' to wake a host.",
+			}
+		}
+		m.statusMsg = "Wake-on-LAN"
+
+	case ViewSTP:
+		if m.stpView == nil {
+			m.stpView = &STPView{
+				statusMessage: fmt.Sprintf("STP listener ready. Press 's' to listen for %s.", stpListenDuration),
+			}
+		}
+		m.statusMsg = "Spanning Tree"
+
+	case ViewMDNS:
+		if m.mdnsView == nil {
+			m.mdnsView = &MDNSView{
+				statusMessage: fmt.Sprintf("mDNS discovery ready. Press 's' to browse for %s.", mdns.DefaultDuration),
+			}
+		}
+		m.statusMsg = "mDNS Discovery"
+
+	case ViewCDP:
+		if m.cdpView == nil {
+			m.cdpView = &CDPView{
+				statusMessage: fmt.Sprintf("CDP discovery ready. Press 's' to listen for %s.", cdpListenDuration),
+			}
+		}
+		m.statusMsg = "CDP Discovery"
+
+	case ViewARPScan:
+		if m.arpScanView == nil {
+			m.arpScanView = &ARPScanView{
+				statusMessage: "Press 's' to choose a range and scan it (requires sudo/root).",
+			}
+		}
+		// Offer the selected interface's subnet, keeping an edited range
+		// until the interface changes
+		if m.arpScanView.iface != m.selectedIface && !m.arpScanView.running {
+			m.arpScanView.iface = m.selectedIface
+			m.arpScanView.cidr = ""
+			m.arpScanView.hosts = nil
+			if subnet, err := arpscan.InterfaceSubnet(m.selectedIface); err == nil {
+				m.arpScanView.cidr = subnet
+			}
+		}
+		m.statusMsg = "ARP Scan"
+	}
+	return m
+}
+
+// loadSnapDiff compares the two most recent saved snapshots
+func loadSnapDiff() *SnapDiffView {
+	v := &SnapDiffView{}
+	snaps, err := store.LoadRecentSnapshots(2)
+	switch {
+	case err != nil:
+		v.err = err
+		v.statusMessage = fmt.Sprintf("Failed to load snapshots: %v", err)
+		logging.Warnf(v.statusMessage)
+	case len(snaps) < 2:
+		v.statusMessage = fmt.Sprintf("Need two snapshots to compare, found %d.", len(snaps))
+	default:
+		v.diff = store.DiffSnapshots(snaps[0], snaps[1])
+		v.statusMessage = fmt.Sprintf("%s -> %s", v.diff.From, v.diff.To)
+	}
+	return v
+}
+
+// refreshARP reloads the ARP cache into the ARP view
+func (m *Model) refreshARP() {
+	if m.arpView == nil {
+		m.arpView = &ARPView{}
+	}
+	entries, err := netpkg.GetARPTable()
+	m.arpView.err = err
+	m.arpView.lastUpdate = time.Now()
+	if err != nil {
+		m.arpView.statusMessage = fmt.Sprintf("Failed to read ARP table: %v", err)
+		logging.Warnf(m.arpView.statusMessage)
+		return
+	}
+	m.arpView.entries = entries
+	m.arpView.statusMessage = fmt.Sprintf("%d entries", len(entries))
+	logging.Debugf("refreshed ARP table: %d entries", len(entries))
+}
+
+func (m Model) renderContent() string {
+	switch m.mode {
+	case ViewDetails:
+		return m.renderDetailsView()
+	case ViewDiagnose:
 		return m.renderDiagnoseView()
 	case ViewVLAN:
 		return m.renderVLANView()
@@ -1372,6 +3120,24 @@ func (m Model) renderContent() string {
 		return m.renderConsoleView()
 	case ViewLLDP:
 		return m.renderLLDPView()
+	case ViewARP:
+		return m.renderARPView()
+	case ViewSnapDiff:
+		return m.renderSnapDiffView()
+	case ViewARPMonitor:
+		return m.renderARPMonitorView()
+	case ViewDNSLog:
+		return m.renderDNSLogView()
+	case ViewARPScan:
+		return m.renderARPScanView()
+	case ViewCDP:
+		return m.renderCDPView()
+	case ViewWoL:
+		return m.renderWoLView()
+	case ViewMDNS:
+		return m.renderMDNSView()
+	case ViewSTP:
+		return m.renderSTPView()
 	default:
 		return "Unknown view"
 	}
@@ -1403,6 +3169,18 @@ func (m Model) renderDetailsView() string {
 	}
 	s += "\n"
 
+	if w := m.details.Wifi; w != nil {
+		s += "═══ Wi-Fi ═══\n"
+		s += fmt.Sprintf("SSID:       %s\n", w.SSID)
+		s += fmt.Sprintf("BSSID:      %s\n", w.BSSID)
+		s += fmt.Sprintf("Signal:     %d dBm\n", w.RSSI)
+		s += fmt.Sprintf("Channel:    %d\n", w.Channel)
+		if w.Standard != "" {
+			s += fmt.Sprintf("Standard:   %s\n", w.Standard)
+		}
+		s += "\n"
+	}
+
 	s += "═══ IP Addresses ═══\n"
 	if len(m.details.IPs) > 0 {
 		for _, ip := range m.details.IPs {
@@ -1413,8 +3191,8 @@ func (m Model) renderDetailsView() string {
 	}
 
 	s += "\n═══ Network ═══\n"
-	if m.details.DefaultGateway != "" {
-		s += fmt.Sprintf("Gateway:    %s\n", m.details.DefaultGateway)
+	if len(m.details.DefaultGateways) > 0 {
+		s += fmt.Sprintf("Gateway:    %s\n", strings.Join(m.details.DefaultGateways, ", "))
 	} else {
 		s += "Gateway:    Not configured\n"
 	}
@@ -1428,6 +3206,18 @@ func (m Model) renderDetailsView() string {
 		s += "  None configured\n"
 	}
 
+	if len(m.details.Routes) > 0 {
+		s += "\n═══ Routes ═══\n"
+		s += fmt.Sprintf("%-28s %-26s %s\n", "Destination", "Gateway", "Metric")
+		for _, r := range m.details.Routes {
+			gateway := r.Gateway
+			if gateway == "" {
+				gateway = "direct"
+			}
+			s += fmt.Sprintf("%-28s %-26s %d\n", r.Prefix(), gateway, r.Metric)
+		}
+	}
+
 	s += "\n═══ Traffic Statistics ═══\n"
 	s += fmt.Sprintf("RX: %s (%s packets)\n",
 		formatBytes(m.details.BytesRx),
@@ -1435,6 +3225,19 @@ func (m Model) renderDetailsView() string {
 	s += fmt.Sprintf("TX: %s (%s packets)\n",
 		formatBytes(m.details.BytesTx),
 		formatNumber(m.details.PacketsTx))
+	s += fmt.Sprintf("Errors:  RX %s / TX %s\n",
+		formatCounter(m.styles, m.details.RxErrors), formatCounter(m.styles, m.details.TxErrors))
+	s += fmt.Sprintf("Dropped: RX %s / TX %s\n",
+		formatCounter(m.styles, m.details.RxDropped), formatCounter(m.styles, m.details.TxDropped))
+
+	if m.detailsView != nil {
+		if m.detailsView.rateReady {
+			s += fmt.Sprintf("RX Rate: %s\n", formatBps(m.detailsView.rate.RxBps))
+			s += fmt.Sprintf("TX Rate: %s\n", formatBps(m.detailsView.rate.TxBps))
+		} else {
+			s += "RX Rate: measuring...\nTX Rate: measuring...\n"
+		}
+	}
 
 	if m.detailsView != nil {
 		s += fmt.Sprintf("\nLast updated: %s (auto-refresh every 2s)\n",
@@ -1457,6 +3260,40 @@ func formatBytes(bytes uint64) string {
 	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
 
+// renderHopRTT colors a hop latency green/yellow/red by threshold
+func renderHopRTT(styles StyleSet, rtt time.Duration) string {
+	style := styles.Success
+	switch {
+	case rtt >= 100*time.Millisecond:
+		style = styles.Error
+	case rtt >= 30*time.Millisecond:
+		style = styles.Warning
+	}
+	return style.Render(rtt.Round(10 * time.Microsecond).String())
+}
+
+// formatBps renders a bit rate with an appropriate unit
+func formatBps(bps float64) string {
+	switch {
+	case bps >= 1e9:
+		return fmt.Sprintf("%.1f Gbps", bps/1e9)
+	case bps >= 1e6:
+		return fmt.Sprintf("%.1f Mbps", bps/1e6)
+	case bps >= 1e3:
+		return fmt.Sprintf("%.1f Kbps", bps/1e3)
+	default:
+		return fmt.Sprintf("%.0f bps", bps)
+	}
+}
+
+// formatCounter renders an error/drop counter, highlighting non-zero values in red
+func formatCounter(styles StyleSet, n uint64) string {
+	if n == 0 {
+		return formatNumber(n)
+	}
+	return styles.Error.Render(formatNumber(n))
+}
+
 func formatNumber(n uint64) string {
 	if n < 1000 {
 		return fmt.Sprintf("%d", n)
@@ -1468,6 +3305,25 @@ func formatNumber(n uint64) string {
 	return fmt.Sprintf("%.1fG", float64(n)/1000000000)
 }
 
+// remotePingLines describes each remote ping, ordered by address
+func remotePingLines(pings map[string]diagnostics.PingResult) []string {
+	targets := make([]string, 0, len(pings))
+	for target := range pings {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	lines := make([]string, 0, len(targets))
+	for _, target := range targets {
+		p := pings[target]
+		if p.Err != "" {
+			lines = append(lines, fmt.Sprintf("%-15s  error %s", target, p.Err))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%-15s  %.1f%% loss, %s", target, p.Loss, p.RTTSummary()))
+	}
+	return lines
+}
+
 func (m Model) renderDiagnoseView() string {
 	var s strings.Builder
 	s.WriteString("═══ Diagnostics ═══\n\n")
@@ -1507,7 +3363,13 @@ func (m Model) renderDiagnoseView() string {
 		s.WriteString(fmt.Sprintf("Ping: error %s\n", res.Ping.Err))
 	} else {
 		s.WriteString(fmt.Sprintf("Ping Loss: %.1f%%\n", res.Ping.Loss))
-		s.WriteString(fmt.Sprintf("Ping RTT: %v\n", res.Ping.MedianRTT))
+		s.WriteString(fmt.Sprintf("Ping RTT: %s\n", res.Ping.RTTSummary()))
+	}
+	if len(res.RemotePings) > 0 {
+		s.WriteString("Remote Pings:\n")
+		for _, line := range remotePingLines(res.RemotePings) {
+			s.WriteString("  " + line + "\n")
+		}
 	}
 
 	if res.DNS.Err != "" {
@@ -1517,11 +3379,79 @@ func (m Model) renderDiagnoseView() string {
 	if len(res.DNS.AltTried) > 0 {
 		s.WriteString(fmt.Sprintf("DNS Alternate OK: %v (tried %s)\n", res.DNS.AltOK, strings.Join(res.DNS.AltTried, ", ")))
 	}
+	if res.DNS.DOH.Server != "" {
+		if res.DNS.DOH.OK {
+			s.WriteString(fmt.Sprintf("DNS-over-HTTPS OK: true (%s, %v)\n", res.DNS.DOH.Server, res.DNS.DOH.Latency.Round(time.Millisecond)))
+		} else {
+			s.WriteString(fmt.Sprintf("DNS-over-HTTPS OK: false (%s: %s)\n", res.DNS.DOH.Server, res.DNS.DOH.Err))
+		}
+	}
+
+	if res.DNS.DNSSEC.Server != "" {
+		if res.DNS.DNSSEC.Err != "" {
+			s.WriteString(fmt.Sprintf("DNSSEC: error %s\n", res.DNS.DNSSEC.Err))
+		} else {
+			s.WriteString(fmt.Sprintf("DNSSEC Validated: %v (%s)\n", res.DNS.DNSSEC.Validated, res.DNS.DNSSEC.Server))
+		}
+	}
 
 	if res.HTTPS.Err != "" {
 		s.WriteString(fmt.Sprintf("HTTPS Error: %s\n", res.HTTPS.Err))
 	} else {
-		s.WriteString(fmt.Sprintf("HTTPS OK: %v (status %d)\n", res.HTTPS.OK, res.HTTPS.Status))
+		s.WriteString(fmt.Sprintf("HTTPS OK: %v (status %d, %s)\n", res.HTTPS.OK, res.HTTPS.Status, res.HTTPS.Target))
+	}
+	if res.HTTPS.CaptivePortal {
+		s.WriteString(m.styles.Warning.
+			Render(fmt.Sprintf("Captive portal detected: %s", res.HTTPS.CaptivePortalURL)) + "\n")
+	}
+	if !res.HTTPS.CertExpiry.IsZero() {
+		cert := fmt.Sprintf("TLS Certificate: %s, expires %s (%d days)", res.HTTPS.CertIssuer, res.HTTPS.CertExpiry.Format("2006-01-02"), res.HTTPS.CertDaysLeft)
+		if res.HTTPS.CertExpiringSoon {
+			cert = m.styles.Warning.Render(cert)
+		}
+		s.WriteString(cert + "\n")
+	}
+
+	if res.NTP != nil {
+		switch {
+		case res.NTP.Err != "":
+			s.WriteString(fmt.Sprintf("Clock Offset: error %s\n", res.NTP.Err))
+		case res.NTP.OK:
+			s.WriteString(fmt.Sprintf("Clock Offset: %v (%s)\n", res.NTP.Offset.Round(time.Millisecond), res.NTP.Server))
+		default:
+			s.WriteString(m.styles.Error.
+				Render(fmt.Sprintf("Clock Offset: %v (%s)", res.NTP.Offset.Round(time.Millisecond), res.NTP.Server)) + "\n")
+		}
+	}
+
+	if res.MTU != nil {
+		if res.MTU.Err != "" {
+			s.WriteString(fmt.Sprintf("Path MTU: error %s\n", res.MTU.Err))
+		} else {
+			s.WriteString(fmt.Sprintf("Path MTU: %d\n", res.MTU.MaxMTU))
+		}
+	}
+
+	if res.Trace != nil {
+		s.WriteString(fmt.Sprintf("\nTraceroute to %s:\n", res.Trace.Dest))
+		if res.Trace.Err != "" {
+			s.WriteString(fmt.Sprintf("  error: %s\n", res.Trace.Err))
+		}
+		for _, hop := range res.Trace.Hops {
+			if hop.Err != "" {
+				s.WriteString(fmt.Sprintf("  %2d  %-40s %s\n", hop.TTL, hop.IP, hop.Err))
+				continue
+			}
+			s.WriteString(fmt.Sprintf("  %2d  %-40s %s\n", hop.TTL, hop.IP, renderHopRTT(m.styles, hop.RTT)))
+		}
+	}
+
+	if len(dv.history) > 1 {
+		losses := make([]float64, len(dv.history))
+		for i, h := range dv.history {
+			losses[i] = h.Ping.Loss
+		}
+		s.WriteString(fmt.Sprintf("\nPing loss (last %d runs): %s\n", len(losses), lossSparkline(m.styles, losses)))
 	}
 
 	if len(res.Suggestions) > 0 {
@@ -1540,12 +3470,89 @@ func (m Model) renderDiagnoseView() string {
 	return s.String()
 }
 
+// diagHistoryLen is how many past runs the diagnose view keeps for its sparkline
+const diagHistoryLen = 5
+
+// lossSparkline renders packet loss percentages as Unicode block characters
+func lossSparkline(styles StyleSet, losses []float64) string {
+	blocks := []rune("▁▂▃▄▅▆▇█")
+	var b strings.Builder
+	for _, loss := range losses {
+		if loss < 0 {
+			loss = 0
+		}
+		if loss > 100 {
+			loss = 100
+		}
+		idx := int(loss / 100 * float64(len(blocks)-1))
+		ch := string(blocks[idx])
+		switch {
+		case loss > 50:
+			ch = styles.Error.Render(ch)
+		case loss > 0:
+			ch = styles.Warning.Render(ch)
+		}
+		b.WriteString(ch)
+	}
+	return b.String()
+}
+
 func (m Model) renderVLANView() string {
-	return "VLAN Tester\n\nThis feature requires root/sudo privileges.\n(Feature implementation in progress)"
+	if m.vlanView == nil {
+		return "VLAN view not initialized"
+	}
+
+	var s string
+	s += "═══ VLAN Tester ═══\n\n"
+	s += fmt.Sprintf("Status: %s\n\n", m.vlanView.statusMessage)
+
+	if m.vlanView.running {
+		s += fmt.Sprintf("Creating VLAN interfaces on %s and waiting for DHCP...\n", m.selectedIface)
+	} else if m.vlanView.discovering {
+		s += fmt.Sprintf("Sniffing tagged traffic on %s...\n", m.selectedIface)
+	} else {
+		s += "Creates a temporary VLAN interface per ID, requests a DHCP lease\n"
+		s += "and removes the interface again. Requires root/sudo.\n\n"
+		s += "Commands:\n"
+		s += fmt.Sprintf("  's' - Enter VLANs and start (up to %d, requires %s consent)\n", vlan.MaxVLANRange, vlan.ConsentToken)
+		s += "  'd' - Discover VLANs from tagged traffic (trunk ports only)\n"
+		if m.vlanView.rangeStr != "" {
+			s += fmt.Sprintf("\nLast range: %s (%d VLANs)\n", m.vlanView.rangeStr, len(m.vlanView.vlans))
+		}
+	}
+
+	if len(m.vlanView.results) > 0 {
+		s += renderVLANResults(m.vlanView.results)
+	}
+
+	return s
+}
+
+// renderVLANResults tabulates the lease obtained on each VLAN
+func renderVLANResults(results []vlan.LeaseResult) string {
+	s := fmt.Sprintf("\n%-6s %-16s %-16s %s\n", "VLAN", "IP", "Router", "DNS")
+	for _, r := range results {
+		if r.Err != "" {
+			s += fmt.Sprintf("%-6d %s\n", r.VLAN, r.Err)
+			continue
+		}
+		s += fmt.Sprintf("%-6d %-16s %-16s %s\n", r.VLAN, r.IP, r.Router, strings.Join(r.DNS, ", "))
+	}
+	return s
 }
 
 func (m Model) renderSnapView() string {
-	return "Snapshots\n\nPress 'n' to create a new snapshot\n(Feature implementation in progress)"
+	s := "Snapshots\n\n"
+	if m.snapView == nil {
+		return s + "Press 's' to save a snapshot."
+	}
+	if m.config != nil && m.config.EncryptSnapshots {
+		s += "Encryption: AES-256-GCM\n"
+	}
+	if m.snapView.lastSnapshot != "" {
+		s += fmt.Sprintf("Last snapshot: %s\n", m.snapView.lastSnapshot)
+	}
+	return s + "\n" + m.snapView.statusMessage
 }
 
 func (m Model) renderSettingsView() string {
@@ -1553,14 +3560,101 @@ func (m Model) renderSettingsView() string {
 		return "No configuration loaded"
 	}
 
+	errs := m.config.Validate()
+	shown := make(map[string]bool)
+	line := func(field, text string) string {
+		shown[field] = true
+		return text + renderConfigErrors(m.styles, errs, field) + "\n"
+	}
+
 	var s string
 	s += "Settings\n\n"
-	s += fmt.Sprintf("DNS Alternates: %v\n", m.config.DNSAlternates)
-	s += fmt.Sprintf("Diagnostics Timeout: %dms (press 't' to cycle)\n", m.config.DiagnosticsTimeout)
-	s += fmt.Sprintf("Redact Mode: %v (press 'r' to toggle)\n", m.config.Redact)
+	if m.settingsView != nil && len(m.settingsView.profiles) > 0 {
+		s += fmt.Sprintf("Profile: %s (press 'p' to switch)\n", profileLabel(store.ActiveProfile()))
+		s += fmt.Sprintf("Profiles: %s, %s\n\n", profileLabel(""), strings.Join(m.settingsView.profiles, ", "))
+	} else if store.ActiveProfile() != "" {
+		s += fmt.Sprintf("Profile: %s\n\n", store.ActiveProfile())
+	}
+	s += line("dns_alternates", fmt.Sprintf("DNS Alternates: %v", m.config.DNSAlternates))
+	s += line("probe_targets", fmt.Sprintf("HTTPS Probe Targets: %v", m.config.ProbeTargets))
+	s += line("diagnostics_timeout_ms", fmt.Sprintf("Diagnostics Timeout: %dms (press 't' to set)", m.config.DiagnosticsTimeout))
+	if override := store.TimeoutOverride(); override > 0 {
+		s += fmt.Sprintf("  Overridden by --timeout %s for this session\n", override)
+	}
+	redactLevel := m.config.RedactLevel
+	if redactLevel == 0 {
+		redactLevel = store.RedactLastOctet
+	}
+	s += line("redact_level", fmt.Sprintf("Redact Mode: %v, level %d (press 'r' to toggle)", m.config.Redact, redactLevel))
+	s += fmt.Sprintf("Path MTU Probe: %v (press 'm' to toggle)\n", m.config.ProbeMTU)
+	s += fmt.Sprintf("Traceroute: %v (press 'e' to toggle)\n", m.config.Traceroute)
+	s += fmt.Sprintf("NTP Check: %v (press 'y' to toggle)\n", m.config.CheckNTP)
+	s += line("fingerprint.min_probe_confidence", fmt.Sprintf("Probe Confidence: %.2f (press 'f' to cycle)", m.config.Fingerprint.MinProbeConfidence))
+	s += line("fingerprint.max_evidence", fmt.Sprintf("Fingerprint Evidence: %d lines", m.config.Fingerprint.MaxEvidence))
+	if len(m.config.ScanPorts) == 0 {
+		s += line("scan_ports", fmt.Sprintf("Audit TCP Ports: default (%d common ports) (press '+'/'-' to edit)", len(scan.CommonPorts)))
+	} else {
+		s += line("scan_ports", fmt.Sprintf("Audit TCP Ports: %s (press '+'/'-' to edit)", scan.FormatPorts(m.config.ScanPorts)))
+	}
+	s += line("console.default_bauds", fmt.Sprintf("Console Bauds: %v", m.config.Console.DefaultBauds))
+	s += line("console.crlf_mode", fmt.Sprintf("Console Line Endings: %s", m.config.Console.CRLFMode))
+
+	// Settings without a line of their own
+	var other []string
+	for _, e := range errs {
+		if !shown[e.Field] {
+			other = append(other, e.Error())
+		}
+	}
+	if len(other) > 0 {
+		s += "\n" + m.styles.Error.Render("Config problems:\n  "+strings.Join(other, "\n  ")) + "\n"
+	}
 	return s
 }
 
+// profileLabel names a profile for display, "" being config.json
+func profileLabel(name string) string {
+	if name == "" {
+		return "default"
+	}
+	return name
+}
+
+// nextProfile returns the profile after current, cycling through config.json
+// ("") and then the saved profiles
+func nextProfile(current string, profiles []string) string {
+	options := append([]string{""}, profiles...)
+	for i, name := range options {
+		if name == current {
+			return options[(i+1)%len(options)]
+		}
+	}
+	return ""
+}
+
+// renderConfigErrors lists the validation errors for a settings field, to
+// follow its value
+func renderConfigErrors(styles StyleSet, errs []store.ConfigError, field string) string {
+	var msgs []string
+	for _, e := range errs {
+		if e.Field == field {
+			msgs = append(msgs, e.Message)
+		}
+	}
+	if len(msgs) == 0 {
+		return ""
+	}
+	return "  " + styles.Error.Render("⚠ "+strings.Join(msgs, "; "))
+}
+
+// saveConfig writes the config, reporting a rejected one in the status line
+func (m *Model) saveConfig() {
+	if err := store.SaveConfig(m.config); err != nil {
+		logging.Errorf("failed to save config: %v", err)
+		m.statusMsg = fmt.Sprintf("Config not saved: %v", err)
+	}
+}
+
 func (m Model) renderCaptureView() string {
 	if m.captureView == nil {
 		return "Capture view not initialized"
@@ -1570,21 +3664,62 @@ func (m Model) renderCaptureView() string {
 	s += "═══ Packet Capture ═══\n\n"
 	s += fmt.Sprintf("Status: %s\n\n", m.captureView.statusMessage)
 
+	if bg := capture.GetBackgroundSession(); bg != nil {
+		s += renderBackgroundStatus(m.styles, bg.Interface, bg.Status())
+	}
+	if d := m.captureView.dhcpDetector; d != nil {
+		s += renderRogueDHCP(m.styles, d.LegitimateServer, d.IsRunning(), d.Offers(), m.captureView.rogueDHCP)
+	}
+
 	if m.captureView.running {
 		count := 0
 		if m.captureSession != nil {
 			count = m.captureSession.GetPacketCount()
 		}
-		s += fmt.Sprintf("Packets captured: %d\n\n", count)
-		s += "Press 'x' to stop capture\n\n"
+		s += fmt.Sprintf("Packets captured: %d\n", count)
+		if m.captureSession != nil && m.captureSession.IsRingMode() {
+			s += fmt.Sprintf("Evicted from ring: %d\n", m.captureSession.GetTotalDropped())
+		}
+		if m.captureSession != nil {
+			s += renderCaptureDrops(m.styles, m.captureStats())
+			s += renderTopTalkers(m.captureSession.TopTalkers(captureTopTalkers))
+		}
+		s += "\nPress 'x' to stop capture, 'h' to toggle HTTP conversations\n\n"
 	} else {
 		s += "Commands:\n"
 		s += "  's' - Start capture (requires sudo/root)\n"
 		if m.captureSession != nil && m.captureSession.GetPacketCount() > 0 {
 			s += "  'w' - Save capture to PCAP file\n"
+			s += "  'j' - Export capture to JSON\n"
+			s += "  'e' - Export capture to CSV\n"
 		}
 		s += "  'f' - Set BPF filter\n"
+		if m.captureView.filterError != "" {
+			s += "        " + m.styles.Error.Render(m.captureView.filterError) + "\n"
+		}
+		if m.captureView.ringCapacity > 0 {
+			s += fmt.Sprintf("  'r' - Ring buffer: %d packets (oldest overwritten)\n", m.captureView.ringCapacity)
+		} else {
+			s += "  'r' - Ring buffer: off (stops at 1000 packets)\n"
+		}
+		s += "  'h' - Toggle packets / HTTP conversations\n"
+		s += "  'R' - Start/stop rogue DHCP detection\n"
 		s += "\nNote: Packet capture requires root privileges.\n\n"
+
+		if m.captureSession != nil && m.captureSession.GetPacketCount() > 0 {
+			st := m.captureStats()
+			s += renderCaptureDrops(m.styles, st)
+			s += renderCaptureStats(st)
+			s += renderLengthHistogram(m.captureSession.LengthHistogram())
+		}
+	}
+
+	if m.captureView.showHTTP {
+		var convs []capture.HTTPConversation
+		if m.captureSession != nil {
+			convs = m.captureSession.GetConversations()
+		}
+		return s + renderHTTPConversations(m.styles, convs)
 	}
 
 	// Show packet list
@@ -1600,8 +3735,8 @@ func (m Model) renderCaptureView() string {
 			p := packets[i]
 			ts := p.Timestamp.Format("15:04:05.000")
 			info := p.Info
-			if len(info) > 30 {
-				info = info[:27] + "..."
+			if len(info) > packetInfoWidth {
+				info = info[:packetInfoWidth-3] + "..."
 			}
 			s += fmt.Sprintf("[%s] %s -> %s (%s) %s\n",
 				ts, p.SourceIP, p.DestIP, p.Protocol, info)
@@ -1612,6 +3747,164 @@ func (m Model) renderCaptureView() string {
 	return s
 }
 
+// packetInfoWidth is how much of a packet's info the capture view shows,
+// enough for a DHCP lease summary
+const packetInfoWidth = 44
+
+// histogramBarWidth is the length of the longest bar in the length histogram
+const histogramBarWidth = 30
+
+// renderLengthHistogram draws packet length buckets as horizontal bars
+func renderLengthHistogram(hist map[string]int) string {
+	maxCount := 0
+	for _, n := range hist {
+		if n > maxCount {
+			maxCount = n
+		}
+	}
+	if maxCount == 0 {
+		return ""
+	}
+
+	s := "Packet Lengths:\n"
+	for _, bucket := range capture.LengthBuckets {
+		n := hist[bucket]
+		bar := strings.Repeat("█", n*histogramBarWidth/maxCount)
+		if n > 0 && bar == "" {
+			bar = "▏"
+		}
+		s += fmt.Sprintf("  %-10s %-*s %d\n", bucket, histogramBarWidth, bar, n)
+	}
+	return s + "\n"
+}
+
+// renderRogueDHCP summarizes rogue DHCP detection and lists recent rogue
+// offers
+func renderRogueDHCP(styles StyleSet, server string, running bool, offers int, rogues []capture.RogueEvent) string {
+	state := "watching"
+	if !running {
+		state = "stopped"
+	}
+	s := fmt.Sprintf("Rogue DHCP detection: %s (legitimate server %s, %d offers checked)\n", state, server, offers)
+	if len(rogues) == 0 {
+		return s + "  No rogue offers seen\n\n"
+	}
+	for _, ev := range rogues {
+		s += styles.Error.Render(fmt.Sprintf("  [%s] %s (%s) offered %s",
+			ev.Timestamp.Format("15:04:05"), ev.ServerIP, ev.ServerMAC, ev.OfferedIP)) + "\n"
+	}
+	return s + "\n"
+}
+
+// renderBackgroundStatus summarizes a background capture to rotating files
+func renderBackgroundStatus(styles StyleSet, iface string, st capture.BackgroundStatus) string {
+	state := "running"
+	if !st.Running {
+		state = "stopped"
+	}
+	s := fmt.Sprintf("Background capture on %s: %s\n", iface, state)
+	s += fmt.Sprintf("  Directory: %s\n", st.Dir)
+	if st.CurrentFile != "" {
+		s += fmt.Sprintf("  Writing:   %s\n", filepath.Base(st.CurrentFile))
+	}
+	s += fmt.Sprintf("  Packets:   %d (%s)\n", st.Packets, formatBytes(uint64(st.BytesWritten)))
+	s += fmt.Sprintf("  Files:     %d completed (keeping %d)\n", len(st.Files), st.MaxFiles)
+	if st.Err != nil {
+		s += styles.Error.Render(fmt.Sprintf("  Error: %v", st.Err)) + "\n"
+	}
+	return s + "\n"
+}
+
+// renderTopTalkers lists the IPs with the most traffic in a capture
+func renderTopTalkers(talkers []capture.TalkerSummary) string {
+	if len(talkers) == 0 {
+		return ""
+	}
+	s := "\nTop Talkers:\n"
+	s += fmt.Sprintf("  %-40s %10s %10s %8s\n", "IP", "Sent", "Received", "Packets")
+	for _, t := range talkers {
+		s += fmt.Sprintf("  %-40s %10s %10s %8d\n", t.IP, formatBytes(t.BytesSent), formatBytes(t.BytesReceived), t.PacketCount)
+	}
+	return s
+}
+
+// renderHTTPConversations lists the most recent HTTP requests and responses
+func renderHTTPConversations(styles StyleSet, convs []capture.HTTPConversation) string {
+	s := "HTTP Conversations (port 80):\n"
+	s += "──────────────────────────────────────────────────────────────\n"
+	if len(convs) == 0 {
+		s += "No HTTP conversations seen yet.\n"
+	}
+	start := len(convs) - 15
+	if start < 0 {
+		start = 0
+	}
+	for _, c := range convs[start:] {
+		req := c.RequestLine
+		if req == "" {
+			req = "(request not captured)"
+		}
+		if c.Host != "" {
+			req += " [" + c.Host + "]"
+		}
+		resp := "(no response)"
+		if c.StatusCode != 0 {
+			resp = fmt.Sprintf("%d", c.StatusCode)
+			if c.ContentType != "" {
+				resp += " " + c.ContentType
+			}
+		}
+		line := fmt.Sprintf("%s -> %s  %s  => %s", c.Client, c.Server, req, resp)
+		// Redirects from plain HTTP are the usual sign of a captive portal
+		if c.StatusCode >= 300 && c.StatusCode < 400 {
+			line = styles.Warning.Render(line)
+		}
+		s += line + "\n"
+	}
+	s += "──────────────────────────────────────────────────────────────\n"
+	return s
+}
+
+// renderCaptureStats renders the per-protocol breakdown of a capture
+// captureStats returns the capture session's stats, logging a failure to
+// read the drop counters
+func (m Model) captureStats() capture.Stats {
+	st, err := m.captureSession.GetStats()
+	if err != nil {
+		logging.Warnf("failed to read capture drop counts: %v", err)
+	}
+	return st
+}
+
+// renderCaptureDrops shows the packets lost before reaching the capture, in
+// red when any were
+func renderCaptureDrops(styles StyleSet, st capture.Stats) string {
+	return fmt.Sprintf("Dropped: %s\n", formatCounter(styles, st.Dropped()))
+}
+
+func renderCaptureStats(st capture.Stats) string {
+	total := st.Total()
+	if total == 0 {
+		return ""
+	}
+	rows := []struct {
+		name  string
+		count uint64
+	}{
+		{"TCP", st.TCP}, {"UDP", st.UDP}, {"ICMP", st.ICMP}, {"ARP", st.ARP},
+		{"DNS", st.DNS}, {"TLS", st.TLS}, {"HTTP", st.HTTP}, {"Other", st.Other},
+	}
+
+	s := "Protocol Breakdown:\n"
+	for _, r := range rows {
+		if r.count == 0 {
+			continue
+		}
+		s += fmt.Sprintf("  %-6s %7d  %5.1f%%\n", r.name, r.count, float64(r.count)*100/float64(total))
+	}
+	return s + "\n"
+}
+
 func (m Model) renderAuditView() string {
 	if m.auditView == nil {
 		return "Audit view not initialized"
@@ -1628,55 +3921,258 @@ func (m Model) renderAuditView() string {
 		s += "and enumerate open ports on discovered devices.\n\n"
 		s += "Commands:\n"
 		s += "  's' - Start audit (requires SCAN-YES consent)\n"
+		s += fmt.Sprintf("  'u' - UDP scan: %v (%s)\n", m.auditView.scanUDP, scan.FormatPorts(scan.DefaultUDPPorts))
+		s += fmt.Sprintf("  'n' - SNMP probe: %v (%s)\n", m.auditView.trySNMP, strings.Join(scan.DefaultSNMPCommunities, ", "))
+		s += "  [S] - Walk the gateway's SNMP system group\n"
+		if m.auditView.diff != nil {
+			s += "  [D] - Show changes since the previous audit\n"
+		}
 		s += "\nNote: This is a network scanning tool. Use responsibly.\n"
 	}
 
-	return s
-}
-
-func (m Model) renderSpeedtestView() string {
-	if m.speedtestView == nil {
-		return "Speedtest view not initialized"
+	if m.auditView.snmpWalking {
+		s += "\nWalking SNMP system group...\n"
+	} else if len(m.auditView.snmpVars) > 0 {
+		s += renderSNMPVars(m.auditView.snmpHost, m.auditView.snmpVars)
 	}
 
-	var s string
-	s += "═══ Speedtest ═══\n\n"
-	s += fmt.Sprintf("Status: %s\n\n", m.speedtestView.statusMessage)
-
-	if m.speedtestView.running {
-		s += "Running speedtest... This may take up to 30 seconds.\n"
-		return s
+	if m.auditView.showDiff && m.auditView.diff != nil {
+		s += renderScanDiff(m.auditView.diff)
+	} else if m.auditView.result != nil {
+		s += renderAuditResult(m.auditView.result)
 	}
 
-	if m.speedtestView.err != nil {
-		s += fmt.Sprintf("Error: %v\n\n", m.speedtestView.err)
-	}
+	return s
+}
 
-	if m.speedtestView.result != nil {
-		s += speedtest.FormatResult(m.speedtestView.result)
-		s += "\n\nPress 's' to run again."
-		if !m.speedtestView.lastRun.IsZero() {
-			s += fmt.Sprintf("\nLast run: %s", m.speedtestView.lastRun.Format("15:04:05"))
+// snmpSystemNames label the system group's scalar OIDs
+var snmpSystemNames = map[string]string{
+	"1.3.6.1.2.1.1.1.0": "sysDescr",
+	"1.3.6.1.2.1.1.2.0": "sysObjectID",
+	"1.3.6.1.2.1.1.3.0": "sysUpTime",
+	"1.3.6.1.2.1.1.4.0": "sysContact",
+	"1.3.6.1.2.1.1.5.0": "sysName",
+	"1.3.6.1.2.1.1.6.0": "sysLocation",
+	"1.3.6.1.2.1.1.7.0": "sysServices",
+}
+
+// renderSNMPVars lists the variables from an SNMP walk, naming the system
+// group's scalars
+func renderSNMPVars(host string, vars []scan.SNMPVar) string {
+	s := fmt.Sprintf("\nSNMP system group of %s:\n", host)
+	for _, v := range vars {
+		name := v.OID
+		if n, ok := snmpSystemNames[v.OID]; ok {
+			name = n
 		}
-		return s
+		value, _, _ := strings.Cut(v.Value, "\n")
+		s += fmt.Sprintf("  %-22s %s\n", name, strings.TrimSpace(value))
 	}
-
-	s += "Measure your internet connection speed using speedtest.net servers.\n\n"
-	s += "Commands:\n"
-	s += "  's' - Start speedtest\n"
-	s += "\nTests download speed, upload speed, and latency.\n"
-
 	return s
 }
 
-func (m Model) renderConsoleView() string {
-	if m.consoleView == nil {
-		return "Console view not initialized"
-	}
-
-	var s string
-	s += "═══ Serial Console ═══\n\n"
-	s += fmt.Sprintf("Status: %s\n\n", m.consoleView.statusMessage)
+// renderAuditResult lists the hosts that had open TCP or UDP ports
+func renderAuditResult(res *scan.ScanResult) string {
+	s := fmt.Sprintf("\nActive hosts: %d of %d\n", res.ActiveHosts, res.TotalHosts)
+	for _, host := range res.Hosts {
+		if len(host.Services) == 0 && len(host.UDPServices) == 0 && host.SNMP == nil {
+			continue
+		}
+		name := host.IP
+		if host.Hostname != "" {
+			name += " (" + host.Hostname + ")"
+		}
+		if host.OsHint != "" {
+			name += " [" + host.OsHint + "]"
+		}
+		s += "\n" + name + "\n"
+		if host.MAC != "" {
+			s += fmt.Sprintf("  MAC %s  %s\n", host.MAC, host.Vendor)
+		}
+		for _, svc := range host.Services {
+			s += fmt.Sprintf("  %5d/tcp %-14s %s\n", svc.Port, svc.State, svc.Service)
+			if svc.Banner != "" {
+				s += "            " + svc.Banner + "\n"
+			}
+		}
+		for _, svc := range host.UDPServices {
+			s += fmt.Sprintf("  %5d/udp %-14s %s\n", svc.Port, svc.State, svc.Service)
+		}
+		if snmp := host.SNMP; snmp != nil {
+			s += fmt.Sprintf("  SNMP (%s): %s\n", snmp.Community, snmp.SysName)
+			if descr, _, _ := strings.Cut(snmp.SysDescr, "\n"); descr != "" {
+				s += "            " + strings.TrimSpace(descr) + "\n"
+			}
+			if snmp.SysLocation != "" {
+				s += "            Location: " + snmp.SysLocation + "\n"
+			}
+		}
+	}
+	return s
+}
+
+// parsePortList reads comma or space separated TCP ports
+func parsePortList(val string) ([]int, error) {
+	fields := strings.FieldsFunc(val, func(r rune) bool { return r == ',' || r == ' ' })
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no ports given")
+	}
+	ports := make([]int, 0, len(fields))
+	for _, f := range fields {
+		port, err := strconv.Atoi(f)
+		if err != nil || port < 1 || port > 65535 {
+			return nil, fmt.Errorf("invalid port %q: must be between 1 and 65535", f)
+		}
+		ports = append(ports, port)
+	}
+	return ports, nil
+}
+
+// parseTimeout reads a diagnostics timeout as a duration ("1500ms", "10s")
+// or a bare number of milliseconds
+func parseTimeout(val string) (time.Duration, error) {
+	val = strings.TrimSpace(val)
+	timeout, err := time.ParseDuration(val)
+	if ms, convErr := strconv.Atoi(val); convErr == nil {
+		timeout, err = time.Duration(ms)*time.Millisecond, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout %q: use a duration such as 1500ms or 10s", val)
+	}
+	if err := store.ValidateTimeout(timeout); err != nil {
+		return 0, err
+	}
+	return timeout, nil
+}
+
+// editPortList adds or removes ports from a copy of current, keeping it
+// sorted and free of duplicates
+func editPortList(current, ports []int, add bool) []int {
+	set := make(map[int]bool, len(current)+len(ports))
+	for _, p := range current {
+		set[p] = true
+	}
+	for _, p := range ports {
+		set[p] = add
+	}
+	out := make([]int, 0, len(set))
+	for p, keep := range set {
+		if keep {
+			out = append(out, p)
+		}
+	}
+	sort.Ints(out)
+	return out
+}
+
+// renderScanDiff lists what changed since the previous audit
+func renderScanDiff(diff *scan.ScanDiff) string {
+	s := "\nChanges since the previous audit:\n"
+	if diff.Empty() {
+		return s + "  none\n"
+	}
+	for _, ip := range diff.NewHosts {
+		s += "  + " + ip + "\n"
+	}
+	for _, ip := range diff.GoneHosts {
+		s += "  - " + ip + "\n"
+	}
+	for _, c := range diff.PortChanges {
+		s += fmt.Sprintf("  ~ %s %d/%s %s -> %s\n", c.Host, c.Port, c.Protocol, c.OldState, c.NewState)
+	}
+	return s
+}
+
+func (m Model) renderSpeedtestView() string {
+	if m.speedtestView == nil {
+		return "Speedtest view not initialized"
+	}
+
+	var s string
+	s += "═══ Speedtest ═══\n\n"
+	s += fmt.Sprintf("Status: %s\n", m.speedtestView.statusMessage)
+	s += fmt.Sprintf("Backend: %s\n\n", speedtestBackend(m.selectedIface, m.config))
+
+	if m.speedtestView.running {
+		s += "Running speedtest... This may take up to 30 seconds.\n"
+		return s
+	}
+
+	if m.speedtestView.err != nil {
+		s += fmt.Sprintf("Error: %v\n\n", m.speedtestView.err)
+	}
+
+	if m.speedtestView.result != nil {
+		s += speedtest.FormatResult(m.speedtestView.result)
+		s += "\n" + renderSpeedtestTrend(m.speedtestView.history)
+		s += "\nPress 's' to run again."
+		if !m.speedtestView.lastRun.IsZero() {
+			s += fmt.Sprintf("\nLast run: %s", m.speedtestView.lastRun.Format("15:04:05"))
+		}
+		return s
+	}
+
+	if m.config != nil && m.config.Iperf3Server != "" {
+		s += "Measure throughput to your iperf3 server. Clear iperf3_server in the\n"
+		s += "config file to use speedtest.net instead.\n\n"
+	} else {
+		s += "Measure your internet connection speed using speedtest.net servers.\n"
+		s += "Set iperf3_server in the config file where speedtest.net is blocked.\n\n"
+	}
+	s += "Commands:\n"
+	s += "  's' - Start speedtest\n"
+	s += "\nTests download speed, upload speed, and latency.\n"
+	s += renderSpeedtestTrend(m.speedtestView.history)
+
+	return s
+}
+
+// speedtestHistoryLen is how many past runs the speedtest view charts
+const speedtestHistoryLen = 10
+
+// renderSpeedtestTrend charts the download speed of past runs, or returns
+// nothing until there are two runs to compare
+func renderSpeedtestTrend(history []*speedtest.Result) string {
+	if len(history) < 2 {
+		return ""
+	}
+	speeds := make([]float64, len(history))
+	lo, hi := history[0].DownloadMbps, history[0].DownloadMbps
+	for i, r := range history {
+		speeds[i] = r.DownloadMbps
+		if r.DownloadMbps < lo {
+			lo = r.DownloadMbps
+		}
+		if r.DownloadMbps > hi {
+			hi = r.DownloadMbps
+		}
+	}
+	return fmt.Sprintf("\nDownload (last %d runs): %s  min %.1f / max %.1f Mbps\n", len(speeds), speedSparkline(speeds, lo, hi), lo, hi)
+}
+
+// speedSparkline renders values as Unicode block characters scaled between
+// lo and hi
+func speedSparkline(values []float64, lo, hi float64) string {
+	blocks := []rune("▁▂▃▄▅▆▇█")
+	var b strings.Builder
+	for _, v := range values {
+		idx := len(blocks) / 2
+		if hi > lo {
+			idx = int((v - lo) / (hi - lo) * float64(len(blocks)-1))
+		}
+		b.WriteRune(blocks[idx])
+	}
+	return b.String()
+}
+
+func (m Model) renderConsoleView() string {
+	if m.consoleView == nil {
+		return "Console view not initialized"
+	}
+
+	var s string
+	s += "═══ Serial Console ═══\n\n"
+	s += fmt.Sprintf("Status: %s\n\n", m.consoleView.statusMessage)
 
 	if fp := m.consoleView.fingerprint; fp != nil {
 		stage := formatStageLabel(fp.Stage)
@@ -1690,6 +4186,9 @@ func (m Model) renderConsoleView() string {
 		if fp.Prompt != "" {
 			s += fmt.Sprintf("Prompt: %s\n", fp.Prompt)
 		}
+		if fp.SerialNumber != "" {
+			s += fmt.Sprintf("Serial: %s\n", fp.SerialNumber)
+		}
 		if len(fp.Evidence) > 0 {
 			s += "Evidence:\n"
 			for _, ev := range fp.Evidence {
@@ -1705,22 +4204,38 @@ func (m Model) renderConsoleView() string {
 		s += "\n"
 	}
 
-	if m.consoleView.session != nil {
+	sessions := m.consoleView.manager.List()
+	if sess, ok := m.consoleView.session.(console.Terminal); ok {
 		// Active session view
+		s += renderConsoleSessions(sessions, sess.ID(), m.consoleView.fingerprints)
 		s += "Console Output:\n"
 		s += "───────────────────────────────────────────────────\n"
 
 		// Show last 20 lines of buffer
-		start := len(m.consoleView.buffer) - 20
+		buffer := m.consoleView.buffers[sess.ID()]
+		start := len(buffer) - 20
 		if start < 0 {
 			start = 0
 		}
-		for i := start; i < len(m.consoleView.buffer); i++ {
-			s += m.consoleView.buffer[i] + "\n"
+		for i := start; i < len(buffer); i++ {
+			s += buffer[i] + "\n"
 		}
 
 		s += "───────────────────────────────────────────────────\n\n"
 
+		if tr := m.consoleView.transfer; tr != nil {
+			s += renderConsoleTransfer(tr.path, tr.sent.Load(), tr.total) + "\n\n"
+		}
+		if rec := m.consoleView.recorder; rec != nil {
+			s += fmt.Sprintf("● Recording macro %s (press 'm' to stop)\n\n", rec.Name())
+		}
+		if m.consoleView.showMacros {
+			s += renderMacroList(m.consoleView.macros, m.consoleView.selectedMacro) + "\n"
+		}
+		if b := m.consoleView.baseline; b != nil && b.port == sess.PortPath() {
+			s += renderBaselineDiff(m.styles, b.key, b.diff, b.first) + "\n"
+		}
+
 		// Control status
 		s += fmt.Sprintf("DTR: %v | RTS: %v | Logging: %v\n\n",
 			m.consoleView.dtrState,
@@ -1729,12 +4244,14 @@ func (m Model) renderConsoleView() string {
 
 		s += "Commands:\n"
 		s += "  'b' - Send BREAK  'd' - Toggle DTR  'r' - Toggle RTS\n"
-		s += "  't' - Toggle logging  'x' - Close session\n"
+		s += "  't' - Toggle logging  'x' - Close session  'tab' - Next session\n"
+		s += "  'u' - Send file (YMODEM)  'm' - Macros  'B' - Baseline diff\n"
 		s += "  'P' - Run safe probe on current fingerprint\n"
 		s += fmt.Sprintf("  '[%s]' Allow safe probe in config mode (press 'A')\n",
 			boolMarker(m.consoleView.allowProbeInConfigMode))
 	} else {
 		// Port selection view
+		s += renderConsoleSessions(sessions, "", m.consoleView.fingerprints)
 		s += "Discovered Serial Ports:\n"
 
 		if len(m.consoleView.ports) == 0 {
@@ -1757,6 +4274,9 @@ func (m Model) renderConsoleView() string {
 			s += "  'p' - Probe selected port\n"
 			s += "  'enter' - Open session\n"
 			s += "  'f' - Refresh ports\n"
+			if len(sessions) > 0 {
+				s += "  'tab' - Switch to next open session\n"
+			}
 			s += fmt.Sprintf("  '[%s]' Allow safe probe in config mode (press 'A')\n",
 				boolMarker(m.consoleView.allowProbeInConfigMode))
 		}
@@ -1765,6 +4285,112 @@ func (m Model) renderConsoleView() string {
 	return s
 }
 
+// renderConsoleTransfer shows the progress of a file upload
+func renderConsoleTransfer(path string, sent, total int64) string {
+	pct := 100
+	if total > 0 {
+		pct = int(sent * 100 / total)
+	}
+	return fmt.Sprintf("YMODEM: %s  %s / %s (%d%%)", filepath.Base(path), formatBytes(uint64(sent)), formatBytes(uint64(total)), pct)
+}
+
+// formatReconnectStatus describes a session's reconnect progress
+func formatReconnectStatus(id string, ev console.ReconnectEvent) string {
+	switch {
+	case ev.GaveUp:
+		return fmt.Sprintf("%s: reconnect failed after %d attempts; press 'x' to close", id, ev.Max)
+	case ev.Connected:
+		return fmt.Sprintf("%s: reconnected", id)
+	default:
+		return fmt.Sprintf("%s: Reconnecting (attempt %d/%d)...", id, ev.Attempt, ev.Max)
+	}
+}
+
+// formatConsoleAlert renders an alert match as a console buffer line
+func formatConsoleAlert(at time.Time, name, match string) string {
+	return fmt.Sprintf("⚠ %s [%s] %s", at.Format("15:04:05"), name, match)
+}
+
+// maxBaselineDiffLines caps the changed lines shown under the console
+const maxBaselineDiffLines = 20
+
+// renderBaselineDiff shows the lines that changed since the previous
+// baseline, added in green and removed in red
+func renderBaselineDiff(styles StyleSet, key string, diff []console.DiffLine, first bool) string {
+	if first {
+		return fmt.Sprintf("Baseline %s saved. Press 'B' again later to see what changed.\n", key)
+	}
+
+	added := styles.Success
+	removed := styles.Error
+
+	var lines []string
+	var nAdded, nRemoved int
+	for _, line := range diff {
+		switch line.Op {
+		case console.DiffAdded:
+			nAdded++
+			lines = append(lines, added.Render("+ "+line.Text))
+		case console.DiffRemoved:
+			nRemoved++
+			lines = append(lines, removed.Render("- "+line.Text))
+		}
+	}
+
+	s := fmt.Sprintf("Baseline %s: %d added, %d removed\n", key, nAdded, nRemoved)
+	if len(lines) == 0 {
+		return s + "  No changes since the last baseline\n"
+	}
+	if len(lines) > maxBaselineDiffLines {
+		more := len(lines) - maxBaselineDiffLines
+		lines = append(lines[:maxBaselineDiffLines], fmt.Sprintf("... %d more", more))
+	}
+	for _, line := range lines {
+		s += "  " + line + "\n"
+	}
+	return s
+}
+
+// renderMacroList shows the saved macros with the selected one marked
+func renderMacroList(names []string, selected int) string {
+	s := "Macros:\n"
+	if len(names) == 0 {
+		s += "  No saved macros\n"
+	}
+	for i, name := range names {
+		marker := " "
+		if i == selected {
+			marker = ">"
+		}
+		s += fmt.Sprintf(" %s %s\n", marker, name)
+	}
+	s += "  'enter' - Run  'r' - Record new  'm'/'esc' - Close\n"
+	return s
+}
+
+// renderConsoleSessions lists the open console sessions with their byte
+// counters and fingerprint, marking the focused one
+func renderConsoleSessions(sessions []console.Terminal, focused string, fps map[string]*fingerprint.Result) string {
+	if len(sessions) == 0 {
+		return ""
+	}
+
+	s := fmt.Sprintf("Open Sessions (%d):\n", len(sessions))
+	for _, sess := range sessions {
+		marker := " "
+		if sess.ID() == focused {
+			marker = ">"
+		}
+		read, written, _ := sess.GetStats()
+		line := fmt.Sprintf(" %s %s  RX %s  TX %s", marker, sess.ID(), formatBytes(read), formatBytes(written))
+		if fp := fps[sess.PortPath()]; fp != nil {
+			line += fmt.Sprintf("  %s / %s", fp.Vendor, fp.OS)
+		}
+		s += line + "\n"
+	}
+	return s + "\n"
+}
+
 func formatStageLabel(stage fingerprint.Stage) string {
 	switch stage {
 	case fingerprint.StagePreLogin:
@@ -1794,12 +4420,9 @@ func runDiagnosticsCmd(iface string, timeout time.Duration, cfg *store.Config) t
 			cfg = store.DefaultConfig()
 		}
 		if timeout <= 0 {
-			if cfg.DiagnosticsTimeout > 0 {
-				timeout = time.Duration(cfg.DiagnosticsTimeout) * time.Millisecond
-			} else {
-				timeout = 5 * time.Second
-			}
+			timeout = cfg.DiagnosticsTimeoutDuration()
 		}
+		timeout += diagnostics.OptionalTimeout(cfg)
 		ctx, cancel := context.WithTimeout(context.Background(), timeout)
 		defer cancel()
 
@@ -1813,18 +4436,103 @@ func runDiagnosticsCmd(iface string, timeout time.Duration, cfg *store.Config) t
 		if err != nil {
 			logging.Errorf("Diagnostics run error: %v", err)
 		}
-		return diagnoseResultMsg{res: res, err: err}
+
+		if res != nil {
+			if err := store.SaveDiagnosticResult(iface, res); err != nil {
+				logging.Warnf("Diagnostics history save failed: %v", err)
+			}
+			// The result is shown without waiting for the webhook
+			go func() {
+				if _, err := webhook.NotifyIfFailed(cfg, res); err != nil {
+					logging.Warnf("Diagnostics webhook failed: %v", err)
+				}
+			}()
+		}
+		history, herr := store.LoadDiagnosticHistory[diagnostics.Result](iface, diagHistoryLen)
+		if herr != nil {
+			logging.Warnf("Diagnostics history load failed: %v", herr)
+		}
+		return diagnoseResultMsg{res: res, history: history, err: err}
+	}
+}
+
+func runVLANCmd(phy, rangeStr string, keep bool, token string) tea.Cmd {
+	return func() tea.Msg {
+		results, err := vlan.TestVLANRange(context.Background(), phy, rangeStr, keep, token)
+		return vlanResultMsg{results: results, err: err}
+	}
+}
+
+// runSnapshotCmd saves a snapshot of iface, encrypted with passphrase if it
+// isn't empty
+func runSnapshotCmd(iface, passphrase string) tea.Cmd {
+	return func() tea.Msg {
+		snap, err := buildSnapshot(context.Background(), iface)
+		if err != nil {
+			return snapshotResultMsg{err: err}
+		}
+		var path string
+		if passphrase != "" {
+			path, err = store.SaveEncryptedSnapshot(snap, passphrase)
+		} else {
+			path, err = store.SaveSnapshot(snap)
+		}
+		return snapshotResultMsg{path: path, err: err}
+	}
+}
+
+func runVLANDiscoverCmd(iface string, duration time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		vlans, err := vlan.DiscoverVLANs(iface, duration)
+		return vlanDiscoverMsg{vlans: vlans, err: err}
+	}
+}
+
+// speedtestTimeout bounds a speedtest.net run, which downloads and uploads
+// for about 15 seconds each
+const speedtestTimeout = 90 * time.Second
+
+// speedtestBackend names the server a speedtest will use
+func speedtestBackend(iface string, cfg *store.Config) string {
+	if cfg == nil || cfg.Iperf3Server == "" {
+		if iface != "" {
+			return fmt.Sprintf("%s (via %s)", speedtest.BackendSpeedtestNet, iface)
+		}
+		return speedtest.BackendSpeedtestNet
 	}
+	port := cfg.Iperf3Port
+	if port <= 0 {
+		port = speedtest.DefaultIperf3Port
+	}
+	return fmt.Sprintf("%s (%s:%d)", speedtest.BackendIperf3, cfg.Iperf3Server, port)
 }
 
-func runSpeedtestCmd() tea.Cmd {
+func runSpeedtestCmd(iface string, cfg *store.Config) tea.Cmd {
 	return func() tea.Msg {
 		logging.Infof("Speedtest command started")
-		res, err := speedtest.Run()
+		var res *speedtest.Result
+		var err error
+		switch {
+		case cfg != nil && cfg.Iperf3Server != "":
+			res, err = speedtest.RunIperf3(context.Background(), cfg.Iperf3Server, cfg.Iperf3Port, speedtest.DefaultIperf3Duration)
+		case iface != "":
+			res, err = speedtest.RunBound(context.Background(), iface, speedtestTimeout)
+		default:
+			res, err = speedtest.Run()
+		}
 		if err != nil {
 			logging.Errorf("Speedtest error: %v", err)
+			return speedtestResultMsg{err: err}
+		}
+
+		if serr := store.SaveSpeedtestResult(res); serr != nil {
+			logging.Warnf("Speedtest history save failed: %v", serr)
+		}
+		history, herr := store.LoadSpeedtestHistory(speedtestHistoryLen)
+		if herr != nil {
+			logging.Warnf("Speedtest history load failed: %v", herr)
 		}
-		return speedtestResultMsg{res: res, err: err}
+		return speedtestResultMsg{res: res, history: history}
 	}
 }
 
@@ -1845,9 +4553,42 @@ func (m Model) renderStatus() string {
 	status := fmt.Sprintf("Layer: %s | Interface: %s%s | %s | esc/q: back",
 		layer, m.selectedIface, rootStatus, m.statusMsg)
 
-	return lipgloss.NewStyle().
-		Foreground(lipgloss.Color("240")).
-		Render(status)
+	return m.styles.Status.Render(status)
+}
+
+// applyInterfaceUpdate replaces the picker list, keeping the cursor on the
+// same interface when it is still present
+func (m *Model) applyInterfaceUpdate(ifaces []netpkg.Iface) {
+	current := ""
+	if visible := m.visibleInterfaces(); m.selectedIndex >= 0 && m.selectedIndex < len(visible) {
+		current = visible[m.selectedIndex].Name
+	}
+
+	m.interfaces = ifaces
+	m.selectedIndex = 0
+	m.scrollOffset = 0
+	for i, iface := range m.visibleInterfaces() {
+		if iface.Name == current {
+			m.selectedIndex = i
+			break
+		}
+	}
+	m.scrollToCursor()
+
+	logging.Infof("interface list updated: %d interfaces", len(ifaces))
+	if m.layer == LayerInterface {
+		m.statusMsg = fmt.Sprintf("Interfaces changed (%d available)", len(ifaces))
+	}
+}
+
+// startInterfaceWatch subscribes the model to hotplug notifications
+func (m *Model) startInterfaceWatch(ctx context.Context) {
+	ch, err := netpkg.WatchInterfaces(ctx)
+	if err != nil {
+		logging.Warnf("interface watching unavailable: %v", err)
+		return
+	}
+	m.ifaceUpdates = ch
 }
 
 // NewModel creates a new TUI model
@@ -1858,6 +4599,15 @@ func NewModel() (*Model, error) {
 		config = store.DefaultConfig()
 	}
 
+	// Apply the snapshot retention policy; a failure only costs disk space
+	if config.RetentionEnabled() {
+		if deleted, err := store.PruneSnapshots(); err != nil {
+			logging.Warnf("NewModel: snapshot pruning failed: %v", err)
+		} else if deleted > 0 {
+			logging.Infof("NewModel: pruned %d old snapshots", deleted)
+		}
+	}
+
 	// List user-friendly interfaces (filtered)
 	ifaces, err := netpkg.ListUserInterfaces()
 	if err != nil {
@@ -1868,15 +4618,39 @@ func NewModel() (*Model, error) {
 		return nil, fmt.Errorf("no suitable network interfaces found")
 	}
 
-	return &Model{
+	model := &Model{
 		mode:          ViewPicker,
 		interfaces:    ifaces,
 		selectedIndex: 0,
 		modeIndex:     0,
+		hoverIndex:    -1,
 		layer:         LayerInterface,
 		config:        config,
+		styles:        themeStyles(config),
 		statusMsg:     "Select an interface to begin",
-	}, nil
+	}
+
+	if config.RememberInterface {
+		last, err := store.LoadLastInterface()
+		if err != nil {
+			logging.Warnf("NewModel: failed to load last interface: %v", err)
+		}
+		model.preselectInterface(last)
+	}
+	return model, nil
+}
+
+// preselectInterface puts the picker cursor on the named interface, or on
+// the first one if it is gone
+func (m *Model) preselectInterface(name string) {
+	m.selectedIndex = 0
+	for i, iface := range m.visibleInterfaces() {
+		if iface.Name == name {
+			m.selectedIndex = i
+			break
+		}
+	}
+	m.scrollToCursor()
 }
 
 // Run starts the TUI application
@@ -1886,7 +4660,48 @@ func Run() error {
 		return err
 	}
 
-	p := tea.NewProgram(model, tea.WithAltScreen())
+	return runProgram(model)
+}
+
+// RunWithCapture starts TUI in the capture view with a saved pcap file loaded.
+// ifaceName is used for any new capture; if empty the first interface is used.
+func RunWithCapture(path, ifaceName string) error {
+	model, err := NewModel()
+	if err != nil {
+		return err
+	}
+
+	session, err := capture.OpenPCAP(path)
+	if err != nil {
+		return err
+	}
+
+	if ifaceName == "" {
+		ifaceName = model.interfaces[0].Name
+	}
+	model.selectedIface = ifaceName
+	model.captureSession = session
+	model.mode = ViewCapture
+	model.layer = LayerView
+	model.captureView = &CaptureView{
+		statusMessage: fmt.Sprintf("Loaded %d packets from %s", session.GetPacketCount(), path),
+	}
+	model.statusMsg = "Packet Capture"
+
+	return runProgram(model)
+}
+
+// runProgram runs the TUI until the user quits
+func runProgram(model *Model) error {
+	// Debug messages copied to stderr would draw over the screen
+	logging.SetStderr(nil)
+	defer logging.SetStderr(os.Stderr)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	model.startInterfaceWatch(ctx)
+
+	p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithMouseAllMotion())
 
 	// Panic recovery
 	defer func() {
@@ -1898,7 +4713,7 @@ func Run() error {
 		}
 	}()
 
-	_, err = p.Run()
+	_, err := p.Run()
 	return err
 }
 
@@ -1936,140 +4751,370 @@ func RunWithInterface(ifaceName string) error {
 		lastUpdate:  time.Now(),
 		autoRefresh: true,
 	}
+	model.detailsView.startRateMonitor(ifaceName)
 
-	p := tea.NewProgram(model, tea.WithAltScreen())
-
-	// Panic recovery
-	defer func() {
-		if r := recover(); r != nil {
-			p.ReleaseTerminal()
-			fmt.Printf("LanAudit crashed: %v\n", r)
-			logging.Errorf("PANIC: %v", r)
-			os.Exit(1)
-		}
-	}()
-
-	_, err = p.Run()
-	return err
+	return runProgram(model)
 }
 
-// RunHeadless prints diagnostics in JSON format
-func RunHeadless(ctx context.Context, ifaceName string) error {
-	details, err := netpkg.GetInterfaceDetails(ifaceName)
+// RunWithAutoInterface starts the TUI with ifaceName already picked, at the
+// mode menu (the --auto-iface flag)
+func RunWithAutoInterface(ifaceName string) error {
+	model, err := NewModel()
 	if err != nil {
 		return err
 	}
-
-	_, err = store.LoadConfig()
-	if err != nil {
-		_ = store.DefaultConfig()
+	for i, iface := range model.visibleInterfaces() {
+		if iface.Name == ifaceName {
+			*model = model.selectInterface(i)
+			model.statusMsg = fmt.Sprintf("Auto-selected %s; select a mode", ifaceName)
+			return runProgram(model)
+		}
 	}
-
-	// This would run diagnostics and print JSON
-	// For now, just print details
-	fmt.Printf("Interface: %s\n", details.Name)
-	fmt.Printf("IPs: %v\n", details.IPs)
-	fmt.Printf("Gateway: %s\n", details.DefaultGateway)
-
-	return nil
+	return fmt.Errorf("interface %s not found", ifaceName)
 }
 
 func getExtendedDetailsCmd(iface string) tea.Cmd {
 	return func() tea.Msg {
 		speed, ifaceType, err := netpkg.GetExtendedInterfaceDetails(iface)
-		return extendedDetailsMsg{speed: speed, ifaceType: ifaceType, err: err}
+		if err != nil {
+			return extendedDetailsMsg{speed: speed, ifaceType: ifaceType, err: err}
+		}
+
+		wifi, wifiErr := netpkg.GetWifiInfo(iface)
+		if wifiErr != nil {
+			logging.Debugf("no wi-fi details for %s: %v", iface, wifiErr)
+			wifi = nil
+		} else {
+			ifaceType = "Wi-Fi"
+			if (speed == "" || speed == "Unknown") && wifi.TxRate > 0 {
+				speed = fmt.Sprintf("%.0f Mbps", wifi.TxRate)
+			}
+		}
+		return extendedDetailsMsg{speed: speed, ifaceType: ifaceType, wifi: wifi}
 	}
 }
 
-func startCaptureCmd(iface, filter string) tea.Cmd {
+func startCaptureCmd(iface, filter string, ringCapacity int) tea.Cmd {
 	return func() tea.Msg {
 		if !netpkg.HasPcapPermissions() {
 			return startCaptureMsg{err: fmt.Errorf("root/sudo permissions required for packet capture")}
 		}
-		_, err := capture.Start(iface, filter, 1000) // Limit to 1000 packets for TUI safety
+		opts := capture.StartOptions{RingMode: ringCapacity > 0, RingCapacity: ringCapacity}
+		_, err := capture.Start(iface, filter, 1000, opts) // Limit to 1000 packets for TUI safety
 		return startCaptureMsg{err: err}
 	}
 }
 
-func stopCaptureCmd() tea.Cmd {
+func startARPMonitorCmd(iface string, threshold int) tea.Cmd {
 	return func() tea.Msg {
-		err := capture.StopCurrentSession()
-		return stopCaptureMsg{err: err}
+		if !netpkg.HasPcapPermissions() {
+			return startARPMonitorMsg{err: fmt.Errorf("root/sudo permissions required for ARP monitoring")}
+		}
+		events, err := capture.StartARPMonitor(iface, threshold)
+		return startARPMonitorMsg{events: events, err: err}
 	}
 }
 
-func saveCaptureCmd(filename string) tea.Cmd {
+func startRogueDHCPCmd(iface, server string) tea.Cmd {
 	return func() tea.Msg {
-		session := capture.GetCurrentSession()
-		if session == nil {
-			return saveCaptureMsg{filename: filename, err: fmt.Errorf("no active session")}
+		if !netpkg.HasPcapPermissions() {
+			return startRogueDHCPMsg{err: fmt.Errorf("root/sudo permissions required for rogue DHCP detection")}
 		}
-		err := session.SaveToPCAP(filename)
-		return saveCaptureMsg{filename: filename, err: err}
+		detector, err := capture.StartRogueDHCPDetector(iface, server)
+		return startRogueDHCPMsg{detector: detector, err: err}
 	}
 }
 
-func runAuditCmd(gateway string) tea.Cmd {
+func startDNSLogCmd(iface string) tea.Cmd {
 	return func() tea.Msg {
-		if gateway == "" {
-			return auditResultMsg{err: fmt.Errorf("no gateway configured")}
+		if !netpkg.HasPcapPermissions() {
+			return startDNSLogMsg{err: fmt.Errorf("root/sudo permissions required for DNS logging")}
 		}
-		// Use real audit with fast timeout (500ms per host)
-		res, err := scan.AuditGateway(gateway, nil, 500*time.Millisecond)
-		return auditResultMsg{result: res, err: err}
+		logger, err := capture.StartDNSLogger(iface)
+		return startDNSLogMsg{logger: logger, err: err}
 	}
 }
 
-func runLLDPCmd(iface string, duration time.Duration) tea.Cmd {
+func stopCaptureCmd() tea.Cmd {
 	return func() tea.Msg {
-		neighbors, err := netpkg.DiscoverLLDP(iface, duration)
+		err := capture.StopCurrentSession()
+		return stopCaptureMsg{err: err}
+	}
+}
+
+func saveCaptureCmd(filename string) tea.Cmd {
+	return func() tea.Msg {
+		session := capture.GetCurrentSession()
+		if session == nil {
+			return saveCaptureMsg{filename: filename, err: fmt.Errorf("no active session")}
+		}
+		var err error
+		switch {
+		case strings.HasSuffix(filename, ".json"):
+			err = session.ExportJSON(filename)
+		case strings.HasSuffix(filename, ".csv"):
+			err = session.ExportCSV(filename)
+		default:
+			err = session.SaveToPCAP(filename)
+		}
+		return saveCaptureMsg{filename: filename, err: err}
+	}
+}
+
+// exportCapture writes the stopped capture session as JSON or CSV
+func (m Model) exportCapture(ext string) (tea.Model, tea.Cmd) {
+	if m.captureView.running {
+		m.statusMsg = "Stop the capture before exporting"
+		return m, nil
+	}
+	if m.captureSession == nil || m.captureSession.GetPacketCount() == 0 {
+		m.captureView.statusMessage = "No packets to export"
+		return m, nil
+	}
+	filename := fmt.Sprintf("capture_%s.%s", time.Now().Format("20060102_150405"), ext)
+	m.captureView.statusMessage = fmt.Sprintf("Exporting to %s...", filename)
+	return m, saveCaptureCmd(filename)
+}
+
+func runSNMPWalkCmd(host, community string) tea.Cmd {
+	return func() tea.Msg {
+		vars, err := scan.SNMPWalk(host, community, scan.SNMPSystemOID, 2*time.Second)
+		return snmpWalkResultMsg{host: host, vars: vars, err: err}
+	}
+}
+
+func runAuditCmd(gateway string, config scan.ScanConfig) tea.Cmd {
+	return func() tea.Msg {
+		if gateway == "" {
+			return auditResultMsg{err: fmt.Errorf("no gateway configured")}
+		}
+		prev, err := store.LoadLastScanResult(gateway)
+		if err != nil {
+			logging.Warnf("audit: failed to load previous result: %v", err)
+		}
+
+		res, err := scan.AuditGateway(gateway, "", config)
+		if err != nil {
+			return auditResultMsg{err: err}
+		}
+		if err := store.SaveScanResult(res); err != nil {
+			logging.Warnf("audit: failed to save result: %v", err)
+		}
+
+		msg := auditResultMsg{result: res}
+		if prev != nil {
+			diff := scan.DiffResults(prev, res)
+			msg.diff = &diff
+		}
+		return msg
+	}
+}
+
+func runLLDPCmd(iface string, duration time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		neighbors, err := netpkg.DiscoverLLDP(iface, duration)
 		return lldpResultMsg{neighbors: neighbors, err: err}
 	}
 }
 
-func discoverPortsCmd() tea.Cmd {
+// promptWoL asks for the MAC to wake, optionally followed by a broadcast
+// address
+func (m Model) promptWoL() Model {
+	m.inputActive = true
+	m.inputPrompt = "MAC to wake [broadcast address]: "
+	m.inputValue = m.wolView.target
+	m.inputSubmit = func(m *Model, val string) tea.Cmd {
+		fields := strings.Fields(val)
+		if len(fields) == 0 || len(fields) > 2 {
+			m.wolView.statusMessage = "Enter a MAC address, optionally followed by a broadcast address"
+			return nil
+		}
+		mac, broadcast := fields[0], ""
+		if len(fields) == 2 {
+			broadcast = fields[1]
+		}
+		m.wolView.target = strings.Join(fields, " ")
+		m.wolView.sending = true
+		m.wolView.statusMessage = fmt.Sprintf("Sending magic packet for %s...", mac)
+		return sendWoLCmd(mac, broadcast)
+	}
+	return m
+}
+
+func sendWoLCmd(mac, broadcast string) tea.Cmd {
+	return func() tea.Msg {
+		err := netpkg.SendWakeOnLAN(mac, broadcast)
+		return wolResultMsg{mac: mac, broadcast: broadcast, err: err}
+	}
+}
+
+func runSTPCmd(iface string, duration time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		frames, err := netpkg.ListenSTP(iface, duration)
+		return stpResultMsg{frames: frames, err: err}
+	}
+}
+
+func runMDNSCmd(duration time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		entries, err := mdns.Discover(context.Background(), "", duration)
+		return mdnsResultMsg{entries: entries, err: err}
+	}
+}
+
+func runCDPCmd(iface string, duration time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		neighbors, err := netpkg.DiscoverCDP(iface, duration)
+		return cdpResultMsg{neighbors: neighbors, err: err}
+	}
+}
+
+func runARPScanCmd(iface, cidr string, timeout time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		hosts, err := arpscan.Scan(iface, cidr, timeout)
+		return arpScanResultMsg{cidr: cidr, hosts: hosts, err: err}
+	}
+}
+
+// telnetTargets returns the console server ports from the config
+func (m Model) telnetTargets() []string {
+	if m.config == nil {
+		return nil
+	}
+	return m.config.Console.TelnetTargets
+}
+
+// sessionConfig returns the console session settings for a port, applying
+// the saved console preferences
+func (m Model) sessionConfig(path string, baud int) console.SessionConfig {
+	cfg := console.DefaultSessionConfig(path, baud)
+	if m.config != nil {
+		cfg.StripANSI = m.config.Console.StripANSI
+		cfg.IdleTimeout = time.Duration(m.config.Console.IdleTimeoutMs) * time.Millisecond
+		cfg.MaxReconnects = m.config.Console.MaxReconnects
+	}
+	return cfg
+}
+
+// fingerprintConfig returns the saved fingerprint thresholds
+func (m Model) fingerprintConfig() fingerprint.Config {
+	cfg := fingerprint.DefaultConfig()
+	if m.config != nil {
+		cfg.MinProbeConfidence = m.config.Fingerprint.MinProbeConfidence
+		cfg.MaxEvidence = m.config.Fingerprint.MaxEvidence
+	}
+	return cfg
+}
+
+// discoverPortsCmd lists local serial ports followed by the configured
+// Telnet console server ports
+func discoverPortsCmd(telnetTargets []string) tea.Cmd {
 	return func() tea.Msg {
 		ports, err := console.DiscoverPorts()
-		return consolePortsMsg{ports: ports, err: err}
+		if err != nil {
+			return consolePortsMsg{err: err}
+		}
+		return consolePortsMsg{ports: append(ports, console.TelnetPorts(telnetTargets)...)}
+	}
+}
+
+func openConsoleSessionCmd(ctx context.Context, mgr *console.SessionManager, cfg console.SessionConfig) tea.Cmd {
+	return func() tea.Msg {
+		sess, err := mgr.Open(ctx, cfg)
+		if err != nil {
+			return consoleSessionMsg{err: err}
+		}
+		return consoleSessionMsg{session: sess}
 	}
 }
 
-func openConsoleSessionCmd(ctx context.Context, port string, baud int) tea.Cmd {
+func openTelnetSessionCmd(ctx context.Context, mgr *console.SessionManager, cfg console.SessionConfig) tea.Cmd {
 	return func() tea.Msg {
-		cfg := console.DefaultSessionConfig(port, baud)
-		sess, err := console.NewSession(ctx, cfg)
-		return consoleSessionMsg{session: sess, err: err}
+		host, port, err := console.ParseTelnetTarget(cfg.PortPath)
+		if err != nil {
+			return consoleSessionMsg{err: err}
+		}
+		sess, err := mgr.OpenTelnet(ctx, host, port, cfg)
+		if err != nil {
+			return consoleSessionMsg{err: err}
+		}
+		return consoleSessionMsg{session: sess}
 	}
 }
 
-func closeConsoleSessionCmd(sess *console.Session) tea.Cmd {
+func closeConsoleSessionCmd(mgr *console.SessionManager, id string) tea.Cmd {
 	return func() tea.Msg {
-		sess.Close()
+		if err := mgr.Close(id); err != nil {
+			logging.Warnf("failed to close console session %s: %v", id, err)
+		}
 		return nil
 	}
 }
 
-func probePortCmd(ctx context.Context, port string) tea.Cmd {
+func probePortCmd(ctx context.Context, port string, fp fingerprint.Config) tea.Cmd {
 	return func() tea.Msg {
-		res := console.QuickProbe(port)
-		return consoleProbeMsg{result: res}
+		ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+		defer cancel()
+		cfg := console.DefaultProbeConfig()
+		cfg.Fingerprint = fp
+		res := console.ProbePort(ctx, port, cfg)
+		return consoleProbeMsg{port: port, result: res}
 	}
 }
 
-func readConsoleDataCmd(sess *console.Session) tea.Cmd {
+// readConsoleDataCmd waits briefly for data from sess. It always reports back
+// so that every open session keeps being polled, even while not focused.
+func readConsoleDataCmd(sess console.Terminal) tea.Cmd {
 	return func() tea.Msg {
 		select {
 		case data := <-sess.ReadChan():
-			return consoleDataMsg{data: data}
+			return consoleDataMsg{id: sess.ID(), data: data}
 		case err := <-sess.ErrorChan():
+			var ev *console.ReconnectEvent
+			if errors.As(err, &ev) {
+				return consoleReconnectMsg{id: sess.ID(), event: *ev}
+			}
 			return err
 		case <-time.After(100 * time.Millisecond):
-			return nil
+			return consoleDataMsg{id: sess.ID()}
+		}
+	}
+}
+
+func sendYMODEMCmd(sess *console.Session, tr *consoleTransfer) tea.Cmd {
+	return func() tea.Msg {
+		err := console.SendYMODEM(sess, []string{tr.path}, func(sent int) {
+			tr.sent.Store(int64(sent))
+		})
+		return consoleTransferMsg{path: tr.path, err: err}
+	}
+}
+
+func captureBaselineCmd(sess *console.Session, probe *fingerprint.SafeProbe) tea.Cmd {
+	return func() tea.Msg {
+		current, err := console.CaptureBaseline(sess, probe)
+		if err != nil {
+			return consoleBaselineMsg{port: sess.PortPath(), err: err}
+		}
+		key := console.BaselineKey(probe, current)
+		previous, err := console.LoadPreviousBaseline(key)
+		if errors.Is(err, os.ErrNotExist) {
+			return consoleBaselineMsg{port: sess.PortPath(), key: key, first: true}
+		}
+		if err != nil {
+			return consoleBaselineMsg{port: sess.PortPath(), err: err}
 		}
+		return consoleBaselineMsg{port: sess.PortPath(), key: key, diff: console.DiffBaseline(current, previous)}
 	}
 }
 
-func sendConsoleDataCmd(sess *console.Session, data []byte) tea.Cmd {
+func playMacroCmd(sess *console.Session, macro console.Macro, secrets []string) tea.Cmd {
+	return func() tea.Msg {
+		return consoleMacroMsg{name: macro.Name, err: console.PlayMacro(sess, macro, secrets)}
+	}
+}
+
+func sendConsoleDataCmd(sess console.Terminal, data []byte) tea.Cmd {
 	return func() tea.Msg {
 		_, err := sess.Write(data)
 		if err != nil {
@@ -2079,51 +5124,570 @@ func sendConsoleDataCmd(sess *console.Session, data []byte) tea.Cmd {
 	}
 }
 
+// consoleSessionFocused reports whether keys are going to a console session
+func (m Model) consoleSessionFocused() bool {
+	return m.mode == ViewConsole && m.layer == LayerView && m.consoleView != nil &&
+		m.consoleView.session != nil && m.consoleView.transfer == nil
+}
+
+// KeyBinding documents a key for the help overlay
+type KeyBinding struct {
+	Layer string // "Any", "Interface", "Mode" or a view title
+	Key   string
+	Desc  string
+}
+
+// keyBindings lists the keys handleKeys and handleMacroKeys act on, layer
+// keys first and then each view's. Update it along with the handlers.
+var keyBindings = []KeyBinding{
+	{"Any", "?", "Toggle this help (f1 in a console session)"},
+	{"Any", "esc / q", "Back one layer"},
+	{"Any", "ctrl+c", "Quit"},
+	{"Interface", "letters", "Search interface names and descriptions"},
+	{"Interface", "backspace", "Edit the search"},
+	{"Interface", "esc", "Clear the search; quit when empty"},
+	{"Interface", "up / down", "Move the cursor"},
+	{"Interface", "1-9", "Select interface by number (no search)"},
+	{"Interface", "enter", "Select interface"},
+	{"Interface", "mouse", "Click to select, scroll to move"},
+	{"Mode", "up / down, k / j", "Move the cursor"},
+	{"Mode", "1-9", "Open mode by number"},
+	{"Mode", "enter", "Open mode"},
+	{"Mode", "mouse", "Click to open, scroll to move"},
+	{"Mode", "d", "Details"},
+	{"Mode", "g", "Diagnose"},
+	{"Mode", "v", "VLAN tester"},
+	{"Mode", "n", "Snapshots"},
+	{"Mode", "s", "Settings"},
+	{"Mode", "c", "Packet capture"},
+	{"Mode", "a", "Gateway audit"},
+	{"Mode", "p", "Speedtest"},
+	{"Mode", "l", "LLDP discovery"},
+	{"Mode", "b", "ARP table"},
+	{"Mode", "M", "ARP monitor"},
+	{"Mode", "Q", "DNS query log"},
+	{"Mode", "D", "Snapshot diff"},
+	{"Mode", "B", "ARP scan"},
+	{"Mode", "C", "CDP discovery"},
+	{"Mode", "w", "Wake-on-LAN"},
+	{"Mode", "m", "mDNS discovery"},
+	{"Mode", "T", "Spanning tree BPDUs"},
+	{"Mode", "o", "Serial console"},
+
+	{"Diagnose", "r", "Run diagnostics"},
+	{"VLAN", "s", "Test VLAN range"},
+	{"VLAN", "d", "Discover VLANs"},
+	{"Snapshots", "s", "Save snapshot"},
+	{"Settings", "r", "Toggle redact mode"},
+	{"Settings", "t", "Set diagnostics timeout"},
+	{"Settings", "m", "Toggle path MTU probe"},
+	{"Settings", "e", "Toggle traceroute"},
+	{"Settings", "y", "Toggle NTP check"},
+	{"Settings", "f", "Cycle probe confidence"},
+	{"Settings", "+ / -", "Add / remove audit ports"},
+	{"Settings", "p", "Switch profile"},
+	{"Capture", "s", "Start capture"},
+	{"Capture", "x", "Stop capture"},
+	{"Capture", "w", "Save to pcapng"},
+	{"Capture", "f", "Set BPF filter"},
+	{"Capture", "r", "Cycle ring buffer"},
+	{"Capture", "h", "Toggle HTTP conversations"},
+	{"Capture", "j", "Export to JSON"},
+	{"Capture", "e", "Export to CSV"},
+	{"Capture", "R", "Start / stop rogue DHCP detection"},
+	{"Audit", "s", "Start audit"},
+	{"Audit", "u", "Toggle UDP scan"},
+	{"Audit", "n", "Toggle SNMP probe"},
+	{"Audit", "D", "Show changes since last audit"},
+	{"Audit", "S", "Walk the gateway's SNMP system group"},
+	{"LLDP", "s", "Listen for 30 seconds"},
+	{"CDP", "s", "Listen for 60 seconds"},
+	{"Wake-on-LAN", "s", "Wake a host by MAC"},
+	{"mDNS", "s", "Browse for 3 seconds"},
+	{"Spanning Tree", "s", "Listen for 10 seconds"},
+	{"Speedtest", "s", "Start speedtest"},
+	{"Speedtest", "x", "Cancel speedtest"},
+	{"ARP Monitor", "s", "Start monitor"},
+	{"ARP Monitor", "x", "Stop monitor"},
+	{"DNS Log", "s", "Start logging"},
+	{"DNS Log", "x", "Stop logging"},
+	{"ARP Scan", "s", "Choose a range and scan it"},
+	{"Console", "up / down", "Choose port"},
+	{"Console", "f", "Refresh ports"},
+	{"Console", "p", "Probe baud rate"},
+	{"Console", "enter", "Connect, or send enter in a session"},
+	{"Console", "x", "Disconnect"},
+	{"Console", "tab", "Next session"},
+	{"Console", "u", "Send file (YMODEM)"},
+	{"Console", "m", "Macros; stops a recording"},
+	{"Console", "B", "Baseline diff"},
+	{"Console", "P", "Safe probe (active)"},
+	{"Console", "A", "Toggle config-mode probes"},
+	{"Console", "other keys", "Sent to the session"},
+	{"Console", "macros: up/down", "Choose macro"},
+	{"Console", "macros: enter", "Run macro"},
+	{"Console", "macros: r", "Record a new macro"},
+	{"Console", "macros: m/esc", "Close the macro list"},
+}
+
+// viewTitles names each view as keyBindings does
+var viewTitles = map[ViewMode]string{
+	ViewDetails:    "Details",
+	ViewDiagnose:   "Diagnose",
+	ViewVLAN:       "VLAN",
+	ViewSnap:       "Snapshots",
+	ViewSettings:   "Settings",
+	ViewCapture:    "Capture",
+	ViewAudit:      "Audit",
+	ViewLLDP:       "LLDP",
+	ViewSpeedtest:  "Speedtest",
+	ViewConsole:    "Console",
+	ViewARP:        "ARP",
+	ViewARPMonitor: "ARP Monitor",
+	ViewDNSLog:     "DNS Log",
+	ViewSnapDiff:   "Snapshot Diff",
+	ViewARPScan:    "ARP Scan",
+	ViewCDP:        "CDP",
+	ViewWoL:        "Wake-on-LAN",
+	ViewMDNS:       "mDNS",
+	ViewSTP:        "Spanning Tree",
+}
+
+// isLayerBinding reports whether a binding belongs to a menu layer rather
+// than a view
+func isLayerBinding(b KeyBinding) bool {
+	switch b.Layer {
+	case "Any", "Interface", "Mode":
+		return true
+	}
+	return false
+}
+
+// helpLines renders the key table: layer keys, then the open view's keys,
+// then every other view's
+func (m Model) helpLines() []string {
+	row := func(b KeyBinding) string {
+		return fmt.Sprintf("  %-15s %-17s %s", b.Layer, b.Key, b.Desc)
+	}
+	lines := []string{fmt.Sprintf("  %-15s %-17s %s", "Layer", "Key", "Description")}
+	var current, other []string
+	for _, b := range keyBindings {
+		switch {
+		case isLayerBinding(b):
+			lines = append(lines, row(b))
+		case m.layer == LayerView && b.Layer == viewTitles[m.mode]:
+			current = append(current, row(b))
+		default:
+			other = append(other, row(b))
+		}
+	}
+	if len(current) > 0 {
+		lines = append(lines, "", "This view:")
+		lines = append(lines, current...)
+	}
+	lines = append(lines, "", "Other views:")
+	return append(lines, other...)
+}
+
+// helpPageSize is how many key table lines fit in the overlay, or 0 when the
+// terminal size is not known yet
+func (m Model) helpPageSize() int {
+	if m.height == 0 {
+		return 0
+	}
+	// Border, padding, title and footer
+	if n := m.height - 10; n > 3 {
+		return n
+	}
+	return 3
+}
+
+// handleHelpKeys scrolls and closes the help overlay
+func (m Model) handleHelpKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	maxScroll := len(m.helpLines()) - m.helpPageSize()
+	if m.helpPageSize() == 0 || maxScroll < 0 {
+		maxScroll = 0
+	}
+	switch msg.String() {
+	case "?", "f1", "esc", "q":
+		m.helpActive = false
+	case "up", "k":
+		if m.helpScroll > 0 {
+			m.helpScroll--
+		}
+	case "down", "j":
+		if m.helpScroll < maxScroll {
+			m.helpScroll++
+		}
+	case "pgup":
+		m.helpScroll -= m.helpPageSize()
+		if m.helpScroll < 0 {
+			m.helpScroll = 0
+		}
+	case "pgdown", " ":
+		m.helpScroll += m.helpPageSize()
+		if m.helpScroll > maxScroll {
+			m.helpScroll = maxScroll
+		}
+	}
+	return m, nil
+}
+
 func (m Model) renderHelp() string {
-	style := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		Padding(1, 2).
-		BorderForeground(lipgloss.Color("63"))
+	lines := m.helpLines()
+	start, end := 0, len(lines)
+	if page := m.helpPageSize(); page > 0 && page < len(lines) {
+		start = m.helpScroll
+		if start > len(lines)-page {
+			start = len(lines) - page
+		}
+		end = start + page
+	}
 
 	var s string
-	s += lipgloss.NewStyle().Bold(true).Render("Help") + "\n\n"
-	s += "General Navigation:\n"
-	s += "  Arrow Keys / hjkl : Navigate\n"
-	s += "  Enter             : Select / Activate\n"
-	s += "  Esc / q           : Back / Quit\n"
-	s += "  ?                 : Toggle Help\n\n"
-
-	s += "Context Commands:\n"
-	switch m.mode {
-	case ViewPicker, ViewDetails:
-		s += "  1-9 : Quick Select Interface\n"
-		s += "  d   : Refresh Details\n"
-	case ViewDiagnose:
-		s += "  r   : Run Diagnostics\n"
-	case ViewSettings:
-		s += "  r   : Toggle Redact Mode\n"
-		s += "  t   : Cycle Timeout\n"
-	case ViewCapture:
-		s += "  s   : Start Capture\n"
-		s += "  x   : Stop Capture\n"
-		s += "  w   : Save to PCAP\n"
-		s += "  f   : Set Filter\n"
-	case ViewAudit:
-		s += "  s   : Start Audit\n"
-	case ViewSpeedtest:
-		s += "  s   : Start Speedtest\n"
-		s += "  x   : Cancel Speedtest\n"
-	case ViewConsole:
-		s += "  f   : Refresh Ports\n"
-		s += "  p   : Probe Port\n"
-		s += "  Enter: Connect\n"
-		s += "  x   : Disconnect\n"
-		s += "  P   : Safe Probe (Active)\n"
-		s += "  A   : Toggle Config Probe\n"
-		s += "  Type to send to console\n"
+	s += m.styles.Header.Render("Help") + "\n\n"
+	s += strings.Join(lines[start:end], "\n") + "\n\n"
+	if start > 0 || end < len(lines) {
+		s += fmt.Sprintf("Lines %d-%d of %d  |  up/down, pgup/pgdown: scroll  |  ?/esc: close", start+1, end, len(lines))
+	} else {
+		s += "?/esc: close"
+	}
+	return m.styles.Box.Render(s)
+}
+
+// ansiPattern matches the escape sequences lipgloss emits
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// overlayCenter draws fg centered over bg, which is padded to width x height.
+// Background rows under fg lose their styling, as splicing styled text by
+// column would need an ANSI-aware cutter.
+func overlayCenter(bg, fg string, width, height int) string {
+	bgLines := strings.Split(strings.TrimSuffix(bg, "\n"), "\n")
+	fgLines := strings.Split(fg, "\n")
+	fgWidth := lipgloss.Width(fg)
+	if w := lipgloss.Width(bg); w > width {
+		width = w
+	}
+	for len(bgLines) < height || len(bgLines) < len(fgLines) {
+		bgLines = append(bgLines, "")
+	}
+
+	x := (width - fgWidth) / 2
+	y := (len(bgLines) - len(fgLines)) / 2
+	if x < 0 {
+		x = 0
+	}
+	for i, line := range fgLines {
+		plain := []rune(ansiPattern.ReplaceAllString(bgLines[y+i], ""))
+		var left, right strings.Builder
+		col := 0
+		for _, r := range plain {
+			switch {
+			case col < x:
+				left.WriteRune(r)
+			case col >= x+fgWidth:
+				right.WriteRune(r)
+			}
+			col += lipgloss.Width(string(r))
+		}
+		pad := x - lipgloss.Width(left.String())
+		if pad < 0 {
+			pad = 0
+		}
+		fill := fgWidth - lipgloss.Width(line)
+		if fill < 0 {
+			fill = 0
+		}
+		bgLines[y+i] = left.String() + strings.Repeat(" ", pad) + line + strings.Repeat(" ", fill) + right.String()
+	}
+	return strings.Join(bgLines, "\n")
+}
+
+func (m Model) renderSnapDiffView() string {
+	if m.snapDiffView == nil {
+		return "Snapshot diff view not initialized"
+	}
+
+	var s string
+	s += "═══ Snapshot Diff ═══\n\n"
+	s += fmt.Sprintf("Status: %s\n", m.snapDiffView.statusMessage)
+	if m.snapDiffView.diff != nil {
+		s += renderSnapshotDiff(m.snapDiffView.diff)
+	}
+	return s
+}
+
+// renderSnapshotDiff lists what changed between two snapshots
+func renderSnapshotDiff(diff *store.SnapshotDiff) string {
+	s := "\nChanges between the last two snapshots:\n"
+	if diff.Empty() {
+		return s + "  none\n"
+	}
+	for _, ip := range diff.IPsAdded {
+		s += "  + address " + ip + "\n"
+	}
+	for _, ip := range diff.IPsRemoved {
+		s += "  - address " + ip + "\n"
+	}
+	if diff.GatewayChanged {
+		s += fmt.Sprintf("  ~ gateway %s -> %s\n", orNone(diff.OldGateway), orNone(diff.NewGateway))
+	}
+	if diff.DNSChanged {
+		s += fmt.Sprintf("  ~ DNS %s -> %s\n", orNone(strings.Join(diff.OldDNS, ", ")), orNone(strings.Join(diff.NewDNS, ", ")))
+	}
+	for _, c := range diff.PortsAdded {
+		s += fmt.Sprintf("  + %s %d/%s %s\n", c.Host, c.Port, c.Protocol, c.Service)
+	}
+	for _, c := range diff.PortsRemoved {
+		s += fmt.Sprintf("  - %s %d/%s %s\n", c.Host, c.Port, c.Protocol, c.Service)
+	}
+	return s
+}
+
+// orNone shows an empty value as "none"
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+func (m Model) renderARPView() string {
+	if m.arpView == nil {
+		return "ARP view not initialized"
+	}
+
+	var s string
+	s += "═══ ARP Table ═══\n\n"
+	s += fmt.Sprintf("Status: %s\n\n", m.arpView.statusMessage)
+
+	if m.arpView.err != nil {
+		return s
+	}
+
+	if len(m.arpView.entries) == 0 {
+		s += "No ARP entries.\n"
+		return s
+	}
+
+	s += fmt.Sprintf("%-40s %-18s %-10s %-12s\n", "IP Address", "MAC Address", "Interface", "State")
+	s += strings.Repeat("─", 80) + "\n"
+	for _, e := range m.arpView.entries {
+		mac := e.MAC
+		if mac == "" {
+			mac = "-"
+		}
+		s += fmt.Sprintf("%-40s %-18s %-10s %-12s\n", e.IP, mac, e.Interface, e.State)
+	}
+
+	s += fmt.Sprintf("\nLast updated: %s (auto-refresh every 2s)\n",
+		m.arpView.lastUpdate.Format("15:04:05"))
+
+	return s
+}
+
+func (m Model) renderARPScanView() string {
+	if m.arpScanView == nil {
+		return "ARP scan view not initialized"
+	}
+
+	var s string
+	s += "═══ ARP Scan ═══\n\n"
+	s += fmt.Sprintf("Status: %s\n", m.arpScanView.statusMessage)
+	cidr := m.arpScanView.cidr
+	if cidr == "" {
+		cidr = "(none; press 's' to enter one)"
+	}
+	s += fmt.Sprintf("Range: %s\n\n", cidr)
+
+	if m.arpScanView.running {
+		s += fmt.Sprintf("Sending ARP requests and waiting %s for replies...\n", arpscan.DefaultTimeout)
+		return s
+	}
+
+	if len(m.arpScanView.hosts) == 0 {
+		if m.arpScanView.lastRun.IsZero() {
+			s += "Commands:\n"
+			s += "  's' - Scan a range (requires sudo/root)\n"
+		} else if m.arpScanView.err == nil {
+			s += "No hosts replied.\n"
+		}
+		return s
+	}
+
+	s += fmt.Sprintf("%-16s %-18s %-24s %10s\n", "IP Address", "MAC Address", "Vendor", "RTT")
+	s += strings.Repeat("─", 71) + "\n"
+	for _, h := range m.arpScanView.hosts {
+		s += fmt.Sprintf("%-16s %-18s %-24s %10s\n", h.IP, h.MAC, truncate(h.Vendor, 23), h.RTT.Round(100*time.Microsecond))
+	}
+
+	s += fmt.Sprintf("\nLast scan: %s. Press 's' to scan again.\n", m.arpScanView.lastRun.Format("15:04:05"))
+	return s
+}
+
+func (m Model) renderWoLView() string {
+	if m.wolView == nil {
+		return "Wake-on-LAN view not initialized"
+	}
+
+	var s string
+	s += "═══ Wake-on-LAN ═══\n\n"
+	status := m.wolView.statusMessage
+	switch {
+	case m.wolView.sending:
+	case m.wolView.err != nil:
+		status = m.styles.Error.Render(status)
+	case !m.wolView.lastSent.IsZero():
+		status = m.styles.Success.Render(status)
+	}
+	s += fmt.Sprintf("Status: %s\n\n", status)
+
+	if !m.wolView.lastSent.IsZero() {
+		s += fmt.Sprintf("Last sent: %s\n\n", m.wolView.lastSent.Format("15:04:05"))
+	}
+
+	s += "Commands:\n"
+	s += "  's' - Send a magic packet\n\n"
+	s += fmt.Sprintf("Enter a MAC such as 00:11:22:33:44:55. Packets go to %s\n", netpkg.DefaultWoLBroadcast)
+	s += "unless a subnet broadcast address (e.g. 192.168.1.255) follows the MAC.\n"
+	return s
+}
+
+func (m Model) renderCDPView() string {
+	if m.cdpView == nil {
+		return "CDP view not initialized"
+	}
+
+	var s string
+	s += "═══ CDP Neighbors ═══\n\n"
+	s += fmt.Sprintf("Status: %s\n\n", m.cdpView.statusMessage)
+
+	if m.cdpView.running {
+		s += fmt.Sprintf("Listening for CDP packets (%s timeout)...\n", cdpListenDuration)
+		return s
+	}
+
+	if len(m.cdpView.neighbors) == 0 {
+		s += "No neighbors found.\n\n"
+		s += "Commands:\n"
+		s += "  's' - Start Discovery (requires sudo/root)\n"
+		return s
+	}
+
+	s += fmt.Sprintf("%-24s %-22s %-22s %-16s\n", "Device ID", "Port ID", "Platform", "Address")
+	s += strings.Repeat("─", 86) + "\n"
+
+	for _, n := range m.cdpView.neighbors {
+		addr := "-"
+		if len(n.Addresses) > 0 {
+			addr = n.Addresses[0]
+		}
+		s += fmt.Sprintf("%-24s %-22s %-22s %-16s\n", truncate(n.DeviceID, 23), truncate(n.PortID, 21), truncate(n.Platform, 21), addr)
+
+		// Detailed info; the version's first line names the image
+		if version, _, _ := strings.Cut(n.SoftwareVersion, "\n"); version != "" {
+			s += fmt.Sprintf("  %s\n", version)
+		}
+		if n.Capabilities != "" {
+			s += fmt.Sprintf("  Caps: %s\n", n.Capabilities)
+		}
+		if len(n.Addresses) > 1 {
+			s += fmt.Sprintf("  Addresses: %s\n", strings.Join(n.Addresses, ", "))
+		}
+		s += "\n"
+	}
+
+	return s
+}
+
+func (m Model) renderSTPView() string {
+	if m.stpView == nil {
+		return "STP view not initialized"
+	}
+
+	var s string
+	s += "═══ Spanning Tree ═══\n\n"
+	s += fmt.Sprintf("Status: %s\n\n", m.stpView.statusMessage)
+
+	if m.stpView.running {
+		s += fmt.Sprintf("Listening for BPDUs (%s)...\n", stpListenDuration)
+		return s
+	}
+
+	if len(m.stpView.frames) == 0 {
+		s += "No BPDUs heard.\n\n"
+		s += "Commands:\n"
+		s += "  's' - Start Listening (requires sudo/root)\n"
+		return s
+	}
+
+	// Every bridge in one tree agrees on the root; list each root heard
+	seen := make(map[string]bool)
+	for _, f := range m.stpView.frames {
+		if seen[f.RootBridgeID] {
+			continue
+		}
+		seen[f.RootBridgeID] = true
+		priority, mac, _ := strings.Cut(f.RootBridgeID, ".")
+		s += fmt.Sprintf("Root bridge: priority %s, MAC %s\n", priority, mac)
+	}
+	s += "\n"
+
+	s += fmt.Sprintf("%-26s %-7s %-6s %8s %-5s %s\n", "Bridge", "Port", "Proto", "Cost", "Hello", "Max Age")
+	s += strings.Repeat("─", 70) + "\n"
+	for _, f := range m.stpView.frames {
+		s += fmt.Sprintf("%-26s 0x%04x  %-6s %8d %-5s %s\n", f.BridgeID, f.PortID, f.Protocol, f.RootPathCost,
+			fmt.Sprintf("%ds", f.HelloTime), fmt.Sprintf("%ds", f.MaxAge))
+		if f.TopologyChange {
+			s += "  Topology change in progress\n"
+		}
 	}
 
-	return style.Render(s)
+	return s
+}
+
+func (m Model) renderMDNSView() string {
+	if m.mdnsView == nil {
+		return "mDNS view not initialized"
+	}
+
+	var s string
+	s += "═══ mDNS Services ═══\n\n"
+	s += fmt.Sprintf("Status: %s\n\n", m.mdnsView.statusMessage)
+
+	if m.mdnsView.running {
+		s += fmt.Sprintf("Browsing mDNS services (%s)...\n", mdns.DefaultDuration)
+		return s
+	}
+
+	if len(m.mdnsView.entries) == 0 {
+		s += "No services found.\n\n"
+		s += "Commands:\n"
+		s += "  's' - Start Discovery\n"
+		return s
+	}
+
+	s += fmt.Sprintf("%-28s %-20s %-24s %-6s %-16s\n", "Name", "Type", "Host", "Port", "Address")
+	s += strings.Repeat("─", 98) + "\n"
+
+	for _, e := range m.mdnsView.entries {
+		addr := "-"
+		if len(e.IPs) > 0 {
+			addr = e.IPs[0]
+		}
+		typ := strings.TrimSuffix(e.Type, ".local")
+		s += fmt.Sprintf("%-28s %-20s %-24s %-6d %-16s\n", truncate(e.Name, 27), truncate(typ, 19), truncate(e.Host, 23), e.Port, addr)
+	}
+
+	return s
+}
+
+// truncate shortens s to at most n bytes for a table column
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
 }
 
 func (m Model) renderLLDPView() string {
@@ -2177,3 +5741,108 @@ func (m Model) renderLLDPView() string {
 
 	return s
 }
+
+func (m Model) renderARPMonitorView() string {
+	if m.arpMonView == nil {
+		return "ARP monitor not initialized"
+	}
+
+	var s string
+	s += "═══ ARP Monitor ═══\n\n"
+	s += fmt.Sprintf("Status: %s\n\n", m.arpMonView.statusMessage)
+
+	if len(m.arpMonView.senders) == 0 {
+		s += "No ARP traffic seen yet.\n"
+	} else {
+		s += fmt.Sprintf("%-18s %-40s %-11s %8s %8s\n", "Sender MAC", "Sender IP", "Last Type", "Count", "Req/s")
+		s += strings.Repeat("─", 89) + "\n"
+		for _, e := range m.arpMonView.senders {
+			line := fmt.Sprintf("%-18s %-40s %-11s %8d %8.0f", e.SenderMAC, e.SenderIP, e.Type, e.Count, e.Rate)
+			if e.Rate > float64(m.arpMonView.threshold) {
+				line = m.styles.Error.Render(line)
+			}
+			s += line + "\n"
+		}
+	}
+
+	if len(m.arpMonView.alerts) > 0 {
+		s += "\nAlerts:\n"
+		for i := len(m.arpMonView.alerts) - 1; i >= 0; i-- {
+			a := m.arpMonView.alerts[i]
+			s += fmt.Sprintf("  [%s] %s (%s) sent %.0f ARP requests/s\n",
+				a.Timestamp.Format("15:04:05"), a.SenderIP, a.SenderMAC, a.Rate)
+		}
+	}
+
+	if m.arpMonView.running {
+		s += "\nPress 'x' to stop monitoring\n"
+	} else {
+		s += "\nPress 's' to start monitoring\n"
+	}
+	return s
+}
+
+func (m Model) renderDNSLogView() string {
+	if m.dnsLogView == nil {
+		return "DNS log not initialized"
+	}
+
+	var s string
+	s += "═══ DNS Query Log ═══\n\n"
+	s += fmt.Sprintf("Status: %s\n\n", m.dnsLogView.statusMessage)
+
+	events := m.dnsLogView.events
+
+	if len(events) == 0 {
+		s += "No DNS queries seen yet.\n"
+	} else {
+		s += fmt.Sprintf("%-8s %-40s %-40s %-6s %-9s %8s  %s\n", "Time", "Client", "Name", "Type", "Rcode", "Latency", "Answers")
+		s += strings.Repeat("─", 130) + "\n"
+		start := len(events) - dnsLogRows
+		if start < 0 {
+			start = 0
+		}
+		for _, ev := range events[start:] {
+			rcode, latency := ev.ResponseCode, "-"
+			if rcode == "" {
+				rcode = "pending"
+			}
+			if ev.Latency > 0 {
+				latency = ev.Latency.Round(time.Millisecond).String()
+			}
+			line := fmt.Sprintf("%-8s %-40s %-40s %-6s %-9s %8s  %s",
+				ev.Timestamp.Format("15:04:05"), ev.SourceIP, ev.QueryName, ev.QueryType,
+				rcode, latency, strings.Join(ev.Answers, ", "))
+			if rcode != "pending" && rcode != "NOERROR" {
+				line = m.styles.Warning.Render(line)
+			}
+			s += line + "\n"
+		}
+
+		summary := m.dnsLogView.summary
+		names := make([]string, 0, len(summary))
+		for name := range summary {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if summary[names[i]] != summary[names[j]] {
+				return summary[names[i]] > summary[names[j]]
+			}
+			return names[i] < names[j]
+		})
+		if len(names) > dnsLogTopNames {
+			names = names[:dnsLogTopNames]
+		}
+		s += fmt.Sprintf("\nTop queried names (%d queries total):\n", len(events))
+		for _, name := range names {
+			s += fmt.Sprintf("  %6d  %s\n", summary[name], name)
+		}
+	}
+
+	if m.dnsLogView.logger != nil && m.dnsLogView.logger.IsRunning() {
+		s += "\nPress 'x' to stop logging\n"
+	} else {
+		s += "\nPress 's' to start logging\n"
+	}
+	return s
+}
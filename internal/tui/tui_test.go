@@ -1,10 +1,25 @@
 package tui
 
 import (
+	"fmt"
+	"net"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/alexpitcher/LanAudit/internal/arpscan"
+	"github.com/alexpitcher/LanAudit/internal/capture"
+	"github.com/alexpitcher/LanAudit/internal/console"
+	"github.com/alexpitcher/LanAudit/internal/diagnostics"
+	"github.com/alexpitcher/LanAudit/internal/mdns"
+	netpkg "github.com/alexpitcher/LanAudit/internal/net"
+	"github.com/alexpitcher/LanAudit/internal/scan"
+	"github.com/alexpitcher/LanAudit/internal/speedtest"
+	"github.com/alexpitcher/LanAudit/internal/store"
+	"github.com/alexpitcher/LanAudit/internal/vlan"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
 // Mock objects and helpers for testing
@@ -15,6 +30,7 @@ func initialModelForTest() Model {
 		layer:      LayerInterface,
 		interfaces: nil, // Mock interfaces if needed
 		config:     nil, // Mock config if needed
+		hoverIndex: -1,
 	}
 }
 
@@ -77,3 +93,1492 @@ func TestRenderLLDPView(t *testing.T) {
 		t.Errorf("Output should indicate no neighbors")
 	}
 }
+
+func TestMouseSelectsInterfaceAndMode(t *testing.T) {
+	m := initialModelForTest()
+	m.interfaces = []netpkg.Iface{{Name: "en0"}, {Name: "en1"}, {Name: "en2"}}
+
+	click := func(y int) tea.MouseMsg {
+		return tea.MouseMsg{Y: y, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress}
+	}
+
+	// Header rows select nothing
+	updated, _ := m.Update(click(1))
+	m = updated.(Model)
+	if m.layer != LayerInterface {
+		t.Fatalf("click on header changed layer to %d", m.layer)
+	}
+
+	// en1 spans rows 6 and 7, below the title and search rows; the
+	// traffic row counts too
+	updated, _ = m.Update(click(7))
+	m = updated.(Model)
+	if m.layer != LayerMode || m.selectedIface != "en1" {
+		t.Fatalf("layer = %d, iface = %q; want mode menu for en1", m.layer, m.selectedIface)
+	}
+
+	// Second mode row is Diagnose
+	updated, _ = m.Update(click(4))
+	m = updated.(Model)
+	if m.layer != LayerView || m.mode != ViewDiagnose {
+		t.Errorf("layer = %d, mode = %v; want Diagnose view", m.layer, m.mode)
+	}
+}
+
+func TestMouseHoverAndScroll(t *testing.T) {
+	m := initialModelForTest()
+	m.interfaces = []netpkg.Iface{{Name: "en0"}, {Name: "en1"}}
+
+	updated, _ := m.Update(tea.MouseMsg{Y: 6, Action: tea.MouseActionMotion})
+	m = updated.(Model)
+	if m.hoverIndex != 1 {
+		t.Errorf("hoverIndex = %d, want 1", m.hoverIndex)
+	}
+	if m.layer != LayerInterface {
+		t.Error("hover should not select")
+	}
+
+	// Past the last interface
+	updated, _ = m.Update(tea.MouseMsg{Y: 20, Action: tea.MouseActionMotion})
+	m = updated.(Model)
+	if m.hoverIndex != -1 {
+		t.Errorf("hoverIndex = %d, want -1", m.hoverIndex)
+	}
+
+	wheel := func(b tea.MouseButton) {
+		updated, _ := m.Update(tea.MouseMsg{Button: b, Action: tea.MouseActionPress})
+		m = updated.(Model)
+	}
+	wheel(tea.MouseButtonWheelDown)
+	if m.selectedIndex != 1 {
+		t.Errorf("after wheel down selectedIndex = %d, want 1", m.selectedIndex)
+	}
+	wheel(tea.MouseButtonWheelDown)
+	if m.selectedIndex != 0 {
+		t.Errorf("wheel down should wrap, got %d", m.selectedIndex)
+	}
+	wheel(tea.MouseButtonWheelUp)
+	if m.selectedIndex != 1 {
+		t.Errorf("wheel up should wrap, got %d", m.selectedIndex)
+	}
+
+	// Keyboard use clears the hover highlight
+	m.hoverIndex = 0
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m = updated.(Model)
+	if m.hoverIndex != -1 {
+		t.Errorf("hoverIndex after key = %d, want -1", m.hoverIndex)
+	}
+}
+
+func TestThemesRender(t *testing.T) {
+	themes := map[string]store.ThemeConfig{"custom": {StatusFg: "#abc", HighlightBg: "#102030"}}
+	for _, name := range store.ThemePresetNames() {
+		themes[name] = store.ThemeConfig{Preset: name}
+	}
+
+	for name, tc := range themes {
+		m := initialModelForTest()
+		m.config = store.DefaultConfig()
+		m.config.Theme = tc
+		m.styles = NewStyleSet(tc)
+		m.interfaces = []netpkg.Iface{{Name: "en0"}}
+		m.hoverIndex = 0
+
+		for _, layer := range []MenuLayer{LayerInterface, LayerMode, LayerView} {
+			m.layer = layer
+			m.mode = ViewSettings
+			if out := m.View(); out == "" {
+				t.Errorf("%s: layer %d rendered nothing", name, layer)
+			}
+		}
+		m.helpActive = true
+		if out := m.View(); !strings.Contains(out, "Help") {
+			t.Errorf("%s: help overlay missing", name)
+		}
+	}
+}
+
+func TestThemePresetOverridesConfig(t *testing.T) {
+	config := store.DefaultConfig()
+	config.Theme = store.ThemeConfig{Preset: "dark", ErrorFg: "#ffffff"}
+
+	if got := themeStyles(config).Error.GetForeground(); got != lipgloss.Color("#ffffff") {
+		t.Errorf("configured error color = %v, want #ffffff", got)
+	}
+
+	ThemePreset = "solarized"
+	defer func() { ThemePreset = "" }()
+	want := lipgloss.Color(store.ThemePresets["solarized"].ErrorFg)
+	if got := themeStyles(config).Error.GetForeground(); got != want {
+		t.Errorf("error color with --theme = %v, want %v", got, want)
+	}
+}
+
+func TestHelpOverlay(t *testing.T) {
+	m := initialModelForTest()
+	m.interfaces = []netpkg.Iface{{Name: "en0"}}
+	m.styles = NewStyleSet(store.ThemeConfig{})
+	m.width, m.height = 100, 20
+
+	key := func(k string) {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		switch k {
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case "down":
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+		case "up":
+			msg = tea.KeyMsg{Type: tea.KeyUp}
+		}
+		updated, _ := m.Update(msg)
+		m = updated.(Model)
+	}
+
+	key("?")
+	if !m.helpActive {
+		t.Fatal("'?' should open help from the interface list")
+	}
+	out := m.View()
+	if !strings.Contains(out, "Help") || !strings.Contains(out, "╔═") {
+		t.Errorf("help should draw over the picker, got:\n%s", out)
+	}
+
+	// Keys scroll the help rather than reaching the picker
+	key("down")
+	key("down")
+	if m.helpScroll != 2 || m.selectedIndex != 0 {
+		t.Errorf("helpScroll = %d, selectedIndex = %d; want 2, 0", m.helpScroll, m.selectedIndex)
+	}
+	key("up")
+	if m.helpScroll != 1 {
+		t.Errorf("helpScroll = %d, want 1", m.helpScroll)
+	}
+	for i := 0; i < 200; i++ {
+		key("down")
+	}
+	if max := len(m.helpLines()) - m.helpPageSize(); m.helpScroll != max {
+		t.Errorf("helpScroll = %d, want it to stop at %d", m.helpScroll, max)
+	}
+
+	// esc closes help without leaving the picker
+	key("esc")
+	if m.helpActive || m.layer != LayerInterface {
+		t.Errorf("helpActive = %v, layer = %d after esc", m.helpActive, m.layer)
+	}
+
+	m.layer = LayerView
+	m.mode = ViewCapture
+	key("?")
+	if !strings.Contains(strings.Join(m.helpLines(), "\n"), "This view:") {
+		t.Error("help in a view should list that view's keys first")
+	}
+	key("?")
+	if m.helpActive {
+		t.Error("'?' should close help")
+	}
+}
+
+func TestKeyBindingLayers(t *testing.T) {
+	known := map[string]bool{}
+	for _, title := range viewTitles {
+		known[title] = true
+	}
+	for _, b := range keyBindings {
+		if !isLayerBinding(b) && !known[b.Layer] {
+			t.Errorf("binding %q: layer %q is not a menu layer or view title", b.Key, b.Layer)
+		}
+	}
+}
+
+func TestOverlayCenter(t *testing.T) {
+	bg := "0123456789\nabcdefghij\nABCDEFGHIJ"
+	got := overlayCenter(bg, "XY", 10, 3)
+	want := "0123456789\nabcdXYghij\nABCDEFGHIJ"
+	if got != want {
+		t.Errorf("overlayCenter() = %q, want %q", got, want)
+	}
+}
+
+func TestFilterInterfaces(t *testing.T) {
+	ifaces := []netpkg.Iface{
+		{Name: "eth0", Description: "Intel I350"},
+		{Name: "bond0", Description: "LACP uplink"},
+		{Name: "eth0.120", Description: "VLAN 120 Guest"},
+		{Name: "wlan0"},
+	}
+	names := func(list []netpkg.Iface) []string {
+		var out []string
+		for _, iface := range list {
+			out = append(out, iface.Name)
+		}
+		return out
+	}
+
+	tests := []struct {
+		filter string
+		want   []string
+	}{
+		{"", []string{"eth0", "bond0", "eth0.120", "wlan0"}},
+		{"eth", []string{"eth0", "eth0.120"}},
+		{"ETH0.1", []string{"eth0.120"}},
+		{"uplink", []string{"bond0"}},
+		{"guest", []string{"eth0.120"}},
+		{"tap", nil},
+	}
+	for _, tt := range tests {
+		if got := names(filterInterfaces(ifaces, tt.filter)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("filterInterfaces(%q) = %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestPickerSearchKeys(t *testing.T) {
+	m := initialModelForTest()
+	m.interfaces = []netpkg.Iface{{Name: "en0"}, {Name: "eth0"}, {Name: "eth1"}, {Name: "wlan0"}}
+	m.selectedIndex = 3
+
+	var cmd tea.Cmd
+	send := func(msg tea.KeyMsg) {
+		var updated tea.Model
+		updated, cmd = m.Update(msg)
+		m = updated.(Model)
+	}
+	typeKeys := func(keys string) {
+		for _, r := range keys {
+			send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+
+	// Letters search instead of triggering shortcuts; digits extend a search
+	typeKeys("eth1")
+	if m.pickerFilter != "eth1" || m.layer != LayerInterface {
+		t.Fatalf("filter = %q, layer = %d; want eth1 in the picker", m.pickerFilter, m.layer)
+	}
+	if visible := m.visibleInterfaces(); len(visible) != 1 || visible[0].Name != "eth1" || m.selectedIndex != 0 {
+		t.Errorf("visible = %v, cursor %d; want eth1 under the cursor", visible, m.selectedIndex)
+	}
+
+	send(tea.KeyMsg{Type: tea.KeyBackspace})
+	if m.pickerFilter != "eth" || len(m.visibleInterfaces()) != 2 {
+		t.Errorf("after backspace filter = %q with %d matches", m.pickerFilter, len(m.visibleInterfaces()))
+	}
+
+	send(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.pickerFilter != "" || cmd != nil {
+		t.Errorf("esc should clear the search, not quit (filter %q)", m.pickerFilter)
+	}
+	send(tea.KeyMsg{Type: tea.KeyEsc})
+	if cmd == nil {
+		t.Error("esc with no search should quit")
+	}
+
+	// Without a search, digits still quick-select
+	typeKeys("2")
+	if m.layer != LayerMode || m.selectedIface != "eth0" {
+		t.Errorf("digit 2 selected %q (layer %d), want eth0", m.selectedIface, m.layer)
+	}
+}
+
+func TestPickerScroll(t *testing.T) {
+	m := initialModelForTest()
+	for i := 0; i < 20; i++ {
+		m.interfaces = append(m.interfaces, netpkg.Iface{Name: fmt.Sprintf("vlan%d", i)})
+	}
+	m.height = pickerHeaderRows + pickerFooterRows + 2*4 // four interfaces
+	if m.pickerPageSize() != 4 {
+		t.Fatalf("pickerPageSize() = %d, want 4", m.pickerPageSize())
+	}
+
+	for i := 0; i < 5; i++ {
+		updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyDown})
+		m = updated.(Model)
+	}
+	if m.selectedIndex != 5 || m.scrollOffset != 2 {
+		t.Errorf("selectedIndex = %d, scrollOffset = %d; want 5, 2", m.selectedIndex, m.scrollOffset)
+	}
+	out := m.renderPicker()
+	if !strings.Contains(out, "vlan5") || strings.Contains(out, "vlan1 ") || !strings.Contains(out, "showing 3-6") {
+		t.Errorf("picker should show vlan2-vlan5:\n%s", out)
+	}
+
+	// Wrapping up from the top scrolls to the end
+	m.selectedIndex, m.scrollOffset = 0, 0
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	m = updated.(Model)
+	if m.selectedIndex != 19 || m.scrollOffset != 16 {
+		t.Errorf("selectedIndex = %d, scrollOffset = %d; want 19, 16", m.selectedIndex, m.scrollOffset)
+	}
+
+	// Clicks map through the scroll offset: second entry on screen
+	updated, _ = m.Update(tea.MouseMsg{Y: pickerHeaderRows + 2, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress})
+	m = updated.(Model)
+	if m.selectedIface != "vlan17" {
+		t.Errorf("click selected %q, want vlan17", m.selectedIface)
+	}
+}
+
+func TestPreselectInterface(t *testing.T) {
+	m := initialModelForTest()
+	m.interfaces = []netpkg.Iface{{Name: "en0"}, {Name: "en1"}, {Name: "en2"}}
+
+	m.preselectInterface("en2")
+	if m.selectedIndex != 2 {
+		t.Errorf("selectedIndex = %d, want 2", m.selectedIndex)
+	}
+
+	// An interface that has since disappeared falls back to the first
+	m.preselectInterface("usb0")
+	if m.selectedIndex != 0 {
+		t.Errorf("selectedIndex = %d, want 0", m.selectedIndex)
+	}
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := updated.(Model).selectedIface; got != "en0" {
+		t.Errorf("enter selected %q, want en0", got)
+	}
+
+	m.interfaces = nil
+	m.preselectInterface("en0")
+	if m.selectedIndex != 0 {
+		t.Errorf("selectedIndex with no interfaces = %d, want 0", m.selectedIndex)
+	}
+}
+
+func TestSelectInterfaceRemembersChoice(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	m := initialModelForTest()
+	m.interfaces = []netpkg.Iface{{Name: "en0"}, {Name: "en1"}}
+	m.config = store.DefaultConfig()
+
+	m.config.RememberInterface = false
+	m.selectInterface(1)
+	if last, _ := store.LoadLastInterface(); last != "" {
+		t.Errorf("saved %q with remember_interface off", last)
+	}
+
+	m.config.RememberInterface = true
+	m.selectInterface(1)
+	if last, _ := store.LoadLastInterface(); last != "en1" {
+		t.Errorf("last interface = %q, want en1", last)
+	}
+}
+
+func TestInterfaceUpdateKeepsSelection(t *testing.T) {
+	m := initialModelForTest()
+	m.interfaces = []netpkg.Iface{{Name: "en0"}, {Name: "en1"}}
+	m.selectedIndex = 1
+
+	ch := make(chan []netpkg.Iface)
+	m.ifaceUpdates = ch
+
+	updated, cmd := m.Update(ifaceUpdateMsg{ifaces: []netpkg.Iface{{Name: "en7"}, {Name: "en0"}, {Name: "en1"}}})
+	m = updated.(Model)
+
+	if len(m.interfaces) != 3 {
+		t.Fatalf("expected 3 interfaces, got %d", len(m.interfaces))
+	}
+	if m.interfaces[m.selectedIndex].Name != "en1" {
+		t.Errorf("expected cursor to stay on en1, got %s", m.interfaces[m.selectedIndex].Name)
+	}
+	if cmd == nil {
+		t.Error("expected command to wait for the next interface update")
+	}
+
+	// Selected interface removed: cursor resets
+	updated, _ = m.Update(ifaceUpdateMsg{ifaces: []netpkg.Iface{{Name: "en0"}}})
+	m = updated.(Model)
+	if m.selectedIndex != 0 {
+		t.Errorf("expected cursor reset to 0, got %d", m.selectedIndex)
+	}
+
+	// Watcher closed: no further command
+	_, cmd = m.Update(ifaceUpdateMsg{closed: true})
+	if cmd != nil {
+		t.Error("expected no command after watcher closed")
+	}
+}
+
+func TestRenderARPView(t *testing.T) {
+	m := initialModelForTest()
+	if out := m.renderARPView(); out != "ARP view not initialized" {
+		t.Errorf("Expected 'ARP view not initialized', got %q", out)
+	}
+
+	m.arpView = &ARPView{
+		statusMessage: "2 entries",
+		entries: []netpkg.ARPEntry{
+			{IP: "192.168.1.1", MAC: "aa:bb:cc:dd:ee:ff", Interface: "en0", State: "reachable"},
+			{IP: "192.168.1.9", Interface: "en0", State: "incomplete"},
+		},
+	}
+	out := m.renderARPView()
+	for _, want := range []string{"192.168.1.1", "aa:bb:cc:dd:ee:ff", "incomplete", "2 entries"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Output should contain %q", want)
+		}
+	}
+}
+
+func TestARPKeyOpensView(t *testing.T) {
+	m := initialModelForTest()
+	m.selectedIface = "en0"
+	m.layer = LayerMode
+
+	newM, _ := m.handleKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'b'}})
+	m = newM.(Model)
+	if m.mode != ViewARP || m.layer != LayerView {
+		t.Errorf("Expected ViewARP/LayerView after 'b', got mode=%v layer=%v", m.mode, m.layer)
+	}
+	if m.arpView == nil {
+		t.Error("Expected ARP view to be initialized")
+	}
+}
+
+func TestARPScanView(t *testing.T) {
+	m := initialModelForTest()
+	m.selectedIface = "lanaudit-test0"
+	m.layer = LayerMode
+
+	newM, _ := m.handleKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'B'}})
+	m = newM.(Model)
+	if m.mode != ViewARPScan || m.layer != LayerView || m.arpScanView == nil {
+		t.Fatalf("Expected ViewARPScan/LayerView after 'B', got mode=%v layer=%v", m.mode, m.layer)
+	}
+
+	// 's' asks for the range, prefilled with the last one
+	m.arpScanView.cidr = "192.168.1.0/24"
+	newM, _ = m.handleKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	m = newM.(Model)
+	if !m.inputActive || m.inputValue != "192.168.1.0/24" {
+		t.Fatalf("Expected a range prompt prefilled with the subnet, got active=%v value=%q", m.inputActive, m.inputValue)
+	}
+	m.inputSubmit(&m, " 10.0.0.0/30 ")
+	if !m.arpScanView.running || m.arpScanView.cidr != "10.0.0.0/30" {
+		t.Errorf("submitting should start a scan of the trimmed range, got running=%v cidr=%q", m.arpScanView.running, m.arpScanView.cidr)
+	}
+
+	newM, _ = m.Update(arpScanResultMsg{cidr: "10.0.0.0/30", hosts: []arpscan.ARPHost{
+		{IP: net.ParseIP("10.0.0.1"), MAC: net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0x01}, Vendor: "Unknown", RTT: 1200 * time.Microsecond},
+	}})
+	m = newM.(Model)
+	if m.arpScanView.running {
+		t.Error("result should end the scan")
+	}
+	out := m.renderARPScanView()
+	for _, want := range []string{"10.0.0.1", "aa:bb:cc:dd:ee:01", "Unknown", "1.2ms", "1 hosts replied"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in ARP scan view, got:\n%s", want, out)
+		}
+	}
+
+	newM, _ = m.Update(arpScanResultMsg{cidr: "10.0.0.0/30", err: fmt.Errorf("permission denied")})
+	m = newM.(Model)
+	if out := m.renderARPScanView(); !strings.Contains(out, "ARP scan failed: permission denied") {
+		t.Errorf("expected the error in the status, got:\n%s", out)
+	}
+}
+
+func TestCDPView(t *testing.T) {
+	m := initialModelForTest()
+	m.selectedIface = "en0"
+	m.layer = LayerMode
+
+	newM, _ := m.handleKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'C'}})
+	m = newM.(Model)
+	if m.mode != ViewCDP || m.layer != LayerView || m.cdpView == nil {
+		t.Fatalf("Expected ViewCDP/LayerView after 'C', got mode=%v layer=%v", m.mode, m.layer)
+	}
+
+	newM, cmd := m.handleKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	m = newM.(Model)
+	if !m.cdpView.running || cmd == nil {
+		t.Fatal("'s' should start CDP discovery")
+	}
+
+	newM, _ = m.Update(cdpResultMsg{neighbors: []netpkg.CDPNeighbor{{
+		DeviceID:        "sw-core-01.example.com",
+		PortID:          "GigabitEthernet0/24",
+		Platform:        "cisco WS-C2960-24TT-L",
+		Capabilities:    "Router, Switch, IGMP",
+		SoftwareVersion: "Cisco IOS Software, C2960 Software\nTechnical Support: http://www.cisco.com/techsupport",
+		Addresses:       []string{"192.168.1.2", "2001:db8::2"},
+	}}})
+	m = newM.(Model)
+	out := m.renderCDPView()
+	for _, want := range []string{"sw-core-01.example.com", "GigabitEthernet0/24", "192.168.1.2", "Caps: Router, Switch, IGMP", "Addresses: 192.168.1.2, 2001:db8::2", "Found 1 neighbors"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in CDP view, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Technical Support") {
+		t.Errorf("only the first line of the version should be shown:\n%s", out)
+	}
+}
+
+func TestWoLView(t *testing.T) {
+	m := initialModelForTest()
+	m.selectedIface = "en0"
+	m.layer = LayerMode
+
+	// 'w' opens the view straight into the MAC prompt
+	newM, _ := m.handleKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'w'}})
+	m = newM.(Model)
+	if m.mode != ViewWoL || m.layer != LayerView || m.wolView == nil {
+		t.Fatalf("Expected ViewWoL/LayerView after 'w', got mode=%v layer=%v", m.mode, m.layer)
+	}
+	if !m.inputActive || m.inputSubmit == nil {
+		t.Fatal("Expected the MAC prompt to be open")
+	}
+
+	if cmd := m.inputSubmit(&m, "  "); cmd != nil || !strings.Contains(m.wolView.statusMessage, "Enter a MAC") {
+		t.Errorf("empty input should ask for a MAC, got status %q", m.wolView.statusMessage)
+	}
+	if cmd := m.inputSubmit(&m, "00:11:22:33:44:55  192.168.1.255"); cmd == nil {
+		t.Fatal("a MAC should produce a send command")
+	}
+	if m.wolView.target != "00:11:22:33:44:55 192.168.1.255" || !m.wolView.sending {
+		t.Errorf("target = %q sending = %v", m.wolView.target, m.wolView.sending)
+	}
+
+	newM, _ = m.Update(wolResultMsg{mac: "00:11:22:33:44:55", broadcast: "192.168.1.255"})
+	m = newM.(Model)
+	if out := m.renderWoLView(); !strings.Contains(out, "Magic packet for 00:11:22:33:44:55 sent to 192.168.1.255") {
+		t.Errorf("expected the success message, got:\n%s", out)
+	}
+
+	// 's' prompts again, prefilled with the last target
+	m.inputActive = false
+	newM, _ = m.handleKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	m = newM.(Model)
+	if !m.inputActive || m.inputValue != "00:11:22:33:44:55 192.168.1.255" {
+		t.Errorf("Expected a prefilled prompt, got active=%v value=%q", m.inputActive, m.inputValue)
+	}
+
+	newM, _ = m.Update(wolResultMsg{mac: "00:11:22:33:44", err: fmt.Errorf("invalid MAC address")})
+	m = newM.(Model)
+	if out := m.renderWoLView(); !strings.Contains(out, "Wake-on-LAN failed: invalid MAC address") {
+		t.Errorf("expected the failure message, got:\n%s", out)
+	}
+}
+
+func TestSTPView(t *testing.T) {
+	m := initialModelForTest()
+	m.selectedIface = "en0"
+	m.layer = LayerMode
+
+	newM, _ := m.handleKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'T'}})
+	m = newM.(Model)
+	if m.mode != ViewSTP || m.layer != LayerView || m.stpView == nil {
+		t.Fatalf("Expected ViewSTP/LayerView after 'T', got mode=%v layer=%v", m.mode, m.layer)
+	}
+
+	newM, cmd := m.handleKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	m = newM.(Model)
+	if !m.stpView.running || cmd == nil {
+		t.Fatal("'s' should start the STP listener")
+	}
+
+	newM, _ = m.Update(stpResultMsg{frames: []netpkg.STPFrame{{
+		Protocol:       "RSTP",
+		RootBridgeID:   "4096.00:11:22:33:44:55",
+		RootPathCost:   4,
+		BridgeID:       "32769.00:1b:21:aa:bb:00",
+		PortID:         0x8002,
+		HelloTime:      2,
+		MaxAge:         20,
+		TopologyChange: true,
+	}}})
+	m = newM.(Model)
+	out := m.renderSTPView()
+	for _, want := range []string{"Root bridge: priority 4096, MAC 00:11:22:33:44:55", "32769.00:1b:21:aa:bb:00", "0x8002", "RSTP", "Topology change", "Heard 1 bridge ports"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in STP view, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestMDNSView(t *testing.T) {
+	m := initialModelForTest()
+	m.selectedIface = "en0"
+	m.layer = LayerMode
+
+	newM, _ := m.handleKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'m'}})
+	m = newM.(Model)
+	if m.mode != ViewMDNS || m.layer != LayerView || m.mdnsView == nil {
+		t.Fatalf("Expected ViewMDNS/LayerView after 'm', got mode=%v layer=%v", m.mode, m.layer)
+	}
+
+	newM, cmd := m.handleKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	m = newM.(Model)
+	if !m.mdnsView.running || cmd == nil {
+		t.Fatal("'s' should start mDNS discovery")
+	}
+
+	newM, _ = m.Update(mdnsResultMsg{entries: []mdns.MDNSEntry{{
+		Name: "Office Printer",
+		Type: "_ipp._tcp.local",
+		Host: "printer.local",
+		Port: 631,
+		IPs:  []string{"192.168.1.50"},
+	}}})
+	m = newM.(Model)
+	out := m.renderMDNSView()
+	for _, want := range []string{"Office Printer", "_ipp._tcp", "printer.local", "631", "192.168.1.50", "Found 1 services"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in mDNS view, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestDetailsViewRate(t *testing.T) {
+	m := initialModelForTest()
+	m.details = &netpkg.InterfaceDetails{Name: "en0"}
+	m.detailsView = &DetailsView{details: m.details}
+
+	if out := m.renderDetailsView(); !strings.Contains(out, "RX Rate: measuring...") {
+		t.Errorf("expected rate to be measuring before two samples")
+	}
+
+	start := time.Unix(0, 0)
+	m.detailsView.updateRate(&netpkg.InterfaceDetails{BytesRx: 0, BytesTx: 0}, start)
+	m.detailsView.updateRate(&netpkg.InterfaceDetails{BytesRx: 3100000, BytesTx: 775000}, start.Add(2*time.Second))
+
+	out := m.renderDetailsView()
+	if !strings.Contains(out, "RX Rate: 12.4 Mbps") || !strings.Contains(out, "TX Rate: 3.1 Mbps") {
+		t.Errorf("unexpected rate output:\n%s", out)
+	}
+}
+
+func TestDetailsViewRateMonitor(t *testing.T) {
+	v := &DetailsView{}
+	if v.readRateMonitor() {
+		t.Fatal("expected no monitor before one is started")
+	}
+
+	mon, err := netpkg.NewInterfaceRateMonitor("en0", 10*time.Millisecond)
+	if err != nil {
+		t.Skipf("interface stats unavailable: %v", err)
+	}
+	v.monitor = mon
+	deadline := time.Now().Add(2 * time.Second)
+	for !v.rateReady && time.Now().Before(deadline) {
+		if !v.readRateMonitor() {
+			t.Fatal("expected the running monitor to be read")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !v.rateReady {
+		t.Fatal("expected a rate from the monitor")
+	}
+
+	v.stopRateMonitor()
+	if v.monitor != nil || v.readRateMonitor() {
+		t.Error("expected the monitor to be gone after stopping")
+	}
+}
+
+func TestDetailsViewRoutes(t *testing.T) {
+	m := initialModelForTest()
+	m.details = &netpkg.InterfaceDetails{Name: "en0"}
+	m.detailsView = &DetailsView{details: m.details}
+	if out := m.renderDetailsView(); strings.Contains(out, "Routes") {
+		t.Errorf("expected no routes section without routes, got:\n%s", out)
+	}
+
+	m.details.Routes = []netpkg.Route{
+		{Destination: "0.0.0.0", Gateway: "192.168.1.1", Mask: "0.0.0.0", Interface: "en0", Metric: 100},
+		{Destination: "192.168.1.0", Mask: "255.255.255.0", Interface: "en0"},
+	}
+	out := m.renderDetailsView()
+	for _, want := range []string{"═══ Routes ═══", "default", "192.168.1.1", "100", "192.168.1.0/24", "direct"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in details view, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestLossSparkline(t *testing.T) {
+	got := lossSparkline(StyleSet{}, []float64{0, 0, 100, -5, 150})
+	if got != "▁▁█▁█" {
+		t.Errorf("lossSparkline() = %q, want %q", got, "▁▁█▁█")
+	}
+}
+
+func TestRenderSpeedtestTrend(t *testing.T) {
+	if got := renderSpeedtestTrend([]*speedtest.Result{{DownloadMbps: 100}}); got != "" {
+		t.Errorf("expected no trend for a single run, got %q", got)
+	}
+
+	history := []*speedtest.Result{{DownloadMbps: 100}, {DownloadMbps: 450}, {DownloadMbps: 800}, {DownloadMbps: 100}}
+	got := renderSpeedtestTrend(history)
+	for _, want := range []string{"last 4 runs", "▁▄█▁", "min 100.0 / max 800.0 Mbps"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in trend, got %q", want, got)
+		}
+	}
+
+	if got := speedSparkline([]float64{50, 50}, 50, 50); got != "▅▅" {
+		t.Errorf("speedSparkline() with a flat history = %q, want %q", got, "▅▅")
+	}
+}
+
+func TestRenderDiagnoseViewHistory(t *testing.T) {
+	m := initialModelForTest()
+	res := &diagnostics.Result{LinkUp: true}
+	m.diagnoseView = &DiagnoseView{
+		result: res,
+		history: []*diagnostics.Result{
+			{Ping: diagnostics.PingResult{Loss: 0}},
+			{Ping: diagnostics.PingResult{Loss: 100}},
+			res,
+		},
+	}
+
+	out := m.renderDiagnoseView()
+	if !strings.Contains(out, "Ping loss (last 3 runs): ▁█▁") {
+		t.Errorf("expected loss sparkline in diagnose view, got:\n%s", out)
+	}
+}
+
+func TestRenderDiagnoseViewRemotePings(t *testing.T) {
+	m := initialModelForTest()
+	m.diagnoseView = &DiagnoseView{result: &diagnostics.Result{
+		LinkUp:  true,
+		Gateway: "192.168.1.1",
+		Ping:    diagnostics.PingResult{RTTMin: time.Millisecond, RTTP50: 2 * time.Millisecond, RTTP95: 3 * time.Millisecond, RTTMax: 3 * time.Millisecond},
+		RemotePings: map[string]diagnostics.PingResult{
+			"8.8.8.8": {Err: "exit status 2"},
+			"1.1.1.1": {Loss: 25, RTTP50: 14 * time.Millisecond},
+		},
+	}}
+
+	out := m.renderDiagnoseView()
+	for _, want := range []string{
+		"Ping RTT: min 1ms, p50 2ms, p95 3ms, max 3ms",
+		"Remote Pings:\n  1.1.1.1          25.0% loss, min 0s, p50 14ms",
+		"  8.8.8.8          error exit status 2",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in diagnose view, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestCaptureRingKeyCycles(t *testing.T) {
+	m := initialModelForTest()
+	m.mode = ViewCapture
+	m.layer = LayerView
+	m.captureView = &CaptureView{}
+
+	for _, want := range []int{1000, 5000, 10000, 0} {
+		newM, _ := m.handleKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+		m = newM.(Model)
+		if m.captureView.ringCapacity != want {
+			t.Fatalf("ringCapacity = %d, want %d", m.captureView.ringCapacity, want)
+		}
+	}
+
+	m.captureView.running = true
+	newM, _ := m.handleKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	if newM.(Model).captureView.ringCapacity != 0 {
+		t.Error("ring mode should not change while a capture is running")
+	}
+}
+
+func TestCaptureFilterValidation(t *testing.T) {
+	m := initialModelForTest()
+	m.mode = ViewCapture
+	m.layer = LayerView
+	m.captureView = &CaptureView{filter: "tcp port 80"}
+
+	newM, _ := m.handleKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'f'}})
+	m = newM.(Model)
+	if !m.inputActive || m.inputValue != "tcp port 80" {
+		t.Fatalf("expected a filter prompt with the current filter, got active=%v value=%q", m.inputActive, m.inputValue)
+	}
+
+	m.inputSubmit(&m, "tcp port")
+	if m.captureView.filter != "tcp port 80" || m.captureView.filterError == "" {
+		t.Errorf("invalid filter should be rejected, got filter %q error %q", m.captureView.filter, m.captureView.filterError)
+	}
+	if out := m.renderCaptureView(); !strings.Contains(out, m.captureView.filterError) {
+		t.Errorf("expected the filter error inline, got:\n%s", out)
+	}
+
+	// Clearing the filter is always valid
+	m.inputSubmit(&m, "")
+	if m.captureView.filter != "" || m.captureView.filterError != "" {
+		t.Errorf("expected the filter cleared, got filter %q error %q", m.captureView.filter, m.captureView.filterError)
+	}
+}
+
+func TestRenderCaptureStats(t *testing.T) {
+	out := renderCaptureStats(capture.Stats{TCP: 3, DNS: 1})
+	if !strings.Contains(out, "TCP") || !strings.Contains(out, "75.0%") || !strings.Contains(out, "DNS") {
+		t.Errorf("unexpected breakdown:\n%s", out)
+	}
+	if strings.Contains(out, "UDP") {
+		t.Errorf("zero counters should be omitted:\n%s", out)
+	}
+	if renderCaptureStats(capture.Stats{}) != "" {
+		t.Error("expected no output for an empty capture")
+	}
+}
+
+func TestRenderCaptureDrops(t *testing.T) {
+	if got := renderCaptureDrops(StyleSet{}, capture.Stats{TCP: 5}); got != "Dropped: 0\n" {
+		t.Errorf("renderCaptureDrops() = %q, want %q", got, "Dropped: 0\n")
+	}
+
+	styles := NewStyleSet(store.ThemeConfig{})
+	got := renderCaptureDrops(styles, capture.Stats{KernelDropped: 12, InterfaceDropped: 3})
+	if want := "Dropped: " + styles.Error.Render("15") + "\n"; got != want {
+		t.Errorf("renderCaptureDrops() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderARPMonitorView(t *testing.T) {
+	m := initialModelForTest()
+	m.mode = ViewARPMonitor
+	m = m.activateMode(ViewARPMonitor)
+	m.arpMonView.senders = []capture.ARPEvent{
+		{SenderMAC: "00:11:22:33:44:01", SenderIP: "192.168.1.10", Type: capture.ARPRequest, Count: 600, Rate: 120},
+	}
+	m.arpMonView.alerts = []capture.ARPEvent{
+		{SenderMAC: "00:11:22:33:44:01", SenderIP: "192.168.1.10", Rate: 120, Timestamp: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)},
+	}
+
+	out := m.renderARPMonitorView()
+	for _, want := range []string{"00:11:22:33:44:01", "192.168.1.10", "600", "[12:00:00] 192.168.1.10", "120 ARP requests/s"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in ARP monitor view, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderDNSLogView(t *testing.T) {
+	m := initialModelForTest()
+	m.mode = ViewDNSLog
+	m = m.activateMode(ViewDNSLog)
+	ts := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	m.dnsLogView.events = []capture.DNSEvent{
+		{Timestamp: ts, SourceIP: "192.168.1.10", QueryName: "example.com", QueryType: "A", ResponseCode: "NOERROR", Answers: []string{"A 93.184.216.34"}, Latency: 12 * time.Millisecond},
+		{Timestamp: ts, SourceIP: "192.168.1.10", QueryName: "example.com", QueryType: "AAAA"},
+		{Timestamp: ts, SourceIP: "192.168.1.11", QueryName: "missing.test", QueryType: "A", ResponseCode: "NXDOMAIN"},
+	}
+	m.dnsLogView.summary = map[string]int{"example.com": 2, "missing.test": 1}
+
+	out := m.renderDNSLogView()
+	for _, want := range []string{"12:00:00", "A 93.184.216.34", "12ms", "pending", "NXDOMAIN", "2  example.com", "3 queries total"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in DNS log view, got:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "2  example.com") > strings.Index(out, "1  missing.test") {
+		t.Errorf("top names should be ordered by count:\n%s", out)
+	}
+}
+
+func TestCaptureHTTPToggle(t *testing.T) {
+	m := initialModelForTest()
+	m.mode = ViewCapture
+	m.layer = LayerView
+	m.captureView = &CaptureView{}
+
+	newM, _ := m.handleKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'h'}})
+	m = newM.(Model)
+	if !m.captureView.showHTTP {
+		t.Fatal("expected 'h' to switch to HTTP conversations")
+	}
+	if out := m.renderCaptureView(); !strings.Contains(out, "No HTTP conversations seen yet.") {
+		t.Errorf("expected empty HTTP conversation list, got:\n%s", out)
+	}
+
+	newM, _ = m.handleKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'h'}})
+	if newM.(Model).captureView.showHTTP {
+		t.Error("expected second 'h' to switch back to packets")
+	}
+}
+
+func TestRenderHTTPConversations(t *testing.T) {
+	out := renderHTTPConversations(StyleSet{}, []capture.HTTPConversation{
+		{Client: "192.168.1.10:50000", Server: "93.184.216.34:80", RequestLine: "GET / HTTP/1.1", Host: "example.com", StatusCode: 302, ContentType: "text/html"},
+		{Client: "192.168.1.10:50001", Server: "93.184.216.34:80", RequestLine: "GET /a HTTP/1.1", Host: "example.com"},
+	})
+	for _, want := range []string{"GET / HTTP/1.1 [example.com]", "302 text/html", "GET /a HTTP/1.1", "(no response)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderTopTalkers(t *testing.T) {
+	out := renderTopTalkers([]capture.TalkerSummary{
+		{IP: "192.168.1.10", BytesSent: 2048, BytesReceived: 100, PacketCount: 7},
+	})
+	for _, want := range []string{"Top Talkers", "192.168.1.10", "2.0 KB", "100 B", "7"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+	if renderTopTalkers(nil) != "" {
+		t.Error("expected no output without talkers")
+	}
+}
+
+func TestCaptureExportRequiresStoppedSession(t *testing.T) {
+	m := initialModelForTest()
+	m.mode = ViewCapture
+	m.layer = LayerView
+	m.captureView = &CaptureView{}
+
+	for _, key := range []rune{'j', 'e'} {
+		newM, cmd := m.handleKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{key}})
+		if cmd != nil {
+			t.Errorf("'%c' should not export without packets", key)
+		}
+		if got := newM.(Model).captureView.statusMessage; got != "No packets to export" {
+			t.Errorf("'%c' status = %q, want %q", key, got, "No packets to export")
+		}
+	}
+
+	m.captureView.running = true
+	newM, cmd := m.handleKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	if cmd != nil || newM.(Model).statusMsg != "Stop the capture before exporting" {
+		t.Error("export should be refused while a capture is running")
+	}
+}
+
+func TestRenderBackgroundStatus(t *testing.T) {
+	out := renderBackgroundStatus(StyleSet{}, "en0", capture.BackgroundStatus{
+		Dir:          "/tmp/caps",
+		CurrentFile:  "/tmp/caps/capture_20240101_120000.000000.pcap.tmp",
+		Files:        []string{"/tmp/caps/a.pcap"},
+		MaxFiles:     10,
+		Packets:      42,
+		BytesWritten: 2048,
+		Running:      true,
+	})
+	for _, want := range []string{"Background capture on en0: running", "/tmp/caps", "capture_20240101_120000.000000.pcap.tmp", "42 (2.0 KB)", "1 completed (keeping 10)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestCaptureRogueDHCPKey(t *testing.T) {
+	m := initialModelForTest()
+	m.mode = ViewCapture
+	m.layer = LayerView
+	m.selectedIface = "en0"
+	m.captureView = &CaptureView{}
+	m.details = &netpkg.InterfaceDetails{DefaultGateways: []string{"fe80::1", "192.168.1.1"}}
+
+	// 'R' asks for the legitimate server, suggesting the gateway
+	newM, _ := m.handleKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'R'}})
+	m = newM.(Model)
+	if !m.inputActive || m.inputValue != "192.168.1.1" {
+		t.Fatalf("Expected a server prompt prefilled with the gateway, got active=%v value=%q", m.inputActive, m.inputValue)
+	}
+	if cmd := m.inputSubmit(&m, " 192.168.1.2 "); cmd == nil || m.captureView.dhcpServer != "192.168.1.2" {
+		t.Errorf("submitting should start the detector for 192.168.1.2, got server %q", m.captureView.dhcpServer)
+	}
+
+	newM, _ = m.Update(startRogueDHCPMsg{err: fmt.Errorf("root/sudo permissions required for rogue DHCP detection")})
+	m = newM.(Model)
+	if !strings.Contains(m.captureView.statusMessage, "Rogue DHCP detection failed") {
+		t.Errorf("status = %q, want the failure", m.captureView.statusMessage)
+	}
+
+	// The last server entered is offered next time
+	m.inputActive = false
+	newM, _ = m.handleKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'R'}})
+	if got := newM.(Model).inputValue; got != "192.168.1.2" {
+		t.Errorf("second prompt value = %q, want the last server", got)
+	}
+}
+
+func TestRenderRogueDHCP(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	out := renderRogueDHCP(StyleSet{}, "192.168.1.1", true, 3, []capture.RogueEvent{
+		{ServerIP: "10.0.0.1", ServerMAC: "bb:bb:bb:00:00:02", OfferedIP: "10.0.0.23", Timestamp: ts},
+	})
+	for _, want := range []string{"watching (legitimate server 192.168.1.1, 3 offers checked)", "[10:00:00] 10.0.0.1 (bb:bb:bb:00:00:02) offered 10.0.0.23"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+	if out := renderRogueDHCP(StyleSet{}, "192.168.1.1", false, 0, nil); !strings.Contains(out, "stopped") || !strings.Contains(out, "No rogue offers seen") {
+		t.Errorf("unexpected output for a stopped detector:\n%s", out)
+	}
+}
+
+func TestRenderLengthHistogram(t *testing.T) {
+	out := renderLengthHistogram(map[string]int{"0-64": 10, "1025-1500": 5, ">1500": 1})
+	lines := strings.Split(out, "\n")
+	if len(lines) < 8 || !strings.HasPrefix(lines[0], "Packet Lengths") {
+		t.Fatalf("unexpected histogram:\n%s", out)
+	}
+	if got := strings.Count(lines[1], "█"); got != histogramBarWidth {
+		t.Errorf("largest bucket bar = %d blocks, want %d", got, histogramBarWidth)
+	}
+	if got := strings.Count(lines[6], "█"); got != histogramBarWidth/2 {
+		t.Errorf("1025-1500 bar = %d blocks, want %d", got, histogramBarWidth/2)
+	}
+	if !strings.Contains(lines[7], ">1500") || !strings.Contains(lines[7], "█") {
+		t.Errorf("expected a bar for >1500, got %q", lines[7])
+	}
+	if renderLengthHistogram(map[string]int{"0-64": 0}) != "" {
+		t.Error("expected no output for an empty histogram")
+	}
+}
+
+func TestRenderConsoleTransfer(t *testing.T) {
+	out := renderConsoleTransfer("/tmp/fw/c2960.bin", 512*1024, 2048*1024)
+	for _, want := range []string{"c2960.bin", "512.0 KB / 2.0 MB", "(25%)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in %q", want, out)
+		}
+	}
+	if out := renderConsoleTransfer("empty.txt", 0, 0); !strings.Contains(out, "(100%)") {
+		t.Errorf("empty file should show complete, got %q", out)
+	}
+}
+
+func TestRenderMacroList(t *testing.T) {
+	out := renderMacroList([]string{"backup", "vlan-setup"}, 1)
+	if !strings.Contains(out, " > vlan-setup") || !strings.Contains(out, "   backup") {
+		t.Errorf("selected macro not marked: %q", out)
+	}
+	if out := renderMacroList(nil, 0); !strings.Contains(out, "No saved macros") {
+		t.Errorf("empty list not reported: %q", out)
+	}
+}
+
+func TestFormatConsoleAlert(t *testing.T) {
+	at := time.Date(2024, 3, 1, 14, 5, 9, 0, time.UTC)
+	got := formatConsoleAlert(at, "link-down", "Interface Gi0/1, changed state to down")
+	if want := "⚠ 14:05:09 [link-down] Interface Gi0/1, changed state to down"; got != want {
+		t.Errorf("formatConsoleAlert() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderBaselineDiff(t *testing.T) {
+	diff := []console.DiffLine{
+		{Op: console.DiffEqual, Text: "Cisco IOS Software"},
+		{Op: console.DiffRemoved, Text: "uptime is 1 week"},
+		{Op: console.DiffAdded, Text: "uptime is 2 weeks"},
+	}
+	out := renderBaselineDiff(StyleSet{}, "Cisco:IOS:FOC1234X5YZ", diff, false)
+	for _, want := range []string{"1 added, 1 removed", "- uptime is 1 week", "+ uptime is 2 weeks"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in %q", want, out)
+		}
+	}
+	if strings.Contains(out, "Cisco IOS Software\n") {
+		t.Errorf("unchanged lines should be hidden: %q", out)
+	}
+
+	if out := renderBaselineDiff(StyleSet{}, "k", diff[:1], false); !strings.Contains(out, "No changes") {
+		t.Errorf("identical baseline not reported: %q", out)
+	}
+	if out := renderBaselineDiff(StyleSet{}, "k", nil, true); !strings.Contains(out, "saved") {
+		t.Errorf("first capture not reported: %q", out)
+	}
+}
+
+func TestFormatReconnectStatus(t *testing.T) {
+	tests := []struct {
+		ev   console.ReconnectEvent
+		want string
+	}{
+		{console.ReconnectEvent{Attempt: 2, Max: 5}, "ttyUSB0: Reconnecting (attempt 2/5)..."},
+		{console.ReconnectEvent{Attempt: 2, Max: 5, Connected: true}, "ttyUSB0: reconnected"},
+		{console.ReconnectEvent{Attempt: 5, Max: 5, GaveUp: true}, "ttyUSB0: reconnect failed after 5 attempts; press 'x' to close"},
+	}
+	for _, tt := range tests {
+		if got := formatReconnectStatus("ttyUSB0", tt.ev); got != tt.want {
+			t.Errorf("formatReconnectStatus(%+v) = %q, want %q", tt.ev, got, tt.want)
+		}
+	}
+}
+
+func TestAuditSNMPWalk(t *testing.T) {
+	m := initialModelForTest()
+	m.mode = ViewAudit
+	m.layer = LayerView
+	m.auditView = &AuditView{}
+
+	newM, _ := m.handleKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'S'}})
+	m = newM.(Model)
+	if m.inputActive || m.auditView.statusMessage != "No gateway to query" {
+		t.Fatalf("Expected no prompt without a gateway, got active=%v status=%q", m.inputActive, m.auditView.statusMessage)
+	}
+
+	m.details = &netpkg.InterfaceDetails{DefaultGateways: []string{"192.168.1.1"}}
+	newM, _ = m.handleKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'S'}})
+	m = newM.(Model)
+	if !m.inputActive || m.inputValue != "public" {
+		t.Fatalf("Expected a community prompt prefilled with public, got active=%v value=%q", m.inputActive, m.inputValue)
+	}
+	if cmd := m.inputSubmit(&m, "public"); cmd == nil || !m.auditView.snmpWalking {
+		t.Fatal("submitting should start the walk")
+	}
+
+	newM, _ = m.Update(snmpWalkResultMsg{host: "192.168.1.1", vars: []scan.SNMPVar{
+		{OID: "1.3.6.1.2.1.1.1.0", Value: "Linux gw 6.1.0\nsecond line"},
+		{OID: "1.3.6.1.2.1.1.5.0", Value: "gw"},
+		{OID: "1.3.6.1.2.1.1.9.1.2.1", Value: "1.3.6.1.6.3.1"},
+	}})
+	m = newM.(Model)
+	out := m.renderAuditView()
+	for _, want := range []string{"SNMP system group of 192.168.1.1:", "sysDescr", "Linux gw 6.1.0\n", "sysName", "1.3.6.1.2.1.1.9.1.2.1", "3 variables"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in audit view, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "second line") {
+		t.Errorf("only the first line of a value should be shown:\n%s", out)
+	}
+}
+
+func TestRenderAuditResult(t *testing.T) {
+	res := &scan.ScanResult{
+		TotalHosts:  254,
+		ActiveHosts: 1,
+		Hosts: []scan.HostResult{
+			{IP: "192.168.1.2"},
+			{
+				IP: "192.168.1.3",
+				SNMP: &scan.SNMPResult{
+					Community:   "public",
+					SysName:     "ap-lobby",
+					SysDescr:    "Cisco AP Software, ap3g2-k9w8\nTechnical Support: http://www.cisco.com/techsupport",
+					SysLocation: "Lobby",
+				},
+			},
+			{
+				IP:       "192.168.1.1",
+				Hostname: "gw.lan",
+				MAC:      "00:00:0c:9f:f0:01",
+				Vendor:   "Cisco Systems",
+				OsHint:   "Network device",
+				Services: []scan.ServiceInfo{{Port: 22, Protocol: "tcp", State: "open", Service: "SSH", Banner: "SSH-2.0-dropbear_2022.83"}},
+				UDPServices: []scan.ServiceInfo{
+					{Port: 161, Protocol: "udp", State: "open", Service: "SNMP"},
+					{Port: 69, Protocol: "udp", State: "open|filtered", Service: "TFTP"},
+				},
+			},
+		},
+	}
+
+	out := renderAuditResult(res)
+	for _, want := range []string{"Active hosts: 1 of 254", "192.168.1.1 (gw.lan) [Network device]", "MAC 00:00:0c:9f:f0:01  Cisco Systems", "22/tcp open", "SSH-2.0-dropbear_2022.83", "161/udp open ", "69/udp open|filtered", "TFTP", "SNMP (public): ap-lobby", "Cisco AP Software, ap3g2-k9w8\n", "Location: Lobby"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "192.168.1.2") {
+		t.Errorf("host without services listed:\n%s", out)
+	}
+}
+
+func TestParsePortList(t *testing.T) {
+	tests := []struct {
+		val     string
+		want    []int
+		wantErr bool
+	}{
+		{val: "8081", want: []int{8081}},
+		{val: "8081, 9100 161", want: []int{8081, 9100, 161}},
+		{val: "", wantErr: true},
+		{val: "22,http", wantErr: true},
+		{val: "0", wantErr: true},
+		{val: "70000", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parsePortList(tt.val)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parsePortList(%q) error = %v, wantErr %v", tt.val, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parsePortList(%q) = %v, want %v", tt.val, got, tt.want)
+		}
+	}
+}
+
+func TestParseTimeout(t *testing.T) {
+	tests := []struct {
+		val     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{val: "1500ms", want: 1500 * time.Millisecond},
+		{val: " 10s ", want: 10 * time.Second},
+		{val: "2000", want: 2 * time.Second},
+		{val: "5m", want: 5 * time.Minute},
+		{val: "50ms", wantErr: true},
+		{val: "6m", wantErr: true},
+		{val: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseTimeout(tt.val)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseTimeout(%q) error = %v, wantErr %v", tt.val, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseTimeout(%q) = %s, want %s", tt.val, got, tt.want)
+		}
+	}
+}
+
+func TestSettingsSetTimeout(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	store.SetTimeoutOverride(30 * time.Second)
+	t.Cleanup(func() { store.SetTimeoutOverride(0) })
+
+	m := initialModelForTest()
+	m.config = store.DefaultConfig()
+	m = m.activateMode(ViewSettings)
+	m.layer = LayerView
+	if out := m.renderSettingsView(); !strings.Contains(out, "Overridden by --timeout 30s") {
+		t.Errorf("expected the session override, got:\n%s", out)
+	}
+
+	newM, _ := m.handleKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	m = newM.(Model)
+	if !m.inputActive || m.inputValue != "30s" {
+		t.Fatalf("expected the timeout prompt holding 30s, active=%v value=%q", m.inputActive, m.inputValue)
+	}
+
+	m.inputValue = "20ms"
+	newM, _ = m.handleKeys(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newM.(Model)
+	if m.config.DiagnosticsTimeout != store.DefaultConfig().DiagnosticsTimeout || !strings.Contains(m.statusMsg, "must be between") {
+		t.Errorf("expected 20ms to be rejected, timeout=%d status=%q", m.config.DiagnosticsTimeout, m.statusMsg)
+	}
+
+	newM, _ = m.handleKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+	m = newM.(Model)
+	m.inputValue = "2.5s"
+	newM, _ = m.handleKeys(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newM.(Model)
+	if m.config.DiagnosticsTimeout != 2500 {
+		t.Errorf("DiagnosticsTimeout = %d, want 2500", m.config.DiagnosticsTimeout)
+	}
+	if store.TimeoutOverride() != 0 {
+		t.Errorf("expected the setting to replace the --timeout override, still %s", store.TimeoutOverride())
+	}
+}
+
+func TestEditPortList(t *testing.T) {
+	current := []int{80, 22, 443}
+	if got := editPortList(current, []int{8080, 22}, true); !reflect.DeepEqual(got, []int{22, 80, 443, 8080}) {
+		t.Errorf("add = %v", got)
+	}
+	if got := editPortList(current, []int{80, 9999}, false); !reflect.DeepEqual(got, []int{22, 443}) {
+		t.Errorf("remove = %v", got)
+	}
+	if !reflect.DeepEqual(current, []int{80, 22, 443}) {
+		t.Errorf("current modified: %v", current)
+	}
+}
+
+func TestRenderVLANResults(t *testing.T) {
+	out := renderVLANResults([]vlan.LeaseResult{
+		{VLAN: 10, IP: "10.10.0.23", Router: "10.10.0.1", DNS: []string{"10.10.0.1", "1.1.1.1"}},
+		{VLAN: 20, Err: "no DHCP lease obtained"},
+	})
+	for _, want := range []string{"10     10.10.0.23       10.10.0.1        10.10.0.1, 1.1.1.1", "20     no DHCP lease obtained"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestVLANDiscoverFillsRange(t *testing.T) {
+	m := initialModelForTest()
+	m.selectedIface = "eth0"
+	m = m.activateMode(ViewVLAN)
+	m.layer = LayerView
+	m.vlanView.discovering = true
+
+	newM, _ := m.Update(vlanDiscoverMsg{vlans: []int{10, 11, 12, 30}})
+	m = newM.(Model)
+	if m.vlanView.discovering {
+		t.Error("expected discovery to be finished")
+	}
+	if m.vlanView.rangeStr != "10-12,30" {
+		t.Errorf("rangeStr = %q, want %q", m.vlanView.rangeStr, "10-12,30")
+	}
+	if len(m.vlanView.vlans) != 4 {
+		t.Errorf("vlans = %v, want 4 IDs", m.vlanView.vlans)
+	}
+}
+
+func TestRenderSnapshotDiff(t *testing.T) {
+	if out := renderSnapshotDiff(&store.SnapshotDiff{}); !strings.Contains(out, "none") {
+		t.Errorf("expected an empty diff to say none, got:\n%s", out)
+	}
+
+	out := renderSnapshotDiff(&store.SnapshotDiff{
+		IPsRemoved:     []string{"192.168.1.20"},
+		GatewayChanged: true,
+		NewGateway:     "192.168.1.254",
+		DNSChanged:     true,
+		OldDNS:         []string{"192.168.1.1"},
+		NewDNS:         []string{"1.1.1.1", "8.8.8.8"},
+		PortsAdded:     []store.ServiceChange{{Host: "192.168.1.254", Port: 23, Protocol: "tcp", Service: "Telnet"}},
+	})
+	for _, want := range []string{"- address 192.168.1.20", "gateway none -> 192.168.1.254", "DNS 192.168.1.1 -> 1.1.1.1, 8.8.8.8", "+ 192.168.1.254 23/tcp Telnet"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderScanDiff(t *testing.T) {
+	if out := renderScanDiff(&scan.ScanDiff{}); !strings.Contains(out, "none") {
+		t.Errorf("empty diff rendered as:\n%s", out)
+	}
+
+	out := renderScanDiff(&scan.ScanDiff{
+		NewHosts:    []string{"192.168.1.50"},
+		GoneHosts:   []string{"192.168.1.10"},
+		PortChanges: []scan.PortChange{{Host: "192.168.1.1", Port: 23, Protocol: "tcp", OldState: "closed", NewState: "open"}},
+	})
+	for _, want := range []string{"+ 192.168.1.50", "- 192.168.1.10", "~ 192.168.1.1 23/tcp closed -> open"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestSpeedtestBackend(t *testing.T) {
+	tests := []struct {
+		iface string
+		cfg   *store.Config
+		want  string
+	}{
+		{cfg: nil, want: "speedtest.net"},
+		{iface: "en0", cfg: &store.Config{}, want: "speedtest.net (via en0)"},
+		{iface: "en0", cfg: &store.Config{Iperf3Server: "10.0.0.5"}, want: "iperf3 (10.0.0.5:5201)"},
+		{cfg: &store.Config{Iperf3Server: "iperf.example.com", Iperf3Port: 5300}, want: "iperf3 (iperf.example.com:5300)"},
+	}
+	for _, tt := range tests {
+		if got := speedtestBackend(tt.iface, tt.cfg); got != tt.want {
+			t.Errorf("speedtestBackend(%q, %+v) = %q, want %q", tt.iface, tt.cfg, got, tt.want)
+		}
+	}
+}
+
+func TestSnapshotPassphrasePromptIsMasked(t *testing.T) {
+	m := initialModelForTest()
+	m.selectedIface = "eth0"
+	m.config = store.DefaultConfig()
+	m.config.EncryptSnapshots = true
+	m = m.activateMode(ViewSnap)
+	m.layer = LayerView
+
+	newM, _ := m.handleKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	m = newM.(Model)
+	if !m.inputActive || !m.inputMasked {
+		t.Fatalf("expected a masked passphrase prompt, inputActive=%v inputMasked=%v", m.inputActive, m.inputMasked)
+	}
+
+	newM, _ = m.handleKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("hunter2")})
+	m = newM.(Model)
+	m.width, m.height = 80, 24
+	if view := m.View(); strings.Contains(view, "hunter2") || !strings.Contains(view, "*******") {
+		t.Errorf("expected the passphrase to be masked, got:\n%s", view)
+	}
+
+	newM, cmd := m.handleKeys(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newM.(Model)
+	if cmd == nil || !m.snapView.running {
+		t.Error("expected the snapshot to start after entering a passphrase")
+	}
+	if m.inputValue != "" || m.inputMasked {
+		t.Errorf("expected the passphrase to be cleared, inputValue=%q inputMasked=%v", m.inputValue, m.inputMasked)
+	}
+}
+
+func TestRenderSettingsShowsConfigErrors(t *testing.T) {
+	m := initialModelForTest()
+	m.config = store.DefaultConfig()
+	if out := m.renderSettingsView(); strings.Contains(out, "⚠") || strings.Contains(out, "Config problems") {
+		t.Errorf("expected no errors for the default config, got:\n%s", out)
+	}
+
+	m.config.DiagnosticsTimeout = 0
+	m.config.Console.CRLFMode = "crlf"
+	m.config.Iperf3Port = 70000
+	out := m.renderSettingsView()
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "Diagnostics Timeout:") && !strings.Contains(line, "must be positive") {
+			t.Errorf("expected the timeout error on its line, got %q", line)
+		}
+		if strings.HasPrefix(line, "Console Line Endings:") && !strings.Contains(line, "must be one of") {
+			t.Errorf("expected the CRLF error on its line, got %q", line)
+		}
+	}
+	if !strings.Contains(out, "Config problems") || !strings.Contains(out, "iperf3_port") {
+		t.Errorf("expected the iperf3 port error listed separately, got:\n%s", out)
+	}
+}
+
+func TestNextProfile(t *testing.T) {
+	profiles := []string{"lab", "office"}
+	tests := []struct {
+		current string
+		want    string
+	}{
+		{"", "lab"},
+		{"lab", "office"},
+		{"office", ""},
+		{"deleted", ""},
+	}
+	for _, tt := range tests {
+		if got := nextProfile(tt.current, profiles); got != tt.want {
+			t.Errorf("nextProfile(%q) = %q, want %q", tt.current, got, tt.want)
+		}
+	}
+	if got := nextProfile("", nil); got != "" {
+		t.Errorf("nextProfile with no profiles = %q, want config.json", got)
+	}
+}
+
+func TestSettingsSwitchProfile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Cleanup(func() { store.SetActiveProfile("") })
+
+	lab := store.DefaultConfig()
+	lab.DiagnosticsTimeout = 5000
+	if err := store.SaveProfile("lab", lab); err != nil {
+		t.Fatal(err)
+	}
+
+	m := initialModelForTest()
+	m.config = store.DefaultConfig()
+	m = m.activateMode(ViewSettings)
+	m.layer = LayerView
+	if out := m.renderSettingsView(); !strings.Contains(out, "Profiles: default, lab") {
+		t.Errorf("expected the profile list, got:\n%s", out)
+	}
+
+	newM, _ := m.handleKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})
+	m = newM.(Model)
+	if store.ActiveProfile() != "lab" || m.config.DiagnosticsTimeout != 5000 {
+		t.Errorf("expected the lab profile, active=%q timeout=%d", store.ActiveProfile(), m.config.DiagnosticsTimeout)
+	}
+
+	newM, _ = m.handleKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})
+	m = newM.(Model)
+	if store.ActiveProfile() != "" || m.config.DiagnosticsTimeout != store.DefaultConfig().DiagnosticsTimeout {
+		t.Errorf("expected config.json again, active=%q timeout=%d", store.ActiveProfile(), m.config.DiagnosticsTimeout)
+	}
+}
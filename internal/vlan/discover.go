@@ -0,0 +1,73 @@
+package vlan
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/pcap"
+)
+
+// 802.1Q and 802.1ad (QinQ) tag protocol identifiers
+const (
+	etherTypeDot1Q  = 0x8100
+	etherTypeDot1AD = 0x88a8
+)
+
+// DiscoverVLANs passively listens on iface for 802.1Q tagged frames and
+// returns the sorted VLAN IDs seen within duration. It only finds VLANs
+// carried to this port, such as on a trunk, and requires root.
+func DiscoverVLANs(iface string, duration time.Duration) ([]int, error) {
+	// Open interface for passive capture
+	handle, err := pcap.OpenLive(iface, 64, true, pcap.BlockForever)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open interface %s: %w (requires sudo/root)", iface, err)
+	}
+	defer handle.Close()
+
+	if err := handle.SetBPFFilter("vlan"); err != nil {
+		return nil, fmt.Errorf("failed to set VLAN filter: %w", err)
+	}
+
+	seen := make(map[int]bool)
+	packetSource := gopacket.NewPacketSource(handle, handle.LinkType())
+
+	timeout := time.After(duration)
+	packetChan := packetSource.Packets()
+
+	for {
+		select {
+		case <-timeout:
+			return sortedVLANs(seen), nil
+
+		case packet := <-packetChan:
+			if packet == nil {
+				continue
+			}
+			if id, ok := vlanIDFromFrame(packet.Data()); ok {
+				seen[id] = true
+			}
+		}
+	}
+}
+
+// vlanIDFromFrame returns the outer VLAN ID of a tagged Ethernet frame. The
+// tag follows the two MAC addresses, and its low 12 bits are the ID.
+func vlanIDFromFrame(data []byte) (int, bool) {
+	if len(data) < 16 {
+		return 0, false
+	}
+	switch binary.BigEndian.Uint16(data[12:14]) {
+	case etherTypeDot1Q, etherTypeDot1AD:
+	default:
+		return 0, false
+	}
+
+	id := int(binary.BigEndian.Uint16(data[14:16]) & 0x0fff)
+	// 0 is priority-only tagging and 4095 is reserved
+	if id == 0 || id == 4095 {
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,40 @@
+package vlan
+
+import "testing"
+
+// taggedFrame builds an Ethernet header with the given tag protocol and TCI
+func taggedFrame(tpid, tci uint16) []byte {
+	frame := make([]byte, 18)
+	copy(frame[0:6], []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	copy(frame[6:12], []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55})
+	frame[12], frame[13] = byte(tpid>>8), byte(tpid)
+	frame[14], frame[15] = byte(tci>>8), byte(tci)
+	frame[16], frame[17] = 0x08, 0x00
+	return frame
+}
+
+func TestVLANIDFromFrame(t *testing.T) {
+	tests := []struct {
+		name   string
+		frame  []byte
+		want   int
+		wantOK bool
+	}{
+		{name: "dot1q", frame: taggedFrame(0x8100, 100), want: 100, wantOK: true},
+		{name: "priority bits ignored", frame: taggedFrame(0x8100, 0xa000|20), want: 20, wantOK: true},
+		{name: "qinq outer tag", frame: taggedFrame(0x88a8, 4094), want: 4094, wantOK: true},
+		{name: "untagged ipv4", frame: taggedFrame(0x0800, 100)},
+		{name: "priority only", frame: taggedFrame(0x8100, 0x6000)},
+		{name: "reserved id", frame: taggedFrame(0x8100, 0x0fff)},
+		{name: "truncated", frame: taggedFrame(0x8100, 100)[:15]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := vlanIDFromFrame(tt.frame)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("vlanIDFromFrame() = %d, %v, want %d, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
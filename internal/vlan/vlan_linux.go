@@ -5,6 +5,14 @@ package vlan
 import (
 	"context"
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alexpitcher/LanAudit/internal/consent"
 )
 
 // LeaseResult contains DHCP lease information for a VLAN
@@ -18,7 +26,159 @@ type LeaseResult struct {
 
 const ConsentToken = "VLAN-YES"
 
-// TestVLANs is not implemented on Linux
+// dhclientScript replaces dhclient's hook script so a test lease never
+// installs routes or rewrites /etc/resolv.conf on the auditing host
+const dhclientScript = "/bin/true"
+
+var (
+	leaseBlockPattern = regexp.MustCompile(`(?s)lease\s*\{(.*?)\}`)
+	boundPattern      = regexp.MustCompile(`bound to ([0-9.]+)`)
+	ackPattern        = regexp.MustCompile(`DHCPACK of ([0-9.]+)`)
+)
+
+// TestVLANs creates ephemeral VLAN interfaces and tests DHCP
 func TestVLANs(ctx context.Context, phy string, vlans []int, keep bool, consentToken string) ([]LeaseResult, error) {
-	return nil, fmt.Errorf("VLAN testing not implemented on Linux")
+	// Validate consent
+	if err := consent.Confirm(consentToken, ConsentToken); err != nil {
+		return nil, fmt.Errorf("consent required: %w", err)
+	}
+
+	// Log consent
+	meta := map[string]string{
+		"physical_interface": phy,
+		"vlans":              fmt.Sprintf("%v", vlans),
+		"keep":               strconv.FormatBool(keep),
+	}
+	if err := consent.Log("VLAN_TEST", meta); err != nil {
+		return nil, fmt.Errorf("failed to log consent: %w", err)
+	}
+
+	results := make([]LeaseResult, 0, len(vlans))
+
+	for _, vlanID := range vlans {
+		result := testSingleVLAN(ctx, phy, vlanID, keep)
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// testSingleVLAN tests a single VLAN interface
+func testSingleVLAN(ctx context.Context, phy string, vlanID int, keep bool) LeaseResult {
+	result := LeaseResult{VLAN: vlanID}
+	ifaceName := fmt.Sprintf("vlan%d", vlanID)
+	id := strconv.Itoa(vlanID)
+
+	// Create VLAN interface
+	if err := runCommand(ctx, "ip", "link", "add", "link", phy, "name", ifaceName, "type", "vlan", "id", id); err != nil {
+		result.Err = fmt.Sprintf("create failed: %v", err)
+		return result
+	}
+
+	// If not keeping, delete the link when done
+	if !keep {
+		defer runCommand(context.Background(), "ip", "link", "delete", ifaceName)
+	}
+
+	// Bring interface up
+	if err := runCommand(ctx, "ip", "link", "set", ifaceName, "up"); err != nil {
+		result.Err = fmt.Sprintf("bring up failed: %v", err)
+		return result
+	}
+
+	// dhclient gets its own lease and pid files, so neither a lease of
+	// another interface nor the system's dhclient is touched
+	dir, err := os.MkdirTemp("", "lanaudit-"+ifaceName+"-")
+	if err != nil {
+		result.Err = fmt.Sprintf("DHCP request failed: %v", err)
+		return result
+	}
+	// dhclient stays running in the background once bound, so release the
+	// lease (stopping it) before the files and link go away. Deferred
+	// calls run last first, so this comes before the link is deleted.
+	defer func() {
+		runCommand(context.Background(), "dhclient", dhclientArgs(dir, ifaceName, "-r")...)
+		os.RemoveAll(dir)
+	}()
+
+	// Request DHCP, trying once
+	output, err := runCommandCombined(ctx, "dhclient", dhclientArgs(dir, ifaceName, "-1", "-v")...)
+	if err != nil {
+		result.Err = fmt.Sprintf("DHCP request failed: %v", err)
+		return result
+	}
+
+	// Prefer the lease file, which has the router and DNS servers
+	if data, err := os.ReadFile(filepath.Join(dir, "dhclient.leases")); err == nil {
+		parseDHClientLeases(string(data), ifaceName, &result)
+	}
+	if result.IP == "" {
+		parseDHClientOutput(output, &result)
+	}
+
+	if result.IP == "" {
+		result.Err = "no DHCP lease obtained"
+	}
+
+	return result
+}
+
+// dhclientArgs builds a dhclient command line for iface that keeps its
+// lease and pid files in dir and runs no hook script
+func dhclientArgs(dir, iface string, args ...string) []string {
+	return append(args,
+		"-sf", dhclientScript,
+		"-lf", filepath.Join(dir, "dhclient.leases"),
+		"-pf", filepath.Join(dir, "dhclient.pid"),
+		iface)
+}
+
+// runCommand executes a command and returns error if it fails
+func runCommand(ctx context.Context, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	return cmd.Run()
+}
+
+// runCommandCombined executes a command and returns its stdout and stderr
+func runCommandCombined(ctx context.Context, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+// parseDHClientLeases fills result from the newest lease for iface in a
+// dhclient.leases file. dhclient appends leases, so the last block wins.
+func parseDHClientLeases(leases, iface string, result *LeaseResult) {
+	blocks := leaseBlockPattern.FindAllStringSubmatch(leases, -1)
+	for i := len(blocks) - 1; i >= 0; i-- {
+		block := blocks[i][1]
+		if !strings.Contains(block, fmt.Sprintf("interface %q;", iface)) {
+			continue
+		}
+
+		for _, line := range strings.Split(block, "\n") {
+			line = strings.TrimSuffix(strings.TrimSpace(line), ";")
+
+			if v, ok := strings.CutPrefix(line, "fixed-address "); ok {
+				result.IP = strings.TrimSpace(v)
+			}
+			if v, ok := strings.CutPrefix(line, "option routers "); ok {
+				result.Router = strings.TrimSpace(strings.Split(v, ",")[0])
+			}
+			if v, ok := strings.CutPrefix(line, "option domain-name-servers "); ok {
+				result.DNS = strings.Fields(strings.ReplaceAll(v, ",", " "))
+			}
+		}
+		return
+	}
+}
+
+// parseDHClientOutput extracts the leased address from dhclient -v output,
+// which doesn't include the router or DNS servers
+func parseDHClientOutput(output string, result *LeaseResult) {
+	if m := boundPattern.FindStringSubmatch(output); m != nil {
+		result.IP = m[1]
+	} else if m := ackPattern.FindStringSubmatch(output); m != nil {
+		result.IP = m[1]
+	}
 }
@@ -0,0 +1,82 @@
+//go:build linux
+
+package vlan
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseDHClientLeases(t *testing.T) {
+	data, err := os.ReadFile("testdata/dhclient.leases")
+	if err != nil {
+		t.Fatalf("failed to read test data: %v", err)
+	}
+
+	tests := []struct {
+		iface      string
+		wantIP     string
+		wantRouter string
+		wantDNS    []string
+	}{
+		// The newer of the two vlan100 leases
+		{"vlan100", "192.168.100.50", "192.168.100.1", []string{"192.168.100.1", "8.8.8.8"}},
+		{"eth0", "10.0.0.15", "10.0.0.1", []string{"10.0.0.1"}},
+		{"vlan200", "", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.iface, func(t *testing.T) {
+			result := &LeaseResult{}
+			parseDHClientLeases(string(data), tt.iface, result)
+
+			if result.IP != tt.wantIP {
+				t.Errorf("IP = %s, want %s", result.IP, tt.wantIP)
+			}
+			if result.Router != tt.wantRouter {
+				t.Errorf("Router = %s, want %s", result.Router, tt.wantRouter)
+			}
+			if !reflect.DeepEqual(result.DNS, tt.wantDNS) {
+				t.Errorf("DNS = %v, want %v", result.DNS, tt.wantDNS)
+			}
+		})
+	}
+}
+
+func TestParseDHClientOutput(t *testing.T) {
+	output := `Internet Systems Consortium DHCP Client 4.4.3-P1
+Listening on LPF/vlan100/02:42:ac:11:00:02
+Sending on   LPF/vlan100/02:42:ac:11:00:02
+DHCPDISCOVER on vlan100 to 255.255.255.255 port 67 interval 3 (xid=0x5c2b1a0e)
+DHCPOFFER of 192.168.100.50 from 192.168.100.1
+DHCPREQUEST for 192.168.100.50 on vlan100 to 255.255.255.255 port 67 (xid=0xe1a2b5c)
+DHCPACK of 192.168.100.50 from 192.168.100.1 (xid=0xe1a2b5c)
+bound to 192.168.100.50 -- renewal in 41225 seconds.
+`
+	result := &LeaseResult{VLAN: 100}
+	parseDHClientOutput(output, result)
+	if result.IP != "192.168.100.50" {
+		t.Errorf("IP = %s, want 192.168.100.50", result.IP)
+	}
+
+	result = &LeaseResult{VLAN: 100}
+	parseDHClientOutput("No DHCPOFFERS received.\n", result)
+	if result.IP != "" {
+		t.Errorf("IP = %s, want none", result.IP)
+	}
+}
+
+func TestDHClientArgs(t *testing.T) {
+	got := dhclientArgs("/tmp/lanaudit-vlan100-1", "vlan100", "-1", "-v")
+	want := []string{
+		"-1", "-v",
+		"-sf", "/bin/true",
+		"-lf", "/tmp/lanaudit-vlan100-1/dhclient.leases",
+		"-pf", "/tmp/lanaudit-vlan100-1/dhclient.pid",
+		"vlan100",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dhclientArgs() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,102 @@
+package vlan
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxVLANRange caps how many VLANs one range may expand to, since each one
+// creates an interface and waits for DHCP
+const MaxVLANRange = 256
+
+// ParseVLANRange expands a list such as "10,20,100-200,300" into sorted,
+// deduplicated VLAN IDs. IDs must be between 1 and 4094 and the list may
+// name at most MaxVLANRange VLANs.
+func ParseVLANRange(s string) ([]int, error) {
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		lo, hi := part, part
+		if a, b, ok := strings.Cut(part, "-"); ok {
+			lo, hi = strings.TrimSpace(a), strings.TrimSpace(b)
+		}
+		start, err := parseVLANID(lo)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseVLANID(hi)
+		if err != nil {
+			return nil, err
+		}
+		if start > end {
+			return nil, fmt.Errorf("invalid VLAN range %q: start is after end", part)
+		}
+		if end-start+1 > MaxVLANRange {
+			return nil, fmt.Errorf("VLAN range %q is larger than %d", part, MaxVLANRange)
+		}
+
+		for id := start; id <= end; id++ {
+			seen[id] = true
+		}
+		if len(seen) > MaxVLANRange {
+			return nil, fmt.Errorf("VLAN list %q names more than %d VLANs", s, MaxVLANRange)
+		}
+	}
+	if len(seen) == 0 {
+		return nil, fmt.Errorf("no VLANs given")
+	}
+
+	return sortedVLANs(seen), nil
+}
+
+// FormatVLANRange is the inverse of ParseVLANRange, collapsing runs of
+// consecutive IDs: [10 11 12 20] becomes "10-12,20". ids must be sorted.
+func FormatVLANRange(ids []int) string {
+	var parts []string
+	for i := 0; i < len(ids); {
+		j := i
+		for j+1 < len(ids) && ids[j+1] == ids[j]+1 {
+			j++
+		}
+		if j == i {
+			parts = append(parts, strconv.Itoa(ids[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", ids[i], ids[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
+
+func sortedVLANs(seen map[int]bool) []int {
+	ids := make([]int, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func parseVLANID(s string) (int, error) {
+	id, err := strconv.Atoi(s)
+	if err != nil || id < 1 || id > 4094 {
+		return 0, fmt.Errorf("invalid VLAN ID %q: must be between 1 and 4094", s)
+	}
+	return id, nil
+}
+
+// TestVLANRange runs TestVLANs for every VLAN named by rangeStr
+func TestVLANRange(ctx context.Context, phy, rangeStr string, keep bool, consentToken string) ([]LeaseResult, error) {
+	vlans, err := ParseVLANRange(rangeStr)
+	if err != nil {
+		return nil, err
+	}
+	return TestVLANs(ctx, phy, vlans, keep, consentToken)
+}
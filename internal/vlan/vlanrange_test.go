@@ -0,0 +1,78 @@
+package vlan
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseVLANRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    []int
+		wantLen int // checked instead of want for long ranges
+		wantErr bool
+	}{
+		{name: "single", in: "100", want: []int{100}},
+		{name: "mixed", in: "300,10, 20,5-7", want: []int{5, 6, 7, 10, 20, 300}},
+		{name: "overlap", in: "10-12,11,12-13", want: []int{10, 11, 12, 13}},
+		{name: "bounds", in: "1,4094", want: []int{1, 4094}},
+		{name: "trailing comma", in: "10,", want: []int{10}},
+		{name: "zero", in: "0", wantErr: true},
+		{name: "too high", in: "4095", wantErr: true},
+		{name: "reversed", in: "200-100", wantErr: true},
+		{name: "not a number", in: "10,abc", wantErr: true},
+		{name: "open range", in: "100-", wantErr: true},
+		{name: "empty", in: " ", wantErr: true},
+		{name: "largest range", in: "1-256", wantLen: MaxVLANRange},
+		{name: "range too large", in: "1-257", wantErr: true},
+		{name: "list too large", in: "1-200,1000-1056", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseVLANRange(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseVLANRange(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.wantLen > 0 {
+				if len(got) != tt.wantLen {
+					t.Errorf("ParseVLANRange(%q) returned %d IDs, want %d", tt.in, len(got), tt.wantLen)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseVLANRange(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatVLANRange(t *testing.T) {
+	tests := []struct {
+		in   []int
+		want string
+	}{
+		{in: nil, want: ""},
+		{in: []int{100}, want: "100"},
+		{in: []int{10, 11, 12, 20}, want: "10-12,20"},
+		{in: []int{1, 3, 4, 4094}, want: "1,3-4,4094"},
+	}
+
+	for _, tt := range tests {
+		got := FormatVLANRange(tt.in)
+		if got != tt.want {
+			t.Errorf("FormatVLANRange(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+		if got == "" {
+			continue
+		}
+		back, err := ParseVLANRange(got)
+		if err != nil || !reflect.DeepEqual(back, tt.in) {
+			t.Errorf("ParseVLANRange(%q) = %v, %v, want %v", got, back, err, tt.in)
+		}
+	}
+}
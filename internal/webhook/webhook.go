@@ -0,0 +1,135 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/alexpitcher/LanAudit/internal/diagnostics"
+	"github.com/alexpitcher/LanAudit/internal/store"
+)
+
+const (
+	// Timeout bounds each delivery attempt
+	Timeout = 10 * time.Second
+
+	// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the body
+	// when a secret is configured
+	SignatureHeader = "X-LanAudit-Signature"
+)
+
+// retryDelay is the pause before retrying after a 5xx; shortened in tests
+var retryDelay = 2 * time.Second
+
+// Payload is the JSON body posted to the webhook
+type Payload struct {
+	Timestamp time.Time `json:"timestamp"`
+	Interface string    `json:"interface"`
+	Failures  []string  `json:"failures"`
+}
+
+// Failures describes the checks r failed: gateway packet loss, system DNS
+// resolution and the HTTPS probe. It is empty for a healthy result.
+func Failures(r *diagnostics.Result) []string {
+	var failures []string
+	if r.Ping.Loss > 0 {
+		failures = append(failures, fmt.Sprintf("%.0f%% packet loss to gateway %s", r.Ping.Loss, r.Gateway))
+	}
+	if !r.DNS.SystemOK {
+		msg := "system DNS resolution failed"
+		if r.DNS.Err != "" {
+			msg += ": " + r.DNS.Err
+		}
+		failures = append(failures, msg)
+	}
+	if !r.HTTPS.OK {
+		msg := "HTTPS probe failed"
+		if r.HTTPS.Target != "" {
+			msg = fmt.Sprintf("HTTPS probe of %s failed", r.HTTPS.Target)
+		}
+		if r.HTTPS.Err != "" {
+			msg += ": " + r.HTTPS.Err
+		}
+		failures = append(failures, msg)
+	}
+	return failures
+}
+
+// Notify posts r's failures to url without a signature
+func Notify(url string, r *diagnostics.Result) error {
+	return NotifySigned(url, "", r)
+}
+
+// NotifySigned posts r's failures to url, signing the body with secret
+// when it is not empty. A 5xx response is retried once.
+func NotifySigned(url, secret string, r *diagnostics.Result) error {
+	body, err := json.Marshal(Payload{
+		Timestamp: r.Timestamp,
+		Interface: r.Interface,
+		Failures:  Failures(r),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	client := &http.Client{Timeout: Timeout}
+	status, err := post(client, url, secret, body)
+	if err == nil && status >= 500 {
+		time.Sleep(retryDelay)
+		status, err = post(client, url, secret, body)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %w", err)
+	}
+	if status < 200 || status > 299 {
+		return fmt.Errorf("webhook returned HTTP %d", status)
+	}
+	return nil
+}
+
+// NotifyIfFailed posts r when the config enables failure notifications and
+// r has failures. It reports whether a notification was sent.
+func NotifyIfFailed(config *store.Config, r *diagnostics.Result) (bool, error) {
+	if config == nil || r == nil || !config.WebhookOnFailure || config.WebhookURL == "" {
+		return false, nil
+	}
+	if len(Failures(r)) == 0 {
+		return false, nil
+	}
+	if err := NotifySigned(config.WebhookURL, config.WebhookSecret, r); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func post(client *http.Client, url, secret string, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(secret, body))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
+
+// Sign returns the hex HMAC-SHA256 of body keyed with secret, for receivers
+// checking SignatureHeader
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
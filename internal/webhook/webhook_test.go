@@ -0,0 +1,130 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alexpitcher/LanAudit/internal/diagnostics"
+	"github.com/alexpitcher/LanAudit/internal/store"
+)
+
+// receiver records the requests posted to it, answering with statuses in
+// turn and 200 once they run out
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	bodies   [][]byte
+	sigs     []string
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.bodies = append(rc.bodies, body)
+	rc.sigs = append(rc.sigs, r.Header.Get(SignatureHeader))
+	status := http.StatusOK
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func failedResult() *diagnostics.Result {
+	return &diagnostics.Result{
+		Timestamp: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		Interface: "eth0",
+		Gateway:   "192.168.1.1",
+		Ping:      diagnostics.PingResult{Loss: 25},
+		DNS:       diagnostics.DNSResult{SystemOK: false, Err: "timeout"},
+		HTTPS:     diagnostics.HTTPSResult{OK: true},
+	}
+}
+
+func TestNotify(t *testing.T) {
+	rc := &receiver{}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	if err := Notify(srv.URL, failedResult()); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if len(rc.bodies) != 1 || rc.sigs[0] != "" {
+		t.Fatalf("got %d requests, signature %q; want 1 unsigned", len(rc.bodies), rc.sigs)
+	}
+	var got Payload
+	if err := json.Unmarshal(rc.bodies[0], &got); err != nil {
+		t.Fatalf("body: %v", err)
+	}
+	want := Payload{
+		Timestamp: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		Interface: "eth0",
+		Failures:  []string{"25% packet loss to gateway 192.168.1.1", "system DNS resolution failed: timeout"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("payload = %+v, want %+v", got, want)
+	}
+}
+
+func TestNotifySignedRetries(t *testing.T) {
+	retryDelay = 0
+	t.Cleanup(func() { retryDelay = 2 * time.Second })
+
+	rc := &receiver{statuses: []int{http.StatusBadGateway}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	if err := NotifySigned(srv.URL, "s3cret", failedResult()); err != nil {
+		t.Fatalf("NotifySigned: %v", err)
+	}
+	if len(rc.bodies) != 2 {
+		t.Fatalf("got %d requests, want a retry after the 502", len(rc.bodies))
+	}
+	if want := "sha256=" + Sign("s3cret", rc.bodies[1]); rc.sigs[1] != want {
+		t.Errorf("signature = %q, want %q", rc.sigs[1], want)
+	}
+
+	// Only one retry, and none for 4xx
+	rc.statuses = []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable}
+	if err := NotifySigned(srv.URL, "", failedResult()); err == nil {
+		t.Error("expected an error after two 503s")
+	}
+	rc.statuses = []int{http.StatusNotFound}
+	before := len(rc.bodies)
+	if err := NotifySigned(srv.URL, "", failedResult()); err == nil || len(rc.bodies) != before+1 {
+		t.Errorf("404: err %v, %d requests; want an error without a retry", err, len(rc.bodies)-before)
+	}
+}
+
+func TestNotifyIfFailed(t *testing.T) {
+	rc := &receiver{}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	healthy := &diagnostics.Result{DNS: diagnostics.DNSResult{SystemOK: true}, HTTPS: diagnostics.HTTPSResult{OK: true}}
+	tests := []struct {
+		name   string
+		config *store.Config
+		result *diagnostics.Result
+		sent   bool
+	}{
+		{"disabled", &store.Config{WebhookURL: srv.URL}, failedResult(), false},
+		{"healthy", &store.Config{WebhookURL: srv.URL, WebhookOnFailure: true}, healthy, false},
+		{"failed", &store.Config{WebhookURL: srv.URL, WebhookOnFailure: true}, failedResult(), true},
+	}
+	for _, tt := range tests {
+		sent, err := NotifyIfFailed(tt.config, tt.result)
+		if err != nil || sent != tt.sent {
+			t.Errorf("%s: NotifyIfFailed() = %v, %v; want %v", tt.name, sent, err, tt.sent)
+		}
+	}
+	if len(rc.bodies) != 1 {
+		t.Errorf("got %d requests, want 1", len(rc.bodies))
+	}
+}